@@ -2815,10 +2815,10 @@ def go_deps():
         name = "com_github_eclipse_paho_mqtt_golang",
         build_file_proto_mode = "disable_global",
         importpath = "github.com/eclipse/paho.mqtt.golang",
-        sha256 = "d36337c4b5a2752b91bcd437bd74e0907bf6c9e6c611dab88407bcca8462e918",
-        strip_prefix = "github.com/eclipse/paho.mqtt.golang@v1.2.0",
+        sha256 = "042dfa5ccaae5ca66f3068038ab866dbbad66ed4386ef4a44864246ab81f3d99",
+        strip_prefix = "github.com/eclipse/paho.mqtt.golang@v1.4.3",
         urls = [
-            "https://storage.googleapis.com/cockroach-godeps/gomod/github.com/eclipse/paho.mqtt.golang/com_github_eclipse_paho_mqtt_golang-v1.2.0.zip",
+            "https://storage.googleapis.com/cockroach-godeps/gomod/github.com/eclipse/paho.mqtt.golang/com_github_eclipse_paho_mqtt_golang-v1.4.3.zip",
         ],
     )
     go_repository(
@@ -4410,10 +4410,10 @@ def go_deps():
         name = "com_github_gorilla_websocket",
         build_file_proto_mode = "disable_global",
         importpath = "github.com/gorilla/websocket",
-        sha256 = "d0d1728deaa06dac190bf4964c9c6395923403eae337cb3305d6dda18ef07337",
-        strip_prefix = "github.com/gorilla/websocket@v1.4.2",
+        sha256 = "690ea4d1ffe00ab5fcb6d63e2ec3783fc5a58e9d0f1789ea5dc9b6663deee6d5",
+        strip_prefix = "github.com/gorilla/websocket@v1.5.0",
         urls = [
-            "https://storage.googleapis.com/cockroach-godeps/gomod/github.com/gorilla/websocket/com_github_gorilla_websocket-v1.4.2.zip",
+            "https://storage.googleapis.com/cockroach-godeps/gomod/github.com/gorilla/websocket/com_github_gorilla_websocket-v1.5.0.zip",
         ],
     )
     go_repository(
@@ -6979,10 +6979,10 @@ def go_deps():
         name = "com_github_nats_io_nats_go",
         build_file_proto_mode = "disable_global",
         importpath = "github.com/nats-io/nats.go",
-        sha256 = "34a735d158d70685faad1fc3153f08da0ddc21c0ae42f6a0cb09430d638364b2",
-        strip_prefix = "github.com/nats-io/nats.go@v1.9.1",
+        sha256 = "a5c7f860eff17625fc52ce4adf81b6ec1a4fdc0cd6a62884fb507cfb94a28efc",
+        strip_prefix = "github.com/nats-io/nats.go@v1.36.0",
         urls = [
-            "https://storage.googleapis.com/cockroach-godeps/gomod/github.com/nats-io/nats.go/com_github_nats_io_nats_go-v1.9.1.zip",
+            "https://storage.googleapis.com/cockroach-godeps/gomod/github.com/nats-io/nats.go/com_github_nats_io_nats_go-v1.36.0.zip",
         ],
     )
     go_repository(
@@ -6999,10 +6999,10 @@ def go_deps():
         name = "com_github_nats_io_nkeys",
         build_file_proto_mode = "disable_global",
         importpath = "github.com/nats-io/nkeys",
-        sha256 = "291930a7abcd84edcaffc2cadc75aeb830ebf561144313ead6b8e1fcc03b124f",
-        strip_prefix = "github.com/nats-io/nkeys@v0.1.3",
+        sha256 = "b5ea0fc3e87853935f2903cd8222f6ad92944625b795ba3bf8c99c2cfc499b5b",
+        strip_prefix = "github.com/nats-io/nkeys@v0.4.7",
         urls = [
-            "https://storage.googleapis.com/cockroach-godeps/gomod/github.com/nats-io/nkeys/com_github_nats_io_nkeys-v0.1.3.zip",
+            "https://storage.googleapis.com/cockroach-godeps/gomod/github.com/nats-io/nkeys/com_github_nats_io_nkeys-v0.4.7.zip",
         ],
     )
     go_repository(
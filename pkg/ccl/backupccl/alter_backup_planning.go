@@ -11,9 +11,12 @@ import (
 	"path"
 	"strings"
 
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupbase"
 	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupdest"
 	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupencryption"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuputils"
 	"github.com/cockroachdb/cockroach/pkg/featureflag"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/sql"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
@@ -38,6 +41,21 @@ func alterBackupTypeCheck(
 	); err != nil {
 		return false, nil, err
 	}
+	for _, cmd := range alterBackupStmt.Cmds {
+		if compact, ok := cmd.(*tree.AlterBackupCompact); ok {
+			if err := exprutil.TypeCheck(
+				ctx, "ALTER BACKUP", p.SemaCtx(),
+				exprutil.Strings{compact.Options.EncryptionPassphrase},
+				exprutil.StringArrays{
+					tree.Exprs(compact.Options.IncrementalStorage),
+					tree.Exprs(compact.Options.EncryptionKMSURI),
+				},
+			); err != nil {
+				return false, nil, err
+			}
+			return true, jobs.DetachedJobExecutionResultHeader, nil
+		}
+	}
 	return true, nil, nil
 }
 
@@ -77,6 +95,16 @@ func alterBackupPlanHook(
 
 	for _, cmd := range alterBackupStmt.Cmds {
 		switch v := cmd.(type) {
+		case *tree.AlterBackupCompact:
+			if len(alterBackupStmt.Cmds) > 1 {
+				return nil, nil, nil, false, errors.New(
+					"ALTER BACKUP ... COMPACT cannot be combined with other ALTER BACKUP commands")
+			}
+			if alterBackupStmt.Subdir == nil {
+				return nil, nil, nil, false, errors.New(
+					"ALTER BACKUP ... COMPACT requires a backup in a collection: use ALTER BACKUP <subdir> IN <collection> COMPACT")
+			}
+			return alterBackupCompactPlanHook(ctx, p, backup, subdir, v.Options)
 		case *tree.AlterBackupKMS:
 			newKms, err = exprEval.StringArray(ctx, tree.Exprs(v.KMSInfo.NewKMSURI))
 			if err != nil {
@@ -210,6 +238,106 @@ func doAlterBackupPlan(
 	return backupencryption.WriteNewEncryptionInfoToBackup(ctx, encryptionInfo, baseStore, len(opts))
 }
 
+// alterBackupCompactPlanHook plans an ALTER BACKUP ... COMPACT statement, which
+// starts a job that compacts the backup in the subdir of the collection and its
+// incremental backups into a new full backup. The job always runs detached.
+func alterBackupCompactPlanHook(
+	ctx context.Context,
+	p sql.PlanHookState,
+	collection string,
+	subdir string,
+	opts tree.BackupOptions,
+) (sql.PlanHookRowFn, colinfo.ResultColumns, []sql.PlanNode, bool, error) {
+	for _, unsupported := range []struct {
+		name string
+		set  bool
+	}{
+		{name: "revision_history", set: opts.CaptureRevisionHistory != nil},
+		{name: "include_all_virtual_clusters", set: opts.IncludeAllSecondaryTenants != nil},
+		{name: "execution locality", set: opts.ExecutionLocality != nil},
+		{name: "updates_cluster_monitoring_metrics", set: opts.UpdatesClusterMonitoringMetrics != nil},
+		{name: "continuous", set: opts.Continuous != nil},
+	} {
+		if unsupported.set {
+			return nil, nil, nil, false, errors.Newf(
+				"%s option is not supported by ALTER BACKUP ... COMPACT", unsupported.name)
+		}
+	}
+
+	exprEval := p.ExprEvaluator("ALTER BACKUP")
+	encryptionParams := jobspb.BackupEncryptionOptions{Mode: jobspb.EncryptionMode_None}
+	if opts.EncryptionPassphrase != nil {
+		pw, err := exprEval.String(ctx, opts.EncryptionPassphrase)
+		if err != nil {
+			return nil, nil, nil, false, err
+		}
+		encryptionParams.Mode = jobspb.EncryptionMode_Passphrase
+		encryptionParams.RawPassphrase = pw
+	}
+	if opts.EncryptionKMSURI != nil {
+		if encryptionParams.Mode != jobspb.EncryptionMode_None {
+			return nil, nil, nil, false,
+				errors.New("cannot have both encryption_passphrase and kms option set")
+		}
+		kms, err := exprEval.StringArray(ctx, tree.Exprs(opts.EncryptionKMSURI))
+		if err != nil {
+			return nil, nil, nil, false, err
+		}
+		encryptionParams.Mode = jobspb.EncryptionMode_KMS
+		encryptionParams.RawKmsUris = kms
+	}
+	var incrementalStorage []string
+	if opts.IncrementalStorage != nil {
+		var err error
+		incrementalStorage, err = exprEval.StringArray(ctx, tree.Exprs(opts.IncrementalStorage))
+		if err != nil {
+			return nil, nil, nil, false, err
+		}
+	}
+
+	fn := func(ctx context.Context, _ []sql.PlanNode, resultsCh chan<- tree.Datums) error {
+		execCfg := p.ExecCfg()
+		mkStore := execCfg.DistSQLSrv.ExternalStorageFromURI
+		if strings.EqualFold(subdir, backupbase.LatestFileName) {
+			latest, err := backupdest.ReadLatestFile(ctx, collection, mkStore, p.User())
+			if err != nil {
+				return err
+			}
+			subdir = latest
+		}
+		subdir = "/" + strings.TrimPrefix(subdir, "/")
+
+		baseURIs, err := backuputils.AppendPaths([]string{collection}, subdir)
+		if err != nil {
+			return err
+		}
+		kmsEnv := backupencryption.MakeBackupKMSEnv(
+			execCfg.Settings, &execCfg.ExternalIODirConfig, execCfg.InternalDB, p.User(),
+		)
+		encryption, err := backupencryption.GetEncryptionFromBase(
+			ctx, p.User(), mkStore, baseURIs[0], encryptionParams, &kmsEnv,
+		)
+		if err != nil {
+			return err
+		}
+
+		jobID, err := createCompactionJob(ctx, execCfg, p.InternalSQLTxn(), p.User(), jobspb.BackupDetails{
+			Destination: jobspb.BackupDetails_Destination{
+				To:                 []string{collection},
+				Subdir:             subdir,
+				IncrementalStorage: incrementalStorage,
+			},
+			EncryptionOptions: encryption,
+		})
+		if err != nil {
+			return err
+		}
+		resultsCh <- tree.Datums{tree.NewDInt(tree.DInt(jobID))}
+		return nil
+	}
+	return fn, jobs.DetachedJobExecutionResultHeader, nil, false, nil
+}
+
 func init() {
 	sql.AddPlanHook(
 		"alter backup",
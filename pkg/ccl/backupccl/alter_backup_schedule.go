@@ -324,6 +324,28 @@ func processScheduleOptions(
 				continue
 			}
 			s.incArgs.UpdatesLastBackupMetric = updatesLastBackupMetric
+		case optCompactionThreshold:
+			threshold, err := parseCompactionThreshold(v)
+			if err != nil {
+				return err
+			}
+			if s.incArgs == nil {
+				return errors.Newf("%s requires a schedule that runs incremental backups",
+					optCompactionThreshold)
+			}
+			s.incArgs.CompactionThreshold = threshold
+		case optRetainFullBackups:
+			n, err := parseRetainFullBackups(v)
+			if err != nil {
+				return err
+			}
+			s.fullArgs.RetainFullBackups = n
+		case optRetentionPeriod:
+			period, err := parseRetentionPeriod(v)
+			if err != nil {
+				return err
+			}
+			s.fullArgs.RetentionPeriod = period
 		default:
 			return errors.Newf("unexpected schedule option: %s = %s", k, v)
 		}
@@ -485,6 +507,7 @@ func processFullBackupRecurrence(
 			s.fullArgs.UpdatesLastBackupMetric,
 			s.incStmt,
 			s.fullArgs.ChainProtectedTimestampRecords,
+			backupScheduleMaintenance{},
 		)
 
 		if err != nil {
@@ -726,6 +749,9 @@ var alterBackupScheduleOptions = exprutil.KVOptionValidationMap{
 	optOnExecFailure:           exprutil.KVStringOptAny,
 	optOnPreviousRunning:       exprutil.KVStringOptAny,
 	optUpdatesLastBackupMetric: exprutil.KVStringOptAny,
+	optCompactionThreshold:     exprutil.KVStringOptRequireValue,
+	optRetainFullBackups:       exprutil.KVStringOptRequireValue,
+	optRetentionPeriod:         exprutil.KVStringOptRequireValue,
 }
 
 func alterBackupScheduleTypeCheck(
@@ -0,0 +1,831 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package backupccl
+
+import (
+	"bytes"
+	"context"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/build"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupbase"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupdest"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupencryption"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupinfo"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuppb"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuputils"
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/scheduledjobs"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/isql"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/ioctx"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
+	"github.com/gogo/protobuf/types"
+)
+
+// compactionWorkers is the number of restore span entries a compaction job
+// reads and rewrites concurrently.
+const compactionWorkers = 4
+
+// compactionChunkSize is the amount of data read from a restore span entry
+// after which the compaction job hands the data read so far to its sink. Chunks
+// are only cut at row boundaries.
+const compactionChunkSize = 16 << 20
+
+// resumeCompaction runs a backup job that compacts an existing backup chain,
+// i.e. a full backup and its incremental backups, into a new full backup as of
+// the end time of the chain. The new full backup is produced entirely from the
+// files of the chain in external storage; no KV data is read.
+//
+// The compaction does not checkpoint its progress: if the job is resumed it
+// starts over, writing new data files into the same destination directory.
+func (b *backupResumer) resumeCompaction(
+	ctx context.Context, p sql.JobExecContext, details jobspb.BackupDetails,
+) error {
+	execCfg := p.ExecCfg()
+	user := p.User()
+	kmsEnv := backupencryption.MakeBackupKMSEnv(
+		execCfg.Settings, &execCfg.ExternalIODirConfig, execCfg.InternalDB, user,
+	)
+
+	mem := execCfg.RootMemoryMonitor.MakeBoundAccount()
+	defer mem.Close(ctx)
+
+	baseURI, manifests, err := resolveCompactionChain(ctx, execCfg, user, &mem, details, &kmsEnv)
+	if err != nil {
+		return err
+	}
+	last := manifests[len(manifests)-1]
+
+	// Resolve the destination of the compacted backup, and lay claim to it, once.
+	if details.URI == "" {
+		details.EndTime = last.EndTime
+		subdir := details.EndTime.GoTime().Format(backupbase.DateBasedIntoFolderName)
+		defaultURI, _, err := backupdest.GetURIsByLocalityKV(details.Destination.To, subdir)
+		if err != nil {
+			return err
+		}
+		foundLockFile, err := backupinfo.CheckForBackupLock(ctx, execCfg, defaultURI, b.job.ID(), user)
+		if err != nil {
+			return err
+		}
+		if !foundLockFile {
+			if err := backupinfo.CheckForPreviousBackup(ctx, execCfg, defaultURI, b.job.ID(), user); err != nil {
+				return err
+			}
+			if err := backupinfo.WriteBackupLock(ctx, execCfg, defaultURI, b.job.ID(), user); err != nil {
+				return err
+			}
+		}
+		details.URI = defaultURI
+		details.CollectionURI = details.Destination.To[0]
+		if err := b.job.NoTxn().Update(ctx, func(txn isql.Txn, md jobs.JobMetadata, ju *jobs.JobUpdater) error {
+			if err := md.CheckRunningOrReverting(); err != nil {
+				return err
+			}
+			md.Payload.Details = jobspb.WrapPayloadDetails(details)
+			ju.UpdatePayload(md.Payload)
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+
+	defaultStore, err := execCfg.DistSQLSrv.ExternalStorageFromURI(ctx, details.URI, user)
+	if err != nil {
+		return errors.Wrapf(err, "make storage")
+	}
+	defer defaultStore.Close()
+
+	var fileEncryption *kvpb.FileEncryptionOptions
+	if details.EncryptionOptions != nil {
+		if err := copyEncryptionInfo(ctx, execCfg, user, baseURI, defaultStore); err != nil {
+			return err
+		}
+		key, err := backupencryption.GetEncryptionKey(ctx, details.EncryptionOptions, &kmsEnv)
+		if err != nil {
+			return err
+		}
+		fileEncryption = &kvpb.FileEncryptionOptions{Key: key}
+	}
+
+	layerToIterFactory, err := backupinfo.GetBackupManifestIterFactories(
+		ctx, execCfg.DistSQLSrv.ExternalStorage, manifests, details.EncryptionOptions, &kmsEnv,
+	)
+	if err != nil {
+		return err
+	}
+
+	// The compacted backup backs up the same descriptors as the last layer of
+	// the chain.
+	descs, pkIDs, err := readLayerDescriptors(ctx, layerToIterFactory[len(manifests)-1])
+	if err != nil {
+		return err
+	}
+
+	files, err := compactFiles(
+		ctx, execCfg, user, manifests, layerToIterFactory, fileEncryption, pkIDs, defaultStore,
+	)
+	if err != nil {
+		return err
+	}
+
+	manifest := last
+	manifest.ID = uuid.MakeV4()
+	manifest.StartTime = hlc.Timestamp{}
+	manifest.MVCCFilter = backuppb.MVCCFilter_Latest
+	manifest.RevisionStartTime = hlc.Timestamp{}
+	manifest.Descriptors = descs
+	manifest.DescriptorChanges = nil
+	manifest.IntroducedSpans = nil
+	manifest.Files = files
+	manifest.EntryCounts = roachpb.RowCount{}
+	for _, f := range files {
+		manifest.EntryCounts.Add(f.EntryCounts)
+	}
+	manifest.HasExternalManifestSSTs = false
+	manifest.LocalityKVs = nil
+	manifest.PartitionDescriptorFilenames = nil
+	manifest.ElidedPrefix = manifests[0].ElidedPrefix
+	manifest.BuildInfo = build.GetInfo()
+	manifest.ClusterVersion = execCfg.Settings.Version.ActiveVersion(ctx).Version
+	manifest.ClusterID = execCfg.NodeInfo.LogicalClusterID()
+	if err := checkCoverage(ctx, manifest.Spans, []backuppb.BackupManifest{manifest}); err != nil {
+		return errors.Wrap(err, "compacted backup would not cover expected time")
+	}
+
+	// Carry over the table statistics of the last layer of the chain.
+	var statsTable backuppb.StatsTable
+	if err := func() error {
+		lastStore, err := execCfg.DistSQLSrv.ExternalStorage(ctx, last.Dir)
+		if err != nil {
+			return err
+		}
+		defer lastStore.Close()
+		statsTable.Statistics, err = backupinfo.GetStatisticsFromBackup(
+			ctx, lastStore, details.EncryptionOptions, &kmsEnv, last,
+		)
+		return err
+	}(); err != nil {
+		return errors.Wrap(err, "reading table statistics")
+	}
+	manifest.StatisticsFilenames = make(map[descpb.ID]string, len(last.StatisticsFilenames))
+	for id := range last.StatisticsFilenames {
+		manifest.StatisticsFilenames[id] = backupinfo.BackupStatisticsFileName
+	}
+
+	if err := writeCompactedBackupMetadata(
+		ctx, execCfg, defaultStore, details.EncryptionOptions, &kmsEnv, &manifest, &statsTable,
+	); err != nil {
+		return err
+	}
+
+	if err := maybeAdvanceLatestToCompactedBackup(ctx, execCfg, user, details, len(manifests)); err != nil {
+		return err
+	}
+
+	b.backupStats = manifest.EntryCounts
+	return nil
+}
+
+// resolveCompactionChain resolves the layers of the backup chain that a
+// compaction job compacts. It returns the URI of the full backup and the
+// manifests of all layers of the chain up to details.EndTime, or up to the
+// last layer if details.EndTime is empty.
+func resolveCompactionChain(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	user username.SQLUsername,
+	mem *mon.BoundAccount,
+	details jobspb.BackupDetails,
+	kmsEnv cloud.KMSEnv,
+) (string, []backuppb.BackupManifest, error) {
+	baseURI, manifests, localityInfo, err := resolveBackupChain(ctx, execCfg, user, mem, details, kmsEnv)
+	if err != nil {
+		return "", nil, err
+	}
+
+	if len(manifests) < 2 {
+		return "", nil, errors.Newf("backup %s has no incremental backups to compact", details.Destination.Subdir)
+	}
+	for i := range manifests {
+		if manifests[i].MVCCFilter == backuppb.MVCCFilter_All {
+			return "", nil, errors.Newf("cannot compact backups with revision history")
+		}
+		if len(localityInfo[i].URIsByOriginalLocalityKV) > 0 {
+			return "", nil, errors.Newf("cannot compact locality-aware backups")
+		}
+	}
+	return baseURI, manifests, nil
+}
+
+// resolveBackupChain resolves the layers of the backup chain in the Subdir of
+// details.Destination. It returns the URI of the full backup, and the manifests
+// and locality info of all layers of the chain up to details.EndTime, or up to
+// the last layer if details.EndTime is empty.
+func resolveBackupChain(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	user username.SQLUsername,
+	mem *mon.BoundAccount,
+	details jobspb.BackupDetails,
+	kmsEnv cloud.KMSEnv,
+) (string, []backuppb.BackupManifest, []jobspb.RestoreDetails_BackupLocalityInfo, error) {
+	mkStore := execCfg.DistSQLSrv.ExternalStorageFromURI
+	dest := details.Destination
+
+	baseDirs, err := backuputils.AppendPaths(dest.To, dest.Subdir)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	incDirs, err := backupdest.ResolveIncrementalsBackupLocation(
+		ctx, user, execCfg, dest.IncrementalStorage, dest.To, dest.Subdir,
+	)
+	if err != nil {
+		return "", nil, nil, err
+	}
+
+	baseStores, cleanupFn, err := backupdest.MakeBackupDestinationStores(ctx, user, mkStore, baseDirs)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	defer func() {
+		if err := cleanupFn(); err != nil {
+			log.Warningf(ctx, "failed to close base store: %+v", err)
+		}
+	}()
+	incStores, cleanupFn, err := backupdest.MakeBackupDestinationStores(ctx, user, mkStore, incDirs)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	defer func() {
+		if err := cleanupFn(); err != nil {
+			log.Warningf(ctx, "failed to close incremental store: %+v", err)
+		}
+	}()
+
+	defaultURIs, manifests, localityInfo, _, err := backupdest.ResolveBackupManifests(
+		ctx, mem, baseStores, incStores, mkStore, baseDirs, incDirs, details.EndTime,
+		details.EncryptionOptions, kmsEnv, user, false, /* includeSkipped */
+	)
+	if err != nil {
+		return "", nil, nil, err
+	}
+	return defaultURIs[0], manifests, localityInfo, nil
+}
+
+// compactFiles reads the data of the chain described by manifests, as of the
+// end time of its last layer, and writes it to dest as the data files of a full
+// backup. It returns the descriptors of the written files.
+func compactFiles(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	user username.SQLUsername,
+	manifests []backuppb.BackupManifest,
+	layerToIterFactory backupinfo.LayerToBackupManifestFileIterFactory,
+	encryption *kvpb.FileEncryptionOptions,
+	pkIDs map[uint64]bool,
+	dest cloud.ExternalStorage,
+) ([]backuppb.BackupManifest_File, error) {
+	sv := &execCfg.Settings.SV
+	last := manifests[len(manifests)-1]
+	endTime := last.EndTime
+
+	introducedSpanFrontier, err := createIntroducedSpanFrontier(manifests, endTime)
+	if err != nil {
+		return nil, err
+	}
+	defer introducedSpanFrontier.Release()
+
+	filter, err := makeSpanCoveringFilter(
+		last.Spans,
+		nil, /* checkpointedSpans */
+		introducedSpanFrontier,
+		targetRestoreSpanSize.Get(sv),
+		maxFileCount.Get(sv),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer filter.close()
+
+	backupLocalityMap, err := makeBackupLocalityMap(
+		make([]jobspb.RestoreDetails_BackupLocalityInfo, len(manifests)), user,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// Revision history backups are rejected when the chain is resolved, so file
+	// spans always have exclusive end keys.
+	var fsc fileSpanComparator = &exclusiveEndKeyComparator{}
+
+	spanCh := make(chan execinfrapb.RestoreSpanEntry, compactionWorkers)
+	progCh := make(chan execinfrapb.RemoteProducerMetadata_BulkProcessorProgress)
+
+	var files []backuppb.BackupManifest_File
+	grp := ctxgroup.WithContext(ctx)
+	grp.GoCtx(func(ctx context.Context) error {
+		defer close(spanCh)
+		return errors.Wrap(generateAndSendImportSpans(
+			ctx,
+			last.Spans,
+			manifests,
+			layerToIterFactory,
+			backupLocalityMap,
+			filter,
+			fsc,
+			spanCh,
+		), "generate and send import spans")
+	})
+	grp.GoCtx(func(ctx context.Context) error {
+		defer close(progCh)
+		return ctxgroup.GroupWorkers(ctx, compactionWorkers, func(ctx context.Context, _ int) error {
+			sink := makeFileSSTSink(sstSinkConf{
+				progCh:   progCh,
+				enc:      encryption,
+				id:       execCfg.NodeInfo.NodeID.SQLInstanceID(),
+				settings: sv,
+			}, dest, nil /* pacer */)
+			defer logClose(ctx, sink, "SST sink")
+			sink.elideMode = manifests[0].ElidedPrefix
+
+			for entry := range spanCh {
+				if err := compactSpanEntry(ctx, execCfg, entry, endTime, encryption, pkIDs, sink); err != nil {
+					return err
+				}
+			}
+			return sink.flush(ctx)
+		})
+	})
+	grp.GoCtx(func(ctx context.Context) error {
+		for prog := range progCh {
+			var progDetails backuppb.BackupManifest_Progress
+			if err := types.UnmarshalAny(&prog.ProgressDetails, &progDetails); err != nil {
+				return errors.Wrap(err, "unable to unmarshal compaction progress details")
+			}
+			files = append(files, progDetails.Files...)
+		}
+		return nil
+	})
+	if err := grp.Wait(); err != nil {
+		return nil, err
+	}
+
+	sort.Sort(backupinfo.BackupFileDescriptors(files))
+	return files, nil
+}
+
+// compactSpanEntry merges the files of a restore span entry as of endTime and
+// writes the result to sink.
+func compactSpanEntry(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	entry execinfrapb.RestoreSpanEntry,
+	endTime hlc.Timestamp,
+	encryption *kvpb.FileEncryptionOptions,
+	pkIDs map[uint64]bool,
+	sink *fileSSTSink,
+) error {
+	storeFiles := make([]storageccl.StoreFile, 0, len(entry.Files))
+	defer func() {
+		for _, f := range storeFiles {
+			logClose(ctx, f.Store, "export storage")
+		}
+	}()
+	for _, file := range entry.Files {
+		dir, err := execCfg.DistSQLSrv.ExternalStorage(ctx, file.Dir)
+		if err != nil {
+			return err
+		}
+		storeFiles = append(storeFiles, storageccl.StoreFile{Store: dir, FilePath: file.Path})
+	}
+
+	iterOpts := storage.IterOptions{
+		RangeKeyMaskingBelow: endTime,
+		KeyTypes:             storage.IterKeyTypePointsAndRanges,
+		LowerBound:           keys.LocalMax,
+		UpperBound:           keys.MaxKey,
+	}
+	sstIter, err := storageccl.ExternalSSTReader(ctx, storeFiles, encryption, iterOpts)
+	if err != nil {
+		return err
+	}
+	iter := storage.NewReadAsOfIterator(sstIter, endTime)
+	defer iter.Close()
+
+	prefix, err := elidedPrefix(entry.Span.Key, entry.ElidedPrefix)
+	if err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	var sst storage.SSTWriter
+	var counter storage.RowCounter
+	// chunkStart is the row key of the first key of the chunk currently being
+	// written, or nil if no chunk is being written.
+	var chunkStart, chunkPrefix roachpb.Key
+	var lastRow, keyScratch []byte
+	defer func() {
+		if chunkStart != nil {
+			sst.Close()
+		}
+	}()
+
+	writeChunk := func(end roachpb.Key) error {
+		if chunkStart == nil {
+			return nil
+		}
+		defer sst.Close()
+		if err := sst.Finish(); err != nil {
+			return err
+		}
+		counter.DataSize = sst.DataSize
+		_, err := sink.write(ctx, exportedSpan{
+			metadata: backuppb.BackupManifest_File{
+				Span:        roachpb.Span{Key: chunkStart, EndKey: end},
+				EntryCounts: countRows(counter.BulkOpSummary, pkIDs),
+				EndTime:     endTime,
+			},
+			dataSST: buf.Bytes(),
+		})
+		chunkStart = nil
+		return err
+	}
+
+	startKeyMVCC := storage.MVCCKey{Key: bytes.TrimPrefix(entry.Span.Key, prefix)}
+	endKeyMVCC := storage.MVCCKey{Key: entry.Span.EndKey}
+	for iter.SeekGE(startKeyMVCC); ; iter.NextKey() {
+		if ok, err := iter.Valid(); err != nil {
+			return err
+		} else if !ok {
+			break
+		}
+
+		key := iter.UnsafeKey()
+		keyScratch = append(append(keyScratch[:0], prefix...), key.Key...)
+		key.Key = keyScratch
+		if !key.Less(endKeyMVCC) {
+			break
+		}
+
+		row, err := keys.EnsureSafeSplitKey(key.Key)
+		if err != nil {
+			// Not a SQL key; treat it as a row of its own.
+			row = key.Key
+		}
+		if !bytes.Equal(row, lastRow) {
+			lastRow = append(lastRow[:0], row...)
+			// The sink elides a single prefix from every key of a file, so a new
+			// chunk is started whenever that prefix changes.
+			keyPrefix, err := elidedPrefix(row, sink.elideMode)
+			if err != nil {
+				return err
+			}
+			if chunkStart != nil && (sst.DataSize >= compactionChunkSize || !bytes.Equal(keyPrefix, chunkPrefix)) {
+				if err := writeChunk(row.Clone()); err != nil {
+					return err
+				}
+			}
+			if chunkStart == nil {
+				chunkStart = row.Clone()
+				chunkPrefix = append(chunkPrefix[:0], keyPrefix...)
+				buf.Reset()
+				sst = storage.MakeBackupSSTWriter(ctx, execCfg.Settings, &buf)
+				counter = storage.RowCounter{}
+			}
+		}
+
+		v, err := iter.UnsafeValue()
+		if err != nil {
+			return err
+		}
+		if err := sst.PutRawMVCC(key, v); err != nil {
+			return err
+		}
+		if err := counter.Count(key.Key); err != nil {
+			return err
+		}
+	}
+	return writeChunk(entry.Span.EndKey)
+}
+
+// readLayerDescriptors returns the descriptors backed up by the backup layer
+// whose iterators are created by iterFactory, along with the bulk op summary IDs
+// of the primary indexes of its tables, as used by countRows.
+func readLayerDescriptors(
+	ctx context.Context, iterFactory *backupinfo.IterFactory,
+) ([]descpb.Descriptor, map[uint64]bool, error) {
+	var descs []descpb.Descriptor
+	pkIDs := make(map[uint64]bool)
+	descIt := iterFactory.NewDescIter(ctx)
+	defer descIt.Close()
+	for ; ; descIt.Next() {
+		if ok, err := descIt.Valid(); err != nil {
+			return nil, nil, errors.Wrap(err, "reading descriptors")
+		} else if !ok {
+			return descs, pkIDs, nil
+		}
+		desc := *descIt.Value()
+		descs = append(descs, desc)
+		if t, _, _, _, _ := descpb.GetDescriptors(&desc); t != nil {
+			pkIDs[kvpb.BulkOpSummaryID(uint64(t.ID), uint64(t.PrimaryIndex.ID))] = true
+		}
+	}
+}
+
+// copyEncryptionInfo copies the ENCRYPTION-INFO files of the full backup at
+// baseURI to dest, so that the compacted backup can be decrypted with the same
+// passphrase or KMS keys as the chain it was produced from.
+func copyEncryptionInfo(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	user username.SQLUsername,
+	baseURI string,
+	dest cloud.ExternalStorage,
+) error {
+	baseStore, err := execCfg.DistSQLSrv.ExternalStorageFromURI(ctx, baseURI, user)
+	if err != nil {
+		return err
+	}
+	defer baseStore.Close()
+
+	names, err := backupencryption.GetEncryptionInfoFiles(ctx, baseStore)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := func() error {
+			r, _, err := baseStore.ReadFile(ctx, name, cloud.ReadOptions{NoFileSize: true})
+			if err != nil {
+				return err
+			}
+			defer r.Close(ctx)
+			buf, err := ioctx.ReadAll(ctx, r)
+			if err != nil {
+				return err
+			}
+			return cloud.WriteFile(ctx, dest, name, bytes.NewReader(buf))
+		}(); err != nil {
+			return errors.Wrapf(err, "copying %s", name)
+		}
+	}
+	return nil
+}
+
+// writeCompactedBackupMetadata writes the manifest and the table statistics of
+// a compacted backup, in the same formats as a backup job does.
+func writeCompactedBackupMetadata(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	store cloud.ExternalStorage,
+	encryption *jobspb.BackupEncryptionOptions,
+	kmsEnv cloud.KMSEnv,
+	manifest *backuppb.BackupManifest,
+	statsTable *backuppb.StatsTable,
+) error {
+	sv := &execCfg.Settings.SV
+	if err := backupinfo.WriteBackupManifest(ctx, store, backupbase.BackupManifestName,
+		encryption, kmsEnv, manifest); err != nil {
+		return err
+	}
+	if backupinfo.WriteMetadataWithExternalSSTsEnabled.Get(sv) {
+		if err := backupinfo.WriteMetadataWithExternalSSTs(ctx, store, encryption,
+			kmsEnv, manifest); err != nil {
+			return err
+		}
+	}
+	if err := backupinfo.WriteTableStatistics(ctx, store, encryption, kmsEnv, statsTable); err != nil {
+		return err
+	}
+	if backupinfo.WriteMetadataSST.Get(sv) {
+		if err := backupinfo.WriteBackupMetadataSST(ctx, store, encryption, kmsEnv, manifest,
+			statsTable.Statistics); err != nil {
+			err = errors.Wrap(err, "writing forward-compat metadata sst")
+			if !build.IsRelease() {
+				return err
+			}
+			log.Warningf(ctx, "%+v", err)
+		}
+	}
+	return nil
+}
+
+// maybeAdvanceLatestToCompactedBackup points the LATEST file of the collection
+// at the compacted backup, so that subsequent incremental backups extend it
+// rather than the chain it was compacted from. This is only done if LATEST
+// still points at the compacted chain and no incremental backups were appended
+// to the chain while it was compacted: a subsequent incremental backup would
+// otherwise need MVCC history older than the last layer of the chain, which may
+// no longer be protected from garbage collection.
+func maybeAdvanceLatestToCompactedBackup(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	user username.SQLUsername,
+	details jobspb.BackupDetails,
+	numLayers int,
+) error {
+	mkStore := execCfg.DistSQLSrv.ExternalStorageFromURI
+	latest, err := backupdest.ReadLatestFile(ctx, details.CollectionURI, mkStore, user)
+	if err != nil {
+		return err
+	}
+	if strings.TrimPrefix(path.Clean(latest), "/") != strings.TrimPrefix(path.Clean(details.Destination.Subdir), "/") {
+		log.Infof(ctx, "not updating LATEST to compacted backup: LATEST now refers to %s", latest)
+		return nil
+	}
+
+	numIncrementals, err := countIncrementalBackups(ctx, execCfg, user, details.Destination)
+	if err != nil {
+		return err
+	}
+	if numIncrementals != numLayers-1 {
+		log.Infof(ctx, "not updating LATEST to compacted backup: %d incremental backups "+
+			"were appended to %s during compaction", numIncrementals-(numLayers-1), latest)
+		return nil
+	}
+
+	backupURI, err := url.Parse(details.URI)
+	if err != nil {
+		return err
+	}
+	collectionURI, err := url.Parse(details.CollectionURI)
+	if err != nil {
+		return err
+	}
+	suffix := strings.TrimPrefix(path.Clean(backupURI.Path), path.Clean(collectionURI.Path))
+
+	c, err := mkStore(ctx, details.CollectionURI, user)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+	return backupdest.WriteNewLatestFile(ctx, execCfg.Settings, c, suffix)
+}
+
+// countIncrementalBackups returns the number of incremental backups of the
+// full backup in the Subdir of dest.
+func countIncrementalBackups(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	user username.SQLUsername,
+	dest jobspb.BackupDetails_Destination,
+) (int, error) {
+	incDirs, err := backupdest.ResolveIncrementalsBackupLocation(
+		ctx, user, execCfg, dest.IncrementalStorage, dest.To, dest.Subdir,
+	)
+	if err != nil {
+		return 0, err
+	}
+	store, err := execCfg.DistSQLSrv.ExternalStorageFromURI(ctx, incDirs[0], user)
+	if err != nil {
+		return 0, err
+	}
+	defer store.Close()
+	prev, err := backupdest.FindPriorBackups(ctx, store, backupdest.OmitManifest)
+	if err != nil {
+		return 0, err
+	}
+	return len(prev), nil
+}
+
+// compactionJobDescription returns the description of a job compacting the
+// backup chain in the Subdir of dest: the ALTER BACKUP statement that would
+// start it, with secrets redacted.
+func compactionJobDescription(dest jobspb.BackupDetails_Destination) (string, error) {
+	to, err := sanitizeURIList(dest.To)
+	if err != nil {
+		return "", err
+	}
+	incrementalStorage, err := sanitizeURIList(dest.IncrementalStorage)
+	if err != nil {
+		return "", err
+	}
+	node := &tree.AlterBackup{
+		Backup: to[0],
+		Subdir: tree.NewDString(dest.Subdir),
+		Cmds: tree.AlterBackupCmds{&tree.AlterBackupCompact{
+			Options: tree.BackupOptions{IncrementalStorage: incrementalStorage},
+		}},
+	}
+	return tree.AsStringWithFlags(node, tree.FmtShowFullURIs), nil
+}
+
+// createCompactionJob creates a job that compacts the backup chain in the
+// Subdir of details.Destination into a new full backup.
+func createCompactionJob(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	txn isql.Txn,
+	user username.SQLUsername,
+	details jobspb.BackupDetails,
+) (jobspb.JobID, error) {
+	description, err := compactionJobDescription(details.Destination)
+	if err != nil {
+		return 0, err
+	}
+	details.Compact = true
+	jobID := execCfg.JobRegistry.MakeJobID()
+	jr := jobs.Record{
+		Description: description,
+		Details:     details,
+		Progress:    jobspb.BackupProgress{},
+		Username:    user,
+	}
+	if _, err := execCfg.JobRegistry.CreateAdoptableJobWithTxn(ctx, jr, jobID, txn); err != nil {
+		return 0, err
+	}
+	return jobID, nil
+}
+
+// maybeStartScheduledCompaction creates a job to compact the backup chain that
+// the incremental backup described by details was appended to, if that backup
+// was started by a schedule with a compaction threshold and the chain has
+// accumulated a multiple of that many incremental backups. Compaction is
+// retried every threshold incremental backups if an earlier compaction of the
+// chain did not take over LATEST.
+func maybeStartScheduledCompaction(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	user username.SQLUsername,
+	details jobspb.BackupDetails,
+) error {
+	if details.ScheduleID == 0 || details.CollectionURI == "" || len(details.URIsByLocalityKV) > 0 {
+		return nil
+	}
+	env := scheduledjobs.ProdJobSchedulerEnv
+	if knobs := execCfg.JobsKnobs(); knobs != nil && knobs.JobSchedulerEnv != nil {
+		env = knobs.JobSchedulerEnv
+	}
+	var args *backuppb.ScheduledBackupExecutionArgs
+	if err := execCfg.InternalDB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		var err error
+		_, args, err = getScheduledBackupExecutionArgsFromSchedule(
+			ctx, env, jobs.ScheduledJobTxn(txn), details.ScheduleID,
+		)
+		return err
+	}); err != nil {
+		return err
+	}
+	if args.CompactionThreshold <= 0 {
+		return nil
+	}
+
+	// The resolved details of a backup only retain the collection and the
+	// subdirectory of its chain, so recover the collection its incremental
+	// backups are written to from its URI, which is of the form
+	// <incrementals collection>/<chain subdir>/<incremental subdir>.
+	incURI, err := url.Parse(details.URI)
+	if err != nil {
+		return err
+	}
+	incURI.Path = strings.TrimSuffix(path.Dir(path.Dir(path.Clean(incURI.Path))), path.Clean(details.Destination.Subdir))
+	dest := jobspb.BackupDetails_Destination{
+		To:                 []string{details.CollectionURI},
+		Subdir:             details.Destination.Subdir,
+		IncrementalStorage: []string{incURI.String()},
+	}
+
+	numIncrementals, err := countIncrementalBackups(ctx, execCfg, user, dest)
+	if err != nil {
+		return err
+	}
+	if numIncrementals == 0 || int64(numIncrementals)%args.CompactionThreshold != 0 {
+		return nil
+	}
+
+	return execCfg.InternalDB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		jobID, err := createCompactionJob(ctx, execCfg, txn, user, jobspb.BackupDetails{
+			Destination:       dest,
+			EncryptionOptions: details.EncryptionOptions,
+		})
+		if err != nil {
+			return err
+		}
+		log.Infof(ctx, "started job %d to compact %d incremental backups of %s",
+			jobID, numIncrementals, dest.Subdir)
+		return nil
+	})
+}
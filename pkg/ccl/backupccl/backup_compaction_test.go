@@ -0,0 +1,77 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package backupccl
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/testutils/jobutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAlterBackupCompact tests that a backup chain compacted by ALTER BACKUP
+// ... COMPACT restores to the same data as the chain it was compacted from.
+func TestAlterBackupCompact(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const numAccounts = 10
+	_, sqlDB, _, cleanupFn := backupRestoreTestSetup(t, singleNode, numAccounts, InitManualReplication)
+	defer cleanupFn()
+
+	sqlDB.Exec(t, `BACKUP DATABASE data INTO $1`, localFoo)
+	sqlDB.Exec(t, `UPDATE data.bank SET balance = balance + 1 WHERE id < 5`)
+	sqlDB.Exec(t, `BACKUP DATABASE data INTO LATEST IN $1`, localFoo)
+	sqlDB.Exec(t, `DELETE FROM data.bank WHERE id >= 8`)
+	sqlDB.Exec(t, `CREATE TABLE data.extra AS SELECT id, balance FROM data.bank`)
+	sqlDB.Exec(t, `BACKUP DATABASE data INTO LATEST IN $1`, localFoo)
+
+	expectedBank := sqlDB.QueryStr(t, `SELECT * FROM data.bank ORDER BY id`)
+	expectedExtra := sqlDB.QueryStr(t, `SELECT * FROM data.extra ORDER BY id`)
+
+	var jobID jobspb.JobID
+	sqlDB.QueryRow(t, `ALTER BACKUP LATEST IN $1 COMPACT`, localFoo).Scan(&jobID)
+	jobutils.WaitForJobToSucceed(t, sqlDB, jobID)
+
+	// The compacted backup is a new full backup in the collection, and LATEST
+	// refers to it.
+	require.Len(t, getFullBackupPaths(t, sqlDB, localFoo), 2)
+	var numLayers int
+	sqlDB.QueryRow(t,
+		`SELECT count(DISTINCT end_time) FROM [SHOW BACKUP LATEST IN $1]`, localFoo,
+	).Scan(&numLayers)
+	require.Equal(t, 1, numLayers)
+
+	sqlDB.Exec(t, `RESTORE DATABASE data FROM LATEST IN $1 WITH new_db_name = restored`, localFoo)
+	sqlDB.CheckQueryResults(t, `SELECT * FROM restored.bank ORDER BY id`, expectedBank)
+	sqlDB.CheckQueryResults(t, `SELECT * FROM restored.extra ORDER BY id`, expectedExtra)
+
+	// Incremental backups extend the compacted backup.
+	sqlDB.Exec(t, `BACKUP DATABASE data INTO LATEST IN $1`, localFoo)
+	sqlDB.Exec(t, `RESTORE DATABASE data FROM LATEST IN $1 WITH new_db_name = restored2`, localFoo)
+	sqlDB.CheckQueryResults(t, `SELECT * FROM restored2.bank ORDER BY id`, expectedBank)
+
+	t.Run("errors", func(t *testing.T) {
+		sqlDB.ExpectErr(t, "requires a backup in a collection",
+			`ALTER BACKUP $1 COMPACT`, localFoo)
+		sqlDB.ExpectErr(t, "revision_history option is not supported",
+			`ALTER BACKUP LATEST IN $1 COMPACT WITH OPTIONS (revision_history)`, localFoo)
+
+		const localRevs = "nodelocal://1/revs"
+		sqlDB.Exec(t, `BACKUP DATABASE data INTO $1 WITH revision_history`, localRevs)
+		sqlDB.QueryRow(t, `ALTER BACKUP LATEST IN $1 COMPACT`, localRevs).Scan(&jobID)
+		jobutils.WaitForJobToFail(t, sqlDB, jobID)
+		sqlDB.Exec(t, `BACKUP DATABASE data INTO LATEST IN $1 WITH revision_history`, localRevs)
+		sqlDB.QueryRow(t, `ALTER BACKUP LATEST IN $1 COMPACT`, localRevs).Scan(&jobID)
+		jobutils.WaitForJobToFail(t, sqlDB, jobID)
+		var jobErr string
+		sqlDB.QueryRow(t, `SELECT error FROM [SHOW JOB $1]`, jobID).Scan(&jobErr)
+		require.Contains(t, jobErr, "cannot compact backups with revision history")
+	})
+}
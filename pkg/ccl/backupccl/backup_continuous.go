@@ -0,0 +1,651 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package backupccl
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/build"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupbase"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupencryption"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupinfo"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuppb"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuputils"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobsprofiler"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/rangefeed"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catalogkeys"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/isql"
+	"github.com/cockroachdb/cockroach/pkg/sql/physicalplan"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
+	gogotypes "github.com/gogo/protobuf/types"
+)
+
+var continuousBackupFlushInterval = settings.RegisterDurationSetting(
+	settings.ApplicationLevel,
+	"bulkio.backup.continuous.flush_interval",
+	"the interval at which continuous backups write the changes they have received to their log, "+
+		"which bounds how far behind the present the most recent restorable time of the log is",
+	10*time.Second,
+	settings.PositiveDuration,
+)
+
+var continuousBackupLayerMaxFiles = settings.RegisterIntSetting(
+	settings.ApplicationLevel,
+	"bulkio.backup.continuous.layer_max_files",
+	"the number of data files after which a continuous backup starts a new layer of its log, "+
+		"which bounds the size of the manifest it rewrites on every flush",
+	1000,
+	settings.PositiveInt,
+)
+
+// resumeContinuous runs a backup job that continuously extends a backup chain,
+// i.e. a full backup and its incremental backups, with a log of the changes to
+// the data it backs up since the end time of the chain. The changes are tailed
+// by a processor on each of the nodes that hold the data, which periodically
+// flushes them to the log. The log is a sequence of layers, each laid out like
+// an incremental backup with revision history in a subdirectory of the full
+// backup and starting where the previous one ends, so that RESTORE ... AS OF
+// SYSTEM TIME can restore the chain to any time covered by the log.
+//
+// The job protects the data it has yet to flush from garbage collection and
+// runs until it is paused or canceled, or until it observes a change that a log
+// cannot capture: a schema change to, or the creation or deletion of, any of
+// the backed up objects, or a bulk ingestion or range deletion into their
+// spans. Changes up to that point remain restorable, and a new backup and
+// continuous backup have to be started to capture changes after it.
+func (b *backupResumer) resumeContinuous(
+	ctx context.Context, p sql.JobExecContext, details jobspb.BackupDetails,
+) error {
+	execCfg := p.ExecCfg()
+	user := p.User()
+	kmsEnv := backupencryption.MakeBackupKMSEnv(
+		execCfg.Settings, &execCfg.ExternalIODirConfig, execCfg.InternalDB, user,
+	)
+
+	mem := execCfg.RootMemoryMonitor.MakeBoundAccount()
+	defer mem.Close(ctx)
+
+	// Start a new log at the end of the chain, and lay claim to it, once.
+	if details.URI == "" {
+		var err error
+		details, err = b.startContinuousBackupLog(ctx, execCfg, user, &mem, details, &kmsEnv)
+		if err != nil {
+			return err
+		}
+	}
+
+	manifest, memSize, err := backupinfo.ReadBackupManifestFromURI(
+		ctx, &mem, details.URI, user, execCfg.DistSQLSrv.ExternalStorageFromURI,
+		details.EncryptionOptions, &kmsEnv,
+	)
+	if err != nil {
+		return err
+	}
+	defer mem.Shrink(ctx, memSize)
+
+	var fileEncryption *kvpb.FileEncryptionOptions
+	if details.EncryptionOptions != nil {
+		key, err := backupencryption.GetEncryptionKey(ctx, details.EncryptionOptions, &kmsEnv)
+		if err != nil {
+			return err
+		}
+		fileEncryption = &kvpb.FileEncryptionOptions{Key: key}
+	}
+
+	// The log cannot capture schema changes, so the coordinator watches the
+	// descriptors of the backed up objects, and the namespace entries of the
+	// complete databases among them, for changes that end the log.
+	pkIDs := make(map[uint64]bool)
+	var schemaSpans roachpb.Spans
+	for i := range manifest.Descriptors {
+		desc := &manifest.Descriptors[i]
+		if t, _, _, _, _ := descpb.GetDescriptors(desc); t != nil {
+			pkIDs[kvpb.BulkOpSummaryID(uint64(t.ID), uint64(t.PrimaryIndex.ID))] = true
+		}
+		id, _, _, _, err := descpb.GetDescriptorMetadata(desc)
+		if err != nil {
+			return err
+		}
+		key := catalogkeys.MakeDescMetadataKey(execCfg.Codec, id)
+		schemaSpans = append(schemaSpans, roachpb.Span{Key: key, EndKey: key.PrefixEnd()})
+	}
+	for _, id := range manifest.CompleteDbs {
+		prefix := catalogkeys.MakeDatabaseChildrenNameKeyPrefix(execCfg.Codec, id)
+		schemaSpans = append(schemaSpans, roachpb.Span{Key: prefix, EndKey: prefix.PrefixEnd()})
+	}
+
+	schema := &continuousBackupSchemaFrontier{}
+	schema.mu.frontier = manifest.EndTime
+	rf := execCfg.RangeFeedFactory.New(
+		fmt.Sprintf("continuous-backup-schema-%d", b.job.ID()),
+		manifest.EndTime,
+		func(ctx context.Context, v *kvpb.RangeFeedValue) {
+			schema.Lock()
+			defer schema.Unlock()
+			schema.mu.stop(v.Value.Timestamp, "a schema change")
+		},
+		rangefeed.WithOnFrontierAdvance(func(ctx context.Context, ts hlc.Timestamp) {
+			schema.Lock()
+			defer schema.Unlock()
+			schema.mu.advance(ts)
+		}),
+		rangefeed.WithOnInternalError(func(ctx context.Context, err error) {
+			schema.Lock()
+			defer schema.Unlock()
+			schema.mu.fail(err)
+		}),
+	)
+	if err := rf.Start(ctx, schemaSpans); err != nil {
+		return errors.Wrap(err, "starting rangefeed")
+	}
+	defer rf.Close()
+
+	for {
+		if err := b.runContinuousBackupLayer(
+			ctx, p, details, &manifest, fileEncryption, pkIDs, schema, &kmsEnv,
+		); err != nil {
+			return err
+		}
+		details, err = b.rollOverContinuousBackupLog(ctx, execCfg, user, details, &manifest, &kmsEnv)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// continuousBackupSchemaFrontier tracks the timestamp up to which the log of a
+// continuous backup has observed no schema change to the objects it backs up.
+type continuousBackupSchemaFrontier struct {
+	syncutil.Mutex
+	mu continuousBackupFrontier
+}
+
+// runContinuousBackupLayer tails the data backed up by a continuous backup
+// into the current layer of its log, at details.URI and described by manifest,
+// with a processor on each of the nodes that hold the data. The log is flushed
+// up to the minimum of the timestamps up to which the processors, and the
+// schema rangefeed, have flushed all changes. It returns nil once the layer has
+// enough files to be rolled over to a new one, and an error once the log ends.
+func (b *backupResumer) runContinuousBackupLayer(
+	ctx context.Context,
+	p sql.JobExecContext,
+	details jobspb.BackupDetails,
+	manifest *backuppb.BackupManifest,
+	fileEncryption *kvpb.FileEncryptionOptions,
+	pkIDs map[uint64]bool,
+	schema *continuousBackupSchemaFrontier,
+	kmsEnv cloud.KMSEnv,
+) error {
+	execCfg := p.ExecCfg()
+	logStore, err := execCfg.DistSQLSrv.ExternalStorageFromURI(ctx, details.URI, p.User())
+	if err != nil {
+		return errors.Wrapf(err, "make storage")
+	}
+	defer logStore.Close()
+
+	dsp := p.DistSQLPlanner()
+	planCtx, _, err := dsp.SetupAllNodesPlanning(ctx, p.ExtendedEvalContext(), execCfg)
+	if err != nil {
+		return errors.Wrap(err, "failed to determine nodes on which to run")
+	}
+	partitions, err := dsp.PartitionSpans(ctx, planCtx, manifest.Spans)
+	if err != nil {
+		return err
+	}
+	specs := make(map[base.SQLInstanceID]*execinfrapb.ContinuousBackupDataSpec, len(partitions))
+	// resolved is the timestamp up to which each processor has flushed all the
+	// changes to its spans, and stopReasons the changes after it that the log
+	// cannot capture, if any.
+	resolved := make(map[base.SQLInstanceID]hlc.Timestamp, len(partitions))
+	stopReasons := make(map[base.SQLInstanceID]string)
+	for _, partition := range partitions {
+		specs[partition.SQLInstanceID] = &execinfrapb.ContinuousBackupDataSpec{
+			JobID:       int64(b.job.ID()),
+			Spans:       partition.Spans,
+			URI:         details.URI,
+			Encryption:  fileEncryption,
+			StartTime:   manifest.EndTime,
+			PKIDs:       pkIDs,
+			UserProto:   p.User().EncodeProto(),
+			ElidePrefix: manifest.ElidedPrefix,
+		}
+		resolved[partition.SQLInstanceID] = manifest.EndTime
+	}
+
+	// flush advances the layer to the timestamp up to which all of its changes
+	// have been flushed. It returns the reason why the log ends, if it does.
+	flush := func() (string, error) {
+		schema.Lock()
+		endTime, stopReason, err := schema.mu.resolved()
+		schema.Unlock()
+		if err != nil {
+			return "", errors.Wrapf(err, "continuous backup stopped at %s; "+
+				"take an incremental backup and start a new continuous backup to capture later changes",
+				manifest.EndTime)
+		}
+		for id, ts := range resolved {
+			if ts.Less(endTime) {
+				endTime, stopReason = ts, stopReasons[id]
+			} else if ts.Equal(endTime) && stopReason == "" {
+				stopReason = stopReasons[id]
+			}
+		}
+		if manifest.EndTime.Less(endTime) {
+			manifest.EndTime = endTime
+			if err := backupinfo.WriteBackupManifestWithoutChecksum(ctx, logStore,
+				backupbase.BackupManifestName, details.EncryptionOptions, kmsEnv, manifest); err != nil {
+				return "", err
+			}
+			if err := b.advanceContinuousBackup(ctx, execCfg, details, manifest.EndTime); err != nil {
+				return "", err
+			}
+		}
+		return stopReason, nil
+	}
+
+	flowCtx, cancelFlow := context.WithCancel(ctx)
+	defer cancelFlow()
+	progCh := make(chan *execinfrapb.RemoteProducerMetadata_BulkProcessorProgress)
+	grp := ctxgroup.WithContext(flowCtx)
+	grp.GoCtx(func(ctx context.Context) error {
+		return distContinuousBackup(ctx, p, planCtx, dsp, progCh, specs)
+	})
+
+	var stopReason string
+	var rollOver bool
+	maxFiles := int(continuousBackupLayerMaxFiles.Get(&execCfg.Settings.SV))
+	for prog := range progCh {
+		if stopReason != "" || rollOver {
+			// Drain the progress of the processors until the flow winds down.
+			continue
+		}
+		var progDetails backuppb.ContinuousBackupProgress
+		if err := gogotypes.UnmarshalAny(&prog.ProgressDetails, &progDetails); err != nil {
+			cancelFlow()
+			_ = grp.Wait()
+			return errors.Wrap(err, "unable to unmarshal continuous backup progress details")
+		}
+		manifest.Files = append(manifest.Files, progDetails.Files...)
+		for _, f := range progDetails.Files {
+			manifest.EntryCounts.Add(f.EntryCounts)
+		}
+		if ts := resolved[prog.NodeID]; ts.Less(progDetails.Resolved) {
+			resolved[prog.NodeID] = progDetails.Resolved
+		}
+		if progDetails.StopReason != "" {
+			stopReasons[prog.NodeID] = progDetails.StopReason
+		}
+
+		stopReason, err = flush()
+		if err != nil {
+			cancelFlow()
+			_ = grp.Wait()
+			return err
+		}
+		// A layer can only be rolled over once it ends in a different
+		// subdirectory than the one it starts in.
+		rollOver = stopReason == "" && len(manifest.Files) >= maxFiles &&
+			layerDirName(manifest.EndTime) != layerDirName(manifest.StartTime)
+		if stopReason != "" || rollOver {
+			cancelFlow()
+		}
+	}
+	if err := grp.Wait(); err != nil && stopReason == "" && !rollOver {
+		return err
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if rollOver {
+		// The files reported after the last flush are dropped along with the
+		// flow; their changes are tailed again into the next layer.
+		return nil
+	}
+
+	// All the processors have stopped: the log ends once the schema rangefeed
+	// has caught up with them.
+	var timer timeutil.Timer
+	defer timer.Stop()
+	for stopReason == "" {
+		timer.Reset(continuousBackupFlushInterval.Get(&execCfg.Settings.SV))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			timer.Read = true
+		}
+		if stopReason, err = flush(); err != nil {
+			return err
+		}
+	}
+	return errors.Newf("continuous backup stopped at %s because of %s, which it cannot capture; "+
+		"take an incremental backup and start a new continuous backup to capture later changes",
+		manifest.EndTime, stopReason)
+}
+
+// rollOverContinuousBackupLog starts a new layer of the log of a continuous
+// backup at the end of its current layer, described by manifest, which is
+// updated in place, so that the manifest rewritten on every flush does not keep
+// growing. It returns the job's details, updated to point at the new layer.
+func (b *backupResumer) rollOverContinuousBackupLog(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	user username.SQLUsername,
+	details jobspb.BackupDetails,
+	manifest *backuppb.BackupManifest,
+	kmsEnv cloud.KMSEnv,
+) (jobspb.BackupDetails, error) {
+	logURI, err := url.Parse(details.URI)
+	if err != nil {
+		return details, err
+	}
+	logURI.Path = backuputils.JoinURLPath(
+		strings.TrimSuffix(logURI.Path, layerDirName(manifest.StartTime)), layerDirName(manifest.EndTime))
+
+	manifest.ID = uuid.MakeV4()
+	manifest.StartTime = manifest.EndTime
+	manifest.RevisionStartTime = manifest.EndTime
+	manifest.Files = nil
+	manifest.EntryCounts = roachpb.RowCount{}
+
+	details.URI = logURI.String()
+	details.StartTime = manifest.StartTime
+	details.EndTime = manifest.EndTime
+	log.Infof(ctx, "rolling continuous backup over to a new layer at %s", manifest.StartTime)
+	if err := b.writeContinuousBackupLayer(ctx, execCfg, user, details, manifest, kmsEnv); err != nil {
+		return details, err
+	}
+	if err := b.updateContinuousBackupDetails(ctx, details); err != nil {
+		return details, err
+	}
+	return details, nil
+}
+
+// layerDirName returns the name of the subdirectory of the log of a continuous
+// backup for a layer that starts at ts.
+func layerDirName(ts hlc.Timestamp) string {
+	return ts.GoTime().Format(backupbase.DateBasedIncFolderName)
+}
+
+// distContinuousBackup plans and runs the processors of a continuous backup. It
+// streams back the progress of their flushes over progCh, which it closes once
+// they are done.
+func distContinuousBackup(
+	ctx context.Context,
+	execCtx sql.JobExecContext,
+	planCtx *sql.PlanningCtx,
+	dsp *sql.DistSQLPlanner,
+	progCh chan *execinfrapb.RemoteProducerMetadata_BulkProcessorProgress,
+	specs map[base.SQLInstanceID]*execinfrapb.ContinuousBackupDataSpec,
+) error {
+	defer close(progCh)
+	evalCtx := execCtx.ExtendedEvalContext()
+	var noTxn *kv.Txn
+
+	if len(specs) == 0 {
+		return nil
+	}
+
+	// Setup a one-stage plan with one proc per input spec.
+	corePlacement := make([]physicalplan.ProcessorCorePlacement, 0, len(specs))
+	var jobID jobspb.JobID
+	for sqlInstanceID, spec := range specs {
+		jobID = jobspb.JobID(spec.JobID)
+		corePlacement = append(corePlacement, physicalplan.ProcessorCorePlacement{
+			SQLInstanceID: sqlInstanceID,
+			Core:          execinfrapb.ProcessorCoreUnion{ContinuousBackupData: spec},
+		})
+	}
+
+	p := planCtx.NewPhysicalPlan()
+	// All of the progress information is sent through the metadata stream, so we
+	// have an empty result stream.
+	p.AddNoInputStage(corePlacement, execinfrapb.PostProcessSpec{}, []*types.T{}, execinfrapb.Ordering{})
+	p.PlanToStreamColMap = []int{}
+
+	sql.FinalizePlan(ctx, planCtx, p)
+
+	metaFn := func(ctx context.Context, meta *execinfrapb.ProducerMetadata) error {
+		if meta.BulkProcessorProgress != nil {
+			select {
+			case progCh <- meta.BulkProcessorProgress:
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		return nil
+	}
+
+	rowResultWriter := sql.NewRowResultWriter(nil)
+
+	recv := sql.MakeDistSQLReceiver(
+		ctx,
+		sql.NewMetadataCallbackWriter(rowResultWriter, metaFn),
+		tree.Rows,
+		nil,   /* rangeCache */
+		noTxn, /* txn - the flow does not read or write the database */
+		nil,   /* clockUpdater */
+		evalCtx.Tracing,
+	)
+	defer recv.Release()
+
+	execCfg := execCtx.ExecCfg()
+	jobsprofiler.StorePlanDiagram(ctx, execCfg.DistSQLSrv.Stopper, p, execCfg.InternalDB, jobID)
+
+	// Copy the evalCtx, as dsp.Run() might change it.
+	evalCtxCopy := *evalCtx
+	dsp.Run(ctx, planCtx, noTxn, p, recv, &evalCtxCopy, nil /* finishedSetupFn */)
+	return rowResultWriter.Err()
+}
+
+// startContinuousBackupLog starts the log of a continuous backup at the end of
+// the backup chain in details.Destination: it writes the initial, empty,
+// manifest of the first layer of the log, protects the data after its start time from garbage
+// collection and persists the location of the log in the job's details, which
+// it returns.
+func (b *backupResumer) startContinuousBackupLog(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	user username.SQLUsername,
+	mem *mon.BoundAccount,
+	details jobspb.BackupDetails,
+	kmsEnv cloud.KMSEnv,
+) (jobspb.BackupDetails, error) {
+	// The log starts at the end of the last layer of the chain, rather than at
+	// the time the job was planned.
+	chainDetails := details
+	chainDetails.EndTime = hlc.Timestamp{}
+	baseURI, manifests, localityInfo, err := resolveBackupChain(ctx, execCfg, user, mem, chainDetails, kmsEnv)
+	if err != nil {
+		return details, err
+	}
+	for i := range localityInfo {
+		if len(localityInfo[i].URIsByOriginalLocalityKV) > 0 {
+			return details, errors.Newf("continuous backups of locality-aware backups are not supported")
+		}
+	}
+	last := manifests[len(manifests)-1]
+
+	layerToIterFactory, err := backupinfo.GetBackupManifestIterFactories(
+		ctx, execCfg.DistSQLSrv.ExternalStorage, manifests, details.EncryptionOptions, kmsEnv,
+	)
+	if err != nil {
+		return details, err
+	}
+	descs, _, err := readLayerDescriptors(ctx, layerToIterFactory[len(manifests)-1])
+	if err != nil {
+		return details, err
+	}
+
+	// The log only captures changes to the data backed up by the chain.
+	backedUp := make(map[descpb.ID]bool, len(descs))
+	for i := range descs {
+		id, _, _, _, err := descpb.GetDescriptorMetadata(&descs[i])
+		if err != nil {
+			return details, err
+		}
+		backedUp[id] = true
+	}
+	for i := range details.ResolvedTargets {
+		id, _, name, _, err := descpb.GetDescriptorMetadata(&details.ResolvedTargets[i])
+		if err != nil {
+			return details, err
+		}
+		if !backedUp[id] {
+			return details, errors.Newf("%s is not backed up by the latest backup in %s; "+
+				"take an incremental backup of it before starting a continuous backup",
+				name, details.Destination.Subdir)
+		}
+	}
+
+	manifest := last
+	manifest.ID = uuid.MakeV4()
+	manifest.StartTime = last.EndTime
+	manifest.EndTime = last.EndTime
+	manifest.MVCCFilter = backuppb.MVCCFilter_All
+	manifest.RevisionStartTime = last.EndTime
+	manifest.Descriptors = descs
+	manifest.DescriptorChanges = nil
+	manifest.IntroducedSpans = nil
+	manifest.Files = nil
+	manifest.EntryCounts = roachpb.RowCount{}
+	manifest.HasExternalManifestSSTs = false
+	manifest.LocalityKVs = nil
+	manifest.PartitionDescriptorFilenames = nil
+	manifest.StatisticsFilenames = nil
+	manifest.ElidedPrefix = manifests[0].ElidedPrefix
+	manifest.BuildInfo = build.GetInfo()
+	manifest.ClusterVersion = execCfg.Settings.Version.ActiveVersion(ctx).Version
+	manifest.ClusterID = execCfg.NodeInfo.LogicalClusterID()
+
+	logURI, err := url.Parse(baseURI)
+	if err != nil {
+		return details, err
+	}
+	logURI.Path = backuputils.JoinURLPath(logURI.Path, backupbase.ContinuousBackupLogDirectory,
+		layerDirName(manifest.StartTime))
+	details.URI = logURI.String()
+	details.CollectionURI = details.Destination.To[0]
+	details.StartTime = manifest.StartTime
+	details.EndTime = manifest.EndTime
+
+	if err := b.writeContinuousBackupLayer(ctx, execCfg, user, details, &manifest, kmsEnv); err != nil {
+		return details, err
+	}
+
+	protectedtsID := uuid.MakeV4()
+	details.ProtectedTimestampRecord = &protectedtsID
+	if err := execCfg.InternalDB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		ptp := execCfg.ProtectedTimestampProvider.WithTxn(txn)
+		return protectTimestampForBackup(ctx, b.job.ID(), ptp, &manifest, details)
+	}); err != nil {
+		return details, err
+	}
+
+	if err := b.updateContinuousBackupDetails(ctx, details); err != nil {
+		return details, err
+	}
+	return details, nil
+}
+
+// writeContinuousBackupLayer lays claim to the layer of the log of a
+// continuous backup at details.URI and writes its initial manifest.
+func (b *backupResumer) writeContinuousBackupLayer(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	user username.SQLUsername,
+	details jobspb.BackupDetails,
+	manifest *backuppb.BackupManifest,
+	kmsEnv cloud.KMSEnv,
+) error {
+	foundLockFile, err := backupinfo.CheckForBackupLock(ctx, execCfg, details.URI, b.job.ID(), user)
+	if err != nil {
+		return err
+	}
+	if !foundLockFile {
+		if err := backupinfo.CheckForPreviousBackup(ctx, execCfg, details.URI, b.job.ID(), user); err != nil {
+			return err
+		}
+		if err := backupinfo.WriteBackupLock(ctx, execCfg, details.URI, b.job.ID(), user); err != nil {
+			return err
+		}
+	}
+
+	logStore, err := execCfg.DistSQLSrv.ExternalStorageFromURI(ctx, details.URI, user)
+	if err != nil {
+		return err
+	}
+	defer logStore.Close()
+	return backupinfo.WriteBackupManifestWithoutChecksum(ctx, logStore,
+		backupbase.BackupManifestName, details.EncryptionOptions, kmsEnv, manifest)
+}
+
+// updateContinuousBackupDetails persists the details of a continuous backup,
+// which point at the current layer of its log, in the job's payload.
+func (b *backupResumer) updateContinuousBackupDetails(
+	ctx context.Context, details jobspb.BackupDetails,
+) error {
+	return b.job.NoTxn().Update(ctx, func(txn isql.Txn, md jobs.JobMetadata, ju *jobs.JobUpdater) error {
+		if err := md.CheckRunningOrReverting(); err != nil {
+			return err
+		}
+		md.Payload.Details = jobspb.WrapPayloadDetails(details)
+		ju.UpdatePayload(md.Payload)
+		return nil
+	})
+}
+
+// advanceContinuousBackup records that the log of a continuous backup has been
+// flushed up to resolved: the data up to resolved no longer needs to be
+// protected from garbage collection, and resolved is reported as the high-water
+// mark of the job.
+func (b *backupResumer) advanceContinuousBackup(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	details jobspb.BackupDetails,
+	resolved hlc.Timestamp,
+) error {
+	if err := execCfg.InternalDB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		pts := execCfg.ProtectedTimestampProvider.WithTxn(txn)
+		return pts.UpdateTimestamp(ctx, *details.ProtectedTimestampRecord, resolved)
+	}); err != nil {
+		return errors.Wrap(err, "updating protected timestamp")
+	}
+	return b.job.NoTxn().Update(ctx, func(txn isql.Txn, md jobs.JobMetadata, ju *jobs.JobUpdater) error {
+		if err := md.CheckRunningOrReverting(); err != nil {
+			return err
+		}
+		progress := md.Progress
+		progress.Progress = &jobspb.Progress_HighWater{HighWater: &resolved}
+		ju.UpdateProgress(progress)
+		return nil
+	})
+}
@@ -0,0 +1,604 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package backupccl
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sort"
+	"unsafe"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupinfo"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuppb"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvclient/rangefeed"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowexec"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+	"github.com/cockroachdb/logtags"
+	gogotypes "github.com/gogo/protobuf/types"
+)
+
+const continuousBackupProcessorName = "continuousBackupDataProcessor"
+
+// continuousBackupDataProcessor represents the work each node in a cluster
+// performs during a continuous backup. It tails the changes to the spans
+// assigned to it with a rangefeed, periodically flushes them to data files in
+// the current layer of the log, and streams back the written files along with
+// the timestamp up to which it has flushed through the metadata channel
+// provided by DistSQL.
+type continuousBackupDataProcessor struct {
+	execinfra.ProcessorBase
+
+	spec execinfrapb.ContinuousBackupDataSpec
+
+	// cancelAndWaitForWorker cancels the producer goroutine and waits for it to
+	// finish. It can be called multiple times.
+	cancelAndWaitForWorker func()
+	progCh                 chan execinfrapb.RemoteProducerMetadata_BulkProcessorProgress
+	backupErr              error
+
+	// BoundAccount that reserves the memory used to buffer the received changes
+	// until they are flushed.
+	memAcc *mon.BoundAccount
+}
+
+var (
+	_ execinfra.Processor = &continuousBackupDataProcessor{}
+	_ execinfra.RowSource = &continuousBackupDataProcessor{}
+)
+
+func newContinuousBackupDataProcessor(
+	ctx context.Context,
+	flowCtx *execinfra.FlowCtx,
+	processorID int32,
+	spec execinfrapb.ContinuousBackupDataSpec,
+	post *execinfrapb.PostProcessSpec,
+) (execinfra.Processor, error) {
+	memMonitor := flowCtx.Cfg.BackupMonitor
+	if knobs, ok := flowCtx.TestingKnobs().BackupRestoreTestingKnobs.(*sql.BackupRestoreTestingKnobs); ok {
+		if knobs.BackupMemMonitor != nil {
+			memMonitor = knobs.BackupMemMonitor
+		}
+	}
+	ba := memMonitor.MakeBoundAccount()
+	bp := &continuousBackupDataProcessor{
+		spec:   spec,
+		progCh: make(chan execinfrapb.RemoteProducerMetadata_BulkProcessorProgress),
+		memAcc: &ba,
+	}
+	if err := bp.Init(ctx, bp, post, backupOutputTypes, flowCtx, processorID, nil, /* memMonitor */
+		execinfra.ProcStateOpts{
+			// This processor doesn't have any inputs to drain.
+			InputsToDrain: nil,
+			TrailingMetaCallback: func() []execinfrapb.ProducerMetadata {
+				bp.close()
+				return nil
+			},
+		}); err != nil {
+		return nil, err
+	}
+	return bp, nil
+}
+
+// Start is part of the RowSource interface.
+func (bp *continuousBackupDataProcessor) Start(ctx context.Context) {
+	ctx = logtags.AddTag(ctx, "job", bp.spec.JobID)
+	ctx = bp.StartInternal(ctx, continuousBackupProcessorName)
+	ctx, cancel := context.WithCancel(ctx)
+
+	bp.cancelAndWaitForWorker = func() {
+		cancel()
+		for range bp.progCh {
+		}
+	}
+	log.Infof(ctx, "starting continuous backup of %d spans at %s", len(bp.spec.Spans), bp.spec.StartTime)
+	if err := bp.FlowCtx.Stopper().RunAsyncTaskEx(ctx, stop.TaskOpts{
+		TaskName: "continuousBackupDataProcessor.runContinuousBackupProcessor",
+		SpanOpt:  stop.ChildSpan,
+	}, func(ctx context.Context) {
+		bp.backupErr = runContinuousBackupProcessor(ctx, bp.FlowCtx, &bp.spec, bp.progCh, bp.memAcc)
+		cancel()
+		close(bp.progCh)
+	}); err != nil {
+		// The closure above hasn't run, so we have to do the cleanup.
+		bp.backupErr = err
+		cancel()
+		close(bp.progCh)
+	}
+}
+
+// Next is part of the RowSource interface.
+func (bp *continuousBackupDataProcessor) Next() (rowenc.EncDatumRow, *execinfrapb.ProducerMetadata) {
+	if bp.State != execinfra.StateRunning {
+		return nil, bp.DrainHelper()
+	}
+
+	prog, ok := <-bp.progCh
+	if !ok {
+		bp.MoveToDraining(bp.backupErr)
+		return nil, bp.DrainHelper()
+	}
+	prog.NodeID = bp.FlowCtx.NodeID.SQLInstanceID()
+	prog.FlowID = bp.FlowCtx.ID
+	prog.ProcessorID = bp.ProcessorID
+	return nil, &execinfrapb.ProducerMetadata{BulkProcessorProgress: &prog}
+}
+
+func (bp *continuousBackupDataProcessor) close() {
+	if bp.cancelAndWaitForWorker != nil {
+		bp.cancelAndWaitForWorker()
+	}
+	if bp.InternalClose() {
+		bp.memAcc.Close(bp.Ctx())
+	}
+}
+
+// ConsumerClosed is part of the RowSource interface. We have to override the
+// implementation provided by ProcessorBase.
+func (bp *continuousBackupDataProcessor) ConsumerClosed() {
+	bp.close()
+}
+
+// runContinuousBackupProcessor tails the changes to the spans of spec and
+// flushes them to the layer of the log at spec.URI every
+// bulkio.backup.continuous.flush_interval, or earlier if the changes it buffers
+// exceed its memory budget. It reports every flush on progCh and returns once
+// it has reported a change that the log cannot capture.
+func runContinuousBackupProcessor(
+	ctx context.Context,
+	flowCtx *execinfra.FlowCtx,
+	spec *execinfrapb.ContinuousBackupDataSpec,
+	progCh chan<- execinfrapb.RemoteProducerMetadata_BulkProcessorProgress,
+	memAcc *mon.BoundAccount,
+) error {
+	execCfg := flowCtx.Cfg.ExecutorConfig.(*sql.ExecutorConfig)
+
+	dest, err := cloud.ExternalStorageConfFromURI(spec.URI, spec.User())
+	if err != nil {
+		return err
+	}
+	logStore, err := flowCtx.Cfg.ExternalStorage(ctx, dest, cloud.WithClientName("backup"))
+	if err != nil {
+		return err
+	}
+	defer logClose(ctx, logStore, "external storage")
+
+	buf := newContinuousBackupBuffer(memAcc, spec.StartTime)
+	rf := execCfg.RangeFeedFactory.New(
+		fmt.Sprintf("continuous-backup-%d", spec.JobID),
+		spec.StartTime,
+		func(ctx context.Context, v *kvpb.RangeFeedValue) {
+			buf.add(ctx, storage.MVCCKey{Key: v.Key, Timestamp: v.Value.Timestamp}, v.Value.RawBytes)
+		},
+		rangefeed.WithOnFrontierAdvance(func(ctx context.Context, ts hlc.Timestamp) {
+			buf.advance(ts)
+		}),
+		rangefeed.WithOnDeleteRange(func(ctx context.Context, e *kvpb.RangeFeedDeleteRange) {
+			buf.stop(e.Timestamp, "a range deletion")
+		}),
+		rangefeed.WithOnSSTable(func(ctx context.Context, sst *kvpb.RangeFeedSSTable, _ roachpb.Span) {
+			buf.stop(sst.WriteTS, "a bulk ingestion")
+		}),
+		rangefeed.WithOnInternalError(func(ctx context.Context, err error) {
+			buf.fail(err)
+		}),
+	)
+	if err := rf.Start(ctx, spec.Spans); err != nil {
+		return errors.Wrap(err, "starting rangefeed")
+	}
+	defer rf.Close()
+
+	resolved := spec.StartTime
+	var timer timeutil.Timer
+	defer timer.Stop()
+	for {
+		timer.Reset(continuousBackupFlushInterval.Get(&execCfg.Settings.SV))
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timer.C:
+			timer.Read = true
+		case <-buf.flushCh:
+		}
+
+		events, size, flushTS, stopReason, err := buf.take(resolved)
+		if err != nil {
+			return err
+		}
+		var files []backuppb.BackupManifest_File
+		if resolved.Less(flushTS) {
+			files, err = writeContinuousBackupFiles(
+				ctx, execCfg, events, resolved, flushTS, spec.ElidePrefix, spec.Encryption, spec.PKIDs, logStore,
+			)
+		}
+		// The memory of the flushed changes is released even if the flush
+		// failed, so that changes waiting for room can be dropped.
+		buf.release(ctx, size)
+		if err != nil {
+			return err
+		}
+		if !resolved.Less(flushTS) && stopReason == "" {
+			continue
+		}
+		resolved.Forward(flushTS)
+
+		progDetails, err := gogotypes.MarshalAny(&backuppb.ContinuousBackupProgress{
+			Files:      files,
+			Resolved:   resolved,
+			StopReason: stopReason,
+		})
+		if err != nil {
+			return err
+		}
+		select {
+		case progCh <- execinfrapb.RemoteProducerMetadata_BulkProcessorProgress{ProgressDetails: *progDetails}:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if stopReason != "" {
+			log.Infof(ctx, "continuous backup stopped at %s because of %s", resolved, stopReason)
+			return nil
+		}
+	}
+}
+
+// continuousBackupEvent is a revision of a key received by the rangefeed of a
+// continuous backup.
+type continuousBackupEvent struct {
+	key   storage.MVCCKey
+	value []byte
+	// size is the memory reserved for the event.
+	size int64
+}
+
+const continuousBackupEventOverhead = int64(unsafe.Sizeof(continuousBackupEvent{}))
+
+// continuousBackupFrontier tracks the timestamp up to which the changes
+// received by a rangefeed of a continuous backup can be flushed to its log.
+type continuousBackupFrontier struct {
+	// frontier is the timestamp up to which all revisions have been received.
+	frontier hlc.Timestamp
+	// stopAt, if set, is the last timestamp up to which the log can capture
+	// changes, because of the change described by stopReason.
+	stopAt     hlc.Timestamp
+	stopReason string
+	// err is set if the rangefeed failed.
+	err error
+}
+
+func (f *continuousBackupFrontier) advance(frontier hlc.Timestamp) {
+	f.frontier.Forward(frontier)
+}
+
+// stop records that the log cannot capture a change at ts, described by reason,
+// or any change after it.
+func (f *continuousBackupFrontier) stop(ts hlc.Timestamp, reason string) {
+	if f.stopAt.IsEmpty() || ts.Prev().Less(f.stopAt) {
+		f.stopAt = ts.Prev()
+		f.stopReason = reason
+	}
+}
+
+func (f *continuousBackupFrontier) fail(err error) {
+	if f.err == nil {
+		f.err = err
+	}
+}
+
+// resolved returns the timestamp up to which changes can be flushed to the log
+// and, if the log cannot capture any changes after that timestamp, the reason
+// why.
+func (f *continuousBackupFrontier) resolved() (hlc.Timestamp, string, error) {
+	if f.err != nil {
+		return hlc.Timestamp{}, "", f.err
+	}
+	if !f.stopAt.IsEmpty() && f.stopAt.LessEq(f.frontier) {
+		return f.stopAt, f.stopReason, nil
+	}
+	return f.frontier, "", nil
+}
+
+// continuousBackupBuffer accumulates the revisions received by the rangefeed of
+// a continuous backup until they are flushed to its log. The buffered revisions
+// are accounted for in memAcc; when it runs out of budget, the buffer asks for
+// an early flush on flushCh and waits for it before buffering more.
+type continuousBackupBuffer struct {
+	memAcc  *mon.BoundAccount
+	flushCh chan struct{}
+
+	mu struct {
+		syncutil.Mutex
+		continuousBackupFrontier
+		events []continuousBackupEvent
+		// flushed, if set, is closed once the next flush has released the
+		// memory of the revisions it flushed.
+		flushed chan struct{}
+	}
+}
+
+func newContinuousBackupBuffer(
+	memAcc *mon.BoundAccount, frontier hlc.Timestamp,
+) *continuousBackupBuffer {
+	b := &continuousBackupBuffer{
+		memAcc:  memAcc,
+		flushCh: make(chan struct{}, 1),
+	}
+	b.mu.frontier = frontier
+	return b
+}
+
+func (b *continuousBackupBuffer) add(ctx context.Context, key storage.MVCCKey, value []byte) {
+	size := continuousBackupEventOverhead + int64(len(key.Key)+len(value))
+	for flushedEarly := false; ; flushedEarly = true {
+		b.mu.Lock()
+		if b.mu.err != nil || (!b.mu.stopAt.IsEmpty() && b.mu.stopAt.Less(key.Timestamp)) {
+			b.mu.Unlock()
+			return
+		}
+		err := b.memAcc.Grow(ctx, size)
+		if err == nil {
+			b.mu.events = append(b.mu.events, continuousBackupEvent{key: key, value: value, size: size})
+			b.mu.Unlock()
+			return
+		}
+		if flushedEarly {
+			b.mu.fail(errors.WithHint(errors.Wrap(err, "buffering changes for continuous backup"),
+				"lower bulkio.backup.continuous.flush_interval so that changes are flushed more often"))
+			b.mu.Unlock()
+			return
+		}
+		if b.mu.flushed == nil {
+			b.mu.flushed = make(chan struct{})
+		}
+		flushed := b.mu.flushed
+		b.mu.Unlock()
+
+		// Flush early to make room for the revision, and try once more.
+		select {
+		case b.flushCh <- struct{}{}:
+		default:
+		}
+		select {
+		case <-flushed:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (b *continuousBackupBuffer) advance(frontier hlc.Timestamp) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.mu.advance(frontier)
+}
+
+func (b *continuousBackupBuffer) stop(ts hlc.Timestamp, reason string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.mu.stop(ts, reason)
+}
+
+func (b *continuousBackupBuffer) fail(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.mu.fail(err)
+}
+
+// take removes and returns the buffered revisions that can be flushed to a log
+// that has been flushed up to after, sorted and deduplicated, along with the
+// timestamp up to which they complete the log. If the log cannot capture any
+// changes after that timestamp, the reason why is returned as well. The memory
+// reserved for the removed revisions, which is also returned, must be released
+// once they have been flushed.
+func (b *continuousBackupBuffer) take(
+	after hlc.Timestamp,
+) (
+	_ []continuousBackupEvent,
+	size int64,
+	flushTS hlc.Timestamp,
+	stopReason string,
+	_ error,
+) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	flushTS, stopReason, err := b.mu.resolved()
+	if err != nil {
+		return nil, 0, hlc.Timestamp{}, "", err
+	}
+
+	var events, remaining []continuousBackupEvent
+	for _, e := range b.mu.events {
+		if flushTS.Less(e.key.Timestamp) {
+			remaining = append(remaining, e)
+			continue
+		}
+		size += e.size
+		if after.Less(e.key.Timestamp) {
+			events = append(events, e)
+		}
+	}
+	b.mu.events = remaining
+
+	// The rangefeed may deliver the same revision more than once.
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].key.Less(events[j].key)
+	})
+	deduped := events[:0]
+	for i := range events {
+		if i > 0 && events[i].key.Equal(events[i-1].key) {
+			continue
+		}
+		deduped = append(deduped, events[i])
+	}
+	return deduped, size, flushTS, stopReason, nil
+}
+
+// release releases the memory reserved for flushed revisions, and wakes up the
+// revisions waiting for room in the buffer.
+func (b *continuousBackupBuffer) release(ctx context.Context, size int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.memAcc.Shrink(ctx, size)
+	if b.mu.flushed != nil {
+		close(b.mu.flushed)
+		b.mu.flushed = nil
+	}
+}
+
+// writeContinuousBackupFiles writes events, which must be sorted and
+// deduplicated, to dest as data files of a continuous backup log that cover the
+// changes in (startTime, endTime]. It returns the descriptors of the written
+// files.
+func writeContinuousBackupFiles(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	events []continuousBackupEvent,
+	startTime, endTime hlc.Timestamp,
+	elideMode execinfrapb.ElidePrefix,
+	encryption *kvpb.FileEncryptionOptions,
+	pkIDs map[uint64]bool,
+	dest cloud.ExternalStorage,
+) ([]backuppb.BackupManifest_File, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+	progCh := make(chan execinfrapb.RemoteProducerMetadata_BulkProcessorProgress)
+
+	var files []backuppb.BackupManifest_File
+	grp := ctxgroup.WithContext(ctx)
+	grp.GoCtx(func(ctx context.Context) error {
+		defer close(progCh)
+		sink := makeFileSSTSink(sstSinkConf{
+			progCh:   progCh,
+			enc:      encryption,
+			id:       execCfg.NodeInfo.NodeID.SQLInstanceID(),
+			settings: &execCfg.Settings.SV,
+		}, dest, nil /* pacer */)
+		defer logClose(ctx, sink, "SST sink")
+		sink.elideMode = elideMode
+		if err := writeContinuousBackupChunks(ctx, execCfg, events, startTime, endTime, pkIDs, sink); err != nil {
+			return err
+		}
+		return sink.flush(ctx)
+	})
+	grp.GoCtx(func(ctx context.Context) error {
+		for prog := range progCh {
+			var progDetails backuppb.BackupManifest_Progress
+			if err := gogotypes.UnmarshalAny(&prog.ProgressDetails, &progDetails); err != nil {
+				return errors.Wrap(err, "unable to unmarshal continuous backup progress details")
+			}
+			files = append(files, progDetails.Files...)
+		}
+		return nil
+	})
+	if err := grp.Wait(); err != nil {
+		return nil, err
+	}
+
+	sort.Sort(backupinfo.BackupFileDescriptors(files))
+	return files, nil
+}
+
+// writeContinuousBackupChunks writes events, which must be sorted and
+// deduplicated, to sink in chunks that are cut at row boundaries.
+func writeContinuousBackupChunks(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	events []continuousBackupEvent,
+	startTime, endTime hlc.Timestamp,
+	pkIDs map[uint64]bool,
+	sink *fileSSTSink,
+) error {
+	var buf bytes.Buffer
+	var sst storage.SSTWriter
+	var counter storage.RowCounter
+	// chunkStart is the row key of the first key of the chunk currently being
+	// written, or nil if no chunk is being written.
+	var chunkStart, chunkPrefix, lastRow roachpb.Key
+	defer func() {
+		if chunkStart != nil {
+			sst.Close()
+		}
+	}()
+
+	writeChunk := func(end roachpb.Key) error {
+		defer sst.Close()
+		if err := sst.Finish(); err != nil {
+			return err
+		}
+		counter.DataSize = sst.DataSize
+		_, err := sink.write(ctx, exportedSpan{
+			metadata: backuppb.BackupManifest_File{
+				Span:        roachpb.Span{Key: chunkStart, EndKey: end},
+				EntryCounts: countRows(counter.BulkOpSummary, pkIDs),
+				StartTime:   startTime,
+				EndTime:     endTime,
+			},
+			dataSST: buf.Bytes(),
+		})
+		chunkStart = nil
+		return err
+	}
+
+	for _, e := range events {
+		row, err := keys.EnsureSafeSplitKey(e.key.Key)
+		if err != nil {
+			// Not a SQL key; treat it as a row of its own.
+			row = e.key.Key
+		}
+		if !bytes.Equal(row, lastRow) {
+			lastRow = row
+			// The sink elides a single prefix from every key of a file, so a new
+			// chunk is started whenever that prefix changes.
+			keyPrefix, err := elidedPrefix(row, sink.elideMode)
+			if err != nil {
+				return err
+			}
+			if chunkStart != nil && (sst.DataSize >= compactionChunkSize || !bytes.Equal(keyPrefix, chunkPrefix)) {
+				if err := writeChunk(row); err != nil {
+					return err
+				}
+			}
+			if chunkStart == nil {
+				chunkStart = row
+				chunkPrefix = keyPrefix
+				buf.Reset()
+				sst = storage.MakeBackupSSTWriter(ctx, execCfg.Settings, &buf)
+				counter = storage.RowCounter{}
+			}
+		}
+		if err := sst.PutRawMVCC(e.key, e.value); err != nil {
+			return err
+		}
+		if err := counter.Count(e.key.Key); err != nil {
+			return err
+		}
+	}
+	if chunkStart == nil {
+		return nil
+	}
+	return writeChunk(lastRow.PrefixEnd())
+}
+
+func init() {
+	rowexec.NewContinuousBackupDataProcessor = newContinuousBackupDataProcessor
+}
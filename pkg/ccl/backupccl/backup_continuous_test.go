@@ -0,0 +1,111 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package backupccl
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/jobutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+	"github.com/stretchr/testify/require"
+)
+
+// TestContinuousBackup tests that a continuous backup extends its backup chain
+// so that it can be restored to times after the last incremental backup. Every
+// flush that writes files rolls the log over to a new layer, so restores have
+// to replay several layers of the log.
+func TestContinuousBackup(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	const numAccounts = 10
+	tc, sqlDB, _, cleanupFn := backupRestoreTestSetup(t, multiNode, numAccounts, InitManualReplication)
+	defer cleanupFn()
+	for i := 0; i < tc.NumServers(); i++ {
+		kvserver.RangefeedEnabled.Override(ctx, &tc.Server(i).ClusterSettings().SV, true)
+	}
+	sqlDB.Exec(t, `SET CLUSTER SETTING bulkio.backup.continuous.flush_interval = '50ms'`)
+	sqlDB.Exec(t, `SET CLUSTER SETTING bulkio.backup.continuous.layer_max_files = 1`)
+
+	sqlDB.Exec(t, `BACKUP DATABASE data INTO $1`, localFoo)
+	sqlDB.Exec(t, `UPDATE data.bank SET balance = balance + 1 WHERE id < 3`)
+	sqlDB.Exec(t, `BACKUP DATABASE data INTO LATEST IN $1`, localFoo)
+
+	var jobID jobspb.JobID
+	sqlDB.QueryRow(t, `BACKUP DATABASE data INTO LATEST IN $1 WITH continuous`, localFoo).Scan(&jobID)
+	jobutils.WaitForJobToRun(t, sqlDB, jobID)
+
+	waitForContinuousBackup := func(ts string) {
+		testutils.SucceedsSoon(t, func() error {
+			var caughtUp bool
+			sqlDB.QueryRow(t, `SELECT COALESCE(high_water_timestamp >= $2::DECIMAL, false)
+FROM crdb_internal.jobs WHERE job_id = $1`, jobID, ts).Scan(&caughtUp)
+			if !caughtUp {
+				return errors.Newf("continuous backup has not reached %s", ts)
+			}
+			return nil
+		})
+	}
+
+	sqlDB.Exec(t, `UPDATE data.bank SET balance = balance + 1 WHERE id < 5`)
+	var ts1 string
+	sqlDB.QueryRow(t, `SELECT cluster_logical_timestamp()`).Scan(&ts1)
+	expected1 := sqlDB.QueryStr(t, `SELECT * FROM data.bank ORDER BY id`)
+	// The changes up to ts1 fill a layer of the log, so the changes after it go
+	// to the next one.
+	waitForContinuousBackup(ts1)
+
+	sqlDB.Exec(t, `DELETE FROM data.bank WHERE id >= 8`)
+	sqlDB.Exec(t, `INSERT INTO data.bank VALUES (100, 100, 'new')`)
+	var ts2 string
+	sqlDB.QueryRow(t, `SELECT cluster_logical_timestamp()`).Scan(&ts2)
+	expected2 := sqlDB.QueryStr(t, `SELECT * FROM data.bank ORDER BY id`)
+
+	waitForContinuousBackup(ts2)
+
+	sqlDB.Exec(t, fmt.Sprintf(`RESTORE DATABASE data FROM LATEST IN $1 AS OF SYSTEM TIME %s
+WITH new_db_name = restored1`, ts1), localFoo)
+	sqlDB.CheckQueryResults(t, `SELECT * FROM restored1.bank ORDER BY id`, expected1)
+	sqlDB.Exec(t, fmt.Sprintf(`RESTORE DATABASE data FROM LATEST IN $1 AS OF SYSTEM TIME %s
+WITH new_db_name = restored2`, ts2), localFoo)
+	sqlDB.CheckQueryResults(t, `SELECT * FROM restored2.bank ORDER BY id`, expected2)
+
+	// A schema change ends the log, but changes before it remain restorable.
+	sqlDB.Exec(t, `ALTER TABLE data.bank ADD COLUMN extra INT`)
+	jobutils.WaitForJobToFail(t, sqlDB, jobID)
+	var jobErr string
+	sqlDB.QueryRow(t, `SELECT error FROM [SHOW JOB $1]`, jobID).Scan(&jobErr)
+	require.Contains(t, jobErr, "because of a schema change")
+	sqlDB.Exec(t, fmt.Sprintf(`RESTORE DATABASE data FROM LATEST IN $1 AS OF SYSTEM TIME %s
+WITH new_db_name = restored3`, ts2), localFoo)
+	sqlDB.CheckQueryResults(t, `SELECT * FROM restored3.bank ORDER BY id`, expected2)
+
+	t.Run("errors", func(t *testing.T) {
+		sqlDB.ExpectErr(t, "only supported for backups INTO LATEST IN a collection",
+			`BACKUP DATABASE data INTO $1 WITH continuous`, localFoo)
+		sqlDB.ExpectErr(t, "not supported for cluster or virtual cluster backups",
+			`BACKUP INTO LATEST IN $1 WITH continuous`, localFoo)
+		sqlDB.ExpectErr(t, "revision_history is not supported with the continuous option",
+			`BACKUP DATABASE data INTO LATEST IN $1 WITH continuous, revision_history`, localFoo)
+		sqlDB.ExpectErr(t, "continuous option is not supported by ALTER BACKUP ... COMPACT",
+			`ALTER BACKUP LATEST IN $1 COMPACT WITH OPTIONS (continuous)`, localFoo)
+
+		// A continuous backup can only extend a chain that backs up its targets.
+		sqlDB.Exec(t, `CREATE TABLE data.other (id INT PRIMARY KEY)`)
+		sqlDB.QueryRow(t, `BACKUP TABLE data.other INTO LATEST IN $1 WITH continuous`, localFoo).Scan(&jobID)
+		jobutils.WaitForJobToFail(t, sqlDB, jobID)
+		sqlDB.QueryRow(t, `SELECT error FROM [SHOW JOB $1]`, jobID).Scan(&jobErr)
+		require.Contains(t, jobErr, "take an incremental backup of it before starting a continuous backup")
+	})
+}
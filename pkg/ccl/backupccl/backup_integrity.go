@@ -0,0 +1,399 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package backupccl
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupbase"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupinfo"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuppb"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/ioctx"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/errors"
+)
+
+// backupSigningKeySize is the size, in bytes, of the random key that a backup
+// manifest is signed with.
+const backupSigningKeySize = 32
+
+// signBackupManifest returns the HMAC-SHA256 of the manifest file contents
+// under key.
+func signBackupManifest(key, manifest []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write(manifest)
+	return mac.Sum(nil)
+}
+
+func readBackupFile(ctx context.Context, store cloud.ExternalStorage, name string) ([]byte, error) {
+	r, _, err := store.ReadFile(ctx, name, cloud.ReadOptions{NoFileSize: true})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close(ctx)
+	return ioctx.ReadAll(ctx, r)
+}
+
+// writeBackupManifestSignature signs the manifest file in store with a new
+// random key, and writes the signature alongside it. The key is stored
+// encrypted by the KMS at kmsURI, so that only a user with access to the KMS
+// can verify the signature, or forge a new one.
+func writeBackupManifestSignature(
+	ctx context.Context, store cloud.ExternalStorage, kmsURI string, kmsEnv cloud.KMSEnv,
+) error {
+	manifest, err := readBackupFile(ctx, store, backupbase.BackupManifestName)
+	if err != nil {
+		return errors.Wrap(err, "reading backup manifest")
+	}
+	kms, err := cloud.KMSFromURI(ctx, kmsURI, kmsEnv)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := kms.Close(); err != nil {
+			log.Warningf(ctx, "failed to close KMS: %+v", err)
+		}
+	}()
+
+	key := make([]byte, backupSigningKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return err
+	}
+	encryptedKey, err := kms.Encrypt(ctx, key)
+	if err != nil {
+		return errors.Wrap(err, "encrypting signing key")
+	}
+	sig := backuppb.BackupManifestSignature{
+		KMSMasterKeyID: kms.MasterKeyID(),
+		EncryptedKey:   encryptedKey,
+		Signature:      signBackupManifest(key, manifest),
+	}
+	buf, err := protoutil.Marshal(&sig)
+	if err != nil {
+		return err
+	}
+	return cloud.WriteFile(ctx, store, backupbase.BackupManifestSignatureName, bytes.NewReader(buf))
+}
+
+// verifyIntegrityWorkers is the number of data files that are read and
+// hashed concurrently when verifying the integrity of a backup.
+var verifyIntegrityWorkers = settings.RegisterIntSetting(
+	settings.ApplicationLevel,
+	"bulkio.restore.verify_integrity_workers",
+	"number of concurrent workers that read and hash backup data files when verifying backup integrity",
+	16,
+	settings.PositiveInt,
+)
+
+// verifyBackupIntegrity verifies, for each layer of a backup chain, that its
+// manifest was signed with a key encrypted by the KMS at kmsURI and has not
+// been modified since, that the contents of each of its data files still
+// hash to the hash recorded in the signed manifest, and that the files read
+// from the layer's metadata match those in the signed manifest. The data
+// files of all layers are hashed concurrently.
+func verifyBackupIntegrity(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	user username.SQLUsername,
+	manifests []backuppb.BackupManifest,
+	localityInfo []jobspb.RestoreDetails_BackupLocalityInfo,
+	layerToIterFactory backupinfo.LayerToBackupManifestFileIterFactory,
+	encryption *jobspb.BackupEncryptionOptions,
+	kmsURI string,
+	kmsEnv cloud.KMSEnv,
+) error {
+	ctx, sp := tracing.ChildSpan(ctx, "backupccl.verifyBackupIntegrity")
+	defer sp.Finish()
+
+	kms, err := cloud.KMSFromURI(ctx, kmsURI, kmsEnv)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := kms.Close(); err != nil {
+			log.Warningf(ctx, "failed to close KMS: %+v", err)
+		}
+	}()
+
+	wrapLayerErr := func(layer int, err error) error {
+		return errors.Wrapf(err, "verifying integrity of backup ending at %s",
+			manifests[layer].EndTime.GoTime().Format(backupbase.DateBasedIntoFolderName))
+	}
+
+	// Verify the signature of each layer first, so that a backup that was
+	// not signed, or whose manifest was changed, fails before any data file
+	// is read.
+	stores := make([]cloud.ExternalStorage, 0, len(manifests))
+	defer func() {
+		for _, store := range stores {
+			if err := store.Close(); err != nil {
+				log.Warningf(ctx, "close export storage failed %v", err)
+			}
+		}
+	}()
+	var files []backupFileToHash
+	for layer := range manifests {
+		store, err := execCfg.DistSQLSrv.ExternalStorage(ctx, manifests[layer].Dir)
+		if err != nil {
+			return wrapLayerErr(layer, err)
+		}
+		stores = append(stores, store)
+		signed, err := verifyBackupManifestSignature(ctx, store, kms, encryption, kmsEnv)
+		if err != nil {
+			return wrapLayerErr(layer, err)
+		}
+		layerFiles, err := uniqueBackupFilesToHash(layer, signed.Files)
+		if err != nil {
+			return wrapLayerErr(layer, err)
+		}
+		files = append(files, layerFiles...)
+	}
+
+	hashes := make([]map[string][]byte, len(manifests))
+	for layer := range hashes {
+		hashes[layer] = make(map[string][]byte)
+	}
+	for _, f := range files {
+		hashes[f.layer][f.Path] = f.ContentHash
+	}
+	if err := verifyBackupFileHashes(
+		ctx, execCfg, user, stores, localityInfo, files, wrapLayerErr,
+	); err != nil {
+		return err
+	}
+
+	for layer := range manifests {
+		if err := func() error {
+			it, err := layerToIterFactory[layer].NewFileIter(ctx)
+			if err != nil {
+				return err
+			}
+			defer it.Close()
+			for ; ; it.Next() {
+				if ok, err := it.Valid(); err != nil {
+					return err
+				} else if !ok {
+					return nil
+				}
+				f := it.Value()
+				if hash, ok := hashes[layer][f.Path]; !ok || !bytes.Equal(hash, f.ContentHash) {
+					return errors.Newf("backup metadata for file %s does not match the signed manifest", f.Path)
+				}
+			}
+		}(); err != nil {
+			return wrapLayerErr(layer, err)
+		}
+	}
+	return nil
+}
+
+// backupFileToHash is a data file of a layer of a backup chain whose contents
+// are to be checked against the hash recorded in the layer's signed manifest.
+type backupFileToHash struct {
+	layer int
+	backuppb.BackupManifest_File
+}
+
+// uniqueBackupFilesToHash returns the files of the signed manifest of a layer
+// with duplicate paths removed, after checking that each of them records a
+// hash and that duplicates record the same one.
+func uniqueBackupFilesToHash(
+	layer int, files []backuppb.BackupManifest_File,
+) ([]backupFileToHash, error) {
+	hashes := make(map[string][]byte, len(files))
+	var res []backupFileToHash
+	for _, f := range files {
+		if hash, ok := hashes[f.Path]; ok {
+			if !bytes.Equal(hash, f.ContentHash) {
+				return nil, errors.Newf("signed manifest lists different hashes for file %s", f.Path)
+			}
+			continue
+		}
+		if len(f.ContentHash) == 0 {
+			return nil, errors.Newf("signed manifest does not record a hash for file %s", f.Path)
+		}
+		hashes[f.Path] = f.ContentHash
+		res = append(res, backupFileToHash{layer: layer, BackupManifest_File: f})
+	}
+	return res, nil
+}
+
+// verifyBackupManifestSignature verifies the signature of the manifest in
+// store, and returns the signed manifest.
+func verifyBackupManifestSignature(
+	ctx context.Context,
+	store cloud.ExternalStorage,
+	kms cloud.KMS,
+	encryption *jobspb.BackupEncryptionOptions,
+	kmsEnv cloud.KMSEnv,
+) (backuppb.BackupManifest, error) {
+	buf, err := readBackupFile(ctx, store, backupbase.BackupManifestSignatureName)
+	if err != nil {
+		if errors.Is(err, cloud.ErrFileDoesNotExist) {
+			return backuppb.BackupManifest{}, errors.New(
+				"backup is not signed; it must be taken with the signing_kms option")
+		}
+		return backuppb.BackupManifest{}, err
+	}
+	var sig backuppb.BackupManifestSignature
+	if err := protoutil.Unmarshal(buf, &sig); err != nil {
+		return backuppb.BackupManifest{}, errors.Wrap(err, "decoding backup manifest signature")
+	}
+	if sig.KMSMasterKeyID != kms.MasterKeyID() {
+		return backuppb.BackupManifest{}, errors.Newf(
+			"backup was signed with KMS key %s, not %s", sig.KMSMasterKeyID, kms.MasterKeyID())
+	}
+	key, err := kms.Decrypt(ctx, sig.EncryptedKey)
+	if err != nil {
+		return backuppb.BackupManifest{}, errors.Wrap(err, "decrypting signing key")
+	}
+
+	manifest, err := readBackupFile(ctx, store, backupbase.BackupManifestName)
+	if err != nil {
+		return backuppb.BackupManifest{}, errors.Wrap(err, "reading backup manifest")
+	}
+	if !hmac.Equal(sig.Signature, signBackupManifest(key, manifest)) {
+		return backuppb.BackupManifest{}, errors.New("backup manifest does not match its signature")
+	}
+	return backupinfo.DecodeBackupManifest(ctx, encryption, kmsEnv, manifest)
+}
+
+// verifyBackupFileHashes verifies that the contents of each of files hash to
+// its recorded content hash, reading verifyIntegrityWorkers files at a time.
+// Files are read from the store of their layer in stores, or from the URI of
+// their locality in localityInfo, and errors about a file are wrapped by
+// wrapLayerErr with its layer. Progress is logged periodically, since
+// reading every data file of a large backup chain can take a long time.
+func verifyBackupFileHashes(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	user username.SQLUsername,
+	stores []cloud.ExternalStorage,
+	localityInfo []jobspb.RestoreDetails_BackupLocalityInfo,
+	files []backupFileToHash,
+	wrapLayerErr func(layer int, err error) error,
+) error {
+	var totalBytes int64
+	for _, f := range files {
+		totalBytes += f.EntryCounts.DataSize
+	}
+	log.Infof(ctx, "verifying the hashes of %d backup files (%s of data)",
+		len(files), humanizeutil.IBytes(totalBytes))
+	verifyProgress := log.Every(10 * time.Second)
+	startingVerification := timeutil.Now()
+
+	logVerifyProgress := func(verifiedFiles int, verifiedBytes int64) {
+		msg := fmt.Sprintf("verified the hashes of %d/%d backup files (%s/%s)",
+			verifiedFiles, len(files), humanizeutil.IBytes(verifiedBytes), humanizeutil.IBytes(totalBytes))
+		if timeSinceStart := timeutil.Since(startingVerification).Seconds(); verifiedBytes != 0 && timeSinceStart >= 1 {
+			msg = fmt.Sprintf("%s; reading at the rate of %s/sec", msg,
+				humanizeutil.IBytes(int64(float64(verifiedBytes)/timeSinceStart)))
+		}
+		log.Infof(ctx, "%s", msg)
+	}
+
+	fileCh := make(chan backupFileToHash, len(files))
+	for _, f := range files {
+		fileCh <- f
+	}
+	close(fileCh)
+
+	mu := struct {
+		syncutil.Mutex
+		verifiedFiles int
+		verifiedBytes int64
+	}{}
+	workers := int(verifyIntegrityWorkers.Get(&execCfg.Settings.SV))
+	if workers > len(files) {
+		workers = len(files)
+	}
+	if err := ctxgroup.GroupWorkers(ctx, workers, func(ctx context.Context, _ int) error {
+		for {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case f, ok := <-fileCh:
+				if !ok {
+					return nil
+				}
+				var uris map[string]string
+				if f.layer < len(localityInfo) {
+					uris = localityInfo[f.layer].URIsByOriginalLocalityKV
+				}
+				hash, err := hashBackupFile(ctx, execCfg, user, stores[f.layer], uris, f.BackupManifest_File)
+				if err != nil {
+					return wrapLayerErr(f.layer, errors.Wrapf(err, "reading file %s", f.Path))
+				}
+				if !bytes.Equal(hash, f.ContentHash) {
+					return wrapLayerErr(f.layer, errors.Newf(
+						"contents of file %s do not match its hash in the signed manifest", f.Path))
+				}
+				mu.Lock()
+				mu.verifiedFiles++
+				mu.verifiedBytes += f.EntryCounts.DataSize
+				verifiedFiles, verifiedBytes := mu.verifiedFiles, mu.verifiedBytes
+				mu.Unlock()
+				if verifyProgress.ShouldLog() {
+					logVerifyProgress(verifiedFiles, verifiedBytes)
+				}
+			}
+		}
+	}); err != nil {
+		return err
+	}
+	logVerifyProgress(len(files), totalBytes)
+	return nil
+}
+
+func hashBackupFile(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	user username.SQLUsername,
+	store cloud.ExternalStorage,
+	urisByLocalityKV map[string]string,
+	f backuppb.BackupManifest_File,
+) ([]byte, error) {
+	if f.LocalityKV != "" {
+		uri, ok := urisByLocalityKV[f.LocalityKV]
+		if !ok {
+			return nil, errors.Newf("no URI given for locality %s", f.LocalityKV)
+		}
+		localityStore, err := execCfg.DistSQLSrv.ExternalStorageFromURI(ctx, uri, user)
+		if err != nil {
+			return nil, err
+		}
+		defer localityStore.Close()
+		store = localityStore
+	}
+	r, _, err := store.ReadFile(ctx, f.Path, cloud.ReadOptions{NoFileSize: true})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close(ctx)
+	h := sha256.New()
+	if _, err := io.Copy(h, ioctx.ReaderCtxAdapter(ctx, r)); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
@@ -468,6 +468,14 @@ func backup(
 		return roachpb.RowCount{}, 0, err
 	}
 
+	// Sign the manifest, which records the hash of each data file, so that any
+	// later change to the backup can be detected.
+	if signingKMSURI := job.Details().(jobspb.BackupDetails).SigningKMSURI; signingKMSURI != "" {
+		if err := writeBackupManifestSignature(ctx, defaultStore, signingKMSURI, &kmsEnv); err != nil {
+			return roachpb.RowCount{}, 0, errors.Wrap(err, "signing backup manifest")
+		}
+	}
+
 	// Write a `BACKUP_METADATA` file along with SSTs for all the alloc heavy
 	// fields elided from the `BACKUP_MANIFEST`.
 	//
@@ -601,6 +609,16 @@ func (b *backupResumer) Resume(ctx context.Context, execCtx interface{}) error {
 		return err
 	}
 
+	if details.Compact {
+		return b.resumeCompaction(ctx, p, details)
+	}
+	if details.Continuous {
+		return b.resumeContinuous(ctx, p, details)
+	}
+	if details.RetainFullBackups > 0 || details.RetentionPeriod > 0 {
+		return b.resumeRetention(ctx, p, details)
+	}
+
 	kmsEnv := backupencryption.MakeBackupKMSEnv(
 		p.ExecCfg().Settings,
 		&p.ExecCfg().ExternalIODirConfig,
@@ -970,6 +988,23 @@ func (b *backupResumer) Resume(ctx context.Context, execCtx interface{}) error {
 		}
 	}
 
+	// If this is an incremental backup started by a schedule that compacts its
+	// chains, check whether the chain it was appended to is due for compaction.
+	// Failing to start a compaction does not fail the backup.
+	if !backupManifest.StartTime.IsEmpty() {
+		if err := maybeStartScheduledCompaction(ctx, p.ExecCfg(), p.User(), details); err != nil {
+			log.Warningf(ctx, "failed to start compaction of backup %s: %+v", details.Destination.Subdir, err)
+		}
+	} else {
+		// If this is a full backup started by a schedule with a retention policy,
+		// delete the backup chains in its collection that the policy no longer
+		// requires. Failing to start the deletion does not fail the backup.
+		if err := maybeStartScheduledRetention(ctx, p.ExecCfg(), p.User(), details); err != nil {
+			log.Warningf(ctx, "failed to start retention of backups in %s: %+v",
+				details.Destination.Subdir, err)
+		}
+	}
+
 	b.backupStats = res
 
 	// Collect telemetry.
@@ -12,7 +12,10 @@ import (
 	"strings"
 
 	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupbase"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupdest"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupencryption"
 	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupresolver"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuputils"
 	"github.com/cockroachdb/cockroach/pkg/ccl/utilccl"
 	"github.com/cockroachdb/cockroach/pkg/cloud"
 	"github.com/cockroachdb/cockroach/pkg/cloud/cloudprivilege"
@@ -78,12 +81,17 @@ func resolveOptionsForBackupJobDescription(
 		Detached:                        opts.Detached,
 		ExecutionLocality:               opts.ExecutionLocality,
 		UpdatesClusterMonitoringMetrics: opts.UpdatesClusterMonitoringMetrics,
+		Continuous:                      opts.Continuous,
 	}
 
 	if opts.EncryptionPassphrase != nil {
 		newOpts.EncryptionPassphrase = tree.NewDString("redacted")
 	}
 
+	if opts.SigningKMSURI != nil {
+		newOpts.SigningKMSURI = tree.NewDString("redacted")
+	}
+
 	var err error
 	// TODO(msbutler): use cloud.RedactKMSURI(uri) here instead?
 	newOpts.EncryptionKMSURI, err = sanitizeURIList(kmsURIs)
@@ -383,7 +391,10 @@ func backupTypeCheck(
 	if backupStmt == nil {
 		return false, nil, nil
 	}
-	detached := backupStmt.Options.Detached == tree.DBoolTrue
+	// Continuous backups run until they are canceled, so they are always
+	// detached.
+	detached := backupStmt.Options.Detached == tree.DBoolTrue ||
+		backupStmt.Options.Continuous == tree.DBoolTrue
 	if detached {
 		header = jobs.DetachedJobExecutionResultHeader
 	} else {
@@ -395,6 +406,7 @@ func backupTypeCheck(
 			backupStmt.Subdir,
 			backupStmt.Options.EncryptionPassphrase,
 			backupStmt.Options.ExecutionLocality,
+			backupStmt.Options.SigningKMSURI,
 		},
 		exprutil.StringArrays{
 			tree.Exprs(backupStmt.To),
@@ -428,7 +440,8 @@ func backupPlanHook(
 		return nil, nil, nil, false, err
 	}
 
-	detached := backupStmt.Options.Detached == tree.DBoolTrue
+	continuous := backupStmt.Options.Continuous == tree.DBoolTrue
+	detached := backupStmt.Options.Detached == tree.DBoolTrue || continuous
 
 	exprEval := p.ExprEvaluator("BACKUP")
 
@@ -517,6 +530,17 @@ func backupPlanHook(
 		}
 	}
 
+	var signingKMS string
+	if backupStmt.Options.SigningKMSURI != nil {
+		signingKMS, err = exprEval.String(ctx, backupStmt.Options.SigningKMSURI)
+		if err != nil {
+			return nil, nil, nil, false, err
+		}
+		if err = logAndSanitizeKmsURIs(ctx, signingKMS); err != nil {
+			return nil, nil, nil, false, err
+		}
+	}
+
 	var updatesClusterMonitoringMetrics bool
 	if backupStmt.Options.UpdatesClusterMonitoringMetrics != nil {
 		updatesClusterMonitoringMetrics, err = exprEval.Bool(
@@ -551,6 +575,33 @@ func backupPlanHook(
 			return errors.New("the include_all_virtual_clusters option is only supported for full cluster backups")
 		}
 
+		if continuous {
+			if !backupStmt.AppendToLatest {
+				return errors.New("the continuous option is only supported for backups INTO LATEST IN a collection")
+			}
+			if backupStmt.Coverage() != tree.RequestedDescriptors ||
+				(backupStmt.Targets != nil && backupStmt.Targets.TenantID.IsSet()) {
+				return errors.New("the continuous option is not supported for cluster or virtual cluster backups")
+			}
+			for _, unsupported := range []struct {
+				name string
+				set  bool
+			}{
+				{name: "revision_history", set: revisionHistory},
+				{name: "execution locality", set: executionLocality.NonEmpty()},
+				{name: "AS OF SYSTEM TIME", set: backupStmt.AsOf.Expr != nil},
+				{name: "partitioned destinations", set: len(to) > 1},
+				{name: "signing_kms", set: signingKMS != ""},
+			} {
+				if unsupported.set {
+					return errors.Newf("%s is not supported with the continuous option", unsupported.name)
+				}
+			}
+			if err := requireEnterprise(p.ExecCfg(), "continuous backups"); err != nil {
+				return err
+			}
+		}
+
 		var asOfInterval int64
 		endTime := p.ExecCfg().Clock.Now()
 		if backupStmt.AsOf.Expr != nil {
@@ -587,6 +638,12 @@ func backupPlanHook(
 			}
 		}
 
+		if signingKMS != "" {
+			if err := requireEnterprise(p.ExecCfg(), "signed backups"); err != nil {
+				return err
+			}
+		}
+
 		var targetDescs []catalog.Descriptor
 		var completeDBs []descpb.ID
 		var requestedDBs []catalog.DatabaseDescriptor
@@ -644,6 +701,7 @@ func backupPlanHook(
 			ApplicationName:                 p.SessionData().ApplicationName,
 			ExecutionLocality:               executionLocality,
 			UpdatesClusterMonitoringMetrics: updatesClusterMonitoringMetrics,
+			SigningKMSURI:                   signingKMS,
 		}
 		if backupStmt.CreatedByInfo != nil {
 			initialDetails.ScheduleID = backupStmt.CreatedByInfo.ScheduleID()
@@ -678,6 +736,32 @@ func backupPlanHook(
 			initialDetails.Destination.Subdir = endTime.GoTime().Format(backupbase.DateBasedIntoFolderName)
 		}
 
+		// A continuous backup extends the chain that LATEST refers to when it is
+		// planned, even if LATEST later moves on to a new full backup, so resolve
+		// the chain and its encryption options now.
+		if continuous {
+			mkStore := p.ExecCfg().DistSQLSrv.ExternalStorageFromURI
+			latest, err := backupdest.ReadLatestFile(ctx, to[0], mkStore, p.User())
+			if err != nil {
+				return err
+			}
+			initialDetails.Destination.Subdir = "/" + strings.TrimPrefix(latest, "/")
+			baseURIs, err := backuputils.AppendPaths(to, initialDetails.Destination.Subdir)
+			if err != nil {
+				return err
+			}
+			kmsEnv := backupencryption.MakeBackupKMSEnv(
+				p.ExecCfg().Settings, &p.ExecCfg().ExternalIODirConfig, p.ExecCfg().InternalDB, p.User(),
+			)
+			initialDetails.EncryptionOptions, err = backupencryption.GetEncryptionFromBase(
+				ctx, p.User(), mkStore, baseURIs[0], encryptionParams, &kmsEnv,
+			)
+			if err != nil {
+				return err
+			}
+			initialDetails.Continuous = true
+		}
+
 		if backupStmt.Targets != nil && backupStmt.Targets.TenantID.IsSet() {
 			if !p.ExecCfg().Codec.ForSystemTenant() {
 				return pgerror.Newf(pgcode.InsufficientPrivilege, "only the system tenant can backup other tenants")
@@ -0,0 +1,385 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package backupccl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupbase"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupdest"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuppb"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuputils"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/scheduledjobs"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/isql"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+)
+
+// backupFolderNamePrecision is the precision of the names of the directories
+// that full backups are written to, which are formatted from their end times
+// using backupbase.DateBasedIntoFolderName.
+const backupFolderNamePrecision = 10 * time.Millisecond
+
+// resumeRetention runs a backup job that enforces the retention policy of a
+// backup schedule on its collection. It does not back up any data from the
+// cluster, but deletes the backup chains, i.e. full backups along with their
+// incremental backups, that the policy no longer requires.
+//
+// The job is idempotent: if it is resumed it lists the collection again and
+// deletes whatever expired chains remain.
+func (b *backupResumer) resumeRetention(
+	ctx context.Context, p sql.JobExecContext, details jobspb.BackupDetails,
+) error {
+	execCfg := p.ExecCfg()
+	user := p.User()
+	mkStore := execCfg.DistSQLSrv.ExternalStorageFromURI
+
+	defaultURI, _, err := backupdest.GetURIsByLocalityKV(details.Destination.To, "")
+	if err != nil {
+		return err
+	}
+	store, err := mkStore(ctx, defaultURI, user)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+	fulls, err := backupdest.ListFullBackupsInCollection(ctx, store)
+	if err != nil {
+		return err
+	}
+	latest, err := backupdest.ReadLatestFile(ctx, defaultURI, mkStore, user)
+	if err != nil {
+		return err
+	}
+
+	expired := expiredBackupChains(
+		fulls, latest, details.RetainFullBackups, details.RetentionPeriod, details.EndTime.GoTime(),
+	)
+	for _, subdir := range expired {
+		if err := deleteBackupChain(ctx, execCfg, user, details.Destination, subdir); err != nil {
+			return errors.Wrapf(err, "deleting backup %s", subdir)
+		}
+	}
+	return nil
+}
+
+// expiredBackupChains returns the subdirectories of the full backups in fulls
+// whose chains are no longer required by a retention policy, oldest first.
+//
+// Only chains in date-based subdirectories that are older than latest, the
+// chain LATEST points to, are considered; the chain LATEST points to is never
+// expired. A chain expires only once every policy that is set agrees:
+//   - retainFullBackups: it is not one of the newest retainFullBackups chains.
+//   - retentionPeriod: restoring to any time since asOf-retentionPeriod does not
+//     require it, i.e. the next newer full backup ended by that time.
+//
+// Since each chain depends on no other chain, deleting an expired chain never
+// breaks a chain that is retained.
+func expiredBackupChains(
+	fulls []string,
+	latest string,
+	retainFullBackups int64,
+	retentionPeriod time.Duration,
+	asOf time.Time,
+) []string {
+	if retainFullBackups <= 0 && retentionPeriod <= 0 {
+		return nil
+	}
+	latest = "/" + strings.TrimPrefix(latest, "/")
+	if _, err := time.Parse(backupbase.DateBasedIntoFolderName, latest); err != nil {
+		// LATEST points to a chain in a custom subdirectory, so the chains in the
+		// collection cannot be ordered relative to it.
+		return nil
+	}
+
+	// Date-based subdirectories sort by their end times.
+	sorted := make([]string, len(fulls))
+	for i := range fulls {
+		sorted[i] = "/" + strings.TrimPrefix(fulls[i], "/")
+	}
+	sort.Strings(sorted)
+
+	var chains []string
+	var endTimes []time.Time
+	for _, full := range sorted {
+		if full > latest {
+			break
+		}
+		endTime, err := time.Parse(backupbase.DateBasedIntoFolderName, full)
+		if err != nil {
+			continue
+		}
+		chains = append(chains, full)
+		endTimes = append(endTimes, endTime)
+	}
+
+	var expired []string
+	cutoff := asOf.Add(-retentionPeriod)
+	for i := 0; i < len(chains)-1; i++ {
+		if retainFullBackups > 0 && int64(len(chains)-i) <= retainFullBackups {
+			break
+		}
+		if retentionPeriod > 0 && endTimes[i+1].Add(backupFolderNamePrecision).After(cutoff) {
+			break
+		}
+		expired = append(expired, chains[i])
+	}
+	return expired
+}
+
+// deleteBackupChain deletes the backup chain in subdir of the collection in
+// dest, in every locality of a locality-aware backup and in the collection of
+// its incremental backups. The incremental backups are deleted newest first,
+// and before the full backup, so that a deletion that is interrupted leaves a
+// shorter chain that is still valid rather than a chain that is missing
+// layers.
+func deleteBackupChain(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	user username.SQLUsername,
+	dest jobspb.BackupDetails_Destination,
+	subdir string,
+) error {
+	if strings.Trim(subdir, "/") == "" {
+		return errors.AssertionFailedf("cannot delete the root of a backup collection")
+	}
+
+	incDirs, err := backupdest.ResolveIncrementalsBackupLocation(
+		ctx, user, execCfg, dest.IncrementalStorage, dest.To, subdir,
+	)
+	if err != nil {
+		return err
+	}
+	incStore, err := execCfg.DistSQLSrv.ExternalStorageFromURI(ctx, incDirs[0], user)
+	if err != nil {
+		return err
+	}
+	defer incStore.Close()
+	// Every locality of a backup holds the same layers, but only the default
+	// locality holds their manifests, so list the layers from it.
+	layers, err := backupdest.FindPriorBackups(ctx, incStore, backupdest.OmitManifest)
+	if err != nil {
+		return err
+	}
+	for i := len(layers) - 1; i >= 0; i-- {
+		layerDirs, err := backuputils.AppendPaths(incDirs, layers[i])
+		if err != nil {
+			return err
+		}
+		if err := deleteBackupDirs(ctx, execCfg, user, layerDirs); err != nil {
+			return err
+		}
+	}
+
+	baseDirs, err := backuputils.AppendPaths(dest.To, subdir)
+	if err != nil {
+		return err
+	}
+	if err := deleteBackupDirs(ctx, execCfg, user, baseDirs); err != nil {
+		return err
+	}
+	log.Infof(ctx, "deleted backup %s and its %d incremental backups", subdir, len(layers))
+	return nil
+}
+
+// deleteBackupDirs deletes every file of a backup whose localities are in
+// dirs, logging each file it deletes. The manifests and other metadata files
+// of the backup are deleted before its data files, so that it stops being
+// recognized as a backup as soon as possible.
+func deleteBackupDirs(
+	ctx context.Context, execCfg *sql.ExecutorConfig, user username.SQLUsername, dirs []string,
+) error {
+	stores, cleanupFn, err := backupdest.MakeBackupDestinationStores(
+		ctx, user, execCfg.DistSQLSrv.ExternalStorageFromURI, dirs,
+	)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := cleanupFn(); err != nil {
+			log.Warningf(ctx, "failed to close backup store: %+v", err)
+		}
+	}()
+
+	for i, store := range stores {
+		redactedDir, err := cloud.SanitizeExternalStorageURI(dirs[i], nil /* extraParams */)
+		if err != nil {
+			return err
+		}
+		var metadata, data []string
+		if err := store.List(ctx, "", "", func(f string) error {
+			if strings.HasPrefix(strings.TrimPrefix(f, "/"), backupbase.BackupOldManifestName) {
+				metadata = append(metadata, f)
+			} else {
+				data = append(data, f)
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		for _, f := range append(metadata, data...) {
+			if err := store.Delete(ctx, f); err != nil {
+				return errors.Wrapf(err, "deleting %s", f)
+			}
+			log.Infof(ctx, "deleted expired backup file %s/%s", redactedDir, strings.TrimPrefix(f, "/"))
+		}
+	}
+	return nil
+}
+
+// retentionJobDescription returns the description of a job that enforces the
+// retention policy of a backup schedule on the collection in dest.
+func retentionJobDescription(dest jobspb.BackupDetails_Destination) (string, error) {
+	to, err := sanitizeURIList(dest.To)
+	if err != nil {
+		return "", err
+	}
+	var collection string
+	if len(to) == 1 {
+		collection = tree.AsString(to[0])
+	} else {
+		collection = tree.AsString(&tree.Tuple{Exprs: to})
+	}
+	return fmt.Sprintf("BACKUP RETENTION IN %s", collection), nil
+}
+
+func createRetentionJob(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	txn isql.Txn,
+	user username.SQLUsername,
+	details jobspb.BackupDetails,
+) (jobspb.JobID, error) {
+	description, err := retentionJobDescription(details.Destination)
+	if err != nil {
+		return 0, err
+	}
+	jobID := execCfg.JobRegistry.MakeJobID()
+	jr := jobs.Record{
+		Description: description,
+		Details:     details,
+		Progress:    jobspb.BackupProgress{},
+		Username:    user,
+	}
+	if _, err := execCfg.JobRegistry.CreateAdoptableJobWithTxn(ctx, jr, jobID, txn); err != nil {
+		return 0, err
+	}
+	return jobID, nil
+}
+
+// maybeStartScheduledRetention starts a job to enforce the retention policy of
+// the schedule that started the full backup described by details, if the
+// schedule has one. The collection and incremental_location of the backups to
+// retain are taken from the backup statements of the schedule and its
+// dependent incremental schedule, and the retention period is measured back
+// from the end time of the full backup.
+func maybeStartScheduledRetention(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	user username.SQLUsername,
+	details jobspb.BackupDetails,
+) error {
+	if details.ScheduleID == 0 {
+		return nil
+	}
+	env := scheduledjobs.ProdJobSchedulerEnv
+	if knobs := execCfg.JobsKnobs(); knobs != nil && knobs.JobSchedulerEnv != nil {
+		env = knobs.JobSchedulerEnv
+	}
+
+	return execCfg.InternalDB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		sj, args, err := getScheduledBackupExecutionArgsFromSchedule(
+			ctx, env, jobs.ScheduledJobTxn(txn), details.ScheduleID,
+		)
+		if err != nil {
+			return err
+		}
+		if args.RetainFullBackups <= 0 && args.RetentionPeriod <= 0 {
+			return nil
+		}
+		dest, err := scheduledBackupDestination(ctx, env, txn, sj, args)
+		if err != nil {
+			return err
+		}
+		jobID, err := createRetentionJob(ctx, execCfg, txn, user, jobspb.BackupDetails{
+			Destination:       dest,
+			EndTime:           details.EndTime,
+			RetainFullBackups: args.RetainFullBackups,
+			RetentionPeriod:   args.RetentionPeriod,
+		})
+		if err != nil {
+			return err
+		}
+		log.Infof(ctx, "started job %d to enforce the retention policy of schedule %d",
+			jobID, details.ScheduleID)
+		return nil
+	})
+}
+
+// scheduledBackupDestination returns the collection that the backups of the
+// full backup schedule sj are written to, along with the incremental_location
+// of its dependent incremental schedule, if it has one.
+func scheduledBackupDestination(
+	ctx context.Context,
+	env scheduledjobs.JobSchedulerEnv,
+	txn isql.Txn,
+	sj *jobs.ScheduledJob,
+	args *backuppb.ScheduledBackupExecutionArgs,
+) (jobspb.BackupDetails_Destination, error) {
+	var dest jobspb.BackupDetails_Destination
+	fullStmt, err := extractBackupStatement(sj)
+	if err != nil {
+		return dest, err
+	}
+	if dest.To, err = scheduledBackupURIs(fullStmt.To); err != nil {
+		return dest, err
+	}
+
+	if args.DependentScheduleID == jobspb.InvalidScheduleID {
+		return dest, nil
+	}
+	incSchedule, _, err := getScheduledBackupExecutionArgsFromSchedule(
+		ctx, env, jobs.ScheduledJobTxn(txn), args.DependentScheduleID,
+	)
+	if err != nil {
+		if jobs.HasScheduledJobNotFoundError(err) {
+			return dest, nil
+		}
+		return dest, err
+	}
+	incStmt, err := extractBackupStatement(incSchedule)
+	if err != nil {
+		return dest, err
+	}
+	if dest.IncrementalStorage, err = scheduledBackupURIs(incStmt.Options.IncrementalStorage); err != nil {
+		return dest, err
+	}
+	return dest, nil
+}
+
+// scheduledBackupURIs returns the URIs in exprs, which are the string literals
+// of a backup statement stored in a schedule.
+func scheduledBackupURIs(exprs tree.StringOrPlaceholderOptList) ([]string, error) {
+	var uris []string
+	for _, expr := range exprs {
+		uri, ok := expr.(*tree.StrVal)
+		if !ok {
+			return nil, errors.Errorf("unexpected %T destination in backup statement", expr)
+		}
+		uris = append(uris, uri.RawString())
+	}
+	return uris, nil
+}
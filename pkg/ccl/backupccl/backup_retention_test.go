@@ -0,0 +1,158 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package backupccl
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/isql"
+	"github.com/cockroachdb/cockroach/pkg/testutils/jobutils"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpiredBackupChains(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	fulls := []string{
+		"/2024/01/01-000000.00",
+		"/2024/01/08-000000.00",
+		"/2024/01/15-000000.00",
+		"/2024/01/22-000000.00",
+		"/2024/01/29-000000.00",
+	}
+	asOf := time.Date(2024, 1, 29, 0, 0, 0, 0, time.UTC)
+	const day = 24 * time.Hour
+
+	for _, tc := range []struct {
+		name     string
+		fulls    []string
+		latest   string
+		retain   int64
+		period   time.Duration
+		asOf     time.Time
+		expected []string
+	}{
+		{name: "no policy", fulls: fulls, latest: fulls[4], asOf: asOf},
+		{name: "retain all", fulls: fulls, latest: fulls[4], retain: 5, asOf: asOf},
+		{name: "retain two", fulls: fulls, latest: fulls[4], retain: 2, asOf: asOf,
+			expected: fulls[:3]},
+		{name: "retain one", fulls: fulls, latest: fulls[4], retain: 1, asOf: asOf,
+			expected: fulls[:4]},
+		// Restoring to 10 days ago requires the chain of the full backup taken
+		// 14 days ago.
+		{name: "period", fulls: fulls, latest: fulls[4], period: 10 * day, asOf: asOf,
+			expected: fulls[:2]},
+		// Restoring to exactly 14 days ago only requires the full backup taken
+		// then.
+		{name: "period on boundary", fulls: fulls, latest: fulls[4], period: 14 * day,
+			asOf: asOf.Add(backupFolderNamePrecision), expected: fulls[:2]},
+		{name: "period and retain", fulls: fulls, latest: fulls[4], retain: 4, period: 10 * day,
+			asOf: asOf, expected: fulls[:1]},
+		// Chains newer than the one LATEST points to are never deleted, nor
+		// counted towards those retained.
+		{name: "newer than latest", fulls: fulls, latest: fulls[2], retain: 1, asOf: asOf,
+			expected: fulls[:2]},
+		{name: "unordered", fulls: []string{fulls[3], fulls[0], "2024/01/08-000000.00"},
+			latest: "2024/01/22-000000.00", retain: 1, asOf: asOf, expected: fulls[:2]},
+		{name: "custom subdirs", fulls: append([]string{"/a/b/c"}, fulls...), latest: fulls[4],
+			retain: 4, asOf: asOf, expected: fulls[:1]},
+		{name: "custom latest", fulls: append([]string{"/a/b/c"}, fulls...), latest: "/a/b/c",
+			retain: 1, asOf: asOf},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected,
+				expiredBackupChains(tc.fulls, tc.latest, tc.retain, tc.period, tc.asOf))
+		})
+	}
+}
+
+// TestBackupRetention tests that a job that enforces the retention policy of a
+// backup schedule deletes expired backup chains, including their incremental
+// backups, and that the retention policy is a schedule option.
+func TestBackupRetention(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	const numAccounts = 10
+	tc, sqlDB, _, cleanupFn := backupRestoreTestSetup(t, singleNode, numAccounts, InitManualReplication)
+	defer cleanupFn()
+	execCfg := tc.Server(0).ExecutorConfig().(sql.ExecutorConfig)
+
+	const localInc = "nodelocal://1/inc"
+	for i := 0; i < 3; i++ {
+		sqlDB.Exec(t, `BACKUP DATABASE data INTO $1`, localFoo)
+		sqlDB.Exec(t, `UPDATE data.bank SET balance = balance + 1 WHERE id < 5`)
+		sqlDB.Exec(t, `BACKUP DATABASE data INTO LATEST IN $1 WITH incremental_location = $2`,
+			localFoo, localInc)
+	}
+	fulls := getFullBackupPaths(t, sqlDB, localFoo)
+	require.Len(t, fulls, 3)
+	expected := sqlDB.QueryStr(t, `SELECT * FROM data.bank ORDER BY id`)
+
+	// Retain the two newest of the three backup chains.
+	var jobID jobspb.JobID
+	require.NoError(t, execCfg.InternalDB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		var err error
+		jobID, err = createRetentionJob(ctx, &execCfg, txn, username.RootUserName(), jobspb.BackupDetails{
+			Destination: jobspb.BackupDetails_Destination{
+				To:                 []string{localFoo},
+				IncrementalStorage: []string{localInc},
+			},
+			EndTime:           hlc.Timestamp{WallTime: timeutil.Now().UnixNano()},
+			RetainFullBackups: 2,
+		})
+		return err
+	}))
+	jobutils.WaitForJobToSucceed(t, sqlDB, jobID)
+
+	require.Equal(t, fulls[1:], getFullBackupPaths(t, sqlDB, localFoo))
+	for _, uri := range []string{localFoo + fulls[0], localInc + fulls[0]} {
+		store, err := execCfg.DistSQLSrv.ExternalStorageFromURI(ctx, uri, username.RootUserName())
+		require.NoError(t, err)
+		require.NoError(t, store.List(ctx, "", "", func(f string) error {
+			t.Errorf("expected %s to be deleted from %s", f, uri)
+			return nil
+		}))
+		require.NoError(t, store.Close())
+	}
+	sqlDB.Exec(t, `RESTORE DATABASE data FROM LATEST IN $1 WITH incremental_location = $2,
+new_db_name = restored`, localFoo, localInc)
+	sqlDB.CheckQueryResults(t, `SELECT * FROM restored.bank ORDER BY id`, expected)
+
+	t.Run("schedule options", func(t *testing.T) {
+		rows := sqlDB.QueryStr(t, `CREATE SCHEDULE FOR BACKUP DATABASE data INTO 'nodelocal://1/sched'
+RECURRING '@hourly' FULL BACKUP '@daily'
+WITH SCHEDULE OPTIONS first_run = '2100-01-01', retain_full_backups = 2, retention_period = '30 days'`)
+		require.Len(t, rows, 2)
+		for _, row := range rows {
+			createStmt := sqlDB.QueryStr(t, fmt.Sprintf(`SHOW CREATE SCHEDULE %s`, row[0]))[0][1]
+			require.Contains(t, createStmt, "retain_full_backups = '2'")
+			require.Contains(t, createStmt, "retention_period = '30 days'")
+		}
+
+		sqlDB.Exec(t, fmt.Sprintf(`ALTER BACKUP SCHEDULE %s SET SCHEDULE OPTION retain_full_backups = 3`,
+			rows[0][0]))
+		createStmt := sqlDB.QueryStr(t, fmt.Sprintf(`SHOW CREATE SCHEDULE %s`, rows[0][0]))[0][1]
+		require.Contains(t, createStmt, "retain_full_backups = '3'")
+
+		sqlDB.ExpectErr(t, "retain_full_backups must not be negative",
+			fmt.Sprintf(`ALTER BACKUP SCHEDULE %s SET SCHEDULE OPTION retain_full_backups = -1`, rows[0][0]))
+		sqlDB.ExpectErr(t, "unexpected value for retention_period",
+			fmt.Sprintf(`ALTER BACKUP SCHEDULE %s SET SCHEDULE OPTION retention_period = 'forever'`, rows[0][0]))
+	})
+}
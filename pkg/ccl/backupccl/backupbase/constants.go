@@ -49,10 +49,18 @@ const (
 	// each of those elided fields.
 	BackupMetadataName = "BACKUP_METADATA"
 
+	// BackupManifestSignatureName is the file name used for the serialized
+	// BackupManifestSignature of the BackupManifest of a signed backup.
+	BackupManifestSignatureName = "BACKUP_MANIFEST-SIGNATURE"
+
 	// DefaultIncrementalsSubdir is the default name of the subdirectory to which
 	// incremental backups will be written.
 	DefaultIncrementalsSubdir = "incrementals"
 
+	// ContinuousBackupLogDirectory is the name of the subdirectory of a full
+	// backup to which continuous backups of its chain write their logs.
+	ContinuousBackupLogDirectory = "log"
+
 	// ListingDelimDataSlash is used when listing to find backups/backup metadata
 	// and groups all the data sst files in each backup, which start with "data/",
 	// into a single result that can be skipped over quickly.
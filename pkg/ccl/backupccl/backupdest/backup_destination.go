@@ -607,6 +607,22 @@ func ResolveBackupManifests(
 		}
 	}
 
+	// If the requested time is after the end of the last layer of the chain, it
+	// may instead be covered by the log of a continuous backup of the chain.
+	if last := mainBackupManifests[numLayers-1]; !endTime.IsEmpty() && last.EndTime.Less(endTime) {
+		logURIs, logManifests, memSize, err := findContinuousBackupLog(ctx, mem, user, mkStore,
+			fullyResolvedBaseDirectory[0], mainBackupManifests, endTime, encryption, kmsEnv)
+		if err != nil {
+			return nil, nil, nil, 0, err
+		}
+		ownedMemSize += memSize
+		defaultURIs = append(defaultURIs, logURIs...)
+		mainBackupManifests = append(mainBackupManifests, logManifests...)
+		for range logURIs {
+			localityInfo = append(localityInfo, jobspb.RestoreDetails_BackupLocalityInfo{})
+		}
+	}
+
 	totalMemSize := ownedMemSize
 	ownedMemSize = 0
 
@@ -618,3 +634,76 @@ func ResolveBackupManifests(
 	}
 	return validatedDefaultURIs, validatedMainBackupManifests, validatedLocalityInfo, totalMemSize, nil
 }
+
+// findContinuousBackupLog looks for a log written by a continuous backup of the
+// chain whose full backup is at baseURI that starts at the end of one of the
+// layers of the chain and extends it to at least endTime. A log is a sequence
+// of layers, each of which starts where the previous one ends. If one is found,
+// the URIs and manifests of its layers up to the one that covers endTime are
+// returned along with the memory reserved for the manifests.
+func findContinuousBackupLog(
+	ctx context.Context,
+	mem *mon.BoundAccount,
+	user username.SQLUsername,
+	mkStore cloud.ExternalStorageFromURIFactory,
+	baseURI string,
+	chain []backuppb.BackupManifest,
+	endTime hlc.Timestamp,
+	encryption *jobspb.BackupEncryptionOptions,
+	kmsEnv cloud.KMSEnv,
+) (_ []string, _ []backuppb.BackupManifest, memSize int64, _ error) {
+	logDirURI, err := url.Parse(baseURI)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	logDirURI.Path = backuputils.JoinURLPath(logDirURI.Path, backupbase.ContinuousBackupLogDirectory)
+
+	logStore, err := mkStore(ctx, logDirURI.String(), user)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	defer logStore.Close()
+
+	// Layers are laid out like incremental backups, in subdirectories named
+	// after their start time.
+	layers, err := FindPriorBackups(ctx, logStore, OmitManifest)
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	var uris []string
+	var manifests []backuppb.BackupManifest
+	// Walk back from the most recently started layer that covers endTime to
+	// a layer that starts at the end of the chain. Empty layers, which a
+	// continuous backup leaves behind if it fails while rolling over to a new
+	// layer, are skipped.
+	for i := len(layers) - 1; i >= 0; i-- {
+		u := *logDirURI
+		u.Path = backuputils.JoinURLPath(u.Path, layers[i])
+		manifest, size, err := backupinfo.ReadBackupManifestFromURI(ctx, mem, u.String(), user,
+			mkStore, encryption, kmsEnv)
+		if err != nil {
+			mem.Shrink(ctx, memSize)
+			return nil, nil, 0, err
+		}
+		extends := manifest.StartTime.Less(manifest.EndTime)
+		if len(manifests) == 0 {
+			extends = extends && endTime.LessEq(manifest.EndTime)
+		} else {
+			extends = extends && manifest.EndTime.Equal(manifests[0].StartTime)
+		}
+		if !extends {
+			mem.Shrink(ctx, size)
+			continue
+		}
+		memSize += size
+		uris = append([]string{u.String()}, uris...)
+		manifests = append([]backuppb.BackupManifest{manifest}, manifests...)
+		for _, layer := range chain {
+			if layer.EndTime.Equal(manifest.StartTime) {
+				return uris, manifests, memSize, nil
+			}
+		}
+	}
+	mem.Shrink(ctx, memSize)
+	return nil, nil, 0, nil
+}
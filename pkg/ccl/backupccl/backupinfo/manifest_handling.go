@@ -309,6 +309,19 @@ func ReadBackupManifest(
 	return readManifest(ctx, mem, encryption, kmsEnv, manifestFile, checksumFile)
 }
 
+// DecodeBackupManifest decodes the contents of a manifest file, as written by
+// WriteBackupManifest.
+func DecodeBackupManifest(
+	ctx context.Context,
+	encryption *jobspb.BackupEncryptionOptions,
+	kmsEnv cloud.KMSEnv,
+	descBytes []byte,
+) (backuppb.BackupManifest, error) {
+	manifest, _, err := readManifest(ctx, nil /* mem */, encryption, kmsEnv,
+		ioctx.NopCloser(ioctx.ReaderAdapter(bytes.NewReader(descBytes))), nil /* checksumReader */)
+	return manifest, err
+}
+
 // readManifest reads and unmarshals a BackupManifest from filename in the
 // provided export store. If the passed bound account is not nil, the bytes read
 // are reserved from it as it is read and then the approximate in-memory size
@@ -652,29 +665,11 @@ func WriteBackupManifest(
 	ctx, sp := tracing.ChildSpan(ctx, "backupinfo.WriteBackupManifest")
 	defer sp.Finish()
 
-	sort.Sort(BackupFileDescriptors(desc.Files))
-
-	descBuf, err := protoutil.Marshal(desc)
+	descBuf, err := encodeBackupManifest(ctx, encryption, kmsEnv, desc)
 	if err != nil {
 		return err
 	}
 
-	descBuf, err = compressData(descBuf)
-	if err != nil {
-		return errors.Wrap(err, "compressing backup manifest")
-	}
-
-	if encryption != nil {
-		encryptionKey, err := backupencryption.GetEncryptionKey(ctx, encryption, kmsEnv)
-		if err != nil {
-			return err
-		}
-		descBuf, err = storageccl.EncryptFile(descBuf, encryptionKey)
-		if err != nil {
-			return err
-		}
-	}
-
 	if err := cloud.WriteFile(ctx, exportStore, filename, bytes.NewReader(descBuf)); err != nil {
 		return err
 	}
@@ -693,6 +688,61 @@ func WriteBackupManifest(
 	return nil
 }
 
+// WriteBackupManifestWithoutChecksum is like WriteBackupManifest, but does not
+// write a checksum file alongside the manifest. It is used for manifests that
+// are repeatedly overwritten in place, where a reader could otherwise observe
+// a new manifest alongside the checksum of a previous one.
+func WriteBackupManifestWithoutChecksum(
+	ctx context.Context,
+	exportStore cloud.ExternalStorage,
+	filename string,
+	encryption *jobspb.BackupEncryptionOptions,
+	kmsEnv cloud.KMSEnv,
+	desc *backuppb.BackupManifest,
+) error {
+	ctx, sp := tracing.ChildSpan(ctx, "backupinfo.WriteBackupManifestWithoutChecksum")
+	defer sp.Finish()
+
+	descBuf, err := encodeBackupManifest(ctx, encryption, kmsEnv, desc)
+	if err != nil {
+		return err
+	}
+	return cloud.WriteFile(ctx, exportStore, filename, bytes.NewReader(descBuf))
+}
+
+// encodeBackupManifest sorts the files of desc and returns it marshaled,
+// compressed and, if encryption is set, encrypted.
+func encodeBackupManifest(
+	ctx context.Context,
+	encryption *jobspb.BackupEncryptionOptions,
+	kmsEnv cloud.KMSEnv,
+	desc *backuppb.BackupManifest,
+) ([]byte, error) {
+	sort.Sort(BackupFileDescriptors(desc.Files))
+
+	descBuf, err := protoutil.Marshal(desc)
+	if err != nil {
+		return nil, err
+	}
+
+	descBuf, err = compressData(descBuf)
+	if err != nil {
+		return nil, errors.Wrap(err, "compressing backup manifest")
+	}
+
+	if encryption != nil {
+		encryptionKey, err := backupencryption.GetEncryptionKey(ctx, encryption, kmsEnv)
+		if err != nil {
+			return nil, err
+		}
+		descBuf, err = storageccl.EncryptFile(descBuf, encryptionKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return descBuf, nil
+}
+
 // GetChecksum returns a 32 bit keyed-checksum for the given data.
 func GetChecksum(data []byte) ([]byte, error) {
 	const checksumSizeBytes = 4
@@ -8,6 +8,8 @@ package backupccl
 import (
 	"context"
 	"fmt"
+	"math"
+	"strconv"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupdest"
@@ -31,6 +33,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/log/eventpb"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/cockroachdb/errors"
@@ -46,6 +49,9 @@ const (
 	optOnPreviousRunning       = "on_previous_running"
 	optIgnoreExistingBackups   = "ignore_existing_backups"
 	optUpdatesLastBackupMetric = "updates_cluster_last_backup_time_metric"
+	optCompactionThreshold     = "compaction_threshold"
+	optRetainFullBackups       = "retain_full_backups"
+	optRetentionPeriod         = "retention_period"
 )
 
 var scheduledBackupOptionExpectValues = map[string]exprutil.KVStringOptValidate{
@@ -54,6 +60,9 @@ var scheduledBackupOptionExpectValues = map[string]exprutil.KVStringOptValidate{
 	optOnPreviousRunning:       exprutil.KVStringOptRequireValue,
 	optIgnoreExistingBackups:   exprutil.KVStringOptRequireNoValue,
 	optUpdatesLastBackupMetric: exprutil.KVStringOptRequireNoValue,
+	optCompactionThreshold:     exprutil.KVStringOptRequireValue,
+	optRetainFullBackups:       exprutil.KVStringOptRequireValue,
+	optRetentionPeriod:         exprutil.KVStringOptRequireValue,
 }
 
 // scheduledBackupGCProtectionEnabled is used to enable and disable the chaining
@@ -113,6 +122,49 @@ func makeScheduleDetails(
 	return details, nil
 }
 
+// parseCompactionThreshold parses the value of the compaction_threshold
+// schedule option. A threshold of 0 disables compaction.
+func parseCompactionThreshold(v string) (int64, error) {
+	threshold, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "unexpected value for %s: %s", optCompactionThreshold, v)
+	}
+	if threshold < 0 {
+		return 0, errors.Newf("%s must not be negative", optCompactionThreshold)
+	}
+	return threshold, nil
+}
+
+// parseRetainFullBackups parses the value of the retain_full_backups schedule
+// option. A value of 0 retains every full backup.
+func parseRetainFullBackups(v string) (int64, error) {
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, errors.Wrapf(err, "unexpected value for %s: %s", optRetainFullBackups, v)
+	}
+	if n < 0 {
+		return 0, errors.Newf("%s must not be negative", optRetainFullBackups)
+	}
+	return n, nil
+}
+
+// parseRetentionPeriod parses the interval value of the retention_period
+// schedule option, e.g. '30 days'. A period of 0 disables time-based retention.
+func parseRetentionPeriod(v string) (time.Duration, error) {
+	d, err := tree.ParseDInterval(duration.IntervalStyle_POSTGRES, v)
+	if err != nil {
+		return 0, errors.Wrapf(err, "unexpected value for %s: %s", optRetentionPeriod, v)
+	}
+	secs, ok := d.Duration.AsInt64()
+	if !ok || secs > int64(math.MaxInt64/time.Second) {
+		return 0, errors.Newf("%s is too large: %s", optRetentionPeriod, v)
+	}
+	if secs < 0 {
+		return 0, errors.Newf("%s must not be negative", optRetentionPeriod)
+	}
+	return time.Duration(secs) * time.Second, nil
+}
+
 func scheduleFirstRun(evalCtx *eval.Context, opts map[string]string) (*time.Time, error) {
 	if v, ok := opts[optFirstRun]; ok {
 		firstRun, _, err := tree.ParseDTimestampTZ(evalCtx, v, time.Microsecond)
@@ -302,6 +354,35 @@ func doCreateBackupSchedules(
 		}
 	}
 
+	var compactionThreshold int64
+	if v, ok := scheduleOptions[optCompactionThreshold]; ok {
+		if compactionThreshold, err = parseCompactionThreshold(v); err != nil {
+			return err
+		}
+		if compactionThreshold > 0 {
+			if incRecurrence == nil {
+				return errors.Newf("%s requires a schedule that runs incremental backups",
+					optCompactionThreshold)
+			}
+			if eval.captureRevisionHistory != nil && *eval.captureRevisionHistory {
+				return errors.Newf("%s cannot be used with revision_history", optCompactionThreshold)
+			}
+		}
+	}
+
+	var retainFullBackups int64
+	if v, ok := scheduleOptions[optRetainFullBackups]; ok {
+		if retainFullBackups, err = parseRetainFullBackups(v); err != nil {
+			return err
+		}
+	}
+	var retentionPeriod time.Duration
+	if v, ok := scheduleOptions[optRetentionPeriod]; ok {
+		if retentionPeriod, err = parseRetentionPeriod(v); err != nil {
+			return err
+		}
+	}
+
 	evalCtx := &p.ExtendedEvalContext().Context
 	firstRun, err := scheduleFirstRun(evalCtx, scheduleOptions)
 	if err != nil {
@@ -349,7 +430,8 @@ func doCreateBackupSchedules(
 		}
 		inc, incScheduledBackupArgs, err = makeBackupSchedule(
 			env, p.User(), scheduleLabel, incRecurrence, incrementalScheduleDetails, unpauseOnSuccessID,
-			updateMetricOnSuccess, backupNode, chainProtectedTimestampRecords)
+			updateMetricOnSuccess, backupNode, chainProtectedTimestampRecords,
+			backupScheduleMaintenance{compactionThreshold: compactionThreshold})
 		if err != nil {
 			return err
 		}
@@ -372,7 +454,11 @@ func doCreateBackupSchedules(
 	var fullScheduledBackupArgs *backuppb.ScheduledBackupExecutionArgs
 	full, fullScheduledBackupArgs, err := makeBackupSchedule(
 		env, p.User(), scheduleLabel, fullRecurrence, details, unpauseOnSuccessID,
-		updateMetricOnSuccess, backupNode, chainProtectedTimestampRecords)
+		updateMetricOnSuccess, backupNode, chainProtectedTimestampRecords,
+		backupScheduleMaintenance{
+			retainFullBackups: retainFullBackups,
+			retentionPeriod:   retentionPeriod,
+		})
 	if err != nil {
 		return err
 	}
@@ -468,6 +554,15 @@ func checkForExistingBackupsInCollection(
 	return nil
 }
 
+// backupScheduleMaintenance holds the options of a backup schedule that
+// maintain the backups it writes: compaction, which applies to incremental
+// schedules, and retention, which applies to full schedules.
+type backupScheduleMaintenance struct {
+	compactionThreshold int64
+	retainFullBackups   int64
+	retentionPeriod     time.Duration
+}
+
 func makeBackupSchedule(
 	env scheduledjobs.JobSchedulerEnv,
 	owner username.SQLUsername,
@@ -478,6 +573,7 @@ func makeBackupSchedule(
 	updateLastMetricOnSuccess bool,
 	backupNode *tree.Backup,
 	chainProtectedTimestampRecords bool,
+	maintenance backupScheduleMaintenance,
 ) (*jobs.ScheduledJob, *backuppb.ScheduledBackupExecutionArgs, error) {
 	sj := jobs.NewScheduledJob(env)
 	sj.SetScheduleLabel(label)
@@ -488,6 +584,9 @@ func makeBackupSchedule(
 		UnpauseOnSuccess:               unpauseOnSuccess,
 		UpdatesLastBackupMetric:        updateLastMetricOnSuccess,
 		ChainProtectedTimestampRecords: chainProtectedTimestampRecords,
+		CompactionThreshold:            maintenance.compactionThreshold,
+		RetainFullBackups:              maintenance.retainFullBackups,
+		RetentionPeriod:                maintenance.retentionPeriod,
 	}
 	if backupNode.AppendToLatest {
 		args.BackupType = backuppb.ScheduledBackupExecutionArgs_INCREMENTAL
@@ -8,7 +8,9 @@ package backupccl
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
 	"fmt"
+	"hash"
 	io "io"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
@@ -47,6 +49,9 @@ type fileSSTSink struct {
 	cancel  func()
 	out     io.WriteCloser
 	outName string
+	// outHash hashes the bytes written to out, as they are written to external
+	// storage.
+	outHash hash.Hash
 
 	flushedFiles []backuppb.BackupManifest_File
 	flushedSize  int64
@@ -149,11 +154,13 @@ func (s *fileSSTSink) flushFile(ctx context.Context) error {
 		return errors.Wrap(err, "writing SST")
 	}
 	wroteSize := s.sst.Meta.Size
+	contentHash := s.outHash.Sum(nil)
 	s.outName = ""
 	s.out = nil
 
 	for i := range s.flushedFiles {
 		s.flushedFiles[i].BackingFileSize = wroteSize
+		s.flushedFiles[i].ContentHash = contentHash
 	}
 
 	progDetails := backuppb.BackupManifest_Progress{
@@ -191,6 +198,8 @@ func (s *fileSSTSink) open(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+	s.outHash = sha256.New()
+	w = hashingWriteCloser{WriteCloser: w, hash: s.outHash}
 	s.out = w
 	if s.conf.enc != nil {
 		e, err := storageccl.EncryptingWriter(w, s.conf.enc.Key)
@@ -493,6 +502,19 @@ func (s *fileSSTSink) copyRangeKeys(dataSST []byte) (roachpb.Key, error) {
 	return maxKey, nil
 }
 
+// hashingWriteCloser is an io.WriteCloser that hashes the bytes written
+// through it.
+type hashingWriteCloser struct {
+	io.WriteCloser
+	hash hash.Hash
+}
+
+func (w hashingWriteCloser) Write(p []byte) (int, error) {
+	n, err := w.WriteCloser.Write(p)
+	_, _ = w.hash.Write(p[:n])
+	return n, err
+}
+
 func generateUniqueSSTName(nodeID base.SQLInstanceID) string {
 	// The data/ prefix, including a /, is intended to group SSTs in most of the
 	// common file/bucket browse UIs.
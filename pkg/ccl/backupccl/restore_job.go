@@ -2366,6 +2366,17 @@ func (r *restoreResumer) publishDescriptors(
 		tableAutoStatsSettings = make(map[uint32]*catpb.AutoStatsSettings, len(details.TableDescs))
 	}
 
+	// A read only restore creates a new database whose tables are never
+	// downloaded: they are made read-only by pointing their external row data
+	// at themselves, as of a timestamp after all of the backup's files were
+	// linked, so that KV keeps serving their rows from the linked files in
+	// external storage. All of them share the same timestamp so that they can
+	// be queried together in one transaction.
+	var externalRowDataAsOf hlc.Timestamp
+	if details.ExperimentalReadOnly {
+		externalRowDataAsOf = txn.KV().ReadTimestamp()
+	}
+
 	// Write the new TableDescriptors and flip state over to public so they can be
 	// accessed.
 	for i := range details.TableDescs {
@@ -2382,6 +2393,14 @@ func (r *restoreResumer) publishDescriptors(
 			tableAutoStatsSettings[uint32(details.TableDescs[i].ID)] = details.TableDescs[i].AutoStatsSettings
 		}
 
+		if details.ExperimentalReadOnly && mutTable.IsTable() {
+			mutTable.SetExternalRowData(&descpb.ExternalRowData{
+				TenantID: r.execCfg.Codec.TenantID,
+				TableID:  mutTable.GetID(),
+				AsOf:     externalRowDataAsOf,
+			})
+		}
+
 		// Note that we don't need to worry about the re-validated indexes for descriptors
 		// with a declarative schema change job.
 		if mutTable.GetDeclarativeSchemaChangerState() != nil {
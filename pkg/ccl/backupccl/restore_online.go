@@ -575,6 +575,12 @@ func (r *restoreResumer) maybeWriteDownloadJob(
 	if !details.ExperimentalOnline {
 		return nil
 	}
+	if details.ExperimentalReadOnly {
+		// Read only restores leave the linked files in external storage, so
+		// there is nothing to download.
+		log.Infof(ctx, "skipping download job for read only restore")
+		return nil
+	}
 	rekey := mainRestoreData.getRekeys()
 	rekey = append(rekey, preRestoreData.getRekeys()...)
 
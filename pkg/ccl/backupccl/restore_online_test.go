@@ -9,6 +9,7 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -183,6 +184,73 @@ func TestOnlineRestoreStatementResult(t *testing.T) {
 	}
 }
 
+// TestOnlineRestoreReadOnly checks that a read only restore into a new database
+// leaves the backup's data in external storage and serves queries against the
+// restored tables from it.
+func TestOnlineRestoreReadOnly(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	defer nodelocal.ReplaceNodeLocalForTesting(t.TempDir())()
+
+	const numAccounts = 100
+	_, sqlDB, _, cleanupFn := backupRestoreTestSetupWithParams(
+		t,
+		singleNode,
+		numAccounts,
+		InitManualReplication,
+		base.TestClusterArgs{
+			ServerArgs: base.TestServerArgs{
+				DefaultTestTenant: base.TestIsSpecificToStorageLayerAndNeedsASystemTenant,
+			},
+		},
+	)
+	defer cleanupFn()
+
+	sqlDB.Exec(t, "BACKUP DATABASE data INTO $1", localFoo)
+	expected := sqlDB.QueryStr(t, "SELECT * FROM data.bank ORDER BY id")
+	sqlDB.Exec(t, "UPDATE data.bank SET balance = balance + 1 WHERE true")
+
+	var id, tables, approxRows, approxBytes, downloadJobID int64
+	sqlDB.QueryRow(t,
+		"RESTORE DATABASE data FROM LATEST IN $1 WITH OPTIONS (new_db_name='data2', experimental deferred copy, experimental read only)",
+		localFoo,
+	).Scan(&id, &tables, &approxRows, &approxBytes, &downloadJobID)
+	require.Equal(t, int64(0), downloadJobID, "read only restore should not create a download job")
+
+	sqlDB.CheckQueryResults(t, "SELECT * FROM data2.bank ORDER BY id", expected)
+	sqlDB.CheckQueryResults(t,
+		"SELECT count(*) FROM data2.bank JOIN data.bank USING (id) WHERE data2.bank.balance <> data.bank.balance",
+		[][]string{{strconv.Itoa(numAccounts)}},
+	)
+	var externalBytes int64
+	sqlDB.QueryRow(t, jobutils.GetExternalBytesForConnectedTenant).Scan(&externalBytes)
+	require.Greater(t, externalBytes, int64(0), "read only restore should not download the backup")
+
+	sqlDB.ExpectErr(t, "cannot mutate", "INSERT INTO data2.bank VALUES (-1, 0, '')")
+	sqlDB.ExpectErr(t, "cannot mutate", "DELETE FROM data2.bank WHERE true")
+
+	t.Run("errors", func(t *testing.T) {
+		sqlDB.ExpectErr(t, "experimental read only can only be used with experimental deferred copy",
+			"RESTORE DATABASE data FROM LATEST IN $1 WITH OPTIONS (new_db_name='data3', experimental read only)",
+			localFoo)
+		sqlDB.ExpectErr(t, "experimental read only requires new_db_name",
+			"RESTORE DATABASE data FROM LATEST IN $1 WITH OPTIONS (experimental deferred copy, experimental read only)",
+			localFoo)
+		sqlDB.ExpectErr(t, "experimental read only requires new_db_name",
+			"RESTORE TABLE data.bank FROM LATEST IN $1 WITH OPTIONS (into_db='data2', experimental deferred copy, experimental read only)",
+			localFoo)
+		sqlDB.ExpectErr(t, "experimental read only cannot be used to restore a cluster or virtual cluster",
+			"RESTORE FROM LATEST IN $1 WITH OPTIONS (experimental deferred copy, experimental read only)",
+			localFoo)
+		sqlDB.ExpectErr(t, "experimental read only cannot be used to restore a cluster or virtual cluster",
+			"RESTORE VIRTUAL CLUSTER 10 FROM LATEST IN $1 WITH OPTIONS (experimental deferred copy, experimental read only)",
+			localFoo)
+		sqlDB.ExpectErr(t, "cannot run a read only restore with schema_only",
+			"RESTORE DATABASE data FROM LATEST IN $1 WITH OPTIONS (new_db_name='data3', schema_only, experimental deferred copy, experimental read only)",
+			localFoo)
+	})
+}
+
 // TestOnlineRestoreWaitForDownload checks that the download job succeeeds even
 // if no queries are run on the restoring key space.
 func TestOnlineRestoreWaitForDownload(t *testing.T) {
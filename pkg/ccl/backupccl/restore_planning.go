@@ -1079,6 +1079,7 @@ func resolveOptionsForRestoreJobDescription(
 		UnsafeRestoreIncompatibleVersion: opts.UnsafeRestoreIncompatibleVersion,
 		ExecutionLocality:                opts.ExecutionLocality,
 		ExperimentalOnline:               opts.ExperimentalOnline,
+		ExperimentalReadOnly:             opts.ExperimentalReadOnly,
 		RemoveRegions:                    opts.RemoveRegions,
 	}
 
@@ -1094,6 +1095,10 @@ func resolveOptionsForRestoreJobDescription(
 		newOpts.NewDBName = tree.NewDString(newDBName)
 	}
 
+	if opts.VerifyIntegrityKMSURI != nil {
+		newOpts.VerifyIntegrityKMSURI = tree.NewDString("redacted")
+	}
+
 	for _, uri := range kmsURIs {
 		redactedURI, err := cloud.RedactKMSURI(uri)
 		if err != nil {
@@ -1180,6 +1185,7 @@ func restoreTypeCheck(
 			restoreStmt.Options.ForceTenantID,
 			restoreStmt.Options.AsTenant,
 			restoreStmt.Options.ExecutionLocality,
+			restoreStmt.Options.VerifyIntegrityKMSURI,
 		},
 	); err != nil {
 		return false, nil, err
@@ -1329,6 +1335,25 @@ func restorePlanHook(
 		return nil, nil, nil, false, errors.New("cannot run online restore with verify_backup_table_data")
 	}
 
+	if restoreStmt.Options.ExperimentalReadOnly {
+		if !restoreStmt.Options.ExperimentalOnline {
+			return nil, nil, nil, false, errors.New("experimental read only can only be used with experimental deferred copy")
+		}
+		if restoreStmt.DescriptorCoverage == tree.AllDescriptors || restoreStmt.Targets.TenantID.IsSet() {
+			return nil, nil, nil, false, errors.New("experimental read only cannot be used to restore a cluster or virtual cluster")
+		}
+		// There is no syntax to query a backup without restoring it, such as
+		// SELECT ... FROM [BACKUP ...].db.t. Instead, a read only restore mounts
+		// the backup's tables, served from external storage, in a database of
+		// their own that can be dropped once the backup has been inspected.
+		if restoreStmt.Options.NewDBName == nil {
+			return nil, nil, nil, false, errors.New("experimental read only requires new_db_name")
+		}
+		if restoreStmt.Options.SchemaOnly {
+			return nil, nil, nil, false, errors.New("cannot run a read only restore with schema_only")
+		}
+	}
+
 	var newTenantID *roachpb.TenantID
 	var newTenantName *roachpb.TenantName
 	if restoreStmt.Options.AsTenant != nil || restoreStmt.Options.ForceTenantID != nil {
@@ -1844,6 +1869,19 @@ func doRestorePlan(
 		return err
 	}
 
+	if restoreStmt.Options.VerifyIntegrityKMSURI != nil {
+		verifyIntegrityKMS, err := exprEval.String(ctx, restoreStmt.Options.VerifyIntegrityKMSURI)
+		if err != nil {
+			return err
+		}
+		if err := verifyBackupIntegrity(
+			ctx, p.ExecCfg(), p.User(), mainBackupManifests, localityInfo,
+			layerToIterFactory, encryption, verifyIntegrityKMS, &kmsEnv,
+		); err != nil {
+			return err
+		}
+	}
+
 	sqlDescs, restoreDBs, descsByTablePattern, tenants, err := selectTargets(
 		ctx, p, mainBackupManifests, layerToIterFactory, restoreStmt.Targets, restoreStmt.DescriptorCoverage, endTime,
 	)
@@ -2096,6 +2134,7 @@ func doRestorePlan(
 		SkipLocalitiesCheck:              restoreStmt.Options.SkipLocalitiesCheck,
 		ExecutionLocality:                execLocality,
 		ExperimentalOnline:               restoreStmt.Options.ExperimentalOnline,
+		ExperimentalReadOnly:             restoreStmt.Options.ExperimentalReadOnly,
 		RemoveRegions:                    restoreStmt.Options.RemoveRegions,
 		UnsafeRestoreIncompatibleVersion: restoreStmt.Options.UnsafeRestoreIncompatibleVersion,
 	}
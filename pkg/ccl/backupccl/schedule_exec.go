@@ -7,6 +7,7 @@ package backupccl
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuppb"
@@ -20,6 +21,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/log/eventpb"
 	"github.com/cockroachdb/cockroach/pkg/util/metric"
@@ -300,6 +302,44 @@ func (e *scheduledBackupExecutor) GetCreateScheduleStatement(
 		},
 	}
 
+	// The compaction threshold is stored on the incremental schedule, while the
+	// retention options are stored on the full schedule.
+	var fullArgs, incArgs *backuppb.ScheduledBackupExecutionArgs
+	if backupNode.AppendToLatest {
+		incArgs = args
+	} else {
+		fullArgs = args
+	}
+	if dependentSchedule != nil {
+		dependentArgs := &backuppb.ScheduledBackupExecutionArgs{}
+		if err := pbtypes.UnmarshalAny(dependentSchedule.ExecutionArgs().Args, dependentArgs); err != nil {
+			return "", errors.Wrap(err, "un-marshaling args")
+		}
+		if backupNode.AppendToLatest {
+			fullArgs = dependentArgs
+		} else {
+			incArgs = dependentArgs
+		}
+	}
+	if incArgs != nil && incArgs.CompactionThreshold > 0 {
+		scheduleOptions = append(scheduleOptions, tree.KVOption{
+			Key:   optCompactionThreshold,
+			Value: tree.NewDString(strconv.FormatInt(incArgs.CompactionThreshold, 10)),
+		})
+	}
+	if fullArgs != nil && fullArgs.RetainFullBackups > 0 {
+		scheduleOptions = append(scheduleOptions, tree.KVOption{
+			Key:   optRetainFullBackups,
+			Value: tree.NewDString(strconv.FormatInt(fullArgs.RetainFullBackups, 10)),
+		})
+	}
+	if fullArgs != nil && fullArgs.RetentionPeriod > 0 {
+		scheduleOptions = append(scheduleOptions, tree.KVOption{
+			Key:   optRetentionPeriod,
+			Value: tree.NewDString(duration.MakeDurationJustifyHours(fullArgs.RetentionPeriod.Nanoseconds(), 0, 0).String()),
+		})
+	}
+
 	var destinations []string
 	for i := range backupNode.To {
 		dest, ok := backupNode.To[i].(*tree.StrVal)
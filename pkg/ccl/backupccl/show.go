@@ -182,7 +182,7 @@ func showBackupTypeCheck(
 	ctx context.Context, stmt tree.Statement, p sql.PlanHookState,
 ) (matched bool, header colinfo.ResultColumns, _ error) {
 	backup, ok := stmt.(*tree.ShowBackup)
-	if !ok {
+	if !ok || backup.Details == tree.BackupDiffDetails {
 		return false, nil, nil
 	}
 	if backup.Path == nil && backup.InCollection != nil {
@@ -199,6 +199,7 @@ func showBackupTypeCheck(
 			backup.Options.EncryptionInfoDir,
 			backup.Options.CheckConnectionTransferSize,
 			backup.Options.CheckConnectionDuration,
+			backup.Options.VerifyIntegrityKMSURI,
 		},
 		exprutil.StringArrays{
 			tree.Exprs(backup.InCollection),
@@ -220,7 +221,7 @@ func showBackupPlanHook(
 	ctx context.Context, stmt tree.Statement, p sql.PlanHookState,
 ) (sql.PlanHookRowFn, colinfo.ResultColumns, []sql.PlanNode, bool, error) {
 	showStmt, ok := stmt.(*tree.ShowBackup)
-	if !ok {
+	if !ok || showStmt.Details == tree.BackupDiffDetails {
 		return nil, nil, nil, false, nil
 	}
 	exprEval := p.ExprEvaluator("SHOW BACKUP")
@@ -287,6 +288,14 @@ func showBackupPlanHook(
 		}
 	}
 
+	var verifyIntegrityKMS string
+	if showStmt.Options.VerifyIntegrityKMSURI != nil {
+		verifyIntegrityKMS, err = exprEval.String(ctx, showStmt.Options.VerifyIntegrityKMSURI)
+		if err != nil {
+			return nil, nil, nil, false, err
+		}
+	}
+
 	infoReader := getBackupInfoReader(p, showStmt)
 
 	if err != nil {
@@ -529,6 +538,14 @@ you must pass the 'encryption_info_dir' parameter that points to the directory o
 			}
 			info.fileSizes = fileSizes
 		}
+		if verifyIntegrityKMS != "" {
+			if err := verifyBackupIntegrity(
+				ctx, p.ExecCfg(), p.User(), info.manifests, info.localityInfo,
+				info.layerToIterFactory, encryption, verifyIntegrityKMS, &kmsEnv,
+			); err != nil {
+				return err
+			}
+		}
 		if err := infoReader.showBackup(ctx, &mem, mkStore, info, p.User(), &kmsEnv, resultsCh); err != nil {
 			return err
 		}
@@ -0,0 +1,743 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package backupccl
+
+import (
+	"bytes"
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupbase"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupdest"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupencryption"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backupinfo"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuppb"
+	"github.com/cockroachdb/cockroach/pkg/ccl/backupccl/backuputils"
+	"github.com/cockroachdb/cockroach/pkg/ccl/storageccl"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/cloud/cloudprivilege"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/server/telemetry"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/exprutil"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/catconstants"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/storage"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/errors"
+)
+
+// showBackupDiffHeader is the header of the results of SHOW BACKUP DIFF. Each
+// row describes either a descriptor of one of the two backups, or, if the key
+// column is set, a key of a table named by the keys option.
+var showBackupDiffHeader = colinfo.ResultColumns{
+	{Name: "database_name", Typ: types.String},
+	{Name: "parent_schema_name", Typ: types.String},
+	{Name: "object_name", Typ: types.String},
+	{Name: "object_type", Typ: types.String},
+	{Name: "change", Typ: types.String},
+	{Name: "row_delta", Typ: types.Int},
+	{Name: "byte_delta", Typ: types.Int},
+	{Name: "key", Typ: types.String},
+}
+
+const (
+	backupDiffAdded     = "added"
+	backupDiffDropped   = "dropped"
+	backupDiffAltered   = "altered"
+	backupDiffUnchanged = "unchanged"
+	backupDiffDeleted   = "deleted"
+	backupDiffUpdated   = "updated"
+)
+
+// backupDiffSide is the resolved chain of one of the two backups compared by
+// SHOW BACKUP DIFF.
+type backupDiffSide struct {
+	manifests          []backuppb.BackupManifest
+	layerToIterFactory backupinfo.LayerToBackupManifestFileIterFactory
+	encryption         *kvpb.FileEncryptionOptions
+
+	// descs are the live descriptors of the backup, as of its end time, by ID.
+	descs       map[descpb.ID]catalog.Descriptor
+	dbNames     map[descpb.ID]string
+	schemaNames map[descpb.ID]string
+}
+
+func (s *backupDiffSide) endTime() hlc.Timestamp {
+	return s.manifests[len(s.manifests)-1].EndTime
+}
+
+// names returns the database and schema names of the parent of desc.
+func (s *backupDiffSide) names(desc catalog.Descriptor) (dbName, schemaName string) {
+	switch desc.(type) {
+	case catalog.DatabaseDescriptor:
+		return "", ""
+	case catalog.SchemaDescriptor:
+		return s.dbNames[desc.GetParentID()], ""
+	default:
+		return s.dbNames[desc.GetParentID()], s.schemaNames[desc.GetParentSchemaID()]
+	}
+}
+
+// tableSizes returns the row counts of the tables of the backup, summed over
+// the layers of its chain.
+//
+// TODO(backup): the row counts of an incremental layer count the rows written
+// in it, not the net change of the table, so the sizes of a chain with
+// incremental backups overstate those of its tables.
+func (s *backupDiffSide) tableSizes(
+	ctx context.Context,
+) (map[descpb.ID]roachpb.RowCount, error) {
+	sizes := make(map[descpb.ID]roachpb.RowCount)
+	for layer := range s.manifests {
+		layerSizes, err := getTableSizes(ctx, s.layerToIterFactory[layer], nil /* fileSizes */)
+		if err != nil {
+			return nil, err
+		}
+		for id, size := range layerSizes {
+			rc := sizes[id]
+			rc.Add(size.rowCount)
+			sizes[id] = rc
+		}
+	}
+	return sizes, nil
+}
+
+// codec returns the codec of the keys of the backup.
+func (s *backupDiffSide) codec() (keys.SQLCodec, error) {
+	for _, m := range s.manifests {
+		if len(m.Spans) > 0 {
+			_, tenantID, err := keys.DecodeTenantPrefix(m.Spans[0].Key)
+			if err != nil {
+				return keys.SQLCodec{}, err
+			}
+			return keys.MakeSQLCodec(tenantID), nil
+		}
+	}
+	return keys.SystemSQLCodec, nil
+}
+
+// storeFiles returns the files of the backup that hold the data of span as of
+// its end time. Layers older than the last one that introduced span are
+// skipped, as their data of span was deleted before it was reintroduced.
+func (s *backupDiffSide) storeFiles(
+	ctx context.Context, execCfg *sql.ExecutorConfig, span roachpb.Span,
+) (_ []storageccl.StoreFile, retErr error) {
+	start := 0
+	for layer := range s.manifests {
+		for _, sp := range s.manifests[layer].IntroducedSpans {
+			if sp.Overlaps(span) {
+				start = layer
+			}
+		}
+	}
+
+	var storeFiles []storageccl.StoreFile
+	defer func() {
+		if retErr != nil {
+			for _, f := range storeFiles {
+				logClose(ctx, f.Store, "export storage")
+			}
+		}
+	}()
+	for layer := start; layer < len(s.manifests); layer++ {
+		it, err := s.layerToIterFactory[layer].NewFileIter(ctx)
+		if err != nil {
+			return nil, err
+		}
+		paths := make(map[string]struct{})
+		err = func() error {
+			defer it.Close()
+			for ; ; it.Next() {
+				if ok, err := it.Valid(); err != nil {
+					return err
+				} else if !ok {
+					return nil
+				}
+				f := it.Value()
+				if !f.Span.Overlaps(span) {
+					continue
+				}
+				if f.LocalityKV != "" {
+					return errors.New(
+						"the keys option of SHOW BACKUP DIFF is not supported for locality-aware backups")
+				}
+				if _, ok := paths[f.Path]; ok {
+					continue
+				}
+				paths[f.Path] = struct{}{}
+				dir, err := execCfg.DistSQLSrv.ExternalStorage(ctx, s.manifests[layer].Dir)
+				if err != nil {
+					return err
+				}
+				storeFiles = append(storeFiles, storageccl.StoreFile{Store: dir, FilePath: f.Path})
+			}
+		}()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return storeFiles, nil
+}
+
+// backupDiffKeyReader reads the latest value of each key of a span of a
+// backup, as of the end time of the backup.
+type backupDiffKeyReader struct {
+	storeFiles []storageccl.StoreFile
+	iter       *storage.ReadAsOfIterator
+	prefix     []byte
+	end        storage.MVCCKey
+
+	valid bool
+	key   roachpb.Key
+	ts    hlc.Timestamp
+	value []byte
+}
+
+func makeBackupDiffKeyReader(
+	ctx context.Context, execCfg *sql.ExecutorConfig, side *backupDiffSide, span roachpb.Span,
+) (*backupDiffKeyReader, error) {
+	storeFiles, err := side.storeFiles(ctx, execCfg, span)
+	if err != nil {
+		return nil, err
+	}
+	r := &backupDiffKeyReader{storeFiles: storeFiles, end: storage.MVCCKey{Key: span.EndKey}}
+	if len(storeFiles) == 0 {
+		return r, nil
+	}
+	endTime := side.endTime()
+	iterOpts := storage.IterOptions{
+		RangeKeyMaskingBelow: endTime,
+		KeyTypes:             storage.IterKeyTypePointsAndRanges,
+		LowerBound:           keys.LocalMax,
+		UpperBound:           keys.MaxKey,
+	}
+	sstIter, err := storageccl.ExternalSSTReader(ctx, storeFiles, side.encryption, iterOpts)
+	if err != nil {
+		r.close(ctx)
+		return nil, err
+	}
+	r.iter = storage.NewReadAsOfIterator(sstIter, endTime)
+	r.prefix, err = elidedPrefix(span.Key, side.manifests[0].ElidedPrefix)
+	if err != nil {
+		r.close(ctx)
+		return nil, err
+	}
+	r.iter.SeekGE(storage.MVCCKey{Key: bytes.TrimPrefix(span.Key, r.prefix)})
+	return r, r.load()
+}
+
+// load positions the reader at the current key of its iterator.
+func (r *backupDiffKeyReader) load() error {
+	r.valid = false
+	if ok, err := r.iter.Valid(); err != nil || !ok {
+		return err
+	}
+	key := r.iter.UnsafeKey()
+	key.Key = append(append(r.key[:0], r.prefix...), key.Key...)
+	if !key.Less(r.end) {
+		return nil
+	}
+	raw, err := r.iter.UnsafeValue()
+	if err != nil {
+		return err
+	}
+	v, err := storage.DecodeMVCCValue(raw)
+	if err != nil {
+		return err
+	}
+	r.valid, r.key, r.ts = true, key.Key, key.Timestamp
+	r.value = append(r.value[:0], v.Value.TagAndDataBytes()...)
+	return nil
+}
+
+func (r *backupDiffKeyReader) next() error {
+	r.iter.NextKey()
+	return r.load()
+}
+
+func (r *backupDiffKeyReader) close(ctx context.Context) {
+	if r.iter != nil {
+		r.iter.Close()
+	}
+	for _, f := range r.storeFiles {
+		logClose(ctx, f.Store, "export storage")
+	}
+}
+
+// resolveBackupDiffTable returns the ID of the table named name in either of
+// the two backups.
+func resolveBackupDiffTable(name string, sides ...*backupDiffSide) (descpb.ID, error) {
+	tn, err := parser.ParseQualifiedTableName(name)
+	if err != nil {
+		return 0, err
+	}
+	matches := make(map[descpb.ID]struct{})
+	for _, side := range sides {
+		for id, desc := range side.descs {
+			if _, ok := desc.(catalog.TableDescriptor); !ok || desc.GetName() != tn.Table() {
+				continue
+			}
+			dbName, schemaName := side.names(desc)
+			switch {
+			case tn.ExplicitCatalog:
+				if dbName != tn.Catalog() || schemaName != tn.Schema() {
+					continue
+				}
+			case tn.ExplicitSchema:
+				// A two part name is either db.table, in the public schema of db, or
+				// schema.table.
+				if (dbName != tn.Schema() || schemaName != catconstants.PublicSchemaName) &&
+					schemaName != tn.Schema() {
+					continue
+				}
+			}
+			matches[id] = struct{}{}
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return 0, errors.Newf("table %q not found in either backup", name)
+	case 1:
+		for id := range matches {
+			return id, nil
+		}
+	}
+	return 0, errors.Newf("table name %q is ambiguous", name)
+}
+
+// diffBackupDescriptors sends a row for each descriptor of either of the two
+// backups, ordered by ID, describing how it changed from the older backup to
+// the newer one.
+func diffBackupDescriptors(
+	ctx context.Context, older, newer *backupDiffSide, resultsCh chan<- tree.Datums,
+) error {
+	olderSizes, err := older.tableSizes(ctx)
+	if err != nil {
+		return err
+	}
+	newerSizes, err := newer.tableSizes(ctx)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]descpb.ID, 0, len(newer.descs))
+	for id := range newer.descs {
+		ids = append(ids, id)
+	}
+	for id := range older.descs {
+		if _, ok := newer.descs[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	for _, id := range ids {
+		olderDesc, inOlder := older.descs[id]
+		newerDesc, inNewer := newer.descs[id]
+		desc, side := newerDesc, newer
+		var change string
+		switch {
+		case !inOlder:
+			change = backupDiffAdded
+		case !inNewer:
+			change, desc, side = backupDiffDropped, olderDesc, older
+		case olderDesc.GetVersion() != newerDesc.GetVersion():
+			change = backupDiffAltered
+		default:
+			change = backupDiffUnchanged
+		}
+
+		var descriptorType string
+		rowDelta, byteDelta := tree.DNull, tree.DNull
+		switch desc.(type) {
+		case catalog.DatabaseDescriptor:
+			descriptorType = "database"
+		case catalog.SchemaDescriptor:
+			descriptorType = "schema"
+		case catalog.TypeDescriptor:
+			descriptorType = "type"
+		case catalog.FunctionDescriptor:
+			descriptorType = "function"
+		case catalog.TableDescriptor:
+			descriptorType = "table"
+			rowDelta = tree.NewDInt(tree.DInt(newerSizes[id].Rows - olderSizes[id].Rows))
+			byteDelta = tree.NewDInt(tree.DInt(newerSizes[id].DataSize - olderSizes[id].DataSize))
+		default:
+			descriptorType = "unknown"
+		}
+		dbName, schemaName := side.names(desc)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case resultsCh <- tree.Datums{
+			nullIfEmpty(dbName),
+			nullIfEmpty(schemaName),
+			tree.NewDString(desc.GetName()),
+			tree.NewDString(descriptorType),
+			tree.NewDString(change),
+			rowDelta,
+			byteDelta,
+			tree.DNull,
+		}:
+		}
+	}
+	return nil
+}
+
+// diffBackupKeys sends a row for each key of the table with the given ID that
+// was added, deleted or updated from the older backup to the newer one. The
+// keys of the two backups are compared by merging the iterators over the SSTs
+// that hold them.
+func diffBackupKeys(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	older, newer *backupDiffSide,
+	tableID descpb.ID,
+	resultsCh chan<- tree.Datums,
+) error {
+	desc, side := newer.descs[tableID], newer
+	if desc == nil {
+		desc, side = older.descs[tableID], older
+	}
+	dbName, schemaName := side.names(desc)
+	codec, err := side.codec()
+	if err != nil {
+		return err
+	}
+	span := codec.TableSpan(uint32(tableID))
+
+	olderReader, err := makeBackupDiffKeyReader(ctx, execCfg, older, span)
+	if err != nil {
+		return err
+	}
+	defer olderReader.close(ctx)
+	newerReader, err := makeBackupDiffKeyReader(ctx, execCfg, newer, span)
+	if err != nil {
+		return err
+	}
+	defer newerReader.close(ctx)
+
+	emit := func(change string, key roachpb.Key) error {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case resultsCh <- tree.Datums{
+			nullIfEmpty(dbName),
+			nullIfEmpty(schemaName),
+			tree.NewDString(desc.GetName()),
+			tree.NewDString("table"),
+			tree.NewDString(change),
+			tree.DNull,
+			tree.DNull,
+			tree.NewDString(key.String()),
+		}:
+			return nil
+		}
+	}
+
+	for olderReader.valid || newerReader.valid {
+		cmp := 0
+		switch {
+		case !olderReader.valid:
+			cmp = 1
+		case !newerReader.valid:
+			cmp = -1
+		default:
+			cmp = olderReader.key.Compare(newerReader.key)
+		}
+		switch {
+		case cmp < 0:
+			if err := emit(backupDiffDeleted, olderReader.key); err != nil {
+				return err
+			}
+			if err := olderReader.next(); err != nil {
+				return err
+			}
+		case cmp > 0:
+			if err := emit(backupDiffAdded, newerReader.key); err != nil {
+				return err
+			}
+			if err := newerReader.next(); err != nil {
+				return err
+			}
+		default:
+			if olderReader.ts != newerReader.ts && !bytes.Equal(olderReader.value, newerReader.value) {
+				if err := emit(backupDiffUpdated, newerReader.key); err != nil {
+					return err
+				}
+			}
+			if err := olderReader.next(); err != nil {
+				return err
+			}
+			if err := newerReader.next(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// resolveBackupDiffSide resolves the chain of the backup in subdir of
+// collection, and reads its descriptors.
+func resolveBackupDiffSide(
+	ctx context.Context,
+	p sql.PlanHookState,
+	mem *mon.BoundAccount,
+	collection string,
+	subdir string,
+	incrementalStorage []string,
+	encryptionParams jobspb.BackupEncryptionOptions,
+	kmsEnv cloud.KMSEnv,
+) (*backupDiffSide, error) {
+	execCfg := p.ExecCfg()
+	mkStore := execCfg.DistSQLSrv.ExternalStorageFromURI
+	if strings.EqualFold(subdir, backupbase.LatestFileName) {
+		latest, err := backupdest.ReadLatestFile(ctx, collection, mkStore, p.User())
+		if err != nil {
+			return nil, errors.Wrap(err, "read LATEST path")
+		}
+		subdir = latest
+	}
+	subdir = "/" + strings.TrimPrefix(subdir, "/")
+
+	baseURIs, err := backuputils.AppendPaths([]string{collection}, subdir)
+	if err != nil {
+		return nil, err
+	}
+	encryption, err := backupencryption.GetEncryptionFromBase(
+		ctx, p.User(), mkStore, baseURIs[0], encryptionParams, kmsEnv,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	side := &backupDiffSide{}
+	_, side.manifests, _, err = resolveBackupChain(
+		ctx, execCfg, p.User(), mem, jobspb.BackupDetails{
+			Destination: jobspb.BackupDetails_Destination{
+				To:                 []string{collection},
+				Subdir:             subdir,
+				IncrementalStorage: incrementalStorage,
+			},
+			EncryptionOptions: encryption,
+		}, kmsEnv,
+	)
+	if err != nil {
+		return nil, errors.Wrapf(err, "resolving backup %s", subdir)
+	}
+	if encryption != nil {
+		key, err := backupencryption.GetEncryptionKey(ctx, encryption, kmsEnv)
+		if err != nil {
+			return nil, err
+		}
+		side.encryption = &kvpb.FileEncryptionOptions{Key: key}
+	}
+	side.layerToIterFactory, err = backupinfo.GetBackupManifestIterFactories(
+		ctx, execCfg.DistSQLSrv.ExternalStorage, side.manifests, encryption, kmsEnv,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := maybeUpgradeDescriptorsInBackupManifests(
+		ctx, execCfg.Settings.Version.ActiveVersion(ctx), side.manifests, side.layerToIterFactory,
+		true, /* skipFKsWithNoMatchingTable */
+	); err != nil {
+		return nil, err
+	}
+
+	descs, err := backupinfo.BackupManifestDescriptors(
+		ctx, side.layerToIterFactory[len(side.manifests)-1], side.endTime(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	side.descs = make(map[descpb.ID]catalog.Descriptor, len(descs))
+	side.dbNames = make(map[descpb.ID]string)
+	side.schemaNames = map[descpb.ID]string{keys.PublicSchemaIDForBackup: catconstants.PublicSchemaName}
+	for _, desc := range descs {
+		if desc.Dropped() {
+			continue
+		}
+		side.descs[desc.GetID()] = desc
+		switch desc.(type) {
+		case catalog.DatabaseDescriptor:
+			side.dbNames[desc.GetID()] = desc.GetName()
+		case catalog.SchemaDescriptor:
+			side.schemaNames[desc.GetID()] = desc.GetName()
+		}
+	}
+	return side, nil
+}
+
+func showBackupDiffTypeCheck(
+	ctx context.Context, stmt tree.Statement, p sql.PlanHookState,
+) (matched bool, header colinfo.ResultColumns, _ error) {
+	backup, ok := stmt.(*tree.ShowBackup)
+	if !ok || backup.Details != tree.BackupDiffDetails {
+		return false, nil, nil
+	}
+	if err := exprutil.TypeCheck(
+		ctx, "SHOW BACKUP DIFF", p.SemaCtx(),
+		exprutil.Strings{
+			backup.DiffFrom,
+			backup.Path,
+			backup.Options.EncryptionPassphrase,
+		},
+		exprutil.StringArrays{
+			tree.Exprs(backup.InCollection),
+			tree.Exprs(backup.Options.IncrementalStorage),
+			tree.Exprs(backup.Options.DecryptionKMSURI),
+			tree.Exprs(backup.Options.KeyDiffTables),
+		},
+	); err != nil {
+		return false, nil, err
+	}
+	return true, showBackupDiffHeader, nil
+}
+
+// showBackupDiffPlanHook implements PlanHookFn for SHOW BACKUP DIFF, which
+// compares the descriptors, and optionally the keys of some tables, of two
+// backups in a collection.
+func showBackupDiffPlanHook(
+	ctx context.Context, stmt tree.Statement, p sql.PlanHookState,
+) (sql.PlanHookRowFn, colinfo.ResultColumns, []sql.PlanNode, bool, error) {
+	showStmt, ok := stmt.(*tree.ShowBackup)
+	if !ok || showStmt.Details != tree.BackupDiffDetails {
+		return nil, nil, nil, false, nil
+	}
+	opts := showStmt.Options
+	for _, unsupported := range []struct {
+		name string
+		set  bool
+	}{
+		{name: "as_json", set: opts.AsJson},
+		{name: "check_files", set: opts.CheckFiles},
+		{name: "debug_ids", set: opts.DebugIDs},
+		{name: "privileges", set: opts.Privileges},
+		{name: "skip size", set: opts.SkipSize},
+		{name: "encryption_info_dir", set: opts.EncryptionInfoDir != nil},
+		{name: "verify_backup_integrity", set: opts.VerifyIntegrityKMSURI != nil},
+		{name: "debug_dump_metadata_sst", set: opts.DebugMetadataSST},
+	} {
+		if unsupported.set {
+			return nil, nil, nil, false, errors.Newf(
+				"%s option is not supported by SHOW BACKUP DIFF", unsupported.name)
+		}
+	}
+
+	exprEval := p.ExprEvaluator("SHOW BACKUP DIFF")
+	from, err := exprEval.String(ctx, showStmt.DiffFrom)
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+	to, err := exprEval.String(ctx, showStmt.Path)
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+	collections, err := exprEval.StringArray(ctx, tree.Exprs(showStmt.InCollection))
+	if err != nil {
+		return nil, nil, nil, false, err
+	}
+	if len(collections) != 1 {
+		return nil, nil, nil, false, errors.New(
+			"SHOW BACKUP DIFF is not supported for locality-aware backup collections")
+	}
+	var incrementalStorage []string
+	if opts.IncrementalStorage != nil {
+		incrementalStorage, err = exprEval.StringArray(ctx, tree.Exprs(opts.IncrementalStorage))
+		if err != nil {
+			return nil, nil, nil, false, err
+		}
+	}
+	encryptionParams := jobspb.BackupEncryptionOptions{Mode: jobspb.EncryptionMode_None}
+	if opts.EncryptionPassphrase != nil {
+		pw, err := exprEval.String(ctx, opts.EncryptionPassphrase)
+		if err != nil {
+			return nil, nil, nil, false, err
+		}
+		encryptionParams.Mode = jobspb.EncryptionMode_Passphrase
+		encryptionParams.RawPassphrase = pw
+	} else if opts.DecryptionKMSURI != nil {
+		kms, err := exprEval.StringArray(ctx, tree.Exprs(opts.DecryptionKMSURI))
+		if err != nil {
+			return nil, nil, nil, false, err
+		}
+		encryptionParams.Mode = jobspb.EncryptionMode_KMS
+		encryptionParams.RawKmsUris = kms
+	}
+	var keyTables []string
+	if opts.KeyDiffTables != nil {
+		keyTables, err = exprEval.StringArray(ctx, tree.Exprs(opts.KeyDiffTables))
+		if err != nil {
+			return nil, nil, nil, false, err
+		}
+	}
+
+	fn := func(ctx context.Context, _ []sql.PlanNode, resultsCh chan<- tree.Datums) error {
+		ctx, span := tracing.ChildSpan(ctx, stmt.StatementTag())
+		defer span.Finish()
+
+		if err := cloudprivilege.CheckDestinationPrivileges(ctx, p, collections); err != nil {
+			return err
+		}
+		execCfg := p.ExecCfg()
+		kmsEnv := backupencryption.MakeBackupKMSEnv(
+			execCfg.Settings, &execCfg.ExternalIODirConfig, execCfg.InternalDB, p.User(),
+		)
+		mem := execCfg.RootMemoryMonitor.MakeBoundAccount()
+		defer mem.Close(ctx)
+
+		older, err := resolveBackupDiffSide(
+			ctx, p, &mem, collections[0], from, incrementalStorage, encryptionParams, &kmsEnv,
+		)
+		if err != nil {
+			return err
+		}
+		newer, err := resolveBackupDiffSide(
+			ctx, p, &mem, collections[0], to, incrementalStorage, encryptionParams, &kmsEnv,
+		)
+		if err != nil {
+			return err
+		}
+
+		tableIDs := make([]descpb.ID, 0, len(keyTables))
+		for _, name := range keyTables {
+			id, err := resolveBackupDiffTable(name, older, newer)
+			if err != nil {
+				return err
+			}
+			tableIDs = append(tableIDs, id)
+		}
+
+		if err := diffBackupDescriptors(ctx, older, newer, resultsCh); err != nil {
+			return err
+		}
+		for _, id := range tableIDs {
+			if err := diffBackupKeys(ctx, execCfg, older, newer, id, resultsCh); err != nil {
+				return err
+			}
+		}
+		telemetry.Count("show-backup.diff")
+		return nil
+	}
+	return fn, showBackupDiffHeader, nil, false, nil
+}
+
+func init() {
+	sql.AddPlanHook("backupccl.showBackupDiffPlanHook", showBackupDiffPlanHook, showBackupDiffTypeCheck)
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package backupccl
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// TestShowBackupDiff tests that SHOW BACKUP DIFF reports the descriptors that
+// were added, dropped or altered between two backups, the row deltas of their
+// tables, and the keys of the tables named by the keys option that changed.
+func TestShowBackupDiff(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	const numAccounts = 10
+	_, sqlDB, _, cleanupFn := backupRestoreTestSetup(t, singleNode, numAccounts, InitManualReplication)
+	defer cleanupFn()
+
+	sqlDB.Exec(t, `CREATE TABLE data.gone (a INT PRIMARY KEY)`)
+	sqlDB.Exec(t, `BACKUP DATABASE data INTO $1`, localFoo)
+	fulls := getFullBackupPaths(t, sqlDB, localFoo)
+	require.Len(t, fulls, 1)
+
+	sqlDB.Exec(t, `INSERT INTO data.bank VALUES (1000, 1, 'a'), (1001, 1, 'b')`)
+	sqlDB.Exec(t, `UPDATE data.bank SET balance = balance + 1 WHERE id = 1`)
+	sqlDB.Exec(t, `DELETE FROM data.bank WHERE id = 2`)
+	sqlDB.Exec(t, `ALTER TABLE data.bank RENAME COLUMN payload TO p`)
+	sqlDB.Exec(t, `DROP TABLE data.gone`)
+	sqlDB.Exec(t, `CREATE TABLE data.other (a INT PRIMARY KEY)`)
+	sqlDB.Exec(t, `BACKUP DATABASE data INTO $1`, localFoo)
+
+	diff := fmt.Sprintf(`SHOW BACKUP DIFF FROM '%s' TO LATEST IN '%s'`, fulls[0], localFoo)
+	sqlDB.CheckQueryResults(t, fmt.Sprintf(
+		`SELECT database_name, parent_schema_name, object_name, change, row_delta
+FROM [%s] WHERE object_type = 'table'`, diff),
+		[][]string{
+			{"data", "public", "bank", "altered", "1"},
+			{"data", "public", "gone", "dropped", "0"},
+			{"data", "public", "other", "added", "0"},
+		})
+
+	t.Run("keys", func(t *testing.T) {
+		sqlDB.CheckQueryResults(t, fmt.Sprintf(
+			`SELECT change, count(*) FROM [%s WITH keys = 'data.bank'] WHERE key IS NOT NULL
+GROUP BY change ORDER BY change`, diff),
+			[][]string{{"added", "2"}, {"deleted", "1"}, {"updated", "1"}})
+		sqlDB.CheckQueryResults(t, fmt.Sprintf(
+			`SELECT count(*) FROM [%s WITH keys = ('data.public.bank', 'data.other')] WHERE key IS NOT NULL`, diff),
+			[][]string{{"4"}})
+		sqlDB.CheckQueryResults(t, fmt.Sprintf(
+			`SELECT count(*) FROM [%s WITH keys = 'data.gone'] WHERE key IS NOT NULL`, diff),
+			[][]string{{"0"}})
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		sqlDB.ExpectErr(t, `table "data.missing" not found in either backup`,
+			diff+` WITH keys = 'data.missing'`)
+		sqlDB.ExpectErr(t, "check_files option is not supported by SHOW BACKUP DIFF",
+			diff+` WITH check_files`)
+		sqlDB.ExpectErr(t, "resolving backup /2000/01/01-000000.00",
+			fmt.Sprintf(`SHOW BACKUP DIFF FROM '/2000/01/01-000000.00' TO LATEST IN '%s'`, localFoo))
+	})
+}
@@ -17,6 +17,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/jobs"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobsauth"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/server/telemetry"
 	"github.com/cockroachdb/cockroach/pkg/sql"
@@ -29,10 +30,12 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/asof"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log/eventpb"
 	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/cockroachdb/errors"
 )
@@ -126,6 +129,11 @@ func alterChangefeedPlanHook(
 			return errors.Errorf(`job %d is not paused`, jobID)
 		}
 
+		if targets := AllTargets(prevDetails); targets.HasNamespaces() {
+			return pgerror.Newf(pgcode.FeatureNotSupported,
+				`job %d is a database- or schema-level changefeed, which cannot be altered`, jobID)
+		}
+
 		newChangefeedStmt := &tree.CreateChangefeed{}
 
 		prevOpts, err := getPrevOpts(job.Payload().Description, prevDetails.Opts)
@@ -148,11 +156,18 @@ func alterChangefeedPlanHook(
 			return err
 		}
 
+		prevProgress, rewindEvent, err := rewindProgress(
+			ctx, p, alterChangefeedStmt.Cmds, prevDetails, job.Progress(),
+		)
+		if err != nil {
+			return err
+		}
+
 		newTargets, newProgress, newStatementTime, originalSpecs, err := generateAndValidateNewTargets(
 			ctx, exprEval, p,
 			alterChangefeedStmt.Cmds,
 			newOptions.AsMap(), // TODO: Remove .AsMap()
-			prevDetails, job.Progress(),
+			prevDetails, prevProgress,
 			newSinkURI,
 		)
 		if err != nil {
@@ -248,6 +263,16 @@ func alterChangefeedPlanHook(
 			return err
 		}
 
+		if rewindEvent != nil {
+			if err := sql.LogEventForJobs(
+				ctx, p.ExecCfg(), p.InternalSQLTxn(), rewindEvent, int64(jobID),
+				newPayload, p.User(), job.Status(),
+			); err != nil {
+				return err
+			}
+			telemetry.Count(telemetryPath + `.rewind`)
+		}
+
 		telemetry.Count(telemetryPath)
 
 		select {
@@ -757,8 +782,10 @@ func generateNewProgress(
 	prevHighWater := prevProgress.GetHighWater()
 	changefeedProgress := prevProgress.GetChangefeed()
 	ptsRecord := uuid.UUID{}
+	var rewinds []jobspb.ChangefeedProgress_Rewind
 	if changefeedProgress != nil {
 		ptsRecord = changefeedProgress.ProtectedTimestampRecord
+		rewinds = changefeedProgress.Rewinds
 	}
 
 	haveHighwater := !(prevHighWater == nil || prevHighWater.IsEmpty())
@@ -803,6 +830,7 @@ func generateNewProgress(
 						Spans: existingTargetSpans,
 					},
 					ProtectedTimestampRecord: ptsRecord,
+					Rewinds:                  rewinds,
 				},
 			},
 		}
@@ -833,12 +861,229 @@ func generateNewProgress(
 					Spans: mergedSpanGroup.Slice(),
 				},
 				ProtectedTimestampRecord: ptsRecord,
+				Rewinds:                  rewinds,
 			},
 		},
 	}
 	return newProgress, prevStatementTime, nil
 }
 
+// maxRecordedRewinds is the number of rewinds kept in the progress of a
+// changefeed.
+const maxRecordedRewinds = 10
+
+// rewindProgress returns the progress of a changefeed rewound by the REWIND
+// command of an ALTER CHANGEFEED statement, along with the event recording the
+// rewind. If the statement doesn't rewind the changefeed, the progress is
+// returned unchanged.
+//
+// Rewinding the whole changefeed moves its high watermark back to the rewind
+// timestamp and clears its checkpoint. Rewinding some of its tables moves the
+// high watermark back as well, but checkpoints the spans of the other tables at
+// the previous high watermark so that only the rewound tables re-emit their
+// changes. Since the other tables already emitted their changes up to the
+// previous high watermark, the changefeed doesn't emit resolved timestamps
+// until it catches up to it again, so that resolved timestamps never regress.
+// Rewinding the whole changefeed does re-emit resolved timestamps from the
+// rewind timestamp on, along with all the changes since then.
+func rewindProgress(
+	ctx context.Context,
+	p sql.PlanHookState,
+	alterCmds tree.AlterChangefeedCmds,
+	prevDetails jobspb.ChangefeedDetails,
+	prevProgress jobspb.Progress,
+) (jobspb.Progress, *eventpb.ChangefeedRewind, error) {
+	var rewind *tree.AlterChangefeedRewind
+	for _, cmd := range alterCmds {
+		switch v := cmd.(type) {
+		case *tree.AlterChangefeedRewind:
+			if rewind != nil {
+				return prevProgress, nil, pgerror.New(pgcode.InvalidParameterValue,
+					`cannot rewind a changefeed more than once in the same statement`)
+			}
+			rewind = v
+		}
+	}
+	if rewind == nil {
+		return prevProgress, nil, nil
+	}
+	for _, cmd := range alterCmds {
+		switch cmd.(type) {
+		case *tree.AlterChangefeedAddTarget, *tree.AlterChangefeedDropTarget:
+			return prevProgress, nil, pgerror.New(pgcode.FeatureNotSupported,
+				`cannot rewind a changefeed while adding or dropping targets`)
+		}
+	}
+
+	asOf, err := asof.Eval(ctx, tree.AsOfClause{Expr: rewind.Timestamp}, p.SemaCtx(), &p.ExtendedEvalContext().Context)
+	if err != nil {
+		return prevProgress, nil, errors.Wrap(err, `invalid rewind timestamp`)
+	}
+	rewindTS := asOf.Timestamp
+
+	highWater := prevProgress.GetHighWater()
+	if highWater == nil || highWater.IsEmpty() {
+		return prevProgress, nil, pgerror.New(pgcode.ObjectNotInPrerequisiteState,
+			`cannot rewind a changefeed which has not completed its initial scan`)
+	}
+	if !rewindTS.Less(*highWater) {
+		return prevProgress, nil, pgerror.Newf(pgcode.InvalidParameterValue,
+			`cannot rewind to %s which is not before the high watermark %s`,
+			eval.TimestampToDecimalDatum(rewindTS).Decimal.String(),
+			eval.TimestampToDecimalDatum(*highWater).Decimal.String(),
+		)
+	}
+	if rewindTS.Less(prevDetails.StatementTime) {
+		return prevProgress, nil, pgerror.Newf(pgcode.InvalidParameterValue,
+			`cannot rewind to %s which is before the changefeed started at %s`,
+			eval.TimestampToDecimalDatum(rewindTS).Decimal.String(),
+			eval.TimestampToDecimalDatum(prevDetails.StatementTime).Decimal.String(),
+		)
+	}
+
+	var targetIDs []descpb.ID
+	targets := AllTargets(prevDetails)
+	if err := targets.EachTableID(func(id descpb.ID) error {
+		targetIDs = append(targetIDs, id)
+		return nil
+	}); err != nil {
+		return prevProgress, nil, err
+	}
+	targetSpans := fetchSpansForDescs(p, targetIDs)
+
+	event := &eventpb.ChangefeedRewind{
+		RewindTimestamp:   eval.TimestampToDecimalDatum(rewindTS).Decimal.String(),
+		PreviousHighWater: eval.TimestampToDecimalDatum(*highWater).Decimal.String(),
+	}
+	rewoundSpans := targetSpans
+	var checkpoint *jobspb.ChangefeedProgress_Checkpoint
+	if len(rewind.Targets) > 0 {
+		rewoundIDs, err := resolveRewoundTargets(ctx, p, rewind.Targets, targetIDs)
+		if err != nil {
+			return prevProgress, nil, err
+		}
+		rewoundSpans = fetchSpansForDescs(p, rewoundIDs)
+		var otherSpans roachpb.SpanGroup
+		otherSpans.Add(targetSpans...)
+		otherSpans.Sub(rewoundSpans...)
+		checkpoint = &jobspb.ChangefeedProgress_Checkpoint{
+			Spans:     otherSpans.Slice(),
+			Timestamp: *highWater,
+		}
+		for i := range rewind.Targets {
+			event.Tables = append(event.Tables, tree.AsString(rewind.Targets[i].TableName))
+		}
+	}
+
+	// The protected timestamp record of the changefeed follows its high
+	// watermark, so it needs to be moved back to keep protecting the changes
+	// which are going to be re-emitted. The record is moved in its own
+	// transaction, before checking that the changes since the rewind timestamp
+	// haven't been garbage collected: once the check passes, GC can no longer
+	// remove them. If the rewind then fails, the record merely protects more
+	// than needed until the changefeed next advances it.
+	if prev := prevProgress.GetChangefeed(); prev != nil && prev.ProtectedTimestampRecord != uuid.Nil {
+		if err := p.ExecCfg().InternalDB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+			pts := p.ExecCfg().ProtectedTimestampProvider.WithTxn(txn)
+			rec, err := pts.GetRecord(ctx, prev.ProtectedTimestampRecord)
+			if err != nil {
+				return err
+			}
+			if rewindTS.Less(rec.Timestamp) {
+				return pts.UpdateTimestamp(ctx, prev.ProtectedTimestampRecord, rewindTS)
+			}
+			return nil
+		}); err != nil {
+			return prevProgress, nil, err
+		}
+	}
+
+	// Make sure the changes since the rewind timestamp are still there to be
+	// re-emitted, i.e. that they weren't garbage collected before the protected
+	// timestamp record was moved back.
+	if err := p.ExecCfg().DB.Txn(ctx, func(ctx context.Context, txn *kv.Txn) error {
+		if err := txn.SetFixedTimestamp(ctx, rewindTS); err != nil {
+			return err
+		}
+		for _, sp := range rewoundSpans {
+			if _, err := txn.Scan(ctx, sp.Key, sp.EndKey, 1 /* maxRows */); err != nil {
+				return err
+			}
+		}
+		return nil
+	}); err != nil {
+		return prevProgress, nil, errors.Wrapf(err, `cannot rewind to %s`,
+			eval.TimestampToDecimalDatum(rewindTS).Decimal.String())
+	}
+
+	var newChangefeedProgress jobspb.ChangefeedProgress
+	if prev := prevProgress.GetChangefeed(); prev != nil {
+		newChangefeedProgress.ProtectedTimestampRecord = prev.ProtectedTimestampRecord
+		newChangefeedProgress.Rewinds = append(newChangefeedProgress.Rewinds, prev.Rewinds...)
+	}
+	newChangefeedProgress.Checkpoint = checkpoint
+	record := jobspb.ChangefeedProgress_Rewind{
+		Timestamp:         rewindTS,
+		PreviousHighWater: *highWater,
+		RewoundAt:         hlc.Timestamp{WallTime: p.ExtendedEvalContext().GetStmtTimestamp().UnixNano()},
+	}
+	if len(rewind.Targets) > 0 {
+		record.Spans = rewoundSpans
+	}
+	newChangefeedProgress.Rewinds = append(newChangefeedProgress.Rewinds, record)
+	if n := len(newChangefeedProgress.Rewinds); n > maxRecordedRewinds {
+		newChangefeedProgress.Rewinds = newChangefeedProgress.Rewinds[n-maxRecordedRewinds:]
+	}
+
+	newProgress := prevProgress
+	newProgress.Progress = &jobspb.Progress_HighWater{HighWater: &rewindTS}
+	newProgress.Details = &jobspb.Progress_Changefeed{Changefeed: &newChangefeedProgress}
+	return newProgress, event, nil
+}
+
+// resolveRewoundTargets returns the IDs of the tables rewound by a REWIND
+// command, which must be watched by the changefeed.
+func resolveRewoundTargets(
+	ctx context.Context,
+	p sql.PlanHookState,
+	rewoundTargets tree.ChangefeedTargets,
+	targetIDs []descpb.ID,
+) ([]descpb.ID, error) {
+	statementTime := hlc.Timestamp{
+		WallTime: p.ExtendedEvalContext().GetStmtTimestamp().UnixNano(),
+	}
+	allDescs, err := backupresolver.LoadAllDescs(ctx, p.ExecCfg(), statementTime)
+	if err != nil {
+		return nil, err
+	}
+	descResolver, err := backupresolver.NewDescriptorResolver(allDescs)
+	if err != nil {
+		return nil, err
+	}
+
+	watched := make(map[descpb.ID]struct{}, len(targetIDs))
+	for _, id := range targetIDs {
+		watched[id] = struct{}{}
+	}
+	var rewoundIDs []descpb.ID
+	for _, target := range rewoundTargets {
+		desc, found, err := getTargetDesc(ctx, p, descResolver, target.TableName)
+		if err != nil {
+			return nil, err
+		}
+		if !found {
+			return nil, pgerror.Newf(pgcode.InvalidParameterValue,
+				`target %q does not exist`, tree.ErrString(&target))
+		}
+		if _, ok := watched[desc.GetID()]; !ok {
+			return nil, pgerror.Newf(pgcode.InvalidParameterValue,
+				`target %q is not watched by changefeed`, tree.ErrString(&target))
+		}
+		rewoundIDs = append(rewoundIDs, desc.GetID())
+	}
+	return rewoundIDs, nil
+}
+
 func removeSpansFromProgress(prevProgress jobspb.Progress, spansToRemove []roachpb.Span) {
 	changefeedProgress := prevProgress.GetChangefeed()
 	if changefeedProgress == nil {
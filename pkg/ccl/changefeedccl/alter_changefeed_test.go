@@ -1579,6 +1579,153 @@ func TestAlterChangefeedWithOldCursorFromCreateChangefeed(t *testing.T) {
 	cdcTest(t, testFn, feedTestEnterpriseSinks, feedTestNoExternalConnection)
 }
 
+func TestAlterChangefeedRewind(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testFn := func(t *testing.T, s TestServer, f cdctest.TestFeedFactory) {
+		registry := s.Server.JobRegistry().(*jobs.Registry)
+
+		sqlDB := sqlutils.MakeSQLRunner(s.DB)
+		sqlDB.Exec(t, `CREATE TABLE foo (a INT PRIMARY KEY)`)
+		sqlDB.Exec(t, `CREATE TABLE bar (a INT PRIMARY KEY)`)
+
+		testFeed := feed(t, f, `CREATE CHANGEFEED FOR foo, bar`)
+		defer closeFeed(t, testFeed)
+
+		feed, ok := testFeed.(cdctest.EnterpriseTestFeed)
+		require.True(t, ok)
+
+		var rewindTS string
+		sqlDB.QueryRow(t, `SELECT cluster_logical_timestamp()`).Scan(&rewindTS)
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (1)`)
+		sqlDB.Exec(t, `INSERT INTO bar VALUES (1)`)
+		assertPayloads(t, testFeed, []string{
+			`foo: [1]->{"after": {"a": 1}}`,
+			`bar: [1]->{"after": {"a": 1}}`,
+		})
+
+		var afterInserts string
+		sqlDB.QueryRow(t, `SELECT cluster_logical_timestamp()`).Scan(&afterInserts)
+		waitForHighwater := func() {
+			testutils.SucceedsSoon(t, func() error {
+				progress := loadProgress(t, feed, registry)
+				if hw := progress.GetHighWater(); hw != nil && parseTimeToHLC(t, afterInserts).Less(*hw) {
+					return nil
+				}
+				return errors.New("waiting for highwater")
+			})
+		}
+		pause := func() {
+			sqlDB.Exec(t, `PAUSE JOB $1`, feed.JobID())
+			waitForJobStatus(sqlDB, t, feed.JobID(), `paused`)
+		}
+		resume := func() {
+			sqlDB.Exec(t, fmt.Sprintf(`RESUME JOB %d`, feed.JobID()))
+			waitForJobStatus(sqlDB, t, feed.JobID(), `running`)
+		}
+
+		// Rewinding a table only re-emits the changes of that table.
+		waitForHighwater()
+		pause()
+		sqlDB.ExpectErr(t, `cannot rewind to .* which is not before the high watermark`,
+			fmt.Sprintf(`ALTER CHANGEFEED %d REWIND TO '%s'`, feed.JobID(), timeutil.Now().Add(time.Hour).Format(time.RFC3339)))
+		sqlDB.ExpectErr(t, `cannot rewind a changefeed while adding or dropping targets`,
+			fmt.Sprintf(`ALTER CHANGEFEED %d DROP foo REWIND TO '%s'`, feed.JobID(), rewindTS))
+		sqlDB.Exec(t, fmt.Sprintf(`ALTER CHANGEFEED %d REWIND bar TO '%s'`, feed.JobID(), rewindTS))
+		resume()
+		assertPayloads(t, testFeed, []string{
+			`bar: [1]->{"after": {"a": 1}}`,
+		})
+
+		// Rewinding the whole changefeed re-emits the changes of every table.
+		waitForHighwater()
+		pause()
+		sqlDB.Exec(t, fmt.Sprintf(`ALTER CHANGEFEED %d REWIND TO '%s'`, feed.JobID(), rewindTS))
+		progress := loadProgress(t, feed, registry)
+		require.Equal(t, parseTimeToHLC(t, rewindTS), *progress.GetHighWater())
+		rewinds := progress.GetChangefeed().Rewinds
+		require.Len(t, rewinds, 2)
+		require.NotEmpty(t, rewinds[0].Spans)
+		require.Empty(t, rewinds[1].Spans)
+		resume()
+		assertPayloads(t, testFeed, []string{
+			`foo: [1]->{"after": {"a": 1}}`,
+			`bar: [1]->{"after": {"a": 1}}`,
+		})
+
+		sqlDB.CheckQueryResults(t,
+			`SELECT count(*) FROM system.eventlog WHERE "eventType" = 'changefeed_rewind'`,
+			[][]string{{`2`}},
+		)
+	}
+
+	cdcTest(t, testFn, feedTestEnterpriseSinks, feedTestNoExternalConnection)
+}
+
+func TestAlterChangefeedRewindTablesSuppressesResolved(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testFn := func(t *testing.T, s TestServer, f cdctest.TestFeedFactory) {
+		registry := s.Server.JobRegistry().(*jobs.Registry)
+
+		sqlDB := sqlutils.MakeSQLRunner(s.DB)
+		sqlDB.Exec(t, `CREATE TABLE foo (a INT PRIMARY KEY)`)
+		sqlDB.Exec(t, `CREATE TABLE bar (a INT PRIMARY KEY)`)
+
+		var rewindTS string
+		sqlDB.QueryRow(t, `SELECT cluster_logical_timestamp()`).Scan(&rewindTS)
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (1)`)
+		sqlDB.Exec(t, `INSERT INTO bar VALUES (1)`)
+
+		testFeed := feed(t, f, `CREATE CHANGEFEED FOR foo, bar WITH resolved = '10ms', cursor = $1`, rewindTS)
+		defer closeFeed(t, testFeed)
+		feed, ok := testFeed.(cdctest.EnterpriseTestFeed)
+		require.True(t, ok)
+		assertPayloads(t, testFeed, []string{
+			`foo: [1]->{"after": {"a": 1}}`,
+			`bar: [1]->{"after": {"a": 1}}`,
+		})
+
+		testutils.SucceedsSoon(t, func() error {
+			progress := loadProgress(t, feed, registry)
+			if hw := progress.GetHighWater(); hw != nil && parseTimeToHLC(t, rewindTS).Less(*hw) {
+				return nil
+			}
+			return errors.New("waiting for highwater")
+		})
+		sqlDB.Exec(t, `PAUSE JOB $1`, feed.JobID())
+		waitForJobStatus(sqlDB, t, feed.JobID(), `paused`)
+		prevHighWater := *loadProgress(t, feed, registry).GetHighWater()
+
+		// The rewound table re-emits its changes, but no resolved timestamp
+		// before the previous high watermark is emitted, since foo already
+		// emitted its changes up to it.
+		sqlDB.Exec(t, fmt.Sprintf(`ALTER CHANGEFEED %d REWIND bar TO '%s'`, feed.JobID(), rewindTS))
+		sqlDB.Exec(t, `RESUME JOB $1`, feed.JobID())
+		waitForJobStatus(sqlDB, t, feed.JobID(), `running`)
+		var sawRow bool
+		for {
+			m, err := testFeed.Next()
+			require.NoError(t, err)
+			if m.Key != nil {
+				require.Equal(t, `bar`, m.Topic)
+				sawRow = true
+				continue
+			}
+			resolved := extractResolvedTimestamp(t, m)
+			require.False(t, resolved.Less(prevHighWater),
+				"resolved timestamp %s regressed below %s", resolved, prevHighWater)
+			if sawRow {
+				break
+			}
+		}
+	}
+
+	cdcTest(t, testFn, feedTestEnterpriseSinks, feedTestNoExternalConnection)
+}
+
 // TestChangefeedJobControl tests if a user can modify and existing changefeed
 // based on their privileges.
 func TestAlterChangefeedAccessControl(t *testing.T) {
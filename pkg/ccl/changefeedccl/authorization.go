@@ -150,6 +150,11 @@ func AuthorizeChangefeedJobAccess(
 	}
 
 	for _, spec := range specs.TargetSpecifications {
+		if spec.Type == jobspb.ChangefeedTargetSpecification_DATABASE {
+			// The tables making up the namespace are listed as separate
+			// specifications.
+			continue
+		}
 		err := a.CheckPrivilegeForTableID(ctx, spec.TableID, privilege.CHANGEFEED)
 		if err != nil {
 			// When performing SHOW JOBS or SHOW CHANGEFEED JOBS, there may be old changefeed
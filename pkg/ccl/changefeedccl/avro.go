@@ -167,6 +167,9 @@ type avroEnvelopeOpts struct {
 	beforeField, afterField, recordField bool
 	updatedField, resolvedField          bool
 	mvccTimestampField                   bool
+	// sourceField and opField are the debezium envelope's source block and
+	// operation code.
+	sourceField, opField bool
 }
 
 // avroEnvelopeRecord is an `avroRecord` that wraps a changed SQL row and some
@@ -176,6 +179,7 @@ type avroEnvelopeRecord struct {
 
 	opts                  avroEnvelopeOpts
 	before, after, record *avroDataRecord
+	source                *avroRecord
 }
 
 // typeToAvroSchema converts a database type to an avro field
@@ -960,6 +964,23 @@ func envelopeToAvroSchema(
 		}
 		schema.Fields = append(schema.Fields, mvccTimestampField)
 	}
+	if opts.sourceField {
+		schema.source = debeziumSourceToAvroSchema(topic, namespace)
+		sourceField := &avroSchemaField{
+			Name:       `source`,
+			SchemaType: []avroSchemaType{avroSchemaNull, schema.source},
+			Default:    nil,
+		}
+		schema.Fields = append(schema.Fields, sourceField)
+	}
+	if opts.opField {
+		opField := &avroSchemaField{
+			SchemaType: []avroSchemaType{avroSchemaNull, avroSchemaString},
+			Name:       `op`,
+			Default:    nil,
+		}
+		schema.Fields = append(schema.Fields, opField)
+	}
 	if opts.resolvedField {
 		resolvedField := &avroSchemaField{
 			SchemaType: []avroSchemaType{avroSchemaNull, avroSchemaString},
@@ -1057,6 +1078,32 @@ func (r *avroEnvelopeRecord) BinaryFromRow(
 		}
 	}
 
+	if r.opts.sourceField {
+		native[`source`] = nil
+		if u, ok := meta[`source`]; ok {
+			delete(meta, `source`)
+			src, ok := u.(debeziumSource)
+			if !ok {
+				return nil, changefeedbase.WithTerminalError(
+					errors.Errorf(`unknown metadata source type: %T`, u))
+			}
+			native[`source`] = goavro.Union(avroUnionKey(r.source), src.avroNative())
+		}
+	}
+
+	if r.opts.opField {
+		native[`op`] = nil
+		if u, ok := meta[`op`]; ok {
+			delete(meta, `op`)
+			op, ok := u.(string)
+			if !ok {
+				return nil, changefeedbase.WithTerminalError(
+					errors.Errorf(`unknown metadata op type: %T`, u))
+			}
+			native[`op`] = goavro.Union(avroUnionKey(avroSchemaString), op)
+		}
+	}
+
 	if r.opts.resolvedField {
 		native[`resolved`] = nil
 		if u, ok := meta[`resolved`]; ok {
@@ -67,6 +67,29 @@ consumed, we can determine if the row ought to be filtered.  This step is done t
 ensure that we correctly release resources for each event -- even the ones that
 are filtered out.
 
+Expressions may also enrich events with data stored in other (lookup) tables
+via scalar (or EXISTS) sub-queries:
+  SELECT *, (SELECT name FROM customers c WHERE c.id = orders.customer_id) AS name FROM orders
+During normalization stage, each sub-query is rewritten as:
+  SELECT *, crdb_internal.cdc_lookup(
+    'SELECT (SELECT name FROM [104 AS c] WHERE c.id = $1::INT8)', NULL::STRING, orders.customer_id
+  ) AS name FROM orders
+That is, lookup tables are resolved to their IDs (so that the persisted
+expression is not affected by renames), and the references to the target
+table columns are replaced with placeholders.  Such references must be
+qualified with the name (or alias) of the target table.  The second argument
+is only used to type the result of the lookup.  The crdb_internal.cdc_lookup
+function evaluates the query as of the MVCC timestamp of the event, and
+caches results; changefeed.expressions.lookup_cache_staleness setting
+controls if cached results may be reused for events with later timestamps.
+With zero staleness, every distinct combination of lookup, arguments and event
+timestamp costs a single read; descriptors of lookup tables are validated via
+leases, so no descriptor transaction is needed per lookup.  Changes to lookup
+table rows are not emitted.  However, changefeed watches lookup tables for
+drops (failing with a terminal error if a lookup table goes away), cached
+results are discarded when lookup table schema changes, and lookup tables are
+protected by the changefeed protected timestamp record along with the targets.
+
 Virtual computed columns can be easily supported but currently are not.
 To support virtual computed columns we must ensure that the expression in that
 column references only the target changefeed column family.
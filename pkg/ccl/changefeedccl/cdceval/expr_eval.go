@@ -74,6 +74,9 @@ type familyEvaluator struct {
 	// updated for each row.
 	// Initialized during preparePlan().
 	rowEvalCtx *rowEvalContext
+
+	// lookups evaluates (and caches) lookup sub-queries.
+	lookups *lookupCache
 }
 
 // NewEvaluator constructs new evaluator for changefeed expression.
@@ -117,6 +120,7 @@ func newFamilyEvaluator(
 		rowCh:       make(chan tree.Datums, 1),
 		statementTS: statementTS,
 		withDiff:    withDiff,
+		lookups:     newLookupCache(execCfg, user, sd),
 	}
 
 	// Arrange to be notified when event does not match predicate.
@@ -289,6 +293,7 @@ func (e *familyEvaluator) preparePlan(
 			e.rowEvalCtx = rowEvalContextFromEvalContext(&execCtx.ExtendedEvalContext().Context)
 			e.rowEvalCtx.withDiff = e.withDiff
 			e.rowEvalCtx.creationTime = e.statementTS
+			e.rowEvalCtx.lookups = e.lookups
 
 			e.norm.desc = e.currDesc
 			requiresPrev := e.prevDesc != nil
@@ -512,6 +517,7 @@ type rowEvalContext struct {
 	withDiff     bool
 	updatedRow   cdcevent.Row
 	op           tree.Datum
+	lookups      *lookupCache
 }
 
 // cdcAnnotationAddr is the address used to store relevant information
@@ -80,6 +80,10 @@ RETURNS FLOAT VOLATILE LANGUAGE SQL AS $$
   SELECT random()
 $$`)
 
+	sqlDB.Exec(t, `CREATE TABLE labels (b STRING PRIMARY KEY, label STRING)`)
+	sqlDB.Exec(t, `INSERT INTO labels VALUES ('1st test', 'first')`)
+	sqlDB.Exec(t, `CREATE VIEW labels_view AS SELECT * FROM labels`)
+
 	desc := cdctest.GetHydratedTableDescriptor(t, s.ExecutorConfig(), "foo")
 
 	type decodeExpectation struct {
@@ -448,22 +452,72 @@ $$`)
 			expectErr:  `function "pg_sleep" unsupported by CDC`,
 		},
 		{
-			testName:   "main/no_subselect",
+			testName:   "main/no_subselect_with",
 			familyName: "main",
-			stmt:       "SELECT cdc_prev, cdc_is_delete(), (select column1 from (values (1,2,3))) FROM foo",
-			expectErr:  `sub-query expressions not supported by CDC`,
+			stmt:       "SELECT (WITH x AS (SELECT 1) SELECT * FROM x) FROM foo",
+			expectErr:  `WITH clause not supported in CDC lookups`,
+		},
+		{
+			testName:   "main/no_lookup_view",
+			familyName: "main",
+			stmt:       "SELECT (SELECT label FROM labels_view WHERE labels_view.b = foo.b) FROM foo",
+			expectErr:  `CDC lookups against "labels_view" are not supported: not a table`,
+		},
+		{
+			testName:   "main/no_cdc_functions_in_lookup",
+			familyName: "main",
+			stmt:       "SELECT (SELECT event_op()) FROM foo",
+			expectErr:  `function "event_op" unsupported in CDC lookups`,
+		},
+		{
+			testName:   "main/no_unqualified_outer_column",
+			familyName: "main",
+			stmt:       "SELECT (SELECT label FROM labels l WHERE l.b = a::STRING) FROM foo",
+			expectErr:  `column "a" does not exist`,
+		},
+		{
+			testName:   "main/subselect",
+			familyName: "main",
+			actions:    []string{"INSERT INTO foo (a, b) VALUES (1, '1st test')"},
+			stmt:       "SELECT a, (SELECT label FROM labels WHERE labels.b = foo.b) AS label FROM foo",
+			expectMainFamily: []decodeExpectation{
+				{
+					keyValues: []string{"1st test", "1"},
+					allValues: map[string]string{"a": "1", "label": "first"},
+				},
+			},
 		},
 		{
-			testName:   "main/no_subselect_in_where",
+			testName:   "main/subselect_in_where",
 			familyName: "main",
-			stmt:       "SELECT cdc_prev FROM foo WHERE a = 2 AND (select 3) = 3",
-			expectErr:  `sub-query expressions not supported by CDC`,
+			actions:    []string{"INSERT INTO foo (a, b) VALUES (1, '1st test'), (2, 'no label')"},
+			stmt:       "SELECT a FROM foo AS f WHERE (SELECT count(*) FROM labels l WHERE l.b = f.b) = 1",
+			expectMainFamily: []decodeExpectation{
+				{
+					keyValues: []string{"1st test", "1"},
+					allValues: map[string]string{"a": "1"},
+				},
+				{
+					expectFiltered: true,
+					keyValues:      []string{"no label", "2"},
+				},
+			},
 		},
 		{
 			testName:   "main/exists_subselect",
 			familyName: "main",
-			stmt:       "SELECT 1 FROM foo WHERE EXISTS (SELECT true)",
-			expectErr:  "sub-query expressions not supported by CDC",
+			actions:    []string{"INSERT INTO foo (a, b) VALUES (1, '1st test'), (2, 'no label')"},
+			stmt:       "SELECT a, EXISTS (SELECT * FROM labels WHERE labels.b = foo.b) AS labeled FROM foo",
+			expectMainFamily: []decodeExpectation{
+				{
+					keyValues: []string{"1st test", "1"},
+					allValues: map[string]string{"a": "1", "labeled": "true"},
+				},
+				{
+					keyValues: []string{"no label", "2"},
+					allValues: map[string]string{"a": "2", "labeled": "false"},
+				},
+			},
 		},
 		{
 			testName:   "main/filter_many",
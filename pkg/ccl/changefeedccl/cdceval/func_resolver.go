@@ -38,16 +38,7 @@ func (rs *cdcFunctionResolver) ResolveFunction(
 		return nil, err
 	}
 
-	fnName := func() string {
-		if !fn.ExplicitSchema {
-			return fn.Object()
-		}
-		var sb strings.Builder
-		sb.WriteString(fn.Schema())
-		sb.WriteByte('.')
-		sb.WriteString(fn.Object())
-		return sb.String()
-	}()
+	fnName := qualifiedFunctionName(&fn)
 
 	if _, denied := functionDenyList[fnName]; denied {
 		return nil, pgerror.Newf(pgcode.UndefinedFunction, "function %q unsupported by CDC", fnName)
@@ -81,6 +72,19 @@ func (rs *cdcFunctionResolver) ResolveFunction(
 	return funcDef, nil
 }
 
+// qualifiedFunctionName returns function name, qualified with the schema name
+// if the schema was explicitly specified.
+func qualifiedFunctionName(fn *tree.RoutineName) string {
+	if !fn.ExplicitSchema {
+		return fn.Object()
+	}
+	var sb strings.Builder
+	sb.WriteString(fn.Schema())
+	sb.WriteByte('.')
+	sb.WriteString(fn.Object())
+	return sb.String()
+}
+
 // ResolveFunctionByOID implements FunctionReferenceResolver interface.
 func (rs *cdcFunctionResolver) ResolveFunctionByOID(
 	ctx context.Context, oid oid.Oid,
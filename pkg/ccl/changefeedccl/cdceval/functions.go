@@ -17,6 +17,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/volatility"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/errors"
 )
 
 // sentinel value indicating we should use default builtin
@@ -113,6 +114,28 @@ var cdcFunctions = map[string]*tree.ResolvedFunctionDefinition{
 			return rowEvalCtx.updatedRow.SchemaTS
 		},
 	),
+	// crdb_internal.cdc_lookup evaluates lookup sub-queries; sub-queries
+	// are rewritten to use this function during normalization (see lookup.go).
+	lookupFnName: makeCDCBuiltIn(
+		lookupFnName,
+		tree.Overload{
+			Types: tree.VariadicType{
+				FixedTypes: []*types.T{types.String, types.Any},
+				VarType:    types.Any,
+			},
+			ReturnType: tree.IdentityReturnType(1),
+			Fn: func(ctx context.Context, evalCtx *eval.Context, args tree.Datums) (tree.Datum, error) {
+				rowEvalCtx := rowEvalContextFromEvalContext(evalCtx)
+				if rowEvalCtx.lookups == nil {
+					return nil, errors.AssertionFailedf("lookups cannot be evaluated outside of changefeed")
+				}
+				return rowEvalCtx.lookups.lookup(rowEvalCtx.ctx,
+					string(tree.MustBeDString(args[0])), rowEvalCtx.updatedRow.MvccTimestamp, args[2:])
+			},
+			Info:              "Evaluates lookup query as of the MVCC timestamp of the event.",
+			Volatility:        volatility.Volatile,
+			CalledOnNullInput: true,
+		}),
 	"changefeed_creation_timestamp": cdcTimestampBuiltin(
 		"changefeed_creation_timestamp",
 		"Returns changefeed creation time.",
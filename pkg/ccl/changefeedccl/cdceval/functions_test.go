@@ -369,8 +369,9 @@ func TestEvaluatesCDCFunctionOverloads(t *testing.T) {
 	// error is returned when cdc function called with wrong arguments.
 	t.Run("cdc function errors", func(t *testing.T) {
 		testRow := makeEventRow(t, desc, s.Clock().Now(), false, s.Clock().Now(), false)
-		// currently, all cdc functions take no args, so call these functions with
-		// some arguments.
+		// cdc functions either take no args, or (crdb_internal.cdc_lookup) expect
+		// string as the first argument, so call these functions with some integer
+		// arguments.
 		rng, _ := randutil.NewTestRand()
 		fnArgs := func() string {
 			switch rng.Int31n(3) {
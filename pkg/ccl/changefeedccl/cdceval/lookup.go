@@ -0,0 +1,721 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package cdceval
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descs"
+	"github.com/cockroachdb/cockroach/pkg/sql/isql"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/cache"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/errors"
+)
+
+// lookupFnName is the name of the function used to evaluate lookup
+// sub-queries. Sub-queries in CDC expressions are rewritten as:
+//
+//	crdb_internal.cdc_lookup('<query>', NULL::<result type>, <outer columns>...)
+//
+// The query references lookup tables by ID, and references columns of the
+// target table via placeholders, which are bound to the remaining arguments.
+// The second argument is used only to type the result of the lookup.
+const lookupFnName = "crdb_internal.cdc_lookup"
+
+// maxCachedLookups is the maximum number of lookup results cached by each
+// family evaluator.
+const maxCachedLookups = 1024
+
+// rewriteLookups rewrites sub-queries in the select clause as calls to
+// crdb_internal.cdc_lookup, and re-validates existing lookups (e.g. when an
+// already normalized expression is normalized again by ALTER CHANGEFEED).
+// Lookup tables are resolved as of the schemaTS.
+func rewriteLookups(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	user username.SQLUsername,
+	sd *sessiondata.SessionData,
+	desc catalog.TableDescriptor,
+	schemaTS hlc.Timestamp,
+	sc *tree.SelectClause,
+) (*tree.SelectClause, error) {
+	r := lookupRewriter{
+		execCfg:  execCfg,
+		desc:     desc,
+		schemaTS: schemaTS,
+		target:   targetTableName(sc),
+		override: sessiondata.InternalExecutorOverride{
+			User:       user,
+			Database:   sd.Database,
+			SearchPath: &sd.SearchPath,
+		},
+	}
+
+	stmt, err := tree.SimpleStmtVisit(sc, func(expr tree.Expr) (bool, tree.Expr, error) {
+		switch e := expr.(type) {
+		case *tree.Subquery:
+			lookup, err := r.rewriteSubquery(ctx, e)
+			return false, lookup, err
+		case *tree.FuncExpr:
+			if isLookupFunc(e) {
+				lookup, err := r.revalidateLookup(ctx, e)
+				return false, lookup, err
+			}
+		}
+		return true, expr, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if rewritten, ok := stmt.(*tree.SelectClause); ok {
+		return rewritten, nil
+	}
+	// We walked tree.SelectClause -- getting anything else would be surprising.
+	return nil, errors.AssertionFailedf("unexpected result type %T", stmt)
+}
+
+// targetTableName returns the name used to refer to the target table
+// in the select clause.
+func targetTableName(sc *tree.SelectClause) tree.Name {
+	if len(sc.From.Tables) != 1 {
+		return ""
+	}
+	switch t := sc.From.Tables[0].(type) {
+	case *tree.AliasedTableExpr:
+		if t.As.Alias != "" {
+			return t.As.Alias
+		}
+		if tn, ok := t.Expr.(*tree.TableName); ok {
+			return tn.ObjectName
+		}
+	case *tree.TableName:
+		return t.ObjectName
+	}
+	return ""
+}
+
+// isLookupFunc returns true if fn is a call to crdb_internal.cdc_lookup.
+func isLookupFunc(fn *tree.FuncExpr) bool {
+	n, ok := fn.Func.FunctionReference.(*tree.UnresolvedName)
+	if !ok {
+		return false
+	}
+	name, err := n.ToRoutineName()
+	if err != nil {
+		return false
+	}
+	return qualifiedFunctionName(&name) == lookupFnName
+}
+
+// lookupRewriter rewrites sub-queries into lookups.
+type lookupRewriter struct {
+	execCfg  *sql.ExecutorConfig
+	override sessiondata.InternalExecutorOverride
+	desc     catalog.TableDescriptor
+	schemaTS hlc.Timestamp
+	// target is the name (or alias) of the target table; column references
+	// qualified with this name are references to the event row.
+	target tree.Name
+}
+
+// rewriteSubquery rewrites sub-query as a lookup.
+func (r *lookupRewriter) rewriteSubquery(
+	ctx context.Context, sub *tree.Subquery,
+) (*tree.FuncExpr, error) {
+	var args tree.Exprs
+	argIdx := make(map[descpb.ColumnID]int)
+
+	w := lookupWalker{
+		visitTable: func(t *tree.AliasedTableExpr) error {
+			return r.resolveTable(ctx, t)
+		},
+		visitExpr: func(expr tree.Expr) (bool, tree.Expr, error) {
+			switch e := expr.(type) {
+			case *tree.FuncExpr:
+				if err := checkLookupFunction(ctx, e); err != nil {
+					return false, expr, err
+				}
+			case *tree.UnresolvedName:
+				if e.Star || e.NumParts != 2 || r.target == "" || tree.Name(e.Parts[1]) != r.target {
+					return true, expr, nil
+				}
+				// Reference to the target table column: replace it with a placeholder
+				// bound to the column value when the lookup is evaluated.
+				col, err := catalog.MustFindColumnByTreeName(r.desc, tree.Name(e.Parts[0]))
+				if err != nil {
+					return false, expr, err
+				}
+				idx, ok := argIdx[col.GetID()]
+				if !ok {
+					idx = len(args)
+					argIdx[col.GetID()] = idx
+					args = append(args, e)
+				}
+				return false, &tree.CastExpr{
+					Expr:       &tree.Placeholder{Idx: tree.PlaceholderIdx(idx)},
+					Type:       col.GetType(),
+					SyntaxMode: tree.CastShort,
+				}, nil
+			}
+			return true, expr, nil
+		},
+	}
+	if err := w.walkSelectStatement(sub.Select); err != nil {
+		return nil, err
+	}
+
+	// Wrap sub-query into a select so that the lookup always returns
+	// exactly one row.
+	query := &tree.Select{
+		Select: &tree.SelectClause{Exprs: tree.SelectExprs{{Expr: sub}}},
+	}
+	return r.makeLookup(ctx, AsStringUnredacted(query), args)
+}
+
+// revalidateLookup validates existing crdb_internal.cdc_lookup call.
+func (r *lookupRewriter) revalidateLookup(
+	ctx context.Context, fn *tree.FuncExpr,
+) (*tree.FuncExpr, error) {
+	if len(fn.Exprs) < 2 {
+		return nil, pgerror.Newf(pgcode.WrongObjectType,
+			"%s expects at least 2 arguments, found %d", lookupFnName, len(fn.Exprs))
+	}
+	q, ok := fn.Exprs[0].(*tree.StrVal)
+	if !ok {
+		return nil, pgerror.Newf(pgcode.WrongObjectType,
+			"%s expects query string literal, found %s", lookupFnName, tree.AsString(fn.Exprs[0]))
+	}
+
+	query, err := parseLookupQuery(q.RawString())
+	if err != nil {
+		return nil, err
+	}
+
+	w := lookupWalker{
+		visitTable: func(t *tree.AliasedTableExpr) error {
+			ref, ok := t.Expr.(*tree.TableRef)
+			if !ok {
+				return pgerror.Newf(pgcode.FeatureNotSupported,
+					"%s expects tables referenced by ID, found %s", lookupFnName, tree.AsString(t.Expr))
+			}
+			return r.withTxn(ctx, func(ctx context.Context, txn isql.Txn, col *descs.Collection) error {
+				_, err := checkLookupTable(ctx, txn, col, descpb.ID(ref.TableID))
+				return err
+			})
+		},
+		visitExpr: func(expr tree.Expr) (bool, tree.Expr, error) {
+			if e, ok := expr.(*tree.FuncExpr); ok {
+				if err := checkLookupFunction(ctx, e); err != nil {
+					return false, expr, err
+				}
+			}
+			return true, expr, nil
+		},
+	}
+	if err := w.walkSelect(query); err != nil {
+		return nil, err
+	}
+	return r.makeLookup(ctx, q.RawString(), fn.Exprs[2:])
+}
+
+// makeLookup returns crdb_internal.cdc_lookup function call for the query.
+func (r *lookupRewriter) makeLookup(
+	ctx context.Context, query string, args tree.Exprs,
+) (*tree.FuncExpr, error) {
+	var typ *types.T
+	if err := r.withTxn(ctx, func(ctx context.Context, txn isql.Txn, _ *descs.Collection) error {
+		// Lookup query is typed (and privileges checked) by planning it with
+		// all placeholders bound to NULL.
+		qargs := make([]interface{}, len(args))
+		_, cols, err := txn.QueryBufferedExWithCols(ctx, "cdc-lookup-type", txn.KV(), r.override,
+			fmt.Sprintf("SELECT * FROM (%s) AS cdc_lookup LIMIT 0", query), qargs...)
+		if err != nil {
+			return err
+		}
+		if len(cols) != 1 {
+			return errors.AssertionFailedf("expected 1 lookup column, found %d", len(cols))
+		}
+		typ = cols[0].Typ
+		return nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "invalid lookup %s", query)
+	}
+
+	switch typ.Family() {
+	case types.TupleFamily:
+		return nil, pgerror.Newf(pgcode.Syntax,
+			"lookup sub-query must return only one column: %s", query)
+	case types.UnknownFamily:
+		return nil, pgerror.Newf(pgcode.IndeterminateDatatype,
+			"could not determine data type of lookup sub-query: %s", query)
+	}
+
+	return &tree.FuncExpr{
+		Func: tree.ResolvableFunctionReference{
+			FunctionReference: tree.NewUnresolvedName("crdb_internal", "cdc_lookup"),
+		},
+		Exprs: append(tree.Exprs{
+			tree.NewStrVal(query),
+			&tree.CastExpr{Expr: tree.DNull, Type: typ, SyntaxMode: tree.CastShort},
+		}, args...),
+	}, nil
+}
+
+// resolveTable replaces table name with the reference to the table ID.
+func (r *lookupRewriter) resolveTable(ctx context.Context, t *tree.AliasedTableExpr) error {
+	switch tbl := t.Expr.(type) {
+	case *tree.TableName:
+		var id descpb.ID
+		if err := r.withTxn(ctx, func(ctx context.Context, txn isql.Txn, col *descs.Collection) error {
+			row, err := txn.QueryRowEx(ctx, "cdc-lookup-resolve", txn.KV(), r.override,
+				"SELECT $1::REGCLASS::INT8", tree.AsStringWithFlags(tbl, tree.FmtParsable))
+			if err != nil {
+				return err
+			}
+			if row == nil {
+				return errors.AssertionFailedf("expected to resolve %s", tree.AsString(tbl))
+			}
+			id = descpb.ID(tree.MustBeDInt(row[0]))
+			_, err = checkLookupTable(ctx, txn, col, id)
+			return err
+		}); err != nil {
+			return err
+		}
+
+		ref := &tree.TableRef{TableID: int64(id)}
+		if t.As.Alias == "" {
+			// Keep the table name visible to the rest of the query.
+			ref.As.Alias = tbl.ObjectName
+		}
+		t.Expr = ref
+		return nil
+	case *tree.TableRef:
+		return r.withTxn(ctx, func(ctx context.Context, txn isql.Txn, col *descs.Collection) error {
+			_, err := checkLookupTable(ctx, txn, col, descpb.ID(tbl.TableID))
+			return err
+		})
+	default:
+		return pgerror.Newf(pgcode.FeatureNotSupported,
+			"%s not supported in CDC lookups", tree.AsString(t.Expr))
+	}
+}
+
+// withTxn runs fn in a transaction as of the schema timestamp.
+func (r *lookupRewriter) withTxn(
+	ctx context.Context, fn func(ctx context.Context, txn isql.Txn, col *descs.Collection) error,
+) error {
+	return sql.DescsTxn(ctx, r.execCfg, func(ctx context.Context, txn isql.Txn, col *descs.Collection) error {
+		if err := txn.KV().SetFixedTimestamp(ctx, r.schemaTS); err != nil {
+			return err
+		}
+		return fn(ctx, txn, col)
+	})
+}
+
+// checkLookupTable verifies that the descriptor with specified ID is a table
+// that can be used in lookups.
+func checkLookupTable(
+	ctx context.Context, txn isql.Txn, col *descs.Collection, id descpb.ID,
+) (catalog.TableDescriptor, error) {
+	desc, err := col.ByIDWithoutLeased(txn.KV()).WithoutNonPublic().Get().Table(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return desc, validateLookupTable(desc)
+}
+
+// validateLookupTable verifies that the table can be used in lookups.
+func validateLookupTable(desc catalog.TableDescriptor) error {
+	if !desc.IsTable() || desc.IsVirtualTable() {
+		return pgerror.Newf(pgcode.WrongObjectType,
+			"CDC lookups against %q are not supported: not a table", desc.GetName())
+	}
+	return nil
+}
+
+// checkLookupFunction verifies that the function may be used in lookups.
+// Functions that are not supported by CDC are not supported in lookups either.
+// CDC specific functions are not available in lookups since lookups are
+// evaluated outside the changefeed expression.
+func checkLookupFunction(ctx context.Context, fnCall *tree.FuncExpr) error {
+	n, ok := fnCall.Func.FunctionReference.(*tree.UnresolvedName)
+	if !ok {
+		return checkFunctionSupported(ctx, fnCall, nil /* semaCtx */)
+	}
+	name, err := n.ToRoutineName()
+	if err != nil {
+		return err
+	}
+	fnName := qualifiedFunctionName(&name)
+	if _, denied := functionDenyList[fnName]; denied {
+		return pgerror.Newf(pgcode.UndefinedFunction, "function %q unsupported by CDC", fnName)
+	}
+	if def, found := cdcFunctions[fnName]; found && def != useDefaultBuiltin {
+		return pgerror.Newf(pgcode.UndefinedFunction, "function %q unsupported in CDC lookups", fnName)
+	}
+	return nil
+}
+
+// parseLookupQuery parses lookup query.
+func parseLookupQuery(query string) (*tree.Select, error) {
+	stmt, err := parser.ParseOne(query)
+	if err != nil {
+		return nil, err
+	}
+	sel, ok := stmt.AST.(*tree.Select)
+	if !ok {
+		return nil, pgerror.Newf(pgcode.Syntax, "expected lookup select, found %s", stmt.AST.StatementTag())
+	}
+	return sel, nil
+}
+
+// lookupWalker walks lookup sub-query, invoking visitTable for each table
+// and visitExpr for each scalar expression (including the ones inside
+// nested sub-queries). The sub-query is modified in place.
+type lookupWalker struct {
+	visitTable func(t *tree.AliasedTableExpr) error
+	visitExpr  tree.SimpleVisitFn
+}
+
+func (w *lookupWalker) walkSelect(s *tree.Select) (err error) {
+	if s.With != nil {
+		return pgerror.New(pgcode.FeatureNotSupported, "WITH clause not supported in CDC lookups")
+	}
+	if len(s.Locking) > 0 {
+		return pgerror.New(pgcode.FeatureNotSupported, "locking clause not supported in CDC lookups")
+	}
+	if err := w.walkSelectStatement(s.Select); err != nil {
+		return err
+	}
+	for _, o := range s.OrderBy {
+		if o.Expr, err = w.walkExpr(o.Expr); err != nil {
+			return err
+		}
+	}
+	if s.Limit != nil {
+		if s.Limit.Count, err = w.walkExpr(s.Limit.Count); err != nil {
+			return err
+		}
+		if s.Limit.Offset, err = w.walkExpr(s.Limit.Offset); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *lookupWalker) walkSelectStatement(stmt tree.SelectStatement) (err error) {
+	switch s := stmt.(type) {
+	case *tree.ParenSelect:
+		return w.walkSelect(s.Select)
+	case *tree.SelectClause:
+		if s.From.AsOf.Expr != nil {
+			return pgerror.New(pgcode.FeatureNotSupported,
+				"AS OF SYSTEM TIME not supported in CDC lookups")
+		}
+		for i := range s.From.Tables {
+			if s.From.Tables[i], err = w.walkTable(s.From.Tables[i]); err != nil {
+				return err
+			}
+		}
+		for i := range s.DistinctOn {
+			if s.DistinctOn[i], err = w.walkExpr(s.DistinctOn[i]); err != nil {
+				return err
+			}
+		}
+		for i := range s.Exprs {
+			if s.Exprs[i].Expr, err = w.walkExpr(s.Exprs[i].Expr); err != nil {
+				return err
+			}
+		}
+		for i := range s.GroupBy {
+			if s.GroupBy[i], err = w.walkExpr(s.GroupBy[i]); err != nil {
+				return err
+			}
+		}
+		for _, where := range []*tree.Where{s.Where, s.Having} {
+			if where == nil {
+				continue
+			}
+			if where.Expr, err = w.walkExpr(where.Expr); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *tree.UnionClause:
+		if err := w.walkSelect(s.Left); err != nil {
+			return err
+		}
+		return w.walkSelect(s.Right)
+	case *tree.ValuesClause:
+		for _, row := range s.Rows {
+			for i := range row {
+				if row[i], err = w.walkExpr(row[i]); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	default:
+		return pgerror.Newf(pgcode.FeatureNotSupported,
+			"%s not supported in CDC lookups", tree.AsString(stmt))
+	}
+}
+
+func (w *lookupWalker) walkTable(te tree.TableExpr) (_ tree.TableExpr, err error) {
+	switch t := te.(type) {
+	case *tree.AliasedTableExpr:
+		if sub, ok := t.Expr.(*tree.Subquery); ok {
+			return te, w.walkSelectStatement(sub.Select)
+		}
+		return te, w.visitTable(t)
+	case *tree.TableName, *tree.TableRef:
+		aliased := &tree.AliasedTableExpr{Expr: t}
+		return aliased, w.visitTable(aliased)
+	case *tree.ParenTableExpr:
+		t.Expr, err = w.walkTable(t.Expr)
+		return te, err
+	case *tree.JoinTableExpr:
+		if t.Left, err = w.walkTable(t.Left); err != nil {
+			return te, err
+		}
+		if t.Right, err = w.walkTable(t.Right); err != nil {
+			return te, err
+		}
+		if on, ok := t.Cond.(*tree.OnJoinCond); ok {
+			on.Expr, err = w.walkExpr(on.Expr)
+		}
+		return te, err
+	default:
+		return te, pgerror.Newf(pgcode.FeatureNotSupported,
+			"%s not supported in CDC lookups", tree.AsString(te))
+	}
+}
+
+func (w *lookupWalker) walkExpr(expr tree.Expr) (tree.Expr, error) {
+	if expr == nil {
+		return nil, nil
+	}
+	return tree.SimpleVisit(expr, func(expr tree.Expr) (bool, tree.Expr, error) {
+		if sub, ok := expr.(*tree.Subquery); ok {
+			return false, expr, w.walkSelectStatement(sub.Select)
+		}
+		return w.visitExpr(expr)
+	})
+}
+
+// LookupTableIDs returns the IDs of the tables referenced by the lookups of a
+// normalized changefeed expression. The changefeed watches these tables for
+// drops and protects them from garbage collection, along with its targets.
+func LookupTableIDs(selectClause string) ([]descpb.ID, error) {
+	sc, err := ParseChangefeedExpression(selectClause)
+	if err != nil {
+		return nil, err
+	}
+	var ids []descpb.ID
+	if _, err := tree.SimpleStmtVisit(sc, func(expr tree.Expr) (bool, tree.Expr, error) {
+		fn, ok := expr.(*tree.FuncExpr)
+		if !ok || !isLookupFunc(fn) {
+			return true, expr, nil
+		}
+		if len(fn.Exprs) == 0 {
+			return false, expr, nil
+		}
+		q, ok := fn.Exprs[0].(*tree.StrVal)
+		if !ok {
+			return false, expr, nil
+		}
+		tableIDs, err := lookupQueryTables(q.RawString())
+		if err != nil {
+			return false, expr, err
+		}
+		ids = append(ids, tableIDs...)
+		return false, expr, nil
+	}); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
+
+// lookupCache evaluates lookups, caching their results.
+// Lookups are evaluated as of the MVCC timestamp of the event.
+type lookupCache struct {
+	execCfg  *sql.ExecutorConfig
+	override sessiondata.InternalExecutorOverride
+
+	// tables lists IDs of the tables referenced by each lookup query.
+	tables map[string][]descpb.ID
+	// versions records the last observed version of each lookup table;
+	// cached results are discarded when lookup table schema changes.
+	versions map[descpb.ID]descpb.DescriptorVersion
+	results  *cache.UnorderedCache
+}
+
+type lookupKey struct {
+	query string
+	args  string
+}
+
+type lookupResult struct {
+	datum  tree.Datum
+	readTS hlc.Timestamp
+}
+
+func newLookupCache(
+	execCfg *sql.ExecutorConfig, user username.SQLUsername, sd *sessiondata.SessionData,
+) *lookupCache {
+	return &lookupCache{
+		execCfg: execCfg,
+		override: sessiondata.InternalExecutorOverride{
+			User:       user,
+			Database:   sd.Database,
+			SearchPath: &sd.SearchPath,
+		},
+		tables:   make(map[string][]descpb.ID),
+		versions: make(map[descpb.ID]descpb.DescriptorVersion),
+		results: cache.NewUnorderedCache(cache.Config{
+			Policy: cache.CacheLRU,
+			ShouldEvict: func(size int, _, _ interface{}) bool {
+				return size > maxCachedLookups
+			},
+		}),
+	}
+}
+
+// lookup evaluates lookup query with specified arguments as of the
+// specified timestamp.  Cached result read at an earlier timestamp may be
+// returned if it is not older than changefeed.expressions.lookup_cache_staleness.
+func (c *lookupCache) lookup(
+	ctx context.Context, query string, ts hlc.Timestamp, args tree.Datums,
+) (tree.Datum, error) {
+	key := lookupKey{query: query, args: tree.AsStringWithFlags(&args, tree.FmtParsable)}
+	staleness := changefeedbase.LookupCacheStaleness.Get(&c.execCfg.Settings.SV)
+	if v, ok := c.results.Get(key); ok {
+		res := v.(*lookupResult)
+		if res.readTS == ts || (res.readTS.Less(ts) && ts.Less(res.readTS.AddDuration(staleness))) {
+			return res.datum, nil
+		}
+	}
+
+	tableIDs, err := c.lookupTables(query)
+	if err != nil {
+		return nil, err
+	}
+
+	// The lookup tables are validated against their leased descriptors, which
+	// the lease manager caches, so that a lookup only costs the read of the
+	// query itself.
+	schemaChanged := false
+	for _, id := range tableIDs {
+		desc, err := c.leasedLookupTable(ctx, id, ts)
+		if err != nil {
+			return nil, errors.Wrapf(err, "evaluating lookup %s", query)
+		}
+		if v, ok := c.versions[id]; ok && v != desc.GetVersion() {
+			schemaChanged = true
+		}
+		c.versions[id] = desc.GetVersion()
+	}
+	if schemaChanged {
+		c.results.Clear()
+	}
+
+	var result tree.Datum
+	if err := c.execCfg.InternalDB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		if err := txn.KV().SetFixedTimestamp(ctx, ts); err != nil {
+			return err
+		}
+		qargs := make([]interface{}, len(args))
+		for i := range args {
+			qargs[i] = args[i]
+		}
+		row, err := txn.QueryRowEx(ctx, "cdc-lookup", txn.KV(), c.override, query, qargs...)
+		if err != nil {
+			return err
+		}
+		result = tree.DNull
+		if row != nil {
+			result = row[0]
+		}
+		return nil
+	}); err != nil {
+		return nil, errors.Wrapf(err, "evaluating lookup %s", query)
+	}
+
+	c.results.Add(key, &lookupResult{datum: result, readTS: ts})
+	return result, nil
+}
+
+// leasedLookupTable returns the leased descriptor of the lookup table as of
+// the specified timestamp.
+func (c *lookupCache) leasedLookupTable(
+	ctx context.Context, id descpb.ID, ts hlc.Timestamp,
+) (catalog.TableDescriptor, error) {
+	ld, err := c.execCfg.LeaseManager.Acquire(ctx, ts, id)
+	if err != nil {
+		return nil, err
+	}
+	// The lease is only needed to read the descriptor as of ts.
+	defer ld.Release(ctx)
+	desc, ok := ld.Underlying().(catalog.TableDescriptor)
+	if !ok {
+		return nil, pgerror.Newf(pgcode.WrongObjectType,
+			"CDC lookups against %q are not supported: not a table", ld.Underlying().GetName())
+	}
+	return desc, validateLookupTable(desc)
+}
+
+// lookupTables returns the IDs of the tables referenced by the lookup query.
+func (c *lookupCache) lookupTables(query string) ([]descpb.ID, error) {
+	if ids, ok := c.tables[query]; ok {
+		return ids, nil
+	}
+	ids, err := lookupQueryTables(query)
+	if err != nil {
+		return nil, err
+	}
+	c.tables[query] = ids
+	return ids, nil
+}
+
+// lookupQueryTables returns the IDs of the tables referenced by the lookup
+// query.
+func lookupQueryTables(query string) ([]descpb.ID, error) {
+	sel, err := parseLookupQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	var ids []descpb.ID
+	w := lookupWalker{
+		visitTable: func(t *tree.AliasedTableExpr) error {
+			ref, ok := t.Expr.(*tree.TableRef)
+			if !ok {
+				return errors.AssertionFailedf("expected table reference, found %s", tree.AsString(t.Expr))
+			}
+			ids = append(ids, descpb.ID(ref.TableID))
+			return nil
+		},
+		visitExpr: func(expr tree.Expr) (bool, tree.Expr, error) {
+			return true, expr, nil
+		},
+	}
+	if err := w.walkSelect(sel); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
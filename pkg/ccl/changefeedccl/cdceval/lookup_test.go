@@ -0,0 +1,135 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package cdceval
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdctest"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookups(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	srv, db, _ := serverutils.StartServer(t, base.TestServerArgs{})
+	defer srv.Stopper().Stop(ctx)
+	s := srv.ApplicationLayer()
+	execCfg := s.ExecutorConfig().(sql.ExecutorConfig)
+
+	sqlDB := sqlutils.MakeSQLRunner(db)
+	sqlDB.ExecMultiple(t,
+		`CREATE TABLE customers (id INT PRIMARY KEY, name STRING)`,
+		`CREATE TABLE orders (id INT PRIMARY KEY, customer_id INT)`,
+		`INSERT INTO customers VALUES (1, 'alice')`,
+	)
+	desc := cdctest.GetHydratedTableDescriptor(t, s.ExecutorConfig(), "orders")
+	customers := cdctest.GetHydratedTableDescriptor(t, s.ExecutorConfig(), "customers")
+	target := jobspb.ChangefeedTargetSpecification{
+		Type:       jobspb.ChangefeedTargetSpecification_PRIMARY_FAMILY_ONLY,
+		TableID:    desc.GetID(),
+		FamilyName: desc.GetFamilies()[0].Name,
+	}
+
+	normalize := func(stmt string) (string, error) {
+		sc, err := ParseChangefeedExpression(stmt)
+		if err != nil {
+			return "", err
+		}
+		norm, _, _, err := normalizeAndPlan(ctx, &execCfg, username.RootUserName(),
+			defaultDBSessionData, desc, s.Clock().Now(), target, sc, false /* splitFams */)
+		if err != nil {
+			return "", err
+		}
+		return AsStringUnredacted(norm), nil
+	}
+
+	normalized, err := normalize(
+		"SELECT id, (SELECT name FROM customers WHERE customers.id = orders.customer_id) AS name FROM orders")
+	require.NoError(t, err)
+	query := fmt.Sprintf(
+		"SELECT (SELECT name FROM [%d AS customers] WHERE customers.id = $1::INT8)", customers.GetID())
+	require.Equal(t, fmt.Sprintf(
+		"SELECT id, crdb_internal.cdc_lookup('%s', NULL::STRING, orders.customer_id) AS name FROM orders",
+		query), normalized)
+
+	t.Run("renormalize", func(t *testing.T) {
+		// Lookup tables are referenced by ID; renaming lookup table does not
+		// affect normalized expression.
+		sqlDB.Exec(t, `ALTER TABLE customers RENAME TO clients`)
+		defer sqlDB.Exec(t, `ALTER TABLE clients RENAME TO customers`)
+
+		renormalized, err := normalize(normalized)
+		require.NoError(t, err)
+		require.Equal(t, normalized, renormalized)
+	})
+
+	t.Run("reject non-table references", func(t *testing.T) {
+		_, err := normalize("SELECT crdb_internal.cdc_lookup('SELECT (SELECT name FROM customers)', NULL::STRING) FROM orders")
+		require.Regexp(t, "expects tables referenced by ID", err)
+	})
+
+	t.Run("lookup table ids", func(t *testing.T) {
+		// Lookup tables of the normalized expression are watched and protected by
+		// the changefeed.
+		ids, err := LookupTableIDs(normalized)
+		require.NoError(t, err)
+		require.Equal(t, []descpb.ID{customers.GetID()}, ids)
+
+		ids, err = LookupTableIDs("SELECT id FROM orders")
+		require.NoError(t, err)
+		require.Empty(t, ids)
+	})
+
+	t.Run("lookup at event timestamp", func(t *testing.T) {
+		defer changefeedbase.LookupCacheStaleness.Override(ctx, &s.ClusterSettings().SV, 0)
+
+		lookups := newLookupCache(&execCfg, username.RootUserName(), defaultDBSessionData)
+		args := tree.Datums{tree.NewDInt(1)}
+		before := s.Clock().Now()
+		sqlDB.Exec(t, `UPDATE customers SET name = 'bob' WHERE id = 1`)
+		after := s.Clock().Now()
+
+		for _, tc := range []struct {
+			staleness time.Duration
+			expectNow string
+		}{
+			{staleness: 0, expectNow: "'bob'"},
+			{staleness: time.Hour, expectNow: "'alice'"},
+		} {
+			changefeedbase.LookupCacheStaleness.Override(ctx, &s.ClusterSettings().SV, tc.staleness)
+			d, err := lookups.lookup(ctx, query, before, args)
+			require.NoError(t, err)
+			require.Equal(t, "'alice'", d.String())
+
+			d, err = lookups.lookup(ctx, query, after, args)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectNow, d.String())
+		}
+
+		// Lookups fail once lookup table is dropped.
+		changefeedbase.LookupCacheStaleness.Override(ctx, &s.ClusterSettings().SV, 0)
+		sqlDB.Exec(t, `DROP TABLE customers`)
+		_, err := lookups.lookup(ctx, query, s.Clock().Now(), args)
+		require.Regexp(t, "evaluating lookup", err)
+	})
+}
@@ -62,6 +62,14 @@ func normalizeExpression(
 	sc *tree.SelectClause,
 	splitFams bool,
 ) (*NormalizedSelectClause, bool, error) {
+	// Rewrite sub-queries as lookups prior to validation, which only
+	// expects to see the target table columns.
+	sc, err := rewriteLookups(
+		ctx, execCtx.ExecCfg(), execCtx.User(), execCtx.SessionData(), descr, schemaTS, sc)
+	if err != nil {
+		return nil, false, changefeedbase.WithTerminalError(err)
+	}
+
 	norm, err := normalizeAndValidateSelectForTarget(
 		ctx, execCtx.ExecCfg(), descr, schemaTS, target, sc, false /* keyOnly */, splitFams, execCtx.SemaCtx())
 	if err != nil {
@@ -41,6 +41,8 @@ const virtualColOrd = 1<<31 - 1
 type Metadata struct {
 	TableID          descpb.ID                // Table ID.
 	TableName        string                   // Table name.
+	DatabaseName     string                   // Name of the table's database, if known.
+	SchemaName       string                   // Name of the table's schema, if known.
 	Version          descpb.DescriptorVersion // Table descriptor version.
 	FamilyID         descpb.FamilyID          // Column family ID.
 	FamilyName       string                   // Column family name.
@@ -430,6 +432,7 @@ func (d *EventDescriptor) TableDescriptor() catalog.TableDescriptor {
 }
 
 type eventDescriptorFactory func(
+	ctx context.Context,
 	desc catalog.TableDescriptor,
 	family *descpb.ColumnFamilyDescriptor,
 	schemaTS hlc.Timestamp,
@@ -456,12 +459,14 @@ type eventDecoder struct {
 }
 
 func getEventDescriptorCached(
+	ctx context.Context,
 	desc catalog.TableDescriptor,
 	family *descpb.ColumnFamilyDescriptor,
 	includeVirtual bool,
 	keyOnly bool,
 	schemaTS hlc.Timestamp,
 	cache *cache.UnorderedCache,
+	descFetcher tableDescFetcher,
 ) (*EventDescriptor, error) {
 	idVer := CacheKey{ID: desc.GetID(), Version: desc.GetVersion(), FamilyID: family.ID}
 
@@ -476,6 +481,12 @@ func getEventDescriptorCached(
 	if err != nil {
 		return nil, err
 	}
+	// NB: The names are cached along with the descriptor version, so renaming
+	// the database or schema is only reflected once the table version changes.
+	ed.DatabaseName, ed.SchemaName, err = descFetcher.FetchNamespaceNames(ctx, desc, schemaTS)
+	if err != nil {
+		return nil, err
+	}
 	cache.Add(idVer, ed)
 	return ed, nil
 }
@@ -510,11 +521,13 @@ func NewEventDecoderWithCache(
 ) Decoder {
 	eventDescriptorCache := cache.NewUnorderedCache(DefaultCacheConfig)
 	getEventDescriptor := func(
+		ctx context.Context,
 		desc catalog.TableDescriptor,
 		family *descpb.ColumnFamilyDescriptor,
 		schemaTS hlc.Timestamp,
 	) (*EventDescriptor, error) {
-		return getEventDescriptorCached(desc, family, includeVirtual, keyOnly, schemaTS, eventDescriptorCache)
+		return getEventDescriptorCached(
+			ctx, desc, family, includeVirtual, keyOnly, schemaTS, eventDescriptorCache, rfCache.descFetcher)
 	}
 
 	return &eventDecoder{
@@ -580,7 +593,7 @@ func (d *eventDecoder) decodeKV(
 		return Row{}, err
 	}
 
-	ed, err := d.getEventDescriptor(d.desc, d.family, schemaTS)
+	ed, err := d.getEventDescriptor(ctx, d.desc, d.family, schemaTS)
 	if err != nil {
 		return Row{}, err
 	}
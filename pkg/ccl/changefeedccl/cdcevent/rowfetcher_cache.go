@@ -22,6 +22,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/lease"
 	"github.com/cockroachdb/cockroach/pkg/sql/row"
 	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/catconstants"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/cache"
@@ -60,6 +61,11 @@ type rowFetcherCache struct {
 type tableDescFetcher interface {
 	// FetchTableDesc returns the TableDescriptor for the gven descriptor ID at the given timestamp.
 	FetchTableDesc(context.Context, descpb.ID, hlc.Timestamp) (catalog.TableDescriptor, error)
+	// FetchNamespaceNames returns the names of the database and schema of the
+	// table at the given timestamp.
+	FetchNamespaceNames(
+		context.Context, catalog.TableDescriptor, hlc.Timestamp,
+	) (database string, schema string, _ error)
 }
 
 // dbTableDescFetcher is a tableDescFetcher that fetches table
@@ -94,6 +100,33 @@ func (f *dbTableDescFetcher) FetchTableDesc(
 	return tableDesc, nil
 }
 
+// FetchNamespaceNames implements tableDescFetcher.
+func (f *dbTableDescFetcher) FetchNamespaceNames(
+	ctx context.Context, tableDesc catalog.TableDescriptor, ts hlc.Timestamp,
+) (database string, schema string, _ error) {
+	name := func(id descpb.ID) (string, error) {
+		desc, err := f.leaseMgr.Acquire(ctx, ts, id)
+		if err != nil {
+			return "", changefeedbase.MarkRetryableError(err)
+		}
+		defer desc.Release(ctx)
+		return desc.Underlying().GetName(), nil
+	}
+	database, err := name(tableDesc.GetParentID())
+	if err != nil {
+		return "", "", err
+	}
+	// The public schema of the system database has no descriptor.
+	if tableDesc.GetParentSchemaID() == keys.PublicSchemaID {
+		return database, catconstants.PublicSchemaName, nil
+	}
+	schema, err = name(tableDesc.GetParentSchemaID())
+	if err != nil {
+		return "", "", err
+	}
+	return database, schema, nil
+}
+
 func refreshUDT(
 	ctx context.Context, tableID descpb.ID, db *kv.DB, collection *descs.Collection, ts hlc.Timestamp,
 ) (tableDesc catalog.TableDescriptor, err error) {
@@ -347,6 +380,14 @@ func (f *fixedDescFetcher) FetchTableDesc(
 	}
 }
 
+// FetchNamespaceNames implements tableDescFetcher. The fixed set of
+// descriptors has no database or schema descriptors, so no names are returned.
+func (f *fixedDescFetcher) FetchNamespaceNames(
+	context.Context, catalog.TableDescriptor, hlc.Timestamp,
+) (database string, schema string, _ error) {
+	return "", "", nil
+}
+
 // newFixedRowFetcherCache constructs row fetcher cache that uses only the fixed
 // set of descriptors provided.
 //
@@ -9,6 +9,7 @@ import (
 	"context"
 	"encoding/json"
 
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdceval"
 	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
 	"github.com/cockroachdb/cockroach/pkg/cloud"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
@@ -53,6 +54,13 @@ func AllTargets(cd jobspb.ChangefeedDetails) (targets changefeedbase.Targets) {
 	// TODO: Use a version gate for this once we have CDC version gates
 	if len(cd.TargetSpecifications) > 0 {
 		for _, ts := range cd.TargetSpecifications {
+			if ts.Type == jobspb.ChangefeedTargetSpecification_DATABASE {
+				targets.AddNamespace(changefeedbase.Namespace{
+					DatabaseID: ts.DatabaseID,
+					SchemaID:   ts.SchemaID,
+				})
+				continue
+			}
 			if ts.TableID > 0 {
 				if ts.StatementTimeName == "" {
 					ts.StatementTimeName = cd.Tables[ts.TableID].StatementTimeName
@@ -74,6 +82,18 @@ func AllTargets(cd jobspb.ChangefeedDetails) (targets changefeedbase.Targets) {
 			})
 		}
 	}
+	if cd.Select != "" {
+		// The expression was validated when the changefeed was created; should
+		// it fail to parse, the error is surfaced by the evaluator.
+		lookupTables, _ := cdceval.LookupTableIDs(cd.Select)
+		for _, id := range lookupTables {
+			if isTarget, _ := targets.EachHavingTableID(id, func(changefeedbase.Target) error {
+				return nil
+			}); !isTarget {
+				targets.AddLookupTable(id)
+			}
+		}
+	}
 	return
 }
 
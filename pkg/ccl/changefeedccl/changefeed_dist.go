@@ -312,6 +312,7 @@ func startDistChangefeed(
 		}
 
 		jobsprofiler.StorePlanDiagram(ctx, execCfg.DistSQLSrv.Stopper, p, execCfg.InternalDB, jobID)
+		clearSpanReports(ctx, execCfg.InternalDB, jobID)
 
 		// Make sure to use special changefeed monitor going forward as the
 		// parent monitor for the DistSQL infrastructure. This is needed to
@@ -137,6 +137,14 @@ func (o *changeAggregatorLowerBoundOracle) inclusiveLowerBoundTS() hlc.Timestamp
 	return o.initialInclusiveLowerBound
 }
 
+// atSchemaChangeBoundary returns true if the local frontier has reached a
+// schema change boundary. It's used by the cloud storage sink to write out rows
+// up to the boundary with the exactly_once option.
+func (o *changeAggregatorLowerBoundOracle) atSchemaChangeBoundary() bool {
+	sf, ok := o.sf.(*schemaChangeFrontier)
+	return ok && sf.schemaChangeBoundaryReached()
+}
+
 var _ execinfra.Processor = &changeAggregator{}
 var _ execinfra.RowSource = &changeAggregator{}
 
@@ -352,7 +360,7 @@ func (ca *changeAggregator) Start(ctx context.Context) {
 	}
 
 	ca.sink, err = getEventSink(ctx, ca.FlowCtx.Cfg, ca.spec.Feed, timestampOracle,
-		ca.spec.User(), ca.spec.JobID, recorder)
+		ca.spec.User(), ca.spec.JobID, ca.FlowCtx.ID, recorder)
 	if err != nil {
 		err = changefeedbase.MarkRetryableError(err)
 		if log.V(2) {
@@ -368,6 +376,9 @@ func (ca *changeAggregator) Start(ctx context.Context) {
 	if b, ok := ca.sink.(*bufferSink); ok {
 		ca.changedRowBuf = &b.buf
 	}
+	if cs, ok := ca.sink.(*cloudStorageSink); ok && cs.exactlyOnce != nil {
+		cs.setExactlyOnceMemMonitor(ca.MemMonitor)
+	}
 
 	// If the initial scan was disabled the highwater would've already been forwarded
 	needsInitialScan := ca.frontier.Frontier().IsEmpty()
@@ -396,6 +407,7 @@ func (ca *changeAggregator) Start(ctx context.Context) {
 		ca.cancel()
 		return
 	}
+
 	ca.sink = &errorWrapperSink{wrapped: ca.sink}
 	ca.eventConsumer, ca.sink, err = newEventConsumer(
 		ctx, ca.FlowCtx.Cfg, ca.spec, feed, ca.frontier, kvFeedHighWater,
@@ -497,6 +509,10 @@ func (ca *changeAggregator) makeKVFeedCfg(
 	if err != nil {
 		return kvfeed.Config{}, err
 	}
+	if ca.spec.JobID != 0 {
+		monitoringCfg.SpanReportCallback = makeSpanReportCallback(cfg.DB, &cfg.Settings.SV,
+			ca.spec.JobID, ca.FlowCtx.NodeID.SQLInstanceID(), cfg.DB.KV().Clock())
+	}
 
 	return kvfeed.Config{
 		Writer:              buf,
@@ -514,6 +530,7 @@ func (ca *changeAggregator) makeKVFeedCfg(
 		EndTime:             config.EndTime,
 		WithDiff:            filters.WithDiff,
 		WithFiltering:       filters.WithFiltering,
+		ScanNewTables:       config.Opts.ShouldScanNewTables(),
 		NeedsInitialScan:    needsInitialScan,
 		SchemaChangeEvents:  schemaChange.EventClass,
 		SchemaChangePolicy:  schemaChange.Policy,
@@ -988,6 +1005,18 @@ type changeFrontier struct {
 	freqEmitResolved time.Duration
 	// lastEmitResolved is the last time a resolved timestamp was emitted.
 	lastEmitResolved time.Time
+	// resolvedSuppressedUntil, if set, is the high watermark of the changefeed
+	// before some of its tables were rewound. Resolved timestamps are not
+	// emitted until the frontier catches up to it, since the tables which
+	// weren't rewound already emitted changes up to it.
+	resolvedSuppressedUntil hlc.Timestamp
+	// exactlyOnceInterval, if non-zero, is the interval at which manifests are
+	// committed to the sink with the exactly_once option. The job highwater
+	// then only advances to committed manifests.
+	exactlyOnceInterval time.Duration
+	// lastManifest is the timestamp of the last manifest committed by this
+	// flow, or the highwater at start.
+	lastManifest hlc.Timestamp
 
 	// lastProtectedTimestampUpdate is the last time the protected timestamp
 	// record was updated to the frontier's highwater mark
@@ -1216,6 +1245,9 @@ func newChangeFrontierProcessor(
 	} else {
 		cf.freqEmitResolved = emitNoResolved
 	}
+	if cf.exactlyOnceInterval, _, err = opts.GetExactlyOnceInterval(); err != nil {
+		return nil, err
+	}
 
 	encodingOpts, err := opts.GetEncodingOptions()
 	if err != nil {
@@ -1275,7 +1307,7 @@ func (cf *changeFrontier) Start(ctx context.Context) {
 	cf.sliMetrics = sli
 
 	cf.sink, err = getResolvedTimestampSink(ctx, cf.FlowCtx.Cfg, cf.spec.Feed, nilOracle,
-		cf.spec.User(), cf.spec.JobID, sli)
+		cf.spec.User(), cf.spec.JobID, cf.FlowCtx.ID, sli)
 	if err != nil {
 		err = changefeedbase.MarkRetryableError(err)
 		if log.V(2) {
@@ -1328,6 +1360,7 @@ func (cf *changeFrontier) Start(ctx context.Context) {
 		if ts := p.GetHighWater(); ts != nil {
 			cf.highWaterAtStart.Forward(*ts)
 			cf.frontier.initialHighWater = *ts
+			cf.lastManifest = *ts
 			for _, span := range cf.spec.TrackedSpans {
 				if _, err := cf.frontier.Forward(span, *ts); err != nil {
 					if log.V(2) {
@@ -1339,6 +1372,12 @@ func (cf *changeFrontier) Start(ctx context.Context) {
 			}
 		}
 
+		if rewinds := p.GetChangefeed().GetRewinds(); len(rewinds) > 0 {
+			if last := rewinds[len(rewinds)-1]; len(last.Spans) > 0 {
+				cf.resolvedSuppressedUntil = last.PreviousHighWater
+			}
+		}
+
 		if p.RunningStatus != "" {
 			// If we had running status set, that means we're probably retrying
 			// due to a transient error.  In that case, keep the previous
@@ -1608,6 +1647,10 @@ func (cf *changeFrontier) forwardFrontier(resolved jobspb.ResolvedSpan) error {
 
 	maybeLogBehindSpan(cf.Ctx(), "coordinator", cf.frontier, frontierChanged, &cf.FlowCtx.Cfg.Settings.SV)
 
+	if cf.exactlyOnceInterval != 0 {
+		return cf.maybeCommitManifest()
+	}
+
 	checkpointed, err := cf.maybeCheckpointJob(resolved, frontierChanged)
 	if err != nil {
 		return err
@@ -1641,6 +1684,35 @@ func (cf *changeFrontier) forwardFrontier(resolved jobspb.ResolvedSpan) error {
 	return nil
 }
 
+// maybeCommitManifest commits a manifest to the sink once the frontier passes
+// a multiple of the manifest interval or reaches a schema change boundary, and
+// then advances the job highwater to it. The highwater must never pass the
+// latest manifest, since a restarted changefeed only drops the replayed rows
+// which were committed; span level checkpoints are not used for the same
+// reason.
+func (cf *changeFrontier) maybeCommitManifest() error {
+	resolved := cf.frontier.Frontier()
+	if !cf.frontier.schemaChangeBoundaryReached() {
+		resolved = floorToInterval(resolved, cf.exactlyOnceInterval)
+	}
+	if resolved.IsEmpty() || resolved.Less(cf.spec.Feed.StatementTime) ||
+		resolved.LessEq(cf.lastManifest) {
+		return nil
+	}
+	if err := emitResolvedTimestamp(cf.Ctx(), cf.encoder, cf.sink, resolved); err != nil {
+		return err
+	}
+	checkpointStart := timeutil.Now()
+	if _, err := cf.checkpointJobProgress(resolved, jobspb.ChangefeedProgress_Checkpoint{}); err != nil {
+		return err
+	}
+	cf.js.checkpointCompleted(cf.Ctx(), timeutil.Since(checkpointStart))
+	cf.lastManifest = resolved
+	cf.lastEmitResolved = resolved.GoTime()
+	cf.sliMetrics.setCheckpoint(cf.sliMetricsID, resolved)
+	return nil
+}
+
 func (cf *changeFrontier) maybeMarkJobIdle(recentKVCount uint64) {
 	if cf.spec.JobID == 0 {
 		return
@@ -1851,7 +1923,8 @@ func (cf *changeFrontier) manageProtectedTimestamps(
 }
 
 func (cf *changeFrontier) maybeEmitResolved(newResolved hlc.Timestamp) error {
-	if cf.freqEmitResolved == emitNoResolved || newResolved.IsEmpty() {
+	if cf.freqEmitResolved == emitNoResolved || newResolved.IsEmpty() ||
+		newResolved.Less(cf.resolvedSuppressedUntil) {
 		return nil
 	}
 	sinceEmitted := newResolved.GoTime().Sub(cf.lastEmitResolved)
@@ -26,6 +26,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/protectedts"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/protectedts/ptpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/rangefeed"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/server/status"
 	"github.com/cockroachdb/cockroach/pkg/server/telemetry"
@@ -35,6 +36,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/exprutil"
 	"github.com/cockroachdb/cockroach/pkg/sql/isql"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
@@ -354,7 +356,10 @@ func coreChangefeed(
 			knobs.BeforeDistChangefeed()
 		}
 
-		err := distChangefeedFlow(ctx, p, 0 /* jobID */, details, localState, resultsCh)
+		err := maybeRefreshNamespaceTargets(ctx, p, 0 /* jobID */, &details, localState.progress)
+		if err == nil {
+			err = distChangefeedFlow(ctx, p, 0 /* jobID */, details, localState, resultsCh)
+		}
 		if err == nil {
 			log.Infof(ctx, "core changefeed completed with no error")
 			return nil
@@ -454,8 +459,16 @@ func createChangefeedJobRecord(
 		}
 	}
 
+	// Database- and schema-level targets are expanded into the tables making
+	// them up at the statement time.
+	rawTargets, namespaces, err := expandNamespaceTargets(
+		ctx, p.ExecCfg(), changefeedStmt.CreateChangefeed, statementTime)
+	if err != nil {
+		return nil, err
+	}
+
 	tableOnlyTargetList := tree.BackupTargetList{}
-	for _, t := range changefeedStmt.Targets {
+	for _, t := range rawTargets {
 		tableOnlyTargetList.Tables.TablePatterns = append(tableOnlyTargetList.Tables.TablePatterns, t.TableName)
 	}
 
@@ -474,12 +487,13 @@ func createChangefeedJobRecord(
 		}
 	}
 
-	targets, tables, err := getTargetsAndTables(ctx, p, targetDescs, changefeedStmt.Targets,
+	targets, tables, err := getTargetsAndTables(ctx, p, targetDescs, rawTargets,
 		changefeedStmt.originalSpecs, opts.ShouldUseFullStatementTimeName(), sinkURI)
 
 	if err != nil {
 		return nil, err
 	}
+	targets = append(targets, namespaces...)
 	tolerances := opts.GetCanHandle()
 	sd := p.SessionData().Clone()
 	// Add non-local session data state (localization, etc).
@@ -520,6 +534,27 @@ func createChangefeedJobRecord(
 	}
 
 	if changefeedStmt.Select != nil {
+		if opts.IsSet(changefeedbase.OptEnvelope) || opts.IsSet(changefeedbase.OptFormat) {
+			encopts, err := opts.GetEncodingOptions()
+			if err != nil {
+				return nil, err
+			}
+			if encopts.Envelope == changefeedbase.OptEnvelopeDebezium {
+				return nil, errors.Errorf(`%s=%s is not supported with CDC queries`,
+					changefeedbase.OptEnvelope, changefeedbase.OptEnvelopeDebezium)
+			}
+			// The field numbers of protobuf messages are the IDs of the columns,
+			// which the columns computed by a CDC query don't have.
+			if encopts.Format == changefeedbase.OptFormatProtobuf {
+				return nil, errors.Errorf(`%s=%s is not supported with CDC queries`,
+					changefeedbase.OptFormat, changefeedbase.OptFormatProtobuf)
+			}
+		}
+		if opts.IsSet(changefeedbase.OptTransactionMarkers) {
+			return nil, errors.Errorf(`%s is not supported with CDC queries`,
+				changefeedbase.OptTransactionMarkers)
+		}
+
 		// Serialize changefeed expression.
 		normalized, withDiff, err := validateAndNormalizeChangefeedExpression(
 			ctx, p, opts, changefeedStmt.Select, targetDescs, targets, statementTime,
@@ -603,6 +638,19 @@ func createChangefeedJobRecord(
 		makeExternalConnectionProvider(ctx, p.ExecCfg().InternalDB), nil); err != nil {
 		return nil, err
 	}
+	// The debezium envelope and transaction markers report the ID of the
+	// transaction which wrote each row, which rangefeeds only provide when
+	// asked to.
+	if !rangefeed.TxnIDsEnabled.Get(&p.ExecCfg().Settings.SV) {
+		if encodingOpts.Envelope == changefeedbase.OptEnvelopeDebezium {
+			return nil, errors.Errorf("%s=%s requires the %s setting",
+				changefeedbase.OptEnvelope, changefeedbase.OptEnvelopeDebezium, rangefeed.TxnIDsEnabled.Name())
+		}
+		if encodingOpts.TransactionMarkers {
+			return nil, errors.Errorf("%s requires the %s setting",
+				changefeedbase.OptTransactionMarkers, rangefeed.TxnIDsEnabled.Name())
+		}
+	}
 
 	if !unspecifiedSink && p.ExecCfg().ExternalIODirConfig.DisableOutbound {
 		return nil, errors.Errorf("Outbound IO is disabled by configuration, cannot create changefeed into %s", parsedSink.Scheme)
@@ -930,7 +978,7 @@ func validateSink(
 
 	var nilOracle timestampLowerBoundOracle
 	canarySink, err := getAndDialSink(ctx, &p.ExecCfg().DistSQLSrv.ServerConfig, details,
-		nilOracle, p.User(), jobID, sli)
+		nilOracle, p.User(), jobID, execinfrapb.FlowID{}, sli)
 	if err != nil {
 		return err
 	}
@@ -988,8 +1036,9 @@ func makeChangefeedDescription(
 	opts changefeedbase.StatementOptions,
 ) (string, error) {
 	c := &tree.CreateChangefeed{
-		Targets: changefeed.Targets,
-		Select:  changefeed.Select,
+		Targets:  changefeed.Targets,
+		Database: changefeed.Database,
+		Select:   changefeed.Select,
 	}
 
 	if sinkURI != "" {
@@ -999,6 +1048,11 @@ func makeChangefeedDescription(
 			return "", err
 		}
 
+		cleanedSinkURI, err = sanitizeIcebergSinkURI(cleanedSinkURI)
+		if err != nil {
+			return "", err
+		}
+
 		cleanedSinkURI, err = changefeedbase.RedactUserFromURI(cleanedSinkURI)
 		if err != nil {
 			return "", err
@@ -1053,6 +1107,25 @@ func validateDetailsAndOptions(
 		}
 	}
 
+	if targets := AllTargets(details); targets.HasNamespaces() {
+		// Tables joining or leaving the watched databases and schemas are
+		// detected through the schema feed, which ignoring schema changes
+		// turns off.
+		schemaChange, err := opts.GetSchemaChangeHandlingOptions()
+		if err != nil {
+			return err
+		}
+		if schemaChange.Policy == changefeedbase.OptSchemaChangePolicyIgnore {
+			return errors.Errorf(
+				`cannot specify %s=%s for database- or schema-level changefeeds`,
+				changefeedbase.OptSchemaChangePolicy, changefeedbase.OptSchemaChangePolicyIgnore)
+		}
+	} else if opts.ShouldScanNewTables() {
+		return errors.Errorf(
+			`%s is only supported for database- or schema-level changefeeds`,
+			changefeedbase.OptInitialScanNewTables)
+	}
+
 	{
 		if details.Select != "" {
 			if len(details.TargetSpecifications) != 1 {
@@ -1281,6 +1354,9 @@ func (b *changefeedResumer) resumeWithRetries(
 
 	for r := getRetry(ctx); r.Next(); {
 		flowErr := maybeUpgradePreProductionReadyExpression(ctx, jobID, details, jobExec)
+		if flowErr == nil {
+			flowErr = maybeRefreshNamespaceTargets(ctx, jobExec, jobID, &details, localState.progress)
+		}
 
 		if flowErr == nil {
 			// startedCh is normally used to signal back to the creator of the job that
@@ -823,6 +823,71 @@ func TestChangefeedMultiTable(t *testing.T) {
 	cdcTest(t, testFn)
 }
 
+func TestChangefeedNamespaceTargets(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testFn := func(t *testing.T, s TestServer, f cdctest.TestFeedFactory) {
+		sqlDB := sqlutils.MakeSQLRunner(s.DB)
+		sqlDB.Exec(t, `CREATE DATABASE ns`)
+		sqlDB.Exec(t, `CREATE SCHEMA ns.sc`)
+		sqlDB.Exec(t, `CREATE TABLE ns.foo (a INT PRIMARY KEY)`)
+		sqlDB.Exec(t, `INSERT INTO ns.foo VALUES (1)`)
+		sqlDB.Exec(t, `CREATE TABLE ns.sc.bar (a INT PRIMARY KEY)`)
+		sqlDB.Exec(t, `INSERT INTO ns.sc.bar VALUES (2)`)
+
+		t.Run(`database`, func(t *testing.T) {
+			db := feed(t, f, `CREATE CHANGEFEED FOR DATABASE ns`)
+			defer closeFeed(t, db)
+			assertPayloads(t, db, []string{
+				`foo: [1]->{"after": {"a": 1}}`,
+				`bar: [2]->{"after": {"a": 2}}`,
+			})
+
+			// A table created in the database joins the changefeed. Its
+			// existing rows aren't scanned without initial_scan_new_tables.
+			sqlDB.Exec(t, `CREATE TABLE ns.baz (a INT PRIMARY KEY)`)
+			sqlDB.Exec(t, `INSERT INTO ns.baz VALUES (3)`)
+			sqlDB.Exec(t, `INSERT INTO ns.foo VALUES (4)`)
+			assertPayloads(t, db, []string{
+				`baz: [3]->{"after": {"a": 3}}`,
+				`foo: [4]->{"after": {"a": 4}}`,
+			})
+			sqlDB.Exec(t, `DROP TABLE ns.baz`)
+		})
+
+		t.Run(`schema`, func(t *testing.T) {
+			sqlDB.Exec(t, `CREATE TABLE ns.moved (a INT PRIMARY KEY)`)
+			sqlDB.Exec(t, `INSERT INTO ns.moved VALUES (5)`)
+
+			sc := feed(t, f, `CREATE CHANGEFEED FOR ns.sc.* WITH initial_scan_new_tables`)
+			defer closeFeed(t, sc)
+			assertPayloads(t, sc, []string{
+				`bar: [2]->{"after": {"a": 2}}`,
+			})
+
+			// A table moved into the schema joins the changefeed and, with
+			// initial_scan_new_tables, its existing rows are scanned.
+			sqlDB.Exec(t, `ALTER TABLE ns.moved SET SCHEMA ns.sc`)
+			assertPayloads(t, sc, []string{
+				`moved: [5]->{"after": {"a": 5}}`,
+			})
+
+			// A table moved out of the schema leaves the changefeed, along with
+			// the tables of the database outside of the schema.
+			sqlDB.Exec(t, `ALTER TABLE ns.sc.bar SET SCHEMA ns.public`)
+			sqlDB.Exec(t, `INSERT INTO ns.public.bar VALUES (6)`)
+			sqlDB.Exec(t, `INSERT INTO ns.foo VALUES (7)`)
+			sqlDB.Exec(t, `INSERT INTO ns.sc.moved VALUES (8)`)
+			assertPayloads(t, sc, []string{
+				`moved: [8]->{"after": {"a": 8}}`,
+			})
+		})
+	}
+
+	cdcTest(t, testFn, feedTestEnterpriseSinks)
+}
+
 func TestChangefeedCursor(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -5103,6 +5168,54 @@ func TestChangefeedSchemaTTL(t *testing.T) {
 	cdcTestWithSystem(t, testFn, feedTestNoTenants)
 }
 
+// TestChangefeedDebeziumTxnID tests that the debezium envelope reports the ID
+// of the transaction which wrote each row, including for 1PC writes.
+func TestChangefeedDebeziumTxnID(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testFn := func(t *testing.T, s TestServer, f cdctest.TestFeedFactory) {
+		sqlDB := sqlutils.MakeSQLRunner(s.DB)
+		sqlDB.Exec(t, `CREATE TABLE foo (a INT PRIMARY KEY, b STRING)`)
+
+		sqlDB.ExpectErr(t, `envelope=debezium requires the kv.rangefeed.txn_ids.enabled setting`,
+			`CREATE CHANGEFEED FOR foo WITH envelope='debezium', initial_scan='no'`)
+
+		sqlDB.Exec(t, `SET CLUSTER SETTING kv.rangefeed.txn_ids.enabled = true`)
+		foo := feed(t, f, `CREATE CHANGEFEED FOR foo WITH envelope='debezium', initial_scan='no'`)
+		defer closeFeed(t, foo)
+
+		// The first insert commits in one phase; the other two share an explicit
+		// transaction.
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (1, 'a')`)
+		sqlDB.Exec(t, `BEGIN; INSERT INTO foo VALUES (2, 'b'); INSERT INTO foo VALUES (3, 'c'); COMMIT`)
+
+		txnIDs := make(map[int]string)
+		for len(txnIDs) < 3 {
+			m, err := foo.Next()
+			require.NoError(t, err)
+			if len(m.Value) == 0 {
+				continue
+			}
+			var v struct {
+				After struct {
+					A int `json:"a"`
+				} `json:"after"`
+				Source struct {
+					TxID *string `json:"txId"`
+				} `json:"source"`
+			}
+			require.NoError(t, json.Unmarshal(m.Value, &v))
+			require.NotNil(t, v.Source.TxID, "missing txId in %s", m.Value)
+			txnIDs[v.After.A] = *v.Source.TxID
+		}
+		require.NotEqual(t, txnIDs[1], txnIDs[2])
+		require.Equal(t, txnIDs[2], txnIDs[3])
+	}
+
+	cdcTest(t, testFn, feedTestForceSink("kafka"), feedTestNoTenants)
+}
+
 func TestChangefeedErrors(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
@@ -5421,6 +5534,13 @@ func TestChangefeedErrors(t *testing.T) {
 		`CREATE CHANGEFEED FOR foo INTO $1 WITH topic_in_value, format='experimental_avro'`,
 		`kafka://nope`,
 	)
+	// The protobuf format numbers fields by column ID, which the columns of
+	// CDC queries don't have.
+	sqlDB.ExpectErrWithTimeout(
+		t, `format=protobuf is not supported with CDC queries`,
+		`CREATE CHANGEFEED INTO $1 WITH format='protobuf' AS SELECT a, b || 'x' AS c FROM foo`,
+		`kafka://nope`,
+	)
 
 	// Unordered flag required for some options, disallowed for others.
 	sqlDB.ExpectErrWithTimeout(t, `resolved timestamps cannot be guaranteed to be correct in unordered mode`, `CREATE CHANGEFEED FOR foo WITH resolved, unordered`)
@@ -5792,8 +5912,8 @@ func TestChangefeedPanicRecovery(t *testing.T) {
 		prep(t, sqlDB)
 		// Check that disallowed expressions have a good error message.
 		// Also regression test for https://github.com/cockroachdb/cockroach/issues/90416
-		sqlDB.ExpectErrWithTimeout(t, "sub-query expressions not supported by CDC",
-			`CREATE CHANGEFEED WITH schema_change_policy='stop' AS SELECT 1 FROM foo WHERE EXISTS (SELECT true)`)
+		sqlDB.ExpectErrWithTimeout(t, "WITH clause not supported in CDC lookups",
+			`CREATE CHANGEFEED WITH schema_change_policy='stop' AS SELECT 1 FROM foo WHERE EXISTS (WITH x AS (SELECT true) SELECT * FROM x)`)
 	})
 
 	// Check that all panics while evaluating the WHERE clause in an expression are recovered from.
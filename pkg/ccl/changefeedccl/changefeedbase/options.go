@@ -103,6 +103,9 @@ const (
 	OptLaggingRangesPollingInterval       = `lagging_ranges_polling_interval`
 	OptIgnoreDisableChangefeedReplication = `ignore_disable_changefeed_replication`
 	OptEncodeJSONValueNullAsObject        = `encode_json_value_null_as_object`
+	OptInitialScanNewTables               = `initial_scan_new_tables`
+	OptTransactionMarkers                 = `transaction_markers`
+	OptExactlyOnce                        = `exactly_once`
 
 	OptVirtualColumnsOmitted VirtualColumnVisibility = `omitted`
 	OptVirtualColumnsNull    VirtualColumnVisibility = `null`
@@ -149,11 +152,13 @@ const (
 	OptEnvelopeDeprecatedRow EnvelopeType = `deprecated_row`
 	OptEnvelopeWrapped       EnvelopeType = `wrapped`
 	OptEnvelopeBare          EnvelopeType = `bare`
+	OptEnvelopeDebezium      EnvelopeType = `debezium`
 
-	OptFormatJSON    FormatType = `json`
-	OptFormatAvro    FormatType = `avro`
-	OptFormatCSV     FormatType = `csv`
-	OptFormatParquet FormatType = `parquet`
+	OptFormatJSON     FormatType = `json`
+	OptFormatAvro     FormatType = `avro`
+	OptFormatCSV      FormatType = `csv`
+	OptFormatParquet  FormatType = `parquet`
+	OptFormatProtobuf FormatType = `protobuf`
 
 	OptOnErrorFail  OnErrorType = `fail`
 	OptOnErrorPause OnErrorType = `pause`
@@ -175,6 +180,8 @@ const (
 	OptKafkaSinkConfig   = `kafka_sink_config`
 	OptPubsubSinkConfig  = `pubsub_sink_config`
 	OptWebhookSinkConfig = `webhook_sink_config`
+	OptNATSSinkConfig    = `nats_sink_config`
+	OptMQTTSinkConfig    = `mqtt_sink_config`
 
 	// OptSink allows users to alter the Sink URI of an existing changefeed.
 	// Note that this option is only allowed for alter changefeed statements.
@@ -206,6 +213,18 @@ const (
 	SinkSchemeWebhookHTTPS          = `webhook-https`
 	SinkSchemePulsar                = `pulsar`
 	SinkSchemeExternalConnection    = `external`
+	SinkSchemeIceberg               = `iceberg`
+	SinkParamIcebergCatalogURI      = `catalog_uri`
+	SinkParamIcebergDataURI         = `data_uri`
+	SinkParamIcebergNamespace       = `namespace`
+	SinkSchemeNATS                  = `nats`
+	SinkSchemeMQTT                  = `mqtt`
+	SinkParamTopicTemplate          = `topic_template`
+	SinkParamNATSToken              = `token`
+	SinkParamNATSCredentials        = `credentials`
+	SinkParamMQTTClientID           = `client_id`
+	SinkParamMQTTQoS                = `qos`
+	SinkParamMQTTRetain             = `retain`
 	SinkParamSASLEnabled            = `sasl_enabled`
 	SinkParamSASLHandshake          = `sasl_handshake`
 	SinkParamSASLUser               = `sasl_user`
@@ -339,8 +358,8 @@ var ChangefeedOptionExpectValues = map[string]OptionPermittedValues{
 	OptCursor:                             timestampOption,
 	OptCustomKeyColumn:                    stringOption,
 	OptEndTime:                            timestampOption,
-	OptEnvelope:                           enum("row", "key_only", "wrapped", "deprecated_row", "bare"),
-	OptFormat:                             enum("json", "avro", "csv", "experimental_avro", "parquet"),
+	OptEnvelope:                           enum("row", "key_only", "wrapped", "deprecated_row", "bare", "debezium"),
+	OptFormat:                             enum("json", "avro", "csv", "experimental_avro", "parquet", "protobuf"),
 	OptFullTableName:                      flagOption,
 	OptKeyInValue:                         flagOption,
 	OptTopicInValue:                       flagOption,
@@ -361,6 +380,8 @@ var ChangefeedOptionExpectValues = map[string]OptionPermittedValues{
 	OptKafkaSinkConfig:                    jsonOption,
 	OptPubsubSinkConfig:                   jsonOption,
 	OptWebhookSinkConfig:                  jsonOption,
+	OptNATSSinkConfig:                     jsonOption,
+	OptMQTTSinkConfig:                     jsonOption,
 	OptWebhookAuthHeader:                  stringOption,
 	OptWebhookClientTimeout:               durationOption,
 	OptOnError:                            enum("pause", "fail"),
@@ -372,6 +393,9 @@ var ChangefeedOptionExpectValues = map[string]OptionPermittedValues{
 	OptLaggingRangesPollingInterval:       durationOption,
 	OptIgnoreDisableChangefeedReplication: flagOption,
 	OptEncodeJSONValueNullAsObject:        flagOption,
+	OptInitialScanNewTables:               flagOption,
+	OptTransactionMarkers:                 flagOption,
+	OptExactlyOnce:                        flagOption,
 }
 
 // CommonOptions is options common to all sinks
@@ -386,6 +410,7 @@ var CommonOptions = makeStringSet(OptCursor, OptEndTime, OptEnvelope,
 	OptMinCheckpointFrequency, OptMetricsScope, OptVirtualColumns, Topics, OptExpirePTSAfter,
 	OptExecutionLocality, OptLaggingRangesThreshold, OptLaggingRangesPollingInterval,
 	OptIgnoreDisableChangefeedReplication, OptEncodeJSONValueNullAsObject,
+	OptInitialScanNewTables,
 )
 
 // SQLValidOptions is options exclusive to SQL sink
@@ -395,7 +420,10 @@ var SQLValidOptions map[string]struct{} = nil
 var KafkaValidOptions = makeStringSet(OptAvroSchemaPrefix, OptConfluentSchemaRegistry, OptKafkaSinkConfig)
 
 // CloudStorageValidOptions is options exclusive to cloud storage sink
-var CloudStorageValidOptions = makeStringSet(OptCompression)
+var CloudStorageValidOptions = makeStringSet(OptCompression, OptExactlyOnce, OptTransactionMarkers)
+
+// IcebergValidOptions is options exclusive to iceberg sink
+var IcebergValidOptions = makeStringSet(OptCompression)
 
 // WebhookValidOptions is options exclusive to webhook sink
 var WebhookValidOptions = makeStringSet(OptWebhookAuthHeader, OptWebhookClientTimeout, OptWebhookSinkConfig)
@@ -403,13 +431,20 @@ var WebhookValidOptions = makeStringSet(OptWebhookAuthHeader, OptWebhookClientTi
 // PubsubValidOptions is options exclusive to pubsub sink
 var PubsubValidOptions = makeStringSet(OptPubsubSinkConfig)
 
+// NATSValidOptions is options exclusive to NATS sink
+var NATSValidOptions = makeStringSet(OptNATSSinkConfig)
+
+// MQTTValidOptions is options exclusive to MQTT sink
+var MQTTValidOptions = makeStringSet(OptMQTTSinkConfig)
+
 // ExternalConnectionValidOptions is options exclusive to the external
 // connection sink.
 //
 // TODO(adityamaru): Some of these options should be supported when creating the
 // external connection rather than when setting up the changefeed. Move them once
 // we support `CREATE EXTERNAL CONNECTION ... WITH <options>`.
-var ExternalConnectionValidOptions = unionStringSets(SQLValidOptions, KafkaValidOptions, CloudStorageValidOptions, WebhookValidOptions, PubsubValidOptions)
+var ExternalConnectionValidOptions = unionStringSets(SQLValidOptions, KafkaValidOptions, CloudStorageValidOptions, WebhookValidOptions, PubsubValidOptions,
+	NATSValidOptions, MQTTValidOptions)
 
 // CaseInsensitiveOpts options which supports case Insensitive value
 var CaseInsensitiveOpts = makeStringSet(OptFormat, OptEnvelope, OptCompression, OptSchemaChangeEvents,
@@ -462,11 +497,11 @@ type OptionsSet map[string]struct{}
 // InitialScanOnlyUnsupportedOptions is options that are not supported with the
 // initial scan only option
 var InitialScanOnlyUnsupportedOptions OptionsSet = makeStringSet(OptEndTime, OptResolvedTimestamps, OptDiff,
-	OptMVCCTimestamps, OptUpdatedTimestamps)
+	OptMVCCTimestamps, OptUpdatedTimestamps, OptTransactionMarkers)
 
 // ParquetFormatUnsupportedOptions is options that are not supported with the
 // parquet format.
-var ParquetFormatUnsupportedOptions OptionsSet = makeStringSet(OptTopicInValue)
+var ParquetFormatUnsupportedOptions OptionsSet = makeStringSet(OptTopicInValue, OptExactlyOnce)
 
 // AlterChangefeedUnsupportedOptions are changefeed options that we do not allow
 // users to alter.
@@ -530,6 +565,8 @@ var incompatibleOptionsMap = makeInvertedIndex([]incompatibleOptions{
 
 var dependentOptionsMap = makeDirectedInvertedIndex([]dependentOption{
 	{opt1: OptCustomKeyColumn, opt2: OptUnordered, reason: `using a value other than the primary key as the message key means end-to-end ordering cannot be preserved`},
+	{opt1: OptExactlyOnce, opt2: OptResolvedTimestamps, reason: `manifests are committed at resolved timestamps`},
+	{opt1: OptTransactionMarkers, opt2: OptExactlyOnce, reason: `transactions are only delivered atomically with their END markers in manifests`},
 })
 
 // MakeStatementOptions wraps and canonicalizes the options we get
@@ -745,6 +782,13 @@ func (s StatementOptions) ShouldUseFullStatementTimeName() bool {
 	return qualified
 }
 
+// ShouldScanNewTables returns true if the tables that join a database- or
+// schema-level changefeed after it started should be scanned when they join.
+func (s StatementOptions) ShouldScanNewTables() bool {
+	_, scan := s.m[OptInitialScanNewTables]
+	return scan
+}
+
 // CanHandle tracks whether users have explicitly specificed how to handle
 // unusual table schemas.
 type CanHandle struct {
@@ -779,6 +823,7 @@ type EncodingOptions struct {
 	MVCCTimestamps              bool
 	Diff                        bool
 	EncodeJSONValueNullAsObject bool
+	TransactionMarkers          bool
 	AvroSchemaPrefix            string
 	SchemaRegistryURI           string
 	Compression                 string
@@ -824,7 +869,10 @@ func (s StatementOptions) GetEncodingOptions() (EncodingOptions, error) {
 	_, o.UpdatedTimestamps = s.m[OptUpdatedTimestamps]
 	_, o.MVCCTimestamps = s.m[OptMVCCTimestamps]
 	_, o.Diff = s.m[OptDiff]
+	// The debezium envelope always carries the previous version of rows.
+	o.Diff = o.Diff || o.Envelope == OptEnvelopeDebezium
 	_, o.EncodeJSONValueNullAsObject = s.m[OptEncodeJSONValueNullAsObject]
+	_, o.TransactionMarkers = s.m[OptTransactionMarkers]
 
 	o.SchemaRegistryURI = s.m[OptConfluentSchemaRegistry]
 	o.AvroSchemaPrefix = s.m[OptAvroSchemaPrefix]
@@ -842,10 +890,48 @@ func (e EncodingOptions) Validate() error {
 			OptEnvelope, OptEnvelopeRow, OptFormat, OptFormatAvro,
 		)
 	}
+	if (e.Envelope == OptEnvelopeRow || e.Envelope == OptEnvelopeDeprecatedRow) &&
+		e.Format == OptFormatProtobuf {
+		return errors.Errorf(`%s=%s is not supported with %s=%s`,
+			OptEnvelope, e.Envelope, OptFormat, OptFormatProtobuf,
+		)
+	}
+	if e.Envelope == OptEnvelopeDebezium {
+		if e.Format != OptFormatJSON && e.Format != OptFormatAvro {
+			return errors.Errorf(`%s=%s is only usable with %s=%s or %s=%s`,
+				OptEnvelope, OptEnvelopeDebezium, OptFormat, OptFormatJSON, OptFormat, OptFormatAvro)
+		}
+		// The source block of debezium envelopes carries the metadata.
+		unsupported := []struct {
+			k string
+			b bool
+		}{
+			{OptKeyInValue, e.KeyInValue},
+			{OptTopicInValue, e.TopicInValue},
+			{OptUpdatedTimestamps, e.UpdatedTimestamps},
+			{OptMVCCTimestamps, e.MVCCTimestamps},
+		}
+		for _, v := range unsupported {
+			if v.b {
+				return errors.Errorf(`%s is not supported with %s=%s`,
+					v.k, OptEnvelope, OptEnvelopeDebezium)
+			}
+		}
+	}
+	if e.TransactionMarkers {
+		if e.Format != OptFormatJSON {
+			return errors.Errorf(`%s is only usable with %s=%s`, OptTransactionMarkers, OptFormat, OptFormatJSON)
+		}
+		if e.Envelope != OptEnvelopeWrapped && e.Envelope != OptEnvelopeBare {
+			return errors.Errorf(`%s is only usable with %s=%s or %s=%s`,
+				OptTransactionMarkers, OptEnvelope, OptEnvelopeWrapped, OptEnvelope, OptEnvelopeBare)
+		}
+	}
 	if e.Format != OptFormatJSON && e.EncodeJSONValueNullAsObject {
 		return errors.Errorf(`%s is only usable with %s=%s`, OptEncodeJSONValueNullAsObject, OptFormat, OptFormatJSON)
 	}
-	if e.Envelope != OptEnvelopeWrapped && e.Format != OptFormatJSON && e.Format != OptFormatParquet {
+	if e.Envelope != OptEnvelopeWrapped && e.Envelope != OptEnvelopeDebezium &&
+		e.Format != OptFormatJSON && e.Format != OptFormatParquet {
 		requiresWrap := []struct {
 			k string
 			b bool
@@ -910,6 +996,8 @@ type Filters struct {
 // GetFilters returns a populated Filters.
 func (s StatementOptions) GetFilters() Filters {
 	_, withDiff := s.m[OptDiff]
+	// The debezium envelope always carries the previous version of rows.
+	withDiff = withDiff || EnvelopeType(s.m[OptEnvelope]) == OptEnvelopeDebezium
 	_, withIgnoreDisableChangefeedReplication := s.m[OptIgnoreDisableChangefeedReplication]
 	return Filters{
 		WithDiff:      withDiff,
@@ -951,6 +1039,18 @@ func (s StatementOptions) GetPubsubConfigJSON() SinkSpecificJSONConfig {
 	return s.getJSONValue(OptPubsubSinkConfig)
 }
 
+// GetNATSConfigJSON returns arbitrary json to be interpreted
+// by the NATS sink.
+func (s StatementOptions) GetNATSConfigJSON() SinkSpecificJSONConfig {
+	return s.getJSONValue(OptNATSSinkConfig)
+}
+
+// GetMQTTConfigJSON returns arbitrary json to be interpreted
+// by the MQTT sink.
+func (s StatementOptions) GetMQTTConfigJSON() SinkSpecificJSONConfig {
+	return s.getJSONValue(OptMQTTSinkConfig)
+}
+
 // GetResolvedTimestampInterval gets the best-effort interval at which resolved timestamps
 // should be emitted. Nil or 0 means emit as often as possible. False means do not emit at all.
 // Returns an error for negative or invalid duration value.
@@ -963,6 +1063,25 @@ func (s StatementOptions) GetResolvedTimestampInterval() (*time.Duration, bool,
 	return d, d != nil, err
 }
 
+// GetExactlyOnceInterval returns the interval at which a changefeed with
+// exactly once delivery commits manifests, or false if exactly once delivery
+// was not requested. Manifests are committed at resolved timestamps, so the
+// interval is the one of the resolved option, which must be explicit.
+func (s StatementOptions) GetExactlyOnceInterval() (time.Duration, bool, error) {
+	if _, ok := s.m[OptExactlyOnce]; !ok {
+		return 0, false, nil
+	}
+	d, _, err := s.GetResolvedTimestampInterval()
+	if err != nil {
+		return 0, false, err
+	}
+	if d == nil || *d <= 0 {
+		return 0, false, errors.Errorf(`%s requires a non-zero %s interval`,
+			OptExactlyOnce, OptResolvedTimestamps)
+	}
+	return *d, true, nil
+}
+
 // GetMetricScope returns a namespace for metrics affected by this changefeed, or
 // false if none has been provided.
 func (s StatementOptions) GetMetricScope() (string, bool) {
@@ -1162,6 +1281,9 @@ func (s StatementOptions) ValidateForCreateChangefeed(isPredicateChangefeed bool
 			}
 		}
 	}
+	if _, _, err := s.GetExactlyOnceInterval(); err != nil {
+		return err
+	}
 	return nil
 }
 
@@ -37,6 +37,12 @@ func TestOptionsValidations(t *testing.T) {
 		{map[string]string{"initial_scan_only": "", "resolved": ""}, true, "cannot specify both initial_scan='only'"},
 		{map[string]string{"initial_scan_only": "", "resolved": ""}, true, "cannot specify both initial_scan='only'"},
 		{map[string]string{"key_column": "b"}, false, "requires the unordered option"},
+		{map[string]string{"exactly_once": ""}, false, "requires the resolved option"},
+		{map[string]string{"exactly_once": "", "resolved": ""}, false, "requires a non-zero resolved interval"},
+		{map[string]string{"exactly_once": "", "resolved": "10s"}, false, ""},
+		{map[string]string{"exactly_once": "", "resolved": "10s", "format": "parquet"}, false, "cannot specify both"},
+		{map[string]string{"transaction_markers": ""}, false, "requires the exactly_once option"},
+		{map[string]string{"transaction_markers": "", "exactly_once": "", "resolved": "10s"}, false, ""},
 	}
 
 	for _, test := range tests {
@@ -66,6 +72,15 @@ func TestEncodingOptionsValidations(t *testing.T) {
 		{EncodingOptions{Format: OptFormatAvro, Envelope: OptEnvelopeBare, UpdatedTimestamps: true}, "is only usable with envelope=wrapped"},
 		{EncodingOptions{Format: OptFormatAvro, Envelope: OptEnvelopeBare, MVCCTimestamps: true}, "is only usable with envelope=wrapped"},
 		{EncodingOptions{Format: OptFormatAvro, Envelope: OptEnvelopeBare, Diff: true}, "is only usable with envelope=wrapped"},
+		{EncodingOptions{Format: OptFormatAvro, Envelope: OptEnvelopeDebezium, Diff: true}, ""},
+		{EncodingOptions{Format: OptFormatJSON, Envelope: OptEnvelopeDebezium, Diff: true}, ""},
+		{EncodingOptions{Format: OptFormatCSV, Envelope: OptEnvelopeDebezium}, "envelope=debezium is only usable with format=json or format=avro"},
+		{EncodingOptions{Format: OptFormatJSON, Envelope: OptEnvelopeDebezium, UpdatedTimestamps: true}, "updated is not supported with envelope=debezium"},
+		{EncodingOptions{Format: OptFormatAvro, Envelope: OptEnvelopeDebezium, MVCCTimestamps: true}, "mvcc_timestamp is not supported with envelope=debezium"},
+		{EncodingOptions{Format: OptFormatJSON, Envelope: OptEnvelopeWrapped, TransactionMarkers: true}, ""},
+		{EncodingOptions{Format: OptFormatJSON, Envelope: OptEnvelopeBare, TransactionMarkers: true}, ""},
+		{EncodingOptions{Format: OptFormatAvro, Envelope: OptEnvelopeWrapped, TransactionMarkers: true}, "transaction_markers is only usable with format=json"},
+		{EncodingOptions{Format: OptFormatJSON, Envelope: OptEnvelopeKeyOnly, TransactionMarkers: true}, "transaction_markers is only usable with envelope=wrapped or envelope=bare"},
 	}
 
 	for _, c := range cases {
@@ -79,3 +94,14 @@ func TestEncodingOptionsValidations(t *testing.T) {
 	}
 
 }
+
+func TestDebeziumEnvelopeImpliesDiff(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	o := MakeStatementOptions(map[string]string{OptEnvelope: string(OptEnvelopeDebezium)})
+	require.True(t, o.GetFilters().WithDiff)
+	encodingOpts, err := o.GetEncodingOptions()
+	require.NoError(t, err)
+	require.True(t, encodingOpts.Diff)
+}
@@ -328,6 +328,28 @@ var UsageMetricsReportingTimeoutPercent = settings.RegisterIntSetting(
 	settings.IntInRange(10, 100),
 )
 
+// LookupCacheStaleness controls how long results of lookup sub-queries in
+// changefeed expressions may be reused for events with later MVCC timestamps.
+var LookupCacheStaleness = settings.RegisterDurationSetting(
+	settings.ApplicationLevel,
+	"changefeed.expressions.lookup_cache_staleness",
+	"the maximum amount by which the MVCC timestamp of an event may exceed the timestamp "+
+		"at which a cached result of a lookup sub-query in a changefeed expression was read; "+
+		"if 0, lookups are always evaluated as of the exact MVCC timestamp of the event",
+	0,
+	settings.NonNegativeDuration,
+)
+
+// SpanReportsEnabled controls whether change aggregators persist the state of
+// their rangefeeds for SHOW CHANGEFEED JOB ... SPANS.
+var SpanReportsEnabled = settings.RegisterBoolSetting(
+	settings.ApplicationLevel,
+	"changefeed.span_reports.enabled",
+	"if enabled, changefeed aggregators persist the state of their most lagging ranges "+
+		"every lagging ranges polling interval, for SHOW CHANGEFEED JOB ... SPANS",
+	false,
+)
+
 // DefaultLaggingRangesThreshold is the default duration by which a range must be
 // lagging behind the present to be considered as 'lagging' behind in metrics.
 var DefaultLaggingRangesThreshold = 3 * time.Minute
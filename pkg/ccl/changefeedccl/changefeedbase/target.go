@@ -64,6 +64,20 @@ func (tbt targetsByTable) each(f func(t Target) error) error {
 type Targets struct {
 	Size uint
 	m    map[descpb.ID]targetsByTable
+	// namespaces is the set of databases and schemas whose tables are watched
+	// by a database- or schema-level changefeed.
+	namespaces map[Namespace]struct{}
+	// lookupTables is the set of tables referenced by the lookups of the
+	// changefeed expression. Their changes are not emitted, but they are
+	// watched for drops and protected along with the targets.
+	lookupTables map[descpb.ID]struct{}
+}
+
+// Namespace identifies a database or, if SchemaID is set, a schema within a
+// database, all of whose tables are watched by the changefeed.
+type Namespace struct {
+	DatabaseID descpb.ID
+	SchemaID   descpb.ID
 }
 
 // Add adds a target to the list.
@@ -75,6 +89,73 @@ func (ts *Targets) Add(t Target) {
 	ts.Size++
 }
 
+// AddNamespace adds a namespace whose tables are watched by the changefeed.
+func (ts *Targets) AddNamespace(ns Namespace) {
+	if ts.namespaces == nil {
+		ts.namespaces = make(map[Namespace]struct{})
+	}
+	ts.namespaces[ns] = struct{}{}
+}
+
+// HasNamespaces returns true if the changefeed watches all the tables of at
+// least one database or schema.
+func (ts *Targets) HasNamespaces() bool {
+	return len(ts.namespaces) > 0
+}
+
+// WatchesNamespace returns true if a table with the given parent database and
+// schema belongs to one of the namespaces watched by the changefeed.
+func (ts *Targets) WatchesNamespace(dbID, schemaID descpb.ID) bool {
+	if _, ok := ts.namespaces[Namespace{DatabaseID: dbID}]; ok {
+		return true
+	}
+	_, ok := ts.namespaces[Namespace{DatabaseID: dbID, SchemaID: schemaID}]
+	return ok
+}
+
+// EachNamespace iterates over the namespaces watched by the changefeed.
+func (ts *Targets) EachNamespace(f func(Namespace) error) error {
+	for ns := range ts.namespaces {
+		if err := f(ns); err != nil {
+			return iterutil.Map(err)
+		}
+	}
+	return nil
+}
+
+// AddLookupTable adds a table referenced by the lookups of the changefeed
+// expression.
+func (ts *Targets) AddLookupTable(id descpb.ID) {
+	if ts.lookupTables == nil {
+		ts.lookupTables = make(map[descpb.ID]struct{})
+	}
+	ts.lookupTables[id] = struct{}{}
+}
+
+// HasLookupTables returns true if the changefeed expression references any
+// lookup tables.
+func (ts *Targets) HasLookupTables() bool {
+	return len(ts.lookupTables) > 0
+}
+
+// IsLookupTable returns true if the table is referenced by the lookups of the
+// changefeed expression.
+func (ts *Targets) IsLookupTable(id descpb.ID) bool {
+	_, ok := ts.lookupTables[id]
+	return ok
+}
+
+// EachLookupTableID iterates over the tables referenced by the lookups of the
+// changefeed expression.
+func (ts *Targets) EachLookupTableID(f func(descpb.ID) error) error {
+	for id := range ts.lookupTables {
+		if err := f(id); err != nil {
+			return iterutil.Map(err)
+		}
+	}
+	return nil
+}
+
 // EachTarget iterates over Targets.
 func (ts *Targets) EachTarget(f func(Target) error) error {
 	for _, l := range ts.m {
@@ -0,0 +1,54 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package changefeedbase
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTargetsWatchesNamespace(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	var targets Targets
+	require.False(t, targets.HasNamespaces())
+	require.False(t, targets.WatchesNamespace(100, 101))
+
+	// A whole database.
+	targets.AddNamespace(Namespace{DatabaseID: 100})
+	// A single schema of another database.
+	targets.AddNamespace(Namespace{DatabaseID: 200, SchemaID: 201})
+	require.True(t, targets.HasNamespaces())
+
+	for _, tc := range []struct {
+		dbID, schemaID int
+		expected       bool
+	}{
+		{dbID: 100, schemaID: 101, expected: true},
+		{dbID: 100, schemaID: 102, expected: true},
+		{dbID: 200, schemaID: 201, expected: true},
+		{dbID: 200, schemaID: 202, expected: false},
+		{dbID: 300, schemaID: 101, expected: false},
+	} {
+		require.Equal(t, tc.expected, targets.WatchesNamespace(descpb.ID(tc.dbID), descpb.ID(tc.schemaID)),
+			"database %d, schema %d", tc.dbID, tc.schemaID)
+	}
+
+	var seen []Namespace
+	require.NoError(t, targets.EachNamespace(func(ns Namespace) error {
+		seen = append(seen, ns)
+		return nil
+	}))
+	require.ElementsMatch(t, []Namespace{
+		{DatabaseID: 100},
+		{DatabaseID: 200, SchemaID: 201},
+	}, seen)
+}
@@ -0,0 +1,170 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package changefeedccl
+
+import (
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdcevent"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/linkedin/goavro/v2"
+)
+
+// The debezium envelope mimics the change events of Debezium connectors, so
+// that consumers built for them (e.g. Kafka Connect sinks) can consume
+// changefeeds unchanged. Each event carries the previous and current versions
+// of the row, a source block describing where the change comes from, and an
+// operation code. Deletions are followed by a tombstone, a message with the
+// key of the deleted row and no value.
+//
+//	https://debezium.io/documentation/reference/stable/connectors/postgresql.html#postgresql-change-events-value
+
+// debeziumConnector is the name of the connector reported in source blocks.
+const debeziumConnector = `cockroachdb`
+
+// Debezium operation codes.
+const (
+	debeziumOpCreate = `c`
+	debeziumOpUpdate = `u`
+	debeziumOpDelete = `d`
+	// debeziumOpRead is the operation of rows emitted by snapshots, which are
+	// the initial scans and schema change backfills of changefeeds.
+	debeziumOpRead = `r`
+)
+
+// debeziumSourceFields are the fields of source blocks, in order.
+var debeziumSourceFields = []string{
+	`connector`, `ts_ms`, `ts_hlc`, `snapshot`, `db`, `schema`, `table`, `txId`,
+}
+
+// debeziumSource is the source block of a debezium envelope.
+type debeziumSource struct {
+	// tsMs is the time of the change in milliseconds since the epoch, and tsHLC
+	// is its full HLC timestamp.
+	tsMs  int64
+	tsHLC string
+	// snapshot is set if the row was emitted by a snapshot rather than by a
+	// change to the row.
+	snapshot bool
+	// db and schema are empty if unknown.
+	db, schema, table string
+	// txID is the ID of the transaction which changed the row, or empty if
+	// unknown. The rangefeed reports it when kv.rangefeed.txn_ids.enabled is
+	// set, which changefeeds with the debezium envelope require, so it is only
+	// empty for rows from initial and catch-up scans and for non-transactional
+	// writes.
+	txID string
+}
+
+// makeDebeziumSource returns the source block of the event.
+func makeDebeziumSource(evCtx eventContext, updated cdcevent.Row) debeziumSource {
+	src := debeziumSource{
+		tsMs:     evCtx.updated.WallTime / 1e6,
+		tsHLC:    evCtx.updated.AsOfSystemTime(),
+		snapshot: evCtx.backfill,
+		db:       updated.DatabaseName,
+		schema:   updated.SchemaName,
+		table:    updated.TableName,
+	}
+	if evCtx.txnID != uuid.Nil {
+		src.txID = evCtx.txnID.String()
+	}
+	return src
+}
+
+// debeziumOp returns the operation code of the event.
+func debeziumOp(evCtx eventContext, updated, prev cdcevent.Row) string {
+	switch {
+	case updated.IsDeleted():
+		return debeziumOpDelete
+	case evCtx.backfill:
+		return debeziumOpRead
+	case prev.IsInitialized() && !prev.IsDeleted():
+		return debeziumOpUpdate
+	default:
+		return debeziumOpCreate
+	}
+}
+
+// snapshotString returns the value of the snapshot field, which Debezium
+// encodes as a string.
+func (s debeziumSource) snapshotString() string {
+	if s.snapshot {
+		return `true`
+	}
+	return `false`
+}
+
+// asJSON sets the fields of the source block in the builder, which has the
+// debeziumSourceFields keys.
+func (s debeziumSource) asJSON(b *json.FixedKeysObjectBuilder) (json.JSON, error) {
+	optString := func(v string) json.JSON {
+		if v == "" {
+			return json.NullJSONValue
+		}
+		return json.FromString(v)
+	}
+	for _, f := range []struct {
+		k string
+		v json.JSON
+	}{
+		{`connector`, json.FromString(debeziumConnector)},
+		{`ts_ms`, json.FromInt64(s.tsMs)},
+		{`ts_hlc`, json.FromString(s.tsHLC)},
+		{`snapshot`, json.FromString(s.snapshotString())},
+		{`db`, optString(s.db)},
+		{`schema`, optString(s.schema)},
+		{`table`, json.FromString(s.table)},
+		{`txId`, optString(s.txID)},
+	} {
+		if err := b.Set(f.k, f.v); err != nil {
+			return nil, err
+		}
+	}
+	return b.Build()
+}
+
+// debeziumSourceToAvroSchema returns the avro schema of the source blocks of
+// the envelopes of the given topic.
+func debeziumSourceToAvroSchema(topic string, namespace string) *avroRecord {
+	schema := &avroRecord{
+		Name:       SQLNameToAvroName(topic) + `_source`,
+		SchemaType: `record`,
+		Namespace:  namespace,
+	}
+	for _, name := range debeziumSourceFields {
+		var typ avroSchemaType = avroSchemaString
+		if name == `ts_ms` {
+			typ = avroSchemaLong
+		}
+		schema.Fields = append(schema.Fields, &avroSchemaField{
+			Name:       name,
+			SchemaType: []avroSchemaType{avroSchemaNull, typ},
+			Default:    nil,
+		})
+	}
+	return schema
+}
+
+// avroNative returns the go native representation of the source block, as
+// expected by the avro schema of debeziumSourceToAvroSchema.
+func (s debeziumSource) avroNative() map[string]interface{} {
+	optString := func(v string) interface{} {
+		if v == "" {
+			return nil
+		}
+		return goavro.Union(avroSchemaString, v)
+	}
+	return map[string]interface{}{
+		`connector`: goavro.Union(avroSchemaString, debeziumConnector),
+		`ts_ms`:     goavro.Union(avroSchemaLong, s.tsMs),
+		`ts_hlc`:    goavro.Union(avroSchemaString, s.tsHLC),
+		`snapshot`:  goavro.Union(avroSchemaString, s.snapshotString()),
+		`db`:        optString(s.db),
+		`schema`:    optString(s.schema),
+		`table`:     goavro.Union(avroSchemaString, s.table),
+		`txId`:      optString(s.txID),
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package changefeedccl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdcevent"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdctest"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebeziumEnvelope(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	tableDesc, err := parseTableDesc(`CREATE TABLE foo (a INT PRIMARY KEY, b STRING)`)
+	require.NoError(t, err)
+	targets := mkTargets(tableDesc)
+
+	mkRow := func(b string, deleted bool) cdcevent.Row {
+		return cdcevent.TestingMakeEventRow(tableDesc, 0, rowenc.EncDatumRow{
+			rowenc.EncDatum{Datum: tree.NewDInt(1)},
+			rowenc.EncDatum{Datum: tree.NewDString(b)},
+		}, deleted)
+	}
+	ts := hlc.Timestamp{WallTime: 2e6, Logical: 1}
+	txnID := uuid.FromStringOrNil(`f7ec2a5c-4f0b-4d2a-9a54-0c43f3d0a6f4`)
+
+	cases := []struct {
+		name         string
+		evCtx        eventContext
+		row, prevRow cdcevent.Row
+		expectedJSON string
+		expectedAvro string
+	}{
+		{
+			name:    "insert",
+			evCtx:   eventContext{updated: ts, mvcc: ts, txnID: txnID},
+			row:     mkRow(`x`, false),
+			prevRow: mkRow(``, true),
+			expectedJSON: `{"before": null, "after": {"a": 1, "b": "x"}, "op": "c", "source": {` +
+				`"connector": "cockroachdb", "ts_ms": 2, "ts_hlc": "2000000.0000000001", "snapshot": "false", ` +
+				`"db": null, "schema": null, "table": "foo", "txId": "f7ec2a5c-4f0b-4d2a-9a54-0c43f3d0a6f4"}}`,
+			expectedAvro: `{"before": null, "after": {"foo": {"a": {"long": 1}, "b": {"string": "x"}}}, ` +
+				`"source": {"foo_source": {"connector": {"string": "cockroachdb"}, "ts_ms": {"long": 2}, ` +
+				`"ts_hlc": {"string": "2000000.0000000001"}, "snapshot": {"string": "false"}, "db": null, ` +
+				`"schema": null, "table": {"string": "foo"}, ` +
+				`"txId": {"string": "f7ec2a5c-4f0b-4d2a-9a54-0c43f3d0a6f4"}}}, "op": {"string": "c"}}`,
+		},
+		{
+			name:    "update",
+			evCtx:   eventContext{updated: ts, mvcc: ts},
+			row:     mkRow(`y`, false),
+			prevRow: mkRow(`x`, false),
+			expectedJSON: `{"before": {"a": 1, "b": "x"}, "after": {"a": 1, "b": "y"}, "op": "u", "source": {` +
+				`"connector": "cockroachdb", "ts_ms": 2, "ts_hlc": "2000000.0000000001", "snapshot": "false", ` +
+				`"db": null, "schema": null, "table": "foo", "txId": null}}`,
+			expectedAvro: `{"before": {"foo_before": {"a": {"long": 1}, "b": {"string": "x"}}}, ` +
+				`"after": {"foo": {"a": {"long": 1}, "b": {"string": "y"}}}, ` +
+				`"source": {"foo_source": {"connector": {"string": "cockroachdb"}, "ts_ms": {"long": 2}, ` +
+				`"ts_hlc": {"string": "2000000.0000000001"}, "snapshot": {"string": "false"}, "db": null, ` +
+				`"schema": null, "table": {"string": "foo"}, "txId": null}}, "op": {"string": "u"}}`,
+		},
+		{
+			name:    "delete",
+			evCtx:   eventContext{updated: ts, mvcc: ts},
+			row:     mkRow(`y`, true),
+			prevRow: mkRow(`y`, false),
+			expectedJSON: `{"before": {"a": 1, "b": "y"}, "after": null, "op": "d", "source": {` +
+				`"connector": "cockroachdb", "ts_ms": 2, "ts_hlc": "2000000.0000000001", "snapshot": "false", ` +
+				`"db": null, "schema": null, "table": "foo", "txId": null}}`,
+			expectedAvro: `{"before": {"foo_before": {"a": {"long": 1}, "b": {"string": "y"}}}, "after": null, ` +
+				`"source": {"foo_source": {"connector": {"string": "cockroachdb"}, "ts_ms": {"long": 2}, ` +
+				`"ts_hlc": {"string": "2000000.0000000001"}, "snapshot": {"string": "false"}, "db": null, ` +
+				`"schema": null, "table": {"string": "foo"}, "txId": null}}, "op": {"string": "d"}}`,
+		},
+		{
+			name:    "initial scan",
+			evCtx:   eventContext{updated: ts, mvcc: ts, backfill: true},
+			row:     mkRow(`x`, false),
+			prevRow: mkRow(``, true),
+			expectedJSON: `{"before": null, "after": {"a": 1, "b": "x"}, "op": "r", "source": {` +
+				`"connector": "cockroachdb", "ts_ms": 2, "ts_hlc": "2000000.0000000001", "snapshot": "true", ` +
+				`"db": null, "schema": null, "table": "foo", "txId": null}}`,
+			expectedAvro: `{"before": null, "after": {"foo": {"a": {"long": 1}, "b": {"string": "x"}}}, ` +
+				`"source": {"foo_source": {"connector": {"string": "cockroachdb"}, "ts_ms": {"long": 2}, ` +
+				`"ts_hlc": {"string": "2000000.0000000001"}, "snapshot": {"string": "true"}, "db": null, ` +
+				`"schema": null, "table": {"string": "foo"}, "txId": null}}, "op": {"string": "r"}}`,
+		},
+	}
+
+	t.Run("json", func(t *testing.T) {
+		opts := changefeedbase.EncodingOptions{
+			Format: changefeedbase.OptFormatJSON, Envelope: changefeedbase.OptEnvelopeDebezium, Diff: true,
+		}
+		require.NoError(t, opts.Validate())
+		e, err := getEncoder(ctx, opts, targets, false, nil, nil)
+		require.NoError(t, err)
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				value, err := e.EncodeValue(ctx, c.evCtx, c.row, c.prevRow)
+				require.NoError(t, err)
+				require.JSONEq(t, c.expectedJSON, string(value))
+			})
+		}
+	})
+
+	t.Run("avro", func(t *testing.T) {
+		reg := cdctest.StartTestSchemaRegistry()
+		defer reg.Close()
+		opts := changefeedbase.EncodingOptions{
+			Format:            changefeedbase.OptFormatAvro,
+			Envelope:          changefeedbase.OptEnvelopeDebezium,
+			Diff:              true,
+			SchemaRegistryURI: reg.URL(),
+		}
+		require.NoError(t, opts.Validate())
+		e, err := getEncoder(ctx, opts, targets, false, nil, nil)
+		require.NoError(t, err)
+		for _, c := range cases {
+			t.Run(c.name, func(t *testing.T) {
+				value, err := e.EncodeValue(ctx, c.evCtx, c.row, c.prevRow)
+				require.NoError(t, err)
+				require.JSONEq(t, c.expectedAvro, string(avroToJSON(t, reg, value)))
+			})
+		}
+	})
+}
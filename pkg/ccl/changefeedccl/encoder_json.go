@@ -52,6 +52,7 @@ func init() {
 // stored in a sub-object under the `__crdb__` key in the top-level JSON object.
 type jsonEncoder struct {
 	updatedField, mvccTimestampField, beforeField, keyInValue, topicInValue bool
+	txnIDField                                                              bool
 	envelopeType                                                            changefeedbase.EnvelopeType
 
 	buf             bytes.Buffer
@@ -96,6 +97,7 @@ func makeJSONEncoder(ctx context.Context, opts jsonEncoderOptions) (*jsonEncoder
 		envelopeType:       opts.Envelope,
 		updatedField:       opts.UpdatedTimestamps,
 		mvccTimestampField: opts.MVCCTimestamps,
+		txnIDField:         opts.TransactionMarkers,
 		customKeyColumn:    opts.CustomKeyColumn,
 		// In the bare envelope we don't output diff directly, it's incorporated into the
 		// projection as desired.
@@ -131,11 +133,16 @@ func makeJSONEncoder(ctx context.Context, opts jsonEncoderOptions) (*jsonEncoder
 		}
 	}
 
-	if e.envelopeType == changefeedbase.OptEnvelopeWrapped {
+	switch e.envelopeType {
+	case changefeedbase.OptEnvelopeWrapped:
 		if err := e.initWrappedEnvelope(ctx); err != nil {
 			return nil, err
 		}
-	} else {
+	case changefeedbase.OptEnvelopeDebezium:
+		if err := e.initDebeziumEnvelope(ctx); err != nil {
+			return nil, err
+		}
+	default:
 		if err := e.initRawEnvelope(ctx); err != nil {
 			return nil, err
 		}
@@ -305,6 +312,9 @@ func (e *jsonEncoder) initRawEnvelope(ctx context.Context) error {
 	if e.topicInValue {
 		metaKeys = append(metaKeys, "topic")
 	}
+	if e.txnIDField {
+		metaKeys = append(metaKeys, "txn_id")
+	}
 
 	// Setup builder for crdb meta if needed.
 	var metaBuilder *json.FixedKeysObjectBuilder
@@ -347,6 +357,12 @@ func (e *jsonEncoder) initRawEnvelope(ctx context.Context) error {
 			}
 		}
 
+		if e.txnIDField {
+			if err := metaBuilder.Set("txn_id", txnIDAsJSON(evCtx)); err != nil {
+				return nil, err
+			}
+		}
+
 		meta, err := metaBuilder.Build()
 		if err != nil {
 			return nil, err
@@ -373,6 +389,9 @@ func (e *jsonEncoder) initWrappedEnvelope(ctx context.Context) error {
 	if e.mvccTimestampField {
 		keys = append(keys, "mvcc_timestamp")
 	}
+	if e.txnIDField {
+		keys = append(keys, "txn_id")
+	}
 	b, err := json.NewFixedKeysObjectBuilder(keys)
 	if err != nil {
 		return err
@@ -429,6 +448,60 @@ func (e *jsonEncoder) initWrappedEnvelope(ctx context.Context) error {
 			}
 		}
 
+		if e.txnIDField {
+			if err := b.Set("txn_id", txnIDAsJSON(evCtx)); err != nil {
+				return nil, err
+			}
+		}
+
+		return b.Build()
+	}
+	return nil
+}
+
+func (e *jsonEncoder) initDebeziumEnvelope(ctx context.Context) error {
+	b, err := json.NewFixedKeysObjectBuilder([]string{"before", "after", "source", "op"})
+	if err != nil {
+		return err
+	}
+	sourceBuilder, err := json.NewFixedKeysObjectBuilder(debeziumSourceFields)
+	if err != nil {
+		return err
+	}
+
+	const emitDeletedRowAsNull = true
+	e.envelopeEncoder = func(evCtx eventContext, updated, prev cdcevent.Row) (json.JSON, error) {
+		after, err := e.versionEncoder(updated.EventDescriptor, false).rowAsGoNative(
+			ctx, updated, emitDeletedRowAsNull, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := b.Set("after", after); err != nil {
+			return nil, err
+		}
+
+		var before json.JSON = json.NullJSONValue
+		if prev.IsInitialized() && !prev.IsDeleted() {
+			before, err = e.versionEncoder(prev.EventDescriptor, true).rowAsGoNative(
+				ctx, prev, emitDeletedRowAsNull, nil)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if err := b.Set("before", before); err != nil {
+			return nil, err
+		}
+
+		source, err := makeDebeziumSource(evCtx, updated).asJSON(sourceBuilder)
+		if err != nil {
+			return nil, err
+		}
+		if err := b.Set("source", source); err != nil {
+			return nil, err
+		}
+		if err := b.Set("op", json.FromString(debeziumOp(evCtx, updated, prev))); err != nil {
+			return nil, err
+		}
 		return b.Build()
 	}
 	return nil
@@ -442,7 +515,8 @@ func (e *jsonEncoder) EncodeValue(
 		return nil, nil
 	}
 
-	if updatedRow.IsDeleted() && !canJSONEncodeMetadata(e.envelopeType) {
+	if updatedRow.IsDeleted() && !canJSONEncodeMetadata(e.envelopeType) &&
+		e.envelopeType != changefeedbase.OptEnvelopeDebezium {
 		return nil, nil
 	}
 
@@ -463,7 +537,8 @@ func (e *jsonEncoder) EncodeResolvedTimestamp(
 		`resolved`: eval.TimestampToDecimalDatum(resolved).Decimal.String(),
 	}
 	var jsonEntries interface{}
-	if e.envelopeType == changefeedbase.OptEnvelopeWrapped {
+	if e.envelopeType == changefeedbase.OptEnvelopeWrapped ||
+		e.envelopeType == changefeedbase.OptEnvelopeDebezium {
 		jsonEntries = meta
 	} else {
 		jsonEntries = map[string]interface{}{
@@ -473,6 +548,16 @@ func (e *jsonEncoder) EncodeResolvedTimestamp(
 	return gojson.Marshal(jsonEntries)
 }
 
+// txnIDAsJSON returns the identifier of the transaction of the event, or null
+// if the event isn't part of a transaction.
+func txnIDAsJSON(evCtx eventContext) json.JSON {
+	id, ok := txnMarkerID(evCtx)
+	if !ok {
+		return json.NullJSONValue
+	}
+	return json.FromString(id)
+}
+
 var placeholderCtx = eventContext{topic: "topic"}
 
 // EncodeAsJSONChangefeedWithFlags implements the crdb_internal.to_json_as_changefeed_with_flags
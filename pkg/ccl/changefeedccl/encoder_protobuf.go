@@ -0,0 +1,590 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package changefeedccl
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdcevent"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/cache"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/errors"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// protobufEncoder encodes changefeed entries as protobuf messages. A message
+// descriptor is generated for every version of every table (and column
+// family), so messages follow the schema changes of the tables. Keys are the
+// primary key columns of a record. Values are all the columns of a record,
+// wrapped in an envelope message unless the envelope is bare.
+//
+// Each column maps to a field whose number is the ID of the column. Column IDs
+// are never reused, which keeps the messages of different versions of a table
+// compatible with each other. The columns computed by CDC queries have no ID,
+// so the format is not supported with CDC queries. Booleans, integers, floats and bytes map to the
+// corresponding protobuf scalar types; all other types, such as decimals,
+// timestamps or arrays, are encoded as strings in their SQL text form.
+//
+// If a schema registry is configured, the message descriptors are registered
+// with it as PROTOBUF schemas and the messages are framed using Confluent's
+// wire format. Otherwise, the messages are emitted as is.
+type protobufEncoder struct {
+	schemaRegistry            schemaRegistry
+	updatedField, beforeField bool
+	mvccTimestampField        bool
+	keyInValue, topicInValue  bool
+	targets                   changefeedbase.Targets
+	envelopeType              changefeedbase.EnvelopeType
+	customKeyColumn           string
+	formatter                 *tree.FmtCtx
+	marshalOpts               proto.MarshalOptions
+	keyCache                  *cache.UnorderedCache // [tableIDAndVersion]protobufRegisteredSchema
+	valueCache                *cache.UnorderedCache // [tableIDAndVersionPair]protobufRegisteredSchema
+	resolvedCache             map[string]protobufRegisteredSchema
+}
+
+// protobufRegisteredSchema is the descriptor of a top-level message, along
+// with the ID the schema registry assigned to it, if any.
+type protobufRegisteredSchema struct {
+	msg        protoreflect.MessageDescriptor
+	registryID int32
+}
+
+var _ Encoder = &protobufEncoder{}
+
+// Field numbers of the envelope messages.
+const (
+	protobufAfterField         protowire.Number = 1
+	protobufBeforeField        protowire.Number = 2
+	protobufUpdatedField       protowire.Number = 3
+	protobufMVCCTimestampField protowire.Number = 4
+	protobufKeyField           protowire.Number = 5
+	protobufTopicField         protowire.Number = 6
+	protobufResolvedField      protowire.Number = 7
+
+	// protobufMetaSentinelField is the number of the metaSentinel field of
+	// bare envelopes, which holds the metadata next to the columns. It is the
+	// largest field number so that it does not collide with column IDs.
+	protobufMetaSentinelField = protowire.MaxValidNumber
+)
+
+func newProtobufEncoder(
+	opts changefeedbase.EncodingOptions,
+	targets changefeedbase.Targets,
+	p externalConnectionProvider,
+	sliMetrics *sliMetrics,
+) (*protobufEncoder, error) {
+	e := &protobufEncoder{
+		updatedField:       opts.UpdatedTimestamps,
+		beforeField:        opts.Diff,
+		mvccTimestampField: opts.MVCCTimestamps,
+		keyInValue:         opts.KeyInValue,
+		topicInValue:       opts.TopicInValue,
+		targets:            targets,
+		envelopeType:       opts.Envelope,
+		customKeyColumn:    opts.CustomKeyColumn,
+		formatter:          tree.NewFmtCtx(tree.FmtExport),
+		marshalOpts:        proto.MarshalOptions{Deterministic: true},
+		keyCache:           cache.NewUnorderedCache(encoderCacheConfig),
+		valueCache:         cache.NewUnorderedCache(encoderCacheConfig),
+		resolvedCache:      make(map[string]protobufRegisteredSchema),
+	}
+	if len(opts.SchemaRegistryURI) > 0 {
+		reg, err := newConfluentSchemaRegistry(opts.SchemaRegistryURI, p, sliMetrics)
+		if err != nil {
+			return nil, err
+		}
+		e.schemaRegistry = reg
+	}
+	return e, nil
+}
+
+// EncodeKey implements the Encoder interface.
+func (e *protobufEncoder) EncodeKey(ctx context.Context, row cdcevent.Row) ([]byte, error) {
+	// No familyID in the cache key for keys because it's the same schema for
+	// all families.
+	cacheKey := tableIDAndVersion{tableID: row.TableID, version: row.Version}
+
+	keyColumns, err := e.keyColumns(row)
+	if err != nil {
+		return nil, err
+	}
+	var registered protobufRegisteredSchema
+	if v, ok := e.keyCache.Get(cacheKey); ok {
+		registered = v.(protobufRegisteredSchema)
+	} else {
+		tableName, err := rawTableName(e.targets, "" /* schemaPrefix */, row.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		key, err := protobufRecordMessage(SQLNameToAvroName(tableName), keyColumns)
+		if err != nil {
+			return nil, err
+		}
+		// NB: This uses the kafka name escaper because it has to match the name
+		// of the kafka topic.
+		subject := SQLNameToKafkaName(tableName) + confluentSubjectSuffixKey
+		if registered, err = e.register(ctx, key, subject); err != nil {
+			return nil, err
+		}
+		e.keyCache.Add(cacheKey, registered)
+	}
+
+	m := dynamicpb.NewMessage(registered.msg)
+	if err := e.setColumns(m, keyColumns); err != nil {
+		return nil, err
+	}
+	return e.marshal(registered, m)
+}
+
+// EncodeValue implements the Encoder interface.
+func (e *protobufEncoder) EncodeValue(
+	ctx context.Context, evCtx eventContext, updatedRow cdcevent.Row, prevRow cdcevent.Row,
+) ([]byte, error) {
+	if e.envelopeType == changefeedbase.OptEnvelopeKeyOnly {
+		return nil, nil
+	}
+	isBare := e.envelopeType == changefeedbase.OptEnvelopeBare
+	if isBare && updatedRow.IsDeleted() && !e.updatedField && !e.mvccTimestampField {
+		// Without metadata, there is nothing to encode for a deleted row.
+		return nil, nil
+	}
+
+	var cacheKey tableIDAndVersionPair
+	if e.beforeField && prevRow.IsInitialized() {
+		cacheKey[0] = tableIDAndVersion{
+			tableID: prevRow.TableID, version: prevRow.Version, familyID: prevRow.FamilyID,
+		}
+	}
+	cacheKey[1] = tableIDAndVersion{
+		tableID: updatedRow.TableID, version: updatedRow.Version, familyID: updatedRow.FamilyID,
+	}
+
+	var registered protobufRegisteredSchema
+	if v, ok := e.valueCache.Get(cacheKey); ok {
+		registered = v.(protobufRegisteredSchema)
+	} else {
+		name, err := rawTableName(e.targets, "" /* schemaPrefix */, updatedRow.Metadata)
+		if err != nil {
+			return nil, err
+		}
+		var envelope *descriptorpb.DescriptorProto
+		if isBare {
+			envelope, err = e.bareEnvelopeMessage(SQLNameToAvroName(name), updatedRow)
+		} else {
+			envelope, err = e.wrappedEnvelopeMessage(SQLNameToAvroName(name), updatedRow, prevRow)
+		}
+		if err != nil {
+			return nil, err
+		}
+		// NB: This uses the kafka name escaper because it has to match the name
+		// of the kafka topic.
+		subject := SQLNameToKafkaName(name) + confluentSubjectSuffixValue
+		if registered, err = e.register(ctx, envelope, subject); err != nil {
+			return nil, err
+		}
+		e.valueCache.Add(cacheKey, registered)
+	}
+
+	m := dynamicpb.NewMessage(registered.msg)
+	fields := registered.msg.Fields()
+	var meta protoreflect.Message
+	if isBare {
+		if !updatedRow.IsDeleted() {
+			if err := e.setColumns(m, updatedRow.ForEachColumn()); err != nil {
+				return nil, err
+			}
+		}
+		if fd := fields.ByNumber(protobufMetaSentinelField); fd != nil {
+			meta = m.Mutable(fd).Message()
+		}
+	} else {
+		meta = m
+		if !updatedRow.IsDeleted() {
+			after := m.Mutable(fields.ByNumber(protobufAfterField)).Message()
+			if err := e.setColumns(after, updatedRow.ForEachColumn()); err != nil {
+				return nil, err
+			}
+		}
+		if e.beforeField && prevRow.IsInitialized() && !prevRow.IsDeleted() {
+			before := m.Mutable(fields.ByNumber(protobufBeforeField)).Message()
+			if err := e.setColumns(before, prevRow.ForEachColumn()); err != nil {
+				return nil, err
+			}
+		}
+		if e.keyInValue {
+			keyColumns, err := e.keyColumns(updatedRow)
+			if err != nil {
+				return nil, err
+			}
+			key := m.Mutable(fields.ByNumber(protobufKeyField)).Message()
+			if err := e.setColumns(key, keyColumns); err != nil {
+				return nil, err
+			}
+		}
+		if e.topicInValue {
+			m.Set(fields.ByNumber(protobufTopicField), protoreflect.ValueOfString(evCtx.topic))
+		}
+	}
+	if meta != nil {
+		metaFields := meta.Descriptor().Fields()
+		if e.updatedField {
+			meta.Set(metaFields.ByNumber(protobufUpdatedField),
+				protoreflect.ValueOfString(evCtx.updated.AsOfSystemTime()))
+		}
+		if e.mvccTimestampField {
+			meta.Set(metaFields.ByNumber(protobufMVCCTimestampField),
+				protoreflect.ValueOfString(evCtx.mvcc.AsOfSystemTime()))
+		}
+	}
+	return e.marshal(registered, m)
+}
+
+// EncodeResolvedTimestamp implements the Encoder interface.
+func (e *protobufEncoder) EncodeResolvedTimestamp(
+	ctx context.Context, topic string, resolved hlc.Timestamp,
+) ([]byte, error) {
+	registered, ok := e.resolvedCache[topic]
+	if !ok {
+		var meta []*descriptorpb.FieldDescriptorProto
+		meta = append(meta, protobufScalarField(`resolved`, protobufResolvedField,
+			descriptorpb.FieldDescriptorProto_TYPE_STRING))
+		msg := &descriptorpb.DescriptorProto{Name: proto.String(SQLNameToAvroName(topic))}
+		if e.envelopeType == changefeedbase.OptEnvelopeWrapped {
+			msg.Field = meta
+		} else {
+			addProtobufMetaSentinel(msg, meta)
+		}
+		// NB: This uses the kafka name escaper because it has to match the name
+		// of the kafka topic.
+		subject := SQLNameToKafkaName(topic) + confluentSubjectSuffixValue
+		var err error
+		if registered, err = e.register(ctx, msg, subject); err != nil {
+			return nil, err
+		}
+		e.resolvedCache[topic] = registered
+	}
+
+	m := dynamicpb.NewMessage(registered.msg)
+	meta := protoreflect.Message(m)
+	if fd := registered.msg.Fields().ByNumber(protobufMetaSentinelField); fd != nil {
+		meta = m.Mutable(fd).Message()
+	}
+	meta.Set(meta.Descriptor().Fields().ByNumber(protobufResolvedField),
+		protoreflect.ValueOfString(eval.TimestampToDecimalDatum(resolved).Decimal.String()))
+	return e.marshal(registered, m)
+}
+
+// keyColumns returns the columns making up the key of the row.
+func (e *protobufEncoder) keyColumns(row cdcevent.Row) (cdcevent.Iterator, error) {
+	if e.customKeyColumn != "" {
+		return row.DatumNamed(e.customKeyColumn)
+	}
+	return row.ForEachKeyColumn(), nil
+}
+
+// wrappedEnvelopeMessage returns the descriptor of the wrapped envelope
+// message of the given row. The message nests a message describing the
+// columns of the row, which the after (and before) fields are made of.
+func (e *protobufEncoder) wrappedEnvelopeMessage(
+	name string, updatedRow cdcevent.Row, prevRow cdcevent.Row,
+) (*descriptorpb.DescriptorProto, error) {
+	envelope := &descriptorpb.DescriptorProto{Name: proto.String(name)}
+	after, err := protobufRecordMessage(`After`, updatedRow.ForEachColumn())
+	if err != nil {
+		return nil, err
+	}
+	addProtobufNestedField(envelope, after, `after`, protobufAfterField)
+	if e.beforeField {
+		beforeRow := updatedRow
+		if prevRow.IsInitialized() {
+			beforeRow = prevRow
+		}
+		before, err := protobufRecordMessage(`Before`, beforeRow.ForEachColumn())
+		if err != nil {
+			return nil, err
+		}
+		addProtobufNestedField(envelope, before, `before`, protobufBeforeField)
+	}
+	if e.updatedField {
+		envelope.Field = append(envelope.Field, protobufScalarField(
+			`updated`, protobufUpdatedField, descriptorpb.FieldDescriptorProto_TYPE_STRING))
+	}
+	if e.mvccTimestampField {
+		envelope.Field = append(envelope.Field, protobufScalarField(
+			`mvcc_timestamp`, protobufMVCCTimestampField, descriptorpb.FieldDescriptorProto_TYPE_STRING))
+	}
+	if e.keyInValue {
+		keyColumns, err := e.keyColumns(updatedRow)
+		if err != nil {
+			return nil, err
+		}
+		key, err := protobufRecordMessage(`Key`, keyColumns)
+		if err != nil {
+			return nil, err
+		}
+		addProtobufNestedField(envelope, key, `key`, protobufKeyField)
+	}
+	if e.topicInValue {
+		envelope.Field = append(envelope.Field, protobufScalarField(
+			`topic`, protobufTopicField, descriptorpb.FieldDescriptorProto_TYPE_STRING))
+	}
+	return envelope, nil
+}
+
+// bareEnvelopeMessage returns the descriptor of the bare envelope message of
+// the given row, which has the columns of the row as its fields. Metadata, if
+// any, goes in a nested message under the metaSentinel field.
+func (e *protobufEncoder) bareEnvelopeMessage(
+	name string, updatedRow cdcevent.Row,
+) (*descriptorpb.DescriptorProto, error) {
+	envelope, err := protobufRecordMessage(name, updatedRow.ForEachColumn())
+	if err != nil {
+		return nil, err
+	}
+	var meta []*descriptorpb.FieldDescriptorProto
+	if e.updatedField {
+		meta = append(meta, protobufScalarField(
+			`updated`, protobufUpdatedField, descriptorpb.FieldDescriptorProto_TYPE_STRING))
+	}
+	if e.mvccTimestampField {
+		meta = append(meta, protobufScalarField(
+			`mvcc_timestamp`, protobufMVCCTimestampField, descriptorpb.FieldDescriptorProto_TYPE_STRING))
+	}
+	if len(meta) > 0 {
+		addProtobufMetaSentinel(envelope, meta)
+	}
+	return envelope, nil
+}
+
+// register builds the descriptor of the given top-level message and, if a
+// schema registry is configured, registers it under the given subject.
+func (e *protobufEncoder) register(
+	ctx context.Context, msg *descriptorpb.DescriptorProto, subject string,
+) (protobufRegisteredSchema, error) {
+	file := &descriptorpb.FileDescriptorProto{
+		Name:        proto.String(msg.GetName() + `.proto`),
+		Syntax:      proto.String(`proto2`),
+		MessageType: []*descriptorpb.DescriptorProto{msg},
+	}
+	fd, err := protodesc.NewFile(file, new(protoregistry.Files))
+	if err != nil {
+		return protobufRegisteredSchema{}, errors.NewAssertionErrorWithWrappedErrf(err,
+			"invalid protobuf message descriptor for %s", msg.GetName())
+	}
+	registered := protobufRegisteredSchema{msg: fd.Messages().Get(0)}
+	if e.schemaRegistry != nil {
+		registered.registryID, err = e.schemaRegistry.RegisterTypedSchemaForSubject(
+			ctx, subject, confluentSchemaTypeProtobuf, protobufSchemaText(file))
+		if err != nil {
+			return protobufRegisteredSchema{}, err
+		}
+	}
+	return registered, nil
+}
+
+// marshal serializes the message. If the schema of the message is registered,
+// the message is prefixed with the header of Confluent's wire format.
+//
+//	https://docs.confluent.io/platform/current/schema-registry/fundamentals/serdes-develop/index.html#wire-format
+func (e *protobufEncoder) marshal(
+	registered protobufRegisteredSchema, m protoreflect.ProtoMessage,
+) ([]byte, error) {
+	var buf []byte
+	if e.schemaRegistry != nil {
+		buf = []byte{
+			changefeedbase.ConfluentAvroWireFormatMagic,
+			0, 0, 0, 0, // Placeholder for the ID.
+			// The message indexes of the message in its schema. The message is
+			// always the first one, which is encoded as a single 0.
+			0,
+		}
+		binary.BigEndian.PutUint32(buf[1:5], uint32(registered.registryID))
+	}
+	return e.marshalOpts.MarshalAppend(buf, m)
+}
+
+// setColumns sets the fields of the message to the (non-NULL) datums of the
+// columns.
+func (e *protobufEncoder) setColumns(m protoreflect.Message, it cdcevent.Iterator) error {
+	fields := m.Descriptor().Fields()
+	return it.Datum(func(d tree.Datum, col cdcevent.ResultColumn) error {
+		if d == tree.DNull {
+			return nil
+		}
+		fd := fields.ByNumber(protowire.Number(col.PGAttributeNum))
+		if fd == nil {
+			return errors.AssertionFailedf("no protobuf field for column %s", col.Name)
+		}
+		v, err := e.datumToProtobufValue(fd.Kind(), d)
+		if err != nil {
+			return errors.Wrapf(err, "column %s", col.Name)
+		}
+		m.Set(fd, v)
+		return nil
+	})
+}
+
+// datumToProtobufValue converts the datum to a value of a field of the given
+// kind, as chosen by protobufFieldType.
+func (e *protobufEncoder) datumToProtobufValue(
+	kind protoreflect.Kind, d tree.Datum,
+) (protoreflect.Value, error) {
+	d = tree.UnwrapDOidWrapper(d)
+	switch kind {
+	case protoreflect.BoolKind:
+		if b, ok := d.(*tree.DBool); ok {
+			return protoreflect.ValueOfBool(bool(*b)), nil
+		}
+	case protoreflect.Int64Kind:
+		if i, ok := d.(*tree.DInt); ok {
+			return protoreflect.ValueOfInt64(int64(*i)), nil
+		}
+	case protoreflect.DoubleKind:
+		if f, ok := d.(*tree.DFloat); ok {
+			return protoreflect.ValueOfFloat64(float64(*f)), nil
+		}
+	case protoreflect.BytesKind:
+		if b, ok := d.(*tree.DBytes); ok {
+			return protoreflect.ValueOfBytes([]byte(*b)), nil
+		}
+	case protoreflect.StringKind:
+		switch d := d.(type) {
+		case *tree.DString:
+			return protoreflect.ValueOfString(string(*d)), nil
+		case *tree.DCollatedString:
+			return protoreflect.ValueOfString(d.Contents), nil
+		default:
+			e.formatter.Reset()
+			e.formatter.FormatNode(d)
+			return protoreflect.ValueOfString(e.formatter.String()), nil
+		}
+	}
+	return protoreflect.Value{}, errors.AssertionFailedf(
+		"cannot encode %T datum as protobuf %s", d, kind)
+}
+
+// protobufRecordMessage returns the descriptor of a message with a field for
+// each of the columns. The field numbers are the IDs of the columns.
+func protobufRecordMessage(
+	name string, it cdcevent.Iterator,
+) (*descriptorpb.DescriptorProto, error) {
+	msg := &descriptorpb.DescriptorProto{Name: proto.String(name)}
+	if err := it.Col(func(col cdcevent.ResultColumn) error {
+		number := protowire.Number(col.PGAttributeNum)
+		if !number.IsValid() || number == protobufMetaSentinelField {
+			return errors.Newf(
+				"column %s cannot be encoded as protobuf: its ID %d is not a valid field number",
+				col.Name, col.PGAttributeNum)
+		}
+		msg.Field = append(msg.Field, protobufScalarField(
+			SQLNameToAvroName(col.Name), number, protobufFieldType(col.Typ)))
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return msg, nil
+}
+
+// protobufFieldType returns the type of the field holding values of the given
+// SQL type.
+func protobufFieldType(typ *types.T) descriptorpb.FieldDescriptorProto_Type {
+	switch typ.Family() {
+	case types.BoolFamily:
+		return descriptorpb.FieldDescriptorProto_TYPE_BOOL
+	case types.IntFamily:
+		return descriptorpb.FieldDescriptorProto_TYPE_INT64
+	case types.FloatFamily:
+		return descriptorpb.FieldDescriptorProto_TYPE_DOUBLE
+	case types.BytesFamily:
+		return descriptorpb.FieldDescriptorProto_TYPE_BYTES
+	default:
+		return descriptorpb.FieldDescriptorProto_TYPE_STRING
+	}
+}
+
+func protobufScalarField(
+	name string, number protowire.Number, typ descriptorpb.FieldDescriptorProto_Type,
+) *descriptorpb.FieldDescriptorProto {
+	return &descriptorpb.FieldDescriptorProto{
+		Name:   proto.String(name),
+		Number: proto.Int32(int32(number)),
+		Label:  descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:   typ.Enum(),
+	}
+}
+
+// addProtobufNestedField nests the given message type in the top-level
+// message msg and adds a field of that type to msg.
+func addProtobufNestedField(
+	msg *descriptorpb.DescriptorProto,
+	nested *descriptorpb.DescriptorProto,
+	name string,
+	number protowire.Number,
+) {
+	msg.NestedType = append(msg.NestedType, nested)
+	msg.Field = append(msg.Field, &descriptorpb.FieldDescriptorProto{
+		Name:     proto.String(name),
+		Number:   proto.Int32(int32(number)),
+		Label:    descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL.Enum(),
+		Type:     descriptorpb.FieldDescriptorProto_TYPE_MESSAGE.Enum(),
+		TypeName: proto.String(fmt.Sprintf(".%s.%s", msg.GetName(), nested.GetName())),
+	})
+}
+
+// addProtobufMetaSentinel adds the given metadata fields to the top-level
+// message msg, nested in a message under the metaSentinel field.
+func addProtobufMetaSentinel(
+	msg *descriptorpb.DescriptorProto, meta []*descriptorpb.FieldDescriptorProto,
+) {
+	addProtobufNestedField(msg, &descriptorpb.DescriptorProto{
+		Name:  proto.String(`Metadata`),
+		Field: meta,
+	}, metaSentinel, protobufMetaSentinelField)
+}
+
+// protobufSchemaText renders the file descriptor in the protobuf language,
+// which is the format schema registries expect protobuf schemas in. Only the
+// subset of the descriptors generated by the protobuf encoder is supported.
+func protobufSchemaText(file *descriptorpb.FileDescriptorProto) string {
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "syntax = %q;\n", file.GetSyntax())
+	var writeMessage func(msg *descriptorpb.DescriptorProto, indent string)
+	writeMessage = func(msg *descriptorpb.DescriptorProto, indent string) {
+		fmt.Fprintf(&buf, "\n%smessage %s {\n", indent, msg.GetName())
+		for _, nested := range msg.NestedType {
+			writeMessage(nested, indent+"  ")
+		}
+		if len(msg.NestedType) > 0 && len(msg.Field) > 0 {
+			buf.WriteString("\n")
+		}
+		for _, f := range msg.Field {
+			typ := f.GetTypeName()
+			if f.GetType() != descriptorpb.FieldDescriptorProto_TYPE_MESSAGE {
+				typ = strings.ToLower(strings.TrimPrefix(f.GetType().String(), "TYPE_"))
+			}
+			fmt.Fprintf(&buf, "%s  optional %s %s = %d;\n", indent, typ, f.GetName(), f.GetNumber())
+		}
+		fmt.Fprintf(&buf, "%s}\n", indent)
+	}
+	for _, msg := range file.MessageType {
+		writeMessage(msg, "")
+	}
+	return buf.String()
+}
@@ -0,0 +1,237 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package changefeedccl
+
+import (
+	"context"
+	"encoding/binary"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdcevent"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdctest"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+// protobufToNative decodes the protobuf message into a map from field names
+// to values, with nested messages decoded as nested maps.
+func protobufToNative(
+	t *testing.T, desc protoreflect.MessageDescriptor, b []byte,
+) map[string]interface{} {
+	m := dynamicpb.NewMessage(desc)
+	require.NoError(t, proto.Unmarshal(b, m))
+	var toNative func(m protoreflect.Message) map[string]interface{}
+	toNative = func(m protoreflect.Message) map[string]interface{} {
+		native := make(map[string]interface{})
+		m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+			if fd.Kind() == protoreflect.MessageKind {
+				native[string(fd.Name())] = toNative(v.Message())
+			} else {
+				native[string(fd.Name())] = v.Interface()
+			}
+			return true
+		})
+		return native
+	}
+	return toNative(m)
+}
+
+func TestProtobufEncoder(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	tableDesc, err := parseTableDesc(
+		`CREATE TABLE foo (a INT PRIMARY KEY, b STRING, c DECIMAL, d BYTES, e BOOL)`)
+	require.NoError(t, err)
+	targets := mkTargets(tableDesc)
+
+	decimal, err := tree.ParseDDecimal(`1.50`)
+	require.NoError(t, err)
+	encRow := rowenc.EncDatumRow{
+		rowenc.EncDatum{Datum: tree.NewDInt(1)},
+		rowenc.EncDatum{Datum: tree.NewDString(`bar`)},
+		rowenc.EncDatum{Datum: decimal},
+		rowenc.EncDatum{Datum: tree.NewDBytes(`\x00`)},
+		rowenc.EncDatum{Datum: tree.DNull},
+	}
+	prevEncRow := rowenc.EncDatumRow{
+		rowenc.EncDatum{Datum: tree.NewDInt(1)},
+		rowenc.EncDatum{Datum: tree.DNull},
+		rowenc.EncDatum{Datum: tree.DNull},
+		rowenc.EncDatum{Datum: tree.DNull},
+		rowenc.EncDatum{Datum: tree.DBoolTrue},
+	}
+	row := cdcevent.TestingMakeEventRow(tableDesc, 0, encRow, false)
+	prevRow := cdcevent.TestingMakeEventRow(tableDesc, 0, prevEncRow, false)
+	deletedRow := cdcevent.TestingMakeEventRow(tableDesc, 0, encRow, true)
+	ts := hlc.Timestamp{WallTime: 1, Logical: 2}
+	evCtx := eventContext{updated: ts, mvcc: ts, topic: `foo`}
+
+	keyDesc := func(e *protobufEncoder) protoreflect.MessageDescriptor {
+		v, ok := e.keyCache.Get(tableIDAndVersion{tableID: row.TableID, version: row.Version})
+		require.True(t, ok)
+		return v.(protobufRegisteredSchema).msg
+	}
+	valueDesc := func(e *protobufEncoder, withPrev bool) protoreflect.MessageDescriptor {
+		var cacheKey tableIDAndVersionPair
+		if withPrev {
+			cacheKey[0] = tableIDAndVersion{tableID: prevRow.TableID, version: prevRow.Version}
+		}
+		cacheKey[1] = tableIDAndVersion{tableID: row.TableID, version: row.Version}
+		v, ok := e.valueCache.Get(cacheKey)
+		require.True(t, ok)
+		return v.(protobufRegisteredSchema).msg
+	}
+	record := map[string]interface{}{
+		`a`: int64(1), `b`: `bar`, `c`: `1.50`, `d`: []byte{0},
+	}
+
+	t.Run("wrapped", func(t *testing.T) {
+		opts := changefeedbase.EncodingOptions{
+			Format:            changefeedbase.OptFormatProtobuf,
+			Envelope:          changefeedbase.OptEnvelopeWrapped,
+			Diff:              true,
+			UpdatedTimestamps: true,
+			KeyInValue:        true,
+			TopicInValue:      true,
+		}
+		require.NoError(t, opts.Validate())
+		enc, err := getEncoder(ctx, opts, targets, false, nil, nil)
+		require.NoError(t, err)
+		e := enc.(*protobufEncoder)
+
+		key, err := e.EncodeKey(ctx, row)
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{`a`: int64(1)}, protobufToNative(t, keyDesc(e), key))
+
+		value, err := e.EncodeValue(ctx, evCtx, row, prevRow)
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{
+			`after`:   record,
+			`before`:  map[string]interface{}{`a`: int64(1), `e`: true},
+			`updated`: ts.AsOfSystemTime(),
+			`key`:     map[string]interface{}{`a`: int64(1)},
+			`topic`:   `foo`,
+		}, protobufToNative(t, valueDesc(e, true), value))
+
+		value, err = e.EncodeValue(ctx, evCtx, deletedRow, prevRow)
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{
+			`before`:  map[string]interface{}{`a`: int64(1), `e`: true},
+			`updated`: ts.AsOfSystemTime(),
+			`key`:     map[string]interface{}{`a`: int64(1)},
+			`topic`:   `foo`,
+		}, protobufToNative(t, valueDesc(e, true), value))
+	})
+
+	t.Run("bare", func(t *testing.T) {
+		opts := changefeedbase.EncodingOptions{
+			Format:         changefeedbase.OptFormatProtobuf,
+			Envelope:       changefeedbase.OptEnvelopeBare,
+			MVCCTimestamps: true,
+		}
+		require.NoError(t, opts.Validate())
+		enc, err := getEncoder(ctx, opts, targets, false, nil, nil)
+		require.NoError(t, err)
+		e := enc.(*protobufEncoder)
+
+		value, err := e.EncodeValue(ctx, evCtx, row, cdcevent.Row{})
+		require.NoError(t, err)
+		expected := map[string]interface{}{
+			metaSentinel: map[string]interface{}{`mvcc_timestamp`: ts.AsOfSystemTime()},
+		}
+		for k, v := range record {
+			expected[k] = v
+		}
+		require.Equal(t, expected, protobufToNative(t, valueDesc(e, false), value))
+
+		value, err = e.EncodeValue(ctx, evCtx, deletedRow, cdcevent.Row{})
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{
+			metaSentinel: map[string]interface{}{`mvcc_timestamp`: ts.AsOfSystemTime()},
+		}, protobufToNative(t, valueDesc(e, false), value))
+
+		resolved, err := e.EncodeResolvedTimestamp(ctx, `foo`, ts)
+		require.NoError(t, err)
+		require.Equal(t, map[string]interface{}{
+			metaSentinel: map[string]interface{}{`resolved`: `1.0000000002`},
+		}, protobufToNative(t, e.resolvedCache[`foo`].msg, resolved))
+	})
+
+	t.Run("schema registry", func(t *testing.T) {
+		reg := cdctest.StartTestSchemaRegistry()
+		defer reg.Close()
+		opts := changefeedbase.EncodingOptions{
+			Format:            changefeedbase.OptFormatProtobuf,
+			Envelope:          changefeedbase.OptEnvelopeWrapped,
+			SchemaRegistryURI: reg.URL(),
+		}
+		require.NoError(t, opts.Validate())
+		enc, err := getEncoder(ctx, opts, targets, false, nil, nil)
+		require.NoError(t, err)
+		e := enc.(*protobufEncoder)
+
+		key, err := e.EncodeKey(ctx, row)
+		require.NoError(t, err)
+		value, err := e.EncodeValue(ctx, evCtx, row, cdcevent.Row{})
+		require.NoError(t, err)
+
+		require.ElementsMatch(t, []string{`foo-key`, `foo-value`}, reg.Subjects())
+		require.Equal(t, `syntax = "proto2";
+
+message foo {
+  optional int64 a = 1;
+}
+`, reg.SchemaForSubject(`foo-key`))
+		require.Equal(t, `syntax = "proto2";
+
+message foo {
+
+  message After {
+    optional int64 a = 1;
+    optional string b = 2;
+    optional string c = 3;
+    optional bytes d = 4;
+    optional bool e = 5;
+  }
+
+  optional .foo.After after = 1;
+}
+`, reg.SchemaForSubject(`foo-value`))
+
+		for _, c := range []struct {
+			encoded []byte
+			desc    protoreflect.MessageDescriptor
+		}{
+			{encoded: key, desc: keyDesc(e)},
+			{encoded: value, desc: valueDesc(e, false)},
+		} {
+			require.Equal(t, changefeedbase.ConfluentAvroWireFormatMagic, c.encoded[0])
+			require.Less(t, int(binary.BigEndian.Uint32(c.encoded[1:5])), reg.RegistrationCount())
+			// The message index of the first message of the schema.
+			require.Equal(t, byte(0), c.encoded[5])
+			require.NotEmpty(t, protobufToNative(t, c.desc, c.encoded[6:]))
+		}
+	})
+
+	t.Run("row envelope", func(t *testing.T) {
+		opts := changefeedbase.EncodingOptions{
+			Format:   changefeedbase.OptFormatProtobuf,
+			Envelope: changefeedbase.OptEnvelopeRow,
+		}
+		require.EqualError(t, opts.Validate(),
+			`envelope=row is not supported with format=protobuf`)
+	})
+}
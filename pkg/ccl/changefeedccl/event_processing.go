@@ -30,6 +30,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/log/logcrash"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/cockroachdb/errors"
 )
 
@@ -38,6 +39,11 @@ type eventContext struct {
 	updated, mvcc hlc.Timestamp
 	// topic is set to the string to be included if TopicInValue is true
 	topic string
+	// backfill is set if the event was produced by an initial scan or a
+	// schema change backfill rather than by a change to the row.
+	backfill bool
+	// txnID is the ID of the transaction which wrote the row, if known.
+	txnID uuid.UUID
 }
 
 type eventConsumer interface {
@@ -63,6 +69,10 @@ type kvEventToRowConsumer struct {
 	topicDescriptorCache map[TopicIdentifier]TopicDescriptor
 	topicNamer           *TopicNamer
 
+	// txnSink, if non-nil, is the sink which rows are emitted to along with
+	// the identifier of their transaction for transaction markers.
+	txnSink txnRowSink
+
 	metrics *sliMetrics
 	sv      *settings.Values
 
@@ -238,6 +248,14 @@ func newKVEventToRowConsumer(
 		return nil, err
 	}
 
+	var txnSink txnRowSink
+	if encodingOpts.TransactionMarkers {
+		var ok bool
+		if txnSink, ok = sink.(txnRowSink); !ok {
+			return nil, errors.AssertionFailedf(`%T does not support transaction markers`, sink)
+		}
+	}
+
 	return &kvEventToRowConsumer{
 		frontier:             frontier,
 		encoder:              encoder,
@@ -248,6 +266,7 @@ func newKVEventToRowConsumer(
 		knobs:                knobs,
 		topicDescriptorCache: make(map[TopicIdentifier]TopicDescriptor),
 		topicNamer:           topicNamer,
+		txnSink:              txnSink,
 		evaluator:            evaluator,
 		encodingOpts:         encodingOpts,
 		metrics:              metrics,
@@ -373,7 +392,10 @@ func (c *kvEventToRowConsumer) ConsumeEvent(ctx context.Context, ev kvevent.Even
 		}
 	}
 
-	return c.encodeAndEmit(ctx, updatedRow, prevRow, schemaTimestamp, ev.DetachAlloc())
+	return c.encodeAndEmit(
+		ctx, updatedRow, prevRow, schemaTimestamp, !ev.BackfillTimestamp().IsEmpty(), ev.TxnID(),
+		ev.DetachAlloc(),
+	)
 }
 
 func (c *kvEventToRowConsumer) encodeAndEmit(
@@ -381,6 +403,8 @@ func (c *kvEventToRowConsumer) encodeAndEmit(
 	updatedRow cdcevent.Row,
 	prevRow cdcevent.Row,
 	schemaTS hlc.Timestamp,
+	backfill bool,
+	txnID uuid.UUID,
 	alloc kvevent.Alloc,
 ) error {
 	topic, err := c.topicForEvent(updatedRow.Metadata)
@@ -400,8 +424,10 @@ func (c *kvEventToRowConsumer) encodeAndEmit(
 	}
 
 	evCtx := eventContext{
-		updated: schemaTS,
-		mvcc:    updatedRow.MvccTimestamp,
+		updated:  schemaTS,
+		mvcc:     updatedRow.MvccTimestamp,
+		backfill: backfill,
+		txnID:    txnID,
 	}
 
 	if c.topicNamer != nil {
@@ -445,6 +471,13 @@ func (c *kvEventToRowConsumer) encodeAndEmit(
 	stop()
 
 	c.metrics.Timers.EmitRow.Time(func() {
+		if c.txnSink != nil {
+			txnID, _ := txnMarkerID(evCtx)
+			err = c.txnSink.EmitTxnRow(
+				ctx, topic, keyCopy, valueCopy, schemaTS, updatedRow.MvccTimestamp, txnID, alloc,
+			)
+			return
+		}
 		err = c.sink.EmitRow(
 			ctx, topic, keyCopy, valueCopy, schemaTS, updatedRow.MvccTimestamp, alloc,
 		)
@@ -459,6 +492,24 @@ func (c *kvEventToRowConsumer) encodeAndEmit(
 	if log.V(3) {
 		log.Infof(ctx, `r %s: %s -> %s`, updatedRow.TableName, keyCopy, valueCopy)
 	}
+
+	if c.encodingOpts.Envelope == changefeedbase.OptEnvelopeDebezium && updatedRow.IsDeleted() {
+		// Debezium follows the deletion of a row with a tombstone, a message
+		// with the key of the row and no value, which lets log compaction
+		// drop the key entirely.
+		c.metrics.Timers.EmitRow.Time(func() {
+			err = c.sink.EmitRow(
+				ctx, topic, keyCopy, nil /* value */, schemaTS, updatedRow.MvccTimestamp, kvevent.Alloc{},
+			)
+		})
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				log.Warningf(ctx, `sink failed to emit tombstone: %v`, err)
+				c.metrics.SinkErrors.Inc(1)
+			}
+			return err
+		}
+	}
 	return nil
 }
 
@@ -0,0 +1,113 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package iceberg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"path"
+
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/util/ioctx"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/errors"
+)
+
+// Identifier identifies a table in a catalog.
+type Identifier struct {
+	Namespace string
+	Name      string
+}
+
+// String implements the fmt.Stringer interface.
+func (id Identifier) String() string {
+	return id.Namespace + `.` + id.Name
+}
+
+// ErrCommitConflict is returned when a table was concurrently updated.
+var ErrCommitConflict = errors.New(`table was concurrently updated`)
+
+// Catalog tracks the current metadata file of tables. Committing a new
+// metadata file to the catalog is what makes a snapshot visible to readers.
+type Catalog interface {
+	// CurrentMetadata returns the location of the current metadata file of the
+	// table, or the empty string if the table doesn't exist.
+	CurrentMetadata(ctx context.Context, id Identifier) (string, error)
+
+	// SwapMetadata atomically replaces the current metadata file of the table
+	// with next, creating the table if base is empty. ErrCommitConflict is
+	// returned if the current metadata file isn't base.
+	SwapMetadata(ctx context.Context, id Identifier, base, next string) error
+}
+
+// storageCatalog is a catalog storing a pointer to the current metadata file
+// of every table in a file of an external storage. It's meant for testing,
+// typically with nodelocal storage, and only guarantees atomic commits within a
+// process.
+type storageCatalog struct {
+	storage cloud.ExternalStorage
+}
+
+var _ Catalog = (*storageCatalog)(nil)
+
+// storageCatalogMu serializes commits to storage catalogs, which may be shared
+// by several changefeeds.
+var storageCatalogMu syncutil.Mutex
+
+// NewFilesystemCatalog returns a catalog which stores table pointers in the
+// given storage, in a file per table.
+func NewFilesystemCatalog(storage cloud.ExternalStorage) Catalog {
+	return &storageCatalog{storage: storage}
+}
+
+type tablePointer struct {
+	MetadataLocation string `json:"metadata-location"`
+}
+
+func pointerPath(id Identifier) string {
+	return path.Join(id.Namespace, id.Name+`.json`)
+}
+
+// CurrentMetadata implements the Catalog interface.
+func (c *storageCatalog) CurrentMetadata(ctx context.Context, id Identifier) (string, error) {
+	r, _, err := c.storage.ReadFile(ctx, pointerPath(id), cloud.ReadOptions{NoFileSize: true})
+	if errors.Is(err, cloud.ErrFileDoesNotExist) {
+		return ``, nil
+	}
+	if err != nil {
+		return ``, err
+	}
+	defer r.Close(ctx)
+	b, err := ioctx.ReadAll(ctx, r)
+	if err != nil {
+		return ``, err
+	}
+	var p tablePointer
+	if err := json.Unmarshal(b, &p); err != nil {
+		return ``, errors.Wrapf(err, `parsing pointer of table %s`, id)
+	}
+	return p.MetadataLocation, nil
+}
+
+// SwapMetadata implements the Catalog interface.
+func (c *storageCatalog) SwapMetadata(ctx context.Context, id Identifier, base, next string) error {
+	storageCatalogMu.Lock()
+	defer storageCatalogMu.Unlock()
+
+	current, err := c.CurrentMetadata(ctx, id)
+	if err != nil {
+		return err
+	}
+	if current != base {
+		return errors.Wrapf(ErrCommitConflict, `table %s`, id)
+	}
+	b, err := json.Marshal(tablePointer{MetadataLocation: next})
+	if err != nil {
+		return err
+	}
+	return cloud.WriteFile(ctx, c.storage, pointerPath(id), bytes.NewReader(b))
+}
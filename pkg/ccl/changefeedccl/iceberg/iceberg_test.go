@@ -0,0 +1,148 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package iceberg
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/cloud/cloudpb"
+	"github.com/cockroachdb/cockroach/pkg/cloud/nodelocal"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+	"github.com/linkedin/goavro/v2"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTableCommit(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	settings := cluster.MakeTestingClusterSettings()
+	storage := nodelocal.TestingMakeNodelocalStorage(t.TempDir(), settings, cloudpb.ExternalStorage{})
+	catalog := NewFilesystemCatalog(
+		nodelocal.TestingMakeNodelocalStorage(t.TempDir(), settings, cloudpb.ExternalStorage{}))
+	id := Identifier{Namespace: `db`, Name: `foo`}
+	const location = `nodelocal://1/foo`
+
+	tbl, err := LoadTable(ctx, catalog, id, storage, location)
+	require.NoError(t, err)
+	require.Nil(t, tbl.Metadata().CurrentSnapshot())
+
+	cols := []Column{{Name: `a`, Type: TypeLong, Key: true}, {Name: `b`, Type: TypeString}}
+	deletes := func(path string, n int64) DataFile {
+		return DataFile{
+			Content: ContentEqualityDeletes, Path: path, RecordCount: n, FileSizeInBytes: 10,
+			EqualityColumns: []string{`a`},
+		}
+	}
+	require.NoError(t, tbl.Commit(ctx, []Change{
+		{Columns: cols, Files: []DataFile{{Path: `data/1.parquet`, RecordCount: 3, FileSizeInBytes: 10}}},
+		{Columns: cols, Files: []DataFile{
+			{Path: `data/2.parquet`, RecordCount: 1, FileSizeInBytes: 10},
+			deletes(`data/2-deletes.parquet`, 2),
+		}},
+	}, map[string]string{`crdb.resolved`: `10.0000000000`}))
+
+	m := tbl.Metadata()
+	require.Len(t, m.Snapshots, 2)
+	require.Equal(t, int64(2), m.LastSequenceNumber)
+	s := m.CurrentSnapshot()
+	require.Equal(t, int64(2), s.SequenceNumber)
+	require.Equal(t, m.Snapshots[0].SnapshotID, *s.ParentSnapshotID)
+	require.Equal(t, `overwrite`, s.Summary[`operation`])
+	require.Equal(t, `10.0000000000`, s.Summary[`crdb.resolved`])
+	require.Equal(t, `append`, m.Snapshots[0].Summary[`operation`])
+	require.NotContains(t, m.Snapshots[0].Summary, `crdb.resolved`)
+	require.Equal(t, []Schema{{
+		Type:               `struct`,
+		IdentifierFieldIDs: []int{1},
+		Fields: []Field{
+			{ID: 1, Name: `a`, Required: true, Type: TypeLong},
+			{ID: 2, Name: `b`, Type: TypeString},
+		},
+	}}, m.Schemas)
+
+	// The manifest list of the last snapshot tracks the files of both snapshots.
+	list, err := tbl.readFile(ctx, s.ManifestList)
+	require.NoError(t, err)
+	manifests, err := decodeManifestList(list)
+	require.NoError(t, err)
+	require.Len(t, manifests, 3)
+	var seqs, contents []int64
+	for _, mf := range manifests {
+		seqs = append(seqs, mf[`sequence_number`].(int64))
+		contents = append(contents, int64(mf[`content`].(int32)))
+	}
+	require.Equal(t, []int64{1, 2, 2}, seqs)
+	require.Equal(t, []int64{0, 0, 1}, contents)
+
+	// Delete files reference their equality columns by field ID.
+	manifest, err := tbl.readFile(ctx, manifests[2][`manifest_path`].(string))
+	require.NoError(t, err)
+	r, err := goavro.NewOCFReader(bytes.NewReader(manifest))
+	require.NoError(t, err)
+	require.Equal(t, `deletes`, string(r.MetaData()[`content`]))
+	require.True(t, r.Scan())
+	entry, err := r.Read()
+	require.NoError(t, err)
+	dataFile := entry.(map[string]interface{})[`data_file`].(map[string]interface{})
+	require.Equal(t, location+`/data/2-deletes.parquet`, dataFile[`file_path`])
+	require.Equal(t, map[string]interface{}{`array`: []interface{}{int32(1)}}, dataFile[`equality_ids`])
+
+	// Tables are loaded from the catalog, and columns keep their field IDs when
+	// the schema changes.
+	tbl, err = LoadTable(ctx, catalog, id, storage, location)
+	require.NoError(t, err)
+	require.Equal(t, s.SnapshotID, tbl.Metadata().CurrentSnapshot().SnapshotID)
+	cols = []Column{{Name: `a`, Type: TypeLong, Key: true}, {Name: `c`, Type: TypeInt}}
+	require.NoError(t, tbl.Commit(ctx, []Change{
+		{Columns: cols, Files: []DataFile{{Path: `data/3.parquet`, RecordCount: 1, FileSizeInBytes: 10}}},
+	}, nil))
+	m = tbl.Metadata()
+	require.Equal(t, 1, m.CurrentSchemaID)
+	require.Equal(t, []Field{
+		{ID: 1, Name: `a`, Required: true, Type: TypeLong},
+		{ID: 3, Name: `c`, Type: TypeInt},
+	}, m.schema(1).Fields)
+	require.Len(t, m.MetadataLog, 1)
+	require.Equal(t,
+		`[{"field-id":1,"names":["a"]},{"field-id":2,"names":["b"]},{"field-id":3,"names":["c"]}]`,
+		m.Properties[nameMappingProperty])
+}
+
+func TestTableCommitConflict(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	settings := cluster.MakeTestingClusterSettings()
+	storage := nodelocal.TestingMakeNodelocalStorage(t.TempDir(), settings, cloudpb.ExternalStorage{})
+	catalog := NewFilesystemCatalog(
+		nodelocal.TestingMakeNodelocalStorage(t.TempDir(), settings, cloudpb.ExternalStorage{}))
+	id := Identifier{Namespace: `db`, Name: `foo`}
+	const location = `nodelocal://1/foo`
+
+	t1, err := LoadTable(ctx, catalog, id, storage, location)
+	require.NoError(t, err)
+	t2, err := LoadTable(ctx, catalog, id, storage, location)
+	require.NoError(t, err)
+
+	change := []Change{{
+		Columns: []Column{{Name: `a`, Type: TypeLong, Key: true}},
+		Files:   []DataFile{{Path: `data/1.parquet`, RecordCount: 1, FileSizeInBytes: 10}},
+	}}
+	require.NoError(t, t1.Commit(ctx, change, nil))
+	err = t2.Commit(ctx, change, nil)
+	require.True(t, errors.Is(err, ErrCommitConflict), `%+v`, err)
+	// The table was refreshed, so the commit can be retried.
+	require.NoError(t, t2.Commit(ctx, change, nil))
+	require.Len(t, t2.Metadata().Snapshots, 2)
+}
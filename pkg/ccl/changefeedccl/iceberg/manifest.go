@@ -0,0 +1,264 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package iceberg
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+
+	"github.com/cockroachdb/errors"
+	"github.com/linkedin/goavro/v2"
+)
+
+// FileContent is the type of content stored by a file.
+type FileContent int
+
+const (
+	// ContentData is the content of data files.
+	ContentData FileContent = 0
+	// ContentEqualityDeletes is the content of files deleting the rows which
+	// have the same values for the equality columns as one of their rows.
+	ContentEqualityDeletes FileContent = 2
+)
+
+// manifestContent is the type of files tracked by a manifest.
+type manifestContent int
+
+const (
+	manifestContentData    manifestContent = 0
+	manifestContentDeletes manifestContent = 1
+)
+
+func (c manifestContent) String() string {
+	if c == manifestContentDeletes {
+		return `deletes`
+	}
+	return `data`
+}
+
+// DataFile is a Parquet file added to a table.
+type DataFile struct {
+	Content FileContent
+	// Path is the location of the file, relative to the location of the table.
+	Path            string
+	RecordCount     int64
+	FileSizeInBytes int64
+	// EqualityColumns are the names of the equality columns of equality delete
+	// files.
+	EqualityColumns []string
+}
+
+// manifestEntryStatus is the status of an entry of a manifest.
+const manifestEntryStatusAdded = 1
+
+// manifestEntrySchema is the Avro schema of the entries of manifests. Fields
+// are identified by the field IDs assigned by the spec, and optional fields
+// which are only used for planning are omitted. Tables are not partitioned, so
+// partition tuples are empty.
+const manifestEntrySchema = `{
+  "type": "record",
+  "name": "manifest_entry",
+  "fields": [
+    {"name": "status", "type": "int", "field-id": 0},
+    {"name": "snapshot_id", "type": ["null", "long"], "default": null, "field-id": 1},
+    {"name": "sequence_number", "type": ["null", "long"], "default": null, "field-id": 3},
+    {"name": "file_sequence_number", "type": ["null", "long"], "default": null, "field-id": 4},
+    {"name": "data_file", "field-id": 2, "type": {
+      "type": "record",
+      "name": "r2",
+      "fields": [
+        {"name": "content", "type": "int", "field-id": 134},
+        {"name": "file_path", "type": "string", "field-id": 100},
+        {"name": "file_format", "type": "string", "field-id": 101},
+        {"name": "partition", "field-id": 102, "type": {"type": "record", "name": "r102", "fields": []}},
+        {"name": "record_count", "type": "long", "field-id": 103},
+        {"name": "file_size_in_bytes", "type": "long", "field-id": 104},
+        {"name": "equality_ids", "default": null, "field-id": 135, "type": ["null",
+          {"type": "array", "items": "int", "element-id": 136}]}
+      ]
+    }}
+  ]
+}`
+
+// manifestFileSchema is the Avro schema of the entries of manifest lists.
+const manifestFileSchema = `{
+  "type": "record",
+  "name": "manifest_file",
+  "fields": [
+    {"name": "manifest_path", "type": "string", "field-id": 500},
+    {"name": "manifest_length", "type": "long", "field-id": 501},
+    {"name": "partition_spec_id", "type": "int", "field-id": 502},
+    {"name": "content", "type": "int", "field-id": 517},
+    {"name": "sequence_number", "type": "long", "field-id": 515},
+    {"name": "min_sequence_number", "type": "long", "field-id": 516},
+    {"name": "added_snapshot_id", "type": "long", "field-id": 503},
+    {"name": "added_files_count", "type": "int", "field-id": 504},
+    {"name": "existing_files_count", "type": "int", "field-id": 505},
+    {"name": "deleted_files_count", "type": "int", "field-id": 506},
+    {"name": "added_rows_count", "type": "long", "field-id": 512},
+    {"name": "existing_rows_count", "type": "long", "field-id": 513},
+    {"name": "deleted_rows_count", "type": "long", "field-id": 514}
+  ]
+}`
+
+var manifestEntryCodec, manifestFileCodec = func() (*goavro.Codec, *goavro.Codec) {
+	entry, err := goavro.NewCodec(manifestEntrySchema)
+	if err != nil {
+		panic(err)
+	}
+	file, err := goavro.NewCodec(manifestFileSchema)
+	if err != nil {
+		panic(err)
+	}
+	return entry, file
+}()
+
+// manifestFile is an entry of a manifest list.
+type manifestFile map[string]interface{}
+
+// encodeManifest encodes a manifest of files added by a snapshot, and returns
+// it along with its entry in the manifest list. The location of the manifest
+// is set by the caller.
+func encodeManifest(
+	schema *Schema,
+	content manifestContent,
+	snapshotID, sequenceNumber int64,
+	location string,
+	files []DataFile,
+) ([]byte, manifestFile, error) {
+	schemaJSON, err := json.Marshal(schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	var buf bytes.Buffer
+	w, err := goavro.NewOCFWriter(goavro.OCFConfig{
+		W:     &buf,
+		Codec: manifestEntryCodec,
+		MetaData: map[string][]byte{
+			`schema`:            schemaJSON,
+			`schema-id`:         []byte(strconv.Itoa(schema.SchemaID)),
+			`partition-spec`:    []byte(`[]`),
+			`partition-spec-id`: []byte(`0`),
+			`format-version`:    []byte(strconv.Itoa(formatVersion)),
+			`content`:           []byte(content.String()),
+		},
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fieldIDs := make(map[string]int, len(schema.Fields))
+	for _, f := range schema.Fields {
+		fieldIDs[f.Name] = f.ID
+	}
+	entries := make([]interface{}, 0, len(files))
+	var rows int64
+	for _, f := range files {
+		var equalityIDs interface{}
+		if f.Content == ContentEqualityDeletes {
+			ids := make([]interface{}, 0, len(f.EqualityColumns))
+			for _, c := range f.EqualityColumns {
+				id, ok := fieldIDs[c]
+				if !ok {
+					return nil, nil, errors.AssertionFailedf(`unknown equality column %q`, c)
+				}
+				ids = append(ids, int32(id))
+			}
+			equalityIDs = goavro.Union(`array`, ids)
+		}
+		entries = append(entries, map[string]interface{}{
+			`status`:               int32(manifestEntryStatusAdded),
+			`snapshot_id`:          goavro.Union(`long`, snapshotID),
+			`sequence_number`:      goavro.Union(`long`, sequenceNumber),
+			`file_sequence_number`: goavro.Union(`long`, sequenceNumber),
+			`data_file`: map[string]interface{}{
+				`content`:            int32(f.Content),
+				`file_path`:          location + `/` + f.Path,
+				`file_format`:        `PARQUET`,
+				`partition`:          map[string]interface{}{},
+				`record_count`:       f.RecordCount,
+				`file_size_in_bytes`: f.FileSizeInBytes,
+				`equality_ids`:       equalityIDs,
+			},
+		})
+		rows += f.RecordCount
+	}
+	if err := w.Append(entries); err != nil {
+		return nil, nil, err
+	}
+
+	return buf.Bytes(), manifestFile{
+		`manifest_length`:      int64(buf.Len()),
+		`partition_spec_id`:    int32(0),
+		`content`:              int32(content),
+		`sequence_number`:      sequenceNumber,
+		`min_sequence_number`:  sequenceNumber,
+		`added_snapshot_id`:    snapshotID,
+		`added_files_count`:    int32(len(files)),
+		`existing_files_count`: int32(0),
+		`deleted_files_count`:  int32(0),
+		`added_rows_count`:     rows,
+		`existing_rows_count`:  int64(0),
+		`deleted_rows_count`:   int64(0),
+	}, nil
+}
+
+// encodeManifestList encodes a manifest list.
+func encodeManifestList(
+	snapshotID int64, parentID *int64, sequenceNumber int64, manifests []manifestFile,
+) ([]byte, error) {
+	parent := `null`
+	if parentID != nil {
+		parent = strconv.FormatInt(*parentID, 10)
+	}
+	var buf bytes.Buffer
+	w, err := goavro.NewOCFWriter(goavro.OCFConfig{
+		W:     &buf,
+		Codec: manifestFileCodec,
+		MetaData: map[string][]byte{
+			`snapshot-id`:        []byte(strconv.FormatInt(snapshotID, 10)),
+			`parent-snapshot-id`: []byte(parent),
+			`sequence-number`:    []byte(strconv.FormatInt(sequenceNumber, 10)),
+			`format-version`:     []byte(strconv.Itoa(formatVersion)),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]interface{}, len(manifests))
+	for i, m := range manifests {
+		entries[i] = map[string]interface{}(m)
+	}
+	if err := w.Append(entries); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decodeManifestList decodes a manifest list.
+func decodeManifestList(data []byte) ([]manifestFile, error) {
+	r, err := goavro.NewOCFReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	var manifests []manifestFile
+	for r.Scan() {
+		datum, err := r.Read()
+		if err != nil {
+			return nil, err
+		}
+		m, ok := datum.(map[string]interface{})
+		if !ok {
+			return nil, errors.Newf(`unexpected manifest list entry %T`, datum)
+		}
+		manifests = append(manifests, m)
+	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	return manifests, nil
+}
@@ -0,0 +1,286 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+// Package iceberg implements the subset of the Apache Iceberg table format
+// (version 2) needed by changefeeds to write tables: table metadata, manifests
+// and manifest lists, and a catalog.
+//
+//	https://iceberg.apache.org/spec/
+package iceberg
+
+import (
+	"encoding/json"
+	"math/rand"
+
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+)
+
+const formatVersion = 2
+
+// Iceberg primitive types.
+const (
+	TypeBoolean = `boolean`
+	TypeInt     = `int`
+	TypeLong    = `long`
+	TypeFloat   = `float`
+	TypeDouble  = `double`
+	TypeString  = `string`
+	TypeBinary  = `binary`
+	TypeUUID    = `uuid`
+	TypeTime    = `time`
+)
+
+// Column describes a column of the files written to a table.
+type Column struct {
+	Name string
+	// Type is the Iceberg type of the column.
+	Type string
+	// Key is set for the columns of the primary key, which identify rows.
+	Key bool
+}
+
+// Field is a field of a schema.
+type Field struct {
+	ID       int    `json:"id"`
+	Name     string `json:"name"`
+	Required bool   `json:"required"`
+	Type     string `json:"type"`
+}
+
+// Schema is a table schema.
+type Schema struct {
+	Type               string  `json:"type"`
+	SchemaID           int     `json:"schema-id"`
+	IdentifierFieldIDs []int   `json:"identifier-field-ids,omitempty"`
+	Fields             []Field `json:"fields"`
+}
+
+// Snapshot is the state of a table at some point in time.
+type Snapshot struct {
+	SnapshotID       int64             `json:"snapshot-id"`
+	ParentSnapshotID *int64            `json:"parent-snapshot-id,omitempty"`
+	SequenceNumber   int64             `json:"sequence-number"`
+	TimestampMs      int64             `json:"timestamp-ms"`
+	ManifestList     string            `json:"manifest-list"`
+	Summary          map[string]string `json:"summary"`
+	SchemaID         int               `json:"schema-id"`
+}
+
+type snapshotLogEntry struct {
+	SnapshotID  int64 `json:"snapshot-id"`
+	TimestampMs int64 `json:"timestamp-ms"`
+}
+
+type metadataLogEntry struct {
+	MetadataFile string `json:"metadata-file"`
+	TimestampMs  int64  `json:"timestamp-ms"`
+}
+
+type partitionSpec struct {
+	SpecID int           `json:"spec-id"`
+	Fields []interface{} `json:"fields"`
+}
+
+type sortOrder struct {
+	OrderID int           `json:"order-id"`
+	Fields  []interface{} `json:"fields"`
+}
+
+type snapshotRef struct {
+	SnapshotID int64  `json:"snapshot-id"`
+	Type       string `json:"type"`
+}
+
+type nameMapping struct {
+	FieldID int      `json:"field-id"`
+	Names   []string `json:"names"`
+}
+
+// nameMappingProperty is the table property holding the mapping from column
+// names to field IDs, which readers use to resolve the columns of data files
+// whose schema doesn't carry field IDs.
+const nameMappingProperty = `schema.name-mapping.default`
+
+// TableMetadata is the metadata of a table, which is stored as JSON in a
+// metadata file.
+type TableMetadata struct {
+	FormatVersion      int                    `json:"format-version"`
+	TableUUID          string                 `json:"table-uuid"`
+	Location           string                 `json:"location"`
+	LastSequenceNumber int64                  `json:"last-sequence-number"`
+	LastUpdatedMs      int64                  `json:"last-updated-ms"`
+	LastColumnID       int                    `json:"last-column-id"`
+	CurrentSchemaID    int                    `json:"current-schema-id"`
+	Schemas            []Schema               `json:"schemas"`
+	DefaultSpecID      int                    `json:"default-spec-id"`
+	PartitionSpecs     []partitionSpec        `json:"partition-specs"`
+	LastPartitionID    int                    `json:"last-partition-id"`
+	DefaultSortOrderID int                    `json:"default-sort-order-id"`
+	SortOrders         []sortOrder            `json:"sort-orders"`
+	Properties         map[string]string      `json:"properties"`
+	CurrentSnapshotID  *int64                 `json:"current-snapshot-id,omitempty"`
+	Snapshots          []Snapshot             `json:"snapshots"`
+	SnapshotLog        []snapshotLogEntry     `json:"snapshot-log"`
+	MetadataLog        []metadataLogEntry     `json:"metadata-log"`
+	Refs               map[string]snapshotRef `json:"refs"`
+}
+
+// newTableMetadata returns the metadata of a new table without any schema or
+// snapshot.
+func newTableMetadata(location string) *TableMetadata {
+	return &TableMetadata{
+		FormatVersion:  formatVersion,
+		TableUUID:      uuid.MakeV4().String(),
+		Location:       location,
+		LastUpdatedMs:  timeutil.Now().UnixMilli(),
+		PartitionSpecs: []partitionSpec{{SpecID: 0, Fields: []interface{}{}}},
+		// Partition field IDs start at 1000.
+		LastPartitionID: 999,
+		SortOrders:      []sortOrder{{OrderID: 0, Fields: []interface{}{}}},
+		Properties:      map[string]string{},
+		Snapshots:       []Snapshot{},
+		SnapshotLog:     []snapshotLogEntry{},
+		MetadataLog:     []metadataLogEntry{},
+		Refs:            map[string]snapshotRef{},
+	}
+}
+
+// CurrentSnapshot returns the current snapshot of the table, or nil if the
+// table has no snapshot.
+func (m *TableMetadata) CurrentSnapshot() *Snapshot {
+	if m.CurrentSnapshotID == nil {
+		return nil
+	}
+	for i := range m.Snapshots {
+		if m.Snapshots[i].SnapshotID == *m.CurrentSnapshotID {
+			return &m.Snapshots[i]
+		}
+	}
+	return nil
+}
+
+// schema returns the schema with the given ID.
+func (m *TableMetadata) schema(id int) *Schema {
+	for i := range m.Schemas {
+		if m.Schemas[i].SchemaID == id {
+			return &m.Schemas[i]
+		}
+	}
+	return nil
+}
+
+// schemaFor returns the schema of files with the given columns, adding it to
+// the table if needed. Columns are matched with the fields of existing schemas
+// by name, and new columns are assigned new field IDs.
+func (m *TableMetadata) schemaFor(cols []Column) (*Schema, error) {
+	fieldIDs := make(map[string]int)
+	for _, s := range m.Schemas {
+		for _, f := range s.Fields {
+			fieldIDs[f.Name] = f.ID
+		}
+	}
+	s := Schema{Type: `struct`}
+	for _, c := range cols {
+		id, ok := fieldIDs[c.Name]
+		if !ok {
+			m.LastColumnID++
+			id = m.LastColumnID
+			fieldIDs[c.Name] = id
+		}
+		s.Fields = append(s.Fields, Field{ID: id, Name: c.Name, Required: c.Key, Type: c.Type})
+		if c.Key {
+			s.IdentifierFieldIDs = append(s.IdentifierFieldIDs, id)
+		}
+	}
+
+	for _, existing := range m.Schemas {
+		if sameFields(existing, s) {
+			return m.schema(existing.SchemaID), nil
+		}
+	}
+	for _, existing := range m.Schemas {
+		if existing.SchemaID >= s.SchemaID {
+			s.SchemaID = existing.SchemaID + 1
+		}
+	}
+	m.Schemas = append(m.Schemas, s)
+	if err := m.updateNameMapping(); err != nil {
+		return nil, err
+	}
+	return &m.Schemas[len(m.Schemas)-1], nil
+}
+
+func sameFields(a, b Schema) bool {
+	if len(a.Fields) != len(b.Fields) || len(a.IdentifierFieldIDs) != len(b.IdentifierFieldIDs) {
+		return false
+	}
+	for i := range a.Fields {
+		if a.Fields[i] != b.Fields[i] {
+			return false
+		}
+	}
+	for i := range a.IdentifierFieldIDs {
+		if a.IdentifierFieldIDs[i] != b.IdentifierFieldIDs[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// updateNameMapping sets the name mapping property to map the names of the
+// fields of every schema to their IDs.
+func (m *TableMetadata) updateNameMapping() error {
+	var mapping []nameMapping
+	seen := make(map[int]int)
+	for _, s := range m.Schemas {
+		for _, f := range s.Fields {
+			if i, ok := seen[f.ID]; ok {
+				if mapping[i].Names[len(mapping[i].Names)-1] != f.Name {
+					mapping[i].Names = append(mapping[i].Names, f.Name)
+				}
+				continue
+			}
+			seen[f.ID] = len(mapping)
+			mapping = append(mapping, nameMapping{FieldID: f.ID, Names: []string{f.Name}})
+		}
+	}
+	b, err := json.Marshal(mapping)
+	if err != nil {
+		return err
+	}
+	m.Properties[nameMappingProperty] = string(b)
+	return nil
+}
+
+// newSnapshotID returns a random positive snapshot ID which isn't used by the
+// table.
+func (m *TableMetadata) newSnapshotID() int64 {
+	for {
+		id := rand.Int63()
+		if id == 0 {
+			continue
+		}
+		used := false
+		for _, s := range m.Snapshots {
+			used = used || s.SnapshotID == id
+		}
+		if !used {
+			return id
+		}
+	}
+}
+
+// addSnapshot adds a snapshot to the table and makes it current.
+func (m *TableMetadata) addSnapshot(s Snapshot) {
+	m.Snapshots = append(m.Snapshots, s)
+	m.SnapshotLog = append(m.SnapshotLog, snapshotLogEntry{SnapshotID: s.SnapshotID, TimestampMs: s.TimestampMs})
+	m.LastSequenceNumber = s.SequenceNumber
+	m.LastUpdatedMs = s.TimestampMs
+	id := s.SnapshotID
+	m.CurrentSnapshotID = &id
+	m.CurrentSchemaID = s.SchemaID
+	m.Refs[`main`] = snapshotRef{SnapshotID: id, Type: `branch`}
+}
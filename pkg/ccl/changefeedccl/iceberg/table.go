@@ -0,0 +1,262 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package iceberg
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/util/ioctx"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
+)
+
+// Table is a table of a catalog whose files are stored in external storage.
+type Table struct {
+	catalog Catalog
+	id      Identifier
+	// storage is rooted at the location of the table.
+	storage  cloud.ExternalStorage
+	location string
+
+	metadataLocation string
+	metadata         *TableMetadata
+}
+
+// Change is a set of files added to a table by a snapshot.
+type Change struct {
+	// Columns are the columns of the data files.
+	Columns []Column
+	Files   []DataFile
+}
+
+// LoadTable loads a table from a catalog, or creates it if it doesn't exist.
+// The files of the table are stored in the given storage, which is rooted at
+// location.
+func LoadTable(
+	ctx context.Context, catalog Catalog, id Identifier, storage cloud.ExternalStorage, location string,
+) (*Table, error) {
+	t := &Table{
+		catalog:  catalog,
+		id:       id,
+		storage:  storage,
+		location: strings.TrimSuffix(location, `/`),
+	}
+	if err := t.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// Metadata returns the current metadata of the table.
+func (t *Table) Metadata() *TableMetadata {
+	return t.metadata
+}
+
+// Refresh reloads the current metadata of the table from the catalog.
+func (t *Table) Refresh(ctx context.Context) error {
+	loc, err := t.catalog.CurrentMetadata(ctx, t.id)
+	if err != nil {
+		return err
+	}
+	t.metadataLocation = loc
+	if loc == `` {
+		t.metadata = newTableMetadata(t.location)
+		return nil
+	}
+	b, err := t.readFile(ctx, loc)
+	if err != nil {
+		return errors.Wrapf(err, `reading metadata of table %s`, t.id)
+	}
+	var m TableMetadata
+	if err := json.Unmarshal(b, &m); err != nil {
+		return errors.Wrapf(err, `parsing metadata of table %s`, t.id)
+	}
+	if m.FormatVersion != formatVersion {
+		return errors.Newf(`table %s has unsupported format version %d`, t.id, m.FormatVersion)
+	}
+	t.metadata = &m
+	return nil
+}
+
+// relativePath returns the path of a location within the table.
+func (t *Table) relativePath(loc string) (string, error) {
+	rel := strings.TrimPrefix(loc, t.location+`/`)
+	if rel == loc {
+		return ``, errors.Newf(`%s is not within table location %s`, loc, t.location)
+	}
+	return rel, nil
+}
+
+func (t *Table) readFile(ctx context.Context, loc string) ([]byte, error) {
+	rel, err := t.relativePath(loc)
+	if err != nil {
+		return nil, err
+	}
+	r, _, err := t.storage.ReadFile(ctx, rel, cloud.ReadOptions{NoFileSize: true})
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close(ctx)
+	return ioctx.ReadAll(ctx, r)
+}
+
+// writeFile writes a file of the table and returns its location.
+func (t *Table) writeFile(ctx context.Context, rel string, data []byte) (string, error) {
+	if err := cloud.WriteFile(ctx, t.storage, rel, bytes.NewReader(data)); err != nil {
+		return ``, err
+	}
+	return t.location + `/` + rel, nil
+}
+
+// Commit adds the files of every change to the table, each change becoming a
+// snapshot whose sequence number follows the one of the previous change, so
+// that the equality deletes of a change apply to the data files of previous
+// changes. All the snapshots are committed atomically. The given summary
+// properties are added to the summary of the last snapshot.
+//
+// ErrCommitConflict is returned if the table was concurrently updated, in which
+// case the table is refreshed and the commit can be retried.
+func (t *Table) Commit(ctx context.Context, changes []Change, summary map[string]string) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	// Work on a copy of the metadata, so that it's unchanged if the commit
+	// fails.
+	b, err := json.Marshal(t.metadata)
+	if err != nil {
+		return err
+	}
+	var m TableMetadata
+	if err := json.Unmarshal(b, &m); err != nil {
+		return err
+	}
+
+	var manifests []manifestFile
+	if s := m.CurrentSnapshot(); s != nil {
+		list, err := t.readFile(ctx, s.ManifestList)
+		if err != nil {
+			return errors.Wrapf(err, `reading manifest list of snapshot %d`, s.SnapshotID)
+		}
+		if manifests, err = decodeManifestList(list); err != nil {
+			return errors.Wrapf(err, `decoding manifest list of snapshot %d`, s.SnapshotID)
+		}
+	}
+
+	for i, c := range changes {
+		schema, err := m.schemaFor(c.Columns)
+		if err != nil {
+			return err
+		}
+		var parentID *int64
+		if s := m.CurrentSnapshot(); s != nil {
+			id := s.SnapshotID
+			parentID = &id
+		}
+		snapshotID := m.newSnapshotID()
+		seq := m.LastSequenceNumber + 1
+
+		var data, deletes []DataFile
+		var addedRecords, addedDeletes int64
+		for _, f := range c.Files {
+			if f.Content == ContentEqualityDeletes {
+				deletes = append(deletes, f)
+				addedDeletes += f.RecordCount
+			} else {
+				data = append(data, f)
+				addedRecords += f.RecordCount
+			}
+		}
+		for j, files := range [][]DataFile{data, deletes} {
+			if len(files) == 0 {
+				continue
+			}
+			content := manifestContentData
+			if j == 1 {
+				content = manifestContentDeletes
+			}
+			manifest, entry, err := encodeManifest(schema, content, snapshotID, seq, t.location, files)
+			if err != nil {
+				return err
+			}
+			loc, err := t.writeFile(ctx, fmt.Sprintf(`metadata/%s-m%d.avro`, uuid.MakeV4(), j), manifest)
+			if err != nil {
+				return err
+			}
+			entry[`manifest_path`] = loc
+			manifests = append(manifests, entry)
+		}
+
+		list, err := encodeManifestList(snapshotID, parentID, seq, manifests)
+		if err != nil {
+			return err
+		}
+		listLoc, err := t.writeFile(ctx, fmt.Sprintf(`metadata/snap-%d-1-%s.avro`, snapshotID, uuid.MakeV4()), list)
+		if err != nil {
+			return err
+		}
+
+		operation := `append`
+		if len(deletes) > 0 {
+			operation = `overwrite`
+		}
+		snapshotSummary := map[string]string{
+			`operation`:              operation,
+			`added-data-files`:       strconv.Itoa(len(data)),
+			`added-delete-files`:     strconv.Itoa(len(deletes)),
+			`added-records`:          strconv.FormatInt(addedRecords, 10),
+			`added-equality-deletes`: strconv.FormatInt(addedDeletes, 10),
+		}
+		if i == len(changes)-1 {
+			for k, v := range summary {
+				snapshotSummary[k] = v
+			}
+		}
+		m.addSnapshot(Snapshot{
+			SnapshotID:       snapshotID,
+			ParentSnapshotID: parentID,
+			SequenceNumber:   seq,
+			TimestampMs:      timeutil.Now().UnixMilli(),
+			ManifestList:     listLoc,
+			Summary:          snapshotSummary,
+			SchemaID:         schema.SchemaID,
+		})
+	}
+
+	version := 0
+	if t.metadataLocation != `` {
+		m.MetadataLog = append(m.MetadataLog, metadataLogEntry{
+			MetadataFile: t.metadataLocation,
+			TimestampMs:  t.metadata.LastUpdatedMs,
+		})
+		version = len(m.MetadataLog)
+	}
+	b, err = json.Marshal(&m)
+	if err != nil {
+		return err
+	}
+	loc, err := t.writeFile(ctx, fmt.Sprintf(`metadata/%05d-%s.metadata.json`, version, uuid.MakeV4()), b)
+	if err != nil {
+		return err
+	}
+	if err := t.catalog.SwapMetadata(ctx, t.id, t.metadataLocation, loc); err != nil {
+		if errors.Is(err, ErrCommitConflict) {
+			if refreshErr := t.Refresh(ctx); refreshErr != nil {
+				return errors.CombineErrors(err, refreshErr)
+			}
+		}
+		return err
+	}
+	t.metadataLocation = loc
+	t.metadata = &m
+	return nil
+}
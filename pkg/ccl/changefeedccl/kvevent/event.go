@@ -18,6 +18,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/cockroachdb/errors"
 )
 
@@ -152,6 +153,16 @@ func (e *Event) KV() roachpb.KeyValue {
 	return roachpb.KeyValue{Key: v.Key, Value: v.Value}
 }
 
+// TxnID returns the ID of the transaction which wrote the value of this KV
+// event, if known. It is only set when kv.rangefeed.txn_ids.enabled is, and it
+// is empty for backfills, catch-up scans, and non-transactional writes.
+func (e *Event) TxnID() uuid.UUID {
+	if id := e.ev.Val.TxnID; id != nil {
+		return *id
+	}
+	return uuid.UUID{}
+}
+
 // PrevKeyValue returns the previous value for this event. PrevKeyValue is non-zero
 // if this is a KV event and the key had a non-tombstone value before the change
 // and the before value of each change was requested (optDiff).
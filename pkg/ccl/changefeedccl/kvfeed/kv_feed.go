@@ -45,6 +45,9 @@ type MonitoringConfig struct {
 	// LaggingRangesThreshold is how far behind a range must be to be considered
 	// lagging.
 	LaggingRangesThreshold time.Duration
+	// SpanReportCallback, if set, is called with the state of the rangefeeds
+	// of the kvfeed every time lagging ranges are polled.
+	SpanReportCallback func(ctx context.Context, ranges []jobspb.ChangefeedSpanReport_Range)
 
 	OnBackfillCallback      func() func()
 	OnBackfillRangeCallback func(int64) (func(), func())
@@ -89,6 +92,11 @@ type Config struct {
 	// enables filtering out any transactional writes with that flag set to true.
 	WithFiltering bool
 
+	// ScanNewTables, if true, causes tables which joined one of the databases
+	// or schemas watched by the changefeed to be scanned when the feed is
+	// restarted with them.
+	ScanNewTables bool
+
 	// Knobs are kvfeed testing knobs.
 	Knobs TestingKnobs
 
@@ -131,8 +139,10 @@ func Run(ctx context.Context, cfg Config) error {
 		cfg.SchemaFeed,
 		sc, pff, bf, cfg.Targets, cfg.ScopedTimers, cfg.Knobs)
 	f.onBackfillCallback = cfg.MonitoringCfg.OnBackfillCallback
+	f.scanNewTables = cfg.ScanNewTables
 	f.rangeObserver = startLaggingRangesObserver(g, cfg.MonitoringCfg.LaggingRangesCallback,
-		cfg.MonitoringCfg.LaggingRangesPollingInterval, cfg.MonitoringCfg.LaggingRangesThreshold)
+		cfg.MonitoringCfg.LaggingRangesPollingInterval, cfg.MonitoringCfg.LaggingRangesThreshold,
+		cfg.MonitoringCfg.SpanReportCallback)
 
 	g.GoCtx(cfg.SchemaFeed.Run)
 	g.GoCtx(f.run)
@@ -183,6 +193,7 @@ func startLaggingRangesObserver(
 	updateLaggingRanges func(lagging int64, total int64),
 	pollingInterval time.Duration,
 	threshold time.Duration,
+	reportSpans func(ctx context.Context, ranges []jobspb.ChangefeedSpanReport_Range),
 ) kvcoord.RangeObserver {
 	return func(fn kvcoord.ForEachRangeFn) {
 		g.GoCtx(func(ctx context.Context) error {
@@ -203,9 +214,24 @@ func startLaggingRangesObserver(
 					timer.Read = true
 
 					var laggingCount, totalCount int64
+					var ranges []jobspb.ChangefeedSpanReport_Range
 					thresholdTS := timeutil.Now().Add(-1 * threshold)
 					err := fn(func(rfCtx kvcoord.RangeFeedContext, feed kvcoord.PartialRangeFeed) error {
 						totalCount += 1
+						if reportSpans != nil {
+							r := jobspb.ChangefeedSpanReport_Range{
+								Span:      feed.Span,
+								Resolved:  feed.Resolved,
+								NodeID:    feed.NodeID,
+								RangeID:   feed.RangeID,
+								InCatchup: feed.InCatchup,
+								Created:   feed.CreatedTime,
+							}
+							if feed.LastErr != nil {
+								r.LastErr = feed.LastErr.Error()
+							}
+							ranges = append(ranges, r)
+						}
 
 						// The resolved timestamp of a range determines the timestamp which is caught up to.
 						// However, during catchup scans, this is not set. For catchup scans, we consider the
@@ -226,6 +252,9 @@ func startLaggingRangesObserver(
 						return err
 					}
 					updateLaggingRanges(laggingCount, totalCount)
+					if reportSpans != nil {
+						reportSpans(ctx, ranges)
+					}
 					timer.Reset(pollingInterval)
 				}
 			}
@@ -251,6 +280,7 @@ type kvFeed struct {
 	withDiff            bool
 	withFiltering       bool
 	withInitialBackfill bool
+	scanNewTables       bool
 	consumerID          int64
 	initialHighWater    hlc.Timestamp
 	endTime             hlc.Timestamp
@@ -403,8 +433,14 @@ func (f *kvFeed) run(ctx context.Context) (err error) {
 		// If is no change in the primary key columns, then a primary key change
 		// should not trigger a failure in the `stop` policy because this change is
 		// effectively invisible to consumers.
+		//
+		// If a table joined or left one of the namespaces watched by the
+		// changefeed, the changefeed restarts with the updated set of tables
+		// regardless of the policy.
 		primaryIndexChange, noColumnChanges := isPrimaryKeyChange(events, f.targets)
-		if primaryIndexChange && (noColumnChanges ||
+		if isNamespaceChange(events) {
+			boundaryType = jobspb.ResolvedSpan_RESTART
+		} else if primaryIndexChange && (noColumnChanges ||
 			f.schemaChangePolicy != changefeedbase.OptSchemaChangePolicyStop) {
 			boundaryType = jobspb.ResolvedSpan_RESTART
 		} else if f.schemaChangePolicy == changefeedbase.OptSchemaChangePolicyStop {
@@ -442,6 +478,24 @@ func isPrimaryKeyChange(
 	return isPrimaryIndexChange, isPrimaryIndexChange && hasNoColumnChanges
 }
 
+// isNamespaceChange returns true if any of the events corresponds to a table
+// joining or leaving the changefeed.
+func isNamespaceChange(events []schemafeed.TableEvent) bool {
+	for _, ev := range events {
+		if ev.NamespaceChange {
+			return true
+		}
+	}
+	return false
+}
+
+// isJoinedTable returns true if the event corresponds to a table which joined
+// the changefeed and is already one of its targets.
+func (f *kvFeed) isJoinedTable(ev schemafeed.TableEvent) bool {
+	isTarget, _ := f.targets.EachHavingTableID(ev.After.GetID(), func(changefeedbase.Target) error { return nil })
+	return isTarget && schemafeed.IsNamespaceMember(f.targets, ev.After)
+}
+
 // filterCheckpointSpans filters spans which have already been completed,
 // and returns the list of spans that still need to be done.
 func filterCheckpointSpans(spans []roachpb.Span, completed []roachpb.Span) []roachpb.Span {
@@ -495,6 +549,12 @@ func (f *kvFeed) scanIfShould(
 			if schemafeed.IsOnlyPrimaryIndexChange(ev) {
 				continue
 			}
+			// Tables joining or leaving the changefeed are handled by restarting
+			// the changefeed with the updated set of tables. Once restarted, the
+			// tables which joined are only scanned if requested.
+			if ev.NamespaceChange && !(f.scanNewTables && f.isJoinedTable(ev)) {
+				continue
+			}
 			tablePrefix := f.codec.TablePrefix(uint32(ev.After.GetID()))
 			tableSpan := roachpb.Span{Key: tablePrefix, EndKey: tablePrefix.PrefixEnd()}
 			for _, sp := range f.spans {
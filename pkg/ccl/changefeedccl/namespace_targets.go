@@ -0,0 +1,300 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package changefeedccl
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/schemafeed"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descs"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/nstree"
+	"github.com/cockroachdb/cockroach/pkg/sql/isql"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/errors"
+)
+
+// Database- and schema-level changefeeds (CREATE CHANGEFEED FOR DATABASE db,
+// CREATE CHANGEFEED FOR db.sc.*) are recorded as a DATABASE target
+// specification per watched namespace, along with a regular table
+// specification for each table currently making up the namespace. The
+// schemafeed notices tables joining or leaving the namespaces and restarts the
+// changefeed, at which point refreshNamespaceTargets brings the table
+// specifications up to date.
+
+// expandNamespaceTargets returns the targets of the CHANGEFEED statement with
+// the database- and schema-level targets replaced by the tables making up those
+// namespaces as of the given timestamp, along with the target specifications of
+// the namespaces themselves.
+func expandNamespaceTargets(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	changefeedStmt *tree.CreateChangefeed,
+	ts hlc.Timestamp,
+) (tree.ChangefeedTargets, []jobspb.ChangefeedTargetSpecification, error) {
+	type schemaPattern struct {
+		database, schema string
+	}
+	var patterns []schemaPattern
+	if changefeedStmt.Database != "" {
+		patterns = append(patterns, schemaPattern{database: string(changefeedStmt.Database)})
+	}
+	for _, ct := range changefeedStmt.Targets {
+		if n, ok := ct.TableName.(*tree.UnresolvedName); ok && n.Star {
+			// Only fully qualified patterns (db.sc.*) are accepted by the
+			// grammar.
+			if n.NumParts != 3 {
+				return nil, nil, errors.Errorf(
+					`CHANGEFEED target %s must be of the form database.schema.*`, tree.ErrString(n))
+			}
+			patterns = append(patterns, schemaPattern{database: n.Parts[2], schema: n.Parts[1]})
+		}
+	}
+	if len(patterns) == 0 {
+		return changefeedStmt.Targets, nil, nil
+	}
+	if len(patterns) != len(changefeedStmt.Targets) && changefeedStmt.Database == "" {
+		return nil, nil, errors.Errorf(
+			`CHANGEFEED cannot target both tables and all the tables of a schema`)
+	}
+
+	var expanded tree.ChangefeedTargets
+	var namespaces []jobspb.ChangefeedTargetSpecification
+	if err := sql.DescsTxn(ctx, execCfg, func(
+		ctx context.Context, txn isql.Txn, descriptors *descs.Collection,
+	) error {
+		expanded, namespaces = nil, nil
+		if err := txn.KV().SetFixedTimestamp(ctx, ts); err != nil {
+			return err
+		}
+		for _, pattern := range patterns {
+			db, err := descriptors.ByName(txn.KV()).Get().Database(ctx, pattern.database)
+			if err != nil {
+				return err
+			}
+			ns := changefeedbase.Namespace{DatabaseID: db.GetID()}
+			if pattern.schema != "" {
+				sc, err := descriptors.ByName(txn.KV()).Get().Schema(ctx, db, pattern.schema)
+				if err != nil {
+					return err
+				}
+				ns.SchemaID = sc.GetID()
+			}
+			namespaces = append(namespaces, jobspb.ChangefeedTargetSpecification{
+				Type:       jobspb.ChangefeedTargetSpecification_DATABASE,
+				DatabaseID: ns.DatabaseID,
+				SchemaID:   ns.SchemaID,
+			})
+
+			numTables := 0
+			if err := forEachNamespaceTable(ctx, txn.KV(), descriptors, ns, func(
+				table catalog.TableDescriptor,
+			) error {
+				sc, err := descriptors.ByIDWithoutLeased(txn.KV()).Get().Schema(ctx, table.GetParentSchemaID())
+				if err != nil {
+					return err
+				}
+				numTables++
+				expanded = append(expanded, tree.ChangefeedTarget{
+					TableName: tree.NewUnresolvedName(db.GetName(), sc.GetName(), table.GetName()),
+				})
+				return nil
+			}); err != nil {
+				return err
+			}
+			if numTables == 0 {
+				if pattern.schema == "" {
+					return errors.Errorf(`CHANGEFEED cannot target database %q: it has no tables`, pattern.database)
+				}
+				return errors.Errorf(`CHANGEFEED cannot target %s.%s.*: the schema has no tables`,
+					tree.NameString(pattern.database), tree.NameString(pattern.schema))
+			}
+		}
+		return nil
+	}); err != nil {
+		return nil, nil, errors.Wrap(err, "failed to resolve targets in the CHANGEFEED stmt")
+	}
+	return expanded, namespaces, nil
+}
+
+// forEachNamespaceTable calls fn on each table making up the namespace.
+func forEachNamespaceTable(
+	ctx context.Context,
+	txn *kv.Txn,
+	descriptors *descs.Collection,
+	ns changefeedbase.Namespace,
+	fn func(table catalog.TableDescriptor) error,
+) error {
+	db, err := descriptors.ByIDWithoutLeased(txn).WithoutNonPublic().Get().Database(ctx, ns.DatabaseID)
+	if err != nil {
+		return err
+	}
+	var objects nstree.Catalog
+	if ns.SchemaID == descpb.InvalidID {
+		objects, err = descriptors.GetAllTablesInDatabase(ctx, txn, db)
+	} else {
+		var sc catalog.SchemaDescriptor
+		sc, err = descriptors.ByIDWithoutLeased(txn).WithoutNonPublic().Get().Schema(ctx, ns.SchemaID)
+		if err != nil {
+			return err
+		}
+		objects, err = descriptors.GetAllObjectsInSchema(ctx, txn, db, sc)
+	}
+	if err != nil {
+		return err
+	}
+
+	var targets changefeedbase.Targets
+	targets.AddNamespace(ns)
+	return objects.ForEachDescriptor(func(desc catalog.Descriptor) error {
+		table, ok := desc.(catalog.TableDescriptor)
+		if !ok || !schemafeed.IsNamespaceMember(targets, table) {
+			return nil
+		}
+		return fn(table)
+	})
+}
+
+// refreshNamespaceTargets updates the table target specifications of a
+// database- or schema-level changefeed to match the tables making up the
+// watched namespaces as of the given timestamp. It returns whether the
+// specifications changed.
+func refreshNamespaceTargets(
+	ctx context.Context,
+	execCfg *sql.ExecutorConfig,
+	details *jobspb.ChangefeedDetails,
+	ts hlc.Timestamp,
+) (bool, error) {
+	targets := AllTargets(*details)
+	if !targets.HasNamespaces() {
+		return false, nil
+	}
+	opts := changefeedbase.MakeStatementOptions(details.Opts)
+
+	var specs []jobspb.ChangefeedTargetSpecification
+	var tables jobspb.ChangefeedTargets
+	changed := false
+	if err := sql.DescsTxn(ctx, execCfg, func(
+		ctx context.Context, txn isql.Txn, descriptors *descs.Collection,
+	) error {
+		specs, tables, changed = nil, make(jobspb.ChangefeedTargets), false
+		if err := txn.KV().SetFixedTimestamp(ctx, ts); err != nil {
+			return err
+		}
+		var members catalog.DescriptorIDSet
+		var joined []catalog.TableDescriptor
+		if err := targets.EachNamespace(func(ns changefeedbase.Namespace) error {
+			return forEachNamespaceTable(ctx, txn.KV(), descriptors, ns, func(
+				table catalog.TableDescriptor,
+			) error {
+				if members.Contains(table.GetID()) {
+					return nil
+				}
+				members.Add(table.GetID())
+				if _, ok := details.Tables[table.GetID()]; !ok {
+					joined = append(joined, table)
+				}
+				return nil
+			})
+		}); err != nil {
+			return err
+		}
+		if members.Empty() {
+			return changefeedbase.WithTerminalError(
+				errors.New("none of the databases or schemas watched by the changefeed have tables left"))
+		}
+
+		// Keep the specifications of the tables which are still part of the
+		// watched namespaces, so that their statement time names are preserved.
+		for _, spec := range details.TargetSpecifications {
+			if spec.Type != jobspb.ChangefeedTargetSpecification_DATABASE &&
+				!members.Contains(spec.TableID) {
+				changed = true
+				continue
+			}
+			specs = append(specs, spec)
+			if spec.TableID != descpb.InvalidID {
+				tables[spec.TableID] = details.Tables[spec.TableID]
+			}
+		}
+		for _, table := range joined {
+			name, err := getChangefeedTargetName(
+				ctx, table, execCfg, txn.KV(), opts.ShouldUseFullStatementTimeName())
+			if err != nil {
+				return err
+			}
+			typ := jobspb.ChangefeedTargetSpecification_PRIMARY_FAMILY_ONLY
+			if table.NumFamilies() > 1 {
+				typ = jobspb.ChangefeedTargetSpecification_EACH_FAMILY
+			}
+			tables[table.GetID()] = jobspb.ChangefeedTargetTable{StatementTimeName: name}
+			specs = append(specs, jobspb.ChangefeedTargetSpecification{
+				Type:              typ,
+				TableID:           table.GetID(),
+				StatementTimeName: name,
+			})
+			changed = true
+		}
+		return nil
+	}); err != nil {
+		if errors.Is(err, catalog.ErrDescriptorDropped) {
+			return false, changefeedbase.WithTerminalError(err)
+		}
+		return false, err
+	}
+	if changed {
+		details.TargetSpecifications = specs
+		details.Tables = tables
+	}
+	return changed, nil
+}
+
+// maybeRefreshNamespaceTargets refreshes the table targets of a database- or
+// schema-level changefeed before its flow is (re)started. The tables are
+// resolved as of the timestamp following the high-water mark, which is the
+// timestamp as of which the flow resolves the spans to watch. The refreshed
+// targets are recorded in the job, if any.
+func maybeRefreshNamespaceTargets(
+	ctx context.Context,
+	execCtx sql.JobExecContext,
+	jobID jobspb.JobID,
+	details *jobspb.ChangefeedDetails,
+	progress jobspb.Progress,
+) error {
+	ts := details.StatementTime
+	if h := progress.GetHighWater(); h != nil && !h.IsEmpty() {
+		ts = h.Next()
+	}
+	newDetails := *details
+	changed, err := refreshNamespaceTargets(ctx, execCtx.ExecCfg(), &newDetails, ts)
+	if err != nil || !changed {
+		return err
+	}
+	log.Infof(ctx, "changefeed %d now watches %d tables", jobID, len(newDetails.Tables))
+
+	if jobID != jobspb.InvalidJobID {
+		if err := execCtx.ExecCfg().JobRegistry.UpdateJobWithTxn(ctx, jobID, nil, /* txn */
+			func(txn isql.Txn, md jobs.JobMetadata, ju *jobs.JobUpdater) error {
+				payload := md.Payload
+				payload.Details = jobspb.WrapPayloadDetails(newDetails)
+				ju.UpdatePayload(payload)
+				return nil
+			},
+		); err != nil {
+			return err
+		}
+	}
+	*details = newDetails
+	return nil
+}
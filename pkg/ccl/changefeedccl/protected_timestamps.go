@@ -58,6 +58,18 @@ func makeTargetToProtect(targets changefeedbase.Targets) *ptpb.Target {
 		tablesToProtect = append(tablesToProtect, id)
 		return nil
 	})
+	// Protecting the watched databases as a whole also covers the tables that
+	// join them after the changefeed started.
+	_ = targets.EachNamespace(func(ns changefeedbase.Namespace) error {
+		tablesToProtect = append(tablesToProtect, ns.DatabaseID)
+		return nil
+	})
+	// Lookups are evaluated as of the MVCC timestamps of the events, so the
+	// history of the lookup tables must be kept as well.
+	_ = targets.EachLookupTableID(func(id descpb.ID) error {
+		tablesToProtect = append(tablesToProtect, id)
+		return nil
+	})
 	tablesToProtect = append(tablesToProtect, systemTablesToProtect...)
 	return ptpb.MakeSchemaObjectsTarget(tablesToProtect)
 }
@@ -75,6 +87,10 @@ func makeSpansToProtect(codec keys.SQLCodec, targets changefeedbase.Targets) []r
 		addTablePrefix(uint32(id))
 		return nil
 	})
+	_ = targets.EachLookupTableID(func(id descpb.ID) error {
+		addTablePrefix(uint32(id))
+		return nil
+	})
 	for _, id := range systemTablesToProtect {
 		addTablePrefix(uint32(id))
 	}
@@ -27,6 +27,10 @@ import (
 
 const confluentSchemaContentType = `application/vnd.schemaregistry.v1+json`
 
+// confluentSchemaTypeProtobuf is the type of protobuf schemas. Schemas without
+// a type are AVRO schemas.
+const confluentSchemaTypeProtobuf = `PROTOBUF`
+
 type schemaRegistry interface {
 	// Ping tests the connectivity to the schema registry. A nil
 	// error is returned if the schema registry appears to be
@@ -38,10 +42,18 @@ type schemaRegistry interface {
 	// be used in Avro wire messages or in other calls to the
 	// schema registry.
 	RegisterSchemaForSubject(ctx context.Context, subject string, schema string) (int32, error)
+
+	// RegisterTypedSchemaForSubject is like RegisterSchemaForSubject, but
+	// registers a schema of the given type, such as PROTOBUF, rather than an
+	// AVRO schema.
+	RegisterTypedSchemaForSubject(
+		ctx context.Context, subject string, schemaType string, schema string,
+	) (int32, error)
 }
 
 type confluentSchemaVersionRequest struct {
-	Schema string `json:"schema"`
+	Schema     string `json:"schema"`
+	SchemaType string `json:"schemaType,omitempty"`
 }
 
 type confluentSchemaVersionResponse struct {
@@ -225,13 +237,25 @@ func (r *confluentSchemaRegistry) Ping(ctx context.Context) error {
 //	https://docs.confluent.io/platform/current/schema-registry/develop/api.html#post--subjects-(string-%20subject)-versions
 func (r *confluentSchemaRegistry) RegisterSchemaForSubject(
 	ctx context.Context, subject string, schema string,
+) (int32, error) {
+	return r.RegisterTypedSchemaForSubject(ctx, subject, "" /* schemaType */, schema)
+}
+
+// RegisterTypedSchemaForSubject registers the given schema of the given type
+// for the given subject.
+func (r *confluentSchemaRegistry) RegisterTypedSchemaForSubject(
+	ctx context.Context, subject string, schemaType string, schema string,
 ) (int32, error) {
 	u := r.urlForPath(fmt.Sprintf("subjects/%s/versions", subject))
 	if log.V(1) {
-		log.Infof(ctx, "registering avro schema %s %s", u, schema)
+		if schemaType == "" {
+			log.Infof(ctx, "registering avro schema %s %s", u, schema)
+		} else {
+			log.Infof(ctx, "registering %s schema %s %s", schemaType, u, schema)
+		}
 	}
 
-	req := confluentSchemaVersionRequest{Schema: schema}
+	req := confluentSchemaVersionRequest{Schema: schema, SchemaType: schemaType}
 	var buf bytes.Buffer
 	if err := json.NewEncoder(&buf).Encode(req); err != nil {
 		return 0, err
@@ -313,8 +337,9 @@ func (r *confluentSchemaRegistry) urlForPath(relPath string) string {
 }
 
 type schemaRegistryCacheKey struct {
-	subject string
-	schema  string
+	subject    string
+	schemaType string
+	schema     string
 }
 
 type schemaRegistryCache struct {
@@ -351,9 +376,16 @@ func (csr *schemaRegistryWithCache) Ping(ctx context.Context) error {
 // RegisterSchemaForSubject implements the schemaRegistry interface.
 func (csr *schemaRegistryWithCache) RegisterSchemaForSubject(
 	ctx context.Context, subject string, schema string,
+) (int32, error) {
+	return csr.RegisterTypedSchemaForSubject(ctx, subject, "" /* schemaType */, schema)
+}
+
+// RegisterTypedSchemaForSubject implements the schemaRegistry interface.
+func (csr *schemaRegistryWithCache) RegisterTypedSchemaForSubject(
+	ctx context.Context, subject string, schemaType string, schema string,
 ) (int32, error) {
 	cacheKey := schemaRegistryCacheKey{
-		subject: subject, schema: schema,
+		subject: subject, schemaType: schemaType, schema: schema,
 	}
 	csr.cache.mu.Lock()
 	defer csr.cache.mu.Unlock()
@@ -361,7 +393,7 @@ func (csr *schemaRegistryWithCache) RegisterSchemaForSubject(
 	if ok {
 		return id, nil
 	}
-	id, err := csr.base.RegisterSchemaForSubject(ctx, subject, schema)
+	id, err := csr.base.RegisterTypedSchemaForSubject(ctx, subject, schemaType, schema)
 	if err == nil {
 		csr.cache.Add(cacheKey, id)
 	}
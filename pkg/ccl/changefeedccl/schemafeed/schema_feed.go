@@ -45,6 +45,20 @@ import (
 // TableEvent represents a change to a table descriptor.
 type TableEvent struct {
 	Before, After catalog.TableDescriptor
+	// NamespaceChange is set if the table joined or left one of the databases
+	// or schemas watched by the changefeed, in which case the changefeed needs
+	// to be restarted with the updated set of tables.
+	NamespaceChange bool
+}
+
+// IsNamespaceMember returns true if the given version of a table makes it part
+// of one of the databases or schemas watched by the changefeed. Only public,
+// non-temporary tables that store their own data are watched; views,
+// sequences and tables that are being created or dropped are not.
+func IsNamespaceMember(targets changefeedbase.Targets, desc catalog.TableDescriptor) bool {
+	return targets.WatchesNamespace(desc.GetParentID(), desc.GetParentSchemaID()) &&
+		desc.Public() && desc.IsTable() && !desc.IsVirtualTable() && !desc.IsTemporary() &&
+		desc.ExternalRowData() == nil
 }
 
 // Timestamp refers to the ModificationTime of the After table descriptor.
@@ -155,6 +169,10 @@ type schemaFeed struct {
 		// that they use.
 		typeDeps typeDependencyTracker
 
+		// joiningTables is the set of target tables which were not yet part of
+		// the watched databases or schemas at the initial frontier.
+		joiningTables catalog.DescriptorIDSet
+
 		// pollingPaused, if set, pauses the polling background work.
 		// Polling can be paused if all tables are locked from schema changes because
 		// we know no table events will occur.
@@ -266,6 +284,7 @@ func (tf *schemaFeed) Run(ctx context.Context) error {
 
 func (tf *schemaFeed) primeInitialTableDescs(ctx context.Context) error {
 	var initialDescs []catalog.Descriptor
+	var joiningTables catalog.DescriptorIDSet
 
 	initialTableDescsFn := func(
 		ctx context.Context, txn descs.Txn,
@@ -275,8 +294,20 @@ func (tf *schemaFeed) primeInitialTableDescs(ctx context.Context) error {
 		if err := txn.KV().SetFixedTimestamp(ctx, tf.initialFrontier); err != nil {
 			return err
 		}
+		joiningTables = catalog.DescriptorIDSet{}
 		// Note that all targets are currently guaranteed to be tables.
 		return tf.targets.EachTableID(func(id descpb.ID) error {
+			if tf.targets.HasNamespaces() {
+				// Tables which joined a watched namespace right when the changefeed
+				// was restarted are not yet part of it at the initial frontier;
+				// their first version is reported once it's ingested.
+				tableDesc, err := descriptors.ByIDWithoutLeased(txn.KV()).Get().Table(ctx, id)
+				if errors.Is(err, catalog.ErrDescriptorNotFound) ||
+					(err == nil && !IsNamespaceMember(tf.targets, tableDesc)) {
+					joiningTables.Add(id)
+					return nil
+				}
+			}
 			tableDesc, err := descriptors.ByIDWithoutLeased(txn.KV()).WithoutNonPublic().Get().Table(ctx, id)
 			if err != nil {
 				return err
@@ -298,6 +329,7 @@ func (tf *schemaFeed) primeInitialTableDescs(ctx context.Context) error {
 			tbl := desc.(catalog.TableDescriptor)
 			tf.mu.typeDeps.ingestTable(tbl)
 		}
+		tf.mu.joiningTables = joiningTables
 	}()
 
 	return tf.ingestDescriptors(ctx, hlc.Timestamp{}, tf.initialFrontier, initialDescs, tf.validateDescriptor)
@@ -480,6 +512,12 @@ func (tf *schemaFeed) pauseOrResumePolling(ctx context.Context, atOrBefore hlc.T
 	// Always assume we need to resume polling until we've proven otherwise.
 	tf.mu.pollingPaused = false
 
+	// Lookup tables may change at any time without a corresponding schema lock
+	// on the targets, so keep polling to notice them being dropped.
+	if tf.targets.HasLookupTables() {
+		return nil
+	}
+
 	if canPausePolling, err := tf.targets.EachTableIDWithBool(func(id descpb.ID) (bool, error) {
 		// Check if target table is schema-locked at the current frontier.
 		ld1, err := tf.leaseMgr.Acquire(ctx, frontier, id)
@@ -662,6 +700,44 @@ func (tf *schemaFeed) validateDescriptor(
 		}
 		return nil
 	case catalog.TableDescriptor:
+		if tf.targets.IsLookupTable(desc.GetID()) {
+			// Lookup tables referenced by cdc_lookup are read, but not emitted, by
+			// the changefeed: their schema changes produce no events, but the
+			// changefeed cannot proceed once they are gone.
+			if desc.Dropped() || desc.Offline() {
+				return changefeedbase.WithTerminalError(errors.Wrapf(catalog.ErrDescriptorDropped,
+					"lookup table %q [%d] is no longer available", desc.GetName(), desc.GetID()))
+			}
+			if !tf.targets.HasNamespaces() || !IsNamespaceMember(tf.targets, desc) {
+				return nil
+			}
+		}
+		if tf.targets.HasNamespaces() {
+			isTarget, _ := tf.targets.EachHavingTableID(desc.GetID(), func(changefeedbase.Target) error {
+				return nil
+			})
+			if isTarget != IsNamespaceMember(tf.targets, desc) {
+				before := desc
+				if lastVersion, ok := tf.mu.previousTableVersion[desc.GetID()]; ok {
+					before = lastVersion
+				}
+				e := TableEvent{Before: before, After: desc, NamespaceChange: true}
+				log.VEventf(ctx, 1, "validate namespace change %v", formatEvent(e))
+				tf.addEventLocked(earliestTsBeingIngested, e)
+				return nil
+			}
+			if !isTarget {
+				return nil
+			}
+			if tf.mu.joiningTables.Contains(desc.GetID()) {
+				// Report the first version of a table which joined the changefeed so
+				// that it can be scanned if need be.
+				tf.mu.joiningTables.Remove(desc.GetID())
+				e := TableEvent{Before: desc, After: desc, NamespaceChange: true}
+				log.VEventf(ctx, 1, "validate joined table %v", formatEvent(e))
+				tf.addEventLocked(earliestTsBeingIngested, e)
+			}
+		}
 		if err := changefeedvalidators.ValidateTable(tf.targets, desc, tf.tolerances); err != nil {
 			return err
 		}
@@ -696,17 +772,7 @@ func (tf *schemaFeed) validateDescriptor(
 				return changefeedbase.WithTerminalError(err)
 			}
 			if !shouldFilter {
-				// Only sort the tail of the events from earliestTsBeingIngested.
-				// The head could already have been handed out and sorting is not
-				// stable.
-				idxToSort := sort.Search(len(tf.mu.events), func(i int) bool {
-					return !tf.mu.events[i].After.GetModificationTime().Less(earliestTsBeingIngested)
-				})
-				tf.mu.events = append(tf.mu.events, e)
-				toSort := tf.mu.events[idxToSort:]
-				sort.Slice(toSort, func(i, j int) bool {
-					return descLess(toSort[i].After, toSort[j].After)
-				})
+				tf.addEventLocked(earliestTsBeingIngested, e)
 			}
 		}
 		// Add the types used by the table into the dependency tracker.
@@ -718,6 +784,21 @@ func (tf *schemaFeed) validateDescriptor(
 	}
 }
 
+// addEventLocked adds an event to the queue of events.
+func (tf *schemaFeed) addEventLocked(earliestTsBeingIngested hlc.Timestamp, e TableEvent) {
+	// Only sort the tail of the events from earliestTsBeingIngested.
+	// The head could already have been handed out and sorting is not
+	// stable.
+	idxToSort := sort.Search(len(tf.mu.events), func(i int) bool {
+		return !tf.mu.events[i].After.GetModificationTime().Less(earliestTsBeingIngested)
+	})
+	tf.mu.events = append(tf.mu.events, e)
+	toSort := tf.mu.events[idxToSort:]
+	sort.Slice(toSort, func(i, j int) bool {
+		return descLess(toSort[i].After, toSort[j].After)
+	})
+}
+
 var highPriorityAfter = settings.RegisterDurationSetting(
 	settings.ApplicationLevel,
 	"changefeed.schema_feed.read_with_priority_after",
@@ -844,8 +925,13 @@ func (tf *schemaFeed) fetchDescriptorVersions(
 						return found // sentinel error to break the loop
 					})
 					isType := tf.mu.typeDeps.containsType(descpb.ID(id))
+					isLookup := tf.targets.IsLookupTable(descpb.ID(id))
+					// Any other table might be joining one of the namespaces
+					// watched by the changefeed, which can only be determined
+					// once the descriptor is decoded.
+					maybeInNamespace := !(isTable || isType || isLookup) && tf.targets.HasNamespaces()
 					// Check if the descriptor is an interesting table or type.
-					if !(isTable || isType) {
+					if !(isTable || isType || isLookup || maybeInNamespace) {
 						// Uninteresting descriptor.
 						continue
 					}
@@ -856,10 +942,17 @@ func (tf *schemaFeed) fetchDescriptorVersions(
 					}
 
 					if len(unsafeValue) == 0 {
+						if maybeInNamespace {
+							continue
+						}
 						if isType {
 							return changefeedbase.WithTerminalError(
 								errors.Wrapf(catalog.ErrDescriptorDropped, "type descriptor %d dropped", id))
 						}
+						if isLookup {
+							return changefeedbase.WithTerminalError(
+								errors.Wrapf(catalog.ErrDescriptorDropped, "lookup table %d was dropped", id))
+						}
 
 						name := origName
 						if name == "" {
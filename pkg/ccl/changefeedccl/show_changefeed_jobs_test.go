@@ -24,11 +24,13 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/catpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
 	"github.com/stretchr/testify/require"
 )
 
@@ -608,3 +610,40 @@ func TestShowChangefeedJobsDefaultFilter(t *testing.T) {
 
 	cdcTest(t, testFn, feedTestForceSink("kafka"), updateKnobs)
 }
+
+func TestShowChangefeedSpans(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testFn := func(t *testing.T, s TestServer, f cdctest.TestFeedFactory) {
+		sqlDB := sqlutils.MakeSQLRunner(s.DB)
+		sqlDB.Exec(t, `CREATE TABLE foo (a INT PRIMARY KEY, b STRING)`)
+		sqlDB.Exec(t, `INSERT INTO foo VALUES (1, 'a')`)
+
+		foo := feed(t, f, `CREATE CHANGEFEED FOR foo WITH resolved='10ms', lagging_ranges_polling_interval='10ms'`)
+		defer closeFeed(t, foo)
+		jobID := foo.(cdctest.EnterpriseTestFeed).JobID()
+
+		sqlDB.ExpectErr(t, `span reports are disabled`,
+			fmt.Sprintf(`SHOW CHANGEFEED JOB %d SPANS`, jobID))
+		sqlDB.Exec(t, `SET CLUSTER SETTING changefeed.span_reports.enabled = true`)
+
+		testutils.SucceedsSoon(t, func() error {
+			rows := sqlDB.QueryStr(t, fmt.Sprintf(
+				`SELECT start_key, range_id, resolved IS NOT NULL, catchup, status FROM [SHOW CHANGEFEED JOB %d SPANS]`,
+				jobID))
+			if len(rows) == 0 {
+				return errors.New("no span report yet")
+			}
+			for _, row := range rows {
+				if row[2] != "true" || row[3] != "false" || row[4] != "ok" {
+					return errors.Newf("range not caught up yet: %v", row)
+				}
+			}
+			require.Contains(t, rows[0][0], "/Table/")
+			return nil
+		})
+	}
+
+	cdcTest(t, testFn, feedTestEnterpriseSinks)
+}
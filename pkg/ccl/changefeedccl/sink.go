@@ -24,6 +24,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/security/username"
 	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/util/admission"
@@ -57,6 +58,9 @@ const (
 	sinkTypeCloudstorage
 	sinkTypeSQL
 	sinkTypePulsar
+	sinkTypeIceberg
+	sinkTypeNATS
+	sinkTypeMQTT
 )
 
 // externalResource is the interface common to both EventSink and
@@ -121,9 +125,10 @@ func getEventSink(
 	timestampOracle timestampLowerBoundOracle,
 	user username.SQLUsername,
 	jobID jobspb.JobID,
+	flowID execinfrapb.FlowID,
 	m metricsRecorder,
 ) (EventSink, error) {
-	return getAndDialSink(ctx, serverCfg, feedCfg, timestampOracle, user, jobID, m)
+	return getAndDialSink(ctx, serverCfg, feedCfg, timestampOracle, user, jobID, flowID, m)
 }
 
 func getResolvedTimestampSink(
@@ -133,9 +138,10 @@ func getResolvedTimestampSink(
 	timestampOracle timestampLowerBoundOracle,
 	user username.SQLUsername,
 	jobID jobspb.JobID,
+	flowID execinfrapb.FlowID,
 	m metricsRecorder,
 ) (ResolvedTimestampSink, error) {
-	return getAndDialSink(ctx, serverCfg, feedCfg, timestampOracle, user, jobID, m)
+	return getAndDialSink(ctx, serverCfg, feedCfg, timestampOracle, user, jobID, flowID, m)
 }
 
 func getAndDialSink(
@@ -145,9 +151,10 @@ func getAndDialSink(
 	timestampOracle timestampLowerBoundOracle,
 	user username.SQLUsername,
 	jobID jobspb.JobID,
+	flowID execinfrapb.FlowID,
 	m metricsRecorder,
 ) (Sink, error) {
-	sink, err := getSink(ctx, serverCfg, feedCfg, timestampOracle, user, jobID, m)
+	sink, err := getSink(ctx, serverCfg, feedCfg, timestampOracle, user, jobID, flowID, m)
 	if err != nil {
 		return nil, err
 	}
@@ -202,6 +209,7 @@ func getSink(
 	timestampOracle timestampLowerBoundOracle,
 	user username.SQLUsername,
 	jobID jobspb.JobID,
+	flowID execinfrapb.FlowID,
 	m metricsRecorder,
 ) (Sink, error) {
 	u, err := url.Parse(feedCfg.SinkURI)
@@ -294,6 +302,18 @@ func getSink(
 			} else {
 				return makeDeprecatedPubsubSink(ctx, u, encodingOpts, AllTargets(feedCfg), opts.IsSet(changefeedbase.OptUnordered), metricsBuilder, testingKnobs)
 			}
+		case isNATSSink(u):
+			return validateOptionsAndMakeSink(changefeedbase.NATSValidOptions, func() (Sink, error) {
+				return makeNATSSink(ctx, sinkURL{URL: u}, encodingOpts, opts.GetNATSConfigJSON(), AllTargets(feedCfg),
+					numSinkIOWorkers(serverCfg), newCPUPacerFactory(ctx, serverCfg), timeutil.DefaultTimeSource{},
+					metricsBuilder, serverCfg.Settings, natsSinkKnobs{})
+			})
+		case isMQTTSink(u):
+			return validateOptionsAndMakeSink(changefeedbase.MQTTValidOptions, func() (Sink, error) {
+				return makeMQTTSink(ctx, sinkURL{URL: u}, encodingOpts, opts.GetMQTTConfigJSON(), AllTargets(feedCfg),
+					numSinkIOWorkers(serverCfg), newCPUPacerFactory(ctx, serverCfg), timeutil.DefaultTimeSource{},
+					metricsBuilder, serverCfg.Settings, mqttSinkKnobs{})
+			})
 		case isCloudStorageSink(u):
 			return validateOptionsAndMakeSink(changefeedbase.CloudStorageValidOptions, func() (Sink, error) {
 				var testingKnobs *TestingKnobs
@@ -306,10 +326,40 @@ func getSink(
 				if serverCfg.NodeID != nil {
 					nodeID = serverCfg.NodeID.SQLInstanceID()
 				}
-				return makeCloudStorageSink(
+				s, err := makeCloudStorageSink(
 					ctx, sinkURL{URL: u}, nodeID, serverCfg.Settings, encodingOpts,
 					timestampOracle, serverCfg.ExternalStorageFromURI, user, metricsBuilder, testingKnobs,
 				)
+				if err != nil {
+					return nil, err
+				}
+				interval, exactlyOnce, err := opts.GetExactlyOnceInterval()
+				if err != nil {
+					return nil, errors.CombineErrors(err, s.Close())
+				}
+				// Sinks which are not part of a changefeed flow, such as the canary
+				// sink, don't stage or commit anything.
+				if exactlyOnce && !flowID.IsUnset() {
+					cs, ok := s.(*cloudStorageSink)
+					if !ok {
+						return nil, errors.CombineErrors(errors.Errorf(`%s is not supported with %s=%s`,
+							changefeedbase.OptExactlyOnce, changefeedbase.OptFormat, encodingOpts.Format), s.Close())
+					}
+					if err := cs.enableExactlyOnce(ctx, flowID, interval); err != nil {
+						return nil, errors.CombineErrors(err, s.Close())
+					}
+				}
+				return s, nil
+			})
+		case isIcebergSink(u):
+			return validateOptionsAndMakeSink(changefeedbase.IcebergValidOptions, func() (Sink, error) {
+				// Snapshots are committed at resolved timestamps.
+				if !opts.IsSet(changefeedbase.OptResolvedTimestamps) {
+					return nil, errors.Errorf(`this sink requires the %s option`,
+						changefeedbase.OptResolvedTimestamps)
+				}
+				return makeIcebergSink(ctx, sinkURL{URL: u}, encodingOpts, AllTargets(feedCfg),
+					serverCfg.ExternalStorageFromURI, user, metricsBuilder)
 			})
 		case u.Scheme == changefeedbase.SinkSchemeExperimentalSQL:
 			return validateOptionsAndMakeSink(changefeedbase.SQLValidOptions, func() (Sink, error) {
@@ -319,7 +369,7 @@ func getSink(
 			return validateOptionsAndMakeSink(changefeedbase.ExternalConnectionValidOptions, func() (Sink, error) {
 				return makeExternalConnectionSink(
 					ctx, sinkURL{URL: u}, user, makeExternalConnectionProvider(ctx, serverCfg.DB),
-					serverCfg, feedCfg, timestampOracle, jobID, m,
+					serverCfg, feedCfg, timestampOracle, jobID, flowID, m,
 				)
 			})
 		case u.Scheme == "":
@@ -462,6 +512,25 @@ func (s errorWrapperSink) EmitResolvedTimestamp(
 	return nil
 }
 
+// EmitTxnRow implements the txnRowSink interface.
+func (s errorWrapperSink) EmitTxnRow(
+	ctx context.Context,
+	topic TopicDescriptor,
+	key, value []byte,
+	updated, mvcc hlc.Timestamp,
+	txnID string,
+	alloc kvevent.Alloc,
+) error {
+	ts, ok := s.wrapped.(txnRowSink)
+	if !ok {
+		return errors.AssertionFailedf(`%T does not support transaction markers`, s.wrapped)
+	}
+	if err := ts.EmitTxnRow(ctx, topic, key, value, updated, mvcc, txnID, alloc); err != nil {
+		return changefeedbase.MarkRetryableError(err)
+	}
+	return nil
+}
+
 // Flush implements Sink interface.
 func (s errorWrapperSink) Flush(ctx context.Context) error {
 	if err := s.wrapped.(EventSink).Flush(ctx); err != nil {
@@ -731,6 +800,24 @@ func (s *safeSink) EmitRow(
 	return s.wrapped.EmitRow(ctx, topic, key, value, updated, mvcc, alloc)
 }
 
+// EmitTxnRow implements the txnRowSink interface.
+func (s *safeSink) EmitTxnRow(
+	ctx context.Context,
+	topic TopicDescriptor,
+	key, value []byte,
+	updated, mvcc hlc.Timestamp,
+	txnID string,
+	alloc kvevent.Alloc,
+) error {
+	ts, ok := s.wrapped.(txnRowSink)
+	if !ok {
+		return errors.AssertionFailedf(`%T does not support transaction markers`, s.wrapped)
+	}
+	s.Lock()
+	defer s.Unlock()
+	return ts.EmitTxnRow(ctx, topic, key, value, updated, mvcc, txnID, alloc)
+}
+
 func (s *safeSink) Flush(ctx context.Context) error {
 	s.Lock()
 	defer s.Unlock()
@@ -300,6 +300,10 @@ func (f *cloudStorageSinkFile) Write(p []byte) (int, error) {
 // satisfies requirements of lemma 1. So we can consider these k jobs conceptually as one
 // job (call it P). Now, we're back to the case where k = 2 with jobs P and Q. Thus, by
 // induction we have the required proof.
+//
+// With the exactly_once option, none of the above applies to data files, which
+// are instead staged and committed in manifests; see
+// sink_cloudstorage_exactly_once.go.
 type cloudStorageSink struct {
 	srcID  base.SQLInstanceID
 	sinkID int64
@@ -338,6 +342,10 @@ type cloudStorageSink struct {
 	asyncFlushTermCh chan struct{}     // channel closed by async flusher to indicate an error
 	asyncFlushErr    error             // set by async flusher, prior to closing asyncFlushTermCh
 
+	// exactlyOnce is set if the sink stages files and commits them in manifests
+	// rather than writing them out directly; see enableExactlyOnce.
+	exactlyOnce *cloudStorageExactlyOnce
+
 	// testingKnobs may be nil if no knobs are set.
 	testingKnobs *TestingKnobs
 }
@@ -552,6 +560,9 @@ func (s *cloudStorageSink) EmitRow(
 	if s.files == nil {
 		return errors.New(`cannot EmitRow on a closed sink`)
 	}
+	if s.exactlyOnce != nil {
+		return s.bufferExactlyOnceRow(ctx, topic, key, value, mvcc, "" /* txnID */, alloc)
+	}
 
 	defer func() {
 		if !s.compression.enabled() {
@@ -605,6 +616,11 @@ func (s *cloudStorageSink) EmitResolvedTimestamp(
 
 	defer s.metrics.recordResolvedCallback()()
 
+	if s.exactlyOnce != nil {
+		// Manifests take the place of resolved timestamp files.
+		return s.commitManifest(ctx, resolved)
+	}
+
 	var noTopic string
 	payload, err := encoder.EncodeResolvedTimestamp(ctx, noTopic, resolved)
 	if err != nil {
@@ -692,6 +708,10 @@ func (s *cloudStorageSink) Flush(ctx context.Context) error {
 
 	s.metrics.recordFlushRequestCallback()()
 
+	if s.exactlyOnce != nil {
+		return s.flushExactlyOnce(ctx)
+	}
+
 	var err error
 	s.files.Ascend(func(i btree.Item) (wantMore bool) {
 		err = s.flushFile(ctx, i.(*cloudStorageSinkFile))
@@ -924,6 +944,9 @@ func (s *cloudStorageSink) closeAllCodecs() (err error) {
 func (s *cloudStorageSink) Close() error {
 	err := s.closeAllCodecs()
 	s.files = nil
+	if s.exactlyOnce != nil {
+		s.exactlyOnce.memAcc.Close(context.Background())
+	}
 	err = errors.CombineErrors(err, s.waitAsyncFlush(context.Background()))
 	close(s.asyncFlushCh) // signal flusher to exit.
 	err = errors.CombineErrors(err, s.flushGroup.Wait())
@@ -0,0 +1,647 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package changefeedccl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+	"unsafe"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/kvevent"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/ioctx"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+)
+
+// With the exactly_once option, the cloud storage sink no longer makes data
+// files visible as soon as they are written. Instead, consumers read the
+// manifests the sink writes at every resolved timestamp: a manifest lists the
+// data files which, together with the files listed by all previous manifests,
+// contain every row change up to and including its resolved timestamp exactly
+// once.
+//
+// The layout of the sink is:
+//
+//	_staging/<partition>/<label>-<session>-<srcID>-<sinkID>-<fileID>-<topic>-<schema><ext>
+//	_pending/<flowID>/<cut>-<session>-<srcID>-<sinkID>.json
+//	_manifests/<YYYY-MM-DD>/<resolved>.json
+//
+// Every manifest timestamp is a multiple of the manifest interval (the value of
+// the resolved option), except at schema change boundaries, where a manifest is
+// committed at the boundary itself. Each row is assigned the label of the
+// manifest which commits it: the smallest manifest timestamp not less than its
+// MVCC timestamp.
+//
+// The change aggregators buffer rows in memory, deduplicated on their key and
+// MVCC timestamp, against the memory budget of the aggregator. Whenever they
+// flush, they record the rows which are at or below the largest manifest
+// timestamp their local frontier has passed (the cut) in a pending commit of
+// the flow, one staging file per label, and then write the staging files. Rows
+// are never written before the local frontier passes them, so all the versions
+// of a row at a given timestamp, including the ones re-emitted by rangefeed
+// retries, end up in the same file. Since the pending commit is written first,
+// every staging file is listed by a pending commit, even if the aggregator
+// fails halfway through the flush.
+//
+// The change frontier commits a manifest once the changefeed frontier passes
+// a manifest timestamp, by collecting the files of the flow's pending commits
+// with labels in (previous manifest, manifest]. Only after the manifest is
+// written is the job highwater advanced to the manifest timestamp, so a
+// restarted changefeed always replays from a committed manifest. Its
+// aggregators drop replayed rows at or below the latest manifest, and its
+// frontier removes the staged files of previous flows which were never
+// committed.
+//
+// With the transaction_markers option, the pending commits also record the
+// number of rows of each transaction in the files they list, and manifests
+// carry the END markers of the transactions whose rows they commit.
+const (
+	cloudStorageStagingDir  = `_staging/`
+	cloudStoragePendingDir  = `_pending/`
+	cloudStorageManifestDir = `_manifests/`
+
+	cloudStorageManifestPartitionFormat = `2006-01-02/`
+)
+
+// cloudStorageManifest is the content of a manifest file.
+type cloudStorageManifest struct {
+	// Resolved is the resolved timestamp of the manifest.
+	Resolved string `json:"resolved"`
+	// Previous is the resolved timestamp of the previous manifest, if any.
+	Previous string `json:"previous,omitempty"`
+	// Files are the data files committed by the manifest, relative to the root
+	// of the sink.
+	Files []string `json:"files"`
+	// Transactions are the END markers of the transactions whose rows are
+	// committed by the manifest, in commit order.
+	Transactions []cloudStorageTxnMarker `json:"transactions,omitempty"`
+}
+
+// cloudStorageTxnMarker is the END marker of a transaction.
+type cloudStorageTxnMarker struct {
+	ID              string `json:"txn_id"`
+	CommitTimestamp string `json:"commit_timestamp"`
+	Rows            int64  `json:"rows"`
+}
+
+// cloudStoragePendingCommit is the content of a pending commit file, which
+// records the staged files written by a flush of a change aggregator.
+type cloudStoragePendingCommit struct {
+	Files []cloudStoragePendingFile `json:"files"`
+	Txns  []cloudStoragePendingTxn  `json:"txns,omitempty"`
+}
+
+type cloudStoragePendingFile struct {
+	// Label is the timestamp of the manifest which commits the file.
+	Label hlc.Timestamp `json:"label"`
+	Path  string        `json:"path"`
+}
+
+// cloudStoragePendingTxn is the number of rows of a transaction in the files
+// of a pending commit.
+type cloudStoragePendingTxn struct {
+	// Label is the timestamp of the manifest which commits the rows.
+	Label           hlc.Timestamp `json:"label"`
+	ID              string        `json:"id"`
+	CommitTimestamp hlc.Timestamp `json:"commit_timestamp"`
+	Rows            int64         `json:"rows"`
+}
+
+// cloudStorageBufferedRow is a row buffered by the exactly once sink until it
+// can be written out.
+type cloudStorageBufferedRow struct {
+	mvcc  hlc.Timestamp
+	value []byte
+	// txnID identifies the transaction of the row for transaction markers, if
+	// any.
+	txnID string
+	// size is the memory accounted for the row and its entry in the set of
+	// seen rows.
+	size int64
+}
+
+// cloudStorageRowID identifies a version of a row.
+type cloudStorageRowID struct {
+	topic string
+	key   string
+	mvcc  hlc.Timestamp
+}
+
+// cloudStorageExactlyOnce is the state of a cloud storage sink running with
+// the exactly_once option.
+type cloudStorageExactlyOnce struct {
+	flowID   execinfrapb.FlowID
+	interval time.Duration
+	// committed is the timestamp of the latest manifest, as of the creation of
+	// the sink for aggregators.
+	committed hlc.Timestamp
+	// flushed is the cut of the last flush of an aggregator.
+	flushed hlc.Timestamp
+	rows    map[cloudStorageSinkKey][]cloudStorageBufferedRow
+	seen    map[cloudStorageRowID]struct{}
+	// memAcc accounts for the buffered rows. It is unlimited until the change
+	// aggregator binds it to its memory monitor.
+	memAcc *mon.BoundAccount
+}
+
+// cloudStorageBufferedRowOverhead is the memory used by a buffered row and
+// its entry in the set of seen rows, besides the bytes of its key, value and
+// topic.
+const cloudStorageBufferedRowOverhead = int64(unsafe.Sizeof(cloudStorageBufferedRow{})) +
+	int64(unsafe.Sizeof(cloudStorageRowID{}))
+
+// schemaChangeBoundaryOracle is implemented by timestamp oracles which can tell
+// whether the frontier they track is at a schema change boundary.
+type schemaChangeBoundaryOracle interface {
+	atSchemaChangeBoundary() bool
+}
+
+// floorToInterval returns the largest multiple of interval not greater than
+// ts.
+func floorToInterval(ts hlc.Timestamp, interval time.Duration) hlc.Timestamp {
+	return hlc.Timestamp{WallTime: ts.WallTime - ts.WallTime%int64(interval)}
+}
+
+// ceilToInterval returns the smallest multiple of interval not less than ts.
+func ceilToInterval(ts hlc.Timestamp, interval time.Duration) hlc.Timestamp {
+	floor := floorToInterval(ts, interval)
+	if floor == ts {
+		return floor
+	}
+	return floor.AddDuration(interval)
+}
+
+// enableExactlyOnce switches the sink to exactly once delivery. Sinks of change
+// aggregators, which have a timestamp oracle, stage files and record them in
+// pending commits of the flow. The sink of the change frontier commits them
+// in manifests.
+func (s *cloudStorageSink) enableExactlyOnce(
+	ctx context.Context, flowID execinfrapb.FlowID, interval time.Duration,
+) error {
+	committed, err := s.latestManifest(ctx)
+	if err != nil {
+		return errors.Wrap(err, `reading latest manifest`)
+	}
+	s.exactlyOnce = &cloudStorageExactlyOnce{
+		flowID:    flowID,
+		interval:  interval,
+		committed: committed,
+		memAcc:    mon.NewStandaloneUnlimitedAccount(),
+	}
+	if s.timestampOracle != nil {
+		s.exactlyOnce.rows = make(map[cloudStorageSinkKey][]cloudStorageBufferedRow)
+		s.exactlyOnce.seen = make(map[cloudStorageRowID]struct{})
+		return nil
+	}
+	return s.removeUncommittedFiles(ctx)
+}
+
+// setExactlyOnceMemMonitor accounts for the rows buffered by the sink of a
+// change aggregator against mm.
+func (s *cloudStorageSink) setExactlyOnceMemMonitor(mm *mon.BytesMonitor) {
+	s.exactlyOnce.memAcc = mm.MakeBoundAccount()
+}
+
+// readJSONFile reads the named file and unmarshals it into v.
+func (s *cloudStorageSink) readJSONFile(ctx context.Context, name string, v interface{}) error {
+	r, _, err := s.es.ReadFile(ctx, name, cloud.ReadOptions{NoFileSize: true})
+	if err != nil {
+		return err
+	}
+	data, err := ioctx.ReadAll(ctx, r)
+	if closeErr := r.Close(ctx); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	return errors.Wrapf(json.Unmarshal(data, v), `parsing %s`, name)
+}
+
+// listNames lists the names under prefix, relative to it and without trailing
+// delimiters, in sorted order.
+func (s *cloudStorageSink) listNames(
+	ctx context.Context, prefix, delimiter string,
+) ([]string, error) {
+	var names []string
+	if err := s.es.List(ctx, prefix, delimiter, func(f string) error {
+		names = append(names, strings.Trim(f, `/`))
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// latestManifest returns the resolved timestamp of the latest manifest, or an
+// empty timestamp if no manifest was written yet. Manifests are partitioned by
+// day and named by their resolved timestamp, so the latest one is the last file
+// of the last partition.
+func (s *cloudStorageSink) latestManifest(ctx context.Context) (hlc.Timestamp, error) {
+	days, err := s.listNames(ctx, cloudStorageManifestDir, `/`)
+	if err != nil || len(days) == 0 {
+		return hlc.Timestamp{}, err
+	}
+	dir := cloudStorageManifestDir + days[len(days)-1] + `/`
+	manifests, err := s.listNames(ctx, dir, ``)
+	if err != nil || len(manifests) == 0 {
+		return hlc.Timestamp{}, err
+	}
+	var m cloudStorageManifest
+	if err := s.readJSONFile(ctx, dir+manifests[len(manifests)-1], &m); err != nil {
+		return hlc.Timestamp{}, err
+	}
+	return hlc.ParseHLC(m.Resolved)
+}
+
+// removeUncommittedFiles removes the pending commits left behind by previous
+// flows of the changefeed, along with the staged files they record which were
+// not committed by any manifest.
+func (s *cloudStorageSink) removeUncommittedFiles(ctx context.Context) error {
+	flows, err := s.listNames(ctx, cloudStoragePendingDir, `/`)
+	if err != nil {
+		return err
+	}
+	for _, flow := range flows {
+		if flow == s.exactlyOnce.flowID.String() {
+			continue
+		}
+		dir := cloudStoragePendingDir + flow + `/`
+		records, err := s.listNames(ctx, dir, ``)
+		if err != nil {
+			return err
+		}
+		for _, record := range records {
+			var pending cloudStoragePendingCommit
+			if err := s.readJSONFile(ctx, dir+record, &pending); err != nil {
+				return err
+			}
+			// Files at or below the latest manifest were committed, the rest never
+			// will be. Note that if the sink fails between deleting the files and
+			// the pending commit, files which were already deleted are ignored the
+			// next time around.
+			for _, f := range pending.Files {
+				if f.Label.LessEq(s.exactlyOnce.committed) {
+					continue
+				}
+				if err := s.es.Delete(ctx, f.Path); err != nil &&
+					!errors.Is(err, cloud.ErrFileDoesNotExist) {
+					return err
+				}
+			}
+			if err := s.es.Delete(ctx, dir+record); err != nil &&
+				!errors.Is(err, cloud.ErrFileDoesNotExist) {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// EmitTxnRow implements the txnRowSink interface.
+func (s *cloudStorageSink) EmitTxnRow(
+	ctx context.Context,
+	topic TopicDescriptor,
+	key, value []byte,
+	updated, mvcc hlc.Timestamp,
+	txnID string,
+	alloc kvevent.Alloc,
+) error {
+	if s.files == nil {
+		return errors.New(`cannot EmitRow on a closed sink`)
+	}
+	if s.exactlyOnce == nil {
+		return errors.AssertionFailedf(`%s requires %s`,
+			changefeedbase.OptTransactionMarkers, changefeedbase.OptExactlyOnce)
+	}
+	return s.bufferExactlyOnceRow(ctx, topic, key, value, mvcc, txnID, alloc)
+}
+
+var _ txnRowSink = (*cloudStorageSink)(nil)
+
+// bufferExactlyOnceRow buffers a row until the local frontier passes it.
+func (s *cloudStorageSink) bufferExactlyOnceRow(
+	ctx context.Context,
+	topic TopicDescriptor,
+	key, value []byte,
+	mvcc hlc.Timestamp,
+	txnID string,
+	alloc kvevent.Alloc,
+) error {
+	// Rows may be held for up to the manifest interval, which must not block
+	// the memory accounting of the event buffer, or the resolved events which
+	// let them be flushed might never be admitted. The rows are copied and
+	// accounted for separately, and their allocations released right away.
+	defer alloc.Release(ctx)
+
+	eo := s.exactlyOnce
+	if mvcc.LessEq(eo.committed) || mvcc.LessEq(eo.flushed) {
+		// The row was already written out, either before the changefeed restarted
+		// or by a previous flush.
+		return nil
+	}
+	name, _ := s.topicNamer.Name(topic)
+	id := cloudStorageRowID{topic: name, key: string(key), mvcc: mvcc}
+	if _, ok := eo.seen[id]; ok {
+		return nil
+	}
+
+	size := int64(len(key)+len(value)+len(name)+len(txnID)) + cloudStorageBufferedRowOverhead
+	if err := eo.memAcc.Grow(ctx, size); err != nil {
+		// Writing out the rows the local frontier has already passed may free
+		// up enough memory; the rest can only be written out once the frontier
+		// passes them.
+		if flushErr := s.flushExactlyOnce(ctx); flushErr != nil {
+			return errors.CombineErrors(err, flushErr)
+		}
+		if err := eo.memAcc.Grow(ctx, size); err != nil {
+			return errors.WithHintf(err,
+				`the rows of a changefeed with %s are buffered until the next manifest; `+
+					`consider a shorter %s interval`,
+				changefeedbase.OptExactlyOnce, changefeedbase.OptResolvedTimestamps)
+		}
+	}
+	eo.seen[id] = struct{}{}
+
+	s.metrics.recordMessageSize(int64(len(key) + len(value)))
+	k := cloudStorageSinkKey{topic: name, schemaID: int64(topic.GetVersion())}
+	eo.rows[k] = append(eo.rows[k], cloudStorageBufferedRow{
+		mvcc:  mvcc,
+		value: append([]byte(nil), value...),
+		txnID: txnID,
+		size:  size,
+	})
+	return nil
+}
+
+// exactlyOnceCut returns the timestamp up to which the rows buffered by an
+// aggregator can be written out.
+func (s *cloudStorageSink) exactlyOnceCut() hlc.Timestamp {
+	frontier := s.timestampOracle.inclusiveLowerBoundTS().Prev()
+	if o, ok := s.timestampOracle.(schemaChangeBoundaryOracle); ok && o.atSchemaChangeBoundary() {
+		return frontier
+	}
+	return floorToInterval(frontier, s.exactlyOnce.interval)
+}
+
+// flushExactlyOnce records the buffered rows up to the cut in a pending commit
+// and writes them to the staging files it lists.
+func (s *cloudStorageSink) flushExactlyOnce(ctx context.Context) error {
+	eo := s.exactlyOnce
+	cut := s.exactlyOnceCut()
+	if cut.LessEq(eo.flushed) {
+		return nil
+	}
+
+	type labeledKey struct {
+		label hlc.Timestamp
+		cloudStorageSinkKey
+	}
+	type txnKey struct {
+		label, mvcc hlc.Timestamp
+		id          string
+	}
+	groups := make(map[labeledKey][]cloudStorageBufferedRow)
+	txns := make(map[txnKey]int64)
+	var released int64
+	for k, rows := range eo.rows {
+		remaining := rows[:0]
+		for _, r := range rows {
+			if cut.Less(r.mvcc) {
+				remaining = append(remaining, r)
+				continue
+			}
+			released += r.size
+			label := ceilToInterval(r.mvcc, eo.interval)
+			if cut.Less(label) {
+				// Only happens at schema change boundaries, which are committed even
+				// if they are not a multiple of the interval.
+				label = cut
+			}
+			lk := labeledKey{label: label, cloudStorageSinkKey: k}
+			groups[lk] = append(groups[lk], r)
+			if r.txnID != "" {
+				txns[txnKey{label: label, mvcc: r.mvcc, id: r.txnID}]++
+			}
+		}
+		if len(remaining) == 0 {
+			delete(eo.rows, k)
+		} else {
+			eo.rows[k] = remaining
+		}
+	}
+	// The rows which were written out can no longer be seen again.
+	for id := range eo.seen {
+		if id.mvcc.LessEq(cut) {
+			delete(eo.seen, id)
+		}
+	}
+
+	keys := make([]labeledKey, 0, len(groups))
+	for lk := range groups {
+		keys = append(keys, lk)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].label != keys[j].label {
+			return keys[i].label.Less(keys[j].label)
+		}
+		return keyLess(keys[i].cloudStorageSinkKey, keys[j].cloudStorageSinkKey)
+	})
+
+	eo.flushed = cut
+	if len(keys) == 0 {
+		return nil
+	}
+
+	// The pending commit is written before the staging files it lists, so that
+	// the files can be found and removed if they are never committed.
+	var pending cloudStoragePendingCommit
+	for _, lk := range keys {
+		pending.Files = append(pending.Files, cloudStoragePendingFile{
+			Label: lk.label,
+			Path:  s.stagedFilePath(lk.label, lk.cloudStorageSinkKey),
+		})
+	}
+	for k, rows := range txns {
+		pending.Txns = append(pending.Txns, cloudStoragePendingTxn{
+			Label: k.label, ID: k.id, CommitTimestamp: k.mvcc, Rows: rows,
+		})
+	}
+	sort.Slice(pending.Txns, func(i, j int) bool {
+		return txnMarkerLess(pending.Txns[i], pending.Txns[j])
+	})
+	payload, err := json.Marshal(pending)
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf(`%s%s/%s-%s-%d-%d.json`, cloudStoragePendingDir, eo.flowID,
+		cloudStorageFormatTime(cut), s.jobSessionID, s.srcID, s.sinkID)
+	if err := cloud.WriteFile(ctx, s.es, name, bytes.NewReader(payload)); err != nil {
+		return err
+	}
+
+	for i, lk := range keys {
+		if err := s.writeStagedFile(ctx, pending.Files[i].Path, lk.cloudStorageSinkKey, groups[lk]); err != nil {
+			return err
+		}
+	}
+	eo.memAcc.Shrink(ctx, released)
+	return nil
+}
+
+// txnMarkerLess orders transactions by commit timestamp, and then by ID.
+func txnMarkerLess(a, b cloudStoragePendingTxn) bool {
+	if a.CommitTimestamp != b.CommitTimestamp {
+		return a.CommitTimestamp.Less(b.CommitTimestamp)
+	}
+	return a.ID < b.ID
+}
+
+// stagedFilePath returns the path of a new staging file for the rows of key
+// committed by the manifest at label.
+func (s *cloudStorageSink) stagedFilePath(label hlc.Timestamp, key cloudStorageSinkKey) string {
+	fileID := s.fileID
+	s.fileID++
+	filename := fmt.Sprintf(`%s-%s-%d-%d-%08x-%s-%x%s`, cloudStorageFormatTime(label),
+		s.jobSessionID, s.srcID, s.sinkID, fileID, key.topic, key.schemaID, s.ext)
+	return path.Join(cloudStorageStagingDir, label.GoTime().Format(s.partitionFormat), filename)
+}
+
+// writeStagedFile writes rows to the staging file at dest.
+func (s *cloudStorageSink) writeStagedFile(
+	ctx context.Context, dest string, key cloudStorageSinkKey, rows []cloudStorageBufferedRow,
+) error {
+	f := &cloudStorageSinkFile{
+		created:             timeutil.Now(),
+		cloudStorageSinkKey: key,
+		oldestMVCC:          rows[0].mvcc,
+		allocCallback:       s.metrics.makeCloudstorageFileAllocCallback(),
+	}
+	if s.compression.enabled() {
+		codec, err := newCompressionCodec(s.compression, &s.settings.SV, &f.buf)
+		if err != nil {
+			return err
+		}
+		f.codec = codec
+	}
+	for _, r := range rows {
+		if r.mvcc.Less(f.oldestMVCC) {
+			f.oldestMVCC = r.mvcc
+		}
+		if _, err := f.Write(r.value); err != nil {
+			return err
+		}
+		if _, err := f.Write(s.rowDelimiter); err != nil {
+			return err
+		}
+		f.numMessages++
+	}
+	return f.flushToStorage(ctx, s.es, dest, s.metrics)
+}
+
+// commitManifest writes the manifest at resolved, committing the staged files
+// of the flow with labels since the previous manifest.
+func (s *cloudStorageSink) commitManifest(ctx context.Context, resolved hlc.Timestamp) error {
+	eo := s.exactlyOnce
+	if resolved.LessEq(eo.committed) {
+		return nil
+	}
+
+	dir := fmt.Sprintf(`%s%s/`, cloudStoragePendingDir, eo.flowID)
+	records, err := s.listNames(ctx, dir, ``)
+	if err != nil {
+		return err
+	}
+	files := []string{}
+	txns := make(map[string]*cloudStoragePendingTxn)
+	var done []string
+	for _, record := range records {
+		var pending cloudStoragePendingCommit
+		if err := s.readJSONFile(ctx, dir+record, &pending); err != nil {
+			return err
+		}
+		allCommitted := true
+		for _, f := range pending.Files {
+			if resolved.Less(f.Label) {
+				allCommitted = false
+			} else if eo.committed.Less(f.Label) {
+				files = append(files, f.Path)
+			}
+		}
+		// The rows of a transaction can be spread over the pending commits of
+		// several aggregators, but are all committed by the same manifest.
+		for _, txn := range pending.Txns {
+			if resolved.Less(txn.Label) || txn.Label.LessEq(eo.committed) {
+				continue
+			}
+			if t, ok := txns[txn.ID]; ok {
+				t.Rows += txn.Rows
+			} else {
+				txn := txn
+				txns[txn.ID] = &txn
+			}
+		}
+		if allCommitted {
+			done = append(done, dir+record)
+		}
+	}
+	sort.Strings(files)
+
+	m := cloudStorageManifest{Resolved: resolved.AsOfSystemTime(), Files: files}
+	if len(txns) > 0 {
+		sorted := make([]cloudStoragePendingTxn, 0, len(txns))
+		for _, txn := range txns {
+			sorted = append(sorted, *txn)
+		}
+		sort.Slice(sorted, func(i, j int) bool { return txnMarkerLess(sorted[i], sorted[j]) })
+		for _, txn := range sorted {
+			m.Transactions = append(m.Transactions, cloudStorageTxnMarker{
+				ID:              txn.ID,
+				CommitTimestamp: txn.CommitTimestamp.AsOfSystemTime(),
+				Rows:            txn.Rows,
+			})
+		}
+	}
+	if !eo.committed.IsEmpty() {
+		m.Previous = eo.committed.AsOfSystemTime()
+	}
+	payload, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	name := cloudStorageManifestDir + resolved.GoTime().Format(cloudStorageManifestPartitionFormat) +
+		cloudStorageFormatTime(resolved) + `.json`
+	if log.V(1) {
+		log.Infof(ctx, "writing manifest %s with %d files", name, len(files))
+	}
+	if err := cloud.WriteFile(ctx, s.es, name, bytes.NewReader(payload)); err != nil {
+		return err
+	}
+	eo.committed = resolved
+
+	// Pending commits which were fully committed are no longer needed. Failing
+	// to delete them is harmless: their files are at or below the latest
+	// manifest, so they are never committed again.
+	for _, record := range done {
+		if err := s.es.Delete(ctx, record); err != nil {
+			log.Warningf(ctx, "failed to delete pending commit %s: %v", record, err)
+		}
+	}
+	return nil
+}
@@ -8,6 +8,7 @@ package changefeedccl
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
 	"math"
@@ -35,6 +36,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
 	"github.com/cockroachdb/cockroach/pkg/sql/catalog/tabledesc"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/testutils"
 	"github.com/cockroachdb/cockroach/pkg/testutils/skip"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
@@ -43,6 +45,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/randutil"
 	"github.com/cockroachdb/cockroach/pkg/util/span"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/cockroachdb/errors"
 	"github.com/klauspost/compress/gzip"
 	"github.com/stretchr/testify/require"
@@ -780,6 +783,223 @@ func TestCloudStorageSink(t *testing.T) {
 		}
 	})
 
+	t.Run(`exactly-once`, func(t *testing.T) {
+		t1 := makeTopic(`t1`)
+		const interval = 10 * time.Nanosecond
+		k1, k2 := []byte(`k1`), []byte(`k2`)
+
+		// makeSinks returns the sinks of the aggregator and the frontier of a flow.
+		makeSinks := func(sf span.Frontier) (agg, front *cloudStorageSink) {
+			flowID := execinfrapb.FlowID{UUID: uuid.MakeV4()}
+			var nilOracle timestampLowerBoundOracle
+			for _, oracle := range []timestampLowerBoundOracle{&changeAggregatorLowerBoundOracle{sf: sf}, nilOracle} {
+				s, err := makeCloudStorageSink(
+					ctx, sinkURI(t, unlimitedFileSize), 1, settings, opts,
+					oracle, externalStorageFromURI, user, nil, nil,
+				)
+				require.NoError(t, err)
+				cs := s.(*cloudStorageSink)
+				require.NoError(t, cs.enableExactlyOnce(ctx, flowID, interval))
+				if oracle == nil {
+					front = cs
+				} else {
+					agg = cs
+				}
+			}
+			return agg, front
+		}
+		readManifest := func(resolved int64) cloudStorageManifest {
+			data, err := os.ReadFile(filepath.Join(externalIODir, testDir(t), `_manifests`,
+				`1970-01-01`, cloudStorageFormatTime(ts(resolved))+`.json`))
+			require.NoError(t, err)
+			var m cloudStorageManifest
+			require.NoError(t, json.Unmarshal(data, &m))
+			return m
+		}
+		readFiles := func(files []string) (contents []string) {
+			for _, f := range files {
+				data, err := os.ReadFile(filepath.Join(externalIODir, testDir(t), f))
+				require.NoError(t, err)
+				contents = append(contents, string(data))
+			}
+			return contents
+		}
+		countStaged := func() (n int) {
+			root := filepath.Join(externalIODir, testDir(t), `_staging`)
+			require.NoError(t, filepath.Walk(root, func(_ string, info os.FileInfo, err error) error {
+				if err == nil && !info.IsDir() {
+					n++
+				}
+				return err
+			}))
+			return n
+		}
+
+		testSpan := roachpb.Span{Key: []byte("a"), EndKey: []byte("b")}
+		sf, err := span.MakeFrontier(testSpan)
+		require.NoError(t, err)
+		agg, front := makeSinks(sf)
+
+		// Duplicates are only written once, and only rows up to the last
+		// manifest timestamp the frontier passed are written out.
+		require.NoError(t, agg.EmitRow(ctx, t1, k1, []byte(`v1`), ts(3), ts(3), zeroAlloc))
+		require.NoError(t, agg.EmitRow(ctx, t1, k1, []byte(`v1`), ts(3), ts(3), zeroAlloc))
+		require.NoError(t, agg.EmitRow(ctx, t1, k2, []byte(`v2`), ts(12), ts(12), zeroAlloc))
+		require.NoError(t, agg.EmitRow(ctx, t1, k1, []byte(`v3`), ts(25), ts(25), zeroAlloc))
+		require.True(t, forwardFrontier(sf, testSpan, 15))
+		require.NoError(t, agg.Flush(ctx))
+		require.NoError(t, agg.EmitRow(ctx, t1, k1, []byte(`v1`), ts(3), ts(3), zeroAlloc))
+		require.NoError(t, front.EmitResolvedTimestamp(ctx, e, ts(10)))
+		m := readManifest(10)
+		require.Equal(t, `10.0000000000`, m.Resolved)
+		require.Equal(t, []string{"v1\n"}, readFiles(m.Files))
+
+		// Stage another file which is never committed, then restart the flow.
+		require.True(t, forwardFrontier(sf, testSpan, 22))
+		require.NoError(t, agg.Flush(ctx))
+		require.Equal(t, 2, countStaged())
+		require.NoError(t, agg.Close())
+		require.NoError(t, front.Close())
+
+		sf, err = span.MakeFrontier(testSpan)
+		require.NoError(t, err)
+		agg, front = makeSinks(sf)
+		defer func() {
+			require.NoError(t, agg.Close())
+			require.NoError(t, front.Close())
+		}()
+		// The uncommitted file of the previous flow was removed.
+		require.Equal(t, 1, countStaged())
+
+		// Replayed rows which were already committed are dropped.
+		require.NoError(t, agg.EmitRow(ctx, t1, k1, []byte(`v1`), ts(3), ts(3), zeroAlloc))
+		require.NoError(t, agg.EmitRow(ctx, t1, k2, []byte(`v2`), ts(12), ts(12), zeroAlloc))
+		require.NoError(t, agg.EmitRow(ctx, t1, k1, []byte(`v3`), ts(25), ts(25), zeroAlloc))
+		require.True(t, forwardFrontier(sf, testSpan, 30))
+		require.NoError(t, agg.Flush(ctx))
+		require.NoError(t, front.EmitResolvedTimestamp(ctx, e, ts(30)))
+		m = readManifest(30)
+		require.Equal(t, `10.0000000000`, m.Previous)
+		require.Equal(t, []string{"v2\n", "v3\n"}, readFiles(m.Files))
+		require.Equal(t, 3, countStaged())
+	})
+
+	t.Run(`exactly-once-memory-budget`, func(t *testing.T) {
+		t1 := makeTopic(`t1`)
+		const interval = 10 * time.Nanosecond
+		value := bytes.Repeat([]byte(`v`), 1000)
+
+		testSpan := roachpb.Span{Key: []byte("a"), EndKey: []byte("b")}
+		sf, err := span.MakeFrontier(testSpan)
+		require.NoError(t, err)
+		s, err := makeCloudStorageSink(
+			ctx, sinkURI(t, unlimitedFileSize), 1, settings, opts,
+			&changeAggregatorLowerBoundOracle{sf: sf}, externalStorageFromURI, user, nil, nil,
+		)
+		require.NoError(t, err)
+		agg := s.(*cloudStorageSink)
+		require.NoError(t, agg.enableExactlyOnce(ctx, execinfrapb.FlowID{UUID: uuid.MakeV4()}, interval))
+		mm := startMonitorWithBudget(4 << 10)
+		defer mm.Stop(ctx)
+		agg.setExactlyOnceMemMonitor(mm)
+		defer func() { require.NoError(t, agg.Close()) }()
+
+		emit := func(i int, mvcc int64) error {
+			key := []byte(fmt.Sprintf(`k%d`, i))
+			return agg.EmitRow(ctx, t1, key, value, ts(mvcc), ts(mvcc), zeroAlloc)
+		}
+
+		// Rows the frontier has passed are written out when the budget runs out.
+		require.True(t, forwardFrontier(sf, testSpan, 15))
+		for i := 0; i < 3; i++ {
+			require.NoError(t, emit(i, 3))
+		}
+		require.NoError(t, emit(3, 8))
+		require.Len(t, agg.exactlyOnce.seen, 1)
+
+		// Rows the frontier has yet to pass can't be written out.
+		require.NoError(t, emit(4, 25))
+		require.NoError(t, emit(5, 26))
+		require.Regexp(t, `memory budget exceeded`, emit(6, 27))
+	})
+
+	t.Run(`exactly-once-transaction-markers`, func(t *testing.T) {
+		t1 := makeTopic(`t1`)
+		const interval = 10 * time.Nanosecond
+		flowID := execinfrapb.FlowID{UUID: uuid.MakeV4()}
+
+		makeSink := func(oracle timestampLowerBoundOracle) *cloudStorageSink {
+			s, err := makeCloudStorageSink(
+				ctx, sinkURI(t, unlimitedFileSize), 1, settings, opts,
+				oracle, externalStorageFromURI, user, nil, nil,
+			)
+			require.NoError(t, err)
+			cs := s.(*cloudStorageSink)
+			require.NoError(t, cs.enableExactlyOnce(ctx, flowID, interval))
+			return cs
+		}
+		readTxns := func(resolved int64) []cloudStorageTxnMarker {
+			data, err := os.ReadFile(filepath.Join(externalIODir, testDir(t), `_manifests`,
+				`1970-01-01`, cloudStorageFormatTime(ts(resolved))+`.json`))
+			require.NoError(t, err)
+			var m cloudStorageManifest
+			require.NoError(t, json.Unmarshal(data, &m))
+			return m.Transactions
+		}
+
+		// The rows of a transaction are spread over two aggregators.
+		span1 := roachpb.Span{Key: []byte("a"), EndKey: []byte("b")}
+		span2 := roachpb.Span{Key: []byte("b"), EndKey: []byte("c")}
+		sf1, err := span.MakeFrontier(span1)
+		require.NoError(t, err)
+		sf2, err := span.MakeFrontier(span2)
+		require.NoError(t, err)
+		var nilOracle timestampLowerBoundOracle
+		agg1 := makeSink(&changeAggregatorLowerBoundOracle{sf: sf1})
+		agg2 := makeSink(&changeAggregatorLowerBoundOracle{sf: sf2})
+		front := makeSink(nilOracle)
+		defer func() {
+			require.NoError(t, agg1.Close())
+			require.NoError(t, agg2.Close())
+			require.NoError(t, front.Close())
+		}()
+
+		emit := func(s *cloudStorageSink, key string, mvcc int64, txnID string) {
+			require.NoError(t, s.EmitTxnRow(ctx, t1, []byte(key), []byte(key), ts(mvcc), ts(mvcc), txnID, zeroAlloc))
+		}
+		const txnA, txnB = `txn-a`, `txn-b`
+		emit(agg1, `k1`, 3, txnA)
+		emit(agg1, `k2`, 3, txnA)
+		// Duplicates are only counted once.
+		emit(agg1, `k1`, 3, txnA)
+		emit(agg2, `k3`, 3, txnA)
+		// Rows identified by their commit timestamp are counted the same way, and
+		// rows which aren't part of a transaction are not counted.
+		emit(agg2, `k4`, 5, `5.0000000000`)
+		emit(agg2, `k5`, 6, ``)
+		emit(agg1, `k6`, 12, txnB)
+
+		require.True(t, forwardFrontier(sf1, span1, 15))
+		require.True(t, forwardFrontier(sf2, span2, 15))
+		require.NoError(t, agg1.Flush(ctx))
+		require.NoError(t, agg2.Flush(ctx))
+		require.NoError(t, front.EmitResolvedTimestamp(ctx, e, ts(10)))
+		require.Equal(t, []cloudStorageTxnMarker{
+			{ID: txnA, CommitTimestamp: `3.0000000000`, Rows: 3},
+			{ID: `5.0000000000`, CommitTimestamp: `5.0000000000`, Rows: 1},
+		}, readTxns(10))
+
+		// The second transaction is committed by the next manifest.
+		require.True(t, forwardFrontier(sf1, span1, 25))
+		require.True(t, forwardFrontier(sf2, span2, 25))
+		require.NoError(t, agg1.Flush(ctx))
+		require.NoError(t, agg2.Flush(ctx))
+		require.NoError(t, front.EmitResolvedTimestamp(ctx, e, ts(20)))
+		require.Equal(t, []cloudStorageTxnMarker{
+			{ID: txnB, CommitTimestamp: `12.0000000000`, Rows: 1},
+		}, readTxns(20))
+	})
+
 	// Verify no goroutines leaked when using compression with context cancellation.
 	testWithAndWithoutAsyncFlushing(t, `no goroutine leaks when context canceled`, func(t *testing.T) {
 		before := opts.Compression
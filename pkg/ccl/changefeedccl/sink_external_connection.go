@@ -15,6 +15,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/security/username"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/isql"
 	"github.com/cockroachdb/errors"
 )
@@ -29,6 +30,7 @@ func makeExternalConnectionSink(
 	feedCfg jobspb.ChangefeedDetails,
 	timestampOracle timestampLowerBoundOracle,
 	jobID jobspb.JobID,
+	flowID execinfrapb.FlowID,
 	m metricsRecorder,
 ) (Sink, error) {
 	if u.Host == "" {
@@ -45,7 +47,7 @@ func makeExternalConnectionSink(
 	// Replace the external connection URI in the `feedCfg` with the URI of the
 	// underlying resource.
 	feedCfg.SinkURI = uri
-	return getSink(ctx, serverCfg, feedCfg, timestampOracle, user, jobID, m)
+	return getSink(ctx, serverCfg, feedCfg, timestampOracle, user, jobID, flowID, m)
 }
 
 func validateExternalConnectionSinkURI(
@@ -77,7 +79,7 @@ func validateExternalConnectionSinkURI(
 	// TODO(adityamaru): When we add `CREATE EXTERNAL CONNECTION ... WITH` support
 	// to accept JSONConfig we should validate that here too.
 	s, err := getSink(ctx, serverCfg, jobspb.ChangefeedDetails{SinkURI: uri}, nil, env.Username,
-		jobspb.JobID(0), (*sliMetrics)(nil))
+		jobspb.JobID(0), execinfrapb.FlowID{}, (*sliMetrics)(nil))
 	if err != nil {
 		return errors.Wrap(err, "invalid changefeed sink URI")
 	}
@@ -103,6 +105,8 @@ var supportedExternalConnectionTypes = map[string]connectionpb.ConnectionProvide
 	changefeedbase.SinkSchemeWebhookHTTPS:          connectionpb.ConnectionProvider_webhookhttps,
 	changefeedbase.SinkSchemeConfluentKafka:        connectionpb.ConnectionProvider_kafka,
 	changefeedbase.SinkSchemeAzureKafka:            connectionpb.ConnectionProvider_kafka,
+	changefeedbase.SinkSchemeNATS:                  connectionpb.ConnectionProvider_nats,
+	changefeedbase.SinkSchemeMQTT:                  connectionpb.ConnectionProvider_mqtt,
 	// TODO (zinger): Not including SinkSchemeExperimentalSQL for now because A: it's undocumented
 	// and B, in tests it leaks a *gosql.DB and I can't figure out why.
 }
@@ -129,6 +133,8 @@ func init() {
 		changefeedbase.SinkParamClientKey,
 		changefeedbase.SinkParamConfluentAPISecret,
 		changefeedbase.SinkParamAzureAccessKey,
+		changefeedbase.SinkParamNATSToken,
+		changefeedbase.SinkParamNATSCredentials,
 	))
 }
 
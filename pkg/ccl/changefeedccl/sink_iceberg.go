@@ -0,0 +1,642 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package changefeedccl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdcevent"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/iceberg"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/kvevent"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/ioctx"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/parquet"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
+	"github.com/lib/pq/oid"
+)
+
+// The iceberg sink writes every table of a changefeed to an Iceberg table of
+// the same name, and commits snapshots of the tables at resolved timestamps.
+//
+// Change aggregators buffer the last version of every row they emit. When they
+// flush, they write a Parquet data file holding the live rows, and an equality
+// delete file holding the primary keys of all the rows, which deletes their
+// previous versions. The files are not visible to readers until they are
+// committed: aggregators record them in a pending commit file, which is only
+// written once the data files are durable. The change frontier emits resolved
+// timestamps once all aggregators flushed the rows below them, so when it
+// does, the sink of the frontier commits all the pending commit files of every
+// table in a single atomic catalog update, and deletes them.
+//
+// Every pending commit file becomes a snapshot, so that the deletes of a file
+// apply to the rows of the files written before it by the same aggregator, or
+// by the aggregators of previous runs of the changefeed. The snapshot
+// committed at a resolved timestamp contains every change up to that
+// timestamp, as well as some changes above it. The names of the pending commit
+// files committed by a snapshot are recorded in its summary, so that they
+// aren't committed again if the sink fails before deleting them.
+
+// icebergTargetFileSize is the size of buffered rows above which the rows of a
+// table are flushed.
+const icebergTargetFileSize = 16 << 20
+
+const (
+	icebergDataDir    = `data/`
+	icebergPendingDir = `pending/`
+
+	// icebergSummaryResolved is the snapshot summary property holding the
+	// resolved timestamp of the changefeed at which the snapshot was committed.
+	icebergSummaryResolved = `crdb.resolved`
+	// icebergSummaryCommitted is the snapshot summary property holding the
+	// names of the pending commit files committed by the snapshot.
+	icebergSummaryCommitted = `crdb.committed-pending`
+)
+
+func isIcebergSink(u *url.URL) bool {
+	return u.Scheme == changefeedbase.SinkSchemeIceberg
+}
+
+// sanitizeIcebergSinkURI redacts the credentials of the storage URIs nested in
+// the URI of an iceberg sink.
+func sanitizeIcebergSinkURI(sinkURI string) (string, error) {
+	u, err := url.Parse(sinkURI)
+	if err != nil {
+		return "", err
+	}
+	if !isIcebergSink(u) {
+		return sinkURI, nil
+	}
+	q := u.Query()
+	for _, p := range []string{changefeedbase.SinkParamIcebergCatalogURI, changefeedbase.SinkParamIcebergDataURI} {
+		if v := q.Get(p); v != `` {
+			sanitized, err := cloud.SanitizeExternalStorageURI(v, nil /* extraParams */)
+			if err != nil {
+				return "", err
+			}
+			q.Set(p, sanitized)
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
+
+// icebergPendingCommit is the content of a pending commit file.
+type icebergPendingCommit struct {
+	Columns []iceberg.Column
+	Files   []iceberg.DataFile
+}
+
+type icebergRow struct {
+	key tree.Datums
+	// datums is nil for deleted rows.
+	datums tree.Datums
+}
+
+// icebergTableBuffer buffers the rows of a table emitted since the last flush.
+type icebergTableBuffer struct {
+	version descpb.DescriptorVersion
+	cols    []iceberg.Column
+	// types are the types of the columns of data files, and keyTypes the types
+	// of the columns of delete files.
+	types, keyTypes []*types.T
+	keyNames        []string
+	// rows is keyed by the string representation of the primary key.
+	rows  map[string]icebergRow
+	alloc kvevent.Alloc
+}
+
+type icebergSink struct {
+	catalogStorage cloud.ExternalStorage
+	catalog        iceberg.Catalog
+	namespace      string
+	// dataURI is the URI of the storage in which tables are stored, in a
+	// directory per table.
+	dataURI     *url.URL
+	makeStorage func(ctx context.Context, uri string) (cloud.ExternalStorage, error)
+	topicNamer  *TopicNamer
+	compression parquet.CompressionCodec
+	metrics     metricsRecorder
+
+	// pendingPrefix prefixes the names of the pending commit files of the
+	// sink. It orders them after the files of sinks created before it, and
+	// seq orders the files of the sink.
+	pendingPrefix string
+	seq           int64
+
+	storages map[string]cloud.ExternalStorage
+	buffers  map[string]*icebergTableBuffer
+	tables   map[string]*iceberg.Table
+}
+
+var _ SinkWithEncoder = (*icebergSink)(nil)
+
+func makeIcebergSink(
+	ctx context.Context,
+	u sinkURL,
+	encodingOpts changefeedbase.EncodingOptions,
+	targets changefeedbase.Targets,
+	makeExternalStorageFromURI cloud.ExternalStorageFromURIFactory,
+	user username.SQLUsername,
+	mb metricsRecorderBuilder,
+) (Sink, error) {
+	if encodingOpts.Format != changefeedbase.OptFormatParquet {
+		return nil, errors.Errorf(`this sink requires %s=%s`,
+			changefeedbase.OptFormat, changefeedbase.OptFormatParquet)
+	}
+	switch {
+	case encodingOpts.UpdatedTimestamps:
+		return nil, errors.Errorf(`this sink is incompatible with %s`, changefeedbase.OptUpdatedTimestamps)
+	case encodingOpts.MVCCTimestamps:
+		return nil, errors.Errorf(`this sink is incompatible with %s`, changefeedbase.OptMVCCTimestamps)
+	case encodingOpts.Diff:
+		return nil, errors.Errorf(`this sink is incompatible with %s`, changefeedbase.OptDiff)
+	}
+
+	catalogURI := u.consumeParam(changefeedbase.SinkParamIcebergCatalogURI)
+	if catalogURI == `` {
+		return nil, errors.Errorf(`%s is required`, changefeedbase.SinkParamIcebergCatalogURI)
+	}
+	dataURI := u.consumeParam(changefeedbase.SinkParamIcebergDataURI)
+	if dataURI == `` {
+		return nil, errors.Errorf(`%s is required`, changefeedbase.SinkParamIcebergDataURI)
+	}
+	namespace := u.consumeParam(changefeedbase.SinkParamIcebergNamespace)
+	if namespace == `` {
+		namespace = `default`
+	}
+	if unknownParams := u.remainingQueryParams(); len(unknownParams) > 0 {
+		return nil, errors.Errorf(
+			`unknown iceberg sink query parameters: %s`, strings.Join(unknownParams, ", "))
+	}
+	parsedDataURI, err := url.Parse(dataURI)
+	if err != nil {
+		return nil, errors.Wrapf(err, `parsing %s`, changefeedbase.SinkParamIcebergDataURI)
+	}
+
+	compression := parquet.CompressionNone
+	if codec := encodingOpts.Compression; codec != `` {
+		algo, _, err := compressionFromString(codec)
+		if err != nil {
+			return nil, err
+		}
+		switch algo {
+		case sinkCompressionGzip:
+			compression = parquet.CompressionGZIP
+		case sinkCompressionZstd:
+			compression = parquet.CompressionZSTD
+		}
+	}
+
+	topicNamer, err := MakeTopicNamer(targets)
+	if err != nil {
+		return nil, err
+	}
+
+	makeStorage := func(ctx context.Context, uri string) (cloud.ExternalStorage, error) {
+		// We make the external storage with a nil IOAccountingInterceptor since
+		// we record usage metrics via s.metrics.
+		return makeExternalStorageFromURI(ctx, uri, user,
+			cloud.WithIOAccountingInterceptor(nil), cloud.WithClientName("cdc"))
+	}
+	catalogStorage, err := makeStorage(ctx, catalogURI)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &icebergSink{
+		catalogStorage: catalogStorage,
+		catalog:        iceberg.NewFilesystemCatalog(catalogStorage),
+		namespace:      namespace,
+		dataURI:        parsedDataURI,
+		makeStorage:    makeStorage,
+		topicNamer:     topicNamer,
+		compression:    compression,
+		pendingPrefix: fmt.Sprintf(`%s%020d-%s`,
+			icebergPendingDir, timeutil.Now().UnixNano(), uuid.MakeV4()),
+		storages: make(map[string]cloud.ExternalStorage),
+		buffers:  make(map[string]*icebergTableBuffer),
+		tables:   make(map[string]*iceberg.Table),
+	}
+	if mb != nil {
+		s.metrics = mb(catalogStorage.RequiresExternalIOAccounting())
+	} else {
+		s.metrics = (*sliMetrics)(nil)
+	}
+	return s, nil
+}
+
+// getConcreteType implements the Sink interface.
+func (s *icebergSink) getConcreteType() sinkType {
+	return sinkTypeIceberg
+}
+
+// Dial implements the Sink interface.
+func (s *icebergSink) Dial() error {
+	return nil
+}
+
+// tableLocation returns the location of a table, which is the data URI without
+// its query parameters, which may hold credentials.
+func (s *icebergSink) tableLocation(name string) string {
+	u := *s.dataURI
+	u.Path = path.Join(u.Path, s.namespace, name)
+	u.RawQuery = ``
+	return u.String()
+}
+
+// tableStorage returns the storage rooted at the location of a table.
+func (s *icebergSink) tableStorage(ctx context.Context, name string) (cloud.ExternalStorage, error) {
+	if es, ok := s.storages[name]; ok {
+		return es, nil
+	}
+	u := *s.dataURI
+	u.Path = path.Join(u.Path, s.namespace, name)
+	es, err := s.makeStorage(ctx, u.String())
+	if err != nil {
+		return nil, err
+	}
+	s.storages[name] = es
+	return es, nil
+}
+
+// EmitRow does not do anything. It must not be called. It is present so that
+// icebergSink implements the Sink interface.
+func (s *icebergSink) EmitRow(
+	ctx context.Context,
+	topic TopicDescriptor,
+	key, value []byte,
+	updated, mvcc hlc.Timestamp,
+	alloc kvevent.Alloc,
+) error {
+	return errors.AssertionFailedf("EmitRow unimplemented by the iceberg sink")
+}
+
+// icebergType returns the Iceberg type of a column, and the type it's written
+// as in Parquet files. Columns whose type has no Iceberg equivalent written by
+// the parquet writer are written as strings.
+func icebergType(typ *types.T) (string, *types.T) {
+	switch typ.Family() {
+	case types.BoolFamily:
+		return iceberg.TypeBoolean, typ
+	case types.IntFamily:
+		if typ.Oid() == oid.T_int8 {
+			return iceberg.TypeLong, typ
+		}
+		return iceberg.TypeInt, typ
+	case types.FloatFamily:
+		if typ.Oid() == oid.T_float4 {
+			return iceberg.TypeFloat, typ
+		}
+		return iceberg.TypeDouble, typ
+	case types.StringFamily:
+		return iceberg.TypeString, typ
+	case types.BytesFamily:
+		return iceberg.TypeBinary, typ
+	case types.UuidFamily:
+		return iceberg.TypeUUID, typ
+	case types.TimeFamily:
+		return iceberg.TypeTime, typ
+	default:
+		return iceberg.TypeString, types.String
+	}
+}
+
+// icebergDatum converts a datum to the type it's written as.
+func icebergDatum(d tree.Datum, typ *types.T) tree.Datum {
+	if d == tree.DNull || typ.Family() != types.StringFamily || d.ResolvedType().Family() == types.StringFamily {
+		return d
+	}
+	return tree.NewDString(tree.AsStringWithFlags(d, tree.FmtExport))
+}
+
+func makeIcebergTableBuffer(row cdcevent.Row) (*icebergTableBuffer, error) {
+	b := &icebergTableBuffer{
+		version: row.Version,
+		rows:    make(map[string]icebergRow),
+	}
+	keys := make(map[string]struct{})
+	if err := row.ForEachKeyColumn().Col(func(col cdcevent.ResultColumn) error {
+		keys[col.Name] = struct{}{}
+		_, typ := icebergType(col.Typ)
+		b.keyNames = append(b.keyNames, col.Name)
+		b.keyTypes = append(b.keyTypes, typ)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	if err := row.ForAllColumns().Col(func(col cdcevent.ResultColumn) error {
+		icebergTyp, typ := icebergType(col.Typ)
+		_, key := keys[col.Name]
+		b.cols = append(b.cols, iceberg.Column{Name: col.Name, Type: icebergTyp, Key: key})
+		b.types = append(b.types, typ)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// EncodeAndEmitRow implements the SinkWithEncoder interface.
+func (s *icebergSink) EncodeAndEmitRow(
+	ctx context.Context,
+	updatedRow cdcevent.Row,
+	prevRow cdcevent.Row,
+	topic TopicDescriptor,
+	updated, mvcc hlc.Timestamp,
+	encodingOpts changefeedbase.EncodingOptions,
+	alloc kvevent.Alloc,
+) error {
+	if s.buffers == nil {
+		return errors.New(`cannot EmitRow on a closed sink`)
+	}
+	name, err := s.topicNamer.Name(topic)
+	if err != nil {
+		return err
+	}
+	b := s.buffers[name]
+	if b != nil && b.version != updatedRow.Version {
+		// Files hold the columns of a single version of the table.
+		if err := s.flushTable(ctx, name, b); err != nil {
+			return err
+		}
+		b = nil
+	}
+	if b == nil {
+		if b, err = makeIcebergTableBuffer(updatedRow); err != nil {
+			return err
+		}
+		s.buffers[name] = b
+	}
+
+	r := icebergRow{key: make(tree.Datums, 0, len(b.keyTypes))}
+	if err := updatedRow.ForEachKeyColumn().Datum(func(d tree.Datum, _ cdcevent.ResultColumn) error {
+		r.key = append(r.key, icebergDatum(d, b.keyTypes[len(r.key)]))
+		return nil
+	}); err != nil {
+		return err
+	}
+	if !updatedRow.IsDeleted() {
+		r.datums = make(tree.Datums, 0, len(b.types))
+		if err := updatedRow.ForAllColumns().Datum(func(d tree.Datum, _ cdcevent.ResultColumn) error {
+			r.datums = append(r.datums, icebergDatum(d, b.types[len(r.datums)]))
+			return nil
+		}); err != nil {
+			return err
+		}
+	}
+	// Only the last version of a row is written.
+	b.rows[tree.AsString(&r.key)] = r
+	size := int(alloc.Bytes())
+	b.alloc.Merge(&alloc)
+	s.metrics.recordOneMessage()(mvcc, size, size)
+
+	if b.alloc.Bytes() > icebergTargetFileSize {
+		s.metrics.recordSizeBasedFlush()
+		return s.flushTable(ctx, name, b)
+	}
+	return nil
+}
+
+// writeParquetFile writes rows to a Parquet file of a table.
+func (s *icebergSink) writeParquetFile(
+	ctx context.Context,
+	es cloud.ExternalStorage,
+	filename string,
+	names []string,
+	typs []*types.T,
+	rows []tree.Datums,
+) (int64, error) {
+	sch, err := parquet.NewSchema(names, typs)
+	if err != nil {
+		return 0, err
+	}
+	opts := []parquet.Option{parquet.WithCompressionCodec(s.compression)}
+	if includeParquestTestMetadata {
+		opts = append(opts, parquet.WithMetadata(parquet.MakeReaderMetadata(sch)))
+	}
+	var buf bytes.Buffer
+	w, err := parquet.NewWriter(sch, &buf, opts...)
+	if err != nil {
+		return 0, err
+	}
+	for _, r := range rows {
+		if err := w.AddRow(r); err != nil {
+			return 0, err
+		}
+	}
+	if err := w.Close(); err != nil {
+		return 0, err
+	}
+	size := int64(buf.Len())
+	if log.V(1) {
+		log.Infof(ctx, "writing file %s", filename)
+	}
+	return size, cloud.WriteFile(ctx, es, filename, &buf)
+}
+
+// flushTable writes the buffered rows of a table to a data file and a delete
+// file, and records them in a pending commit file.
+func (s *icebergSink) flushTable(ctx context.Context, name string, b *icebergTableBuffer) error {
+	defer delete(s.buffers, name)
+	defer b.alloc.Release(ctx)
+	if len(b.rows) == 0 {
+		return nil
+	}
+	es, err := s.tableStorage(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, len(b.cols))
+	for i, c := range b.cols {
+		names[i] = c.Name
+	}
+	var live, keys []tree.Datums
+	for _, r := range b.rows {
+		keys = append(keys, r.key)
+		if r.datums != nil {
+			live = append(live, r.datums)
+		}
+	}
+
+	id := uuid.MakeV4()
+	var pending icebergPendingCommit
+	pending.Columns = b.cols
+	if len(live) > 0 {
+		file := iceberg.DataFile{
+			Content:     iceberg.ContentData,
+			Path:        fmt.Sprintf(`%s%s.parquet`, icebergDataDir, id),
+			RecordCount: int64(len(live)),
+		}
+		if file.FileSizeInBytes, err = s.writeParquetFile(ctx, es, file.Path, names, b.types, live); err != nil {
+			return err
+		}
+		pending.Files = append(pending.Files, file)
+	}
+	deletes := iceberg.DataFile{
+		Content:         iceberg.ContentEqualityDeletes,
+		Path:            fmt.Sprintf(`%s%s-deletes.parquet`, icebergDataDir, id),
+		RecordCount:     int64(len(keys)),
+		EqualityColumns: b.keyNames,
+	}
+	if deletes.FileSizeInBytes, err = s.writeParquetFile(ctx, es, deletes.Path, b.keyNames, b.keyTypes, keys); err != nil {
+		return err
+	}
+	pending.Files = append(pending.Files, deletes)
+
+	data, err := json.Marshal(pending)
+	if err != nil {
+		return err
+	}
+	s.seq++
+	return cloud.WriteFile(ctx, es, fmt.Sprintf(`%s-%020d.json`, s.pendingPrefix, s.seq), bytes.NewReader(data))
+}
+
+// Flush implements the Sink interface.
+func (s *icebergSink) Flush(ctx context.Context) error {
+	if s.buffers == nil {
+		return errors.New(`cannot Flush on a closed sink`)
+	}
+	s.metrics.recordFlushRequestCallback()()
+
+	names := make([]string, 0, len(s.buffers))
+	for name := range s.buffers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := s.flushTable(ctx, name, s.buffers[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EmitResolvedTimestamp commits the pending files of every table.
+func (s *icebergSink) EmitResolvedTimestamp(
+	ctx context.Context, _ Encoder, resolved hlc.Timestamp,
+) error {
+	if s.buffers == nil {
+		return errors.New(`cannot EmitResolvedTimestamp on a closed sink`)
+	}
+	defer s.metrics.recordResolvedCallback()()
+
+	for _, name := range s.topicNamer.DisplayNamesSlice() {
+		if err := s.commitTable(ctx, name, resolved); err != nil {
+			return errors.Wrapf(err, `committing table %s`, name)
+		}
+	}
+	return nil
+}
+
+// commitTable commits the pending files of a table.
+func (s *icebergSink) commitTable(ctx context.Context, name string, resolved hlc.Timestamp) error {
+	es, err := s.tableStorage(ctx, name)
+	if err != nil {
+		return err
+	}
+	var pendingFiles []string
+	if err := es.List(ctx, icebergPendingDir, ``, func(f string) error {
+		pendingFiles = append(pendingFiles, icebergPendingDir+strings.TrimPrefix(f, `/`))
+		return nil
+	}); err != nil {
+		return err
+	}
+	if len(pendingFiles) == 0 {
+		return nil
+	}
+	sort.Strings(pendingFiles)
+
+	tbl, ok := s.tables[name]
+	if !ok {
+		id := iceberg.Identifier{Namespace: s.namespace, Name: name}
+		if tbl, err = iceberg.LoadTable(ctx, s.catalog, id, es, s.tableLocation(name)); err != nil {
+			return err
+		}
+		s.tables[name] = tbl
+	}
+
+	// Skip the files committed by the current snapshot, which the sink failed to
+	// delete.
+	committed := make(map[string]struct{})
+	if snap := tbl.Metadata().CurrentSnapshot(); snap != nil {
+		for _, f := range strings.Split(snap.Summary[icebergSummaryCommitted], `,`) {
+			committed[f] = struct{}{}
+		}
+	}
+	var changes []iceberg.Change
+	var toCommit []string
+	for _, f := range pendingFiles {
+		if _, ok := committed[f]; ok {
+			continue
+		}
+		r, _, err := es.ReadFile(ctx, f, cloud.ReadOptions{NoFileSize: true})
+		if err != nil {
+			return err
+		}
+		data, err := ioctx.ReadAll(ctx, r)
+		if closeErr := r.Close(ctx); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+		var pending icebergPendingCommit
+		if err := json.Unmarshal(data, &pending); err != nil {
+			return errors.Wrapf(err, `parsing %s`, f)
+		}
+		changes = append(changes, iceberg.Change{Columns: pending.Columns, Files: pending.Files})
+		toCommit = append(toCommit, f)
+	}
+
+	if len(changes) > 0 {
+		if err := tbl.Commit(ctx, changes, map[string]string{
+			icebergSummaryResolved:  resolved.AsOfSystemTime(),
+			icebergSummaryCommitted: strings.Join(toCommit, `,`),
+		}); err != nil {
+			return err
+		}
+		if log.V(1) {
+			log.Infof(ctx, "committed %d changes to table %s at %s", len(changes), name, resolved)
+		}
+	}
+	for _, f := range pendingFiles {
+		if err := es.Delete(ctx, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close implements the Sink interface.
+func (s *icebergSink) Close() error {
+	for _, b := range s.buffers {
+		b.alloc.Release(context.Background())
+	}
+	s.buffers = nil
+	var err error
+	for _, es := range s.storages {
+		err = errors.CombineErrors(err, es.Close())
+	}
+	s.storages = nil
+	return errors.CombineErrors(err, s.catalogStorage.Close())
+}
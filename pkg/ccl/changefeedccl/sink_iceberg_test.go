@@ -0,0 +1,189 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package changefeedccl
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/blobs"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdcevent"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/iceberg"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/kvevent"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/parquet"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIcebergSink(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+
+	externalIODir, dirCleanupFn := testutils.TempDir(t)
+	defer dirCleanupFn()
+	settings := cluster.MakeTestingClusterSettings()
+	settings.ExternalIODir = externalIODir
+	clientFactory := blobs.TestBlobServiceClient(settings.ExternalIODir)
+	externalStorageFromURI := func(
+		ctx context.Context, uri string, user username.SQLUsername, opts ...cloud.ExternalStorageOption,
+	) (cloud.ExternalStorage, error) {
+		return cloud.ExternalStorageFromURI(ctx, uri, base.ExternalIODirConfig{}, settings,
+			clientFactory, user, nil /* db */, nil /* limiters */, cloud.NilMetrics, opts...)
+	}
+	user := username.RootUserName()
+
+	tableDesc, err := parseTableDesc(`CREATE TABLE foo (a INT PRIMARY KEY, b STRING)`)
+	require.NoError(t, err)
+	targets := mkTargets(tableDesc)
+	topic := &tableDescriptorTopic{Metadata: makeMetadata(tableDesc), spec: changefeedbase.Target{
+		Type:              jobspb.ChangefeedTargetSpecification_PRIMARY_FAMILY_ONLY,
+		TableID:           tableDesc.GetID(),
+		StatementTimeName: changefeedbase.StatementTimeName(tableDesc.GetName()),
+	}}
+
+	sinkURI := url.URL{Scheme: changefeedbase.SinkSchemeIceberg, RawQuery: url.Values{
+		changefeedbase.SinkParamIcebergCatalogURI: {`nodelocal://1/catalog`},
+		changefeedbase.SinkParamIcebergDataURI:    {`nodelocal://1/warehouse`},
+		changefeedbase.SinkParamIcebergNamespace:  {`db`},
+	}.Encode()}
+	opts := changefeedbase.EncodingOptions{
+		Format:   changefeedbase.OptFormatParquet,
+		Envelope: changefeedbase.OptEnvelopeWrapped,
+	}
+	makeSink := func() *icebergSink {
+		s, err := makeIcebergSink(ctx, sinkURL{URL: &sinkURI}, opts, targets,
+			externalStorageFromURI, user, nil /* mb */)
+		require.NoError(t, err)
+		return s.(*icebergSink)
+	}
+	// Aggregators and the frontier have their own sink.
+	aggregator, frontier := makeSink(), makeSink()
+	defer func() {
+		require.NoError(t, aggregator.Close())
+		require.NoError(t, frontier.Close())
+	}()
+
+	emit := func(a int, b string, deleted bool) {
+		row := cdcevent.TestingMakeEventRow(tableDesc, 0, rowenc.EncDatumRow{
+			rowenc.EncDatum{Datum: tree.NewDInt(tree.DInt(a))},
+			rowenc.EncDatum{Datum: tree.NewDString(b)},
+		}, deleted)
+		ts := hlc.Timestamp{WallTime: int64(a)}
+		require.NoError(t, aggregator.EncodeAndEmitRow(
+			ctx, row, cdcevent.Row{}, topic, ts, ts, opts, kvevent.Alloc{}))
+	}
+	tableDir := filepath.Join(externalIODir, `warehouse`, `db`, `foo`)
+	pendingFiles := func() []string {
+		entries, err := os.ReadDir(filepath.Join(tableDir, `pending`))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		require.NoError(t, err)
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		return names
+	}
+	loadTable := func() *iceberg.Table {
+		catalogStorage, err := externalStorageFromURI(ctx, `nodelocal://1/catalog`, user)
+		require.NoError(t, err)
+		tableStorage, err := externalStorageFromURI(ctx, `nodelocal://1/warehouse/db/foo`, user)
+		require.NoError(t, err)
+		tbl, err := iceberg.LoadTable(ctx, iceberg.NewFilesystemCatalog(catalogStorage),
+			iceberg.Identifier{Namespace: `db`, Name: `foo`}, tableStorage, `nodelocal://1/warehouse/db/foo`)
+		require.NoError(t, err)
+		return tbl
+	}
+	readFile := func(path string) [][]tree.Datum {
+		_, datums, err := parquet.ReadFile(filepath.Join(tableDir, path))
+		require.NoError(t, err)
+		sort.Slice(datums, func(i, j int) bool {
+			return tree.MustBeDInt(datums[i][0]) < tree.MustBeDInt(datums[j][0])
+		})
+		return datums
+	}
+
+	// Nothing is committed before the rows are flushed.
+	emit(1, `a`, false)
+	emit(2, `b`, false)
+	emit(1, `c`, false)
+	require.NoError(t, frontier.EmitResolvedTimestamp(ctx, nil, hlc.Timestamp{WallTime: 5}))
+	require.Nil(t, loadTable().Metadata().CurrentSnapshot())
+
+	// Flushing writes data and delete files, which are committed at the next
+	// resolved timestamp.
+	require.NoError(t, aggregator.Flush(ctx))
+	require.Len(t, pendingFiles(), 1)
+	emit(2, ``, true)
+	emit(3, `d`, false)
+	require.NoError(t, aggregator.Flush(ctx))
+	require.Len(t, pendingFiles(), 2)
+	require.NoError(t, frontier.EmitResolvedTimestamp(ctx, nil, hlc.Timestamp{WallTime: 10}))
+	require.Empty(t, pendingFiles())
+
+	m := loadTable().Metadata()
+	require.Len(t, m.Snapshots, 2)
+	require.Equal(t, []iceberg.Field{
+		{ID: 1, Name: `a`, Required: true, Type: iceberg.TypeLong},
+		{ID: 2, Name: `b`, Type: iceberg.TypeString},
+	}, m.Schemas[0].Fields)
+	s := m.CurrentSnapshot()
+	require.Equal(t, `10.0000000000`, s.Summary[icebergSummaryResolved])
+	require.Equal(t, `1`, s.Summary[`added-records`])
+	require.Equal(t, `2`, s.Summary[`added-equality-deletes`])
+
+	// Each flush wrote the last version of its rows, and the keys of all of
+	// them, which delete the versions written by previous flushes.
+	var data, deletes [][][]tree.Datum
+	require.NoError(t, filepath.Walk(filepath.Join(tableDir, `data`), func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return err
+		}
+		datums := readFile(filepath.Join(`data`, info.Name()))
+		if strings.HasSuffix(path, `-deletes.parquet`) {
+			deletes = append(deletes, datums)
+		} else {
+			data = append(data, datums)
+		}
+		return nil
+	}))
+	require.ElementsMatch(t, []string{`[(1, 'c') (2, 'b')]`, `[(3, 'd')]`}, datumsStrings(data))
+	require.ElementsMatch(t, []string{`[(1) (2)]`, `[(2) (3)]`}, datumsStrings(deletes))
+}
+
+func datumsStrings(files [][][]tree.Datum) []string {
+	var res []string
+	for _, rows := range files {
+		s := `[`
+		for i, r := range rows {
+			if i > 0 {
+				s += ` `
+			}
+			d := tree.Datums(r)
+			s += tree.AsString(&d)
+		}
+		res = append(res, s+`]`)
+	}
+	return res
+}
@@ -0,0 +1,285 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package changefeedccl
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/admission"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/cockroachdb/errors"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// mqttConnectTimeout is how long the sink waits to connect to the broker.
+const mqttConnectTimeout = 30 * time.Second
+
+func isMQTTSink(u *url.URL) bool {
+	return u.Scheme == changefeedbase.SinkSchemeMQTT
+}
+
+// MQTTPublisher is a small interface restricting the functionality in
+// mqtt.Client.
+type MQTTPublisher interface {
+	Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token
+	Disconnect(quiesce uint)
+}
+
+type mqttSinkKnobs struct {
+	// OverrideClient, if set, is used instead of connecting to the broker.
+	OverrideClient func() MQTTPublisher
+}
+
+// mqttSinkClient publishes messages to the topics of an MQTT broker. With a
+// QoS of 1 or more, a batch is flushed once every message of the batch was
+// acknowledged by the broker.
+type mqttSinkClient struct {
+	client   MQTTPublisher
+	qos      byte
+	retain   bool
+	batchCfg sinkBatchConfig
+}
+
+var _ SinkClient = (*mqttSinkClient)(nil)
+var _ SinkPayload = ([]mqttMessage)(nil)
+
+// mqttMessage is a message published to a topic.
+type mqttMessage struct {
+	topic   string
+	payload []byte
+}
+
+// makeMQTTSinkClient connects to the MQTT broker of the sink URL. The query
+// parameters of the URL which aren't consumed by the client are rejected.
+func makeMQTTSinkClient(
+	u sinkURL, batchCfg sinkBatchConfig, knobs mqttSinkKnobs,
+) (*mqttSinkClient, error) {
+	sc := &mqttSinkClient{qos: 1, batchCfg: batchCfg}
+	if qos := u.consumeParam(changefeedbase.SinkParamMQTTQoS); qos != `` {
+		v, err := strconv.Atoi(qos)
+		if err != nil || v < 0 || v > 2 {
+			return nil, errors.Errorf(`%s must be 0, 1 or 2: %s`, changefeedbase.SinkParamMQTTQoS, qos)
+		}
+		sc.qos = byte(v)
+	}
+	if _, err := u.consumeBool(changefeedbase.SinkParamMQTTRetain, &sc.retain); err != nil {
+		return nil, err
+	}
+	clientID := u.consumeParam(changefeedbase.SinkParamMQTTClientID)
+	if clientID == `` {
+		clientID = fmt.Sprintf(`crdb-cf-%s`, uuid.MakeV4().Short())
+	}
+
+	tlsCfg, err := buildSinkTLSConfig(u)
+	if err != nil {
+		return nil, err
+	}
+	broker := url.URL{Scheme: `tcp`, Host: u.Host}
+	if tlsCfg != nil {
+		broker.Scheme = `ssl`
+	}
+	opts := mqtt.NewClientOptions().
+		AddBroker(broker.String()).
+		SetClientID(clientID).
+		// Messages which weren't acknowledged are published again by the
+		// batching sink, so the client doesn't need to resume them when it
+		// reconnects.
+		SetCleanSession(true).
+		SetAutoReconnect(true).
+		SetConnectTimeout(mqttConnectTimeout).
+		SetDialer(&net.Dialer{Timeout: mqttConnectTimeout})
+	if tlsCfg != nil {
+		opts.SetTLSConfig(tlsCfg)
+	}
+	if u.User != nil {
+		opts.SetUsername(u.User.Username())
+		if password, ok := u.User.Password(); ok {
+			opts.SetPassword(password)
+		}
+	}
+
+	if unknownParams := u.remainingQueryParams(); len(unknownParams) > 0 {
+		return nil, errors.Errorf(
+			`unknown mqtt sink query parameters: %s`, strings.Join(unknownParams, ", "))
+	}
+
+	if knobs.OverrideClient != nil {
+		sc.client = knobs.OverrideClient()
+		return sc, nil
+	}
+
+	client := mqtt.NewClient(opts)
+	token := client.Connect()
+	if !token.WaitTimeout(mqttConnectTimeout) {
+		client.Disconnect(0)
+		return nil, errors.Newf(`timed out connecting to mqtt broker %s`, u.Host)
+	}
+	if err := token.Error(); err != nil {
+		return nil, errors.Wrap(err, `connecting to mqtt broker`)
+	}
+	sc.client = client
+	return sc, nil
+}
+
+// Flush implements the SinkClient interface.
+func (sc *mqttSinkClient) Flush(ctx context.Context, payload SinkPayload) error {
+	msgs := payload.([]mqttMessage)
+	tokens := make([]mqtt.Token, 0, len(msgs))
+	for _, msg := range msgs {
+		tokens = append(tokens, sc.client.Publish(msg.topic, sc.qos, sc.retain, msg.payload))
+	}
+	for i, token := range tokens {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-token.Done():
+			if err := token.Error(); err != nil {
+				return errors.Wrapf(err, `publishing to topic %s`, msgs[i].topic)
+			}
+		}
+	}
+	return nil
+}
+
+// FlushResolvedPayload implements the SinkClient interface.
+func (sc *mqttSinkClient) FlushResolvedPayload(
+	ctx context.Context,
+	body []byte,
+	forEachTopic func(func(topic string) error) error,
+	retryOpts retry.Options,
+) error {
+	var msgs []mqttMessage
+	if err := forEachTopic(func(topic string) error {
+		msgs = append(msgs, mqttMessage{topic: topic, payload: body})
+		return nil
+	}); err != nil {
+		return err
+	}
+	return retry.WithMaxAttempts(ctx, retryOpts, retryOpts.MaxRetries+1, func() error {
+		return sc.Flush(ctx, msgs)
+	})
+}
+
+// CheckConnection implements the SinkClient interface.
+func (sc *mqttSinkClient) CheckConnection(ctx context.Context) error {
+	return nil
+}
+
+// MakeBatchBuffer implements the SinkClient interface.
+func (sc *mqttSinkClient) MakeBatchBuffer(topic string) BatchBuffer {
+	return &mqttBuffer{topic: topic, batchCfg: sc.batchCfg}
+}
+
+// Close implements the SinkClient interface.
+func (sc *mqttSinkClient) Close() error {
+	if sc.client != nil {
+		// Wait up to a second for the messages being published to be sent.
+		sc.client.Disconnect(1000 /* quiesce */)
+	}
+	return nil
+}
+
+type mqttBuffer struct {
+	topic    string
+	messages []mqttMessage
+	numBytes int
+	batchCfg sinkBatchConfig
+}
+
+var _ BatchBuffer = (*mqttBuffer)(nil)
+
+// Append implements the BatchBuffer interface. MQTT messages have no key, so
+// only the value of rows is published.
+func (b *mqttBuffer) Append(_ []byte, value []byte, _ attributes) {
+	b.messages = append(b.messages, mqttMessage{topic: b.topic, payload: value})
+	b.numBytes += len(value)
+}
+
+// ShouldFlush implements the BatchBuffer interface.
+func (b *mqttBuffer) ShouldFlush() bool {
+	return shouldFlushBatch(b.numBytes, len(b.messages), b.batchCfg)
+}
+
+// Close implements the BatchBuffer interface.
+func (b *mqttBuffer) Close() (SinkPayload, error) {
+	return b.messages, nil
+}
+
+// mqttTopicReplacer replaces the characters which aren't allowed in the topics
+// messages are published to.
+var mqttTopicReplacer = strings.NewReplacer(`+`, `_`, `#`, `_`, "\x00", `_`)
+
+func makeMQTTSink(
+	ctx context.Context,
+	u sinkURL,
+	encodingOpts changefeedbase.EncodingOptions,
+	jsonConfig changefeedbase.SinkSpecificJSONConfig,
+	targets changefeedbase.Targets,
+	parallelism int,
+	pacerFactory func() *admission.Pacer,
+	source timeutil.TimeSource,
+	mb metricsRecorderBuilder,
+	settings *cluster.Settings,
+	knobs mqttSinkKnobs,
+) (Sink, error) {
+	if err := validateBrokerSinkFormat(encodingOpts); err != nil {
+		return nil, err
+	}
+	if u.Host == `` {
+		return nil, errors.New(`missing mqtt broker address`)
+	}
+	m := mb(requiresResourceAccounting)
+
+	batchCfg, retryOpts, err := getSinkConfigFromJson(jsonConfig, sinkJSONConfig{
+		Flush: sinkBatchConfig{
+			Frequency: jsonDuration(10 * time.Millisecond),
+			Messages:  100,
+			Bytes:     1 << 20,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	topicNamer, err := MakeTopicNamer(targets,
+		WithJoinByte('/'),
+		WithPrefix(u.consumeParam(changefeedbase.SinkParamTopicPrefix)),
+		WithSingleName(u.consumeParam(changefeedbase.SinkParamTopicName)),
+		WithTemplate(u.consumeParam(changefeedbase.SinkParamTopicTemplate)),
+		WithSanitizeFn(mqttTopicReplacer.Replace))
+	if err != nil {
+		return nil, err
+	}
+
+	sinkClient, err := makeMQTTSinkClient(u, batchCfg, knobs)
+	if err != nil {
+		return nil, err
+	}
+
+	return makeBatchingSink(
+		ctx,
+		sinkTypeMQTT,
+		sinkClient,
+		time.Duration(batchCfg.Frequency),
+		retryOpts,
+		parallelism,
+		topicNamer,
+		pacerFactory,
+		source,
+		m,
+		settings,
+	), nil
+}
@@ -0,0 +1,124 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package changefeedccl
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/require"
+)
+
+type mqttPublished struct {
+	topic    string
+	qos      byte
+	retained bool
+	payload  string
+}
+
+// fakeMQTTPublisher acknowledges the messages published to every topic but
+// the ones of failTopics.
+type fakeMQTTPublisher struct {
+	syncutil.Mutex
+	msgs       []mqttPublished
+	failTopics map[string]bool
+}
+
+type fakeMQTTToken struct {
+	done chan struct{}
+	err  error
+}
+
+func (t *fakeMQTTToken) Wait() bool                     { <-t.done; return true }
+func (t *fakeMQTTToken) WaitTimeout(time.Duration) bool { <-t.done; return true }
+func (t *fakeMQTTToken) Done() <-chan struct{}          { return t.done }
+func (t *fakeMQTTToken) Error() error                   { return t.err }
+
+// Publish implements the MQTTPublisher interface.
+func (p *fakeMQTTPublisher) Publish(
+	topic string, qos byte, retained bool, payload interface{},
+) mqtt.Token {
+	p.Lock()
+	defer p.Unlock()
+	token := &fakeMQTTToken{done: make(chan struct{})}
+	close(token.done)
+	if p.failTopics[topic] {
+		token.err = errors.New(`not authorized`)
+		return token
+	}
+	p.msgs = append(p.msgs, mqttPublished{
+		topic: topic, qos: qos, retained: retained, payload: string(payload.([]byte)),
+	})
+	return token
+}
+
+// Disconnect implements the MQTTPublisher interface.
+func (p *fakeMQTTPublisher) Disconnect(uint) {}
+
+func (p *fakeMQTTPublisher) published() []mqttPublished {
+	p.Lock()
+	defer p.Unlock()
+	return append([]mqttPublished(nil), p.msgs...)
+}
+
+func TestMQTTSink(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+
+	pub := &fakeMQTTPublisher{failTopics: map[string]bool{`edge/t2`: true}}
+	knobs := mqttSinkKnobs{OverrideClient: func() MQTTPublisher { return pub }}
+	makeSink := func(format changefeedbase.FormatType, query url.Values) (Sink, error) {
+		u := &url.URL{Scheme: changefeedbase.SinkSchemeMQTT, Host: `localhost:1883`, RawQuery: query.Encode()}
+		encodingOpts := changefeedbase.EncodingOptions{
+			Format: format, Envelope: changefeedbase.OptEnvelopeWrapped,
+		}
+		return makeMQTTSink(ctx, sinkURL{URL: u}, encodingOpts,
+			`{"Flush": {"Messages": 2}, "Retry": {"Max": 1, "Backoff": "1ms"}}`,
+			makeChangefeedTargets(`t1`, `t2`), 1, nilPacerFactory, timeutil.DefaultTimeSource{},
+			nilMetricsRecorderBuilder, cluster.MakeTestingClusterSettings(), knobs)
+	}
+
+	s, err := makeSink(changefeedbase.OptFormatJSON, url.Values{
+		changefeedbase.SinkParamTopicTemplate: {`edge/{table}`},
+		changefeedbase.SinkParamMQTTQoS:       {`2`},
+		changefeedbase.SinkParamMQTTRetain:    {`true`},
+	})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, s.Close()) }()
+
+	// Messages are published to the topic of their table with the configured
+	// QoS, and flushes wait for them to be acknowledged.
+	require.NoError(t, s.EmitRow(ctx, topic(`t1`), []byte(`[1]`), []byte(`{"after": 1}`), zeroTS, zeroTS, zeroAlloc))
+	require.NoError(t, s.EmitRow(ctx, topic(`t1`), []byte(`[2]`), []byte(`{"after": 2}`), zeroTS, zeroTS, zeroAlloc))
+	require.NoError(t, s.Flush(ctx))
+	require.Equal(t, []mqttPublished{
+		{topic: `edge/t1`, qos: 2, retained: true, payload: `{"after": 1}`},
+		{topic: `edge/t1`, qos: 2, retained: true, payload: `{"after": 2}`},
+	}, pub.published())
+
+	// Messages which aren't acknowledged fail the sink once they were retried.
+	require.NoError(t, s.EmitRow(ctx, topic(`t2`), []byte(`[3]`), []byte(`{"after": 3}`), zeroTS, zeroTS, zeroAlloc))
+	require.Regexp(t, `publishing to topic edge/t2: not authorized`, s.Flush(ctx))
+
+	// Options are validated.
+	_, err = makeSink(changefeedbase.OptFormatJSON, url.Values{changefeedbase.SinkParamMQTTQoS: {`3`}})
+	require.Regexp(t, `qos must be 0, 1 or 2: 3`, err)
+	_, err = makeSink(changefeedbase.OptFormatJSON, url.Values{`foo`: {`bar`}})
+	require.Regexp(t, `unknown mqtt sink query parameters: foo`, err)
+	_, err = makeSink(changefeedbase.OptFormatAvro, nil)
+	require.Regexp(t, `this sink is incompatible with format=avro`, err)
+}
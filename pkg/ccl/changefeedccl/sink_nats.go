@@ -0,0 +1,367 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package changefeedccl
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/admission"
+	"github.com/cockroachdb/cockroach/pkg/util/cidr"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/nats-io/nkeys"
+)
+
+// natsKeyHeader is the header of NATS messages carrying the encoded key of
+// the row.
+const natsKeyHeader = `Crdb-Key`
+
+// natsMaxPendingAcks bounds the number of messages published by a sink which
+// haven't been acknowledged by JetStream yet. Publishing blocks once it's
+// reached, which applies backpressure to the changefeed.
+const natsMaxPendingAcks = 4096
+
+// natsStallWait is how long a publish waits for pending acknowledgements
+// before failing when natsMaxPendingAcks is reached.
+const natsStallWait = 10 * time.Second
+
+func isNATSSink(u *url.URL) bool {
+	return u.Scheme == changefeedbase.SinkSchemeNATS
+}
+
+// NATSPublisher is a small interface restricting the functionality in
+// jetstream.JetStream.
+type NATSPublisher interface {
+	PublishMsgAsync(msg *nats.Msg, opts ...jetstream.PublishOpt) (jetstream.PubAckFuture, error)
+}
+
+type natsSinkKnobs struct {
+	// OverrideClient, if set, is used instead of connecting to the server.
+	OverrideClient func() NATSPublisher
+}
+
+// natsSinkClient publishes messages to NATS JetStream subjects. A batch is
+// flushed once every message of the batch was acknowledged by the stream
+// storing its subject.
+type natsSinkClient struct {
+	conn     *nats.Conn
+	js       NATSPublisher
+	batchCfg sinkBatchConfig
+}
+
+var _ SinkClient = (*natsSinkClient)(nil)
+var _ SinkPayload = ([]*nats.Msg)(nil)
+
+// makeNATSSinkClient connects to the NATS server of the sink URL. The query
+// parameters of the URL which aren't consumed by the client are rejected.
+func makeNATSSinkClient(
+	u sinkURL, batchCfg sinkBatchConfig, knobs natsSinkKnobs, nm *cidr.NetMetrics,
+) (*natsSinkClient, error) {
+	opts := []nats.Option{
+		nats.Name(`cockroach-changefeed`),
+		// Reconnect forever; failed publishes are retried by the batching sink.
+		nats.MaxReconnects(-1),
+		nats.SetCustomDialer(natsDialer{
+			dial: nm.Wrap((&net.Dialer{Timeout: nats.DefaultTimeout}).DialContext, "nats"),
+		}),
+	}
+
+	tlsCfg, err := buildSinkTLSConfig(u)
+	if err != nil {
+		return nil, err
+	}
+	if tlsCfg != nil {
+		opts = append(opts, nats.Secure(tlsCfg))
+	}
+
+	if token := u.consumeParam(changefeedbase.SinkParamNATSToken); token != `` {
+		opts = append(opts, nats.Token(token))
+	}
+	var creds []byte
+	if err := u.decodeBase64(changefeedbase.SinkParamNATSCredentials, &creds); err != nil {
+		return nil, err
+	}
+	if creds != nil {
+		jwt, err := nkeys.ParseDecoratedJWT(creds)
+		if err != nil {
+			return nil, errors.Wrapf(err, `parsing %s`, changefeedbase.SinkParamNATSCredentials)
+		}
+		kp, err := nkeys.ParseDecoratedNKey(creds)
+		if err != nil {
+			return nil, errors.Wrapf(err, `parsing %s`, changefeedbase.SinkParamNATSCredentials)
+		}
+		seed, err := kp.Seed()
+		if err != nil {
+			return nil, errors.Wrapf(err, `parsing %s`, changefeedbase.SinkParamNATSCredentials)
+		}
+		opts = append(opts, nats.UserJWTAndSeed(jwt, string(seed)))
+	}
+
+	if unknownParams := u.remainingQueryParams(); len(unknownParams) > 0 {
+		return nil, errors.Errorf(
+			`unknown nats sink query parameters: %s`, strings.Join(unknownParams, ", "))
+	}
+
+	sc := &natsSinkClient{batchCfg: batchCfg}
+	if knobs.OverrideClient != nil {
+		sc.js = knobs.OverrideClient()
+		return sc, nil
+	}
+
+	// The server URL keeps the user info, which the client uses as
+	// credentials, but not the query parameters.
+	serverURL := url.URL{Scheme: u.Scheme, User: u.User, Host: u.Host}
+	sc.conn, err = nats.Connect(serverURL.String(), opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, `connecting to nats`)
+	}
+	js, err := jetstream.New(sc.conn, jetstream.WithPublishAsyncMaxPending(natsMaxPendingAcks))
+	if err != nil {
+		sc.conn.Close()
+		return nil, err
+	}
+	sc.js = js
+	return sc, nil
+}
+
+// natsDialer implements nats.CustomDialer.
+type natsDialer struct {
+	dial cidr.DialContext
+}
+
+// Dial implements the nats.CustomDialer interface.
+func (d natsDialer) Dial(network, address string) (net.Conn, error) {
+	return d.dial(context.Background(), network, address)
+}
+
+// Flush implements the SinkClient interface.
+func (sc *natsSinkClient) Flush(ctx context.Context, payload SinkPayload) error {
+	msgs := payload.([]*nats.Msg)
+	acks := make([]jetstream.PubAckFuture, 0, len(msgs))
+	for _, msg := range msgs {
+		ack, err := sc.js.PublishMsgAsync(msg, jetstream.WithStallWait(natsStallWait))
+		if err != nil {
+			return errors.Wrapf(err, `publishing to subject %s`, msg.Subject)
+		}
+		acks = append(acks, ack)
+	}
+	for _, ack := range acks {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ack.Ok():
+		case err := <-ack.Err():
+			return errors.Wrapf(err, `publishing to subject %s`, ack.Msg().Subject)
+		}
+	}
+	return nil
+}
+
+// FlushResolvedPayload implements the SinkClient interface.
+func (sc *natsSinkClient) FlushResolvedPayload(
+	ctx context.Context,
+	body []byte,
+	forEachTopic func(func(topic string) error) error,
+	retryOpts retry.Options,
+) error {
+	var msgs []*nats.Msg
+	if err := forEachTopic(func(topic string) error {
+		msgs = append(msgs, &nats.Msg{Subject: topic, Data: body})
+		return nil
+	}); err != nil {
+		return err
+	}
+	return retry.WithMaxAttempts(ctx, retryOpts, retryOpts.MaxRetries+1, func() error {
+		return sc.Flush(ctx, msgs)
+	})
+}
+
+// CheckConnection implements the SinkClient interface.
+func (sc *natsSinkClient) CheckConnection(ctx context.Context) error {
+	if sc.conn != nil && !sc.conn.IsConnected() {
+		return errors.Newf(`nats connection is %s`, sc.conn.Status())
+	}
+	return nil
+}
+
+// MakeBatchBuffer implements the SinkClient interface.
+func (sc *natsSinkClient) MakeBatchBuffer(topic string) BatchBuffer {
+	return &natsBuffer{subject: topic, batchCfg: sc.batchCfg}
+}
+
+// Close implements the SinkClient interface.
+func (sc *natsSinkClient) Close() error {
+	if sc.conn != nil {
+		sc.conn.Close()
+	}
+	return nil
+}
+
+type natsBuffer struct {
+	subject  string
+	messages []*nats.Msg
+	numBytes int
+	batchCfg sinkBatchConfig
+}
+
+var _ BatchBuffer = (*natsBuffer)(nil)
+
+// Append implements the BatchBuffer interface.
+func (b *natsBuffer) Append(key []byte, value []byte, _ attributes) {
+	msg := &nats.Msg{Subject: b.subject, Data: value}
+	if len(key) > 0 {
+		msg.Header = nats.Header{natsKeyHeader: []string{string(key)}}
+	}
+	b.messages = append(b.messages, msg)
+	b.numBytes += len(key) + len(value)
+}
+
+// ShouldFlush implements the BatchBuffer interface.
+func (b *natsBuffer) ShouldFlush() bool {
+	return shouldFlushBatch(b.numBytes, len(b.messages), b.batchCfg)
+}
+
+// Close implements the BatchBuffer interface.
+func (b *natsBuffer) Close() (SinkPayload, error) {
+	return b.messages, nil
+}
+
+// buildSinkTLSConfig returns the TLS configuration specified by the sink URL
+// parameters, or nil if TLS isn't enabled.
+func buildSinkTLSConfig(u sinkURL) (*tls.Config, error) {
+	var tlsEnabled, tlsSkipVerify bool
+	var caCert, clientCert, clientKey []byte
+	if _, err := u.consumeBool(changefeedbase.SinkParamTLSEnabled, &tlsEnabled); err != nil {
+		return nil, err
+	}
+	if _, err := u.consumeBool(changefeedbase.SinkParamSkipTLSVerify, &tlsSkipVerify); err != nil {
+		return nil, err
+	}
+	if err := u.decodeBase64(changefeedbase.SinkParamCACert, &caCert); err != nil {
+		return nil, err
+	}
+	if err := u.decodeBase64(changefeedbase.SinkParamClientCert, &clientCert); err != nil {
+		return nil, err
+	}
+	if err := u.decodeBase64(changefeedbase.SinkParamClientKey, &clientKey); err != nil {
+		return nil, err
+	}
+	if !tlsEnabled {
+		if tlsSkipVerify || caCert != nil || clientCert != nil || clientKey != nil {
+			return nil, errors.Errorf(`%s must be true to use TLS parameters`, changefeedbase.SinkParamTLSEnabled)
+		}
+		return nil, nil
+	}
+
+	tlsCfg := &tls.Config{InsecureSkipVerify: tlsSkipVerify}
+	if caCert != nil {
+		caCertPool := x509.NewCertPool()
+		if !caCertPool.AppendCertsFromPEM(caCert) {
+			return nil, errors.Errorf(`failed to parse %s`, changefeedbase.SinkParamCACert)
+		}
+		tlsCfg.RootCAs = caCertPool
+	}
+	if clientCert != nil && clientKey == nil {
+		return nil, errors.Errorf(`%s requires %s to be set`, changefeedbase.SinkParamClientCert, changefeedbase.SinkParamClientKey)
+	} else if clientKey != nil && clientCert == nil {
+		return nil, errors.Errorf(`%s requires %s to be set`, changefeedbase.SinkParamClientKey, changefeedbase.SinkParamClientCert)
+	}
+	if clientCert != nil {
+		cert, err := tls.X509KeyPair(clientCert, clientKey)
+		if err != nil {
+			return nil, errors.Wrap(err, `invalid client certificate data provided`)
+		}
+		tlsCfg.Certificates = []tls.Certificate{cert}
+	}
+	return tlsCfg, nil
+}
+
+// validateBrokerSinkFormat checks that rows can be encoded as the messages of
+// the NATS and MQTT sinks, which carry a single text encoded row.
+func validateBrokerSinkFormat(encodingOpts changefeedbase.EncodingOptions) error {
+	switch encodingOpts.Format {
+	case changefeedbase.OptFormatJSON, changefeedbase.OptFormatCSV:
+		return nil
+	default:
+		return errors.Errorf(`this sink is incompatible with %s=%s`,
+			changefeedbase.OptFormat, encodingOpts.Format)
+	}
+}
+
+// natsSubjectReplacer replaces the characters which aren't allowed in the
+// subjects messages are published to.
+var natsSubjectReplacer = strings.NewReplacer(` `, `_`, "\t", `_`, `*`, `_`, `>`, `_`)
+
+func makeNATSSink(
+	ctx context.Context,
+	u sinkURL,
+	encodingOpts changefeedbase.EncodingOptions,
+	jsonConfig changefeedbase.SinkSpecificJSONConfig,
+	targets changefeedbase.Targets,
+	parallelism int,
+	pacerFactory func() *admission.Pacer,
+	source timeutil.TimeSource,
+	mb metricsRecorderBuilder,
+	settings *cluster.Settings,
+	knobs natsSinkKnobs,
+) (Sink, error) {
+	if err := validateBrokerSinkFormat(encodingOpts); err != nil {
+		return nil, err
+	}
+	if u.Host == `` {
+		return nil, errors.New(`missing nats server address`)
+	}
+	m := mb(requiresResourceAccounting)
+
+	batchCfg, retryOpts, err := getSinkConfigFromJson(jsonConfig, sinkJSONConfig{
+		Flush: sinkBatchConfig{
+			Frequency: jsonDuration(10 * time.Millisecond),
+			Messages:  1000,
+			Bytes:     1 << 20,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	topicNamer, err := MakeTopicNamer(targets,
+		WithPrefix(u.consumeParam(changefeedbase.SinkParamTopicPrefix)),
+		WithSingleName(u.consumeParam(changefeedbase.SinkParamTopicName)),
+		WithTemplate(u.consumeParam(changefeedbase.SinkParamTopicTemplate)),
+		WithSanitizeFn(natsSubjectReplacer.Replace))
+	if err != nil {
+		return nil, err
+	}
+
+	sinkClient, err := makeNATSSinkClient(u, batchCfg, knobs, m.netMetrics())
+	if err != nil {
+		return nil, err
+	}
+	return makeBatchingSink(
+		ctx,
+		sinkTypeNATS,
+		sinkClient,
+		time.Duration(batchCfg.Frequency),
+		retryOpts,
+		parallelism,
+		topicNamer,
+		pacerFactory,
+		source,
+		m,
+		settings,
+	), nil
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package changefeedccl
+
+import (
+	"context"
+	"net/url"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/retry"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeNATSPublisher acknowledges the messages published to every subject but
+// the ones of failSubjects.
+type fakeNATSPublisher struct {
+	syncutil.Mutex
+	msgs         []*nats.Msg
+	failSubjects map[string]bool
+}
+
+type fakeNATSAck struct {
+	msg *nats.Msg
+	ok  chan *jetstream.PubAck
+	err chan error
+}
+
+func (a *fakeNATSAck) Ok() <-chan *jetstream.PubAck { return a.ok }
+func (a *fakeNATSAck) Err() <-chan error            { return a.err }
+func (a *fakeNATSAck) Msg() *nats.Msg               { return a.msg }
+
+// PublishMsgAsync implements the NATSPublisher interface.
+func (p *fakeNATSPublisher) PublishMsgAsync(
+	msg *nats.Msg, _ ...jetstream.PublishOpt,
+) (jetstream.PubAckFuture, error) {
+	p.Lock()
+	defer p.Unlock()
+	ack := &fakeNATSAck{msg: msg, ok: make(chan *jetstream.PubAck, 1), err: make(chan error, 1)}
+	if p.failSubjects[msg.Subject] {
+		ack.err <- errors.New(`no responders`)
+		return ack, nil
+	}
+	p.msgs = append(p.msgs, msg)
+	ack.ok <- &jetstream.PubAck{Stream: `cdc`}
+	return ack, nil
+}
+
+func (p *fakeNATSPublisher) published() []*nats.Msg {
+	p.Lock()
+	defer p.Unlock()
+	return append([]*nats.Msg(nil), p.msgs...)
+}
+
+func TestNATSSink(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+	ctx := context.Background()
+
+	pub := &fakeNATSPublisher{failSubjects: map[string]bool{`cdc.t2.rows`: true}}
+	knobs := natsSinkKnobs{OverrideClient: func() NATSPublisher { return pub }}
+	encodingOpts := changefeedbase.EncodingOptions{
+		Format: changefeedbase.OptFormatJSON, Envelope: changefeedbase.OptEnvelopeWrapped,
+	}
+	makeSink := func(query url.Values) (Sink, error) {
+		u := &url.URL{Scheme: changefeedbase.SinkSchemeNATS, Host: `localhost:4222`, RawQuery: query.Encode()}
+		return makeNATSSink(ctx, sinkURL{URL: u}, encodingOpts,
+			`{"Flush": {"Messages": 2}, "Retry": {"Max": 1, "Backoff": "1ms"}}`,
+			makeChangefeedTargets(`t1`, `t2`), 1, nilPacerFactory, timeutil.DefaultTimeSource{},
+			nilMetricsRecorderBuilder, cluster.MakeTestingClusterSettings(), knobs)
+	}
+
+	s, err := makeSink(url.Values{changefeedbase.SinkParamTopicTemplate: {`cdc.{table}.rows`}})
+	require.NoError(t, err)
+	defer func() { require.NoError(t, s.Close()) }()
+
+	// Messages are published to the subject of their table, and flushes wait
+	// for them to be acknowledged.
+	require.NoError(t, s.EmitRow(ctx, topic(`t1`), []byte(`[1]`), []byte(`{"after": 1}`), zeroTS, zeroTS, zeroAlloc))
+	require.NoError(t, s.EmitRow(ctx, topic(`t1`), []byte(`[2]`), []byte(`{"after": 2}`), zeroTS, zeroTS, zeroAlloc))
+	require.NoError(t, s.Flush(ctx))
+	msgs := pub.published()
+	require.Len(t, msgs, 2)
+	for i, msg := range msgs {
+		require.Equal(t, `cdc.t1.rows`, msg.Subject)
+		require.Equal(t, []string{`[1]`, `[2]`}[i], msg.Header.Get(natsKeyHeader))
+		require.Equal(t, []string{`{"after": 1}`, `{"after": 2}`}[i], string(msg.Data))
+	}
+
+	// Resolved timestamps are published to the subject of every table.
+	client := s.(*batchingSink).client
+	require.NoError(t, client.FlushResolvedPayload(ctx, []byte(`{"resolved": "1"}`),
+		func(fn func(topic string) error) error { return fn(`cdc.t1.rows`) }, retry.Options{}))
+	require.Len(t, pub.published(), 3)
+
+	// Messages which aren't acknowledged fail the sink once they were retried.
+	require.NoError(t, s.EmitRow(ctx, topic(`t2`), []byte(`[3]`), []byte(`{"after": 3}`), zeroTS, zeroTS, zeroAlloc))
+	require.Regexp(t, `publishing to subject cdc.t2.rows: no responders`, s.Flush(ctx))
+
+	// Parameters are validated.
+	_, err = makeSink(url.Values{`foo`: {`bar`}})
+	require.Regexp(t, `unknown nats sink query parameters: foo`, err)
+	_, err = makeSink(url.Values{changefeedbase.SinkParamCACert: {`Zm9v`}})
+	require.Regexp(t, `tls_enabled must be true to use TLS parameters`, err)
+	_, err = makeSink(url.Values{changefeedbase.SinkParamTLSEnabled: {`true`}, changefeedbase.SinkParamClientCert: {`Zm9v`}})
+	require.Regexp(t, `client_cert requires client_key to be set`, err)
+	_, err = makeSink(url.Values{changefeedbase.SinkParamNATSCredentials: {`Zm9v`}})
+	require.Regexp(t, `parsing credentials`, err)
+}
@@ -0,0 +1,286 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package changefeedccl
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobsauth"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/exprutil"
+	"github.com/cockroachdb/cockroach/pkg/sql/isql"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/duration"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
+	"github.com/cockroachdb/errors"
+)
+
+func init() {
+	sql.AddPlanHook("show changefeed spans", showChangefeedSpansPlanHook, showChangefeedSpansTypeCheck)
+}
+
+// spanReportInfoKeyPrefix is the prefix of the job info keys under which each
+// change aggregator persists the state of the rangefeeds it is running. The
+// key is suffixed with the SQL instance ID of the aggregator.
+const spanReportInfoKeyPrefix = "~changefeed-span-report-"
+
+// spanReportInfoKeyMax sorts after every key with spanReportInfoKeyPrefix.
+const spanReportInfoKeyMax = spanReportInfoKeyPrefix + "~"
+
+// maxSpanReportRanges bounds the number of ranges persisted by a single
+// aggregator. Ranges are ordered by how far they are behind, so the ranges
+// which are dropped are the ones least likely to be of interest.
+const maxSpanReportRanges = 1000
+
+func makeSpanReportInfoKey(instanceID base.SQLInstanceID) string {
+	return fmt.Sprintf("%s%d", spanReportInfoKeyPrefix, instanceID)
+}
+
+// spanReportTimestamp returns the timestamp the range is caught up to. During
+// catchup scans the resolved timestamp is not yet set, in which case the time
+// the rangefeed was created is used instead, the same way the lagging ranges
+// metric does.
+func spanReportTimestamp(r *jobspb.ChangefeedSpanReport_Range) hlc.Timestamp {
+	if r.Resolved.IsEmpty() {
+		return hlc.Timestamp{WallTime: r.Created.UnixNano()}
+	}
+	return r.Resolved
+}
+
+// makeSpanReportCallback returns a kvfeed.MonitoringConfig.SpanReportCallback
+// which persists the most lagging ranges of an aggregator to the job info
+// table, where they can be read by SHOW CHANGEFEED JOB ... SPANS. Nothing is
+// persisted unless changefeed.span_reports.enabled is set.
+func makeSpanReportCallback(
+	db isql.DB,
+	sv *settings.Values,
+	jobID jobspb.JobID,
+	instanceID base.SQLInstanceID,
+	clock *hlc.Clock,
+) func(ctx context.Context, ranges []jobspb.ChangefeedSpanReport_Range) {
+	key := makeSpanReportInfoKey(instanceID)
+	return func(ctx context.Context, ranges []jobspb.ChangefeedSpanReport_Range) {
+		if !changefeedbase.SpanReportsEnabled.Get(sv) {
+			return
+		}
+		sort.Slice(ranges, func(i, j int) bool {
+			return spanReportTimestamp(&ranges[i]).Less(spanReportTimestamp(&ranges[j]))
+		})
+		if len(ranges) > maxSpanReportRanges {
+			ranges = ranges[:maxSpanReportRanges]
+		}
+		report := jobspb.ChangefeedSpanReport{
+			SQLInstanceID: instanceID,
+			AsOf:          clock.Now(),
+			Ranges:        ranges,
+		}
+		value, err := protoutil.Marshal(&report)
+		if err != nil {
+			log.Warningf(ctx, "failed to marshal span report for job %d: %v", jobID, err)
+			return
+		}
+		if err := db.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+			return jobs.InfoStorageForJob(txn, jobID).Write(ctx, key, value)
+		}); err != nil {
+			log.Warningf(ctx, "failed to write span report for job %d: %v", jobID, err)
+		}
+	}
+}
+
+// clearSpanReports removes the span reports persisted by a previous
+// incarnation of the changefeed flow, so that SHOW CHANGEFEED JOB ... SPANS
+// does not return reports of aggregators that are no longer running.
+func clearSpanReports(ctx context.Context, db isql.DB, jobID jobspb.JobID) {
+	if jobID == 0 {
+		return
+	}
+	if err := db.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		return jobs.InfoStorageForJob(txn, jobID).DeleteRange(
+			ctx, spanReportInfoKeyPrefix, spanReportInfoKeyMax, 0 /* limit */)
+	}); err != nil {
+		log.Warningf(ctx, "failed to clear span reports for job %d: %v", jobID, err)
+	}
+}
+
+var showChangefeedSpansHeader = colinfo.ResultColumns{
+	{Name: "start_key", Typ: types.String},
+	{Name: "end_key", Typ: types.String},
+	{Name: "range_id", Typ: types.Int},
+	{Name: "node_id", Typ: types.Int},
+	{Name: "aggregator_instance_id", Typ: types.Int},
+	{Name: "resolved", Typ: types.Decimal},
+	{Name: "lag", Typ: types.Interval},
+	{Name: "catchup", Typ: types.Bool},
+	{Name: "status", Typ: types.String},
+	{Name: "last_error", Typ: types.String},
+	{Name: "reported_at", Typ: types.Decimal},
+}
+
+// Span statuses reported by SHOW CHANGEFEED JOB ... SPANS.
+const (
+	spanStatusOK      = "ok"
+	spanStatusCatchup = "catchup"
+	spanStatusLagging = "lagging"
+	spanStatusError   = "error"
+)
+
+func showChangefeedSpansTypeCheck(
+	ctx context.Context, stmt tree.Statement, p sql.PlanHookState,
+) (matched bool, header colinfo.ResultColumns, _ error) {
+	showStmt, ok := stmt.(*tree.ShowChangefeedSpans)
+	if !ok {
+		return false, nil, nil
+	}
+	if err := exprutil.TypeCheck(
+		ctx, "SHOW CHANGEFEED SPANS", p.SemaCtx(), exprutil.Ints{showStmt.Job},
+	); err != nil {
+		return false, nil, err
+	}
+	return true, showChangefeedSpansHeader, nil
+}
+
+// showChangefeedSpansPlanHook implements sql.PlanHookFn.
+func showChangefeedSpansPlanHook(
+	ctx context.Context, stmt tree.Statement, p sql.PlanHookState,
+) (sql.PlanHookRowFn, colinfo.ResultColumns, []sql.PlanNode, bool, error) {
+	showStmt, ok := stmt.(*tree.ShowChangefeedSpans)
+	if !ok {
+		return nil, nil, nil, false, nil
+	}
+
+	fn := func(ctx context.Context, _ []sql.PlanNode, resultsCh chan<- tree.Datums) error {
+		id, err := p.ExprEvaluator("SHOW CHANGEFEED SPANS").Int(ctx, showStmt.Job)
+		if err != nil {
+			return pgerror.Wrap(err, pgcode.DatatypeMismatch, "changefeed ID must be an INT value")
+		}
+		jobID := jobspb.JobID(id)
+
+		job, err := p.ExecCfg().JobRegistry.LoadJobWithTxn(ctx, jobID, p.InternalSQLTxn())
+		if err != nil {
+			return errors.Wrapf(err, `could not load job with job id %d`, jobID)
+		}
+		jobPayload := job.Payload()
+		globalPrivileges, err := jobsauth.GetGlobalJobPrivileges(ctx, p)
+		if err != nil {
+			return err
+		}
+		if err := jobsauth.Authorize(
+			ctx, p, jobID, &jobPayload, jobsauth.ViewAccess, globalPrivileges,
+		); err != nil {
+			return err
+		}
+		details, ok := job.Details().(jobspb.ChangefeedDetails)
+		if !ok {
+			return errors.Errorf(`job %d is not changefeed job`, jobID)
+		}
+
+		if !changefeedbase.SpanReportsEnabled.Get(&p.ExecCfg().Settings.SV) {
+			return pgerror.Newf(pgcode.ObjectNotInPrerequisiteState,
+				`span reports are disabled; enable them with SET CLUSTER SETTING %s = true`,
+				changefeedbase.SpanReportsEnabled.Name())
+		}
+
+		threshold, _, err := changefeedbase.MakeStatementOptions(details.Opts).
+			GetLaggingRangesConfig(ctx, p.ExecCfg().Settings)
+		if err != nil {
+			return err
+		}
+
+		var reports []jobspb.ChangefeedSpanReport
+		if err := jobs.InfoStorageForJob(p.InternalSQLTxn(), jobID).Iterate(
+			ctx, spanReportInfoKeyPrefix, func(_ string, value []byte) error {
+				var report jobspb.ChangefeedSpanReport
+				if err := protoutil.Unmarshal(value, &report); err != nil {
+					return err
+				}
+				reports = append(reports, report)
+				return nil
+			}); err != nil {
+			return err
+		}
+
+		now := p.ExecCfg().Clock.PhysicalTime()
+		for i := range reports {
+			report := &reports[i]
+			for j := range report.Ranges {
+				row := makeShowChangefeedSpansRow(report, &report.Ranges[j], now, threshold)
+				select {
+				case <-ctx.Done():
+					return ctx.Err()
+				case resultsCh <- row:
+				}
+			}
+		}
+		return nil
+	}
+	return fn, showChangefeedSpansHeader, nil, false, nil
+}
+
+func makeShowChangefeedSpansRow(
+	report *jobspb.ChangefeedSpanReport,
+	r *jobspb.ChangefeedSpanReport_Range,
+	now time.Time,
+	threshold time.Duration,
+) tree.Datums {
+	lag := now.Sub(spanReportTimestamp(r).GoTime())
+	if lag < 0 {
+		lag = 0
+	}
+
+	// Only the resolved timestamp of a range is known here, not what holds it
+	// back, so a range which trails by more than the lagging threshold is
+	// reported as lagging whatever the cause.
+	status := spanStatusOK
+	switch {
+	case r.LastErr != "":
+		status = spanStatusError
+	case r.InCatchup:
+		status = spanStatusCatchup
+	case lag > threshold:
+		status = spanStatusLagging
+	}
+
+	resolved := tree.DNull
+	if !r.Resolved.IsEmpty() {
+		resolved = eval.TimestampToDecimalDatum(r.Resolved)
+	}
+	lastErr := tree.DNull
+	if r.LastErr != "" {
+		lastErr = tree.NewDString(r.LastErr)
+	}
+
+	return tree.Datums{
+		tree.NewDString(r.Span.Key.String()),
+		tree.NewDString(r.Span.EndKey.String()),
+		tree.NewDInt(tree.DInt(r.RangeID)),
+		tree.NewDInt(tree.DInt(r.NodeID)),
+		tree.NewDInt(tree.DInt(report.SQLInstanceID)),
+		resolved,
+		tree.NewDInterval(
+			duration.MakeDuration(lag.Nanoseconds(), 0 /* days */, 0 /* months */),
+			types.DefaultIntervalTypeMetadata,
+		),
+		tree.MakeDBool(tree.DBool(r.InCatchup)),
+		tree.NewDString(status),
+		lastErr,
+		eval.TimestampToDecimalDatum(report.AsOf),
+	}
+}
@@ -48,6 +48,7 @@ type TopicNamer struct {
 	join       byte
 	prefix     string
 	singleName string
+	template   string
 	sanitize   func(string) string
 
 	// DisplayNames are initialized once from specs and may contain placeholder strings.
@@ -99,6 +100,18 @@ func WithSingleName(s string) TopicNameOption {
 	return optSingleName(s)
 }
 
+type optTemplate string
+
+func (o optTemplate) set(tn *TopicNamer) {
+	tn.template = string(o)
+}
+
+// WithTemplate places all topics named by this TopicNamer within a template,
+// substituting the topic name for each {table} placeholder of the template.
+func WithTemplate(s string) TopicNameOption {
+	return optTemplate(s)
+}
+
 type optSanitize func(string) string
 
 func (o optSanitize) set(tn *TopicNamer) {
@@ -112,7 +125,8 @@ func WithSanitizeFn(fn func(string) string) TopicNameOption {
 
 // MakeTopicNamer creates a TopicNamer.
 // specs are used to populate DisplayNames and the values iterated over in Each.
-// Add options using WithJoinByte, WithPrefix, WithSingleName, and/or WithSanitizeFn.
+// Add options using WithJoinByte, WithPrefix, WithSingleName, WithTemplate,
+// and/or WithSanitizeFn.
 func MakeTopicNamer(targets changefeedbase.Targets, opts ...TopicNameOption) (*TopicNamer, error) {
 	tn := &TopicNamer{
 		join:         '.',
@@ -137,6 +151,9 @@ func MakeTopicNamer(targets changefeedbase.Targets, opts ...TopicNameOption) (*T
 
 const familyPlaceholder = "{family}"
 
+// tablePlaceholder is substituted for topic names in templates.
+const tablePlaceholder = "{table}"
+
 // Name generates (with caching) a sink's topic identifier string.
 func (tn *TopicNamer) Name(td TopicDescriptor) (string, error) {
 	if name, ok := tn.FullNames[td.GetTopicIdentifier()]; ok {
@@ -215,6 +232,9 @@ func (tn *TopicNamer) nameFromComponents(
 		}
 	}
 	str := b.String()
+	if tn.template != "" {
+		str = strings.ReplaceAll(tn.template, tablePlaceholder, str)
+	}
 
 	if tn.sanitize != nil {
 		return tn.sanitize(str)
@@ -0,0 +1,63 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package changefeedccl
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/kvevent"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+)
+
+// Transaction markers let consumers rebuild the transactions of a changefeed.
+// Every row event is tagged with the identifier of its transaction and, once
+// the resolved timestamp of the changefeed passes the commit timestamp of a
+// transaction, an END marker carrying the number of rows of the transaction is
+// delivered along with them.
+//
+// Transactions are identified by their ID, which rangefeeds report when the
+// kv.rangefeed.txn_ids.enabled setting is on. Rows delivered by catch-up scans,
+// after a changefeed or one of its rangefeeds restarts, and rows written
+// outside of transactions have no ID: they are identified by their commit
+// timestamp instead, and get a marker of their own. Rows emitted by initial
+// scans and backfills are not part of any transaction, so they are neither
+// tagged nor counted.
+//
+// The rows of a transaction and its END marker are delivered atomically, so
+// transaction markers are only supported by sinks which can do so: the cloud
+// storage sink with the exactly_once option. Its change aggregators record the
+// number of rows of each transaction in the pending commits of the staging
+// files holding them, and the change frontier writes the END markers into the
+// manifest which commits those files. Rows, counts and markers are thus
+// persisted before the job highwater passes them, and a restarted changefeed
+// neither loses nor double counts any of them.
+
+// txnMarkerID returns the identifier of the transaction of the event, or false
+// if the event isn't part of a transaction.
+func txnMarkerID(evCtx eventContext) (string, bool) {
+	if evCtx.backfill {
+		return "", false
+	}
+	if evCtx.txnID != uuid.Nil {
+		return evCtx.txnID.String(), true
+	}
+	return evCtx.mvcc.AsOfSystemTime(), true
+}
+
+// txnRowSink is implemented by the sinks which support transaction markers.
+type txnRowSink interface {
+	// EmitTxnRow is like EmitRow, but also counts the row as part of the
+	// transaction identified by txnID, unless it's empty.
+	EmitTxnRow(
+		ctx context.Context,
+		topic TopicDescriptor,
+		key, value []byte,
+		updated, mvcc hlc.Timestamp,
+		txnID string,
+		alloc kvevent.Alloc,
+	) error
+}
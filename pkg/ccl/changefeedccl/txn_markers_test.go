@@ -0,0 +1,82 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package changefeedccl
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/cdcevent"
+	"github.com/cockroachdb/cockroach/pkg/ccl/changefeedccl/changefeedbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxnMarkersJSONEncoding(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	tableDesc, err := parseTableDesc(`CREATE TABLE foo (a INT PRIMARY KEY, b STRING)`)
+	require.NoError(t, err)
+	targets := mkTargets(tableDesc)
+	row := cdcevent.TestingMakeEventRow(tableDesc, 0, rowenc.EncDatumRow{
+		rowenc.EncDatum{Datum: tree.NewDInt(1)},
+		rowenc.EncDatum{Datum: tree.NewDString(`x`)},
+	}, false)
+
+	txnID := uuid.FromStringOrNil(`4a6b0ff4-9e0b-4b4b-8b36-5a6f0ad1e1a0`)
+	ts := hlc.Timestamp{WallTime: 10, Logical: 2}
+
+	for _, c := range []struct {
+		envelope         changefeedbase.EnvelopeType
+		expectedTxn      string
+		expectedNoTxn    string
+		expectedBackfill string
+	}{
+		{
+			envelope:         changefeedbase.OptEnvelopeWrapped,
+			expectedTxn:      `{"after": {"a": 1, "b": "x"}, "txn_id": "4a6b0ff4-9e0b-4b4b-8b36-5a6f0ad1e1a0"}`,
+			expectedNoTxn:    `{"after": {"a": 1, "b": "x"}, "txn_id": "10.0000000002"}`,
+			expectedBackfill: `{"after": {"a": 1, "b": "x"}, "txn_id": null}`,
+		},
+		{
+			envelope:         changefeedbase.OptEnvelopeBare,
+			expectedTxn:      `{"__crdb__": {"txn_id": "4a6b0ff4-9e0b-4b4b-8b36-5a6f0ad1e1a0"}, "a": 1, "b": "x"}`,
+			expectedNoTxn:    `{"__crdb__": {"txn_id": "10.0000000002"}, "a": 1, "b": "x"}`,
+			expectedBackfill: `{"__crdb__": {"txn_id": null}, "a": 1, "b": "x"}`,
+		},
+	} {
+		t.Run(string(c.envelope), func(t *testing.T) {
+			opts := changefeedbase.EncodingOptions{
+				Format:             changefeedbase.OptFormatJSON,
+				Envelope:           c.envelope,
+				TransactionMarkers: true,
+			}
+			require.NoError(t, opts.Validate())
+			e, err := getEncoder(ctx, opts, targets, false, nil, nil)
+			require.NoError(t, err)
+
+			for _, ev := range []struct {
+				evCtx    eventContext
+				expected string
+			}{
+				{eventContext{updated: ts, mvcc: ts, txnID: txnID}, c.expectedTxn},
+				{eventContext{updated: ts, mvcc: ts}, c.expectedNoTxn},
+				{eventContext{updated: ts, mvcc: ts, backfill: true}, c.expectedBackfill},
+			} {
+				value, err := e.EncodeValue(ctx, ev.evCtx, row, cdcevent.Row{})
+				require.NoError(t, err)
+				require.JSONEq(t, ev.expected, string(value))
+			}
+		})
+	}
+}
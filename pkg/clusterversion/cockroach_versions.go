@@ -238,6 +238,10 @@ const (
 	// V25_1_AddJobsTables added new jobs tables.
 	V25_1_AddJobsTables
 
+	// V25_1_WorkloadIndexAdviceJob is the version that adds the workload index
+	// advice job type.
+	V25_1_WorkloadIndexAdviceJob
+
 	// *************************************************
 	// Step (1) Add new versions above this comment.
 	// Do not add new versions to a patch release.
@@ -293,7 +297,8 @@ var versionTable = [numKeys]roachpb.Version{
 	// v25.1 versions. Internal versions must be even.
 	V25_1_Start: {Major: 24, Minor: 3, Internal: 2},
 
-	V25_1_AddJobsTables: {Major: 24, Minor: 3, Internal: 4},
+	V25_1_AddJobsTables:          {Major: 24, Minor: 3, Internal: 4},
+	V25_1_WorkloadIndexAdviceJob: {Major: 24, Minor: 3, Internal: 6},
 
 	// *************************************************
 	// Step (2): Add new versions above this comment.
@@ -1150,7 +1150,7 @@ var specs = []stmtSpec{
 		replace: map[string]string{
 			"table_option":          "table_name",
 			"insert_column_item":    "column_name",
-			"import_format":         "( 'CSV' | 'AVRO' | 'DELIMITED' )",
+			"import_format":         "( 'CSV' | 'AVRO' | 'DELIMITED' | 'PARQUET' | 'NDJSON' )",
 			"string_or_placeholder": "file_location",
 			"kv_option":             "option '=' value"},
 		unlink: []string{"table_name", "column_name", "file_location", "option", "value"},
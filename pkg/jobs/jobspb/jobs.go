@@ -58,3 +58,17 @@ func (rse ResolvedSpanEntries) Equal(rse2 ResolvedSpanEntries) bool {
 	}
 	return true
 }
+
+// Add combines the counts from other, for use on an accumulator
+// ImportRowSummary.
+func (s *ImportRowSummary) Add(other ImportRowSummary) {
+	s.SkippedRows += other.SkippedRows
+	s.ReplacedRows += other.ReplacedRows
+	s.RejectedRows += other.RejectedRows
+	for class, n := range other.RejectedByErrorClass {
+		if s.RejectedByErrorClass == nil {
+			s.RejectedByErrorClass = make(map[string]int64)
+		}
+		s.RejectedByErrorClass[class] += n
+	}
+}
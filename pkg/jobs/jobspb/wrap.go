@@ -46,6 +46,7 @@ var (
 	_ Details = LogicalReplicationDetails{}
 	_ Details = UpdateTableMetadataCacheDetails{}
 	_ Details = StandbyReadTSPollerDetails{}
+	_ Details = WorkloadIndexAdviceDetails{}
 )
 
 // ProgressDetails is a marker interface for job progress details proto structs.
@@ -76,6 +77,7 @@ var (
 	_ ProgressDetails = LogicalReplicationProgress{}
 	_ ProgressDetails = UpdateTableMetadataCacheProgress{}
 	_ ProgressDetails = StandbyReadTSPollerProgress{}
+	_ ProgressDetails = WorkloadIndexAdviceProgress{}
 )
 
 // Type returns the payload's job type and panics if the type is invalid.
@@ -236,6 +238,8 @@ func DetailsType(d isPayload_Details) (Type, error) {
 		return TypeUpdateTableMetadataCache, nil
 	case *Payload_StandbyReadTsPollerDetails:
 		return TypeStandbyReadTSPoller, nil
+	case *Payload_WorkloadIndexAdviceDetails:
+		return TypeWorkloadIndexAdvice, nil
 	default:
 		return TypeUnspecified, errors.Newf("Payload.Type called on a payload with an unknown details type: %T", d)
 	}
@@ -289,6 +293,7 @@ var JobDetailsForEveryJobType = map[Type]Details{
 	TypeLogicalReplication:           LogicalReplicationDetails{},
 	TypeUpdateTableMetadataCache:     UpdateTableMetadataCacheDetails{},
 	TypeStandbyReadTSPoller:          StandbyReadTSPollerDetails{},
+	TypeWorkloadIndexAdvice:          WorkloadIndexAdviceDetails{},
 }
 
 // WrapProgressDetails wraps a ProgressDetails object in the protobuf wrapper
@@ -356,6 +361,8 @@ func WrapProgressDetails(details ProgressDetails) interface {
 		return &Progress_TableMetadataCache{TableMetadataCache: &d}
 	case StandbyReadTSPollerProgress:
 		return &Progress_StandbyReadTsPoller{StandbyReadTsPoller: &d}
+	case WorkloadIndexAdviceProgress:
+		return &Progress_WorkloadIndexAdvice{WorkloadIndexAdvice: &d}
 	default:
 		panic(errors.AssertionFailedf("WrapProgressDetails: unknown progress type %T", d))
 	}
@@ -421,6 +428,8 @@ func (p *Payload) UnwrapDetails() Details {
 		return *d.UpdateTableMetadataCacheDetails
 	case *Payload_StandbyReadTsPollerDetails:
 		return *d.StandbyReadTsPollerDetails
+	case *Payload_WorkloadIndexAdviceDetails:
+		return *d.WorkloadIndexAdviceDetails
 	default:
 		return nil
 	}
@@ -486,6 +495,8 @@ func (p *Progress) UnwrapDetails() ProgressDetails {
 		return *d.TableMetadataCache
 	case *Progress_StandbyReadTsPoller:
 		return *d.StandbyReadTsPoller
+	case *Progress_WorkloadIndexAdvice:
+		return *d.WorkloadIndexAdvice
 	default:
 		return nil
 	}
@@ -575,6 +586,8 @@ func WrapPayloadDetails(details Details) interface {
 		return &Payload_UpdateTableMetadataCacheDetails{UpdateTableMetadataCacheDetails: &d}
 	case StandbyReadTSPollerDetails:
 		return &Payload_StandbyReadTsPollerDetails{StandbyReadTsPollerDetails: &d}
+	case WorkloadIndexAdviceDetails:
+		return &Payload_WorkloadIndexAdviceDetails{WorkloadIndexAdviceDetails: &d}
 	default:
 		panic(errors.AssertionFailedf("jobs.WrapPayloadDetails: unknown details type %T", d))
 	}
@@ -610,7 +623,7 @@ const (
 func (Type) SafeValue() {}
 
 // NumJobTypes is the number of jobs types.
-const NumJobTypes = 31
+const NumJobTypes = 32
 
 // ChangefeedDetailsMarshaler allows for dependency injection of
 // cloud.SanitizeExternalStorageURI to avoid the dependency from this
@@ -91,15 +91,18 @@ func rangefeedCheckpointOpMemUsage() int64 {
 // Pointer to the MVCCWriteValueOp was already accounted in mvccLogicalOp in the
 // caller. writeValueOpMemUsage accounts for the memory usage of
 // MVCCWriteValueOp.
-func writeValueOpMemUsage(key roachpb.Key, value, prevValue []byte) int64 {
-	// MVCCWriteValueOp has Key, Timestamp, Value, PrevValue, OmitInRangefeeds.
-	// Only key, value, and prevValue has underlying memory usage in []byte.
-	// Timestamp and OmitInRangefeeds have no underlying data and are already
-	// accounted in MVCCWriteValueOp.
+func writeValueOpMemUsage(key roachpb.Key, value, prevValue []byte, txnID *uuid.UUID) int64 {
+	// MVCCWriteValueOp has Key, Timestamp, Value, PrevValue, OmitInRangefeeds,
+	// and TxnID. Only key, value, prevValue, and TxnID, if set, have underlying
+	// memory usage. Timestamp and OmitInRangefeeds have no underlying data and
+	// are already accounted in MVCCWriteValueOp.
 	currMemUsage := mvccWriteValueOp
 	currMemUsage += int64(cap(key))
 	currMemUsage += int64(cap(value))
 	currMemUsage += int64(cap(prevValue))
+	if txnID != nil {
+		currMemUsage += int64(cap(*txnID))
+	}
 	return currMemUsage
 }
 
@@ -191,7 +194,7 @@ func opsCurrMemUsage(ops opsEvent) int64 {
 		// mvccLogicalOp. We now account for the underlying memory usage inside the
 		// op below.
 		case *enginepb.MVCCWriteValueOp:
-			currMemUsage += writeValueOpMemUsage(t.Key, t.Value, t.PrevValue)
+			currMemUsage += writeValueOpMemUsage(t.Key, t.Value, t.PrevValue, t.TxnID)
 		case *enginepb.MVCCDeleteRangeOp:
 			currMemUsage += deleteRangeOpMemUsage(t.StartKey, t.EndKey)
 		case *enginepb.MVCCWriteIntentOp:
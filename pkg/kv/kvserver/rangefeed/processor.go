@@ -54,6 +54,20 @@ var (
 			"(disabling may emit premature checkpoints before writes in rare cases)",
 		true,
 	)
+
+	// TxnIDsEnabled controls whether rangefeed values written by transactions
+	// carry the ID of the transaction that wrote them. Values written by
+	// non-transactional writes, and values from catch-up scans, never carry
+	// one. It is visible to virtual clusters so that changefeeds which report
+	// transaction IDs can check it.
+	TxnIDsEnabled = settings.RegisterBoolSetting(
+		settings.SystemVisible,
+		"kv.rangefeed.txn_ids.enabled",
+		"if set, rangefeed values written by transactions, including 1PC "+
+			"transactions, carry the ID of the transaction that wrote them "+
+			"(values from catch-up scans and non-transactional writes carry none)",
+		false,
+	)
 )
 
 func newRetryErrBufferCapacityExceeded() error {
@@ -159,6 +159,14 @@ func rangeFeedValue(key roachpb.Key, val roachpb.Value) *kvpb.RangeFeedEvent {
 	return rangeFeedValueWithPrev(key, val, roachpb.Value{})
 }
 
+func rangeFeedValueWithTxn(key roachpb.Key, val roachpb.Value, txnID uuid.UUID) *kvpb.RangeFeedEvent {
+	return makeRangeFeedEvent(&kvpb.RangeFeedValue{
+		Key:   key,
+		Value: val,
+		TxnID: &txnID,
+	})
+}
+
 func rangeFeedCheckpoint(span roachpb.Span, ts hlc.Timestamp) *kvpb.RangeFeedEvent {
 	return makeRangeFeedEvent(&kvpb.RangeFeedCheckpoint{
 		Span:       span,
@@ -424,6 +424,81 @@ func TestProcessorOmitRemote(t *testing.T) {
 	})
 }
 
+func TestProcessorTxnIDs(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	testutils.RunValues(t, "feed type", testTypes, func(t *testing.T, rt rangefeedTestType) {
+		st := cluster.MakeTestingClusterSettings()
+		p, h, stopper := newTestProcessor(t, withRangefeedTestType(rt), withSettings(st))
+		ctx := context.Background()
+		defer stopper.Stop(ctx)
+
+		require.NotPanics(t, func() { p.ForwardClosedTS(ctx, hlc.Timestamp{WallTime: 1}) })
+
+		rStream := newTestStream()
+		rOK, _, _ := p.Register(
+			rStream.ctx,
+			roachpb.RSpan{Key: roachpb.RKey("a"), EndKey: roachpb.RKey("m")},
+			hlc.Timestamp{WallTime: 1},
+			nil,   /* catchUpIter */
+			false, /* withDiff */
+			false, /* withFiltering */
+			false, /* withOmitRemote */
+			rStream,
+			func() {},
+		)
+		require.True(t, rOK)
+		h.syncEventAndRegistrations()
+		rStream.GetAndClearEvents()
+
+		// Transaction IDs are omitted unless enabled.
+		txn := uuid.MakeV4()
+		p.ConsumeLogicalOps(ctx,
+			commitIntentOpWithKV(txn, roachpb.Key("b"), hlc.Timestamp{WallTime: 2},
+				[]byte("val1"), false /* omitInRangefeeds */, 0 /* originID */))
+		h.syncEventAndRegistrations()
+		require.Equal(t,
+			[]*kvpb.RangeFeedEvent{rangeFeedValue(
+				roachpb.Key("b"),
+				roachpb.Value{RawBytes: []byte("val1"), Timestamp: hlc.Timestamp{WallTime: 2}},
+			)},
+			rStream.GetAndClearEvents(),
+		)
+
+		// Once enabled, values committed through intent resolution and values
+		// written by 1PC transactions carry the ID of their transaction, while
+		// non-transactional writes carry none.
+		TxnIDsEnabled.Override(ctx, &st.SV, true)
+		onePCTxn := uuid.MakeV4()
+		onePCOp := writeValueOpWithKV(roachpb.Key("e"), hlc.Timestamp{WallTime: 5}, []byte("val4"))
+		onePCOp.WriteValue.TxnID = &onePCTxn
+		p.ConsumeLogicalOps(ctx,
+			commitIntentOpWithKV(txn, roachpb.Key("c"), hlc.Timestamp{WallTime: 3},
+				[]byte("val2"), false /* omitInRangefeeds */, 0 /* originID */),
+			writeValueOpWithKV(roachpb.Key("d"), hlc.Timestamp{WallTime: 4}, []byte("val3")),
+			onePCOp)
+		h.syncEventAndRegistrations()
+		require.Equal(t,
+			[]*kvpb.RangeFeedEvent{
+				rangeFeedValueWithTxn(
+					roachpb.Key("c"),
+					roachpb.Value{RawBytes: []byte("val2"), Timestamp: hlc.Timestamp{WallTime: 3}},
+					txn,
+				),
+				rangeFeedValue(
+					roachpb.Key("d"),
+					roachpb.Value{RawBytes: []byte("val3"), Timestamp: hlc.Timestamp{WallTime: 4}},
+				),
+				rangeFeedValueWithTxn(
+					roachpb.Key("e"),
+					roachpb.Value{RawBytes: []byte("val4"), Timestamp: hlc.Timestamp{WallTime: 5}},
+					onePCTxn,
+				),
+			},
+			rStream.GetAndClearEvents(),
+		)
+	})
+}
+
 func TestProcessorSlowConsumer(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	testutils.RunValues(t, "feed type", testTypes, func(t *testing.T, rt rangefeedTestType) {
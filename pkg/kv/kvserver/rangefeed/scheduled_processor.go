@@ -16,6 +16,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/stop"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/cockroachdb/errors"
 )
 
@@ -673,8 +674,13 @@ func (p *ScheduledProcessor) consumeLogicalOps(
 		// MVCCWriteValueOp (could be the result of a 1PC write).
 
 		case *enginepb.MVCCWriteValueOp:
-			// Publish the new value directly.
-			p.publishValue(ctx, t.Key, t.Timestamp, t.Value, t.PrevValue, logicalOpMetadata{omitInRangefeeds: t.OmitInRangefeeds, originID: t.OriginID}, alloc)
+			// Publish the new value directly, along with the ID of the 1PC
+			// transaction that wrote it, if any, if requested.
+			var txnID *uuid.UUID
+			if TxnIDsEnabled.Get(&p.Settings.SV) {
+				txnID = t.TxnID
+			}
+			p.publishValue(ctx, t.Key, t.Timestamp, t.Value, t.PrevValue, txnID, logicalOpMetadata{omitInRangefeeds: t.OmitInRangefeeds, originID: t.OriginID}, alloc)
 		case *enginepb.MVCCDeleteRangeOp:
 			// Publish the range deletion directly.
 			p.publishDeleteRange(ctx, t.StartKey, t.EndKey, t.Timestamp, alloc)
@@ -686,8 +692,14 @@ func (p *ScheduledProcessor) consumeLogicalOps(
 			// No updates to publish.
 
 		case *enginepb.MVCCCommitIntentOp:
-			// Publish the newly committed value.
-			p.publishValue(ctx, t.Key, t.Timestamp, t.Value, t.PrevValue, logicalOpMetadata{omitInRangefeeds: t.OmitInRangefeeds, originID: t.OriginID}, alloc)
+			// Publish the newly committed value, along with the ID of the
+			// transaction that wrote it if requested.
+			var txnID *uuid.UUID
+			if TxnIDsEnabled.Get(&p.Settings.SV) {
+				id := t.TxnID
+				txnID = &id
+			}
+			p.publishValue(ctx, t.Key, t.Timestamp, t.Value, t.PrevValue, txnID, logicalOpMetadata{omitInRangefeeds: t.OmitInRangefeeds, originID: t.OriginID}, alloc)
 
 		case *enginepb.MVCCAbortIntentOp:
 			// No updates to publish.
@@ -736,6 +748,7 @@ func (p *ScheduledProcessor) publishValue(
 	key roachpb.Key,
 	timestamp hlc.Timestamp,
 	value, prevValue []byte,
+	txnID *uuid.UUID,
 	valueMetadata logicalOpMetadata,
 	alloc *SharedBudgetAllocation,
 ) {
@@ -755,6 +768,7 @@ func (p *ScheduledProcessor) publishValue(
 			Timestamp: timestamp,
 		},
 		PrevValue: prevVal,
+		TxnID:     txnID,
 	})
 	p.reg.PublishToOverlapping(ctx, roachpb.Span{Key: key}, &event, valueMetadata, alloc)
 }
@@ -18,6 +18,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvadmission"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverbase"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverpb"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/rangefeed"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/spanset"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/uncertainty"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
@@ -614,6 +615,18 @@ func (r *Replica) evaluate1PC(
 				pErr:    kvpb.NewError(err),
 			}
 		}
+		// The writes were evaluated without the transaction, so they were
+		// logged as non-transactional writes. Attach the ID of the transaction
+		// to them, so that rangefeeds can report it like for the values
+		// committed through intent resolution.
+		if res.LogicalOpLog != nil && rangefeed.TxnIDsEnabled.Get(&r.store.cfg.Settings.SV) {
+			txnID := ba.Txn.ID
+			for _, op := range res.LogicalOpLog.Ops {
+				if t, ok := op.GetValue().(*enginepb.MVCCWriteValueOp); ok {
+					t.TxnID = &txnID
+				}
+			}
+		}
 	}
 
 	// Even though the transaction is 1PC and hasn't written any intents, it may
@@ -145,6 +145,20 @@ func NewTxnWithAdmissionControl(
 	gatewayNodeID roachpb.NodeID,
 	source kvpb.AdmissionHeader_Source,
 	priority admissionpb.WorkPriority,
+) *Txn {
+	return newTxnWithAdmissionControlAt(ctx, db, gatewayNodeID, source, priority, hlc.Timestamp{})
+}
+
+// newTxnWithAdmissionControlAt is like NewTxnWithAdmissionControl, but the
+// transaction starts at the given timestamp. If the timestamp is empty, the
+// current time is used.
+func newTxnWithAdmissionControlAt(
+	ctx context.Context,
+	db *DB,
+	gatewayNodeID roachpb.NodeID,
+	source kvpb.AdmissionHeader_Source,
+	priority admissionpb.WorkPriority,
+	readTS hlc.Timestamp,
 ) *Txn {
 	if db == nil {
 		panic(errors.WithContextTags(
@@ -152,12 +166,15 @@ func NewTxnWithAdmissionControl(
 	}
 
 	now := db.clock.NowAsClockTimestamp()
+	if readTS.IsEmpty() {
+		readTS = now.ToTimestamp()
+	}
 	kvTxn := roachpb.MakeTransaction(
 		"unnamed",
 		nil, // baseKey
 		isolation.Serializable,
 		roachpb.NormalUserPriority,
-		now.ToTimestamp(),
+		readTS,
 		db.clock.MaxOffset().Nanoseconds(),
 		int32(db.ctx.NodeID.SQLInstanceID()),
 		priority,
@@ -189,6 +206,25 @@ func NewTxnWithSteppingEnabled(
 	return txn
 }
 
+// NewTxnWithSteppingEnabledAt is like NewTxnWithSteppingEnabled, but the
+// transaction reads at the given timestamp, which must not be in the future,
+// instead of at the current time. Its uncertainty interval starts at readTS,
+// so it is still subject to uncertainty restarts. This allows multiple
+// transactions to read at the same timestamp, as long as none of them needs to
+// move its read timestamp.
+func NewTxnWithSteppingEnabledAt(
+	ctx context.Context,
+	db *DB,
+	gatewayNodeID roachpb.NodeID,
+	readTS hlc.Timestamp,
+	qualityOfService sessiondatapb.QoSLevel,
+) *Txn {
+	txn := newTxnWithAdmissionControlAt(ctx, db, gatewayNodeID,
+		kvpb.AdmissionHeader_FROM_SQL, admissionpb.WorkPriority(qualityOfService), readTS)
+	_ = txn.ConfigureStepping(ctx, SteppingEnabled)
+	return txn
+}
+
 // NewTxnRootKV is like NewTxn but specifically represents a transaction
 // originating within KV and that is at the root of the tree of requests. For KV
 // usage that should be subject to admission control. Do not use this for
@@ -4235,6 +4235,55 @@ func (s *statusServer) GetUpdateTableMetadataCacheSignal() chan struct{} {
 	return s.updateTableMetadataJobSignal
 }
 
+// WorkloadIndexAdvice returns the advice computed by the latest successful
+// workload index advice job, restricted to the jobs over the statements
+// executed since the requested start time if any. If requested, it also
+// starts a new job, defaulting to the statements of the last day, without
+// waiting for it to complete.
+func (s *statusServer) WorkloadIndexAdvice(
+	ctx context.Context, req *serverpb.WorkloadIndexAdviceRequest,
+) (*serverpb.WorkloadIndexAdviceResponse, error) {
+	ctx = authserver.ForwardSQLIdentityThroughRPCCalls(ctx)
+	ctx = s.AnnotateCtx(ctx)
+
+	if err := s.privilegeChecker.RequireViewActivityOrViewActivityRedactedPermission(ctx); err != nil {
+		return nil, err
+	}
+	user, _, err := s.privilegeChecker.GetUserAndRole(ctx)
+	if err != nil {
+		return nil, srverrors.ServerError(ctx, err)
+	}
+
+	resp := &serverpb.WorkloadIndexAdviceResponse{}
+	execCfg := s.sqlServer.execCfg
+	if err := execCfg.InternalDB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		*resp = serverpb.WorkloadIndexAdviceResponse{}
+		advice, err := sql.LatestWorkloadIndexAdvice(ctx, execCfg, txn, req.Start)
+		if err != nil {
+			return err
+		}
+		if advice != nil {
+			resp.JobId = int64(advice.JobID)
+			resp.Advice = advice.Advice
+			resp.Start = &advice.Since
+			resp.CompletedAt = &advice.Finished
+		}
+		if !req.Refresh {
+			return nil
+		}
+		start := timeutil.Now().Add(-24 * time.Hour)
+		if req.Start != nil {
+			start = *req.Start
+		}
+		jobID, err := sql.CreateWorkloadIndexAdviceJob(ctx, execCfg, txn, user, start)
+		resp.RefreshJobId = int64(jobID)
+		return err
+	}); err != nil {
+		return nil, srverrors.ServerError(ctx, err)
+	}
+	return resp, nil
+}
+
 func (s *statusServer) GetThrottlingMetadata(
 	ctx context.Context, req *serverpb.GetThrottlingMetadataRequest,
 ) (*serverpb.GetThrottlingMetadataResponse, error) {
@@ -1316,6 +1316,7 @@ func (ex *connExecutor) close(ctx context.Context, closeType closeType) {
 		ex.state.finishExternalTxn()
 	}
 
+	ex.resetParallelReadBatch(ctx)
 	ex.resetExtraTxnState(ctx, txnEvent{eventType: txnEvType}, payloadErr)
 	if ex.hasCreatedTemporarySchema && !ex.server.cfg.TestingKnobs.DisableTempObjectsCleanupOnSessionExit {
 		err := cleanupSessionTempObjects(
@@ -1469,6 +1470,12 @@ type connExecutor struct {
 		// transaction has been executed.
 		firstStmtExecuted bool
 
+		// parallelReadTimestamp is the read timestamp of the parallel read
+		// batch started in this transaction, if any. The transaction fails to
+		// commit if its read timestamp moved past it, since the results of the
+		// batch wouldn't be consistent with the rest of the transaction.
+		parallelReadTimestamp hlc.Timestamp
+
 		// upgradedToSerializable indicates that the transaction has been implicitly
 		// upgraded to the SERIALIZABLE isolation level.
 		upgradedToSerializable bool
@@ -1774,6 +1781,10 @@ type connExecutor struct {
 	// temporary schema, which requires special cleanup on close.
 	hasCreatedTemporarySchema bool
 
+	// parallelReads tracks the read-only statements of the current batch that
+	// are being executed concurrently. See maybeStartParallelReadBatch.
+	parallelReads parallelReadBatch
+
 	// stmtDiagnosticsRecorder is used to track which queries need to have
 	// information collected.
 	stmtDiagnosticsRecorder *stmtdiagnostics.Registry
@@ -2097,6 +2108,12 @@ func (ex *connExecutor) resetExtraTxnState(ctx context.Context, ev txnEvent, pay
 	ex.extraTxnState.upgradedToSerializable = false
 	ex.extraTxnState.hasAdminRoleCache = HasAdminRoleCache{}
 	ex.extraTxnState.createdSequences = nil
+	// Parallel reads started in this transaction can't be served in the next
+	// one, nor after a restart.
+	if ev.eventType == txnRestart || !ex.extraTxnState.parallelReadTimestamp.IsEmpty() {
+		ex.resetParallelReadBatch(ctx)
+	}
+	ex.extraTxnState.parallelReadTimestamp = hlc.Timestamp{}
 
 	if ex.extraTxnState.skipResettingSchemaObjects {
 		if ex.extraTxnState.shouldResetSyntheticDescriptors {
@@ -2334,6 +2351,7 @@ func (ex *connExecutor) execCmd() (retErr error) {
 				PortalPausabilityDisabled, /* portalPausability */
 			)
 			res = stmtRes
+			ex.maybeStartParallelReadBatch(ctx, tcmd, pos)
 
 			// In the simple protocol, autocommit only when this is the last statement
 			// in the batch. This matches the Postgres behavior. See
@@ -2442,6 +2460,7 @@ func (ex *connExecutor) execCmd() (retErr error) {
 				portal.pauseInfo.curRes = stmtRes
 			}
 			res = stmtRes
+			ex.maybeStartParallelReadBatch(ctx, tcmd, pos)
 
 			// In the extended protocol, autocommit is not always allowed. The postgres
 			// docs say that commands in the extended protocol are all treated as an
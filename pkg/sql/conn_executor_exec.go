@@ -1567,6 +1567,16 @@ func (ex *connExecutor) commitSQLTransactionInternal(ctx context.Context) (retEr
 
 	ex.extraTxnState.prepStmtsNamespace.closeAllPortals(ctx, &ex.extraTxnState.prepStmtsNamespaceMemAcc)
 
+	// The results of a parallel read batch were read at the original read
+	// timestamp of the transaction, so the transaction needs to be retried if
+	// it moved its read timestamp since.
+	if readTS := ex.extraTxnState.parallelReadTimestamp; !readTS.IsEmpty() &&
+		ex.state.mu.txn.ReadTimestamp() != readTS {
+		return ex.state.mu.txn.GenerateForcedRetryableErr(
+			ctx, "read timestamp moved after parallel reads",
+		)
+	}
+
 	// We need to step the transaction's internal read sequence before committing
 	// if it has stepping enabled. If it doesn't have stepping enabled, then we
 	// just set the stepping mode back to what it was.
@@ -1978,9 +1988,14 @@ func (ex *connExecutor) dispatchToExecutionEngine(
 		distribute = FullDistribution
 	}
 	ex.sessionTracing.TraceExecStart(ctx, "distributed")
-	stats, err := ex.execWithDistSQLEngine(
-		ctx, planner, stmt.AST.StatementReturnType(), res, distribute, progAtomic, distSQLProhibitedErr,
-	)
+	// The statement might have already been executed as part of a parallel
+	// read batch, in which case its result only needs to be sent.
+	stats, served, err := ex.maybeServeParallelRead(ctx, planner, res)
+	if !served {
+		stats, err = ex.execWithDistSQLEngine(
+			ctx, planner, stmt.AST.StatementReturnType(), res, distribute, progAtomic, distSQLProhibitedErr,
+		)
+	}
 	if ppInfo := getPausablePortalInfo(); ppInfo != nil {
 		// For pausable portals, we log the stats when closing the portal, so we need
 		// to aggregate the stats for all executions.
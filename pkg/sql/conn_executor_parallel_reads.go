@@ -0,0 +1,678 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package sql
+
+import (
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/colinfo"
+	"github.com/cockroachdb/cockroach/pkg/sql/clusterunique"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/memo"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgwirebase"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/volatility"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/mon"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing"
+	"github.com/cockroachdb/cockroach/pkg/util/tracing/tracingpb"
+	"github.com/cockroachdb/errors"
+	"github.com/lib/pq/oid"
+)
+
+// parallelReadBatchesEnabled controls whether consecutive read-only statements
+// of a batch are executed concurrently.
+var parallelReadBatchesEnabled = settings.RegisterBoolSetting(
+	settings.ApplicationLevel,
+	"sql.parallel_read_batches.enabled",
+	"if set, consecutive read-only statements of a multi-statement query string "+
+		"or of a pipelined extended protocol batch are executed concurrently at "+
+		"the same read timestamp, when they run outside of a transaction or in a "+
+		"read-only transaction",
+	false,
+)
+
+// maxParallelReadBatchSize is the maximum number of statements of a batch that
+// are executed concurrently.
+const maxParallelReadBatchSize = 16
+
+// parallelReadBatch tracks the statements of a batch that are being executed
+// concurrently, ahead of the connExecutor reaching them in the stmtBuf.
+type parallelReadBatch struct {
+	// results maps the position of each statement in the stmtBuf to its
+	// result.
+	results map[CmdPos]*parallelReadResult
+	// cancel cancels the context of all statements of the batch.
+	cancel context.CancelFunc
+}
+
+// parallelReadResult is the buffered result of a single statement executed
+// as part of a parallel read batch.
+type parallelReadResult struct {
+	// sql and args are the statement and the values of its placeholders, and
+	// typeHints are the types of the placeholders of a prepared statement.
+	sql       string
+	args      tree.Datums
+	typeHints tree.PlaceholderTypes
+	// cancel cancels the execution of the statement.
+	cancel context.CancelFunc
+	// done is closed once the statement finishes executing, at which point
+	// the fields below can be accessed.
+	done chan struct{}
+	// acc accounts for the memory used by rows. It is closed once the result
+	// is served or discarded.
+	acc  mon.BoundAccount
+	cols colinfo.ResultColumns
+	rows []tree.Datums
+	err  error
+	// stats, flowInfos and trace are the execution statistics of the
+	// statement, which are attributed to it once it is served. vectorized is
+	// set if its main query was executed by the vectorized engine.
+	stats      topLevelQueryStats
+	flowInfos  []flowInfo
+	trace      tracingpb.Recording
+	vectorized bool
+}
+
+// errParallelReadTimestampMoved is returned by the execution of a statement of
+// a parallel read batch that had to move its read timestamp, e.g. because of
+// an uncertainty restart, so its result can't be served along with the results
+// of the other statements.
+var errParallelReadTimestampMoved = errors.New("parallel read moved its read timestamp")
+
+// isParallelizableRead returns whether the given statement can be executed
+// concurrently with other such statements at a fixed read timestamp. Only
+// plain SELECT statements over tables without locking, CTEs, non-immutable
+// function calls, and AS OF SYSTEM TIME clauses are allowed since they are
+// guaranteed to have no side effects and to produce the same result regardless
+// of when and in which transaction they run.
+func isParallelizableRead(stmt tree.Statement) bool {
+	sel, ok := stmt.(*tree.Select)
+	if !ok || sel.With != nil || len(sel.Locking) != 0 {
+		return false
+	}
+	clause, ok := sel.Select.(*tree.SelectClause)
+	if !ok || clause.From.AsOf.Expr != nil {
+		return false
+	}
+	for _, table := range clause.From.Tables {
+		if !isParallelizableTableExpr(table) {
+			return false
+		}
+	}
+	_, err := tree.SimpleStmtVisit(sel, checkParallelizableExpr)
+	return err == nil
+}
+
+// isParallelizableTableExpr returns whether the given table expression only
+// reads from tables or from parallelizable subqueries.
+func isParallelizableTableExpr(expr tree.TableExpr) bool {
+	switch t := expr.(type) {
+	case *tree.TableName:
+		return true
+	case *tree.AliasedTableExpr:
+		if t.Lateral {
+			return false
+		}
+		return isParallelizableTableExpr(t.Expr)
+	case *tree.ParenTableExpr:
+		return isParallelizableTableExpr(t.Expr)
+	case *tree.Subquery:
+		ps, ok := t.Select.(*tree.ParenSelect)
+		return ok && isParallelizableRead(ps.Select)
+	case *tree.JoinTableExpr:
+		if !isParallelizableTableExpr(t.Left) || !isParallelizableTableExpr(t.Right) {
+			return false
+		}
+		if on, ok := t.Cond.(*tree.OnJoinCond); ok {
+			_, err := tree.SimpleVisit(on.Expr, checkParallelizableExpr)
+			return err == nil
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+var errNotParallelizable = errors.New("statement cannot be executed in parallel")
+
+// isImmutableBuiltin returns whether the given function call refers to a
+// builtin function whose overloads are all immutable, such as count, sum or
+// max. User-defined functions are never considered immutable since resolving
+// them requires a transaction. The optimizer checks the volatility of the
+// resolved overloads again when the statement is planned, see
+// runParallelRead.
+func isImmutableBuiltin(f *tree.FuncExpr) bool {
+	name, ok := f.Func.FunctionReference.(*tree.UnresolvedName)
+	if !ok {
+		return false
+	}
+	fn, err := name.ToRoutineName()
+	if err != nil {
+		return false
+	}
+	def, err := tree.GetBuiltinFuncDefinition(fn, &sessiondata.DefaultSearchPath)
+	if err != nil || def == nil {
+		return false
+	}
+	for _, o := range def.Overloads {
+		if o.Volatility > volatility.Immutable {
+			return false
+		}
+	}
+	return true
+}
+
+// checkParallelizableExpr is a tree.SimpleVisitFn that returns an error for
+// calls of functions that aren't immutable builtins, which might have side
+// effects or depend on the time and the transaction in which they are
+// evaluated, and for subqueries that aren't parallelizable.
+func checkParallelizableExpr(expr tree.Expr) (recurse bool, newExpr tree.Expr, err error) {
+	switch t := expr.(type) {
+	case *tree.FuncExpr:
+		if !isImmutableBuiltin(t) {
+			return false, expr, errNotParallelizable
+		}
+	case *tree.Subquery:
+		if ps, ok := t.Select.(*tree.ParenSelect); !ok || !isParallelizableRead(ps.Select) {
+			return false, expr, errNotParallelizable
+		}
+	}
+	return true, expr, nil
+}
+
+// maybeStartParallelReadBatch checks whether the command at the given position
+// starts a run of parallelizable read-only statements and, if so, starts
+// executing all of them concurrently at the same read timestamp. The results
+// are served by maybeServeParallelRead once the connExecutor reaches each
+// statement.
+//
+// Runs are made of ExecStmt commands in the simple protocol, and of BindStmt,
+// DescribeStmt and ExecPortal commands of already prepared statements in the
+// extended protocol. Unless in a read-only transaction, the statements must
+// make up the remainder of the batch, so that the implicit transaction of the
+// batch doesn't write after the reads. Outside of a transaction, the
+// statements read at the current time. In a read-only transaction, or in an
+// implicit transaction that hasn't executed any statement yet, they read at
+// the read timestamp of the transaction, and the transaction fails to commit
+// if its read timestamp moves afterwards.
+func (ex *connExecutor) maybeStartParallelReadBatch(ctx context.Context, cmd Command, pos CmdPos) {
+	if ex.parallelReads.results != nil || ex.executorType == executorTypeInternal ||
+		!parallelReadBatchesEnabled.Get(&ex.server.cfg.Settings.SV) {
+		return
+	}
+	var readTS hlc.Timestamp
+	var inTxn, readOnlyTxn bool
+	switch ex.machine.CurState().(type) {
+	case stateNoTxn:
+		readTS = ex.server.cfg.Clock.Now()
+	case stateOpen:
+		if ex.implicitTxn() && ex.extraTxnState.firstStmtExecuted ||
+			!ex.implicitTxn() && !ex.state.readOnly.Load() ||
+			ex.state.mu.txn.IsoLevel().PerStatementReadSnapshot() {
+			return
+		}
+		readTS = ex.state.mu.txn.ReadTimestamp()
+		inTxn = true
+		readOnlyTxn = !ex.implicitTxn()
+	default:
+		return
+	}
+
+	var stmts []parallelReadResult
+	var complete bool
+	switch tcmd := cmd.(type) {
+	case ExecStmt:
+		if tcmd.AST == nil || !isParallelizableRead(tcmd.AST) {
+			return
+		}
+		stmts = append(stmts, parallelReadResult{sql: tcmd.SQL})
+		complete = tcmd.LastInBatch
+		if !complete {
+			ex.stmtBuf.peekBatch(pos+1, func(cmd Command) (more bool) {
+				next, ok := cmd.(ExecStmt)
+				if !ok || next.AST == nil || !isParallelizableRead(next.AST) ||
+					len(stmts) == maxParallelReadBatchSize {
+					return false
+				}
+				stmts = append(stmts, parallelReadResult{sql: next.SQL})
+				complete = next.LastInBatch
+				return !complete
+			})
+		}
+	case ExecPortal:
+		portal, ok := ex.extraTxnState.prepStmtsNamespace.portals[tcmd.Name]
+		if !ok || tcmd.Limit != 0 || portal.Stmt.AST == nil || !isParallelizableRead(portal.Stmt.AST) {
+			return
+		}
+		args, ok := placeholderDatums(portal.Qargs)
+		if !ok {
+			return
+		}
+		stmts = append(stmts, parallelReadResult{
+			sql: portal.Stmt.SQL, args: args, typeHints: portal.Stmt.Types,
+		})
+		var bind *BindStmt
+		ex.stmtBuf.peekBatch(pos+1, func(cmd Command) (more bool) {
+			switch next := cmd.(type) {
+			case BindStmt:
+				if bind != nil || len(stmts) == maxParallelReadBatchSize {
+					return false
+				}
+				bind = &next
+				return true
+			case DescribeStmt:
+				return true
+			case ExecPortal:
+				if bind == nil || next.Name != bind.PortalName || next.Limit != 0 {
+					return false
+				}
+				ps, ok := ex.extraTxnState.prepStmtsNamespace.prepStmts[bind.PreparedStatementName]
+				if !ok || ps.AST == nil || !isParallelizableRead(ps.AST) {
+					return false
+				}
+				args, ok := ex.decodeParallelReadArgs(ctx, ps, bind)
+				if !ok {
+					return false
+				}
+				stmts = append(stmts, parallelReadResult{
+					sql: ps.SQL, args: args, typeHints: ps.Types,
+				})
+				bind = nil
+				return true
+			case Sync:
+				complete = bind == nil
+				return false
+			default:
+				return false
+			}
+		})
+		// The commands of an incomplete statement at the end of the run are
+		// executed normally.
+	default:
+		return
+	}
+	if len(stmts) < 2 || !readOnlyTxn && !complete {
+		// Either there is nothing to execute concurrently, or the remainder of
+		// the batch isn't in the buffer yet or contains statements that must be
+		// executed serially, which might write in the same transaction after
+		// the reads.
+		return
+	}
+
+	sd := ex.sessionData().Clone()
+	// The statements outlive the execution of the current command, so they
+	// use the context of the session.
+	batchCtx, cancel := ex.server.cfg.Stopper.WithCancelOnQuiesce(ex.ctxHolder.ctx())
+	ex.parallelReads = parallelReadBatch{
+		results: make(map[CmdPos]*parallelReadResult, len(stmts)),
+		cancel:  cancel,
+	}
+	if inTxn {
+		ex.extraTxnState.parallelReadTimestamp = readTS
+	}
+	for i := range stmts {
+		res := &stmts[i]
+		res.done = make(chan struct{})
+		res.acc = ex.sessionMon.MakeBoundAccount()
+		ex.parallelReads.results[ex.parallelReadPos(pos, i)] = res
+		stmtCtx, stmtCancel := context.WithCancel(batchCtx)
+		res.cancel = stmtCancel
+		if err := ex.server.cfg.Stopper.RunAsyncTask(stmtCtx, "parallel-read", func(ctx context.Context) {
+			defer close(res.done)
+			res.err = ex.runParallelRead(ctx, sd, readTS, res)
+		}); err != nil {
+			res.err = err
+			close(res.done)
+		}
+	}
+	if log.V(2) {
+		log.Infof(ctx, "executing %d statements in parallel at %s", len(stmts), readTS)
+	}
+}
+
+// parallelReadPos returns the position in the stmtBuf of the i-th statement of
+// a parallel read batch starting at the given position. In the extended
+// protocol, each ExecPortal command after the first one is preceded by a
+// BindStmt command and optionally by DescribeStmt commands.
+func (ex *connExecutor) parallelReadPos(start CmdPos, i int) CmdPos {
+	if i == 0 {
+		return start
+	}
+	pos := start
+	ex.stmtBuf.peekBatch(start+1, func(cmd Command) (more bool) {
+		pos++
+		switch cmd.(type) {
+		case ExecStmt, ExecPortal:
+			i--
+		}
+		return i > 0
+	})
+	return pos
+}
+
+// runParallelRead executes a statement of a parallel read batch in its own
+// transaction reading at readTS, and buffers its result. The statement is
+// planned and executed by a planner of its own with the session data of the
+// session, the same way as the connExecutor would, and its execution
+// statistics are always collected since it isn't known yet whether they will
+// be needed once it is served. The transaction is subject to uncertainty
+// restarts like any other, but the result is discarded if the transaction had
+// to move its read timestamp.
+func (ex *connExecutor) runParallelRead(
+	ctx context.Context, sd *sessiondata.SessionData, readTS hlc.Timestamp, res *parallelReadResult,
+) (retErr error) {
+	if fn := ex.server.cfg.TestingKnobs.BeforeParallelRead; fn != nil {
+		fn(ctx, res.sql)
+	}
+	// The statement is parsed again since planning it might modify its AST,
+	// which is shared with the connExecutor.
+	parsed, err := parser.ParseOne(res.sql)
+	if err != nil {
+		return err
+	}
+	ctx, sp := tracing.EnsureChildSpan(
+		ctx, ex.server.cfg.AmbientCtx.Tracer, "parallel read",
+		tracing.WithRecording(tracingpb.RecordingStructured),
+	)
+	defer func() {
+		res.trace = sp.FinishAndGetConfiguredRecording()
+	}()
+	txn := kv.NewTxnWithSteppingEnabledAt(
+		ctx, ex.transitionCtx.db, ex.transitionCtx.nodeIDOrZero, readTS, ex.QualityOfService(),
+	)
+	defer func() {
+		if retErr != nil {
+			_ = txn.Rollback(ctx)
+			res.rows = nil
+			res.flowInfos = nil
+			res.acc.Clear(ctx)
+		}
+	}()
+
+	p, cleanup := newInternalPlanner(
+		"parallel-read", txn, sd.User(), &ex.memMetrics, ex.server.cfg, sd,
+	)
+	defer cleanup()
+	// Internal planners use the default search path and are allowed to access
+	// internal objects, unlike the session.
+	p.SessionData().SearchPath = sd.SearchPath
+	p.SessionData().Internal = sd.Internal
+	p.stmt = makeStatement(parsed, clusterunique.ID{}, /* queryID */
+		tree.FmtFlags(queryFormattingForFingerprintsMask.Get(&ex.server.cfg.Settings.SV)))
+	p.semaCtx.Placeholders.Init(parsed.NumPlaceholders, res.typeHints)
+	p.semaCtx.Placeholders.Values = make(tree.QueryArguments, len(res.args))
+	for i := range res.args {
+		p.semaCtx.Placeholders.Values[i] = res.args[i]
+	}
+	p.instrumentation.collectExecStats = true
+	if err := p.makeOptimizerPlan(ctx); err != nil {
+		return err
+	}
+	defer p.curPlan.close(ctx)
+	// The function calls were only checked by name, so the statement isn't
+	// executed if any of the resolved overloads turns out not to be immutable.
+	if vs := p.curPlan.mem.RootExpr().(memo.RelExpr).Relational().VolatilitySet; vs.HasStable() || vs.HasVolatile() {
+		return errNotParallelizable
+	}
+
+	rw := NewCallbackResultWriter(func(ctx context.Context, row tree.Datums) error {
+		var size int64
+		for _, d := range row {
+			size += int64(d.Size())
+		}
+		if err := res.acc.Grow(ctx, size); err != nil {
+			return err
+		}
+		res.rows = append(res.rows, append(tree.Datums(nil), row...))
+		return nil
+	})
+	recv := MakeDistSQLReceiver(
+		ctx, rw, tree.Rows,
+		ex.server.cfg.RangeDescriptorCache,
+		txn,
+		ex.server.cfg.Clock,
+		p.ExtendedEvalContext().Tracing,
+	)
+	defer recv.Release()
+	distributePlan, distSQLProhibitedErr := getPlanDistribution(
+		ctx, p.Descriptors().HasUncommittedTypes(),
+		p.SessionData().DistSQLMode, p.curPlan.main, &p.distSQLVisitor,
+	)
+	distribute := DistributionType(LocalDistribution)
+	if distributePlan.WillDistribute() {
+		distribute = FullDistribution
+	}
+	evalCtx := p.ExtendedEvalContext()
+	planCtx := ex.server.cfg.DistSQLPlanner.NewPlanningCtx(ctx, evalCtx, p, txn, distribute)
+	planCtx.setUpForMainQuery(ctx, p, recv)
+	planCtx.distSQLProhibitedErr = distSQLProhibitedErr
+	evalCtxFactory := func(bool) *extendedEvalContext {
+		return p.ExtendedEvalContextCopy()
+	}
+	if err := ex.server.cfg.DistSQLPlanner.PlanAndRunAll(
+		ctx, evalCtx, planCtx, p, recv, evalCtxFactory,
+	); err != nil {
+		return err
+	}
+	if err := rw.Err(); err != nil {
+		return err
+	}
+	res.cols = p.curPlan.main.planColumns()
+	res.stats = recv.stats
+	res.flowInfos = p.curPlan.distSQLFlowInfos
+	res.vectorized = p.curPlan.flags.IsSet(planFlagVectorized)
+	if txn.ReadTimestamp() != readTS {
+		return errParallelReadTimestampMoved
+	}
+	return txn.Commit(ctx)
+}
+
+// decodeParallelReadArgs decodes the arguments of the given BindStmt for the
+// prepared statement ps. It returns false if the arguments can't be decoded
+// ahead of the execution of the BindStmt, in which case the statement can't be
+// executed in parallel.
+func (ex *connExecutor) decodeParallelReadArgs(
+	ctx context.Context, ps *PreparedStatement, bind *BindStmt,
+) (tree.Datums, bool) {
+	if bind.internalArgs != nil || len(bind.Args) != len(ps.InferredTypes) {
+		return nil, false
+	}
+	formatCodes := bind.ArgFormatCodes
+	if len(formatCodes) != 1 && len(formatCodes) != len(bind.Args) {
+		return nil, false
+	}
+	var alloc tree.DatumAlloc
+	args := make(tree.Datums, len(bind.Args))
+	for i, arg := range bind.Args {
+		if arg == nil {
+			args[i] = tree.DNull
+			continue
+		}
+		// Only the arguments of builtin types are decoded, since resolving
+		// user-defined types requires a transaction.
+		t := ps.InferredTypes[i]
+		typ, ok := types.OidToType[t]
+		if !ok {
+			if t != oid.T_json {
+				return nil, false
+			}
+			typ = types.Json
+		}
+		formatCode := formatCodes[0]
+		if len(formatCodes) > 1 {
+			formatCode = formatCodes[i]
+		}
+		d, err := pgwirebase.DecodeDatum(ctx, ex.planner.EvalContext(), typ, formatCode, arg, &alloc)
+		if err != nil {
+			return nil, false
+		}
+		args[i] = d
+	}
+	return args, true
+}
+
+// placeholderDatums returns the values of the given placeholders, which are
+// expected to be datums.
+func placeholderDatums(qargs tree.QueryArguments) (tree.Datums, bool) {
+	args := make(tree.Datums, len(qargs))
+	for i, arg := range qargs {
+		d, ok := arg.(tree.Datum)
+		if !ok {
+			return nil, false
+		}
+		args[i] = d
+	}
+	return args, true
+}
+
+// matches returns whether the result is the one of the given statement with
+// the given placeholder values.
+func (r *parallelReadResult) matches(sql string, placeholders *tree.PlaceholderInfo) bool {
+	var values tree.QueryArguments
+	if placeholders != nil {
+		values = placeholders.Values
+	}
+	if r.sql != sql || len(r.args) != len(values) {
+		return false
+	}
+	for i := range r.args {
+		if tree.AsStringWithFlags(r.args[i], tree.FmtParsable) !=
+			tree.AsStringWithFlags(values[i], tree.FmtParsable) {
+			return false
+		}
+	}
+	return true
+}
+
+// discard cancels the execution of the statement, waits for it to finish, and
+// releases the memory of its result.
+func (r *parallelReadResult) discard(ctx context.Context) {
+	r.cancel()
+	<-r.done
+	r.rows = nil
+	r.acc.Close(ctx)
+}
+
+// maybeServeParallelRead writes the result of the statement being executed to
+// res if it was executed as part of a parallel read batch, blocking until its
+// execution finishes. It is called in place of the execution of the plan of
+// the statement, once the statement went through planning and was registered
+// as an active query, so that it is reported, logged, and canceled like any
+// other statement. The execution statistics of the parallel execution are
+// returned and, if the statement is being traced, its trace and flows are
+// attributed to the statement, so that its statement statistics are recorded
+// like for any other statement. It returns false if the statement needs to be
+// executed normally, which is also the case if its parallel execution failed,
+// if it produced columns of different types than the ones of the statement,
+// or if a statement bundle is being collected for it. The returned error is
+// only set if the result couldn't be sent to the client.
+func (ex *connExecutor) maybeServeParallelRead(
+	ctx context.Context, planner *planner, res RestrictedCommandResult,
+) (stats topLevelQueryStats, served bool, _ error) {
+	if ex.parallelReads.results == nil {
+		return stats, false, nil
+	}
+	_, pos, err := ex.stmtBuf.CurCmd()
+	if err != nil {
+		return stats, false, nil //nolint:returnerrcheck
+	}
+	r, ok := ex.parallelReads.results[pos]
+	if !ok || !r.matches(planner.stmt.SQL, planner.EvalContext().Placeholders) {
+		// The connExecutor moved past the batch, for example because the
+		// execution of one of its statements failed.
+		ex.resetParallelReadBatch(ctx)
+		return stats, false, nil
+	}
+	delete(ex.parallelReads.results, pos)
+	if len(ex.parallelReads.results) == 0 {
+		defer ex.resetParallelReadBatch(ctx)
+	}
+	if ih := &planner.instrumentation; ih.collectBundle || ih.outputMode != unmodifiedOutput {
+		r.discard(ctx)
+		return stats, false, nil
+	}
+
+	select {
+	case <-r.done:
+	case <-ctx.Done():
+		// The statement was canceled. The error is replaced with the usual
+		// cancellation error by execStmtInOpenState.
+		r.discard(ctx)
+		ex.resetParallelReadBatch(ctx)
+		res.SetError(ctx.Err())
+		return stats, true, nil
+	}
+	defer r.acc.Close(ctx)
+	if r.err != nil || !resultTypesIdentical(r.cols, planner.curPlan.main.planColumns()) {
+		log.VEventf(ctx, 2, "falling back to serial execution: %v", r.err)
+		return stats, false, nil
+	}
+	if sp, ok := planner.instrumentation.Tracing(); ok {
+		sp.ImportRemoteRecording(r.trace)
+		planner.curPlan.distSQLFlowInfos = append(planner.curPlan.distSQLFlowInfos, r.flowInfos...)
+	}
+	if r.vectorized {
+		planner.curPlan.flags.Set(planFlagVectorized)
+	}
+	planner.curPlan.savePlanInfo()
+	for _, row := range r.rows {
+		if err := res.AddRow(ctx, row); err != nil {
+			return r.stats, true, err
+		}
+	}
+	return r.stats, true, nil
+}
+
+// resultTypesIdentical returns whether the given result columns have the same
+// types. The types are compared exactly since the rows of the parallel
+// execution are sent to the client as if they were produced by the plan of the
+// statement.
+func resultTypesIdentical(a, b colinfo.ResultColumns) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Typ.Identical(b[i].Typ) {
+			return false
+		}
+	}
+	return true
+}
+
+// resetParallelReadBatch cancels all statements of the current parallel read
+// batch, if any, and discards their results.
+func (ex *connExecutor) resetParallelReadBatch(ctx context.Context) {
+	if ex.parallelReads.cancel != nil {
+		ex.parallelReads.cancel()
+	}
+	for _, r := range ex.parallelReads.results {
+		r.discard(ctx)
+	}
+	ex.parallelReads = parallelReadBatch{}
+}
+
+// peekBatch calls fn on the commands in the buffer starting at pos until it
+// returns false or it runs out of commands, which can happen if they haven't
+// been pushed yet. The cursor isn't moved.
+func (buf *StmtBuf) peekBatch(pos CmdPos, fn func(Command) (more bool)) {
+	buf.mu.Lock()
+	defer buf.mu.Unlock()
+	idx, err := buf.translatePosLocked(pos)
+	if err != nil {
+		return
+	}
+	for ; idx < buf.mu.data.Len(); idx++ {
+		if !fn(buf.mu.data.Get(idx)) {
+			return
+		}
+	}
+}
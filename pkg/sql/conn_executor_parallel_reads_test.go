@@ -0,0 +1,216 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package sql
+
+import (
+	"context"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/jackc/pgx/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsParallelizableRead(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	testCases := []struct {
+		stmt     string
+		expected bool
+	}{
+		{stmt: "SELECT * FROM t", expected: true},
+		{stmt: "SELECT a, b FROM t WHERE a IN (1, 2, 3) ORDER BY b LIMIT 10", expected: true},
+		{stmt: "SELECT * FROM t JOIN u ON t.a = u.a WHERE u.b > 1", expected: true},
+		{stmt: "SELECT * FROM t WHERE a IN (SELECT a FROM u)", expected: true},
+		{stmt: "SELECT * FROM (SELECT a FROM t) AS s", expected: true},
+		{stmt: "SELECT * FROM t WHERE a > (SELECT max(a) FROM u)", expected: true},
+		{stmt: "SELECT count(*), sum(b) FROM t WHERE lower('A') = 'a'", expected: true},
+		{stmt: "SELECT * FROM t FOR UPDATE"},
+		{stmt: "SELECT * FROM t AS OF SYSTEM TIME '-1s'"},
+		{stmt: "SELECT nextval('s')"},
+		{stmt: "SELECT * FROM t JOIN u ON t.a = u.a + random()"},
+		{stmt: "SELECT * FROM t WHERE b < extract(epoch FROM now())"},
+		{stmt: "SELECT f(a) FROM t"},
+		{stmt: "SELECT * FROM generate_series(1, 10)"},
+		{stmt: "WITH x AS (INSERT INTO t VALUES (1) RETURNING a) SELECT * FROM x"},
+		{stmt: "SELECT * FROM t UNION SELECT * FROM u"},
+		{stmt: "INSERT INTO t VALUES (1)"},
+		{stmt: "SHOW TABLES"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.stmt, func(t *testing.T) {
+			stmt, err := parser.ParseOne(tc.stmt)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, isParallelizableRead(stmt.AST))
+		})
+	}
+}
+
+func TestParallelReadBatch(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	// The knob makes each statement of a batch wait for another one to start
+	// executing, and records the maximum number of statements that were
+	// waiting at the same time. If the statements were executed serially,
+	// each of them would wait until the timeout alone.
+	var waiting, maxWaiting atomic.Int32
+	var mu syncutil.Mutex
+	barrier := make(chan struct{})
+	resetBarrier := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		barrier = make(chan struct{})
+		maxWaiting.Store(0)
+	}
+	ctx := context.Background()
+	s, db, _ := serverutils.StartServer(t, base.TestServerArgs{
+		Knobs: base.TestingKnobs{
+			SQLExecutor: &ExecutorTestingKnobs{
+				BeforeParallelRead: func(ctx context.Context, stmt string) {
+					mu.Lock()
+					ch := barrier
+					if n := waiting.Add(1); n > maxWaiting.Load() {
+						maxWaiting.Store(n)
+						if n == 2 {
+							close(ch)
+						}
+					}
+					mu.Unlock()
+					defer waiting.Add(-1)
+					select {
+					case <-ch:
+					case <-ctx.Done():
+					case <-time.After(10 * time.Second):
+					}
+				},
+			},
+		},
+	})
+	defer s.Stopper().Stop(ctx)
+	parallelReadBatchesEnabled.Override(ctx, &s.ClusterSettings().SV, true)
+
+	sqlDB := sqlutils.MakeSQLRunner(db)
+	sqlDB.Exec(t, "CREATE TABLE t (a INT PRIMARY KEY, b INT)")
+	sqlDB.Exec(t, "INSERT INTO t VALUES (1, 10), (2, 20), (3, 30)")
+
+	// The results of all statements are returned in order, both outside of a
+	// transaction and in a read-only transaction.
+	for _, batch := range []string{
+		"SELECT a FROM t WHERE a = 1; SELECT b FROM t WHERE a > 1 ORDER BY a; SELECT a FROM t WHERE b = 30",
+		"BEGIN READ ONLY; SELECT a FROM t WHERE a = 1; SELECT b FROM t WHERE a > 1 ORDER BY a; SELECT a FROM t WHERE b = 30; COMMIT",
+	} {
+		t.Run(batch, func(t *testing.T) {
+			resetBarrier()
+			rows, err := db.Query(batch)
+			require.NoError(t, err)
+			var results [][]int
+			for {
+				var res []int
+				for rows.Next() {
+					var i int
+					require.NoError(t, rows.Scan(&i))
+					res = append(res, i)
+				}
+				if len(res) > 0 {
+					results = append(results, res)
+				}
+				if !rows.NextResultSet() {
+					break
+				}
+			}
+			require.NoError(t, rows.Err())
+			require.NoError(t, rows.Close())
+			require.Equal(t, [][]int{{1}, {20, 30}, {3}}, results)
+			require.GreaterOrEqual(t, maxWaiting.Load(), int32(2))
+		})
+	}
+
+	// The statements of a pipelined extended protocol batch are executed in
+	// parallel as well.
+	t.Run("extended protocol", func(t *testing.T) {
+		resetBarrier()
+		pgURL, cleanup := sqlutils.PGUrl(t, s.AdvSQLAddr(), "", url.User(username.RootUser))
+		defer cleanup()
+		conn, err := pgx.Connect(ctx, pgURL.String())
+		require.NoError(t, err)
+		defer func() { require.NoError(t, conn.Close(ctx)) }()
+
+		var batch pgx.Batch
+		batch.Queue("SELECT a FROM t WHERE a = $1", 1)
+		batch.Queue("SELECT b FROM t WHERE a > $1 ORDER BY a", 1)
+		batch.Queue("SELECT a FROM t WHERE b = $1", 30)
+		br := conn.SendBatch(ctx, &batch)
+		var results [][]int
+		for i := 0; i < 3; i++ {
+			rows, err := br.Query()
+			require.NoError(t, err)
+			var res []int
+			for rows.Next() {
+				var v int
+				require.NoError(t, rows.Scan(&v))
+				res = append(res, v)
+			}
+			require.NoError(t, rows.Err())
+			results = append(results, res)
+		}
+		require.NoError(t, br.Close())
+		require.Equal(t, [][]int{{1}, {20, 30}, {3}}, results)
+		require.GreaterOrEqual(t, maxWaiting.Load(), int32(2))
+	})
+
+	// The statements executed in parallel are recorded in the statement
+	// statistics along with their execution statistics, like any other
+	// statement.
+	t.Run("statistics", func(t *testing.T) {
+		resetBarrier()
+		sqlDB.Exec(t, "SET CLUSTER SETTING sql.txn_stats.sample_rate = 1")
+		defer sqlDB.Exec(t, "RESET CLUSTER SETTING sql.txn_stats.sample_rate")
+		conn, err := db.Conn(ctx)
+		require.NoError(t, err)
+		defer func() { require.NoError(t, conn.Close()) }()
+		_, err = conn.ExecContext(ctx, "SET application_name = 'parallel_reads'")
+		require.NoError(t, err)
+
+		rows, err := conn.QueryContext(ctx, "SELECT count(*) FROM t; SELECT max(b) FROM t WHERE a > 1")
+		require.NoError(t, err)
+		var results []int
+		for {
+			for rows.Next() {
+				var i int
+				require.NoError(t, rows.Scan(&i))
+				results = append(results, i)
+			}
+			if !rows.NextResultSet() {
+				break
+			}
+		}
+		require.NoError(t, rows.Err())
+		require.NoError(t, rows.Close())
+		require.Equal(t, []int{3, 30}, results)
+		require.GreaterOrEqual(t, maxWaiting.Load(), int32(2))
+
+		sqlDB.CheckQueryResults(t, `
+SELECT key, count, rows_read_avg > 0, max_mem_usage_avg IS NOT NULL
+  FROM crdb_internal.node_statement_statistics
+ WHERE application_name = 'parallel_reads' AND key LIKE 'SELECT %FROM t%'
+ ORDER BY key`, [][]string{
+			{"SELECT count(*) FROM t", "1", "true", "true"},
+			{"SELECT max(b) FROM t WHERE a > _", "1", "true", "true"},
+		})
+	})
+}
@@ -38,6 +38,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfra/execopnode"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/execstats"
+	"github.com/cockroachdb/cockroach/pkg/sql/inverted"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/exec"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
 	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
@@ -460,6 +461,7 @@ func (dsp *DistSQLPlanner) mustWrapNode(planCtx *PlanningCtx, node planNode) boo
 	case *renderNode:
 	case *scanNode:
 	case *sortNode:
+	case *specInputNode:
 	case *topKNode:
 	case *unaryNode:
 	case *unionNode:
@@ -923,6 +925,12 @@ type PlanningCtx struct {
 	// This is true if plan is a simple insert that can be vectorized.
 	isVectorInsert bool
 
+	// deferSubqueries is set when the physical plan is constructed before the
+	// subqueries are run (i.e. by the distSQLSpecExecFactory), in which case
+	// the subqueries are left in the expressions to be evaluated during the
+	// execution.
+	deferSubqueries bool
+
 	// OverridePlannerMon, if set, will be used instead of the Planner.Mon() as
 	// the parent monitor for the DistSQL flow.
 	OverridePlannerMon *mon.BytesMonitor
@@ -955,6 +963,12 @@ func (p *PlanningCtx) IsLocal() bool {
 	return p.isLocal
 }
 
+// DeferSubqueries returns true if the subqueries are evaluated during the
+// execution rather than replaced with their results during the planning.
+func (p *PlanningCtx) DeferSubqueries() bool {
+	return p.deferSubqueries
+}
+
 // getPortalPauseInfo returns the portal pause info if the current planner is
 // for a pausable portal. Otherwise, returns nil.
 func (p *PlanningCtx) getPortalPauseInfo() *portalPauseInfo {
@@ -3103,13 +3117,47 @@ func (dsp *DistSQLPlanner) createPlanForIndexJoin(
 	if err != nil {
 		return nil, err
 	}
+	if err = dsp.addIndexJoinStage(ctx, planCtx, plan, &indexJoinPlanningInfo{
+		keyCols:           n.keyCols,
+		desc:              n.table.desc,
+		cols:              n.cols,
+		lockingStrength:   n.table.lockingStrength,
+		lockingWaitPolicy: n.table.lockingWaitPolicy,
+		lockingDurability: n.table.lockingDurability,
+		reqOrdering:       n.reqOrdering,
+		limitHint:         n.limitHint,
+	}); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// indexJoinPlanningInfo is a helper struct that is extracted from
+// indexJoinNode to be reused during physical planning.
+type indexJoinPlanningInfo struct {
+	// keyCols are the indices of the PK columns in the input plan.
+	keyCols []int
+	desc    catalog.TableDescriptor
+	// cols are the columns fetched from the primary index.
+	cols              []catalog.Column
+	lockingStrength   descpb.ScanLockingStrength
+	lockingWaitPolicy descpb.ScanLockingWaitPolicy
+	lockingDurability descpb.ScanLockingDurability
+	reqOrdering       ReqOrdering
+	limitHint         int64
+}
 
+// addIndexJoinStage adds a stage of join readers that perform the index join
+// of the plan's output with the primary index of the table.
+func (dsp *DistSQLPlanner) addIndexJoinStage(
+	ctx context.Context, planCtx *PlanningCtx, plan *PhysicalPlan, info *indexJoinPlanningInfo,
+) error {
 	// In "index-join mode", the join reader assumes that the PK cols are a prefix
 	// of the input stream columns (see #40749). We need a projection to make that
 	// happen. The other columns are not used by the join reader.
-	pkCols := make([]uint32, len(n.keyCols))
-	for i := range n.keyCols {
-		streamColOrd := plan.PlanToStreamColMap[n.keyCols[i]]
+	pkCols := make([]uint32, len(info.keyCols))
+	for i := range info.keyCols {
+		streamColOrd := plan.PlanToStreamColMap[info.keyCols[i]]
 		if streamColOrd == -1 {
 			panic("key column not in planToStreamColMap")
 		}
@@ -3126,46 +3174,46 @@ func (dsp *DistSQLPlanner) createPlanForIndexJoin(
 
 	joinReaderSpec := execinfrapb.JoinReaderSpec{
 		Type:              descpb.InnerJoin,
-		LockingStrength:   n.table.lockingStrength,
-		LockingWaitPolicy: n.table.lockingWaitPolicy,
-		LockingDurability: n.table.lockingDurability,
-		MaintainOrdering:  len(n.reqOrdering) > 0,
-		LimitHint:         n.limitHint,
+		LockingStrength:   info.lockingStrength,
+		LockingWaitPolicy: info.lockingWaitPolicy,
+		LockingDurability: info.lockingDurability,
+		MaintainOrdering:  len(info.reqOrdering) > 0,
+		LimitHint:         info.limitHint,
 	}
 
-	fetchColIDs := make([]descpb.ColumnID, len(n.cols))
+	fetchColIDs := make([]descpb.ColumnID, len(info.cols))
 	var fetchOrdinals intsets.Fast
-	for i := range n.cols {
-		fetchColIDs[i] = n.cols[i].GetID()
-		fetchOrdinals.Add(n.cols[i].Ordinal())
+	for i := range info.cols {
+		fetchColIDs[i] = info.cols[i].GetID()
+		fetchOrdinals.Add(info.cols[i].Ordinal())
 	}
-	index := n.table.desc.GetPrimaryIndex()
+	index := info.desc.GetPrimaryIndex()
 	if err := rowenc.InitIndexFetchSpec(
 		&joinReaderSpec.FetchSpec,
 		planCtx.ExtendedEvalCtx.Codec,
-		n.table.desc,
+		info.desc,
 		index,
 		fetchColIDs,
 	); err != nil {
-		return nil, err
+		return err
 	}
 
-	splitter := span.MakeSplitter(n.table.desc, index, fetchOrdinals)
+	splitter := span.MakeSplitter(info.desc, index, fetchOrdinals)
 	joinReaderSpec.SplitFamilyIDs = splitter.FamilyIDs()
 
 	plan.PlanToStreamColMap = identityMap(plan.PlanToStreamColMap, len(fetchColIDs))
 
-	types, err := getTypesForPlanResult(n, plan.PlanToStreamColMap)
-	if err != nil {
-		return nil, err
+	typs := make([]*types.T, len(info.cols))
+	for i := range info.cols {
+		typs[i] = info.cols[i].GetType()
 	}
 	if len(plan.ResultRouters) > 1 {
 		// Instantiate one join reader for every stream.
 		plan.AddNoGroupingStage(
 			execinfrapb.ProcessorCoreUnion{JoinReader: &joinReaderSpec},
 			execinfrapb.PostProcessSpec{},
-			types,
-			dsp.convertOrdering(n.reqOrdering, plan.PlanToStreamColMap),
+			typs,
+			dsp.convertOrdering(info.reqOrdering, plan.PlanToStreamColMap),
 		)
 	} else {
 		// We have a single stream, so use a single join reader on that node.
@@ -3174,10 +3222,10 @@ func (dsp *DistSQLPlanner) createPlanForIndexJoin(
 			plan.Processors[plan.ResultRouters[0]].SQLInstanceID,
 			execinfrapb.ProcessorCoreUnion{JoinReader: &joinReaderSpec},
 			execinfrapb.PostProcessSpec{},
-			types,
+			typs,
 		)
 	}
-	return plan, nil
+	return nil
 }
 
 // createPlanForLookupJoin creates a distributed plan for a lookupJoinNode.
@@ -3188,14 +3236,66 @@ func (dsp *DistSQLPlanner) createPlanForLookupJoin(
 	if err != nil {
 		return nil, err
 	}
+	if err = dsp.addLookupJoinStage(ctx, planCtx, plan, &lookupJoinPlanningInfo{
+		joinType:                   n.joinType,
+		desc:                       n.table.desc,
+		index:                      n.table.index,
+		cols:                       n.table.cols,
+		lockingStrength:            n.table.lockingStrength,
+		lockingWaitPolicy:          n.table.lockingWaitPolicy,
+		lockingDurability:          n.table.lockingDurability,
+		eqCols:                     n.eqCols,
+		eqColsAreKey:               n.eqColsAreKey,
+		lookupExpr:                 n.lookupExpr,
+		remoteLookupExpr:           n.remoteLookupExpr,
+		onCond:                     n.onCond,
+		isFirstJoinInPairedJoiner:  n.isFirstJoinInPairedJoiner,
+		isSecondJoinInPairedJoiner: n.isSecondJoinInPairedJoiner,
+		reqOrdering:                n.reqOrdering,
+		limitHint:                  n.limitHint,
+		remoteOnlyLookups:          n.remoteOnlyLookups,
+	}); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
 
+// lookupJoinPlanningInfo is a helper struct that is extracted from
+// lookupJoinNode to be reused during physical planning.
+type lookupJoinPlanningInfo struct {
+	joinType descpb.JoinType
+	desc     catalog.TableDescriptor
+	index    catalog.Index
+	// cols are the columns fetched from the index.
+	cols              []catalog.Column
+	lockingStrength   descpb.ScanLockingStrength
+	lockingWaitPolicy descpb.ScanLockingWaitPolicy
+	lockingDurability descpb.ScanLockingDurability
+	// eqCols are the indices of the input plan's columns used for the lookup.
+	eqCols                     []int
+	eqColsAreKey               bool
+	lookupExpr                 tree.TypedExpr
+	remoteLookupExpr           tree.TypedExpr
+	onCond                     tree.TypedExpr
+	isFirstJoinInPairedJoiner  bool
+	isSecondJoinInPairedJoiner bool
+	reqOrdering                ReqOrdering
+	limitHint                  int64
+	remoteOnlyLookups          bool
+}
+
+// addLookupJoinStage adds a stage of join readers that perform the lookup join
+// of the plan's output with the given index.
+func (dsp *DistSQLPlanner) addLookupJoinStage(
+	ctx context.Context, planCtx *PlanningCtx, plan *PhysicalPlan, info *lookupJoinPlanningInfo,
+) error {
 	// If any of the ordering columns originate from the lookup table, this is a
 	// case where we are ordering on a prefix of input columns followed by the
 	// lookup columns.
 	var maintainLookupOrdering bool
 	numInputCols := len(plan.GetResultTypes())
-	for i := range n.reqOrdering {
-		if n.reqOrdering[i].ColIdx >= numInputCols {
+	for i := range info.reqOrdering {
+		if info.reqOrdering[i].ColIdx >= numInputCols {
 			// We need to maintain the index ordering on each lookup.
 			maintainLookupOrdering = true
 			break
@@ -3203,69 +3303,69 @@ func (dsp *DistSQLPlanner) createPlanForLookupJoin(
 	}
 
 	joinReaderSpec := execinfrapb.JoinReaderSpec{
-		Type:              n.joinType,
-		LockingStrength:   n.table.lockingStrength,
-		LockingWaitPolicy: n.table.lockingWaitPolicy,
-		LockingDurability: n.table.lockingDurability,
+		Type:              info.joinType,
+		LockingStrength:   info.lockingStrength,
+		LockingWaitPolicy: info.lockingWaitPolicy,
+		LockingDurability: info.lockingDurability,
 		// TODO(sumeer): specifying ordering here using isFirstJoinInPairedJoiner
 		// is late in the sense that the cost of this has not been taken into
 		// account. Make this decision earlier in CustomFuncs.GenerateLookupJoins.
-		MaintainOrdering:                  len(n.reqOrdering) > 0 || n.isFirstJoinInPairedJoiner,
+		MaintainOrdering:                  len(info.reqOrdering) > 0 || info.isFirstJoinInPairedJoiner,
 		MaintainLookupOrdering:            maintainLookupOrdering,
-		LeftJoinWithPairedJoiner:          n.isSecondJoinInPairedJoiner,
-		OutputGroupContinuationForLeftRow: n.isFirstJoinInPairedJoiner,
+		LeftJoinWithPairedJoiner:          info.isSecondJoinInPairedJoiner,
+		OutputGroupContinuationForLeftRow: info.isFirstJoinInPairedJoiner,
 		LookupBatchBytesLimit:             dsp.distSQLSrv.TestingKnobs.JoinReaderBatchBytesLimit,
-		LimitHint:                         n.limitHint,
-		RemoteOnlyLookups:                 n.remoteOnlyLookups,
+		LimitHint:                         info.limitHint,
+		RemoteOnlyLookups:                 info.remoteOnlyLookups,
 	}
 
-	fetchColIDs := make([]descpb.ColumnID, len(n.table.cols))
+	fetchColIDs := make([]descpb.ColumnID, len(info.cols))
 	var fetchOrdinals intsets.Fast
-	for i := range n.table.cols {
-		fetchColIDs[i] = n.table.cols[i].GetID()
-		fetchOrdinals.Add(n.table.cols[i].Ordinal())
+	for i := range info.cols {
+		fetchColIDs[i] = info.cols[i].GetID()
+		fetchOrdinals.Add(info.cols[i].Ordinal())
 	}
 	if err := rowenc.InitIndexFetchSpec(
 		&joinReaderSpec.FetchSpec,
 		planCtx.ExtendedEvalCtx.Codec,
-		n.table.desc,
-		n.table.index,
+		info.desc,
+		info.index,
 		fetchColIDs,
 	); err != nil {
-		return nil, err
+		return err
 	}
 
 	var splitter span.Splitter
 	if joinReaderSpec.LockingStrength != descpb.ScanLockingStrength_FOR_NONE &&
 		planCtx.ExtendedEvalCtx.TxnIsoLevel != isolation.Serializable {
-		splitter = span.MakeSplitterForSideEffect(n.table.desc, n.table.index, fetchOrdinals)
+		splitter = span.MakeSplitterForSideEffect(info.desc, info.index, fetchOrdinals)
 	} else {
-		splitter = span.MakeSplitter(n.table.desc, n.table.index, fetchOrdinals)
+		splitter = span.MakeSplitter(info.desc, info.index, fetchOrdinals)
 	}
 	joinReaderSpec.SplitFamilyIDs = splitter.FamilyIDs()
 
-	joinReaderSpec.LookupColumns = make([]uint32, len(n.eqCols))
-	for i, col := range n.eqCols {
+	joinReaderSpec.LookupColumns = make([]uint32, len(info.eqCols))
+	for i, col := range info.eqCols {
 		if plan.PlanToStreamColMap[col] == -1 {
 			panic("lookup column not in planToStreamColMap")
 		}
 		joinReaderSpec.LookupColumns[i] = uint32(plan.PlanToStreamColMap[col])
 	}
-	joinReaderSpec.LookupColumnsAreKey = n.eqColsAreKey
+	joinReaderSpec.LookupColumnsAreKey = info.eqColsAreKey
 
 	inputTypes := plan.GetResultTypes()
 	fetchedColumns := joinReaderSpec.FetchSpec.FetchedColumns
 	numOutCols := len(inputTypes) + len(fetchedColumns)
-	if n.isFirstJoinInPairedJoiner {
+	if info.isFirstJoinInPairedJoiner {
 		// We will add a continuation column.
 		numOutCols++
 	}
 
 	var outTypes []*types.T
 	var planToStreamColMap []int
-	if !n.joinType.ShouldIncludeRightColsInOutput() {
-		if n.isFirstJoinInPairedJoiner {
-			return nil, errors.AssertionFailedf("continuation column without right columns")
+	if !info.joinType.ShouldIncludeRightColsInOutput() {
+		if info.isFirstJoinInPairedJoiner {
+			return errors.AssertionFailedf("continuation column without right columns")
 		}
 		outTypes = inputTypes
 		planToStreamColMap = plan.PlanToStreamColMap
@@ -3277,7 +3377,7 @@ func (dsp *DistSQLPlanner) createPlanForLookupJoin(
 			outTypes[len(inputTypes)+i] = fetchedColumns[i].Type
 			planToStreamColMap = append(planToStreamColMap, len(inputTypes)+i)
 		}
-		if n.isFirstJoinInPairedJoiner {
+		if info.isFirstJoinInPairedJoiner {
 			outTypes[numOutCols-1] = types.Bool
 			planToStreamColMap = append(planToStreamColMap, numOutCols-1)
 		}
@@ -3287,30 +3387,30 @@ func (dsp *DistSQLPlanner) createPlanForLookupJoin(
 	ef.Init(ctx, planCtx, nil /* indexVarMap */)
 
 	// Set the lookup condition.
-	if n.lookupExpr != nil {
+	if info.lookupExpr != nil {
 		var err error
-		joinReaderSpec.LookupExpr, err = ef.Make(n.lookupExpr)
+		joinReaderSpec.LookupExpr, err = ef.Make(info.lookupExpr)
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
-	if n.remoteLookupExpr != nil {
-		if n.lookupExpr == nil {
-			return nil, errors.AssertionFailedf("remoteLookupExpr is set but lookupExpr is not")
+	if info.remoteLookupExpr != nil {
+		if info.lookupExpr == nil {
+			return errors.AssertionFailedf("remoteLookupExpr is set but lookupExpr is not")
 		}
 		var err error
-		joinReaderSpec.RemoteLookupExpr, err = ef.Make(n.remoteLookupExpr)
+		joinReaderSpec.RemoteLookupExpr, err = ef.Make(info.remoteLookupExpr)
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
 
 	// Set the ON condition.
-	if n.onCond != nil {
+	if info.onCond != nil {
 		var err error
-		joinReaderSpec.OnExpr, err = ef.Make(n.onCond)
+		joinReaderSpec.OnExpr, err = ef.Make(info.onCond)
 		if err != nil {
-			return nil, err
+			return err
 		}
 	}
 
@@ -3322,10 +3422,10 @@ func (dsp *DistSQLPlanner) createPlanForLookupJoin(
 		execinfrapb.ProcessorCoreUnion{JoinReader: &joinReaderSpec},
 		execinfrapb.PostProcessSpec{},
 		outTypes,
-		dsp.convertOrdering(planReqOrdering(n), planToStreamColMap),
+		dsp.convertOrdering(info.reqOrdering, planToStreamColMap),
 	)
 	plan.PlanToStreamColMap = planToStreamColMap
-	return plan, nil
+	return nil
 }
 
 func (dsp *DistSQLPlanner) createPlanForInvertedJoin(
@@ -3335,38 +3435,81 @@ func (dsp *DistSQLPlanner) createPlanForInvertedJoin(
 	if err != nil {
 		return nil, err
 	}
+	if err = dsp.addInvertedJoinStage(ctx, planCtx, plan, &invertedJoinPlanningInfo{
+		joinType:                  n.joinType,
+		desc:                      n.table.desc,
+		index:                     n.table.index,
+		cols:                      n.table.cols,
+		lockingStrength:           n.table.lockingStrength,
+		lockingWaitPolicy:         n.table.lockingWaitPolicy,
+		lockingDurability:         n.table.lockingDurability,
+		prefixEqCols:              n.prefixEqCols,
+		invertedExpr:              n.invertedExpr,
+		onCond:                    n.onExpr,
+		isFirstJoinInPairedJoiner: n.isFirstJoinInPairedJoiner,
+		reqOrdering:               n.reqOrdering,
+	}); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// invertedJoinPlanningInfo is a helper struct that is extracted from
+// invertedJoinNode to be reused during physical planning.
+type invertedJoinPlanningInfo struct {
+	joinType descpb.JoinType
+	desc     catalog.TableDescriptor
+	index    catalog.Index
+	// cols are the columns fetched from the inverted index.
+	cols              []catalog.Column
+	lockingStrength   descpb.ScanLockingStrength
+	lockingWaitPolicy descpb.ScanLockingWaitPolicy
+	lockingDurability descpb.ScanLockingDurability
+	// prefixEqCols are the indices of the input plan's columns that are
+	// equated with the non-inverted prefix columns of the index.
+	prefixEqCols              []int
+	invertedExpr              tree.TypedExpr
+	onCond                    tree.TypedExpr
+	isFirstJoinInPairedJoiner bool
+	reqOrdering               ReqOrdering
+}
 
+// addInvertedJoinStage adds a stage of inverted joiners that perform the
+// inverted join of the plan's output with the given inverted index.
+func (dsp *DistSQLPlanner) addInvertedJoinStage(
+	ctx context.Context, planCtx *PlanningCtx, plan *PhysicalPlan, info *invertedJoinPlanningInfo,
+) error {
 	invertedJoinerSpec := execinfrapb.InvertedJoinerSpec{
-		Type:                              n.joinType,
-		MaintainOrdering:                  len(n.reqOrdering) > 0,
-		OutputGroupContinuationForLeftRow: n.isFirstJoinInPairedJoiner,
-		LockingStrength:                   n.table.lockingStrength,
-		LockingWaitPolicy:                 n.table.lockingWaitPolicy,
-		LockingDurability:                 n.table.lockingDurability,
+		Type:                              info.joinType,
+		MaintainOrdering:                  len(info.reqOrdering) > 0,
+		OutputGroupContinuationForLeftRow: info.isFirstJoinInPairedJoiner,
+		LockingStrength:                   info.lockingStrength,
+		LockingWaitPolicy:                 info.lockingWaitPolicy,
+		LockingDurability:                 info.lockingDurability,
 	}
 
-	fetchColIDs := make([]descpb.ColumnID, len(n.table.cols))
-	for i := range n.table.cols {
-		fetchColIDs[i] = n.table.cols[i].GetID()
+	fetchColIDs := make([]descpb.ColumnID, len(info.cols))
+	for i := range info.cols {
+		fetchColIDs[i] = info.cols[i].GetID()
 	}
 	if err := rowenc.InitIndexFetchSpec(
 		&invertedJoinerSpec.FetchSpec,
 		planCtx.ExtendedEvalCtx.Codec,
-		n.table.desc,
-		n.table.index,
+		info.desc,
+		info.index,
 		fetchColIDs,
 	); err != nil {
-		return nil, err
+		return err
 	}
 
-	invCol, err := catalog.MustFindColumnByID(n.table.desc, n.table.index.InvertedColumnID())
+	invCol, err := catalog.MustFindColumnByID(info.desc, info.index.InvertedColumnID())
 	if err != nil {
-		return nil, err
+		return err
 	}
 	invertedJoinerSpec.InvertedColumnOriginalType = invCol.GetType()
 
-	invertedJoinerSpec.PrefixEqualityColumns = make([]uint32, len(n.prefixEqCols))
-	for i, col := range n.prefixEqCols {
+	invertedJoinerSpec.PrefixEqualityColumns = make([]uint32, len(info.prefixEqCols))
+	for i, col := range info.prefixEqCols {
 		if plan.PlanToStreamColMap[col] == -1 {
 			panic("lookup column not in planToStreamColMap")
 		}
@@ -3375,13 +3518,13 @@ func (dsp *DistSQLPlanner) createPlanForInvertedJoin(
 
 	var ef physicalplan.ExprFactory
 	ef.Init(ctx, planCtx, nil /* indexVarMap */)
-	if invertedJoinerSpec.InvertedExpr, err = ef.Make(n.invertedExpr); err != nil {
-		return nil, err
+	if invertedJoinerSpec.InvertedExpr, err = ef.Make(info.invertedExpr); err != nil {
+		return err
 	}
 	// Set the ON condition.
-	if n.onExpr != nil {
-		if invertedJoinerSpec.OnExpr, err = ef.Make(n.onExpr); err != nil {
-			return nil, err
+	if info.onCond != nil {
+		if invertedJoinerSpec.OnExpr, err = ef.Make(info.onCond); err != nil {
+			return err
 		}
 	}
 
@@ -3390,7 +3533,7 @@ func (dsp *DistSQLPlanner) createPlanForInvertedJoin(
 
 	outTypes := inputTypes
 	planToStreamColMap := plan.PlanToStreamColMap
-	if n.joinType.ShouldIncludeRightColsInOutput() {
+	if info.joinType.ShouldIncludeRightColsInOutput() {
 		outTypes = make([]*types.T, len(inputTypes)+len(fetchedColumns))
 		copy(outTypes, inputTypes)
 		for i := range fetchedColumns {
@@ -3398,7 +3541,7 @@ func (dsp *DistSQLPlanner) createPlanForInvertedJoin(
 			planToStreamColMap = append(planToStreamColMap, len(inputTypes)+i)
 		}
 	}
-	if n.isFirstJoinInPairedJoiner {
+	if info.isFirstJoinInPairedJoiner {
 		outTypes = append(outTypes, types.Bool)
 		planToStreamColMap = append(planToStreamColMap, len(outTypes)-1)
 	}
@@ -3408,10 +3551,10 @@ func (dsp *DistSQLPlanner) createPlanForInvertedJoin(
 		execinfrapb.ProcessorCoreUnion{InvertedJoiner: &invertedJoinerSpec},
 		execinfrapb.PostProcessSpec{},
 		outTypes,
-		dsp.convertOrdering(planReqOrdering(n), planToStreamColMap),
+		dsp.convertOrdering(info.reqOrdering, planToStreamColMap),
 	)
 	plan.PlanToStreamColMap = planToStreamColMap
-	return plan, nil
+	return nil
 }
 
 // createPlanForZigzagJoin creates a distributed plan for a zigzagJoinNode.
@@ -3544,18 +3687,48 @@ func (dsp *DistSQLPlanner) createPlanForInvertedFilter(
 	if err != nil {
 		return nil, err
 	}
+	if err = dsp.addInvertedFilterStage(ctx, planCtx, plan, &invertedFilterPlanningInfo{
+		expression:      n.expression,
+		preFiltererExpr: n.preFiltererExpr,
+		preFiltererType: n.preFiltererType,
+		invColumn:       n.invColumn,
+		numCols:         len(n.resultColumns),
+	}); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// invertedFilterPlanningInfo is a helper struct that is extracted from
+// invertedFilterNode to be reused during physical planning.
+type invertedFilterPlanningInfo struct {
+	expression      *inverted.SpanExpression
+	preFiltererExpr tree.TypedExpr
+	preFiltererType *types.T
+	// invColumn is the index of the inverted column in the plan's output.
+	invColumn int
+	// numCols is the number of columns in the plan's output.
+	numCols int
+}
+
+// addInvertedFilterStage adds the stages of inverted filterers (and, if the
+// filtering is distributed, of the de-duplicating distinct) to the plan.
+func (dsp *DistSQLPlanner) addInvertedFilterStage(
+	ctx context.Context, planCtx *PlanningCtx, plan *PhysicalPlan, info *invertedFilterPlanningInfo,
+) error {
 	invertedFiltererSpec := &execinfrapb.InvertedFiltererSpec{
-		InvertedColIdx: uint32(n.invColumn),
-		InvertedExpr:   *n.expression.ToProto(),
+		InvertedColIdx: uint32(info.invColumn),
+		InvertedExpr:   *info.expression.ToProto(),
 	}
-	if n.preFiltererExpr != nil {
+	if info.preFiltererExpr != nil {
 		invertedFiltererSpec.PreFiltererSpec = &execinfrapb.InvertedFiltererSpec_PreFiltererSpec{
-			Type: n.preFiltererType,
+			Type: info.preFiltererType,
 		}
+		var err error
 		if invertedFiltererSpec.PreFiltererSpec.Expression, err = physicalplan.MakeExpression(
-			ctx, n.preFiltererExpr, planCtx, nil,
+			ctx, info.preFiltererExpr, planCtx, nil,
 		); err != nil {
-			return nil, err
+			return err
 		}
 	}
 
@@ -3576,12 +3749,12 @@ func (dsp *DistSQLPlanner) createPlanForInvertedFilter(
 				InvertedFilterer: invertedFiltererSpec,
 			},
 			execinfrapb.PostProcessSpec{}, plan.GetResultTypes())
-		return plan, nil
+		return nil
 	}
 	// Must be distributable.
-	distributable := n.expression.Left == nil && n.expression.Right == nil
+	distributable := info.expression.Left == nil && info.expression.Right == nil
 	if !distributable {
-		return nil, errors.Errorf("expected distributable inverted filterer")
+		return errors.Errorf("expected distributable inverted filterer")
 	}
 	reqOrdering := execinfrapb.Ordering{}
 	// Instantiate one inverted filterer for every stream.
@@ -3592,9 +3765,9 @@ func (dsp *DistSQLPlanner) createPlanForInvertedFilter(
 	// De-duplicate the PKs. Note that the inverted filterer output includes
 	// the inverted column always set to NULL, so we exclude it from the
 	// distinct columns.
-	distinctColumns := make([]uint32, 0, len(n.resultColumns)-1)
-	for i := 0; i < len(n.resultColumns); i++ {
-		if i == n.invColumn {
+	distinctColumns := make([]uint32, 0, info.numCols-1)
+	for i := 0; i < info.numCols; i++ {
+		if i == info.invColumn {
 			continue
 		}
 		distinctColumns = append(distinctColumns, uint32(i))
@@ -3614,7 +3787,7 @@ func (dsp *DistSQLPlanner) createPlanForInvertedFilter(
 		execinfrapb.PostProcessSpec{},
 		plan.GetResultTypes(),
 	)
-	return plan, nil
+	return nil
 }
 
 func getTypesFromResultColumns(cols colinfo.ResultColumns) []*types.T {
@@ -3926,6 +4099,9 @@ func (dsp *DistSQLPlanner) createPhysPlanForPlanNode(
 
 		dsp.addSorters(ctx, plan, n.ordering, n.alreadyOrderedPrefix, 0 /* limit */)
 
+	case *specInputNode:
+		plan = n.physPlan
+
 	case *topKNode:
 		plan, err = dsp.createPhysPlanForPlanNode(ctx, planCtx, n.plan)
 		if err != nil {
@@ -4290,7 +4466,14 @@ func (dsp *DistSQLPlanner) createPlanForOrdinality(
 	if err != nil {
 		return nil, err
 	}
+	dsp.addOrdinality(ctx, plan)
+	return plan, nil
+}
 
+// addOrdinality adds a single group stage consisting of an ordinality
+// processor planned on the gateway. The ordinality column is appended to the
+// output of the plan.
+func (dsp *DistSQLPlanner) addOrdinality(ctx context.Context, plan *PhysicalPlan) {
 	ordinalitySpec := execinfrapb.ProcessorCoreUnion{
 		Ordinality: &execinfrapb.OrdinalitySpec{},
 	}
@@ -4301,8 +4484,6 @@ func (dsp *DistSQLPlanner) createPlanForOrdinality(
 	// WITH ORDINALITY never gets distributed so that the gateway node can
 	// always number each row in order.
 	plan.AddSingleGroupStage(ctx, dsp.gatewaySQLInstanceID, ordinalitySpec, execinfrapb.PostProcessSpec{}, outputTypes)
-
-	return plan, nil
 }
 
 func createProjectSetSpec(
@@ -4445,6 +4626,50 @@ func (dsp *DistSQLPlanner) createPlanForSetOp(
 		leftPlan, rightPlan = rightPlan, leftPlan
 		leftLogicalPlan, rightLogicalPlan = rightLogicalPlan, leftLogicalPlan
 	}
+	return dsp.planSetOp(ctx, planCtx, leftPlan, rightPlan, &setOpPlanningInfo{
+		unionType:         n.unionType,
+		all:               n.all,
+		streamingOrdering: n.streamingOrdering,
+		leftReqOrdering:   planReqOrdering(leftLogicalPlan),
+		rightReqOrdering:  planReqOrdering(rightLogicalPlan),
+		hardLimit:         n.hardLimit,
+		enforceHomeRegion: n.enforceHomeRegion,
+		// In the old execFactory we can only have either local or fully
+		// distributed plans, so checking the last stage is sufficient to get
+		// the distribution of the whole plans.
+		leftPlanDistribution:     leftPlan.GetLastStageDistribution(),
+		rightPlanDistribution:    rightPlan.GetLastStageDistribution(),
+		allowPartialDistribution: false,
+	})
+}
+
+// setOpPlanningInfo is a helper struct that is extracted from unionNode to be
+// reused during physical planning by both the execFactory and the
+// distSQLSpecExecFactory.
+type setOpPlanningInfo struct {
+	unionType tree.UnionType
+	all       bool
+	// streamingOrdering, hardLimit and enforceHomeRegion have the same meaning
+	// as the corresponding fields of unionNode.
+	streamingOrdering colinfo.ColumnOrdering
+	hardLimit         uint64
+	enforceHomeRegion bool
+	// leftReqOrdering and rightReqOrdering are the orderings provided by the
+	// left and right inputs, respectively.
+	leftReqOrdering, rightReqOrdering ReqOrdering
+
+	leftPlanDistribution, rightPlanDistribution physicalplan.PlanDistribution
+	allowPartialDistribution                    bool
+}
+
+// planSetOp merges the physical plans of the two inputs of a set operation
+// according to info. See createPlanForSetOp for more details.
+func (dsp *DistSQLPlanner) planSetOp(
+	ctx context.Context,
+	planCtx *PlanningCtx,
+	leftPlan, rightPlan *PhysicalPlan,
+	info *setOpPlanningInfo,
+) (*PhysicalPlan, error) {
 	childPhysicalPlans := []*PhysicalPlan{leftPlan, rightPlan}
 
 	// Check that the left and right side PlanToStreamColMaps are equivalent.
@@ -4468,13 +4693,13 @@ func (dsp *DistSQLPlanner) createPlanForSetOp(
 
 	var distinctSpecs [2]execinfrapb.ProcessorCoreUnion
 
-	if !n.all {
+	if !info.all {
 		var distinctOrds [2]execinfrapb.Ordering
 		distinctOrds[0] = execinfrapb.ConvertToMappedSpecOrdering(
-			planReqOrdering(leftLogicalPlan), leftPlan.PlanToStreamColMap,
+			info.leftReqOrdering, leftPlan.PlanToStreamColMap,
 		)
 		distinctOrds[1] = execinfrapb.ConvertToMappedSpecOrdering(
-			planReqOrdering(rightLogicalPlan), rightPlan.PlanToStreamColMap,
+			info.rightReqOrdering, rightPlan.PlanToStreamColMap,
 		)
 
 		// Build distinct processor specs for the left and right child plans.
@@ -4516,30 +4741,27 @@ func (dsp *DistSQLPlanner) createPlanForSetOp(
 	}
 
 	// Set the merge ordering.
-	mergeOrdering := dsp.convertOrdering(n.streamingOrdering, p.PlanToStreamColMap)
+	mergeOrdering := dsp.convertOrdering(info.streamingOrdering, p.PlanToStreamColMap)
 
 	// Merge processors, streams, result routers, and stage counter.
 	leftRouters := leftPlan.ResultRouters
 	rightRouters := rightPlan.ResultRouters
 	physicalplan.MergePlans(
 		&p.PhysicalPlan, &leftPlan.PhysicalPlan, &rightPlan.PhysicalPlan,
-		// In the old execFactory we can only have either local or fully
-		// distributed plans, so checking the last stage is sufficient to get
-		// the distribution of the whole plans.
-		leftPlan.GetLastStageDistribution(),
-		rightPlan.GetLastStageDistribution(),
-		false, /* allowPartialDistribution */
+		info.leftPlanDistribution,
+		info.rightPlanDistribution,
+		info.allowPartialDistribution,
 	)
 
-	if n.unionType == tree.UnionOp {
+	if info.unionType == tree.UnionOp {
 		// We just need to append the left and right streams together, so append
 		// the left and right output routers.
 		p.ResultRouters = append(leftRouters, rightRouters...)
 
 		p.SetMergeOrdering(mergeOrdering)
 
-		if !n.all {
-			if n.hardLimit != 0 {
+		if !info.all {
+			if info.hardLimit != 0 {
 				return nil, errors.AssertionFailedf("a hard limit is not supported for UNION (only for UNION ALL)")
 			}
 
@@ -4558,7 +4780,7 @@ func (dsp *DistSQLPlanner) createPlanForSetOp(
 			p.AddSingleGroupStage(ctx, dsp.gatewaySQLInstanceID, distinctSpec, execinfrapb.PostProcessSpec{}, resultTypes)
 		} else {
 			var serialStreamErrorSpec physicalplan.SerialStreamErrorSpec
-			if n.enforceHomeRegion {
+			if info.enforceHomeRegion {
 				// When inputIdx of the SerialUnorderedSynchronizer is incremented to 1,
 				// error out.
 				serialStreamErrorSpec.SerialInputIdxExclusiveUpperBound = 1
@@ -4577,7 +4799,7 @@ func (dsp *DistSQLPlanner) createPlanForSetOp(
 			// we can fuse everything so there are no concurrent KV operations (see
 			// #40487, #41307).
 
-			if n.hardLimit == 0 {
+			if info.hardLimit == 0 {
 				// In order to disable auto-parallelism that could occur when merging
 				// multiple streams on the same node, we force the serialization of the
 				// merge operation (otherwise, it would be possible that we have a
@@ -4599,7 +4821,7 @@ func (dsp *DistSQLPlanner) createPlanForSetOp(
 				p.EnsureSingleStreamPerNode(
 					ctx,
 					true, /* forceSerialization */
-					execinfrapb.PostProcessSpec{Limit: n.hardLimit},
+					execinfrapb.PostProcessSpec{Limit: info.hardLimit},
 					serialStreamErrorSpec,
 				)
 			}
@@ -4613,13 +4835,13 @@ func (dsp *DistSQLPlanner) createPlanForSetOp(
 			}
 		}
 	} else {
-		if n.hardLimit != 0 {
+		if info.hardLimit != 0 {
 			return nil, errors.AssertionFailedf("a hard limit is not supported for INTERSECT or EXCEPT")
 		}
 
 		// We plan INTERSECT and EXCEPT queries with joiners. Get the appropriate
 		// join type.
-		joinType := distsqlSetOpJoinType(n.unionType)
+		joinType := distsqlSetOpJoinType(info.unionType)
 
 		// Nodes where we will run the join processors.
 		nodes := findJoinProcessorNodes(leftRouters, rightRouters, p.Processors)
@@ -4652,7 +4874,7 @@ func (dsp *DistSQLPlanner) createPlanForSetOp(
 			}
 		}
 
-		if n.all {
+		if info.all {
 			p.AddJoinStage(
 				ctx, nodes, core, post, eqCols, eqCols,
 				leftPlan.GetResultTypes(), rightPlan.GetResultTypes(),
@@ -4683,36 +4905,47 @@ func (dsp *DistSQLPlanner) createPlanForWindow(
 	if err != nil {
 		return nil, err
 	}
+	if err = dsp.addWindowers(ctx, planCtx, plan, n.funcs); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
 
-	if len(n.funcs) == 0 {
+// addWindowers adds a stage of windowers computing the given window functions
+// to the plan. All functions must have the same PARTITION BY and ORDER BY
+// clauses.
+func (dsp *DistSQLPlanner) addWindowers(
+	ctx context.Context, planCtx *PlanningCtx, plan *PhysicalPlan, funcs []*windowFuncHolder,
+) error {
+	if len(funcs) == 0 {
 		// If we don't have any window functions to compute, then all input
 		// columns are simply passed-through, so we don't need to plan the
 		// windower. This shouldn't really happen since the optimizer should
 		// eliminate such a window node, but if some of the optimizer's rules
 		// are disabled (in tests), it could happen.
-		return plan, nil
+		return nil
 	}
 
-	partitionIdxs := make([]uint32, len(n.funcs[0].partitionIdxs))
+	partitionIdxs := make([]uint32, len(funcs[0].partitionIdxs))
 	for i := range partitionIdxs {
-		partitionIdxs[i] = uint32(n.funcs[0].partitionIdxs[i])
+		partitionIdxs[i] = uint32(funcs[0].partitionIdxs[i])
 	}
 
 	// Check that all window functions have the same PARTITION BY and ORDER BY
 	// clauses. We can assume that because the optbuilder ensures that all
 	// window functions in the windowNode have the same PARTITION BY and ORDER
 	// BY clauses.
-	for _, f := range n.funcs[1:] {
-		if !n.funcs[0].samePartition(f) {
-			return nil, errors.AssertionFailedf(
+	for _, f := range funcs[1:] {
+		if !funcs[0].samePartition(f) {
+			return errors.AssertionFailedf(
 				"PARTITION BY clauses of window functions handled by the same "+
-					"windowNode are different: %v, %v", n.funcs[0].partitionIdxs, f.partitionIdxs,
+					"windowNode are different: %v, %v", funcs[0].partitionIdxs, f.partitionIdxs,
 			)
 		}
-		if !n.funcs[0].columnOrdering.Equal(f.columnOrdering) {
-			return nil, errors.AssertionFailedf(
+		if !funcs[0].columnOrdering.Equal(f.columnOrdering) {
+			return errors.AssertionFailedf(
 				"ORDER BY clauses of window functions handled by the same "+
-					"windowNode are different: %v, %v", n.funcs[0].columnOrdering, f.columnOrdering,
+					"windowNode are different: %v, %v", funcs[0].columnOrdering, f.columnOrdering,
 			)
 		}
 	}
@@ -4724,15 +4957,15 @@ func (dsp *DistSQLPlanner) createPlanForWindow(
 	// stage.
 	windowerSpec := execinfrapb.WindowerSpec{
 		PartitionBy: partitionIdxs,
-		WindowFns:   make([]execinfrapb.WindowerSpec_WindowFn, len(n.funcs)),
+		WindowFns:   make([]execinfrapb.WindowerSpec_WindowFn, len(funcs)),
 	}
 
-	newResultTypes := make([]*types.T, len(plan.GetResultTypes())+len(n.funcs))
+	newResultTypes := make([]*types.T, len(plan.GetResultTypes())+len(funcs))
 	copy(newResultTypes, plan.GetResultTypes())
-	for windowFnSpecIdx, windowFn := range n.funcs {
+	for windowFnSpecIdx, windowFn := range funcs {
 		windowFnSpec, outputType, err := createWindowFnSpec(ctx, planCtx, plan, windowFn)
 		if err != nil {
-			return nil, err
+			return err
 		}
 		newResultTypes[windowFn.outputColIdx] = outputType
 		windowerSpec.WindowFns[windowFnSpecIdx] = windowFnSpec
@@ -4814,8 +5047,7 @@ func (dsp *DistSQLPlanner) createPlanForWindow(
 	// reset MergeOrdering. There shouldn't be an ordering here, but we reset it
 	// defensively (see #35179).
 	plan.SetMergeOrdering(execinfrapb.Ordering{})
-
-	return plan, nil
+	return nil
 }
 
 // createPlanForExport creates a physical plan for EXPORT.
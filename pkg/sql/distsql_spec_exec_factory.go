@@ -27,9 +27,32 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/span"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/errorutil/unimplemented"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/cockroachdb/errors"
 )
 
+// distSQLSpecExecFactory is an exec.Factory that produces physical plans
+// directly from the memo, without constructing planNodes first.
+//
+// Operators that map onto DistSQL processors (scans, values, filters,
+// renders, joins, aggregations, sorts, limits, set operations, window
+// functions, etc) are planned directly. The remaining operators (e.g. apply
+// joins, max1row, mutations, buffers and recursive CTEs, and all DDL and
+// utility statements) are constructed as planNodes by execFactory and wrapped
+// into the physical plan on the gateway, with the physical plan of their input
+// (if any) feeding into them (see constructWithExecFactory).
+//
+// The physical plans are constructed before the subqueries are run, so the
+// subqueries are left in the expressions and are evaluated on the gateway
+// during the execution (see PlanningCtx.deferSubqueries). The subqueries,
+// checks and the main query share the physical infrastructure during the
+// construction and are separated in ConstructPlan.
+//
+// A few cases still return an unimplemented error (see #47473): subqueries in
+// LIMIT / OFFSET expressions or in window frame offsets (which are evaluated
+// during the planning), and non-distributable inverted filters on top of a
+// distributed input. The planning falls back to execFactory in those cases
+// unless experimental_distsql_planning is set to 'always'.
 type distSQLSpecExecFactory struct {
 	ctx     context.Context
 	planner *planner
@@ -75,6 +98,7 @@ func newDistSQLSpecExecFactory(
 	}
 	evalCtx := p.ExtendedEvalContext()
 	e.planCtx = e.dsp.NewPlanningCtx(ctx, evalCtx, e.planner, e.planner.txn, distribute)
+	e.planCtx.deferSubqueries = true
 	return e
 }
 
@@ -310,7 +334,9 @@ func (e *distSQLSpecExecFactory) checkExprsAndMaybeMergeLastStage(
 		recommendation = cannotDistribute
 	}
 	for _, expr := range exprs {
-		if err := checkExprForDistSQL(expr, &e.distSQLVisitor); err != nil {
+		// Subqueries are evaluated on the gateway during the execution, so the
+		// expressions that contain them cannot be distributed.
+		if err := checkExprForDistSQL(expr, &e.distSQLVisitor); err != nil || containsSubquery(expr) {
 			recommendation = cannotDistribute
 			if physPlan != nil {
 				// The filter expression cannot be distributed, so we need to
@@ -347,8 +373,47 @@ func (e *distSQLSpecExecFactory) ConstructInvertedFilter(
 	preFiltererType *types.T,
 	invColumn exec.NodeColumnOrdinal,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(
-		47473, "experimental opt-driven distsql planning: inverted filter")
+	physPlan, plan := getPhysPlan(n)
+	// The inverted filterer refers to the inverted column by its ordinal, so
+	// we need the plan columns to match the stream columns.
+	e.ensureIdentityPlanToStreamColMap(physPlan)
+	// When filtering is a union of inverted spans, it is distributable (see
+	// checkSupportForInvertedFilterNode for more details).
+	distributable := invFilter.Left == nil && invFilter.Right == nil
+	recommendation := e.checkExprsAndMaybeMergeLastStage(
+		nonNilExprs(preFiltererExpr), physPlan,
+	)
+	if !distributable {
+		if len(physPlan.ResultRouters) > 1 {
+			// The inverted column cannot be streamed between nodes since the
+			// remote nodes would attempt to decode it as the original type (see
+			// #50659), so we cannot merge the streams before the filterer.
+			return nil, unimplemented.NewWithIssue(
+				47473, "experimental opt-driven distsql planning: non-distributable inverted filter on distributed input")
+		}
+		recommendation = cannotDistribute
+	}
+	if err := e.dsp.addInvertedFilterStage(e.ctx, e.getPlanCtx(recommendation), physPlan, &invertedFilterPlanningInfo{
+		expression:      invFilter,
+		preFiltererExpr: preFiltererExpr,
+		preFiltererType: preFiltererType,
+		invColumn:       int(invColumn),
+		numCols:         len(physPlan.ResultColumns),
+	}); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// nonNilExprs returns the non-nil expressions among exprs.
+func nonNilExprs(exprs ...tree.TypedExpr) tree.TypedExprs {
+	res := make(tree.TypedExprs, 0, len(exprs))
+	for _, expr := range exprs {
+		if expr != nil {
+			res = append(res, expr)
+		}
+	}
+	return res
 }
 
 func (e *distSQLSpecExecFactory) ConstructSimpleProject(
@@ -414,7 +479,9 @@ func (e *distSQLSpecExecFactory) ConstructApplyJoin(
 	onCond tree.TypedExpr,
 	planRightSideFn exec.ApplyJoinPlanRightSideFn,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: apply join")
+	return e.constructWithExecFactory(left, func(ef *execFactory, left exec.Node) (exec.Node, error) {
+		return ef.ConstructApplyJoin(joinType, left, rightColumns, onCond, planRightSideFn)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructHashJoin(
@@ -613,7 +680,10 @@ func (e *distSQLSpecExecFactory) ConstructDistinct(
 func (e *distSQLSpecExecFactory) ConstructHashSetOp(
 	typ tree.UnionType, all bool, left, right exec.Node,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: hash set op")
+	return e.constructSetOp(
+		typ, all, left, right, nil /* streamingOrdering */, 0, /* hardLimit */
+		false, /* enforceHomeRegion */
+	)
 }
 
 // ConstructStreamingSetOp is part of the exec.Factory interface.
@@ -624,14 +694,107 @@ func (e *distSQLSpecExecFactory) ConstructStreamingSetOp(
 	streamingOrdering colinfo.ColumnOrdering,
 	reqOrdering exec.OutputOrdering,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: streaming set op")
+	return e.constructSetOp(
+		typ, all, left, right, streamingOrdering, 0, /* hardLimit */
+		false, /* enforceHomeRegion */
+	)
 }
 
 // ConstructUnionAll is part of the exec.Factory interface.
 func (e *distSQLSpecExecFactory) ConstructUnionAll(
 	left, right exec.Node, reqOrdering exec.OutputOrdering, hardLimit uint64, enforceHomeRegion bool,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: union all")
+	return e.constructSetOp(
+		tree.UnionOp, true /* all */, left, right,
+		colinfo.ColumnOrdering(reqOrdering), hardLimit, enforceHomeRegion,
+	)
+}
+
+// constructSetOp is a helper for constructing the physical plans for all set
+// operations. It performs the same physical planning as
+// DistSQLPlanner.createPlanForSetOp.
+func (e *distSQLSpecExecFactory) constructSetOp(
+	typ tree.UnionType,
+	all bool,
+	left, right exec.Node,
+	streamingOrdering colinfo.ColumnOrdering,
+	hardLimit uint64,
+	enforceHomeRegion bool,
+) (exec.Node, error) {
+	leftPhysPlan, leftPlan := getPhysPlan(left)
+	rightPhysPlan, rightPlan := getPhysPlan(right)
+	resultColumns, err := getSetOpResultColumns(typ, leftPhysPlan.ResultColumns, rightPhysPlan.ResultColumns)
+	if err != nil {
+		return nil, err
+	}
+	// planSetOp requires both inputs to have the same PlanToStreamColMap, so
+	// we project each side to have all of its columns in order.
+	e.ensureIdentityPlanToStreamColMap(leftPhysPlan)
+	e.ensureIdentityPlanToStreamColMap(rightPhysPlan)
+	recommendation := canDistribute
+	if hardLimit != 0 {
+		// Locality optimized UNION ALL (i.e. the one with a hard limit) must be
+		// planned locally so that the right input is only executed once the
+		// left one is exhausted.
+		recommendation = cannotDistribute
+		leftPhysPlan.EnsureSingleStreamOnGateway(e.ctx)
+		rightPhysPlan.EnsureSingleStreamOnGateway(e.ctx)
+	}
+	planCtx := e.getPlanCtx(recommendation)
+	p, err := e.dsp.planSetOp(e.ctx, planCtx, leftPhysPlan, rightPhysPlan, &setOpPlanningInfo{
+		unionType:                typ,
+		all:                      all,
+		streamingOrdering:        streamingOrdering,
+		leftReqOrdering:          execinfrapb.ConvertToColumnOrdering(leftPhysPlan.MergeOrdering),
+		rightReqOrdering:         execinfrapb.ConvertToColumnOrdering(rightPhysPlan.MergeOrdering),
+		hardLimit:                hardLimit,
+		enforceHomeRegion:        enforceHomeRegion,
+		leftPlanDistribution:     leftPhysPlan.Distribution,
+		rightPlanDistribution:    rightPhysPlan.Distribution,
+		allowPartialDistribution: e.planningMode != distSQLLocalOnlyPlanning,
+	})
+	if err != nil {
+		return nil, err
+	}
+	p.ResultColumns = resultColumns
+	return makePlanMaybePhysical(p, append(leftPlan.physPlan.planNodesToClose, rightPlan.physPlan.planNodesToClose...)), nil
+}
+
+// ensureIdentityPlanToStreamColMap adds a projection to the plan, if
+// necessary, so that the i-th column of the plan is the i-th output column of
+// the last stage.
+func (e *distSQLSpecExecFactory) ensureIdentityPlanToStreamColMap(physPlan *PhysicalPlan) {
+	isIdentity := len(physPlan.PlanToStreamColMap) == len(physPlan.GetResultTypes())
+	for i, streamCol := range physPlan.PlanToStreamColMap {
+		if streamCol != i {
+			isIdentity = false
+			break
+		}
+	}
+	if isIdentity {
+		return
+	}
+	projection := make([]uint32, len(physPlan.PlanToStreamColMap))
+	streamToPlanColMap := make(map[uint32]uint32, len(projection))
+	for i, streamCol := range physPlan.PlanToStreamColMap {
+		projection[i] = uint32(streamCol)
+		streamToPlanColMap[uint32(streamCol)] = uint32(i)
+	}
+	// Remap the merge ordering onto the projected columns. Any ordering columns
+	// that are projected out truncate the ordering.
+	var newMergeOrdering execinfrapb.Ordering
+	for _, c := range physPlan.MergeOrdering.Columns {
+		planCol, ok := streamToPlanColMap[c.ColIdx]
+		if !ok {
+			break
+		}
+		newMergeOrdering.Columns = append(newMergeOrdering.Columns, execinfrapb.Ordering_Column{
+			ColIdx:    planCol,
+			Direction: c.Direction,
+		})
+	}
+	physPlan.AddProjection(projection, newMergeOrdering)
+	physPlan.PlanToStreamColMap = identityMap(physPlan.PlanToStreamColMap, len(projection))
 }
 
 func (e *distSQLSpecExecFactory) ConstructSort(
@@ -647,7 +810,13 @@ func (e *distSQLSpecExecFactory) ConstructSort(
 func (e *distSQLSpecExecFactory) ConstructOrdinality(
 	input exec.Node, colName string,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: ordinality")
+	physPlan, plan := getPhysPlan(input)
+	e.dsp.addOrdinality(e.ctx, physPlan)
+	physPlan.ResultColumns = append(physPlan.ResultColumns, colinfo.ResultColumn{
+		Name: colName,
+		Typ:  types.Int,
+	})
+	return plan, nil
 }
 
 func (e *distSQLSpecExecFactory) ConstructIndexJoin(
@@ -659,7 +828,30 @@ func (e *distSQLSpecExecFactory) ConstructIndexJoin(
 	locking opt.Locking,
 	limitHint int64,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: index join")
+	physPlan, plan := getPhysPlan(input)
+	tabDesc := table.(*optTable).desc
+	cols := makeColList(table, tableCols)
+
+	// TODO (cucaroach): update indexUsageStats.
+
+	info := &indexJoinPlanningInfo{
+		keyCols:           make([]int, len(keyCols)),
+		desc:              tabDesc,
+		cols:              cols,
+		lockingStrength:   descpb.ToScanLockingStrength(locking.Strength),
+		lockingWaitPolicy: descpb.ToScanLockingWaitPolicy(locking.WaitPolicy),
+		lockingDurability: descpb.ToScanLockingDurability(locking.Durability),
+		reqOrdering:       ReqOrdering(reqOrdering),
+		limitHint:         limitHint,
+	}
+	for i, c := range keyCols {
+		info.keyCols[i] = int(c)
+	}
+	if err := e.dsp.addIndexJoinStage(e.ctx, e.getPlanCtx(canDistribute), physPlan, info); err != nil {
+		return nil, err
+	}
+	physPlan.ResultColumns = colinfo.ResultColumnsFromColumns(tabDesc.GetID(), cols)
+	return plan, nil
 }
 
 func (e *distSQLSpecExecFactory) ConstructLookupJoin(
@@ -680,8 +872,74 @@ func (e *distSQLSpecExecFactory) ConstructLookupJoin(
 	limitHint int64,
 	remoteOnlyLookups bool,
 ) (exec.Node, error) {
-	// TODO (rohany): Implement production of system columns by the underlying scan here.
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: lookup join")
+	if table.IsVirtualTable() {
+		// Virtual tables can only be accessed via planNodes.
+		return e.constructWithExecFactory(input, func(ef *execFactory, input exec.Node) (exec.Node, error) {
+			return ef.ConstructLookupJoin(
+				joinType, input, table, index, eqCols, eqColsAreKey, lookupExpr, remoteLookupExpr,
+				lookupCols, onCond, isFirstJoinInPairedJoiner, isSecondJoinInPairedJoiner,
+				reqOrdering, locking, limitHint, remoteOnlyLookups,
+			)
+		})
+	}
+	physPlan, plan := getPhysPlan(input)
+	tabDesc := table.(*optTable).desc
+	idx := index.(*optIndex).idx
+	cols, err := initColsForScan(tabDesc, makeScanColumnsConfig(table, lookupCols))
+	if err != nil {
+		return nil, err
+	}
+	if onCond == tree.DBoolTrue {
+		onCond = nil
+	}
+
+	// TODO (cucaroach): update indexUsageStats.
+
+	recommendation := e.checkExprsAndMaybeMergeLastStage(
+		nonNilExprs(lookupExpr, remoteLookupExpr, onCond), physPlan,
+	)
+	lockingStrength := descpb.ToScanLockingStrength(locking.Strength)
+	if remoteLookupExpr != nil || remoteOnlyLookups || lockingStrength != descpb.ScanLockingStrength_FOR_NONE {
+		// Locality optimized lookup joins must be planned on the gateway.
+		// Lookup joins that are performing row-level locking cannot currently
+		// be distributed because their locks would not be propagated back to
+		// the root transaction coordinator.
+		// TODO(nvanbenschoten): lift this restriction.
+		recommendation = cannotDistribute
+		physPlan.EnsureSingleStreamOnGateway(e.ctx)
+	}
+	info := &lookupJoinPlanningInfo{
+		joinType:                   joinType,
+		desc:                       tabDesc,
+		index:                      idx,
+		cols:                       cols,
+		lockingStrength:            lockingStrength,
+		lockingWaitPolicy:          descpb.ToScanLockingWaitPolicy(locking.WaitPolicy),
+		lockingDurability:          descpb.ToScanLockingDurability(locking.Durability),
+		eqCols:                     make([]int, len(eqCols)),
+		eqColsAreKey:               eqColsAreKey,
+		lookupExpr:                 lookupExpr,
+		remoteLookupExpr:           remoteLookupExpr,
+		onCond:                     onCond,
+		isFirstJoinInPairedJoiner:  isFirstJoinInPairedJoiner,
+		isSecondJoinInPairedJoiner: isSecondJoinInPairedJoiner,
+		reqOrdering:                ReqOrdering(reqOrdering),
+		limitHint:                  limitHint,
+		remoteOnlyLookups:          remoteOnlyLookups,
+	}
+	for i, c := range eqCols {
+		info.eqCols[i] = int(c)
+	}
+	if err = e.dsp.addLookupJoinStage(e.ctx, e.getPlanCtx(recommendation), physPlan, info); err != nil {
+		return nil, err
+	}
+	physPlan.ResultColumns = getJoinResultColumns(
+		joinType, physPlan.ResultColumns, colinfo.ResultColumnsFromColumns(tabDesc.GetID(), cols),
+	)
+	if isFirstJoinInPairedJoiner {
+		physPlan.ResultColumns = append(physPlan.ResultColumns, colinfo.ResultColumn{Name: "cont", Typ: types.Bool})
+	}
+	return plan, nil
 }
 
 func (e *distSQLSpecExecFactory) ConstructInvertedJoin(
@@ -697,7 +955,60 @@ func (e *distSQLSpecExecFactory) ConstructInvertedJoin(
 	reqOrdering exec.OutputOrdering,
 	locking opt.Locking,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: inverted join")
+	physPlan, plan := getPhysPlan(input)
+	tabDesc := table.(*optTable).desc
+	idx := index.(*optIndex).idx
+	cols, err := initColsForScan(tabDesc, makeScanColumnsConfig(table, lookupCols))
+	if err != nil {
+		return nil, err
+	}
+	if onCond == tree.DBoolTrue {
+		onCond = nil
+	}
+
+	// TODO (cucaroach): update indexUsageStats.
+
+	recommendation := e.checkExprsAndMaybeMergeLastStage(nonNilExprs(invertedExpr, onCond), physPlan)
+	lockingStrength := descpb.ToScanLockingStrength(locking.Strength)
+	if lockingStrength != descpb.ScanLockingStrength_FOR_NONE {
+		// Inverted joins that are performing row-level locking cannot currently
+		// be distributed because their locks would not be propagated back to
+		// the root transaction coordinator.
+		// TODO(nvanbenschoten): lift this restriction.
+		recommendation = cannotDistribute
+		physPlan.EnsureSingleStreamOnGateway(e.ctx)
+	}
+	info := &invertedJoinPlanningInfo{
+		joinType:                  joinType,
+		desc:                      tabDesc,
+		index:                     idx,
+		cols:                      cols,
+		lockingStrength:           lockingStrength,
+		lockingWaitPolicy:         descpb.ToScanLockingWaitPolicy(locking.WaitPolicy),
+		lockingDurability:         descpb.ToScanLockingDurability(locking.Durability),
+		invertedExpr:              invertedExpr,
+		onCond:                    onCond,
+		isFirstJoinInPairedJoiner: isFirstJoinInPairedJoiner,
+		reqOrdering:               ReqOrdering(reqOrdering),
+	}
+	if len(prefixEqCols) > 0 {
+		info.prefixEqCols = make([]int, len(prefixEqCols))
+		for i, c := range prefixEqCols {
+			info.prefixEqCols[i] = int(c)
+		}
+	}
+	if err = e.dsp.addInvertedJoinStage(e.ctx, e.getPlanCtx(recommendation), physPlan, info); err != nil {
+		return nil, err
+	}
+	if joinType.ShouldIncludeRightColsInOutput() {
+		physPlan.ResultColumns = append(
+			physPlan.ResultColumns, colinfo.ResultColumnsFromColumns(tabDesc.GetID(), cols)...,
+		)
+	}
+	if isFirstJoinInPairedJoiner {
+		physPlan.ResultColumns = append(physPlan.ResultColumns, colinfo.ResultColumn{Name: "cont", Typ: types.Bool})
+	}
+	return plan, nil
 }
 
 func (e *distSQLSpecExecFactory) constructZigzagJoinSide(
@@ -796,6 +1107,11 @@ func (e *distSQLSpecExecFactory) ConstructZigzagJoin(
 func (e *distSQLSpecExecFactory) ConstructLimit(
 	input exec.Node, limitExpr, offsetExpr tree.TypedExpr,
 ) (exec.Node, error) {
+	if (limitExpr != nil && containsSubquery(limitExpr)) || (offsetExpr != nil && containsSubquery(offsetExpr)) {
+		// LIMIT and OFFSET are evaluated during the planning, before the
+		// subqueries are run.
+		return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: subquery in limit or offset")
+	}
 	physPlan, plan := getPhysPlan(input)
 	// Note that we pass in nil slice for exprs because we will evaluate both
 	// expressions below, locally.
@@ -830,7 +1146,9 @@ func (e *distSQLSpecExecFactory) ConstructTopK(
 func (e *distSQLSpecExecFactory) ConstructMax1Row(
 	input exec.Node, errorText string,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: max1row")
+	return e.constructWithExecFactory(input, func(ef *execFactory, input exec.Node) (exec.Node, error) {
+		return ef.ConstructMax1Row(input, errorText)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructProjectSet(
@@ -862,7 +1180,26 @@ func (e *distSQLSpecExecFactory) ConstructProjectSet(
 func (e *distSQLSpecExecFactory) ConstructWindow(
 	input exec.Node, window exec.WindowInfo,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: window")
+	for _, expr := range window.Exprs {
+		if expr.WindowDef != nil && expr.WindowDef.Frame != nil && frameContainsSubquery(expr.WindowDef.Frame) {
+			// The frame offsets are evaluated during the planning, before the
+			// subqueries are run.
+			return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: subquery in window frame offset")
+		}
+	}
+	physPlan, plan := getPhysPlan(input)
+	funcs, err := makeWindowFuncHolders(window)
+	if err != nil {
+		return nil, err
+	}
+	// The window functions refer to the input columns by their ordinals, so
+	// we need the plan columns to match the stream columns.
+	e.ensureIdentityPlanToStreamColMap(physPlan)
+	if err = e.dsp.addWindowers(e.ctx, e.getPlanCtx(shouldDistribute), physPlan, funcs); err != nil {
+		return nil, err
+	}
+	physPlan.ResultColumns = window.Cols
+	return plan, nil
 }
 
 func (e *distSQLSpecExecFactory) ConstructPlan(
@@ -873,30 +1210,53 @@ func (e *distSQLSpecExecFactory) ConstructPlan(
 	rootRowCount int64,
 	flags exec.PlanFlags,
 ) (exec.Plan, error) {
-	if len(subqueries) != 0 {
-		return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: subqueries")
+	rootPlan, ok := root.(planMaybePhysical)
+	if !ok {
+		return nil, errors.AssertionFailedf("unexpected type for root: %T", root)
 	}
-	if len(cascades) != 0 {
-		return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: cascades")
+	if e.planner.stmt.AST != nil && e.planner.stmt.AST.StatementReturnType() == tree.RowsAffected {
+		if err := e.enableFastPath(rootPlan.physPlan.PhysicalPlan); err != nil {
+			return nil, err
+		}
 	}
-	if len(checks) != 0 {
-		return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: checks")
+	if len(subqueries) > 0 || len(checks) > 0 {
+		// The subqueries, the checks and the main query are run as separate
+		// flows, but they were constructed using the same physical
+		// infrastructure, so each of them is moved into its own.
+		subqueries = append([]exec.Subquery(nil), subqueries...)
+		for i := range subqueries {
+			if err := e.detachPlan(subqueries[i].Root); err != nil {
+				return nil, err
+			}
+		}
+		for _, check := range checks {
+			if err := e.detachPlan(check); err != nil {
+				return nil, err
+			}
+		}
+		if err := e.detachPlan(root); err != nil {
+			return nil, err
+		}
 	}
-	if len(triggers) != 0 {
-		return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: triggers")
+	// The cascades and the triggers are planned during the execution using
+	// execFactory, which expects the buffers to be bufferNodes.
+	var err error
+	if cascades, err = unwrapPostQueryBuffers(cascades); err != nil {
+		return nil, err
 	}
-	if p, ok := root.(planMaybePhysical); !ok {
-		return nil, errors.AssertionFailedf("unexpected type for root: %T", root)
-	} else {
-		p.physPlan.onClose = e.planCtx.getCleanupFunc()
+	if triggers, err = unwrapPostQueryBuffers(triggers); err != nil {
+		return nil, err
 	}
+	rootPlan.physPlan.onClose = e.planCtx.getCleanupFunc()
 	return constructPlan(e.planner, root, subqueries, cascades, triggers, checks, rootRowCount, flags)
 }
 
 func (e *distSQLSpecExecFactory) ConstructExplainOpt(
 	plan string, envOpts exec.ExplainEnvData,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: explain opt")
+	return e.constructWithExecFactory(nil /* input */, func(ef *execFactory, _ exec.Node) (exec.Node, error) {
+		return ef.ConstructExplainOpt(plan, envOpts)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructExplain(
@@ -961,7 +1321,9 @@ func (e *distSQLSpecExecFactory) ConstructExplain(
 func (e *distSQLSpecExecFactory) ConstructShowTrace(
 	typ tree.ShowTraceType, compact bool,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: show trace")
+	return e.constructWithExecFactory(nil /* input */, func(ef *execFactory, _ exec.Node) (exec.Node, error) {
+		return ef.ConstructShowTrace(typ, compact)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructInsert(
@@ -975,7 +1337,12 @@ func (e *distSQLSpecExecFactory) ConstructInsert(
 	uniqueWithTombstoneIndexes cat.IndexOrdinals,
 	autoCommit bool,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: insert")
+	return e.constructWithExecFactory(input, func(ef *execFactory, input exec.Node) (exec.Node, error) {
+		return ef.ConstructInsert(
+			input, table, arbiterIndexes, arbiterConstraints, insertCols, returnCols, checkCols,
+			uniqueWithTombstoneIndexes, autoCommit,
+		)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructInsertFastPath(
@@ -989,7 +1356,12 @@ func (e *distSQLSpecExecFactory) ConstructInsertFastPath(
 	uniqueWithTombstoneIndexes cat.IndexOrdinals,
 	autoCommit bool,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: insert fast path")
+	return e.constructWithExecFactory(nil /* input */, func(ef *execFactory, _ exec.Node) (exec.Node, error) {
+		return ef.ConstructInsertFastPath(
+			rows, table, insertCols, returnCols, checkCols, fkChecks, uniqChecks,
+			uniqueWithTombstoneIndexes, autoCommit,
+		)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructUpdate(
@@ -1003,7 +1375,12 @@ func (e *distSQLSpecExecFactory) ConstructUpdate(
 	uniqueWithTombstoneIndexes cat.IndexOrdinals,
 	autoCommit bool,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: update")
+	return e.constructWithExecFactory(input, func(ef *execFactory, input exec.Node) (exec.Node, error) {
+		return ef.ConstructUpdate(
+			input, table, fetchCols, updateCols, returnCols, checks, passthrough,
+			uniqueWithTombstoneIndexes, autoCommit,
+		)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructUpsert(
@@ -1020,7 +1397,12 @@ func (e *distSQLSpecExecFactory) ConstructUpsert(
 	uniqueWithTombstoneIndexes cat.IndexOrdinals,
 	autoCommit bool,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: upsert")
+	return e.constructWithExecFactory(input, func(ef *execFactory, input exec.Node) (exec.Node, error) {
+		return ef.ConstructUpsert(
+			input, table, arbiterIndexes, arbiterConstraints, canaryCol, insertCols, fetchCols,
+			updateCols, returnCols, checks, uniqueWithTombstoneIndexes, autoCommit,
+		)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructDelete(
@@ -1031,7 +1413,9 @@ func (e *distSQLSpecExecFactory) ConstructDelete(
 	passthrough colinfo.ResultColumns,
 	autoCommit bool,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: delete")
+	return e.constructWithExecFactory(input, func(ef *execFactory, input exec.Node) (exec.Node, error) {
+		return ef.ConstructDelete(input, table, fetchCols, returnCols, passthrough, autoCommit)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructDeleteRange(
@@ -1040,19 +1424,25 @@ func (e *distSQLSpecExecFactory) ConstructDeleteRange(
 	indexConstraint *constraint.Constraint,
 	autoCommit bool,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: delete range")
+	return e.constructWithExecFactory(nil /* input */, func(ef *execFactory, _ exec.Node) (exec.Node, error) {
+		return ef.ConstructDeleteRange(table, needed, indexConstraint, autoCommit)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructCreateTable(
 	schema cat.Schema, ct *tree.CreateTable,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: create table")
+	return e.constructWithExecFactory(nil /* input */, func(ef *execFactory, _ exec.Node) (exec.Node, error) {
+		return ef.ConstructCreateTable(schema, ct)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructCreateTableAs(
 	input exec.Node, schema cat.Schema, ct *tree.CreateTable,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: create table")
+	return e.constructWithExecFactory(input, func(ef *execFactory, input exec.Node) (exec.Node, error) {
+		return ef.ConstructCreateTableAs(input, schema, ct)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructCreateView(
@@ -1063,7 +1453,9 @@ func (e *distSQLSpecExecFactory) ConstructCreateView(
 	deps opt.SchemaDeps,
 	typeDeps opt.SchemaTypeDeps,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: create view")
+	return e.constructWithExecFactory(nil /* input */, func(ef *execFactory, _ exec.Node) (exec.Node, error) {
+		return ef.ConstructCreateView(createView, schema, viewQuery, columns, deps, typeDeps)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructCreateFunction(
@@ -1073,27 +1465,37 @@ func (e *distSQLSpecExecFactory) ConstructCreateFunction(
 	typeDeps opt.SchemaTypeDeps,
 	functionDeps opt.SchemaFunctionDeps,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: create function")
+	return e.constructWithExecFactory(nil /* input */, func(ef *execFactory, _ exec.Node) (exec.Node, error) {
+		return ef.ConstructCreateFunction(schema, cf, deps, typeDeps, functionDeps)
+	})
 }
 
-func (e *distSQLSpecExecFactory) ConstructCreateTrigger(_ *tree.CreateTrigger) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: create trigger")
+func (e *distSQLSpecExecFactory) ConstructCreateTrigger(ct *tree.CreateTrigger) (exec.Node, error) {
+	return e.constructWithExecFactory(nil /* input */, func(ef *execFactory, _ exec.Node) (exec.Node, error) {
+		return ef.ConstructCreateTrigger(ct)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructSequenceSelect(sequence cat.Sequence) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: sequence select")
+	return e.constructWithExecFactory(nil /* input */, func(ef *execFactory, _ exec.Node) (exec.Node, error) {
+		return ef.ConstructSequenceSelect(sequence)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructSaveTable(
 	input exec.Node, table *cat.DataSourceName, colNames []string,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: save table")
+	return e.constructWithExecFactory(input, func(ef *execFactory, input exec.Node) (exec.Node, error) {
+		return ef.ConstructSaveTable(input, table, colNames)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructErrorIfRows(
 	input exec.Node, mkErr exec.MkErrFn,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: error if rows")
+	return e.constructWithExecFactory(input, func(ef *execFactory, input exec.Node) (exec.Node, error) {
+		return ef.ConstructErrorIfRows(input, mkErr)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructOpaque(metadata opt.OpaqueMetadata) (exec.Node, error) {
@@ -1113,23 +1515,31 @@ func (e *distSQLSpecExecFactory) ConstructOpaque(metadata opt.OpaqueMetadata) (e
 func (e *distSQLSpecExecFactory) ConstructAlterTableSplit(
 	index cat.Index, input exec.Node, expiration tree.TypedExpr,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: alter table split")
+	return e.constructWithExecFactory(input, func(ef *execFactory, input exec.Node) (exec.Node, error) {
+		return ef.ConstructAlterTableSplit(index, input, expiration)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructAlterTableUnsplit(
 	index cat.Index, input exec.Node,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: alter table unsplit")
+	return e.constructWithExecFactory(input, func(ef *execFactory, input exec.Node) (exec.Node, error) {
+		return ef.ConstructAlterTableUnsplit(index, input)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructAlterTableUnsplitAll(index cat.Index) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: alter table unsplit all")
+	return e.constructWithExecFactory(nil /* input */, func(ef *execFactory, _ exec.Node) (exec.Node, error) {
+		return ef.ConstructAlterTableUnsplitAll(index)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructAlterTableRelocate(
 	index cat.Index, input exec.Node, relocateSubject tree.RelocateSubject,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: alter table relocate")
+	return e.constructWithExecFactory(input, func(ef *execFactory, input exec.Node) (exec.Node, error) {
+		return ef.ConstructAlterTableRelocate(index, input, relocateSubject)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructAlterRangeRelocate(
@@ -1138,59 +1548,85 @@ func (e *distSQLSpecExecFactory) ConstructAlterRangeRelocate(
 	toStoreID tree.TypedExpr,
 	fromStoreID tree.TypedExpr,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: alter range relocate")
+	return e.constructWithExecFactory(input, func(ef *execFactory, input exec.Node) (exec.Node, error) {
+		return ef.ConstructAlterRangeRelocate(input, relocateSubject, toStoreID, fromStoreID)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructBuffer(input exec.Node, label string) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: buffer")
+	return e.constructWithExecFactory(input, func(ef *execFactory, input exec.Node) (exec.Node, error) {
+		return ef.ConstructBuffer(input, label)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructScanBuffer(
 	ref exec.Node, label string,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: scan buffer")
+	buffer, err := getBufferNode(ref)
+	if err != nil {
+		return nil, err
+	}
+	return e.constructWithExecFactory(nil /* input */, func(ef *execFactory, _ exec.Node) (exec.Node, error) {
+		return ef.ConstructScanBuffer(buffer, label)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructRecursiveCTE(
 	initial exec.Node, fn exec.RecursiveCTEIterationFn, label string, deduplicate bool,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: recursive CTE")
+	// Note that the iterations are planned during the execution, using
+	// execFactory.
+	return e.constructWithExecFactory(initial, func(ef *execFactory, initial exec.Node) (exec.Node, error) {
+		return ef.ConstructRecursiveCTE(initial, fn, label, deduplicate)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructControlJobs(
 	command tree.JobCommand, input exec.Node, reason tree.TypedExpr,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: control jobs")
+	return e.constructWithExecFactory(input, func(ef *execFactory, input exec.Node) (exec.Node, error) {
+		return ef.ConstructControlJobs(command, input, reason)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructControlSchedules(
 	command tree.ScheduleCommand, input exec.Node,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: control jobs")
+	return e.constructWithExecFactory(input, func(ef *execFactory, input exec.Node) (exec.Node, error) {
+		return ef.ConstructControlSchedules(command, input)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructCancelQueries(
 	input exec.Node, ifExists bool,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: cancel queries")
+	return e.constructWithExecFactory(input, func(ef *execFactory, input exec.Node) (exec.Node, error) {
+		return ef.ConstructCancelQueries(input, ifExists)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructShowCompletions(
 	input *tree.ShowCompletions,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: show completions")
+	return e.constructWithExecFactory(nil /* input */, func(ef *execFactory, _ exec.Node) (exec.Node, error) {
+		return ef.ConstructShowCompletions(input)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructCancelSessions(
 	input exec.Node, ifExists bool,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: cancel sessions")
+	return e.constructWithExecFactory(input, func(ef *execFactory, input exec.Node) (exec.Node, error) {
+		return ef.ConstructCancelSessions(input, ifExists)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructCreateStatistics(
 	cs *tree.CreateStats,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: create statistics")
+	return e.constructWithExecFactory(nil /* input */, func(ef *execFactory, _ exec.Node) (exec.Node, error) {
+		return ef.ConstructCreateStatistics(cs)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructExport(
@@ -1200,7 +1636,11 @@ func (e *distSQLSpecExecFactory) ConstructExport(
 	options []exec.KVOption,
 	notNullColsSet exec.NodeColumnOrdinalSet,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: export")
+	// The exporters are planned by the DistSQL planner on top of the input
+	// (see createPlanForExport).
+	return e.constructWithExecFactory(input, func(ef *execFactory, input exec.Node) (exec.Node, error) {
+		return ef.ConstructExport(input, fileName, fileFormat, options, notNullColsSet)
+	})
 }
 
 func getPhysPlan(n exec.Node) (*PhysicalPlan, planMaybePhysical) {
@@ -1219,6 +1659,18 @@ func (e *distSQLSpecExecFactory) constructHashOrMergeJoin(
 ) (exec.Node, error) {
 	leftPhysPlan, leftPlan := getPhysPlan(left)
 	rightPhysPlan, rightPlan := getPhysPlan(right)
+	// We always try to distribute the join, but planJoiners() itself might
+	// decide not to.
+	recommendation := shouldDistribute
+	if onCond != nil {
+		if err := checkExprForDistSQL(onCond, &e.distSQLVisitor); err != nil || containsSubquery(onCond) {
+			// The ON expression cannot be distributed, so we plan the joiner
+			// on the gateway by bringing both inputs there.
+			recommendation = cannotDistribute
+			leftPhysPlan.EnsureSingleStreamOnGateway(e.ctx)
+			rightPhysPlan.EnsureSingleStreamOnGateway(e.ctx)
+		}
+	}
 	resultColumns := getJoinResultColumns(joinType, leftPhysPlan.ResultColumns, rightPhysPlan.ResultColumns)
 	leftMap, rightMap := leftPhysPlan.PlanToStreamColMap, rightPhysPlan.PlanToStreamColMap
 	helper := &joinPlanningHelper{
@@ -1229,9 +1681,7 @@ func (e *distSQLSpecExecFactory) constructHashOrMergeJoin(
 		rightPlanToStreamColMap: rightMap,
 	}
 	post, joinToStreamColMap := helper.joinOutColumns(joinType, resultColumns)
-	// We always try to distribute the join, but planJoiners() itself might
-	// decide not to.
-	planCtx := e.getPlanCtx(shouldDistribute)
+	planCtx := e.getPlanCtx(recommendation)
 	onExpr, err := helper.remapOnExpr(e.ctx, planCtx, onCond)
 	if err != nil {
 		return nil, err
@@ -1265,9 +1715,161 @@ func (e *distSQLSpecExecFactory) constructHashOrMergeJoin(
 func (e *distSQLSpecExecFactory) ConstructLiteralValues(
 	rows tree.ExprContainer, cols colinfo.ResultColumns,
 ) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: literal values")
+	return e.constructWithExecFactory(nil /* input */, func(ef *execFactory, _ exec.Node) (exec.Node, error) {
+		return ef.ConstructLiteralValues(rows, cols)
+	})
 }
 
 func (e *distSQLSpecExecFactory) ConstructCall(proc *tree.RoutineExpr) (exec.Node, error) {
-	return nil, unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: call")
+	return e.constructWithExecFactory(nil /* input */, func(ef *execFactory, _ exec.Node) (exec.Node, error) {
+		return ef.ConstructCall(proc)
+	})
+}
+
+// constructWithExecFactory constructs an operator that doesn't have a DistSQL
+// processor equivalent as a planNode using execFactory, and wraps it into the
+// physical plan on the gateway. The physical plan of the input, if there is
+// one, is passed to build as a specInputNode, and it feeds into the wrapped
+// planNode during the execution.
+func (e *distSQLSpecExecFactory) constructWithExecFactory(
+	input exec.Node, build func(ef *execFactory, input exec.Node) (exec.Node, error),
+) (exec.Node, error) {
+	var (
+		inputNode        exec.Node
+		planNodesToClose []planNode
+	)
+	if input != nil {
+		physPlan, plan := getPhysPlan(input)
+		inputNode = &specInputNode{physPlan: physPlan}
+		planNodesToClose = plan.physPlan.planNodesToClose
+	}
+	n, err := build(newExecFactory(e.ctx, e.planner), inputNode)
+	if err != nil {
+		return nil, err
+	}
+	plan := n.(planNode)
+	physPlan, err := e.dsp.createPhysPlanForPlanNode(e.ctx, e.getPlanCtx(cannotDistribute), plan)
+	if err != nil {
+		return nil, err
+	}
+	physPlan.ResultColumns = planColumns(plan)
+	return makePlanMaybePhysical(physPlan, append(planNodesToClose, plan)), nil
+}
+
+// enableFastPath makes the root of the plan of a statement that returns the
+// number of rows affected output that number, the same way wrapPlan does it
+// for the root planNode.
+func (e *distSQLSpecExecFactory) enableFastPath(physPlan *PhysicalPlan) error {
+	if len(physPlan.ResultRouters) == 1 {
+		proc := &physPlan.Processors[physPlan.ResultRouters[0]]
+		post := &proc.Spec.Post
+		isEmptyPost := !post.Projection && len(post.RenderExprs) == 0 && post.Offset == 0 && post.Limit == 0
+		if localPlanNode := proc.Spec.Core.LocalPlanNode; localPlanNode != nil && isEmptyPost {
+			if wrapper, ok := physPlan.LocalProcessors[localPlanNode.RowSourceIdx].(*planNodeToRowSource); ok {
+				wrapper.enableFastPath()
+				proc.Spec.ResultTypes = wrapper.outputTypes
+				physPlan.PlanToStreamColMap = []int{0}
+				return nil
+			}
+		}
+	}
+	return unimplemented.NewWithIssue(47473, "experimental opt-driven distsql planning: rows affected by a non-wrapped plan")
+}
+
+// detachPlan moves the physical plan of the given node into its own physical
+// infrastructure which is released together with the shared one.
+func (e *distSQLSpecExecFactory) detachPlan(n exec.Node) error {
+	plan, ok := n.(planMaybePhysical)
+	if !ok {
+		return errors.AssertionFailedf("unexpected type for plan: %T", n)
+	}
+	plan.physPlan.Detach(uuid.MakeV4())
+	e.planCtx.onFlowCleanup = append(e.planCtx.onFlowCleanup, plan.physPlan.PhysicalInfrastructure.Release)
+	return nil
+}
+
+// getBufferNode returns the bufferNode that ConstructBuffer wrapped into the
+// physical plan of n.
+func getBufferNode(n exec.Node) (*bufferNode, error) {
+	if plan, ok := n.(planMaybePhysical); ok && plan.isPhysicalPlan() {
+		if toClose := plan.physPlan.planNodesToClose; len(toClose) > 0 {
+			if buffer, ok := toClose[len(toClose)-1].(*bufferNode); ok {
+				return buffer, nil
+			}
+		}
+	}
+	return nil, errors.AssertionFailedf("unexpected type for buffer: %T", n)
+}
+
+// unwrapPostQueryBuffers returns a copy of postQueries with the buffers
+// replaced by the corresponding bufferNodes.
+func unwrapPostQueryBuffers(postQueries []exec.PostQuery) ([]exec.PostQuery, error) {
+	if len(postQueries) == 0 {
+		return postQueries, nil
+	}
+	res := append([]exec.PostQuery(nil), postQueries...)
+	for i := range res {
+		if res[i].Buffer == nil {
+			continue
+		}
+		buffer, err := getBufferNode(res[i].Buffer)
+		if err != nil {
+			return nil, err
+		}
+		res[i].Buffer = buffer
+	}
+	return res, nil
+}
+
+// containsSubquery returns whether expr contains a subquery.
+func containsSubquery(expr tree.Expr) bool {
+	var v subqueryFinder
+	tree.WalkExprConst(&v, expr)
+	return v.found
 }
+
+// frameContainsSubquery returns whether the offsets of the window frame
+// contain a subquery.
+func frameContainsSubquery(frame *tree.WindowFrame) bool {
+	for _, bound := range []*tree.WindowFrameBound{frame.Bounds.StartBound, frame.Bounds.EndBound} {
+		if bound != nil && bound.HasOffset() && containsSubquery(bound.OffsetExpr) {
+			return true
+		}
+	}
+	return false
+}
+
+type subqueryFinder struct {
+	found bool
+}
+
+var _ tree.Visitor = &subqueryFinder{}
+
+func (v *subqueryFinder) VisitPre(expr tree.Expr) (recurse bool, newExpr tree.Expr) {
+	if _, ok := expr.(*tree.Subquery); ok {
+		v.found = true
+	}
+	return !v.found, expr
+}
+
+func (v *subqueryFinder) VisitPost(expr tree.Expr) tree.Expr { return expr }
+
+// specInputNode is a planNode that stands in for the physical plan of the
+// input of a planNode constructed in constructWithExecFactory. It is never
+// executed: the DistSQL planner uses the physical plan in its place (see
+// createPhysPlanForPlanNode), and the planNodeToRowSource wrapping the parent
+// replaces it with a rowSourceToPlanNode before the execution.
+type specInputNode struct {
+	physPlan *PhysicalPlan
+}
+
+func (n *specInputNode) startExec(runParams) error {
+	return errors.AssertionFailedf("specInputNode cannot be executed")
+}
+
+func (n *specInputNode) Next(runParams) (bool, error) {
+	return false, errors.AssertionFailedf("specInputNode cannot be executed")
+}
+
+func (n *specInputNode) Values() tree.Datums       { return nil }
+func (n *specInputNode) Close(ctx context.Context) {}
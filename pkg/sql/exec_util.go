@@ -1562,6 +1562,11 @@ type ExecutorTestingKnobs struct {
 	// statement.
 	AfterExecute func(ctx context.Context, stmt string, isInternal bool, err error)
 
+	// BeforeParallelRead is called before each statement of a parallel read
+	// batch is executed, in the goroutine executing it. It is useful for
+	// observing the concurrency of the batch.
+	BeforeParallelRead func(ctx context.Context, stmt string)
+
 	// AfterExecCmd is called after successful execution of any command.
 	AfterExecCmd func(ctx context.Context, cmd Command, buf *StmtBuf)
 
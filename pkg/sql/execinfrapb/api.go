@@ -62,6 +62,11 @@ func (m *BackupDataSpec) User() username.SQLUsername {
 	return m.UserProto.Decode()
 }
 
+// User accesses the user field.
+func (m *ContinuousBackupDataSpec) User() username.SQLUsername {
+	return m.UserProto.Decode()
+}
+
 // User accesses the user field.
 func (m *ExportSpec) User() username.SQLUsername {
 	return m.UserProto.Decode()
@@ -258,6 +258,13 @@ func (*DummyEvalPlanner) DecodeGist(
 	return nil, errors.WithStack(errEvalPlanner)
 }
 
+// EstimateStatementCost is part of the Planner interface.
+func (*DummyEvalPlanner) EstimateStatementCost(
+	ctx context.Context, stmt string, database string, hypotheticalIndexes []tree.CreateIndex,
+) (cost, hypotheticalCost, rowCount float64, _ error) {
+	return 0, 0, 0, errors.WithStack(errEvalPlanner)
+}
+
 // SerializeSessionState is part of the Planner interface.
 func (*DummyEvalPlanner) SerializeSessionState() (*tree.DBytes, error) {
 	return nil, errors.WithStack(errEvalPlanner)
@@ -358,6 +365,20 @@ func (p *DummyEvalPlanner) ExtendHistoryRetention(ctx context.Context, id jobspb
 	return errors.WithStack(errEvalPlanner)
 }
 
+// CreateWorkloadIndexAdviceJob is part of the Planner interface.
+func (*DummyEvalPlanner) CreateWorkloadIndexAdviceJob(
+	ctx context.Context, since time.Time,
+) (jobspb.JobID, error) {
+	return 0, errors.WithStack(errEvalPlanner)
+}
+
+// LatestWorkloadIndexAdvice is part of the Planner interface.
+func (*DummyEvalPlanner) LatestWorkloadIndexAdvice(
+	ctx context.Context, since *time.Time,
+) ([]jobspb.WorkloadIndexAdviceProgress_Advice, error) {
+	return nil, errors.WithStack(errEvalPlanner)
+}
+
 var _ eval.Planner = &DummyEvalPlanner{}
 
 var errEvalPlanner = pgerror.New(pgcode.ScalarOperationCannotRunWithoutFullSessionContext,
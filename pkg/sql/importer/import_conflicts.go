@@ -0,0 +1,383 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package importer
+
+import (
+	"bytes"
+	"context"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/keys"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/settings"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/descpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog/fetchpb"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/row"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowinfra"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/catid"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/util/hlc"
+	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
+	"github.com/cockroachdb/errors"
+)
+
+// onConflictModes maps the values of the on_conflict option to the modes they
+// select.
+var onConflictModes = map[string]roachpb.IOFileFormat_OnConflict{
+	"error":     roachpb.IOFileFormat_Error,
+	"skip":      roachpb.IOFileFormat_Skip,
+	"overwrite": roachpb.IOFileFormat_Overwrite,
+}
+
+// checkOnConflictSupported returns an error if IMPORT INTO cannot apply the
+// given on_conflict mode to the table.
+func checkOnConflictSupported(
+	table catalog.TableDescriptor, mode roachpb.IOFileFormat_OnConflict,
+) error {
+	if mode != roachpb.IOFileFormat_Overwrite {
+		return nil
+	}
+	// Replacing a row means deleting its secondary index entries, which are
+	// derived from the values of the existing row. The values of virtual
+	// columns are not stored, so they cannot be read back to do so.
+	for _, idx := range table.DeletableNonPrimaryIndexes() {
+		for i := 0; i < idx.NumKeyColumns(); i++ {
+			col, err := catalog.MustFindColumnByID(table, idx.GetKeyColumnID(i))
+			if err != nil {
+				return err
+			}
+			if col.IsVirtual() {
+				return pgerror.Newf(pgcode.FeatureNotSupported,
+					"%s = 'overwrite' is not supported for tables with indexes on virtual column %q",
+					importOptionOnConflict, col.GetName())
+			}
+		}
+	}
+	return nil
+}
+
+// conflictResolver implements the skip and overwrite modes of the on_conflict
+// option of IMPORT INTO. It rewrites each batch of converted KVs before it is
+// handed to the BulkAdders, based on the rows and unique index entries that
+// were present in the target table when the import started.
+//
+// The KVs of a converted row are contiguous in a batch and start with the
+// primary index KVs of the row, which is what lets the resolver recover row
+// boundaries from the keys alone.
+type conflictResolver struct {
+	mode  roachpb.IOFileFormat_OnConflict
+	db    *kv.DB
+	codec keys.SQLCodec
+	table catalog.TableDescriptor
+	// readTS is the timestamp at which the existing contents of the table are
+	// read. Everything written by the import lands above it.
+	readTS hlc.Timestamp
+	// uniqueIndexes are the unique secondary indexes whose entries are checked
+	// for conflicts.
+	uniqueIndexes map[catid.IndexID]catalog.Index
+
+	// deleter and fetchSpec are used in overwrite mode to read back the rows
+	// being replaced and to remove their secondary index entries.
+	deleter   row.Deleter
+	fetchSpec fetchpb.IndexFetchSpec
+	alloc     tree.DatumAlloc
+
+	mu struct {
+		syncutil.Mutex
+		// pending counts the rows resolved since the KVs were last flushed.
+		pending jobspb.ImportRowSummary
+		// flushed counts the rows whose KVs have been flushed.
+		flushed jobspb.ImportRowSummary
+	}
+}
+
+// importedRow is the span of KVs of a single converted row in a batch.
+type importedRow struct {
+	start, end int
+	// prefix is the prefix shared by the primary index keys of the row.
+	prefix roachpb.Key
+	// familyZeroKey is the key of the first column family of the row, which
+	// exists for every row of the table.
+	familyZeroKey roachpb.Key
+	// uniqueKVs are the offsets, in the batch, of the KVs of the row that
+	// belong to unique secondary indexes.
+	uniqueKVs []int
+}
+
+func newConflictResolver(
+	db *kv.DB,
+	codec keys.SQLCodec,
+	sv *settings.Values,
+	table catalog.TableDescriptor,
+	mode roachpb.IOFileFormat_OnConflict,
+	walltime int64,
+) (*conflictResolver, error) {
+	if err := checkOnConflictSupported(table, mode); err != nil {
+		return nil, err
+	}
+	r := &conflictResolver{
+		mode:          mode,
+		db:            db,
+		codec:         codec,
+		table:         table,
+		readTS:        hlc.Timestamp{WallTime: walltime}.Prev(),
+		uniqueIndexes: make(map[catid.IndexID]catalog.Index),
+	}
+	for _, idx := range table.PublicNonPrimaryIndexes() {
+		if idx.IsUnique() && idx.GetType() == descpb.IndexDescriptor_FORWARD {
+			r.uniqueIndexes[idx.GetID()] = idx
+		}
+	}
+	if mode == roachpb.IOFileFormat_Overwrite {
+		r.deleter = row.MakeDeleter(codec, table, nil /* requestedCols */, sv, true /* internal */, nil /* metrics */)
+		colIDs := make([]descpb.ColumnID, len(r.deleter.FetchCols))
+		for i, col := range r.deleter.FetchCols {
+			colIDs[i] = col.GetID()
+		}
+		if err := rowenc.InitIndexFetchSpec(
+			&r.fetchSpec, codec, table, table.GetPrimaryIndex(), colIDs,
+		); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// resolve returns the KVs to ingest in place of the passed batch of converted
+// rows.
+func (r *conflictResolver) resolve(
+	ctx context.Context, kvs []roachpb.KeyValue,
+) ([]roachpb.KeyValue, error) {
+	rows, err := r.splitRows(kvs)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) == 0 {
+		return kvs, nil
+	}
+
+	var existingRows []roachpb.Span
+	var replaced []roachpb.KeyValue
+	rowExists := make([]bool, len(rows))
+	uniqueExists := make(map[int]bool)
+	if err := r.db.Txn(ctx, func(ctx context.Context, txn *kv.Txn) error {
+		existingRows, replaced = existingRows[:0], replaced[:0]
+		if err := txn.SetFixedTimestamp(ctx, r.readTS); err != nil {
+			return err
+		}
+		b := txn.NewBatch()
+		for i := range rows {
+			b.Get(rows[i].familyZeroKey)
+			for _, j := range rows[i].uniqueKVs {
+				b.Get(kvs[j].Key)
+			}
+		}
+		if err := txn.Run(ctx, b); err != nil {
+			return err
+		}
+		res := b.Results
+		for i := range rows {
+			rowExists[i] = res[0].Rows[0].Exists()
+			res = res[1:]
+			for _, j := range rows[i].uniqueKVs {
+				uniqueExists[j] = res[0].Rows[0].Exists()
+				res = res[1:]
+			}
+			if rowExists[i] {
+				existingRows = append(existingRows,
+					roachpb.Span{Key: rows[i].prefix, EndKey: rows[i].prefix.PrefixEnd()})
+			}
+		}
+		if r.mode != roachpb.IOFileFormat_Overwrite || len(existingRows) == 0 {
+			return nil
+		}
+		replaced, err = r.replaceRows(ctx, txn, existingRows)
+		return err
+	}); err != nil {
+		return nil, errors.Wrap(err, "checking for conflicts with existing rows")
+	}
+
+	var counts jobspb.ImportRowSummary
+	out := make([]roachpb.KeyValue, 0, len(kvs))
+	switch r.mode {
+	case roachpb.IOFileFormat_Skip:
+		for i := range rows {
+			skip := rowExists[i]
+			for _, j := range rows[i].uniqueKVs {
+				skip = skip || uniqueExists[j]
+			}
+			if skip {
+				counts.SkippedRows++
+				continue
+			}
+			out = append(out, kvs[rows[i].start:rows[i].end]...)
+		}
+
+	case roachpb.IOFileFormat_Overwrite:
+		// The KVs of the replaced rows that the new rows do not write again are
+		// deleted, or rewritten for indexes that are being backfilled.
+		written := make(map[string]struct{}, len(kvs))
+		for i := range kvs {
+			written[string(kvs[i].Key)] = struct{}{}
+		}
+		removed := make(map[string]struct{}, len(replaced))
+		for i := range replaced {
+			removed[string(replaced[i].Key)] = struct{}{}
+			if _, ok := written[string(replaced[i].Key)]; !ok {
+				out = append(out, replaced[i])
+			}
+		}
+		for i := range rows {
+			// An existing entry of a unique index that does not belong to a
+			// replaced row belongs to another row of the table, which a new row
+			// cannot take the place of.
+			for _, j := range rows[i].uniqueKVs {
+				if _, ok := removed[string(kvs[j].Key)]; uniqueExists[j] && !ok {
+					_, _, indexID, err := r.codec.DecodeIndexPrefix(kvs[j].Key)
+					if err != nil {
+						return nil, err
+					}
+					return nil, pgerror.Newf(pgcode.UniqueViolation,
+						"duplicate key value violates unique constraint %q",
+						r.uniqueIndexes[catid.IndexID(indexID)].GetName())
+				}
+			}
+			if rowExists[i] {
+				counts.ReplacedRows++
+			}
+		}
+		out = append(out, kvs...)
+
+	default:
+		return nil, errors.AssertionFailedf("unexpected on_conflict mode %s", r.mode)
+	}
+
+	r.mu.Lock()
+	r.mu.pending.Add(counts)
+	r.mu.Unlock()
+	return out, nil
+}
+
+// splitRows returns the rows that the batch of KVs was converted from.
+func (r *conflictResolver) splitRows(kvs []roachpb.KeyValue) ([]importedRow, error) {
+	var rows []importedRow
+	var prefix roachpb.Key
+	for i := range kvs {
+		_, tableID, indexID, err := r.codec.DecodeIndexPrefix(kvs[i].Key)
+		if err != nil {
+			return nil, err
+		}
+		if catid.DescID(tableID) != r.table.GetID() {
+			return nil, errors.AssertionFailedf("unexpected key %s for table %d", kvs[i].Key, r.table.GetID())
+		}
+		if catid.IndexID(indexID) == r.table.GetPrimaryIndexID() {
+			n, err := keys.GetRowPrefixLength(kvs[i].Key)
+			if err != nil {
+				return nil, err
+			}
+			if rows == nil || !bytes.Equal(prefix, kvs[i].Key[:n]) {
+				prefix = kvs[i].Key[:n:n]
+				if len(rows) > 0 {
+					rows[len(rows)-1].end = i
+				}
+				rows = append(rows, importedRow{
+					start:         i,
+					prefix:        prefix,
+					familyZeroKey: keys.MakeFamilyKey(prefix, 0),
+				})
+			}
+		} else if rows == nil {
+			return nil, errors.AssertionFailedf("index key %s precedes the primary key of its row", kvs[i].Key)
+		} else if _, ok := r.uniqueIndexes[catid.IndexID(indexID)]; ok {
+			rows[len(rows)-1].uniqueKVs = append(rows[len(rows)-1].uniqueKVs, i)
+		}
+	}
+	if len(rows) > 0 {
+		rows[len(rows)-1].end = len(kvs)
+	}
+	return rows, nil
+}
+
+// replaceRows reads the existing rows in the passed spans and returns the KVs
+// that remove them, and their secondary index entries, from the table.
+func (r *conflictResolver) replaceRows(
+	ctx context.Context, txn *kv.Txn, spans roachpb.Spans,
+) ([]roachpb.KeyValue, error) {
+	// A batch can hold several rows with the same key, which all replace the
+	// same existing row.
+	sort.Sort(spans)
+	deduped := spans[:0]
+	for i := range spans {
+		if i == 0 || !spans[i].Equal(spans[i-1]) {
+			deduped = append(deduped, spans[i])
+		}
+	}
+	spans = deduped
+
+	var fetcher row.Fetcher
+	if err := fetcher.Init(ctx, row.FetcherInitArgs{
+		Txn:   txn,
+		Alloc: &r.alloc,
+		Spec:  &r.fetchSpec,
+	}); err != nil {
+		return nil, err
+	}
+	defer fetcher.Close(ctx)
+	if err := fetcher.StartScan(
+		ctx, spans, nil /* spanIDs */, rowinfra.NoBytesLimit, rowinfra.NoRowLimit,
+	); err != nil {
+		return nil, err
+	}
+
+	var kvs []roachpb.KeyValue
+	for {
+		datums, err := fetcher.NextRowDecoded(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if datums == nil {
+			return kvs, nil
+		}
+		var b kv.Batch
+		if err := r.deleter.DeleteRow(
+			ctx, &b, datums, row.PartialIndexUpdateHelper{}, nil /* oth */, false, /* traceKV */
+		); err != nil {
+			return nil, err
+		}
+		for _, req := range b.Requests() {
+			switch t := req.GetInner().(type) {
+			case *kvpb.DeleteRequest:
+				kvs = append(kvs, roachpb.KeyValue{Key: t.Key})
+			case *kvpb.PutRequest:
+				kvs = append(kvs, roachpb.KeyValue{Key: t.Key, Value: t.Value})
+			default:
+				return nil, errors.AssertionFailedf("unexpected request %s deleting a replaced row", t.Method())
+			}
+		}
+	}
+}
+
+// onFlush records that the KVs of every row resolved so far have been flushed.
+func (r *conflictResolver) onFlush() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.mu.flushed.Add(r.mu.pending)
+	r.mu.pending = jobspb.ImportRowSummary{}
+}
+
+// summary returns the counts of the rows whose KVs have been flushed.
+func (r *conflictResolver) summary() jobspb.ImportRowSummary {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.mu.flushed
+}
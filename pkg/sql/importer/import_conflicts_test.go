@@ -0,0 +1,104 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package importer_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// TestImportIntoOnConflict tests that IMPORT INTO skips or replaces rows whose
+// primary or unique keys are already present in the table, and reports how
+// many it skipped or replaced.
+func TestImportIntoOnConflict(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	dir, cleanup := testutils.TempDir(t)
+	defer cleanup()
+
+	// Row 1 conflicts on the primary key, row 4 conflicts on the unique index
+	// with existing row 3, and row 5 is new.
+	const data = "1,a,10\n4,c,40\n5,e,50\n"
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "data.csv"), []byte(data), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "replace.csv"), []byte("1,x,11\n2,y,22\n"), 0644))
+
+	srv, db, _ := serverutils.StartServer(t, base.TestServerArgs{ExternalIODir: dir})
+	defer srv.Stopper().Stop(ctx)
+	sqlDB := sqlutils.MakeSQLRunner(db)
+
+	setup := func(name string) {
+		sqlDB.Exec(t, `CREATE TABLE `+name+` (
+			id INT PRIMARY KEY, name STRING UNIQUE, v INT, INDEX (v), FAMILY (id, name), FAMILY (v)
+		)`)
+		sqlDB.Exec(t, `INSERT INTO `+name+` VALUES (1, 'a', 1), (2, 'b', 2), (3, 'c', 3)`)
+	}
+
+	t.Run("error", func(t *testing.T) {
+		setup("t_error")
+		sqlDB.ExpectErr(t, "ingested key collides with an existing one",
+			`IMPORT INTO t_error CSV DATA ('nodelocal://1/data.csv')`)
+		sqlDB.ExpectErr(t, "ingested key collides with an existing one",
+			`IMPORT INTO t_error CSV DATA ('nodelocal://1/data.csv') WITH on_conflict = 'error'`)
+	})
+
+	t.Run("skip", func(t *testing.T) {
+		setup("t_skip")
+		var skipped, replaced int
+		sqlDB.QueryRow(t,
+			`SELECT skipped_rows, replaced_rows FROM [IMPORT INTO t_skip CSV DATA ('nodelocal://1/data.csv') WITH on_conflict = 'skip']`,
+		).Scan(&skipped, &replaced)
+		require.Equal(t, 2, skipped)
+		require.Equal(t, 0, replaced)
+		sqlDB.CheckQueryResults(t, `SELECT id, name, v FROM t_skip ORDER BY id`, [][]string{
+			{"1", "a", "1"}, {"2", "b", "2"}, {"3", "c", "3"}, {"5", "e", "50"},
+		})
+	})
+
+	t.Run("overwrite", func(t *testing.T) {
+		setup("t_overwrite")
+		var skipped, replaced int
+		sqlDB.QueryRow(t,
+			`SELECT skipped_rows, replaced_rows FROM [IMPORT INTO t_overwrite CSV DATA ('nodelocal://1/replace.csv') WITH on_conflict = 'overwrite']`,
+		).Scan(&skipped, &replaced)
+		require.Equal(t, 0, skipped)
+		require.Equal(t, 2, replaced)
+		sqlDB.CheckQueryResults(t, `SELECT id, name, v FROM t_overwrite ORDER BY id`, [][]string{
+			{"1", "x", "11"}, {"2", "y", "22"}, {"3", "c", "3"},
+		})
+		// The secondary index entries of the replaced rows are gone.
+		sqlDB.CheckQueryResults(t, `SELECT id FROM t_overwrite@t_overwrite_v_idx WHERE v < 10 ORDER BY v`,
+			[][]string{{"3"}})
+		sqlDB.CheckQueryResults(t, `SELECT count(*) FROM t_overwrite@t_overwrite_name_key WHERE name IN ('a', 'b')`,
+			[][]string{{"0"}})
+
+		// A row cannot take the unique key of another existing row.
+		sqlDB.ExpectErr(t, `duplicate key value violates unique constraint "t_overwrite_name_key"`,
+			`IMPORT INTO t_overwrite CSV DATA ('nodelocal://1/data.csv') WITH on_conflict = 'overwrite'`)
+		sqlDB.CheckQueryResults(t, `SELECT id, name, v FROM t_overwrite ORDER BY id`, [][]string{
+			{"1", "x", "11"}, {"2", "y", "22"}, {"3", "c", "3"},
+		})
+	})
+
+	t.Run("errors", func(t *testing.T) {
+		sqlDB.ExpectErr(t, `invalid on_conflict value "ignore"`,
+			`IMPORT INTO t_skip CSV DATA ('nodelocal://1/data.csv') WITH on_conflict = 'ignore'`)
+		sqlDB.Exec(t, `CREATE TABLE t_virtual (id INT PRIMARY KEY, v INT, w INT AS (v + 1) VIRTUAL, INDEX (w))`)
+		sqlDB.ExpectErr(t, `on_conflict = 'overwrite' is not supported for tables with indexes on virtual column "w"`,
+			`IMPORT INTO t_virtual (id, v) CSV DATA ('nodelocal://1/replace.csv') WITH on_conflict = 'overwrite'`)
+	})
+}
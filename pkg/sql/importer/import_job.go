@@ -18,6 +18,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/jobs/ingeststopped"
 	"github.com/cockroachdb/cockroach/pkg/jobs/joberror"
 	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/protectedts"
@@ -41,6 +42,8 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/gcjob"
 	"github.com/cockroachdb/cockroach/pkg/sql/isql"
 	"github.com/cockroachdb/cockroach/pkg/sql/opt/memo"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/catconstants"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/catid"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
@@ -49,6 +52,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/stats"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/ioctx"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
 	"github.com/cockroachdb/cockroach/pkg/util/log/eventpb"
 	"github.com/cockroachdb/cockroach/pkg/util/log/logutil"
@@ -72,6 +76,7 @@ type importResumer struct {
 	job      *jobs.Job
 	settings *cluster.Settings
 	res      roachpb.RowCount
+	rowRes   jobspb.ImportRowSummary
 
 	testingKnobs importTestingKnobs
 }
@@ -307,11 +312,19 @@ func (r *importResumer) Resume(ctx context.Context, execCtx interface{}) error {
 
 	procsPerNode := int(processorsPerNode.Get(&p.ExecCfg().Settings.SV))
 
-	res, err := ingestWithRetry(ctx, p, r.job, tables, typeDescs, files, format, details.Walltime,
+	res, rowRes, err := ingestWithRetry(ctx, p, r.job, tables, typeDescs, files, format, details.Walltime,
 		r.testingKnobs, procsPerNode)
 	if err != nil {
 		return err
 	}
+	// Each processor stops once it rejects more than max_errors rows, but the
+	// rows rejected by all of them together may still exceed it.
+	if format.SaveRejected && rowRes.RejectedRows > maxRejectedRows(format) {
+		return pgerror.Newf(pgcode.DataCorrupted,
+			"too many rejected rows (%d) encountered, max_errors is %d",
+			rowRes.RejectedRows, maxRejectedRows(format))
+	}
+	r.rowRes = rowRes
 
 	pkIDs := make(map[uint64]struct{}, len(details.Tables))
 	for _, t := range details.Tables {
@@ -1287,7 +1300,7 @@ func ingestWithRetry(
 	walltime int64,
 	testingKnobs importTestingKnobs,
 	procsPerNode int,
-) (kvpb.BulkOpSummary, error) {
+) (kvpb.BulkOpSummary, jobspb.ImportRowSummary, error) {
 	ctx, sp := tracing.ChildSpan(ctx, "importer.ingestWithRetry")
 	defer sp.Finish()
 
@@ -1302,12 +1315,13 @@ func ingestWithRetry(
 	// nodes dying), so if we receive a retryable error, re-plan and retry the
 	// import.
 	var res kvpb.BulkOpSummary
+	var rowRes jobspb.ImportRowSummary
 	var err error
 	// State to decide when to exit the retry loop.
 	lastProgressChange, lastProgress := timeutil.Now(), getFractionCompleted(job)
 	for r := retry.StartWithCtx(ctx, retryOpts); r.Next(); {
 		for {
-			res, err = distImport(
+			res, rowRes, err = distImport(
 				ctx, execCtx, job, tables, typeDescs, from, format, walltime, testingKnobs, procsPerNode,
 			)
 			// If we got a re-planning error, then do at least one more attempt
@@ -1321,18 +1335,18 @@ func ingestWithRetry(
 		}
 
 		if errors.HasType(err, &kvpb.InsufficientSpaceError{}) {
-			return res, jobs.MarkPauseRequestError(errors.UnwrapAll(err))
+			return res, rowRes, jobs.MarkPauseRequestError(errors.UnwrapAll(err))
 		}
 
 		if joberror.IsPermanentBulkJobError(err) {
-			return res, err
+			return res, rowRes, err
 		}
 
 		// If we are draining, it is unlikely we can start a new DistSQL
 		// flow. Exit with a retryable error so that another node can
 		// pick up the job.
 		if execCtx.ExecCfg().JobRegistry.IsDraining() {
-			return res, jobs.MarkAsRetryJobError(errors.Wrapf(err, "job encountered retryable error on draining node"))
+			return res, rowRes, jobs.MarkAsRetryJobError(errors.Wrapf(err, "job encountered retryable error on draining node"))
 		}
 
 		// Re-load the job in order to update our progress object, which
@@ -1340,7 +1354,7 @@ func ingestWithRetry(
 		reloadedJob, reloadErr := execCtx.ExecCfg().JobRegistry.LoadClaimedJob(ctx, job.ID())
 		if reloadErr != nil {
 			if ctx.Err() != nil {
-				return res, ctx.Err()
+				return res, rowRes, ctx.Err()
 			}
 			log.Warningf(ctx, "IMPORT job %d could not reload job progress when retrying: %+v",
 				job.ID(), reloadErr)
@@ -1373,9 +1387,9 @@ func ingestWithRetry(
 	// Let's pause the job instead of failing it so that the user can decide
 	// whether to resume it or cancel it.
 	if err != nil {
-		return res, jobs.MarkPauseRequestError(errors.Wrap(err, "exhausted retries"))
+		return res, rowRes, jobs.MarkPauseRequestError(errors.Wrap(err, "exhausted retries"))
 	}
-	return res, nil
+	return res, rowRes, nil
 }
 
 // emitImportJobEvent emits an import job event to the event log.
@@ -1536,6 +1550,32 @@ func (r *importResumer) OnFailOrCancel(
 	return nil
 }
 
+// revertTableToTime reverts the data of the table to its state as of the target
+// time.
+func revertTableToTime(
+	ctx context.Context, db *kv.DB, codec keys.SQLCodec, tableID descpb.ID, targetTime hlc.Timestamp,
+) error {
+	tableKey := codec.TablePrefix(uint32(tableID))
+	span := &roachpb.Span{Key: tableKey, EndKey: tableKey.PrefixEnd()}
+	for span != nil {
+		var b kv.Batch
+		b.AddRawRequest(&kvpb.RevertRangeRequest{
+			RequestHeader: kvpb.RequestHeader{Key: span.Key, EndKey: span.EndKey},
+			TargetTime:    targetTime,
+		})
+		b.Header.MaxSpanRequestKeys = sql.RevertTableDefaultBatchSize
+		log.VEventf(ctx, 2, "reverting %s to %s", span, targetTime)
+		if err := db.Run(ctx, &b); err != nil {
+			return err
+		}
+		span = b.RawResponse().Responses[0].GetRevertRange().ResumeSpan
+		if span != nil && !span.Valid() {
+			return errors.Errorf("invalid resume span: %s", span)
+		}
+	}
+	return nil
+}
+
 // CollectProfile is a part of the Resumer interface.
 func (r *importResumer) CollectProfile(_ context.Context, _ interface{}) error {
 	return nil
@@ -1590,15 +1630,20 @@ func (r *importResumer) dropTables(
 		// it was rolled back to its pre-IMPORT state, and instead provide a manual
 		// admin knob (e.g. ALTER TABLE REVERT TO SYSTEM TIME) if anything goes wrong.
 		ts := hlc.Timestamp{WallTime: details.Walltime}.Prev()
-		predicates := kvpb.DeleteRangePredicates{StartTime: ts}
-		if err := sql.DeleteTableWithPredicate(
+		if details.Format.OnConflict == roachpb.IOFileFormat_Overwrite {
+			// Rows replaced by the IMPORT must be brought back rather than deleted,
+			// so the table is reverted to its state before the IMPORT instead.
+			if err := revertTableToTime(ctx, execCfg.DB, execCfg.Codec, intoTable.GetID(), ts); err != nil {
+				return errors.Wrap(err, "rolling back IMPORT INTO in non empty table via RevertRange")
+			}
+		} else if err := sql.DeleteTableWithPredicate(
 			ctx,
 			execCfg.DB,
 			execCfg.Codec,
 			&execCfg.Settings.SV,
 			execCfg.DistSender,
 			intoTable.GetID(),
-			predicates, sql.RevertTableDefaultBatchSize); err != nil {
+			kvpb.DeleteRangePredicates{StartTime: ts}, sql.RevertTableDefaultBatchSize); err != nil {
 			return errors.Wrap(err, "rolling back IMPORT INTO in non empty table via DeleteRange")
 		}
 	} else if tableWasEmpty {
@@ -1804,15 +1849,33 @@ func (r *importResumer) releaseProtectedTimestamp(
 
 // ReportResults implements JobResultsReporter interface.
 func (r *importResumer) ReportResults(ctx context.Context, resultsCh chan<- tree.Datums) error {
-	select {
-	case resultsCh <- tree.Datums{
+	res := tree.Datums{
 		tree.NewDInt(tree.DInt(r.job.ID())),
 		tree.NewDString(string(jobs.StatusSucceeded)),
 		tree.NewDFloat(tree.DFloat(1.0)),
 		tree.NewDInt(tree.DInt(r.res.Rows)),
 		tree.NewDInt(tree.DInt(r.res.IndexEntries)),
 		tree.NewDInt(tree.DInt(r.res.DataSize)),
-	}:
+	}
+	details := r.job.Details().(jobspb.ImportDetails)
+	if details.Format.OnConflict != roachpb.IOFileFormat_Error {
+		res = append(res,
+			tree.NewDInt(tree.DInt(r.rowRes.SkippedRows)),
+			tree.NewDInt(tree.DInt(r.rowRes.ReplacedRows)),
+		)
+	}
+	if details.Format.SaveRejected {
+		b := json.NewObjectBuilder(len(r.rowRes.RejectedByErrorClass))
+		for class, n := range r.rowRes.RejectedByErrorClass {
+			b.Add(class, json.FromInt64(n))
+		}
+		res = append(res,
+			tree.NewDInt(tree.DInt(r.rowRes.RejectedRows)),
+			tree.NewDJSON(b.Build()),
+		)
+	}
+	select {
+	case resultsCh <- res:
 		return nil
 	case <-ctx.Done():
 		return ctx.Err()
@@ -45,6 +45,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/privilege"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/catconstants"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/errorutil/unimplemented"
 	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
@@ -74,8 +75,14 @@ const (
 	importOptionOversample       = "oversample"
 	importOptionSkipFKs          = "skip_foreign_keys"
 	importOptionDisableGlobMatch = "disable_glob_matching"
-	importOptionSaveRejected     = "experimental_save_rejected"
+	importOptionSaveRejected     = "save_rejected"
+	importOptionMaxErrors        = "max_errors"
 	importOptionDetached         = "detached"
+	importOptionOnConflict       = "on_conflict"
+
+	// importOptionExperimentalSaveRejected is the name save_rejected had before
+	// it applied to every format.
+	importOptionExperimentalSaveRejected = "experimental_save_rejected"
 
 	pgCopyDelimiter = "delimiter"
 	pgCopyNull      = "nullif"
@@ -119,14 +126,17 @@ var importOptionExpectValues = map[string]exprutil.KVStringOptValidate{
 	mysqlOutfileEnclose:  exprutil.KVStringOptRequireValue,
 	mysqlOutfileEscape:   exprutil.KVStringOptRequireValue,
 
-	importOptionSSTSize:      exprutil.KVStringOptRequireValue,
-	importOptionDecompress:   exprutil.KVStringOptRequireValue,
-	importOptionOversample:   exprutil.KVStringOptRequireValue,
-	importOptionSaveRejected: exprutil.KVStringOptRequireNoValue,
+	importOptionSSTSize:                  exprutil.KVStringOptRequireValue,
+	importOptionDecompress:               exprutil.KVStringOptRequireValue,
+	importOptionOversample:               exprutil.KVStringOptRequireValue,
+	importOptionSaveRejected:             exprutil.KVStringOptAny,
+	importOptionExperimentalSaveRejected: exprutil.KVStringOptRequireNoValue,
+	importOptionMaxErrors:                exprutil.KVStringOptRequireValue,
 
 	importOptionSkipFKs:          exprutil.KVStringOptRequireNoValue,
 	importOptionDisableGlobMatch: exprutil.KVStringOptRequireNoValue,
 	importOptionDetached:         exprutil.KVStringOptRequireNoValue,
+	importOptionOnConflict:       exprutil.KVStringOptRequireValue,
 
 	optMaxRowSize: exprutil.KVStringOptRequireValue,
 
@@ -162,7 +172,8 @@ func makeStringSet(opts ...string) map[string]struct{} {
 // Options common to all formats.
 var allowedCommonOptions = makeStringSet(
 	importOptionSSTSize, importOptionDecompress, importOptionOversample,
-	importOptionSaveRejected, importOptionDisableGlobMatch, importOptionDetached)
+	importOptionSaveRejected, importOptionExperimentalSaveRejected, importOptionMaxErrors,
+	importOptionDisableGlobMatch, importOptionDetached, importOptionOnConflict)
 
 // Format specific allowed options.
 var avroAllowedOptions = makeStringSet(
@@ -170,6 +181,11 @@ var avroAllowedOptions = makeStringSet(
 	avroRecordsSeparatedBy, avroSchema, avroSchemaURI, optMaxRowSize, csvRowLimit,
 )
 
+var (
+	parquetAllowedOptions = makeStringSet(avroStrict, csvRowLimit)
+	ndjsonAllowedOptions  = makeStringSet(avroStrict, optMaxRowSize, csvRowLimit)
+)
+
 var csvAllowedOptions = makeStringSet(
 	csvDelimiter, csvComment, csvNullIf, csvSkip, csvStrictQuotes, csvRowLimit, csvAllowQuotedNulls,
 )
@@ -186,6 +202,30 @@ var (
 		pgDumpIgnoreAllUnsupported, pgDumpIgnoreShuntFileDest)
 )
 
+// importResultHeader returns the result header of an IMPORT. An IMPORT that
+// sets on_conflict also reports the rows that were skipped or replaced, and
+// one that saves rejected rows reports how many rows were rejected, by the
+// class of error that rejected them.
+func importResultHeader(onConflict, saveRejected bool) colinfo.ResultColumns {
+	if !onConflict && !saveRejected {
+		return jobs.BulkJobExecutionResultHeader
+	}
+	header := append(colinfo.ResultColumns(nil), jobs.BulkJobExecutionResultHeader...)
+	if onConflict {
+		header = append(header,
+			colinfo.ResultColumn{Name: "skipped_rows", Typ: types.Int},
+			colinfo.ResultColumn{Name: "replaced_rows", Typ: types.Int},
+		)
+	}
+	if saveRejected {
+		header = append(header,
+			colinfo.ResultColumn{Name: "rejected_rows", Typ: types.Int},
+			colinfo.ResultColumn{Name: "rejected_by_error_class", Typ: types.Jsonb},
+		)
+	}
+	return header
+}
+
 // DROP is required because the target table needs to be take offline during
 // IMPORT INTO.
 var importIntoRequiredPrivileges = []privilege.Kind{privilege.INSERT, privilege.DROP}
@@ -196,6 +236,8 @@ var allowedIntoFormats = map[string]struct{}{
 	"AVRO":      {},
 	"DELIMITED": {},
 	"PGCOPY":    {},
+	"PARQUET":   {},
+	"NDJSON":    {},
 }
 
 // featureImportEnabled is used to enable and disable the IMPORT feature.
@@ -342,7 +384,11 @@ func importTypeCheck(
 	); err != nil {
 		return false, nil, err
 	}
-	header = jobs.BulkJobExecutionResultHeader
+	header = importResultHeader(
+		importStmt.Options.HasKey(importOptionOnConflict),
+		importStmt.Options.HasKey(importOptionSaveRejected) ||
+			importStmt.Options.HasKey(importOptionExperimentalSaveRejected),
+	)
 	if importStmt.Options.HasKey(importOptionDetached) {
 		header = jobs.DetachedJobExecutionResultHeader
 	}
@@ -574,9 +620,6 @@ func importPlanHook(
 			if _, ok := opts[csvStrictQuotes]; ok {
 				format.Csv.StrictQuotes = true
 			}
-			if _, ok := opts[importOptionSaveRejected]; ok {
-				format.SaveRejected = true
-			}
 			if override, ok := opts[csvRowLimit]; ok {
 				rowLimit, err := strconv.Atoi(override)
 				if err != nil {
@@ -643,9 +686,6 @@ func importPlanHook(
 			if override, ok := opts[csvNullIf]; ok {
 				format.MysqlOut.NullEncoding = &override
 			}
-			if _, ok := opts[importOptionSaveRejected]; ok {
-				format.SaveRejected = true
-			}
 			if override, ok := opts[csvRowLimit]; ok {
 				rowLimit, err := strconv.Atoi(override)
 				if err != nil {
@@ -748,6 +788,50 @@ func importPlanHook(
 			if err != nil {
 				return err
 			}
+		case "PARQUET":
+			if err = validateFormatOptions(importStmt.FileFormat, opts, parquetAllowedOptions); err != nil {
+				return err
+			}
+			format.Format = roachpb.IOFileFormat_Parquet
+			_, format.Parquet.StrictMode = opts[avroStrict]
+			if override, ok := opts[csvRowLimit]; ok {
+				rowLimit, err := strconv.Atoi(override)
+				if err != nil {
+					return pgerror.Wrapf(err, pgcode.Syntax, "invalid numeric %s value", csvRowLimit)
+				}
+				if rowLimit <= 0 {
+					return pgerror.Newf(pgcode.Syntax, "%s must be > 0", csvRowLimit)
+				}
+				format.Parquet.RowLimit = int64(rowLimit)
+			}
+		case "NDJSON":
+			if err = validateFormatOptions(importStmt.FileFormat, opts, ndjsonAllowedOptions); err != nil {
+				return err
+			}
+			format.Format = roachpb.IOFileFormat_NDJSON
+			_, format.NDJSON.StrictMode = opts[avroStrict]
+			maxRowSize := int32(defaultScanBuffer)
+			if override, ok := opts[optMaxRowSize]; ok {
+				sz, err := humanizeutil.ParseBytes(override)
+				if err != nil {
+					return err
+				}
+				if sz < 1 || sz > math.MaxInt32 {
+					return errors.Errorf("%s out of range: %d", override, sz)
+				}
+				maxRowSize = int32(sz)
+			}
+			format.NDJSON.MaxRowSize = maxRowSize
+			if override, ok := opts[csvRowLimit]; ok {
+				rowLimit, err := strconv.Atoi(override)
+				if err != nil {
+					return pgerror.Wrapf(err, pgcode.Syntax, "invalid numeric %s value", csvRowLimit)
+				}
+				if rowLimit <= 0 {
+					return pgerror.Newf(pgcode.Syntax, "%s must be > 0", csvRowLimit)
+				}
+				format.NDJSON.RowLimit = int64(rowLimit)
+			}
 		default:
 			return unimplemented.Newf("import.format", "unsupported import format: %q", importStmt.FileFormat)
 		}
@@ -777,6 +861,23 @@ func importPlanHook(
 			skipFKs = true
 		}
 
+		if override, ok := opts[importOptionOnConflict]; ok {
+			mode, found := onConflictModes[strings.ToLower(override)]
+			if !found {
+				return pgerror.Newf(pgcode.InvalidParameterValue,
+					"invalid %s value %q, expected one of 'error', 'skip' or 'overwrite'", importOptionOnConflict, override)
+			}
+			if !importStmt.Into {
+				return pgerror.Newf(pgcode.FeatureNotSupported,
+					"%s is only supported by IMPORT INTO", importOptionOnConflict)
+			}
+			format.OnConflict = mode
+		}
+
+		if err := parseSaveRejectedOptions(ctx, p, opts, &format); err != nil {
+			return err
+		}
+
 		if override, ok := opts[importOptionDecompress]; ok {
 			found := false
 			for name, value := range roachpb.IOFileFormat_Compression_value {
@@ -789,6 +890,13 @@ func importPlanHook(
 			if !found {
 				return unimplemented.Newf("import.compression", "unsupported compression value: %q", override)
 			}
+			// Parquet files compress their columns themselves, and are read in
+			// place rather than as a stream.
+			if format.Format == roachpb.IOFileFormat_Parquet &&
+				format.Compression != roachpb.IOFileFormat_Auto && format.Compression != roachpb.IOFileFormat_None {
+				return errors.Newf("%s option is not supported for %s import format",
+					importOptionDecompress, importStmt.FileFormat)
+			}
 		}
 
 		var tableDetails []jobspb.ImportDetails_Table
@@ -814,6 +922,10 @@ func importPlanHook(
 				return err
 			}
 
+			if err := checkOnConflictSupported(found, format.OnConflict); err != nil {
+				return err
+			}
+
 			// Validate target columns.
 			var intoCols []string
 			isTargetCol := make(map[string]bool)
@@ -1028,7 +1140,54 @@ func importPlanHook(
 	if isDetached {
 		return fn, jobs.DetachedJobExecutionResultHeader, nil, false, nil
 	}
-	return fn, jobs.BulkJobExecutionResultHeader, nil, false, nil
+	_, onConflict := opts[importOptionOnConflict]
+	_, saveRejected := opts[importOptionSaveRejected]
+	if _, ok := opts[importOptionExperimentalSaveRejected]; ok {
+		saveRejected = true
+	}
+	return fn, importResultHeader(onConflict, saveRejected), nil, false, nil
+}
+
+// parseSaveRejectedOptions sets up format so that the input rows that cannot
+// be imported are saved to reject files instead of failing the import.
+func parseSaveRejectedOptions(
+	ctx context.Context, p sql.PlanHookState, opts map[string]string, format *roachpb.IOFileFormat,
+) error {
+	location, saveRejected := opts[importOptionSaveRejected]
+	if _, ok := opts[importOptionExperimentalSaveRejected]; ok {
+		saveRejected = true
+	}
+	override, hasMaxErrors := opts[importOptionMaxErrors]
+	if !saveRejected {
+		if hasMaxErrors {
+			return pgerror.Newf(pgcode.InvalidParameterValue,
+				"%s requires the %s option", importOptionMaxErrors, importOptionSaveRejected)
+		}
+		return nil
+	}
+	format.SaveRejected = true
+
+	if hasMaxErrors {
+		maxErrors, err := strconv.ParseInt(override, 10, 64)
+		if err != nil {
+			return pgerror.Wrapf(err, pgcode.Syntax, "invalid numeric %s value", importOptionMaxErrors)
+		}
+		if maxErrors <= 0 {
+			return pgerror.Newf(pgcode.Syntax, "%s must be > 0", importOptionMaxErrors)
+		}
+		format.MaxErrors = maxErrors
+	}
+
+	if location != "" {
+		if _, err := cloud.ExternalStorageConfFromURI(location, p.User()); err != nil {
+			return err
+		}
+		if err := cloudprivilege.CheckDestinationPrivileges(ctx, p, []string{location}); err != nil {
+			return err
+		}
+		format.RejectedLocation = location
+	}
+	return nil
 }
 
 func parseAvroOptions(
@@ -13,6 +13,7 @@ import (
 	"sync/atomic"
 	"time"
 
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvserver/kvserverbase"
@@ -31,7 +32,6 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
-	"github.com/cockroachdb/cockroach/pkg/util/errorutil/unimplemented"
 	"github.com/cockroachdb/cockroach/pkg/util/hlc"
 	"github.com/cockroachdb/cockroach/pkg/util/protoutil"
 	"github.com/cockroachdb/cockroach/pkg/util/syncutil"
@@ -125,8 +125,9 @@ type readImportDataProcessor struct {
 
 	seqChunkProvider *row.SeqChunkProvider
 
-	importErr error
-	summary   *kvpb.BulkOpSummary
+	importErr  error
+	summary    *kvpb.BulkOpSummary
+	rowSummary jobspb.ImportRowSummary
 }
 
 var (
@@ -181,7 +182,7 @@ func (idp *readImportDataProcessor) Start(ctx context.Context) {
 	idp.wg = ctxgroup.WithContext(grpCtx)
 	idp.wg.GoCtx(func(ctx context.Context) error {
 		defer close(idp.progCh)
-		idp.summary, idp.importErr = runImport(ctx, idp.FlowCtx, &idp.spec, idp.progCh,
+		idp.summary, idp.rowSummary, idp.importErr = runImport(ctx, idp.FlowCtx, &idp.spec, idp.progCh,
 			idp.seqChunkProvider)
 		return nil
 	})
@@ -210,8 +211,15 @@ func (idp *readImportDataProcessor) Next() (rowenc.EncDatumRow, *execinfrapb.Pro
 	}
 
 	// Once the import is done, send back to the controller the serialized
-	// summary of the import operation. For more info see kvpb.BulkOpSummary.
+	// summary of the import operation, and of the input rows that were not
+	// ingested as they were read. For more info see kvpb.BulkOpSummary and
+	// jobspb.ImportRowSummary.
 	countsBytes, err := protoutil.Marshal(idp.summary)
+	if err != nil {
+		idp.MoveToDraining(err)
+		return nil, idp.DrainHelper()
+	}
+	rowCountsBytes, err := protoutil.Marshal(&idp.rowSummary)
 	idp.MoveToDraining(err)
 	if err != nil {
 		return nil, idp.DrainHelper()
@@ -219,7 +227,7 @@ func (idp *readImportDataProcessor) Next() (rowenc.EncDatumRow, *execinfrapb.Pro
 
 	return rowenc.EncDatumRow{
 		rowenc.DatumToEncDatum(types.Bytes, tree.NewDBytes(tree.DBytes(countsBytes))),
-		rowenc.DatumToEncDatum(types.Bytes, tree.NewDBytes(tree.DBytes([]byte{}))),
+		rowenc.DatumToEncDatum(types.Bytes, tree.NewDBytes(tree.DBytes(rowCountsBytes))),
 	}, nil
 }
 
@@ -277,19 +285,11 @@ func makeInputConverter(
 
 	if singleTable != nil {
 		// If we're using a format like CSV where data columns are not "named", and
-		// therefore cannot be mapped to schema columns, then require the user to
-		// use IMPORT INTO.
-		//
-		// We could potentially do something smarter here and check that only a
-		// suffix of the columns are computed, and then expect the data file to have
-		// #(visible columns) - #(computed columns).
+		// therefore cannot be mapped to schema columns, then expect the data file
+		// to have a value for every visible column that is not computed, in table
+		// order. The row converter evaluates the computed columns from those.
 		if len(singleTableTargetCols) == 0 && !formatHasNamedColumns(spec.Format.Format) {
-			for _, col := range singleTable.VisibleColumns() {
-				if col.IsComputed() {
-					return nil, unimplemented.NewWithIssueDetail(56002, "import.computed",
-						"to use computed columns, use IMPORT INTO")
-				}
-			}
+			singleTableTargetCols = nonComputedVisibleColumnNames(singleTable)
 		}
 	}
 
@@ -333,6 +333,12 @@ func makeInputConverter(
 		return newAvroInputReader(
 			semaCtx, kvCh, singleTable, spec.Format.Avro, spec.WalltimeNanos,
 			readerParallelism, evalCtx, db)
+	case roachpb.IOFileFormat_Parquet:
+		return newParquetInputReader(semaCtx, spec.Format.Parquet, kvCh, spec.WalltimeNanos,
+			readerParallelism, singleTable, singleTableTargetCols, evalCtx, db)
+	case roachpb.IOFileFormat_NDJSON:
+		return newNDJSONInputReader(semaCtx, spec.Format.NDJSON, kvCh, spec.WalltimeNanos,
+			readerParallelism, singleTable, singleTableTargetCols, evalCtx, db)
 	default:
 		return nil, errors.Errorf(
 			"Requested IMPORT format (%d) not supported by this node", spec.Format.Format)
@@ -352,7 +358,7 @@ func ingestKvs(
 	spec *execinfrapb.ReadImportDataSpec,
 	progCh chan execinfrapb.RemoteProducerMetadata_BulkProcessorProgress,
 	kvCh <-chan row.KVBatch,
-) (*kvpb.BulkOpSummary, error) {
+) (*kvpb.BulkOpSummary, jobspb.ImportRowSummary, error) {
 	ctx, span := tracing.ChildSpan(ctx, "import-ingest-kvs")
 	defer span.Finish()
 
@@ -390,13 +396,36 @@ func ingestKvs(
 		if bulkAdderImportEpoch == 0 {
 			bulkAdderImportEpoch = v.Desc.ImportEpoch
 		} else if bulkAdderImportEpoch != v.Desc.ImportEpoch {
-			return nil, errors.AssertionFailedf("inconsistent import epoch on multi-table import")
+			return nil, jobspb.ImportRowSummary{}, errors.AssertionFailedf("inconsistent import epoch on multi-table import")
+		}
+	}
+
+	// If rows that conflict with existing ones are to be skipped or overwritten,
+	// the resolver rewrites the converted KVs before they are ingested. Rows that
+	// are overwritten shadow existing keys, so AddSSTable must allow it.
+	var resolver *conflictResolver
+	disallowShadowingBelow := writeTS
+	if mode := spec.Format.OnConflict; mode != roachpb.IOFileFormat_Error {
+		if len(spec.Tables) != 1 {
+			return nil, jobspb.ImportRowSummary{}, errors.AssertionFailedf(
+				"on_conflict is only supported when importing into a single table")
+		}
+		for _, t := range spec.Tables {
+			var err error
+			resolver, err = newConflictResolver(flowCtx.Cfg.DB.KV(), flowCtx.Codec(), &flowCtx.Cfg.Settings.SV,
+				tabledesc.NewBuilder(t.Desc).BuildImmutableTable(), mode, spec.WalltimeNanos)
+			if err != nil {
+				return nil, jobspb.ImportRowSummary{}, err
+			}
+		}
+		if mode == roachpb.IOFileFormat_Overwrite {
+			disallowShadowingBelow = hlc.Timestamp{}
 		}
 	}
 
 	pkIndexAdder, err := flowCtx.Cfg.BulkAdder(ctx, flowCtx.Cfg.DB.KV(), writeTS, kvserverbase.BulkAdderOptions{
 		Name:                     pkAdderName,
-		DisallowShadowingBelow:   writeTS,
+		DisallowShadowingBelow:   disallowShadowingBelow,
 		SkipDuplicates:           true,
 		MinBufferSize:            minBufferSize,
 		MaxBufferSize:            maxBufferSize,
@@ -405,7 +434,7 @@ func ingestKvs(
 		ImportEpoch:              bulkAdderImportEpoch,
 	})
 	if err != nil {
-		return nil, err
+		return nil, jobspb.ImportRowSummary{}, err
 	}
 	defer pkIndexAdder.Close(ctx)
 
@@ -413,7 +442,7 @@ func ingestKvs(
 		false /* isPKAdder */)
 	indexAdder, err := flowCtx.Cfg.BulkAdder(ctx, flowCtx.Cfg.DB.KV(), writeTS, kvserverbase.BulkAdderOptions{
 		Name:                     indexAdderName,
-		DisallowShadowingBelow:   writeTS,
+		DisallowShadowingBelow:   disallowShadowingBelow,
 		SkipDuplicates:           true,
 		MinBufferSize:            minBufferSize,
 		MaxBufferSize:            maxBufferSize,
@@ -422,7 +451,7 @@ func ingestKvs(
 		ImportEpoch:              bulkAdderImportEpoch,
 	})
 	if err != nil {
-		return nil, err
+		return nil, jobspb.ImportRowSummary{}, err
 	}
 	defer indexAdder.Close(ctx)
 
@@ -460,6 +489,9 @@ func ingestKvs(
 				atomic.StoreInt64(&idxFlushedRow[i], emitted)
 			}
 		}
+		if resolver != nil {
+			resolver.onFlush()
+		}
 	})
 	indexAdder.SetOnFlush(func(summary kvpb.BulkOpSummary) {
 		for i, emitted := range writtenRow {
@@ -549,7 +581,14 @@ func ingestKvs(
 		// results in flushing a much larger number of small SSTs. This increases the
 		// number of L0 (and total) files, but with a lower memory usage.
 		for kvBatch := range kvCh {
-			for _, kv := range kvBatch.KVs {
+			kvs := kvBatch.KVs
+			if resolver != nil {
+				var err error
+				if kvs, err = resolver.resolve(ctx, kvs); err != nil {
+					return err
+				}
+			}
+			for _, kv := range kvs {
 				_, tableID, indexID, indexErr := flowCtx.Codec().DecodeIndexPrefix(kv.Key)
 				if indexErr != nil {
 					return indexErr
@@ -589,26 +628,31 @@ func ingestKvs(
 	})
 
 	if err := g.Wait(); err != nil {
-		return nil, err
+		return nil, jobspb.ImportRowSummary{}, err
 	}
 
 	if err := pkIndexAdder.Flush(ctx); err != nil {
 		if errors.HasType(err, (*kvserverbase.DuplicateKeyError)(nil)) {
-			return nil, errors.Wrap(err, "duplicate key in primary index")
+			return nil, jobspb.ImportRowSummary{}, errors.Wrap(err, "duplicate key in primary index")
 		}
-		return nil, err
+		return nil, jobspb.ImportRowSummary{}, err
 	}
 
 	if err := indexAdder.Flush(ctx); err != nil {
 		if errors.HasType(err, (*kvserverbase.DuplicateKeyError)(nil)) {
-			return nil, errors.Wrap(err, "duplicate key in index")
+			return nil, jobspb.ImportRowSummary{}, errors.Wrap(err, "duplicate key in index")
 		}
-		return nil, err
+		return nil, jobspb.ImportRowSummary{}, err
 	}
 
 	addedSummary := pkIndexAdder.GetSummary()
 	addedSummary.Add(indexAdder.GetSummary())
-	return &addedSummary, nil
+	var rowSummary jobspb.ImportRowSummary
+	if resolver != nil {
+		resolver.onFlush()
+		rowSummary = resolver.summary()
+	}
+	return &addedSummary, rowSummary, nil
 }
 
 func init() {
@@ -63,7 +63,7 @@ func distImport(
 	walltime int64,
 	testingKnobs importTestingKnobs,
 	procsPerNode int,
-) (kvpb.BulkOpSummary, error) {
+) (kvpb.BulkOpSummary, jobspb.ImportRowSummary, error) {
 	ctx, sp := tracing.ChildSpan(ctx, "importer.distImport")
 	defer sp.Finish()
 
@@ -101,7 +101,8 @@ func distImport(
 		p.AddNoInputStage(
 			corePlacement,
 			execinfrapb.PostProcessSpec{},
-			// The direct-ingest readers will emit a binary encoded BulkOpSummary.
+			// The direct-ingest readers will emit a binary encoded BulkOpSummary
+			// and ImportRowSummary.
 			[]*types.T{types.Bytes, types.Bytes},
 			execinfrapb.Ordering{},
 		)
@@ -114,7 +115,7 @@ func distImport(
 
 	p, planCtx, err := makePlan(ctx, dsp)
 	if err != nil {
-		return kvpb.BulkOpSummary{}, err
+		return kvpb.BulkOpSummary{}, jobspb.ImportRowSummary{}, err
 	}
 	evalCtx := planCtx.ExtendedEvalCtx
 
@@ -148,7 +149,7 @@ func distImport(
 			return 0.0
 		},
 		); err != nil {
-			return kvpb.BulkOpSummary{}, err
+			return kvpb.BulkOpSummary{}, jobspb.ImportRowSummary{}, err
 		}
 	}
 
@@ -200,18 +201,24 @@ func distImport(
 	}
 
 	var res kvpb.BulkOpSummary
+	var rowRes jobspb.ImportRowSummary
 	rowResultWriter := sql.NewCallbackResultWriter(func(ctx context.Context, row tree.Datums) error {
 		var counts kvpb.BulkOpSummary
 		if err := protoutil.Unmarshal([]byte(*row[0].(*tree.DBytes)), &counts); err != nil {
 			return err
 		}
 		res.Add(counts)
+		var rowCounts jobspb.ImportRowSummary
+		if err := protoutil.Unmarshal([]byte(*row[1].(*tree.DBytes)), &rowCounts); err != nil {
+			return err
+		}
+		rowRes.Add(rowCounts)
 		return nil
 	})
 
 	if evalCtx.Codec.ForSystemTenant() {
 		if err := presplitTableBoundaries(ctx, execCtx.ExecCfg(), tables); err != nil {
-			return kvpb.BulkOpSummary{}, err
+			return kvpb.BulkOpSummary{}, jobspb.ImportRowSummary{}, err
 		}
 	}
 
@@ -276,10 +283,10 @@ func distImport(
 	g.GoCtx(replanChecker)
 
 	if err := g.Wait(); err != nil {
-		return kvpb.BulkOpSummary{}, err
+		return kvpb.BulkOpSummary{}, jobspb.ImportRowSummary{}, err
 	}
 
-	return res, nil
+	return res, rowRes, nil
 }
 
 func getLastImportSummary(job *jobs.Job) kvpb.BulkOpSummary {
@@ -131,7 +131,7 @@ func TestConverterFlushesBatches(t *testing.T) {
 				group.Go(func() error {
 					defer close(kvCh)
 					return conv.readFiles(ctx, inputs, nil, converterSpec.Format,
-						externalStorageFactory, username.RootUserName())
+						externalStorageFactory, username.RootUserName(), nil /* rejects */)
 				})
 
 				lastBatch := 0
@@ -454,7 +454,7 @@ func TestImportHonorsResumePosition(t *testing.T) {
 					}
 				}()
 
-				_, err := runImport(ctx, flowCtx, spec, progCh, nil /* seqChunkProvider */)
+				_, _, err := runImport(ctx, flowCtx, spec, progCh, nil /* seqChunkProvider */)
 				if err != nil {
 					t.Fatal(err)
 				}
@@ -533,7 +533,7 @@ func TestImportHandlesDuplicateKVs(t *testing.T) {
 				}
 			}()
 
-			_, err := runImport(ctx, flowCtx, spec, progCh, nil /* seqChunkProvider */)
+			_, _, err := runImport(ctx, flowCtx, spec, progCh, nil /* seqChunkProvider */)
 			require.True(t, errors.HasType(err, &kvserverbase.DuplicateKeyError{}))
 		})
 	}
@@ -680,7 +680,8 @@ ORDER BY table_name
 			typ: "CSV",
 			data: `1,,
 		2,"",""`,
-			err: "null value in column \"s2\" violates not-null constraint",
+			err:      "null value in column \"s2\" violates not-null constraint",
+			rejected: "1,,\n",
 		},
 		{
 			name: "quoted nullif is treated as a string",
@@ -1184,9 +1185,12 @@ END;
 			typ:  "PGDUMP",
 			data: `
 CREATE TABLE t (a INT8, b INT8);
+INSERT INTO t VALUES (1, 5), (2, 20), (3, 30);
 CREATE INDEX i ON t USING btree (a) WHERE (b > 10);
 			`,
-			err: "cannot import a table with partial indexes",
+			query: map[string][][]string{
+				`SELECT a FROM t@i WHERE b > 10 ORDER BY a`: {{"2"}, {"3"}},
+			},
 		},
 		{
 			name: "user defined type",
@@ -1303,7 +1307,10 @@ COPY public.t (a, b) FROM stdin;
 			if err != nil {
 				panic(err)
 			}
-			mockRecorder.rejectedString = string(body)
+			// Only record the rejected rows, not the errors that rejected them.
+			if strings.HasSuffix(r.URL.Path, ".rejected") {
+				mockRecorder.rejectedString = string(body)
+			}
 		}
 	}))
 	defer srv.Close()
@@ -4838,6 +4845,10 @@ func TestImportComputed(t *testing.T) {
 	pgdumpData := `
 CREATE TABLE users (a INT, b INT, c INT AS (a + b) STORED);
 INSERT INTO users (a, b) VALUES (1, 2), (3, 4);
+`
+	pgdumpVirtualData := `
+CREATE TABLE users (a INT, b INT, c INT AS (a + b) VIRTUAL, INDEX ((a * b)));
+INSERT INTO users (a, b) VALUES (1, 2), (3, 4);
 `
 	defer srv.Close()
 	tests := []struct {
@@ -4860,6 +4871,22 @@ INSERT INTO users (a, b) VALUES (1, 2), (3, 4);
 			format:          "CSV",
 			expectedResults: [][]string{{"35", "23", "58"}, {"67", "10", "77"}},
 		},
+		{
+			into:            true,
+			name:            "no-target-cols",
+			data:            "35,23\n67,10",
+			create:          "a INT, b INT, c INT AS (a + b) STORED",
+			format:          "CSV",
+			expectedResults: [][]string{{"35", "23", "58"}, {"67", "10", "77"}},
+		},
+		{
+			into:            true,
+			name:            "no-target-cols-virtual",
+			data:            "35,23\n67,10",
+			create:          "a INT, c INT AS (a + b) VIRTUAL, b INT",
+			format:          "CSV",
+			expectedResults: [][]string{{"35", "58", "23"}, {"67", "77", "10"}},
+		},
 		{
 			into:          true,
 			name:          "cannot-be-targeted",
@@ -4921,6 +4948,13 @@ INSERT INTO users (a, b) VALUES (1, 2), (3, 4);
 			format:          "PGDUMP",
 			expectedResults: [][]string{{"1", "2", "3"}, {"3", "4", "7"}},
 		},
+		{
+			into:            false,
+			name:            "pgdump-virtual-expression-index",
+			data:            pgdumpVirtualData,
+			format:          "PGDUMP",
+			expectedResults: [][]string{{"1", "2", "3"}, {"3", "4", "7"}},
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -4929,8 +4963,12 @@ INSERT INTO users (a, b) VALUES (1, 2), (3, 4);
 			var importStmt string
 			if test.into {
 				sqlDB.Exec(t, fmt.Sprintf(`CREATE TABLE users (%s)`, test.create))
-				importStmt = fmt.Sprintf(`IMPORT INTO users (%s) %s DATA (%q)`,
-					test.targetCols, test.format, srv.URL)
+				if test.targetCols == "" {
+					importStmt = fmt.Sprintf(`IMPORT INTO users %s DATA (%q)`, test.format, srv.URL)
+				} else {
+					importStmt = fmt.Sprintf(`IMPORT INTO users (%s) %s DATA (%q)`,
+						test.targetCols, test.format, srv.URL)
+				}
 			} else {
 				importStmt = fmt.Sprintf(`IMPORT %s (%q)`, test.format, srv.URL)
 			}
@@ -7469,3 +7507,89 @@ CREATE TABLE t (
 		})
 	})
 }
+
+// TestImportSaveRejected tests that IMPORT writes the input rows it cannot
+// import to reject files for every format, reports how many rows it rejected
+// by error class, and fails once it rejects more than max_errors rows.
+func TestImportSaveRejected(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	dir, cleanup := testutils.TempDir(t)
+	defer cleanup()
+
+	files := map[string]string{
+		// Row 2 cannot be parsed and row 3 violates the NOT NULL constraint.
+		"data.csv": "1,a\nx,b\n3,\n4,d\n",
+		"data.ndjson": `{"id": 1, "s": "a"}` + "\n" + `{"id": "x", "s": "b"}` + "\n" +
+			`{"id": 3}` + "\n" + `{"id": 4, "s": "d"}` + "\n",
+		"dump.sql": `CREATE TABLE p (id INT8 PRIMARY KEY, s STRING NOT NULL);
+INSERT INTO p VALUES (1, 'a'), (2, NULL), (3, 'c');
+`,
+	}
+	for name, data := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(data), 0644))
+	}
+
+	srv, db, _ := serverutils.StartServer(t, base.TestServerArgs{ExternalIODir: dir})
+	defer srv.Stopper().Stop(ctx)
+	sqlDB := sqlutils.MakeSQLRunner(db)
+
+	readRejected := func(t *testing.T, name string) []string {
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		require.NoError(t, err)
+		return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	}
+
+	t.Run("csv", func(t *testing.T) {
+		sqlDB.Exec(t, `CREATE TABLE t_csv (id INT PRIMARY KEY, s STRING NOT NULL)`)
+		var rows, rejected int
+		var classes string
+		sqlDB.QueryRow(t, `SELECT rows, rejected_rows, rejected_by_error_class::STRING
+			FROM [IMPORT INTO t_csv CSV DATA ('nodelocal://1/data.csv') WITH save_rejected]`,
+		).Scan(&rows, &rejected, &classes)
+		require.Equal(t, 2, rows)
+		require.Equal(t, 2, rejected)
+		require.Equal(t, `{"22P02": 1, "23502": 1}`, classes)
+		sqlDB.CheckQueryResults(t, `SELECT id, s FROM t_csv ORDER BY id`, [][]string{{"1", "a"}, {"4", "d"}})
+		require.ElementsMatch(t, []string{"x,b", "3,"}, readRejected(t, "data.csv.rejected"))
+		require.Len(t, readRejected(t, "data.csv.rejected.errors"), 2)
+	})
+
+	t.Run("ndjson to location", func(t *testing.T) {
+		sqlDB.Exec(t, `CREATE TABLE t_ndjson (id INT PRIMARY KEY, s STRING NOT NULL)`)
+		var rejected int
+		sqlDB.QueryRow(t, `SELECT rejected_rows FROM [IMPORT INTO t_ndjson NDJSON DATA ('nodelocal://1/data.ndjson')
+			WITH save_rejected = 'nodelocal://1/rejects']`,
+		).Scan(&rejected)
+		require.Equal(t, 2, rejected)
+		sqlDB.CheckQueryResults(t, `SELECT id, s FROM t_ndjson ORDER BY id`, [][]string{{"1", "a"}, {"4", "d"}})
+		require.Len(t, readRejected(t, "rejects/data.ndjson.rejected"), 2)
+	})
+
+	t.Run("pgdump", func(t *testing.T) {
+		sqlDB.Exec(t, `CREATE DATABASE pgdump; USE pgdump`)
+		defer sqlDB.Exec(t, `USE defaultdb`)
+		var rows, rejected int
+		sqlDB.QueryRow(t,
+			`SELECT rows, rejected_rows FROM [IMPORT PGDUMP 'nodelocal://1/dump.sql' WITH save_rejected]`,
+		).Scan(&rows, &rejected)
+		require.Equal(t, 2, rows)
+		require.Equal(t, 1, rejected)
+		sqlDB.CheckQueryResults(t, `SELECT id, s FROM p ORDER BY id`, [][]string{{"1", "a"}, {"3", "c"}})
+		require.Len(t, readRejected(t, "dump.sql.rejected"), 1)
+	})
+
+	t.Run("max_errors", func(t *testing.T) {
+		sqlDB.Exec(t, `CREATE TABLE t_max (id INT PRIMARY KEY, s STRING NOT NULL)`)
+		sqlDB.ExpectErr(t, `too many rejected rows \(2\) encountered, max_errors is 1`,
+			`IMPORT INTO t_max CSV DATA ('nodelocal://1/data.csv') WITH save_rejected, max_errors = '1'`)
+		sqlDB.CheckQueryResults(t, `SELECT count(*) FROM t_max`, [][]string{{"0"}})
+
+		sqlDB.ExpectErr(t, `max_errors requires the save_rejected option`,
+			`IMPORT INTO t_max CSV DATA ('nodelocal://1/data.csv') WITH max_errors = '1'`)
+		sqlDB.ExpectErr(t, `max_errors must be > 0`,
+			`IMPORT INTO t_max CSV DATA ('nodelocal://1/data.csv') WITH save_rejected, max_errors = '0'`)
+	})
+}
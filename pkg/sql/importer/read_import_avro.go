@@ -526,12 +526,13 @@ func (a *avroInputReader) readFiles(
 	format roachpb.IOFileFormat,
 	makeExternalStorage cloud.ExternalStorageFactory,
 	user username.SQLUsername,
+	rejects *rejectedRows,
 ) error {
-	return readInputFiles(ctx, dataFiles, resumePos, format, a.readFile, makeExternalStorage, user)
+	return readInputFiles(ctx, dataFiles, resumePos, format, a.readFile, makeExternalStorage, user, rejects)
 }
 
 func (a *avroInputReader) readFile(
-	ctx context.Context, input *fileReader, inputIdx int32, resumePos int64, rejected chan string,
+	ctx context.Context, input *fileReader, inputIdx int32, resumePos int64, rejected chan *importRowError,
 ) error {
 	producer, consumer, err := newImportAvroPipeline(a, input)
 	if err != nil {
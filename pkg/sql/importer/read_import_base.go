@@ -14,12 +14,14 @@ import (
 	"io"
 	"math"
 	"net/url"
+	"path"
 	"strings"
 	"sync/atomic"
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/ccl/crosscluster"
 	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
@@ -34,6 +36,7 @@ import (
 	"github.com/cockroachdb/cockroach/pkg/sql/row"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util"
 	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
 	"github.com/cockroachdb/cockroach/pkg/util/encoding/csv"
@@ -50,7 +53,7 @@ func runImport(
 	spec *execinfrapb.ReadImportDataSpec,
 	progCh chan execinfrapb.RemoteProducerMetadata_BulkProcessorProgress,
 	seqChunkProvider *row.SeqChunkProvider,
-) (*kvpb.BulkOpSummary, error) {
+) (*kvpb.BulkOpSummary, jobspb.ImportRowSummary, error) {
 	// Used to send ingested import rows to the KV layer.
 	kvCh := make(chan row.KVBatch, 10)
 
@@ -60,7 +63,7 @@ func runImport(
 	for _, table := range spec.Tables {
 		cpy := tabledesc.NewBuilder(table.Desc).BuildCreatedMutableTable()
 		if err := typedesc.HydrateTypesInDescriptor(ctx, cpy, importResolver); err != nil {
-			return nil, err
+			return nil, jobspb.ImportRowSummary{}, err
 		}
 		table.Desc = cpy.TableDesc()
 	}
@@ -70,8 +73,9 @@ func runImport(
 	semaCtx := tree.MakeSemaContext(importResolver)
 	conv, err := makeInputConverter(ctx, &semaCtx, spec, evalCtx, kvCh, seqChunkProvider, flowCtx.Cfg.DB.KV())
 	if err != nil {
-		return nil, err
+		return nil, jobspb.ImportRowSummary{}, err
 	}
+	rejects := newRejectedRows(spec.Format)
 
 	// This group holds the go routines that are responsible for producing KV batches.
 	// and ingesting produced KVs.
@@ -99,19 +103,24 @@ func runImport(
 		}
 
 		return conv.readFiles(ctx, inputs, spec.ResumePos, spec.Format, flowCtx.Cfg.ExternalStorage,
-			spec.User())
+			spec.User(), rejects)
 	})
 
 	// Ingest the KVs that the producer group emitted to the chan and the row result
-	// at the end is one row containing an encoded BulkOpSummary.
+	// at the end is one row containing an encoded BulkOpSummary and
+	// ImportRowSummary.
 	var summary *kvpb.BulkOpSummary
+	var rowSummary jobspb.ImportRowSummary
 	group.GoCtx(func(ctx context.Context) error {
-		summary, err = ingestKvs(ctx, flowCtx, spec, progCh, kvCh)
+		summary, rowSummary, err = ingestKvs(ctx, flowCtx, spec, progCh, kvCh)
 		return err
 	})
 
 	if err = group.Wait(); err != nil {
-		return nil, err
+		return nil, jobspb.ImportRowSummary{}, err
+	}
+	if rejects != nil {
+		rowSummary.Add(rejects.summary)
 	}
 
 	var prog execinfrapb.RemoteProducerMetadata_BulkProcessorProgress
@@ -123,13 +132,13 @@ func runImport(
 	}
 	select {
 	case <-ctx.Done():
-		return nil, ctx.Err()
+		return nil, jobspb.ImportRowSummary{}, ctx.Err()
 	case progCh <- prog:
-		return summary, nil
+		return summary, rowSummary, nil
 	}
 }
 
-type readFileFunc func(context.Context, *fileReader, int32, int64, chan string) error
+type readFileFunc func(context.Context, *fileReader, int32, int64, chan *importRowError) error
 
 // readInputFile reads each of the passed dataFiles using the passed func. The
 // key part of dataFiles is the unique index of the data file among all files in
@@ -138,7 +147,9 @@ type readFileFunc func(context.Context, *fileReader, int32, int64, chan string)
 // attempts to use the Size() method of ExternalStorage to determine how many
 // bytes must be read of the input files, and reports the percent of bytes read
 // among all dataFiles. If any Size() fails for any file, then progress is
-// reported only after each file has been read.
+// reported only after each file has been read. If rejects is not nil, the rows
+// of each file that cannot be imported are saved to it rather than failing the
+// import.
 func readInputFiles(
 	ctx context.Context,
 	dataFiles map[int32]string,
@@ -147,6 +158,7 @@ func readInputFiles(
 	fileFunc readFileFunc,
 	makeExternalStorage cloud.ExternalStorageFactory,
 	user username.SQLUsername,
+	rejects *rejectedRows,
 ) error {
 	done := ctx.Done()
 
@@ -236,68 +248,12 @@ func readInputFiles(
 			defer decompressed.Close()
 			src.Reader = decompressed
 
-			var rejected chan string
-			if (format.Format == roachpb.IOFileFormat_CSV && format.SaveRejected) ||
-				(format.Format == roachpb.IOFileFormat_MysqlOutfile && format.SaveRejected) {
-				rejected = make(chan string)
-			}
-			dataFile := dataFile // copy for safe reference in Go routine
-			if rejected != nil {
-				grp := ctxgroup.WithContext(ctx)
-				grp.GoCtx(func(ctx context.Context) error {
-					var buf []byte
-					var countRejected int64
-					for s := range rejected {
-						countRejected++
-						if countRejected > 1000 { // TODO(spaskob): turn the magic constant into an option
-							return pgerror.Newf(
-								pgcode.DataCorrupted,
-								"too many parsing errors (%d) encountered for file %s",
-								countRejected,
-								dataFile,
-							)
-						}
-						buf = append(buf, s...)
-					}
-					if countRejected == 0 {
-						// no rejected rows
-						return nil
-					}
-					rejFn, err := rejectedFilename(dataFile)
-					if err != nil {
-						return err
-					}
-					conf, err := cloud.ExternalStorageConfFromURI(rejFn, user)
-					if err != nil {
-						return err
-					}
-					rejectedStorage, err := makeExternalStorage(ctx, conf)
-					if err != nil {
-						return err
-					}
-					defer rejectedStorage.Close()
-					if err := cloud.WriteFile(ctx, rejectedStorage, "", bytes.NewReader(buf)); err != nil {
-						return err
-					}
-					return nil
-				})
-
-				dataFileIndex := dataFileIndex // copy for safe reference in Go routine
-				grp.GoCtx(func(ctx context.Context) error {
-					defer close(rejected)
-					if err := fileFunc(ctx, src, dataFileIndex, resumePos[dataFileIndex], rejected); err != nil {
-						return err
-					}
-					return nil
-				})
-
-				if err := grp.Wait(); err != nil {
-					return errors.Wrapf(err, "%s", dataFile)
-				}
-			} else {
-				if err := fileFunc(ctx, src, dataFileIndex, resumePos[dataFileIndex], nil /* rejected */); err != nil {
-					return errors.Wrapf(err, "%s", dataFile)
-				}
+			dataFileIndex := dataFileIndex // copy for safe reference in Go routine
+			if err := rejects.readFile(ctx, dataFile, makeExternalStorage, user,
+				func(ctx context.Context, rejected chan *importRowError) error {
+					return fileFunc(ctx, src, dataFileIndex, resumePos[dataFileIndex], rejected)
+				}); err != nil {
+				return errors.Wrapf(err, "%s", dataFile)
 			}
 			return nil
 		}(); err != nil {
@@ -307,13 +263,195 @@ func readInputFiles(
 	return nil
 }
 
-func rejectedFilename(datafile string) (string, error) {
+// defaultMaxRejectedRows is the number of rows an import that saves rejected
+// rows may reject before it fails, unless max_errors is set.
+const defaultMaxRejectedRows = 1000
+
+// rejectedRows saves the rows of the input files of an import that could not
+// be imported, counting them by the class of error that rejected them. The
+// rows rejected from each file are written, as they appeared in the input, to
+// a file named after it so they can be fixed and imported again, and the
+// errors that rejected them to a second file alongside it.
+//
+// The input files of a processor are read one at a time, so a rejectedRows is
+// never used by more than one file concurrently.
+type rejectedRows struct {
+	maxErrors int64
+	location  string
+	summary   jobspb.ImportRowSummary
+}
+
+// newRejectedRows returns the rejectedRows for an import in the passed format,
+// or nil if the import does not save rejected rows.
+func newRejectedRows(format roachpb.IOFileFormat) *rejectedRows {
+	if !format.SaveRejected {
+		return nil
+	}
+	return &rejectedRows{maxErrors: maxRejectedRows(format), location: format.RejectedLocation}
+}
+
+// maxRejectedRows returns the number of rows an import in the passed format
+// may reject before it fails.
+func maxRejectedRows(format roachpb.IOFileFormat) int64 {
+	if format.MaxErrors <= 0 {
+		return defaultMaxRejectedRows
+	}
+	return format.MaxErrors
+}
+
+// readFile runs readFn to read dataFile. If r is not nil, readFn is passed a
+// channel to which it sends the rows it rejects, and the rows are saved to the
+// reject files of dataFile.
+func (r *rejectedRows) readFile(
+	ctx context.Context,
+	dataFile string,
+	makeExternalStorage cloud.ExternalStorageFactory,
+	user username.SQLUsername,
+	readFn func(ctx context.Context, rejected chan *importRowError) error,
+) error {
+	if r == nil {
+		return readFn(ctx, nil /* rejected */)
+	}
+	rejected := make(chan *importRowError)
+	grp := ctxgroup.WithContext(ctx)
+	grp.GoCtx(func(ctx context.Context) error {
+		return r.save(ctx, dataFile, rejected, makeExternalStorage, user)
+	})
+	grp.GoCtx(func(ctx context.Context) error {
+		defer close(rejected)
+		return readFn(ctx, rejected)
+	})
+	return grp.Wait()
+}
+
+// save writes the rows rejected while reading dataFile, and their errors, to
+// the reject files for dataFile until rejected is closed. The files are only
+// created if a row is rejected. It returns an error once the import has
+// rejected more than the maximum number of rows.
+func (r *rejectedRows) save(
+	ctx context.Context,
+	dataFile string,
+	rejected <-chan *importRowError,
+	makeExternalStorage cloud.ExternalStorageFactory,
+	user username.SQLUsername,
+) (retErr error) {
+	rowsURI, errsURI, err := rejectedFilenames(dataFile, r.location)
+	if err != nil {
+		return err
+	}
+	// The rows are streamed to their file while the errors, which are much
+	// smaller, are buffered and only written once the rows file is complete,
+	// so that at most one reject file is open at a time.
+	var rows io.WriteCloser
+	var errs bytes.Buffer
+	defer func() {
+		if rows == nil {
+			return
+		}
+		err := rows.Close()
+		if err == nil {
+			err = writeRejectedFile(ctx, errsURI, errs.Bytes(), makeExternalStorage, user)
+		}
+		if retErr == nil {
+			retErr = err
+		}
+	}()
+	for rowErr := range rejected {
+		r.summary.RejectedRows++
+		if r.summary.RejectedRows > r.maxErrors {
+			return pgerror.Newf(pgcode.DataCorrupted,
+				"too many rejected rows (%d) encountered, max_errors is %d",
+				r.summary.RejectedRows, r.maxErrors)
+		}
+		class := pgerror.GetPGCode(rowErr.err).String()
+		if r.summary.RejectedByErrorClass == nil {
+			r.summary.RejectedByErrorClass = make(map[string]int64)
+		}
+		r.summary.RejectedByErrorClass[class]++
+
+		if rows == nil {
+			if rows, err = openRejectedFile(ctx, rowsURI, makeExternalStorage, user); err != nil {
+				return err
+			}
+		}
+		if _, err := io.WriteString(rows, rowErr.row+"\n"); err != nil {
+			return err
+		}
+		msg := strings.ReplaceAll(rowErr.err.Error(), "\n", " ")
+		fmt.Fprintf(&errs, "row %d: %s: %s\n", rowErr.rowNum, class, msg)
+	}
+	return nil
+}
+
+func writeRejectedFile(
+	ctx context.Context,
+	uri string,
+	content []byte,
+	makeExternalStorage cloud.ExternalStorageFactory,
+	user username.SQLUsername,
+) error {
+	w, err := openRejectedFile(ctx, uri, makeExternalStorage, user)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(content); err != nil {
+		return errors.CombineErrors(err, w.Close())
+	}
+	return w.Close()
+}
+
+func openRejectedFile(
+	ctx context.Context,
+	uri string,
+	makeExternalStorage cloud.ExternalStorageFactory,
+	user username.SQLUsername,
+) (io.WriteCloser, error) {
+	conf, err := cloud.ExternalStorageConfFromURI(uri, user)
+	if err != nil {
+		return nil, err
+	}
+	es, err := makeExternalStorage(ctx, conf)
+	if err != nil {
+		return nil, err
+	}
+	w, err := es.Writer(ctx, "")
+	if err != nil {
+		return nil, errors.CombineErrors(err, es.Close())
+	}
+	return &rejectedFileWriter{WriteCloser: w, es: es}, nil
+}
+
+// rejectedFileWriter closes the external storage of a reject file along with
+// the file.
+type rejectedFileWriter struct {
+	io.WriteCloser
+	es cloud.ExternalStorage
+}
+
+func (w *rejectedFileWriter) Close() error {
+	return errors.CombineErrors(w.WriteCloser.Close(), w.es.Close())
+}
+
+// rejectedFilenames returns the URIs of the files that the rows rejected from
+// datafile, and the errors that rejected them, are written to. The files are
+// placed in location if it is set, and next to datafile otherwise.
+func rejectedFilenames(datafile, location string) (rows, errs string, _ error) {
 	parsedURI, err := url.Parse(datafile)
 	if err != nil {
-		return "", err
+		return "", "", err
 	}
+	if location != "" {
+		locationURI, err := url.Parse(location)
+		if err != nil {
+			return "", "", err
+		}
+		locationURI.Path = path.Join(locationURI.Path, path.Base(parsedURI.Path))
+		parsedURI = locationURI
+	}
+	errsURI := *parsedURI
 	parsedURI.Path = parsedURI.Path + ".rejected"
-	return parsedURI.String(), nil
+	errsURI.Path = errsURI.Path + ".rejected.errors"
+	return parsedURI.String(), errsURI.String(), nil
 }
 
 func decompressingReader(
@@ -375,7 +513,8 @@ func (f fileReader) ReadFraction() float32 {
 type inputConverter interface {
 	start(group ctxgroup.Group)
 	readFiles(ctx context.Context, dataFiles map[int32]string, resumePos map[int32]int64,
-		format roachpb.IOFileFormat, makeExternalStorage cloud.ExternalStorageFactory, user username.SQLUsername) error
+		format roachpb.IOFileFormat, makeExternalStorage cloud.ExternalStorageFactory, user username.SQLUsername,
+		rejects *rejectedRows) error
 }
 
 // formatHasNamedColumns returns true if the data in the input files can be
@@ -384,12 +523,45 @@ func formatHasNamedColumns(format roachpb.IOFileFormat_FileFormat) bool {
 	switch format {
 	case roachpb.IOFileFormat_Avro,
 		roachpb.IOFileFormat_Mysqldump,
-		roachpb.IOFileFormat_PgDump:
+		roachpb.IOFileFormat_PgDump,
+		roachpb.IOFileFormat_Parquet,
+		roachpb.IOFileFormat_NDJSON:
 		return true
 	}
 	return false
 }
 
+// nonComputedVisibleColumnNames returns the names of the visible columns of
+// table that are not computed, or nil if none of its visible columns are
+// computed. Formats without named columns use it as the implicit target column
+// list so that computed columns are evaluated rather than read from the file.
+func nonComputedVisibleColumnNames(table catalog.TableDescriptor) tree.NameList {
+	var names tree.NameList
+	var sawComputed bool
+	for _, col := range table.VisibleColumns() {
+		if col.IsComputed() {
+			sawComputed = true
+			continue
+		}
+		names = append(names, col.ColName())
+	}
+	if !sawComputed {
+		return nil
+	}
+	return names
+}
+
+// coerceImportDatum casts d, a value read from a format whose values are
+// typed, to the type of the column it is imported into.
+func coerceImportDatum(
+	ctx context.Context, d tree.Datum, typ *types.T, evalCtx *eval.Context,
+) (tree.Datum, error) {
+	if d == tree.DNull || typ.Equivalent(d.ResolvedType()) {
+		return d, nil
+	}
+	return eval.PerformCastNoTruncate(ctx, evalCtx, d, typ)
+}
+
 func isMultiTableFormat(format roachpb.IOFileFormat_FileFormat) bool {
 	switch format {
 	case roachpb.IOFileFormat_Mysqldump,
@@ -464,10 +636,10 @@ type parallelImportContext struct {
 
 // importFileContext describes state specific to a file being imported.
 type importFileContext struct {
-	source   int32       // Source is where the row data in the batch came from.
-	skip     int64       // Number of records to skip
-	rejected chan string // Channel for reporting corrupt "rows"
-	rowLimit int64       // Number of records to process before we stop importing from a file.
+	source   int32                // Source is where the row data in the batch came from.
+	skip     int64                // Number of records to skip
+	rejected chan *importRowError // Channel for reporting corrupt "rows"
+	rowLimit int64                // Number of records to process before we stop importing from a file.
 }
 
 // handleCorruptRow reports an error encountered while processing a row
@@ -476,13 +648,55 @@ func handleCorruptRow(ctx context.Context, fileCtx *importFileContext, err error
 	log.Errorf(ctx, "%+v", err)
 
 	if rowErr := (*importRowError)(nil); errors.As(err, &rowErr) && fileCtx.rejected != nil {
-		fileCtx.rejected <- rowErr.row + "\n"
-		return nil
+		select {
+		case fileCtx.rejected <- rowErr:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
 	}
 
 	return err
 }
 
+// rejectRow reports err, encountered while converting the input row numbered
+// rowNum whose text is row, so that the row is rejected if fileCtx saves
+// rejected rows. It returns err if the row cannot be rejected.
+func rejectRow(
+	ctx context.Context, fileCtx *importFileContext, err error, row string, rowNum int64,
+) error {
+	if fileCtx.rejected == nil {
+		return err
+	}
+	return handleCorruptRow(ctx, fileCtx, newImportRowError(err, row, rowNum))
+}
+
+// convertRow converts the datums of conv into KVs like conv.Row, but discards
+// the KVs of the row if the conversion fails so that the row can be rejected
+// without leaving part of it behind.
+func convertRow(
+	ctx context.Context, conv *row.DatumRowConverter, sourceID int32, rowIndex int64,
+) error {
+	numKVs, memSize := len(conv.KvBatch.KVs), conv.KvBatch.MemSize
+	if err := conv.Row(ctx, sourceID, rowIndex); err != nil {
+		if len(conv.KvBatch.KVs) >= numKVs {
+			conv.KvBatch.KVs = conv.KvBatch.KVs[:numKVs]
+			conv.KvBatch.MemSize = memSize
+		}
+		return err
+	}
+	return nil
+}
+
+// recordString returns the text of a record produced by an importRowProducer
+// for reporting in an importRowError.
+func recordString(record interface{}) string {
+	if r, ok := record.([]csv.Record); ok {
+		return strRecord(r, ',')
+	}
+	return fmt.Sprintf("%v", record)
+}
+
 func makeDatumConverter(
 	ctx context.Context, importCtx *parallelImportContext, fileCtx *importFileContext, db *kv.DB,
 ) (*row.DatumRowConverter, error) {
@@ -715,6 +929,12 @@ func (p *parallelImporter) importWorker(
 		for batchIdx, record := range batch.data {
 			rowNum = batch.startPos + int64(batchIdx)
 			if err := consumer.FillDatums(ctx, record, rowNum, conv); err != nil {
+				// Only wrap errors the consumer did not attribute to the row when
+				// the row can be rejected, so that the error an import fails with
+				// is otherwise unchanged.
+				if rowErr := (*importRowError)(nil); fileCtx.rejected != nil && !errors.As(err, &rowErr) {
+					err = newImportRowError(err, recordString(record), rowNum)
+				}
 				if err = handleCorruptRow(ctx, fileCtx, err); err != nil {
 					return err
 				}
@@ -722,12 +942,12 @@ func (p *parallelImporter) importWorker(
 			}
 
 			rowIndex := int64(timestamp) + rowNum
-			if err := conv.Row(ctx, conv.KvBatch.Source, rowIndex); err != nil {
-				s := fmt.Sprintf("%v", record)
-				if r, ok := record.([]csv.Record); ok {
-					s = strRecord(r, ',')
+			if err := convertRow(ctx, conv, conv.KvBatch.Source, rowIndex); err != nil {
+				err = newImportRowError(err, recordString(record), rowNum)
+				if err = handleCorruptRow(ctx, fileCtx, err); err != nil {
+					return err
 				}
-				return newImportRowError(err, s, rowNum)
+				continue
 			}
 		}
 	}
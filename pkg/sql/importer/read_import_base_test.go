@@ -8,6 +8,7 @@ package importer
 import (
 	"context"
 	"math/rand"
+	"strings"
 	"testing"
 	"time"
 
@@ -23,12 +24,13 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-func TestRejectedFilename(t *testing.T) {
+func TestRejectedFilenames(t *testing.T) {
 	defer leaktest.AfterTest(t)()
 	defer log.Scope(t).Close(t)
 	tests := []struct {
 		name     string
 		fname    string
+		location string
 		rejected string
 	}{
 		{
@@ -41,15 +43,20 @@ func TestRejectedFilename(t *testing.T) {
 			fname:    "nodelocal://1/file.csv",
 			rejected: "nodelocal://1/file.csv.rejected",
 		},
+		{
+			name:     "location",
+			fname:    "s3://bucket/data/file.csv?AUTH=implicit",
+			location: "nodelocal://1/rejects",
+			rejected: "nodelocal://1/rejects/file.csv.rejected",
+		},
 	}
 	for _, tc := range tests {
-		rej, err := rejectedFilename(tc.fname)
-		if err != nil {
-			t.Fatal(err)
-		}
-		if tc.rejected != rej {
-			t.Errorf("expected:\n%v\ngot:\n%v\n", tc.rejected, rej)
-		}
+		t.Run(tc.name, func(t *testing.T) {
+			rej, errs, err := rejectedFilenames(tc.fname, tc.location)
+			require.NoError(t, err)
+			require.Equal(t, tc.rejected, rej)
+			require.Equal(t, strings.Replace(tc.rejected, ".rejected", ".rejected.errors", 1), errs)
+		})
 	}
 }
 
@@ -77,12 +77,13 @@ func (c *csvInputReader) readFiles(
 	format roachpb.IOFileFormat,
 	makeExternalStorage cloud.ExternalStorageFactory,
 	user username.SQLUsername,
+	rejects *rejectedRows,
 ) error {
-	return readInputFiles(ctx, dataFiles, resumePos, format, c.readFile, makeExternalStorage, user)
+	return readInputFiles(ctx, dataFiles, resumePos, format, c.readFile, makeExternalStorage, user, rejects)
 }
 
 func (c *csvInputReader) readFile(
-	ctx context.Context, input *fileReader, inputIdx int32, resumePos int64, rejected chan string,
+	ctx context.Context, input *fileReader, inputIdx int32, resumePos int64, rejected chan *importRowError,
 ) error {
 	producer, consumer := newCSVPipeline(c, input)
 
@@ -9,12 +9,15 @@ import (
 	"bufio"
 	"bytes"
 	"context"
+	"encoding/binary"
 	"fmt"
 	"io"
 	"strconv"
 	"strings"
 
 	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/geo"
+	"github.com/cockroachdb/cockroach/pkg/geo/geopb"
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/security/username"
@@ -101,12 +104,13 @@ func (m *mysqldumpReader) readFiles(
 	format roachpb.IOFileFormat,
 	makeExternalStorage cloud.ExternalStorageFactory,
 	user username.SQLUsername,
+	rejects *rejectedRows,
 ) error {
-	return readInputFiles(ctx, dataFiles, resumePos, format, m.readFile, makeExternalStorage, user)
+	return readInputFiles(ctx, dataFiles, resumePos, format, m.readFile, makeExternalStorage, user, rejects)
 }
 
 func (m *mysqldumpReader) readFile(
-	ctx context.Context, input *fileReader, inputIdx int32, resumePos int64, rejected chan string,
+	ctx context.Context, input *fileReader, inputIdx int32, resumePos int64, rejected chan *importRowError,
 ) error {
 	var inserts, count int64
 	r := bufio.NewReaderSize(input, 1024*64)
@@ -114,6 +118,7 @@ func (m *mysqldumpReader) readFile(
 	rowLimit := m.opts.RowLimit
 	tokens := mysql.NewTokenizer(r)
 	tokens.SkipSpecialComments = true
+	fileCtx := &importFileContext{source: inputIdx, rejected: rejected}
 
 	for _, conv := range m.tables {
 		conv.KvBatch.Source = inputIdx
@@ -154,6 +159,7 @@ func (m *mysqldumpReader) readFile(
 				)
 			}
 			startingCount := count
+		insertRows:
 			for _, inputRow := range rows {
 				count++
 				tableNameToRowsProcessed[name]++
@@ -165,18 +171,29 @@ func (m *mysqldumpReader) readFile(
 					break
 				}
 				if expected, got := len(conv.VisibleCols), len(inputRow); expected != got {
-					return errors.Errorf("expected %d values, got %d: %v", expected, got, inputRow)
+					err := errors.Errorf("expected %d values, got %d: %v", expected, got, inputRow)
+					if err := rejectRow(ctx, fileCtx, err, mysql.String(inputRow), count); err != nil {
+						return err
+					}
+					continue
 				}
 				for i, raw := range inputRow {
 					converted, err := mysqlValueToDatum(ctx, raw, conv.VisibleColTypes[i], conv.EvalCtx, conv.SemaCtx)
 					if err != nil {
-						return errors.Wrapf(err, "reading row %d (%d in insert statement %d)",
+						err = errors.Wrapf(err, "reading row %d (%d in insert statement %d)",
 							count, count-startingCount, inserts)
+						if err := rejectRow(ctx, fileCtx, err, mysql.String(inputRow), count); err != nil {
+							return err
+						}
+						continue insertRows
 					}
 					conv.Datums[i] = converted
 				}
-				if err := conv.Row(ctx, inputIdx, count+int64(timestamp)); err != nil {
-					return err
+				if err := convertRow(ctx, conv, inputIdx, count+int64(timestamp)); err != nil {
+					if err := rejectRow(ctx, fileCtx, err, mysql.String(inputRow), count); err != nil {
+						return err
+					}
+					continue
 				}
 				if m.debugRow != nil {
 					m.debugRow(conv.Datums)
@@ -211,12 +228,73 @@ func mysqlStrToDatum(evalCtx *eval.Context, s string, desired *types.T) (tree.Da
 		// tree.ParseAndRequireString and mysqlStrToDatum is whether or not it
 		// attempts to parse bytes.
 		return tree.NewDBytes(tree.DBytes(s)), nil
+	case types.BitFamily:
+		// A string assigned to a BIT column contributes its bytes, not its
+		// characters, as it does in MySQL.
+		return mysqlBytesToBitArray([]byte(s), desired)
+	case types.GeometryFamily:
+		return mysqlGeometryToDatum([]byte(s))
+	case types.ArrayFamily:
+		// SET columns are imported as string arrays.
+		return mysqlSetToDatum(s), nil
 	default:
 		res, _, err := tree.ParseAndRequireString(desired, s, evalCtx)
 		return res, err
 	}
 }
 
+// mysqlBytesToBitArray interprets b as a big-endian unsigned integer, which is
+// how MySQL stores BIT values, and returns it as a bit array of the width of
+// desired.
+func mysqlBytesToBitArray(b []byte, desired *types.T) (tree.Datum, error) {
+	if len(b) > 8 {
+		return nil, errors.Errorf("value of %d bytes is too long for type %s", len(b), desired.SQLString())
+	}
+	var v uint64
+	for _, c := range b {
+		v = v<<8 | uint64(c)
+	}
+	return tree.NewDBitArrayFromInt(int64(v), uint(desired.Width()))
+}
+
+// mysqlBitsToBitArray converts the binary digits of a b'...' literal to a bit
+// array of the width of desired. mysqldump omits leading zeros, so the digits
+// are padded up to the column width.
+func mysqlBitsToBitArray(bits string, desired *types.T) (tree.Datum, error) {
+	if width := int(desired.Width()); len(bits) < width {
+		bits = strings.Repeat("0", width-len(bits)) + bits
+	}
+	return tree.ParseDBitArray(bits)
+}
+
+// mysqlGeometryToDatum decodes a value in MySQL's internal geometry format,
+// which is a 4-byte little-endian SRID followed by the WKB of the geometry.
+func mysqlGeometryToDatum(b []byte) (tree.Datum, error) {
+	if len(b) < 4 {
+		return nil, errors.Errorf("invalid geometry value of %d bytes", len(b))
+	}
+	srid := geopb.SRID(binary.LittleEndian.Uint32(b[:4]))
+	g, err := geo.ParseGeometryFromEWKBAndSRID(geopb.EWKB(b[4:]), srid)
+	if err != nil {
+		return nil, err
+	}
+	return tree.NewDGeometry(g), nil
+}
+
+// mysqlSetToDatum converts the comma-separated members of a MySQL SET value
+// to a string array.
+func mysqlSetToDatum(s string) tree.Datum {
+	arr := tree.NewDArray(types.String)
+	if s == "" {
+		return arr
+	}
+	for _, member := range strings.Split(s, ",") {
+		// Append only fails on type mismatches, which cannot happen here.
+		_ = arr.Append(tree.NewDString(member))
+	}
+	return arr
+}
+
 // mysqlValueToDatum attempts to convert a value, as parsed from a mysqldump
 // INSERT statement, in to a Cockroach Datum of type `desired`. The MySQL parser
 // does not parse the values themselves to Go primitivies, rather leaving the
@@ -256,14 +334,35 @@ func mysqlValueToDatum(
 			}
 			return mysqlStrToDatum(evalContext, s, desired)
 		case mysql.IntVal:
+			if desired.Family() == types.BitFamily {
+				i, err := strconv.ParseUint(string(v.Val), 10, 64)
+				if err != nil {
+					return nil, err
+				}
+				return tree.NewDBitArrayFromInt(int64(i), uint(desired.Width()))
+			}
 			return rowenc.ParseDatumStringAs(ctx, desired, string(v.Val), evalContext, semaCtx)
 		case mysql.FloatVal:
 			return rowenc.ParseDatumStringAs(ctx, desired, string(v.Val), evalContext, semaCtx)
 		case mysql.HexVal:
-			v, err := v.HexDecode()
-			return tree.NewDBytes(tree.DBytes(v)), err
+			b, err := v.HexDecode()
+			if err != nil {
+				return nil, err
+			}
+			switch desired.Family() {
+			case types.BitFamily:
+				return mysqlBytesToBitArray(b, desired)
+			case types.GeometryFamily:
+				return mysqlGeometryToDatum(b)
+			}
+			return tree.NewDBytes(tree.DBytes(b)), nil
+		case mysql.BitVal:
+			if desired.Family() != types.BitFamily {
+				return nil, errors.Errorf("unsupported bit value for type %s", desired.SQLString())
+			}
+			return mysqlBitsToBitArray(string(v.Val), desired)
 		// ValArg appears to be for placeholders, which should not appear in dumps.
-		// TODO(dt): Do we need to handle HexNum or BitVal?
+		// TODO(dt): Do we need to handle HexNum?
 		default:
 			return nil, fmt.Errorf("unsupported value type %c: %v", v.Type, v)
 		}
@@ -755,15 +854,26 @@ func mysqlColToCockroach(
 		def.Type = types.Jsonb
 
 	case mysqltypes.Set:
-		return nil, errors.WithHint(
-			unimplemented.NewWithIssue(32560, "cannot import SET columns at this time"),
-			"try converting the column to a 64-bit integer before import")
+		def.Type = types.StringArray
+
+		expr, err := parser.ParseExpr(fmt.Sprintf("%s <@ ARRAY[%s]", name, strings.Join(col.EnumValues, ",")))
+		if err != nil {
+			return nil, err
+		}
+		checks[name] = &tree.CheckConstraintTableDef{
+			Name: tree.Name(fmt.Sprintf("imported_from_set_%s", name)),
+			Expr: expr,
+		}
+
 	case mysqltypes.Geometry:
-		return nil, unimplemented.NewWithIssue(32559, "cannot import GEOMETRY columns at this time")
+		def.Type = types.Geometry
+
 	case mysqltypes.Bit:
-		return nil, errors.WithHint(
-			unimplemented.NewWithIssue(32561, "cannot import BIT columns at this time"),
-			"try converting the column to a 64-bit integer before import")
+		// BIT without a length is BIT(1) in MySQL.
+		if length == 0 {
+			length = 1
+		}
+		def.Type = types.MakeBit(int32(length))
 	default:
 		return nil, unimplemented.Newf(fmt.Sprintf("import.mysqlcoltype.%s", typ),
 			"unsupported mysql type %q", col.Type)
@@ -781,6 +891,9 @@ func mysqlColToCockroach(
 				// mysql.String(col.Default) returns a quoted string for string
 				// literals. We should use the literal's Val instead.
 				def.DefaultExpr.Expr = tree.NewStrVal(string(literal.Val))
+				if def.Type.Family() == types.ArrayFamily {
+					def.DefaultExpr.Expr = mysqlSetToDatum(string(literal.Val))
+				}
 			} else {
 				exprString := mysql.String(col.Default)
 				expr, err := parser.ParseExpr(exprString)
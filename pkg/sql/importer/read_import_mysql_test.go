@@ -17,6 +17,7 @@ import (
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/geo"
 	"github.com/cockroachdb/cockroach/pkg/roachpb"
 	"github.com/cockroachdb/cockroach/pkg/security/username"
 	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
@@ -356,6 +357,20 @@ func TestMysqlValueToDatum(t *testing.T) {
 	}
 	asdfCS, err := tree.NewDCollatedString("Asdf", "en_US", &tree.CollationEnvironment{})
 	require.NoError(t, err)
+	bits := func(s string) tree.Datum {
+		d, err := tree.ParseDBitArray(s)
+		require.NoError(t, err)
+		return d
+	}
+	set := func(members ...string) tree.Datum {
+		d := tree.NewDArray(types.String)
+		for _, m := range members {
+			require.NoError(t, d.Append(tree.NewDString(m)))
+		}
+		return d
+	}
+	point, err := geo.ParseGeometry("SRID=4326;POINT(1 2)")
+	require.NoError(t, err)
 	tests := []struct {
 		raw  mysql.Expr
 		typ  *types.T
@@ -366,6 +381,15 @@ func TestMysqlValueToDatum(t *testing.T) {
 		{raw: mysql.NewStrLiteral([]byte("Asdf")), typ: types.MakeCollatedString(types.String, "en_US"), want: asdfCS},
 		{raw: mysql.NewStrLiteral([]byte("0000-00-00 00:00:00")), typ: types.Timestamp, want: tree.DNull},
 		{raw: mysql.NewStrLiteral([]byte("2010-01-01 00:00:00")), typ: types.Timestamp, want: ts("2010-01-01 00:00:00")},
+		{raw: mysql.NewBitLiteral([]byte("101")), typ: types.MakeBit(8), want: bits("00000101")},
+		{raw: mysql.NewStrLiteral([]byte("a,c")), typ: types.StringArray, want: set("a", "c")},
+		{raw: mysql.NewStrLiteral([]byte("")), typ: types.StringArray, want: set()},
+		{
+			// MySQL's internal format: a little-endian SRID followed by WKB.
+			raw:  mysql.NewHexLiteral([]byte("E61000000101000000000000000000F03F0000000000000040")),
+			typ:  types.Geometry,
+			want: tree.NewDGeometry(point),
+		},
 	}
 	st := cluster.MakeTestingClusterSettings()
 	evalContext := eval.NewTestingEvalContext(st)
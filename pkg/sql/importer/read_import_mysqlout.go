@@ -67,8 +67,9 @@ func (d *mysqloutfileReader) readFiles(
 	format roachpb.IOFileFormat,
 	makeExternalStorage cloud.ExternalStorageFactory,
 	user username.SQLUsername,
+	rejects *rejectedRows,
 ) error {
-	return readInputFiles(ctx, dataFiles, resumePos, format, d.readFile, makeExternalStorage, user)
+	return readInputFiles(ctx, dataFiles, resumePos, format, d.readFile, makeExternalStorage, user, rejects)
 }
 
 type delimitedProducer struct {
@@ -346,7 +347,7 @@ func (d *delimitedConsumer) FillDatums(
 }
 
 func (d *mysqloutfileReader) readFile(
-	ctx context.Context, input *fileReader, inputIdx int32, resumePos int64, rejected chan string,
+	ctx context.Context, input *fileReader, inputIdx int32, resumePos int64, rejected chan *importRowError,
 ) error {
 	producer := &delimitedProducer{
 		importCtx: d.importCtx,
@@ -0,0 +1,258 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/lexbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/row"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/errors"
+)
+
+// ndjsonInputReader reads newline-delimited JSON files, in which each line is
+// a JSON object whose keys name the columns of the target table.
+type ndjsonInputReader struct {
+	importCtx *parallelImportContext
+	opts      roachpb.NDJSONOptions
+}
+
+var _ inputConverter = &ndjsonInputReader{}
+
+func newNDJSONInputReader(
+	semaCtx *tree.SemaContext,
+	opts roachpb.NDJSONOptions,
+	kvCh chan row.KVBatch,
+	walltime int64,
+	parallelism int,
+	tableDesc catalog.TableDescriptor,
+	targetCols tree.NameList,
+	evalCtx *eval.Context,
+	db *kv.DB,
+) (*ndjsonInputReader, error) {
+	return &ndjsonInputReader{
+		importCtx: &parallelImportContext{
+			semaCtx:    semaCtx,
+			walltime:   walltime,
+			numWorkers: parallelism,
+			evalCtx:    evalCtx,
+			tableDesc:  tableDesc,
+			targetCols: targetCols,
+			kvCh:       kvCh,
+			db:         db,
+		},
+		opts: opts,
+	}, nil
+}
+
+func (n *ndjsonInputReader) start(group ctxgroup.Group) {}
+
+func (n *ndjsonInputReader) readFiles(
+	ctx context.Context,
+	dataFiles map[int32]string,
+	resumePos map[int32]int64,
+	format roachpb.IOFileFormat,
+	makeExternalStorage cloud.ExternalStorageFactory,
+	user username.SQLUsername,
+	rejects *rejectedRows,
+) error {
+	return readInputFiles(ctx, dataFiles, resumePos, format, n.readFile, makeExternalStorage, user, rejects)
+}
+
+func (n *ndjsonInputReader) readFile(
+	ctx context.Context, input *fileReader, inputIdx int32, resumePos int64, rejected chan *importRowError,
+) error {
+	maxRowSize := int(n.opts.MaxRowSize)
+	if maxRowSize <= 0 {
+		maxRowSize = defaultScanBuffer
+	}
+	s := bufio.NewScanner(input)
+	s.Buffer(nil, maxRowSize)
+
+	producer := &ndjsonProducer{input: input, scanner: s}
+	consumer := &ndjsonConsumer{
+		fieldNameToIdx: visibleColumnIdxByName(n.importCtx.tableDesc),
+		strict:         n.opts.StrictMode,
+	}
+	fileCtx := &importFileContext{
+		source:   inputIdx,
+		skip:     resumePos,
+		rejected: rejected,
+		rowLimit: n.opts.RowLimit,
+	}
+	return runParallelImport(ctx, n.importCtx, fileCtx, producer, consumer)
+}
+
+// visibleColumnIdxByName maps the name of each visible column of tableDesc to
+// its index among the visible columns.
+func visibleColumnIdxByName(tableDesc catalog.TableDescriptor) map[string]int {
+	idxByName := make(map[string]int)
+	for idx, col := range tableDesc.VisibleColumns() {
+		idxByName[col.GetName()] = idx
+	}
+	return idxByName
+}
+
+// ndjsonProducer produces the non-blank lines of its input.
+type ndjsonProducer struct {
+	input   *fileReader
+	scanner *bufio.Scanner
+	line    []byte
+}
+
+var _ importRowProducer = &ndjsonProducer{}
+
+// Scan implements importRowProducer.
+func (p *ndjsonProducer) Scan() bool {
+	for p.scanner.Scan() {
+		if line := bytes.TrimSpace(p.scanner.Bytes()); len(line) > 0 {
+			// The scanner reuses its buffer, so copy the line out of it before it
+			// is handed to a consumer.
+			p.line = append([]byte(nil), line...)
+			return true
+		}
+	}
+	return false
+}
+
+// Err implements importRowProducer.
+func (p *ndjsonProducer) Err() error {
+	return p.scanner.Err()
+}
+
+// Skip implements importRowProducer.
+func (p *ndjsonProducer) Skip() error {
+	return nil // no-op
+}
+
+// Row implements importRowProducer.
+func (p *ndjsonProducer) Row() (interface{}, error) {
+	return p.line, nil
+}
+
+// Progress implements importRowProducer.
+func (p *ndjsonProducer) Progress() float32 {
+	return p.input.ReadFraction()
+}
+
+// ndjsonConsumer parses lines into JSON objects and sets the columns named by
+// their keys.
+type ndjsonConsumer struct {
+	fieldNameToIdx map[string]int
+	strict         bool
+}
+
+var _ importRowConsumer = &ndjsonConsumer{}
+
+// FillDatums implements importRowConsumer.
+func (c *ndjsonConsumer) FillDatums(
+	ctx context.Context, native interface{}, rowNum int64, conv *row.DatumRowConverter,
+) error {
+	line := native.([]byte)
+	if err := c.convertLine(ctx, line, conv); err != nil {
+		return newImportRowError(err, string(line), rowNum)
+	}
+	return nil
+}
+
+func (c *ndjsonConsumer) convertLine(
+	ctx context.Context, line []byte, conv *row.DatumRowConverter,
+) error {
+	j, err := json.ParseJSON(string(line))
+	if err != nil {
+		return err
+	}
+	if j.Type() != json.ObjectJSONType {
+		return errors.Errorf("expected a JSON object, found %s", j.Type())
+	}
+	it, err := j.ObjectIter()
+	if err != nil {
+		return err
+	}
+	for it.Next() {
+		field := lexbase.NormalizeName(it.Key())
+		idx, ok := c.fieldNameToIdx[field]
+		if !ok || !conv.TargetColOrds.Contains(idx) {
+			if c.strict {
+				return errors.Errorf("could not find column for key %s", field)
+			}
+			continue
+		}
+		col := conv.VisibleCols[idx]
+		datum, err := ndjsonValueToDatum(ctx, it.Value(), conv.VisibleColTypes[idx], conv.EvalCtx, conv.SemaCtx)
+		if err != nil {
+			return errors.Wrapf(err, "converting %q to %s", col.GetName(), col.GetType().SQLString())
+		}
+		conv.Datums[idx] = datum
+	}
+
+	// Set any target columns that the object didn't set to NULL.
+	for i := range conv.Datums {
+		if conv.TargetColOrds.Contains(i) && conv.Datums[i] == nil {
+			if c.strict {
+				return errors.Errorf("key %s was not set in the JSON object", conv.VisibleCols[i].GetName())
+			}
+			conv.Datums[i] = tree.DNull
+		}
+	}
+	return nil
+}
+
+// ndjsonValueToDatum converts a JSON value to a datum of type typ. Any JSON
+// value, including objects and arrays, can be imported into a JSONB column.
+// JSON arrays are converted element by element into arrays, and strings and
+// numbers are parsed as if they were the text of a value of type typ.
+func ndjsonValueToDatum(
+	ctx context.Context, j json.JSON, typ *types.T, evalCtx *eval.Context, semaCtx *tree.SemaContext,
+) (tree.Datum, error) {
+	if typ.Family() == types.JsonFamily {
+		return tree.NewDJSON(j), nil
+	}
+	switch j.Type() {
+	case json.NullJSONType:
+		return tree.DNull, nil
+	case json.TrueJSONType:
+		return coerceImportDatum(ctx, tree.DBoolTrue, typ, evalCtx)
+	case json.FalseJSONType:
+		return coerceImportDatum(ctx, tree.DBoolFalse, typ, evalCtx)
+	case json.StringJSONType, json.NumberJSONType:
+		s, err := j.AsText()
+		if err != nil {
+			return nil, err
+		}
+		return rowenc.ParseDatumStringAs(ctx, typ, *s, evalCtx, semaCtx)
+	case json.ArrayJSONType:
+		if typ.Family() != types.ArrayFamily {
+			break
+		}
+		elts, _ := j.AsArray()
+		arr := tree.NewDArray(typ.ArrayContents())
+		for _, elt := range elts {
+			d, err := ndjsonValueToDatum(ctx, elt, typ.ArrayContents(), evalCtx, semaCtx)
+			if err != nil {
+				return nil, err
+			}
+			if err := arr.Append(d); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	}
+	return nil, errors.Errorf("cannot convert JSON %s to %s", j.Type(), typ.SQLString())
+}
@@ -0,0 +1,68 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package importer_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/stretchr/testify/require"
+)
+
+// TestImportNDJSON tests that IMPORT INTO reads newline-delimited JSON,
+// mapping keys to columns by name, coercing values to the column types and
+// storing nested values in JSONB columns.
+func TestImportNDJSON(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	dir, cleanup := testutils.TempDir(t)
+	defer cleanup()
+
+	const data = `{"id": 1, "name": "a", "price": 1.25, "at": "2024-01-02T03:04:05Z", "tags": ["x", "y"], "attrs": {"k": [1, 2]}}
+
+{"ID": 2, "name": null, "price": "3", "tags": [], "attrs": "str", "unknown": true}
+{"id": 3, "name": "c", "active": false}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "data.ndjson"), []byte(data), 0644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bad.ndjson"), []byte(`[1, 2]`+"\n"), 0644))
+
+	srv, db, _ := serverutils.StartServer(t, base.TestServerArgs{ExternalIODir: dir})
+	defer srv.Stopper().Stop(ctx)
+	sqlDB := sqlutils.MakeSQLRunner(db)
+
+	sqlDB.Exec(t, `CREATE TABLE t (
+		id INT PRIMARY KEY, name STRING, price DECIMAL, at TIMESTAMPTZ, tags STRING[], attrs JSONB,
+		active BOOL DEFAULT true
+	)`)
+	sqlDB.Exec(t, `IMPORT INTO t (id, name, price, at, tags, attrs) NDJSON DATA ('nodelocal://1/data.ndjson')`)
+	sqlDB.CheckQueryResults(t,
+		`SELECT id, name, price, at, tags, attrs, active FROM t ORDER BY id`,
+		[][]string{
+			{"1", "a", "1.25", "2024-01-02 03:04:05 +0000 UTC", "{x,y}", `{"k": [1, 2]}`, "true"},
+			{"2", "NULL", "3", "NULL", "{}", `"str"`, "true"},
+			{"3", "c", "NULL", "NULL", "NULL", "NULL", "true"},
+		})
+
+	t.Run("errors", func(t *testing.T) {
+		sqlDB.Exec(t, `CREATE TABLE e (id INT PRIMARY KEY, name STRING)`)
+		sqlDB.ExpectErr(t, "could not find column for key at",
+			`IMPORT INTO e NDJSON DATA ('nodelocal://1/data.ndjson') WITH strict_validation`)
+		sqlDB.ExpectErr(t, "expected a JSON object, found array",
+			`IMPORT INTO e NDJSON DATA ('nodelocal://1/bad.ndjson')`)
+		sqlDB.ExpectErr(t, `invalid option "delimiter" specified for NDJSON import format`,
+			`IMPORT INTO e NDJSON DATA ('nodelocal://1/data.ndjson') WITH delimiter = ','`)
+	})
+}
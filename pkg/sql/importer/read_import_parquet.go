@@ -0,0 +1,574 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package importer
+
+import (
+	"context"
+	"encoding/hex"
+	gojson "encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/apache/arrow/go/v11/arrow"
+	"github.com/apache/arrow/go/v11/arrow/array"
+	"github.com/apache/arrow/go/v11/arrow/memory"
+	"github.com/apache/arrow/go/v11/parquet/file"
+	"github.com/apache/arrow/go/v11/parquet/pqarrow"
+	"github.com/cockroachdb/cockroach/pkg/cloud"
+	"github.com/cockroachdb/cockroach/pkg/geo"
+	"github.com/cockroachdb/cockroach/pkg/kv"
+	"github.com/cockroachdb/cockroach/pkg/roachpb"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/sql/catalog"
+	"github.com/cockroachdb/cockroach/pkg/sql/lexbase"
+	"github.com/cockroachdb/cockroach/pkg/sql/row"
+	"github.com/cockroachdb/cockroach/pkg/sql/rowenc"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/types"
+	"github.com/cockroachdb/cockroach/pkg/util/ctxgroup"
+	"github.com/cockroachdb/cockroach/pkg/util/ioctx"
+	"github.com/cockroachdb/cockroach/pkg/util/json"
+	"github.com/cockroachdb/cockroach/pkg/util/timeofday"
+	"github.com/cockroachdb/errors"
+)
+
+// parquetReadBatchSize is the number of rows read from each column of a
+// parquet file at a time.
+const parquetReadBatchSize = 1024
+
+// parquetInputReader reads parquet files, mapping their top-level columns to
+// the columns of the target table by name.
+type parquetInputReader struct {
+	importCtx *parallelImportContext
+	opts      roachpb.ParquetOptions
+}
+
+var _ inputConverter = &parquetInputReader{}
+
+func newParquetInputReader(
+	semaCtx *tree.SemaContext,
+	opts roachpb.ParquetOptions,
+	kvCh chan row.KVBatch,
+	walltime int64,
+	parallelism int,
+	tableDesc catalog.TableDescriptor,
+	targetCols tree.NameList,
+	evalCtx *eval.Context,
+	db *kv.DB,
+) (*parquetInputReader, error) {
+	return &parquetInputReader{
+		importCtx: &parallelImportContext{
+			semaCtx:    semaCtx,
+			walltime:   walltime,
+			numWorkers: parallelism,
+			evalCtx:    evalCtx,
+			tableDesc:  tableDesc,
+			targetCols: targetCols,
+			kvCh:       kvCh,
+			db:         db,
+		},
+		opts: opts,
+	}, nil
+}
+
+func (p *parquetInputReader) start(group ctxgroup.Group) {}
+
+// readFiles implements inputConverter. Unlike the other formats, parquet files
+// are not read as a stream: their metadata is in a footer at the end of the
+// file, and their columns are stored separately, so each file is read in
+// place from external storage.
+func (p *parquetInputReader) readFiles(
+	ctx context.Context,
+	dataFiles map[int32]string,
+	resumePos map[int32]int64,
+	format roachpb.IOFileFormat,
+	makeExternalStorage cloud.ExternalStorageFactory,
+	user username.SQLUsername,
+	rejects *rejectedRows,
+) error {
+	done := ctx.Done()
+	for dataFileIndex, dataFile := range dataFiles {
+		select {
+		case <-done:
+			return ctx.Err()
+		default:
+		}
+		if err := func() error {
+			conf, err := cloud.ExternalStorageConfFromURI(dataFile, user)
+			if err != nil {
+				return err
+			}
+			es, err := makeExternalStorage(ctx, conf)
+			if err != nil {
+				return err
+			}
+			defer es.Close()
+			return rejects.readFile(ctx, dataFile, makeExternalStorage, user,
+				func(ctx context.Context, rejected chan *importRowError) error {
+					return p.readFile(ctx, es, dataFileIndex, resumePos[dataFileIndex], rejected)
+				})
+		}(); err != nil {
+			return errors.Wrapf(err, "%s", dataFile)
+		}
+	}
+	return nil
+}
+
+func (p *parquetInputReader) readFile(
+	ctx context.Context,
+	es cloud.ExternalStorage,
+	inputIdx int32,
+	resumePos int64,
+	rejected chan *importRowError,
+) error {
+	size, err := es.Size(ctx, "")
+	if err != nil {
+		return err
+	}
+	pf, err := file.NewParquetReader(&externalStorageReaderAt{ctx: ctx, es: es, size: size})
+	if err != nil {
+		return errors.Wrap(err, "reading parquet file")
+	}
+	defer func() { _ = pf.Close() }()
+
+	// Batches are allocated by the Go allocator, so the rows handed to the
+	// consumers can keep referencing them after the reader has moved on.
+	fr, err := pqarrow.NewFileReader(
+		pf, pqarrow.ArrowReadProperties{BatchSize: parquetReadBatchSize}, memory.DefaultAllocator)
+	if err != nil {
+		return err
+	}
+	schema, err := fr.Schema()
+	if err != nil {
+		return err
+	}
+	fieldNameToIdx := visibleColumnIdxByName(p.importCtx.tableDesc)
+	colIdx := make([]int, len(schema.Fields()))
+	for i, f := range schema.Fields() {
+		field := lexbase.NormalizeName(f.Name)
+		idx, ok := fieldNameToIdx[field]
+		if !ok {
+			if p.opts.StrictMode {
+				return errors.Errorf("could not find column for parquet column %s", field)
+			}
+			idx = -1
+		}
+		colIdx[i] = idx
+	}
+
+	rr, err := fr.GetRecordReader(ctx, nil /* colIndices */, nil /* rowGroups */)
+	if err != nil {
+		return err
+	}
+	defer rr.Release()
+
+	producer := &parquetProducer{reader: rr, numRows: pf.NumRows()}
+	consumer := &parquetConsumer{colIdx: colIdx, strict: p.opts.StrictMode}
+	fileCtx := &importFileContext{
+		source:   inputIdx,
+		skip:     resumePos,
+		rejected: rejected,
+		rowLimit: p.opts.RowLimit,
+	}
+	return runParallelImport(ctx, p.importCtx, fileCtx, producer, consumer)
+}
+
+// externalStorageReaderAt adapts a file in external storage to the
+// io.ReaderAt and io.Seeker interfaces that the parquet reader reads through.
+type externalStorageReaderAt struct {
+	ctx  context.Context
+	es   cloud.ExternalStorage
+	size int64
+	pos  int64
+}
+
+// ReadAt implements io.ReaderAt.
+func (r *externalStorageReaderAt) ReadAt(p []byte, off int64) (int, error) {
+	if off >= r.size {
+		return 0, io.EOF
+	}
+	rc, _, err := r.es.ReadFile(r.ctx, "", cloud.ReadOptions{
+		Offset:     off,
+		LengthHint: int64(len(p)),
+		NoFileSize: true,
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer rc.Close(r.ctx)
+	n, err := io.ReadFull(ioctx.ReaderCtxAdapter(r.ctx, rc), p)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Seek implements io.Seeker.
+func (r *externalStorageReaderAt) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekStart:
+	case io.SeekCurrent:
+		offset += r.pos
+	case io.SeekEnd:
+		offset += r.size
+	default:
+		return 0, errors.Errorf("invalid whence %d", whence)
+	}
+	if offset < 0 {
+		return 0, errors.Errorf("invalid offset %d", offset)
+	}
+	r.pos = offset
+	return offset, nil
+}
+
+// parquetRow is a row of a batch read from a parquet file.
+type parquetRow struct {
+	rec arrow.Record
+	idx int
+}
+
+// String formats the row as a JSON object, for error messages.
+func (r parquetRow) String() string {
+	obj := make(map[string]interface{}, r.rec.NumCols())
+	for i := range r.rec.Columns() {
+		v, err := parquetValueToNative(r.rec.Column(i), r.idx)
+		if err != nil {
+			return fmt.Sprintf("row %d of batch", r.idx)
+		}
+		obj[r.rec.ColumnName(i)] = v
+	}
+	j, err := json.MakeJSON(obj)
+	if err != nil {
+		return fmt.Sprintf("row %d of batch", r.idx)
+	}
+	return j.String()
+}
+
+// parquetProducer produces the rows of a parquet file, batch by batch.
+type parquetProducer struct {
+	reader   pqarrow.RecordReader
+	numRows  int64
+	rowsRead int64
+	rec      arrow.Record
+	idx      int
+	err      error
+}
+
+var _ importRowProducer = &parquetProducer{}
+
+// Scan implements importRowProducer.
+func (p *parquetProducer) Scan() bool {
+	p.idx++
+	for p.rec == nil || p.idx >= int(p.rec.NumRows()) {
+		rec, err := p.reader.Read()
+		if err != nil {
+			if err != io.EOF {
+				p.err = err
+			}
+			return false
+		}
+		// The reader releases each batch when it reads the next one, but the
+		// rows of this batch may still be being converted.
+		rec.Retain()
+		p.rec, p.idx = rec, 0
+	}
+	p.rowsRead++
+	return true
+}
+
+// Err implements importRowProducer.
+func (p *parquetProducer) Err() error {
+	return p.err
+}
+
+// Skip implements importRowProducer.
+func (p *parquetProducer) Skip() error {
+	return nil // no-op
+}
+
+// Row implements importRowProducer.
+func (p *parquetProducer) Row() (interface{}, error) {
+	return parquetRow{rec: p.rec, idx: p.idx}, nil
+}
+
+// Progress implements importRowProducer.
+func (p *parquetProducer) Progress() float32 {
+	if p.numRows == 0 {
+		return 0
+	}
+	return float32(p.rowsRead) / float32(p.numRows)
+}
+
+// parquetConsumer sets the columns of the target table from the columns of a
+// parquet row.
+type parquetConsumer struct {
+	// colIdx maps the index of each column of the file to the index of the
+	// visible column of the table that it is imported into, or -1 if it is not
+	// imported.
+	colIdx []int
+	strict bool
+}
+
+var _ importRowConsumer = &parquetConsumer{}
+
+// FillDatums implements importRowConsumer.
+func (c *parquetConsumer) FillDatums(
+	ctx context.Context, native interface{}, rowNum int64, conv *row.DatumRowConverter,
+) error {
+	r := native.(parquetRow)
+	if err := c.convertRow(ctx, r, conv); err != nil {
+		return newImportRowError(err, r.String(), rowNum)
+	}
+	return nil
+}
+
+func (c *parquetConsumer) convertRow(
+	ctx context.Context, r parquetRow, conv *row.DatumRowConverter,
+) error {
+	for i, idx := range c.colIdx {
+		if idx < 0 {
+			continue
+		}
+		if !conv.TargetColOrds.Contains(idx) {
+			if c.strict {
+				return errors.Errorf("parquet column %s is not a target column", r.rec.ColumnName(i))
+			}
+			continue
+		}
+		col := conv.VisibleCols[idx]
+		datum, err := parquetValueToDatum(
+			ctx, r.rec.Column(i), r.idx, conv.VisibleColTypes[idx], conv.EvalCtx, conv.SemaCtx)
+		if err != nil {
+			return errors.Wrapf(err, "converting %q to %s", col.GetName(), col.GetType().SQLString())
+		}
+		conv.Datums[idx] = datum
+	}
+
+	// Set any target columns that the file doesn't have to NULL.
+	for i := range conv.Datums {
+		if conv.TargetColOrds.Contains(i) && conv.Datums[i] == nil {
+			if c.strict {
+				return errors.Errorf("column %s was not set in the parquet file", conv.VisibleCols[i].GetName())
+			}
+			conv.Datums[i] = tree.DNull
+		}
+	}
+	return nil
+}
+
+// parquetValueToDatum converts the value at index i of arr to a datum of type
+// typ. Lists are converted element by element into arrays, and lists, structs
+// and maps are converted into JSON for JSONB columns. Strings are parsed as if
+// they were the text of a value of type typ, which is how EXPORT writes most
+// types.
+func parquetValueToDatum(
+	ctx context.Context,
+	arr arrow.Array,
+	i int,
+	typ *types.T,
+	evalCtx *eval.Context,
+	semaCtx *tree.SemaContext,
+) (tree.Datum, error) {
+	if arr.IsNull(i) {
+		return tree.DNull, nil
+	}
+	switch a := arr.(type) {
+	case *array.String:
+		return rowenc.ParseDatumStringAs(ctx, typ, a.Value(i), evalCtx, semaCtx)
+	case *array.Binary:
+		b := a.Value(i)
+		switch typ.Family() {
+		case types.BytesFamily:
+			return tree.NewDBytes(tree.DBytes(b)), nil
+		case types.GeometryFamily:
+			g, err := geo.ParseGeometryFromEWKB(append([]byte(nil), b...))
+			if err != nil {
+				return nil, err
+			}
+			return tree.NewDGeometry(g), nil
+		case types.GeographyFamily:
+			g, err := geo.ParseGeographyFromEWKB(append([]byte(nil), b...))
+			if err != nil {
+				return nil, err
+			}
+			return tree.NewDGeography(g), nil
+		}
+		return rowenc.ParseDatumStringAs(ctx, typ, string(b), evalCtx, semaCtx)
+	case *array.FixedSizeBinary:
+		switch typ.Family() {
+		case types.UuidFamily:
+			return tree.ParseDUuidFromBytes(a.Value(i))
+		case types.BytesFamily:
+			return tree.NewDBytes(tree.DBytes(a.Value(i))), nil
+		}
+	case *array.List:
+		if typ.Family() != types.ArrayFamily {
+			break
+		}
+		start, end := a.ValueOffsets(i)
+		values := a.ListValues()
+		darr := tree.NewDArray(typ.ArrayContents())
+		for j := int(start); j < int(end); j++ {
+			d, err := parquetValueToDatum(ctx, values, j, typ.ArrayContents(), evalCtx, semaCtx)
+			if err != nil {
+				return nil, err
+			}
+			if err := darr.Append(d); err != nil {
+				return nil, err
+			}
+		}
+		return darr, nil
+	}
+
+	if typ.Family() == types.JsonFamily {
+		v, err := parquetValueToNative(arr, i)
+		if err != nil {
+			return nil, err
+		}
+		j, err := json.MakeJSON(v)
+		if err != nil {
+			return nil, err
+		}
+		return tree.NewDJSON(j), nil
+	}
+	d, err := parquetScalarToDatum(arr, i)
+	if err != nil {
+		return nil, err
+	}
+	return coerceImportDatum(ctx, d, typ, evalCtx)
+}
+
+// parquetScalarToDatum converts the value at index i of arr, which must not
+// be null, to the datum of the type that corresponds to its parquet type.
+func parquetScalarToDatum(arr arrow.Array, i int) (tree.Datum, error) {
+	switch a := arr.(type) {
+	case *array.Boolean:
+		return tree.MakeDBool(tree.DBool(a.Value(i))), nil
+	case *array.Int8:
+		return tree.NewDInt(tree.DInt(a.Value(i))), nil
+	case *array.Int16:
+		return tree.NewDInt(tree.DInt(a.Value(i))), nil
+	case *array.Int32:
+		return tree.NewDInt(tree.DInt(a.Value(i))), nil
+	case *array.Int64:
+		return tree.NewDInt(tree.DInt(a.Value(i))), nil
+	case *array.Uint8:
+		return tree.NewDInt(tree.DInt(a.Value(i))), nil
+	case *array.Uint16:
+		return tree.NewDInt(tree.DInt(a.Value(i))), nil
+	case *array.Uint32:
+		return tree.NewDInt(tree.DInt(a.Value(i))), nil
+	case *array.Uint64:
+		if v := a.Value(i); v > math.MaxInt64 {
+			return tree.ParseDDecimal(strconv.FormatUint(v, 10))
+		}
+		return tree.NewDInt(tree.DInt(a.Value(i))), nil
+	case *array.Float32:
+		return tree.NewDFloat(tree.DFloat(a.Value(i))), nil
+	case *array.Float64:
+		return tree.NewDFloat(tree.DFloat(a.Value(i))), nil
+	case *array.Decimal128:
+		scale := a.DataType().(*arrow.Decimal128Type).Scale
+		return tree.ParseDDecimal(a.Value(i).ToString(scale))
+	case *array.Date32:
+		return tree.NewDDateFromTime(a.Value(i).ToTime())
+	case *array.Date64:
+		return tree.NewDDateFromTime(a.Value(i).ToTime())
+	case *array.Timestamp:
+		typ := a.DataType().(*arrow.TimestampType)
+		t := a.Value(i).ToTime(typ.Unit)
+		if typ.TimeZone != "" {
+			return tree.MakeDTimestampTZ(t, time.Microsecond)
+		}
+		return tree.MakeDTimestamp(t, time.Microsecond)
+	case *array.Time32:
+		d := time.Duration(a.Value(i)) * a.DataType().(*arrow.Time32Type).Unit.Multiplier()
+		return tree.MakeDTime(timeofday.TimeOfDay(d / time.Microsecond)), nil
+	case *array.Time64:
+		d := time.Duration(a.Value(i)) * a.DataType().(*arrow.Time64Type).Unit.Multiplier()
+		return tree.MakeDTime(timeofday.TimeOfDay(d / time.Microsecond)), nil
+	case *array.String:
+		return tree.NewDString(a.Value(i)), nil
+	case *array.Binary:
+		return tree.NewDBytes(tree.DBytes(a.Value(i))), nil
+	case *array.FixedSizeBinary:
+		return tree.NewDBytes(tree.DBytes(a.Value(i))), nil
+	}
+	return nil, errors.Errorf("cannot convert parquet %s value", arr.DataType())
+}
+
+// parquetValueToNative converts the value at index i of arr to a Go value
+// that json.MakeJSON accepts.
+func parquetValueToNative(arr arrow.Array, i int) (interface{}, error) {
+	if arr.IsNull(i) {
+		return nil, nil
+	}
+	switch a := arr.(type) {
+	case *array.Map:
+		start, end := a.ValueOffsets(i)
+		obj := make(map[string]interface{}, end-start)
+		for j := int(start); j < int(end); j++ {
+			k, err := parquetValueToNative(a.Keys(), j)
+			if err != nil {
+				return nil, err
+			}
+			v, err := parquetValueToNative(a.Items(), j)
+			if err != nil {
+				return nil, err
+			}
+			obj[fmt.Sprint(k)] = v
+		}
+		return obj, nil
+	case *array.List:
+		start, end := a.ValueOffsets(i)
+		elts := make([]interface{}, 0, end-start)
+		for j := int(start); j < int(end); j++ {
+			v, err := parquetValueToNative(a.ListValues(), j)
+			if err != nil {
+				return nil, err
+			}
+			elts = append(elts, v)
+		}
+		return elts, nil
+	case *array.Struct:
+		typ := a.DataType().(*arrow.StructType)
+		obj := make(map[string]interface{}, a.NumField())
+		for f := 0; f < a.NumField(); f++ {
+			v, err := parquetValueToNative(a.Field(f), i)
+			if err != nil {
+				return nil, err
+			}
+			obj[typ.Field(f).Name] = v
+		}
+		return obj, nil
+	case *array.String:
+		return a.Value(i), nil
+	case *array.Binary:
+		return `\x` + hex.EncodeToString(a.Value(i)), nil
+	case *array.FixedSizeBinary:
+		return `\x` + hex.EncodeToString(a.Value(i)), nil
+	}
+
+	d, err := parquetScalarToDatum(arr, i)
+	if err != nil {
+		return nil, err
+	}
+	switch t := d.(type) {
+	case *tree.DBool:
+		return bool(*t), nil
+	case *tree.DInt:
+		return int64(*t), nil
+	case *tree.DFloat:
+		return float64(*t), nil
+	case *tree.DDecimal:
+		return gojson.Number(t.String()), nil
+	}
+	return tree.AsStringWithFlags(d, tree.FmtBareStrings), nil
+}
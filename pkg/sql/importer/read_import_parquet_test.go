@@ -0,0 +1,66 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package importer_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/base"
+	"github.com/cockroachdb/cockroach/pkg/testutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/serverutils"
+	"github.com/cockroachdb/cockroach/pkg/testutils/sqlutils"
+	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// TestImportParquet tests that IMPORT INTO reads back the parquet files that
+// EXPORT writes, mapping their columns to the columns of the table by name.
+func TestImportParquet(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	ctx := context.Background()
+	dir, cleanup := testutils.TempDir(t)
+	defer cleanup()
+
+	srv, db, _ := serverutils.StartServer(t, base.TestServerArgs{ExternalIODir: dir})
+	defer srv.Stopper().Stop(ctx)
+	sqlDB := sqlutils.MakeSQLRunner(db)
+
+	sqlDB.Exec(t, `CREATE TABLE src (
+		id INT PRIMARY KEY, s STRING, d DECIMAL(10, 2), ts TIMESTAMP, a INT[], j JSONB
+	)`)
+	sqlDB.Exec(t, `INSERT INTO src VALUES
+		(1, 'a', 1.25, '2024-01-02 03:04:05', ARRAY[1, 2], '{"k": [1, 2]}'),
+		(2, NULL, NULL, NULL, ARRAY[]::INT[], NULL),
+		(3, 'c', -7.5, '1999-12-31 23:59:59.5', ARRAY[3, NULL], '"str"')`)
+	sqlDB.Exec(t, `EXPORT INTO PARQUET 'nodelocal://1/src' FROM SELECT * FROM src`)
+
+	// The columns of dst are in a different order than those of the file, and
+	// extra sets its default since the file doesn't have it.
+	sqlDB.Exec(t, `CREATE TABLE dst (
+		j JSONB, a INT[], ts TIMESTAMP, d DECIMAL(10, 2), s STRING, id INT PRIMARY KEY,
+		extra STRING DEFAULT 'x'
+	)`)
+	sqlDB.Exec(t, `IMPORT INTO dst (id, s, d, ts, a, j) PARQUET DATA ('nodelocal://1/src/*')`)
+	sqlDB.CheckQueryResults(t, `SELECT id, s, d, ts, a, j, extra FROM dst ORDER BY id`,
+		sqlDB.QueryStr(t, `SELECT id, s, d, ts, a, j, 'x' FROM src ORDER BY id`))
+
+	t.Run("row-limit", func(t *testing.T) {
+		sqlDB.Exec(t, `CREATE TABLE limited (id INT PRIMARY KEY, s STRING)`)
+		sqlDB.Exec(t, `IMPORT INTO limited PARQUET DATA ('nodelocal://1/src/*') WITH row_limit = '2'`)
+		sqlDB.CheckQueryResults(t, `SELECT count(*) FROM limited`, [][]string{{"2"}})
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		sqlDB.Exec(t, `CREATE TABLE narrow (id INT PRIMARY KEY, s STRING)`)
+		sqlDB.ExpectErr(t, "could not find column for parquet column d",
+			`IMPORT INTO narrow PARQUET DATA ('nodelocal://1/src/*') WITH strict_validation`)
+		sqlDB.ExpectErr(t, `decompress option is not supported for PARQUET import format`,
+			`IMPORT INTO narrow PARQUET DATA ('nodelocal://1/src/*') WITH decompress = 'gzip'`)
+	})
+}
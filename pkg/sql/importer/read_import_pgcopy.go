@@ -74,8 +74,9 @@ func (d *pgCopyReader) readFiles(
 	format roachpb.IOFileFormat,
 	makeExternalStorage cloud.ExternalStorageFactory,
 	user username.SQLUsername,
+	rejects *rejectedRows,
 ) error {
-	return readInputFiles(ctx, dataFiles, resumePos, format, d.readFile, makeExternalStorage, user)
+	return readInputFiles(ctx, dataFiles, resumePos, format, d.readFile, makeExternalStorage, user, rejects)
 }
 
 type postgreStreamCopy struct {
@@ -346,7 +347,7 @@ func (p *pgCopyConsumer) FillDatums(
 }
 
 func (d *pgCopyReader) readFile(
-	ctx context.Context, input *fileReader, inputIdx int32, resumePos int64, rejected chan string,
+	ctx context.Context, input *fileReader, inputIdx int32, resumePos int64, rejected chan *importRowError,
 ) error {
 	s := bufio.NewScanner(input)
 	s.Split(bufio.ScanLines)
@@ -627,9 +627,6 @@ func readPostgresStmt(
 			schemaObjects.createTbl[schemaQualifiedName] = nil
 		}
 	case *tree.CreateIndex:
-		if stmt.Predicate != nil {
-			return unimplemented.NewWithIssue(50225, "cannot import a table with partial indexes")
-		}
 		schemaQualifiedTableName, err := getSchemaAndTableName(&stmt.Table)
 		if err != nil {
 			return err
@@ -645,6 +642,7 @@ func readPostgresStmt(
 			Inverted:         stmt.Inverted,
 			PartitionByIndex: stmt.PartitionByIndex,
 			StorageParams:    stmt.StorageParams,
+			Predicate:        stmt.Predicate,
 			// Postgres doesn't support NotVisible Index, so NotVisible is not populated here.
 		}
 		if stmt.Unique {
@@ -1033,13 +1031,14 @@ func (m *pgDumpReader) readFiles(
 	format roachpb.IOFileFormat,
 	makeExternalStorage cloud.ExternalStorageFactory,
 	user username.SQLUsername,
+	rejects *rejectedRows,
 ) error {
 	// Setup logger to handle unsupported DML statements seen in the PGDUMP file.
 	m.unsupportedStmtLogger = makeUnsupportedStmtLogger(ctx, user,
 		m.jobID, format.PgDump.IgnoreUnsupported, format.PgDump.IgnoreUnsupportedLog, dataIngestion,
 		makeExternalStorage)
 
-	err := readInputFiles(ctx, dataFiles, resumePos, format, m.readFile, makeExternalStorage, user)
+	err := readInputFiles(ctx, dataFiles, resumePos, format, m.readFile, makeExternalStorage, user, rejects)
 	if err != nil {
 		return err
 	}
@@ -1054,13 +1053,14 @@ func wrapErrorWithUnsupportedHint(err error) error {
 }
 
 func (m *pgDumpReader) readFile(
-	ctx context.Context, input *fileReader, inputIdx int32, resumePos int64, rejected chan string,
+	ctx context.Context, input *fileReader, inputIdx int32, resumePos int64, rejected chan *importRowError,
 ) error {
 	tableNameToRowsProcessed := make(map[string]int64)
 	var inserts, count int64
 	rowLimit := m.opts.RowLimit
 	ps := newPostgreStream(ctx, input, int(m.opts.MaxRowSize), m.unsupportedStmtLogger)
 	semaCtx := tree.MakeSemaContext(nil /* resolver */)
+	fileCtx := &importFileContext{source: inputIdx, rejected: rejected}
 	for _, conv := range m.tables {
 		conv.KvBatch.Source = inputIdx
 		conv.FractionFn = input.ReadFraction
@@ -1147,6 +1147,7 @@ func (m *pgDumpReader) readFile(
 					}
 				}
 			}
+		insertRows:
 			for _, tuple := range values.Rows {
 				count++
 				tableNameToRowsProcessed[name.String()]++
@@ -1157,7 +1158,11 @@ func (m *pgDumpReader) readFile(
 					break
 				}
 				if got := len(tuple); expectedColLen != got {
-					return errors.Errorf("expected %d values, got %d: %v", expectedColLen, got, tuple)
+					err := errors.Errorf("expected %d values, got %d: %v", expectedColLen, got, tuple)
+					if err := rejectRow(ctx, fileCtx, err, tree.AsString(&tuple), count); err != nil {
+						return err
+					}
+					continue
 				}
 				for j, expr := range tuple {
 					idx := j
@@ -1165,19 +1170,22 @@ func (m *pgDumpReader) readFile(
 						idx = targetColMapIdx[j]
 					}
 					typed, err := expr.TypeCheck(ctx, &semaCtx, conv.VisibleColTypes[idx])
-					if err != nil {
-						return errors.Wrapf(err, "reading row %d (%d in insert statement %d)",
-							count, count-startingCount, inserts)
+					if err == nil {
+						conv.Datums[idx], err = eval.Expr(ctx, conv.EvalCtx, typed)
 					}
-					converted, err := eval.Expr(ctx, conv.EvalCtx, typed)
 					if err != nil {
-						return errors.Wrapf(err, "reading row %d (%d in insert statement %d)",
+						err = errors.Wrapf(err, "reading row %d (%d in insert statement %d)",
 							count, count-startingCount, inserts)
+						if err := rejectRow(ctx, fileCtx, err, tree.AsString(&tuple), count); err != nil {
+							return err
+						}
+						continue insertRows
 					}
-					conv.Datums[idx] = converted
 				}
-				if err := conv.Row(ctx, inputIdx, count+int64(timestamp)); err != nil {
-					return err
+				if err := convertRow(ctx, conv, inputIdx, count+int64(timestamp)); err != nil {
+					if err := rejectRow(ctx, fileCtx, err, tree.AsString(&tuple), count); err != nil {
+						return err
+					}
 				}
 			}
 		case *tree.CopyFrom:
@@ -1238,30 +1246,39 @@ func (m *pgDumpReader) readFile(
 				switch row := row.(type) {
 				case copyData:
 					if expected, got := conv.TargetColOrds.Len(), len(row); expected != got {
-						return makeRowErr(count, pgcode.Syntax,
+						err := makeRowErr(count, pgcode.Syntax,
 							"expected %d values, got %d", expected, got)
+						if err := rejectRow(ctx, fileCtx, err, row.String(), count); err != nil {
+							return err
+						}
+						continue
 					}
 					if rowLimit != 0 && tableNameToRowsProcessed[name.String()] > rowLimit {
 						break
 					}
-					for i, s := range row {
-						idx := targetColMapIdx[i]
-						if s == nil {
-							conv.Datums[idx] = tree.DNull
-						} else {
-							// We use ParseAndRequireString instead of ParseDatumStringAs
-							// because postgres dumps arrays in COPY statements using their
-							// internal string representation.
-							conv.Datums[idx], _, err = tree.ParseAndRequireString(conv.VisibleColTypes[idx], *s, conv.EvalCtx)
-							if err != nil {
-								col := conv.VisibleCols[idx]
-								return wrapRowErr(err, count, pgcode.Syntax,
-									"parse %q as %s", col.GetName(), col.GetType().SQLString())
+					if err := func() error {
+						for i, s := range row {
+							idx := targetColMapIdx[i]
+							if s == nil {
+								conv.Datums[idx] = tree.DNull
+							} else {
+								// We use ParseAndRequireString instead of ParseDatumStringAs
+								// because postgres dumps arrays in COPY statements using their
+								// internal string representation.
+								var err error
+								conv.Datums[idx], _, err = tree.ParseAndRequireString(conv.VisibleColTypes[idx], *s, conv.EvalCtx)
+								if err != nil {
+									col := conv.VisibleCols[idx]
+									return wrapRowErr(err, count, pgcode.Syntax,
+										"parse %q as %s", col.GetName(), col.GetType().SQLString())
+								}
 							}
 						}
-					}
-					if err := conv.Row(ctx, inputIdx, count); err != nil {
-						return err
+						return convertRow(ctx, conv, inputIdx, count)
+					}(); err != nil {
+						if err := rejectRow(ctx, fileCtx, err, row.String(), count); err != nil {
+							return err
+						}
 					}
 				default:
 					return makeRowErr(count, pgcode.Uncategorized,
@@ -132,6 +132,7 @@ func (w *workloadReader) readFiles(
 	_ roachpb.IOFileFormat,
 	_ cloud.ExternalStorageFactory,
 	_ username.SQLUsername,
+	_ *rejectedRows,
 ) error {
 
 	wcs := make([]*WorkloadKVConverter, 0, len(dataFiles))
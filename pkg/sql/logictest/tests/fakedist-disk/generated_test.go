@@ -2558,6 +2558,13 @@ func TestLogic_with(
 	runLogicTest(t, "with")
 }
 
+func TestLogic_workload_index_advice(
+	t *testing.T,
+) {
+	defer leaktest.AfterTest(t)()
+	runLogicTest(t, "workload_index_advice")
+}
+
 func TestLogic_workload_indexrecs(
 	t *testing.T,
 ) {
@@ -422,6 +422,7 @@ func init() {
 		&tree.AlterTenantReset{},
 		&tree.Backup{},
 		&tree.ShowBackup{},
+		&tree.ShowChangefeedSpans{},
 		&tree.Restore{},
 		&tree.CreateChangefeed{},
 		&tree.ScheduledChangefeed{},
@@ -0,0 +1,563 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package workloadindexrec
+
+import (
+	"context"
+	"sort"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/catconstants"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/eval"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/errors"
+)
+
+// IndexAdvice is a single recommendation made by the workload index advisor,
+// along with its estimated impact on the cost of the workload.
+//
+// The costs are expressed in the units of the optimizer's cost model.
+// ReadCostDelta is the change in the cost of the workload's statements that
+// benefit from the recommendation, estimated by optimizing each of them with
+// the recommended index added hypothetically. WriteCostDelta is the cost of the
+// index writes that the recommendation adds (positive) or removes (negative),
+// estimated from the number of rows written by the workload's mutations.
+type IndexAdvice struct {
+	// Stmt is a CREATE INDEX, DROP INDEX or ALTER INDEX statement.
+	Stmt           string
+	ReadCostDelta  float64
+	WriteCostDelta float64
+}
+
+// indexRowWriteCost is the estimated cost of writing a single row to a
+// secondary index. Every written row results in a KV write, which is costed
+// like a random I/O in the optimizer's cost model.
+const indexRowWriteCost = 4
+
+// workloadStmt is a statement fingerprint of the workload.
+type workloadStmt struct {
+	query string
+	// database is the current database the statement was executed in.
+	database string
+	// count is the number of executions of the statement.
+	count int64
+}
+
+// tableWrite is the estimated number of rows written to a table by a mutation
+// statement of the workload.
+type tableWrite struct {
+	table tree.TableName
+	// updatedColumns are the columns assigned by an UPDATE statement. It is nil
+	// for the other mutations, which write every index of the table.
+	updatedColumns []tree.Name
+	// rows is the number of rows written by all executions of the statement.
+	rows float64
+}
+
+// existingIndex describes a secondary index that already exists, along with
+// whether it was used by the workload.
+type existingIndex struct {
+	table   tree.TableName
+	name    tree.Name
+	columns []indexedColumn
+	storing []tree.Name
+	// droppable is false for the indexes that the advisor never recommends
+	// dropping: unique, partial, inverted, expression and hash-sharded
+	// indexes.
+	droppable bool
+	visible   bool
+	// used is true if the index was read by the workload.
+	used bool
+}
+
+// FindWorkloadAdvice finds index recommendations for the whole workload after
+// the timestamp ts. Unlike FindWorkloadRecs, it weighs the write amplification
+// of each index against the reads that benefit from it, and it recommends
+// dropping unused and redundant indexes as well as making invisible indexes
+// visible instead of creating equivalent ones. The recommendations are ranked
+// from the most to the least beneficial.
+func FindWorkloadAdvice(
+	ctx context.Context, evalCtx *eval.Context, ts *tree.DTimestampTZ,
+) ([]IndexAdvice, error) {
+	cis, stmts, dis, err := collectIndexRecs(ctx, evalCtx, ts)
+	if err != nil {
+		return nil, err
+	}
+	trieMap := buildTrieForIndexRecs(cis)
+	newCis, err := extractIndexCovering(trieMap)
+	if err != nil {
+		return nil, err
+	}
+	existing, tables, err := collectExistingIndexes(ctx, evalCtx, ts)
+	if err != nil {
+		return nil, err
+	}
+	writes, err := collectTableWrites(ctx, evalCtx, ts, tables)
+	if err != nil {
+		return nil, err
+	}
+	readCostDelta := func(stmt workloadStmt, ci *tree.CreateIndex) (float64, bool) {
+		cost, hypCost, _, err := evalCtx.Planner.EstimateStatementCost(
+			ctx, stmt.query, stmt.database, []tree.CreateIndex{*ci},
+		)
+		if err != nil {
+			// Not every fingerprint can be planned again, e.g. if the schema
+			// changed since it was executed. Such statements are not counted.
+			return 0, false
+		}
+		return hypCost - cost, true
+	}
+	return adviseIndexes(cis, stmts, newCis, dis, existing, writes, readCostDelta), nil
+}
+
+// collectExistingIndexes collects the secondary indexes of all the user
+// tables along with their usage after the timestamp ts. It also returns the
+// set of all the user tables.
+func collectExistingIndexes(
+	ctx context.Context, evalCtx *eval.Context, ts *tree.DTimestampTZ,
+) (_ []existingIndex, tables map[tree.TableName]struct{}, retErr error) {
+	query := `SELECT t.database_name, t.schema_name, ti.descriptor_name, ti.index_name,
+						 ti.index_type = 'secondary', ti.create_statement, ti.is_visible,
+						 COALESCE(us.last_read > $1, false)
+						 FROM "".crdb_internal.table_indexes AS ti
+						 JOIN "".crdb_internal.tables AS t ON ti.descriptor_id = t.table_id
+						 LEFT JOIN "".crdb_internal.index_usage_statistics AS us
+						 ON ti.descriptor_id = us.table_id AND ti.index_id = us.index_id
+						 WHERE t.drop_time IS NULL AND t.database_name IS NOT NULL
+						 AND t.database_name != 'system';`
+	it, err := evalCtx.Planner.QueryIteratorEx(ctx, "get-existing-indexes-for-workload-advice",
+		sessiondata.NoSessionDataOverride, query, ts.Time)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		retErr = errors.CombineErrors(retErr, it.Close())
+	}()
+
+	var p parser.Parser
+	var res []existingIndex
+	tables = make(map[tree.TableName]struct{})
+	var ok bool
+	for ok, err = it.Next(ctx); ok; ok, err = it.Next(ctx) {
+		row := it.Cur()
+		table := tree.MakeTableNameWithSchema(
+			tree.Name(tree.MustBeDString(row[0])),
+			tree.Name(tree.MustBeDString(row[1])),
+			tree.Name(tree.MustBeDString(row[2])),
+		)
+		tables[table] = struct{}{}
+		if !bool(tree.MustBeDBool(row[4])) {
+			continue
+		}
+		createStmt := string(tree.MustBeDString(row[5]))
+		stmt, err := p.ParseOne(createStmt)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "%s is not a valid index definition", createStmt)
+		}
+		ci, ok := stmt.AST.(*tree.CreateIndex)
+		if !ok {
+			return nil, nil, errors.AssertionFailedf("%s is not a CREATE INDEX statement", createStmt)
+		}
+		idx := existingIndex{
+			table:     table,
+			name:      tree.Name(tree.MustBeDString(row[3])),
+			columns:   make([]indexedColumn, len(ci.Columns)),
+			storing:   ci.Storing,
+			droppable: !ci.Unique && !ci.Inverted && ci.Predicate == nil && ci.Sharded == nil,
+			visible:   bool(tree.MustBeDBool(row[6])),
+			used:      bool(tree.MustBeDBool(row[7])),
+		}
+		for i, col := range ci.Columns {
+			idx.columns[i] = makeIndexedColumn(col)
+			if col.Expr != nil {
+				idx.droppable = false
+			}
+		}
+		res = append(res, idx)
+	}
+	return res, tables, err
+}
+
+// collectTableWrites estimates the number of rows written to each table after
+// the timestamp ts from the mutation statements stored in
+// system.statement_statistics. The tables referenced by the statements are
+// resolved against the given set of tables.
+func collectTableWrites(
+	ctx context.Context,
+	evalCtx *eval.Context,
+	ts *tree.DTimestampTZ,
+	tables map[tree.TableName]struct{},
+) ([]tableWrite, error) {
+	query := `SELECT metadata ->> 'query', COALESCE(metadata ->> 'db', ''),
+						 COALESCE((statistics -> 'statistics' ->> 'cnt')::INT8, 1)
+						 FROM system.statement_statistics
+						 WHERE (statistics -> 'statistics' ->> 'lastExecAt')::TIMESTAMPTZ > $1
+						 AND metadata ->> 'stmtType' = 'TypeDML';`
+	it, err := evalCtx.Planner.QueryIteratorEx(ctx, "get-table-writes-for-workload-advice",
+		sessiondata.NoSessionDataOverride, query, ts.Time)
+	if err != nil {
+		return nil, err
+	}
+
+	// The statements are buffered so that the iterator is closed before they
+	// are planned below.
+	var p parser.Parser
+	var stmts []workloadStmt
+	var writes []tableWrite
+	var ok bool
+	for ok, err = it.Next(ctx); ok; ok, err = it.Next(ctx) {
+		row := it.Cur()
+		if row[0] == tree.DNull {
+			continue
+		}
+		stmt := workloadStmt{
+			query:    string(tree.MustBeDString(row[0])),
+			database: string(tree.MustBeDString(row[1])),
+			count:    int64(tree.MustBeDInt(row[2])),
+		}
+		// Statement fingerprints are not always valid SQL, so we skip the
+		// statements that cannot be parsed.
+		parsed, parseErr := p.ParseOne(stmt.query)
+		if parseErr != nil {
+			continue
+		}
+		table, updatedColumns, ok := mutatedTable(parsed.AST)
+		if !ok {
+			continue
+		}
+		stmts = append(stmts, stmt)
+		writes = append(writes, tableWrite{
+			table:          qualifyTableName(table, stmt.database, tables),
+			updatedColumns: updatedColumns,
+		})
+	}
+	if err != nil {
+		return nil, errors.CombineErrors(err, it.Close())
+	}
+	if err := it.Close(); err != nil {
+		return nil, err
+	}
+
+	for i, stmt := range stmts {
+		rows := 1.0
+		if _, _, rowCount, err := evalCtx.Planner.EstimateStatementCost(
+			ctx, stmt.query, stmt.database, nil, /* hypotheticalIndexes */
+		); err == nil {
+			rows = rowCount
+		}
+		// If the statement cannot be planned again, it is assumed to write a
+		// single row per execution.
+		writes[i].rows = rows * float64(stmt.count)
+	}
+	return writes, nil
+}
+
+// mutatedTable returns the name of the table written by the given mutation
+// statement. For an UPDATE statement, it also returns the updated columns.
+func mutatedTable(stmt tree.Statement) (_ tree.TableName, updatedColumns []tree.Name, ok bool) {
+	var table tree.TableExpr
+	switch stmt := stmt.(type) {
+	case *tree.Insert:
+		table = stmt.Table
+	case *tree.Update:
+		table = stmt.Table
+		updatedColumns = []tree.Name{}
+		for _, expr := range stmt.Exprs {
+			updatedColumns = append(updatedColumns, expr.Names...)
+		}
+	case *tree.Delete:
+		table = stmt.Table
+	default:
+		return tree.TableName{}, nil, false
+	}
+	if aliased, ok := table.(*tree.AliasedTableExpr); ok {
+		table = aliased.Expr
+	}
+	if tn, ok := table.(*tree.TableName); ok {
+		return *tn, updatedColumns, true
+	}
+	return tree.TableName{}, nil, false
+}
+
+// qualifyTableName returns the fully qualified name of the table that tn
+// refers to in a statement executed in the given database. Since the search
+// path of the statement is not recorded, an unqualified name is assumed to be
+// in the public schema, and a two-part name is resolved as schema.table if
+// such a table exists in the database and as database.table otherwise.
+func qualifyTableName(
+	tn tree.TableName, database string, tables map[tree.TableName]struct{},
+) tree.TableName {
+	switch {
+	case tn.ExplicitCatalog:
+		return tree.MakeTableNameWithSchema(tn.CatalogName, tn.SchemaName, tn.ObjectName)
+	case tn.ExplicitSchema:
+		qualified := tree.MakeTableNameWithSchema(tree.Name(database), tn.SchemaName, tn.ObjectName)
+		if _, ok := tables[qualified]; ok {
+			return qualified
+		}
+		return tree.MakeTableNameWithSchema(tn.SchemaName, catconstants.PublicSchemaName, tn.ObjectName)
+	default:
+		return tree.MakeTableNameWithSchema(tree.Name(database), catconstants.PublicSchemaName, tn.ObjectName)
+	}
+}
+
+// makeIndexedColumn converts an index element to an indexedColumn, normalizing
+// the default direction to ascending.
+func makeIndexedColumn(elem tree.IndexElem) indexedColumn {
+	col := indexedColumn{column: elem.Column, direction: elem.Direction}
+	if col.direction == tree.DefaultDirection {
+		col.direction = tree.Ascending
+	}
+	return col
+}
+
+// indexCovers returns whether the index with the given indexed and storing
+// columns can serve every read served by the other index: the other index's
+// indexed columns must be a prefix of the indexed columns, and all of its
+// stored columns must be available in the index.
+func indexCovers(
+	columns []indexedColumn, storing []tree.Name, otherColumns []indexedColumn, otherStoring []tree.Name,
+) bool {
+	if len(otherColumns) > len(columns) {
+		return false
+	}
+	for i := range otherColumns {
+		if otherColumns[i] != columns[i] {
+			return false
+		}
+	}
+	available := make(map[tree.Name]struct{}, len(columns)+len(storing))
+	for _, col := range columns {
+		available[col.column] = struct{}{}
+	}
+	for _, col := range storing {
+		available[col] = struct{}{}
+	}
+	for _, col := range otherStoring {
+		if _, ok := available[col]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// indexWriteCost returns the cost of maintaining the index with the given
+// indexed and storing columns of the table under the workload's writes. An
+// UPDATE only writes the index if it assigns one of the index's columns.
+func indexWriteCost(
+	writes []tableWrite, table tree.TableName, columns []indexedColumn, storing []tree.Name,
+) float64 {
+	var cost float64
+	for i := range writes {
+		w := &writes[i]
+		if w.table != table {
+			continue
+		}
+		if w.updatedColumns != nil && !writesIndex(w.updatedColumns, columns, storing) {
+			continue
+		}
+		cost += w.rows * indexRowWriteCost
+	}
+	return cost
+}
+
+// writesIndex returns whether updating the given columns writes the index
+// with the given indexed and storing columns.
+func writesIndex(updatedColumns []tree.Name, columns []indexedColumn, storing []tree.Name) bool {
+	for _, updated := range updatedColumns {
+		for _, col := range columns {
+			if col.column == updated {
+				return true
+			}
+		}
+		for _, col := range storing {
+			if col == updated {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func createIndexColumns(ci *tree.CreateIndex) []indexedColumn {
+	cols := make([]indexedColumn, len(ci.Columns))
+	for i, col := range ci.Columns {
+		cols[i] = makeIndexedColumn(col)
+	}
+	return cols
+}
+
+// createIndexFor returns the CREATE INDEX statement of an index with the same
+// columns as the given existing index.
+func createIndexFor(idx *existingIndex) tree.CreateIndex {
+	ci := tree.CreateIndex{
+		Table:   idx.table,
+		Columns: make(tree.IndexElemList, len(idx.columns)),
+		Storing: idx.storing,
+	}
+	for i, col := range idx.columns {
+		ci.Columns[i] = tree.IndexElem{Column: col.column, Direction: col.direction}
+	}
+	return ci
+}
+
+// adviseIndexes builds the ranked index advice for the workload:
+//
+//   - origCis are the CREATE INDEX recommendations of individual statements
+//     and stmts the statements that they were made for.
+//   - newCis are the consolidated CREATE INDEX recommendations.
+//   - dis are the DROP INDEX recommendations of individual statements.
+//   - existing are the existing secondary indexes.
+//   - writes are the rows written by the workload's mutations.
+//   - readCostDelta estimates the change in the cost of a single execution of
+//     a statement if the given index is created. It returns false if the cost
+//     cannot be estimated.
+//
+// All the table names must be fully qualified, which is the case of the
+// recommendations made by the optimizer.
+func adviseIndexes(
+	origCis []tree.CreateIndex,
+	stmts []workloadStmt,
+	newCis []tree.CreateIndex,
+	dis []tree.DropIndex,
+	existing []existingIndex,
+	writes []tableWrite,
+	readCostDelta func(stmt workloadStmt, ci *tree.CreateIndex) (float64, bool),
+) []IndexAdvice {
+	var res []IndexAdvice
+	dropped := make([]bool, len(existing))
+	drop := func(i int) {
+		dropped[i] = true
+		idx := &existing[i]
+		dropCmd := tree.DropIndex{IndexList: tree.TableIndexNames{{
+			Table: idx.table,
+			Index: tree.UnrestrictedName(idx.name),
+		}}}
+		// Dropped indexes are either unused or covered by another index that
+		// serves their reads, so dropping them does not change the read cost.
+		advice := IndexAdvice{Stmt: dropCmd.String() + ";"}
+		if cost := indexWriteCost(writes, idx.table, idx.columns, idx.storing); cost > 0 {
+			advice.WriteCostDelta = -cost
+		}
+		res = append(res, advice)
+	}
+
+	for i := range newCis {
+		ci := &newCis[i]
+		table := ci.Table
+		cols := createIndexColumns(ci)
+
+		// If an invisible index already serves the same reads, it is cheaper
+		// to make it visible than to create a new one.
+		alter := -1
+		for j := range existing {
+			idx := &existing[j]
+			if idx.table == table && !idx.visible && !dropped[j] &&
+				indexCovers(idx.columns, idx.storing, cols, ci.Storing) {
+				alter = j
+				break
+			}
+		}
+		target := ci
+		if alter >= 0 {
+			alterCi := createIndexFor(&existing[alter])
+			target = &alterCi
+		}
+
+		// Every statement whose recommended index is covered by the new index
+		// benefits from it.
+		var readDelta float64
+		seen := make(map[workloadStmt]struct{})
+		for j := range origCis {
+			if origCis[j].Table != table ||
+				!indexCovers(cols, ci.Storing, createIndexColumns(&origCis[j]), origCis[j].Storing) {
+				continue
+			}
+			if _, ok := seen[stmts[j]]; ok {
+				continue
+			}
+			seen[stmts[j]] = struct{}{}
+			if delta, ok := readCostDelta(stmts[j], target); ok {
+				readDelta += delta * float64(stmts[j].count)
+			}
+		}
+
+		if alter >= 0 {
+			alterCmd := tree.AlterIndexVisible{
+				Index: tree.TableIndexName{
+					Table: table,
+					Index: tree.UnrestrictedName(existing[alter].name),
+				},
+			}
+			// Invisible indexes are already maintained by writes.
+			res = append(res, IndexAdvice{Stmt: alterCmd.String() + ";", ReadCostDelta: readDelta})
+			// The altered index must not be dropped below.
+			existing[alter].used = true
+			existing[alter].visible = true
+			continue
+		}
+		res = append(res, IndexAdvice{
+			Stmt:           ci.String() + ";",
+			ReadCostDelta:  readDelta,
+			WriteCostDelta: indexWriteCost(writes, table, cols, ci.Storing),
+		})
+
+		// Existing indexes that are covered by the new index are consolidated
+		// into it.
+		for j := range existing {
+			idx := &existing[j]
+			if idx.table == table && idx.droppable && !dropped[j] &&
+				indexCovers(cols, ci.Storing, idx.columns, idx.storing) {
+				drop(j)
+			}
+		}
+	}
+
+	// Drop the indexes that the individual statement recommendations replace.
+	for _, di := range dis {
+		for _, index := range di.IndexList {
+			for j := range existing {
+				if existing[j].table == index.Table &&
+					existing[j].name == tree.Name(index.Index) && !dropped[j] {
+					drop(j)
+				}
+			}
+		}
+	}
+
+	// Drop the indexes that are either redundant with another existing index
+	// or were not used by the workload.
+	for i := range existing {
+		idx := &existing[i]
+		if !idx.droppable || dropped[i] {
+			continue
+		}
+		redundant := false
+		for j := range existing {
+			other := &existing[j]
+			if i != j && other.table == idx.table && other.visible && !dropped[j] &&
+				indexCovers(other.columns, other.storing, idx.columns, idx.storing) {
+				redundant = true
+				break
+			}
+		}
+		if redundant || !idx.used {
+			drop(i)
+		}
+	}
+
+	// Rank the recommendations from the most to the least beneficial.
+	sort.SliceStable(res, func(i, j int) bool {
+		ci := res[i].ReadCostDelta + res[i].WriteCostDelta
+		cj := res[j].ReadCostDelta + res[j].WriteCostDelta
+		if ci != cj {
+			return ci < cj
+		}
+		return res[i].Stmt < res[j].Stmt
+	})
+	return res
+}
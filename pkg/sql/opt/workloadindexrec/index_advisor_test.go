@@ -0,0 +1,214 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package workloadindexrec
+
+import (
+	"testing"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdviseIndexes(t *testing.T) {
+	table := tree.MakeTableNameWithSchema("db", "public", "t")
+	otherTable := tree.MakeTableNameWithSchema("db", "sc", "t")
+	createIndex := func(cols []tree.Name, storing ...tree.Name) tree.CreateIndex {
+		ci := tree.CreateIndex{Table: table, Storing: storing}
+		for _, col := range cols {
+			ci.Columns = append(ci.Columns, tree.IndexElem{Column: col, Direction: tree.DefaultDirection})
+		}
+		return ci
+	}
+	asc := func(cols ...tree.Name) []indexedColumn {
+		res := make([]indexedColumn, len(cols))
+		for i, col := range cols {
+			res[i] = indexedColumn{column: col, direction: tree.Ascending}
+		}
+		return res
+	}
+	stmt := func(query string, count int64) workloadStmt {
+		return workloadStmt{query: query, database: "db", count: count}
+	}
+	// Every index write costs 2.5 rows * indexRowWriteCost. The UPDATE of the
+	// column z does not write any of the indexes, and the writes to db.sc.t do
+	// not write the indexes of db.public.t.
+	writes := []tableWrite{
+		{table: table, rows: 2.5},
+		{table: table, updatedColumns: []tree.Name{"z"}, rows: 100},
+		{table: otherTable, rows: 100},
+	}
+	// Every statement benefits from the hypothetical index by a cost of 1 per
+	// execution, except the statements that cannot be planned.
+	readCostDelta := func(stmt workloadStmt, _ *tree.CreateIndex) (float64, bool) {
+		if stmt.query == "unplannable" {
+			return 0, false
+		}
+		return -1, true
+	}
+
+	testCases := []struct {
+		origCis  []tree.CreateIndex
+		stmts    []workloadStmt
+		newCis   []tree.CreateIndex
+		dis      []tree.DropIndex
+		existing []existingIndex
+		expected []IndexAdvice
+	}{
+		{
+			// 0:
+			// The new index (a) STORING (b) consolidates the existing index (a),
+			// the index (c) is unused, and the index (d) is redundant with the
+			// index (d, e). The unique index (f) is never dropped.
+			origCis: []tree.CreateIndex{
+				createIndex([]tree.Name{"a"}),
+				createIndex([]tree.Name{"a"}, "b"),
+			},
+			stmts: []workloadStmt{stmt("q1", 5), stmt("q2", 3)},
+			newCis: []tree.CreateIndex{
+				createIndex([]tree.Name{"a"}, "b"),
+			},
+			existing: []existingIndex{
+				{table: table, name: "t_a_idx", columns: asc("a"), droppable: true, visible: true, used: true},
+				{table: table, name: "t_c_idx", columns: asc("c"), droppable: true, visible: true},
+				{table: table, name: "t_d_idx", columns: asc("d"), droppable: true, visible: true, used: true},
+				{table: table, name: "t_d_e_idx", columns: asc("d", "e"), droppable: true, visible: true, used: true},
+				{table: table, name: "t_f_key", columns: asc("f"), visible: true},
+			},
+			expected: []IndexAdvice{
+				{Stmt: "DROP INDEX db.public.t@t_a_idx;", WriteCostDelta: -10},
+				{Stmt: "DROP INDEX db.public.t@t_c_idx;", WriteCostDelta: -10},
+				{Stmt: "DROP INDEX db.public.t@t_d_idx;", WriteCostDelta: -10},
+				{Stmt: "CREATE INDEX ON db.public.t (a) STORING (b);", ReadCostDelta: -8, WriteCostDelta: 10},
+			},
+		},
+		{
+			// 1:
+			// An invisible index already covers the recommended index, so it is
+			// made visible instead. A statement recommended the same index twice
+			// is only counted once, and a statement that cannot be planned is not
+			// counted.
+			origCis: []tree.CreateIndex{
+				createIndex([]tree.Name{"a"}),
+				createIndex([]tree.Name{"a"}),
+				createIndex([]tree.Name{"a"}),
+			},
+			stmts: []workloadStmt{stmt("q1", 20), stmt("q1", 20), stmt("unplannable", 7)},
+			newCis: []tree.CreateIndex{
+				createIndex([]tree.Name{"a"}),
+			},
+			existing: []existingIndex{
+				{table: table, name: "t_a_b_idx", columns: asc("a", "b"), droppable: true},
+			},
+			expected: []IndexAdvice{
+				{Stmt: "ALTER INDEX db.public.t@t_a_b_idx VISIBLE;", ReadCostDelta: -20},
+			},
+		},
+		{
+			// 2:
+			// An index dropped by a statement recommendation is reported once.
+			dis: []tree.DropIndex{{IndexList: tree.TableIndexNames{{Table: table, Index: "t_a_idx"}}}},
+			existing: []existingIndex{
+				{table: table, name: "t_a_idx", columns: asc("a"), droppable: true, visible: true},
+			},
+			expected: []IndexAdvice{
+				{Stmt: "DROP INDEX db.public.t@t_a_idx;", WriteCostDelta: -10},
+			},
+		},
+		{
+			// 3:
+			// Tables with the same name in different schemas are distinct: the
+			// index of db.sc.t is neither consolidated into the new index of
+			// db.public.t nor does it make the new index redundant.
+			origCis: []tree.CreateIndex{
+				createIndex([]tree.Name{"a"}),
+			},
+			stmts: []workloadStmt{stmt("q1", 4)},
+			newCis: []tree.CreateIndex{
+				createIndex([]tree.Name{"a"}),
+			},
+			existing: []existingIndex{
+				{table: otherTable, name: "t_a_idx", columns: asc("a"), droppable: true, visible: true, used: true},
+				{table: otherTable, name: "t_a_b_idx", columns: asc("a", "b"), droppable: true, used: true},
+			},
+			expected: []IndexAdvice{
+				{Stmt: "CREATE INDEX ON db.public.t (a);", ReadCostDelta: -4, WriteCostDelta: 10},
+			},
+		},
+	}
+
+	for i, tc := range testCases {
+		res := adviseIndexes(tc.origCis, tc.stmts, tc.newCis, tc.dis, tc.existing, writes, readCostDelta)
+		require.Equal(t, tc.expected, res, "test case %d", i)
+	}
+}
+
+func TestMutatedTable(t *testing.T) {
+	testCases := []struct {
+		stmt           tree.Statement
+		expected       tree.TableName
+		updatedColumns []tree.Name
+		ok             bool
+	}{
+		{
+			stmt:     &tree.Insert{Table: tree.NewUnqualifiedTableName("t")},
+			expected: tree.MakeUnqualifiedTableName("t"),
+			ok:       true,
+		},
+		{
+			stmt: &tree.Update{
+				Table: &tree.AliasedTableExpr{
+					Expr: tree.NewUnqualifiedTableName("u"),
+					As:   tree.AliasClause{Alias: "x"},
+				},
+				Exprs: tree.UpdateExprs{{Names: tree.NameList{"a", "b"}}},
+			},
+			expected:       tree.MakeUnqualifiedTableName("u"),
+			updatedColumns: []tree.Name{"a", "b"},
+			ok:             true,
+		},
+		{
+			stmt: &tree.Select{},
+		},
+	}
+	for _, tc := range testCases {
+		table, updatedColumns, ok := mutatedTable(tc.stmt)
+		require.Equal(t, tc.ok, ok)
+		require.Equal(t, tc.expected, table)
+		require.Equal(t, tc.updatedColumns, updatedColumns)
+	}
+}
+
+func TestQualifyTableName(t *testing.T) {
+	tables := map[tree.TableName]struct{}{
+		tree.MakeTableNameWithSchema("db", "sc", "t"): {},
+	}
+	testCases := []struct {
+		tn       tree.TableName
+		expected tree.TableName
+	}{
+		{
+			tn:       tree.MakeUnqualifiedTableName("t"),
+			expected: tree.MakeTableNameWithSchema("db", "public", "t"),
+		},
+		{
+			tn:       tree.MakeTableNameWithSchema("other", "sc", "t"),
+			expected: tree.MakeTableNameWithSchema("other", "sc", "t"),
+		},
+		{
+			// sc.t is a table of the current database.
+			tn:       tree.MakeTableNameFromPrefix(tree.ObjectNamePrefix{SchemaName: "sc", ExplicitSchema: true}, "t"),
+			expected: tree.MakeTableNameWithSchema("db", "sc", "t"),
+		},
+		{
+			// other.t is a table of the public schema of another database.
+			tn:       tree.MakeTableNameFromPrefix(tree.ObjectNamePrefix{SchemaName: "other", ExplicitSchema: true}, "t"),
+			expected: tree.MakeTableNameWithSchema("other", "public", "t"),
+		},
+	}
+	for _, tc := range testCases {
+		require.Equal(t, tc.expected, qualifyTableName(tc.tn, "db", tables))
+	}
+}
@@ -21,7 +21,7 @@ import (
 func FindWorkloadRecs(
 	ctx context.Context, evalCtx *eval.Context, ts *tree.DTimestampTZ,
 ) ([]string, error) {
-	cis, dis, err := collectIndexRecs(ctx, evalCtx, ts)
+	cis, _ /* stmts */, dis, err := collectIndexRecs(ctx, evalCtx, ts)
 	if err != nil {
 		return nil, err
 	}
@@ -59,22 +59,26 @@ func FindWorkloadRecs(
 }
 
 // collectIndexRecs collects all the index recommendations stored in the
-// system.statement_statistics with the time later than ts.
+// system.statement_statistics with the time later than ts. For each CREATE
+// INDEX recommendation, it also returns the statement that the recommendation
+// was made for.
 func collectIndexRecs(
 	ctx context.Context, evalCtx *eval.Context, ts *tree.DTimestampTZ,
-) ([]tree.CreateIndex, []tree.DropIndex, error) {
-	query := `SELECT index_recommendations FROM system.statement_statistics
+) (cis []tree.CreateIndex, recStmts []workloadStmt, dis []tree.DropIndex, _ error) {
+	query := `SELECT index_recommendations,
+						 COALESCE((statistics -> 'statistics' ->> 'cnt')::INT8, 1),
+						 COALESCE(metadata ->> 'query', ''),
+						 COALESCE(metadata ->> 'db', '')
+						 FROM system.statement_statistics
 						 WHERE (statistics -> 'statistics' ->> 'lastExecAt')::TIMESTAMPTZ > $1
 						 AND array_length(index_recommendations, 1) > 0;`
 	indexRecs, err := evalCtx.Planner.QueryIteratorEx(ctx, "get-candidates-for-workload-indexrecs",
 		sessiondata.NoSessionDataOverride, query, ts.Time)
 	if err != nil {
-		return nil, nil, err
+		return nil, nil, nil, err
 	}
 
 	var p parser.Parser
-	var cis []tree.CreateIndex
-	var dis []tree.DropIndex
 	var ok bool
 
 	// The index recommendation starts with "creation", "replacement" or
@@ -85,7 +89,7 @@ func collectIndexRecs(
 		if err != nil {
 			err = errors.CombineErrors(err, indexRecs.Close())
 			indexRecs = nil
-			return cis, dis, err
+			return cis, recStmts, dis, err
 		}
 
 		if !ok {
@@ -93,19 +97,24 @@ func collectIndexRecs(
 		}
 
 		indexes := tree.MustBeDArray(indexRecs.Cur()[0])
+		recStmt := workloadStmt{
+			count:    int64(tree.MustBeDInt(indexRecs.Cur()[1])),
+			query:    string(tree.MustBeDString(indexRecs.Cur()[2])),
+			database: string(tree.MustBeDString(indexRecs.Cur()[3])),
+		}
 		for _, index := range indexes.Array {
 			indexStr, ok := index.(*tree.DString)
 			if !ok {
 				err = errors.CombineErrors(errors.Newf("%s is not a string!", index.String()), indexRecs.Close())
 				indexRecs = nil
-				return cis, dis, err
+				return cis, recStmts, dis, err
 			}
 
 			indexStrArr := r.FindStringSubmatch(string(*indexStr))
 			if indexStrArr == nil {
 				err = errors.CombineErrors(errors.Newf("%s is not a valid index recommendation!", string(*indexStr)), indexRecs.Close())
 				indexRecs = nil
-				return cis, dis, err
+				return cis, recStmts, dis, err
 			}
 
 			// Since Alter index recommendation only makes invisible indexes visible,
@@ -118,7 +127,7 @@ func collectIndexRecs(
 			if err != nil {
 				err = errors.CombineErrors(errors.Newf("%s is not a valid index operation!", indexStrArr[2]), indexRecs.Close())
 				indexRecs = nil
-				return cis, dis, err
+				return cis, recStmts, dis, err
 			}
 
 			for _, stmt := range stmts {
@@ -127,6 +136,7 @@ func collectIndexRecs(
 					// Ignore all the inverted, partial and sharded indexes right now.
 					if !stmt.Inverted && stmt.Predicate == nil && stmt.Sharded == nil {
 						cis = append(cis, *stmt)
+						recStmts = append(recStmts, recStmt)
 					}
 				case *tree.DropIndex:
 					dis = append(dis, *stmt)
@@ -135,7 +145,7 @@ func collectIndexRecs(
 		}
 	}
 
-	return cis, dis, nil
+	return cis, recStmts, dis, nil
 }
 
 // buildTrieForIndexRecs builds the relation among all the indexRecs by a trie tree.
@@ -1139,24 +1139,33 @@ func (ef *execFactory) ConstructProjectSet(
 
 // ConstructWindow is part of the exec.Factory interface.
 func (ef *execFactory) ConstructWindow(root exec.Node, wi exec.WindowInfo) (exec.Node, error) {
-	p := &windowNode{
+	funcs, err := makeWindowFuncHolders(wi)
+	if err != nil {
+		return nil, err
+	}
+	return &windowNode{
 		plan:    root.(planNode),
 		columns: wi.Cols,
-	}
+		funcs:   funcs,
+	}, nil
+}
 
+// makeWindowFuncHolders creates a windowFuncHolder for each window function
+// in wi.
+func makeWindowFuncHolders(wi exec.WindowInfo) ([]*windowFuncHolder, error) {
 	partitionIdxs := make([]int, len(wi.Partition))
 	for i, idx := range wi.Partition {
 		partitionIdxs[i] = int(idx)
 	}
 
-	p.funcs = make([]*windowFuncHolder, len(wi.Exprs))
+	funcs := make([]*windowFuncHolder, len(wi.Exprs))
 	for i := range wi.Exprs {
 		argsIdxs := make([]uint32, len(wi.ArgIdxs[i]))
 		for j := range argsIdxs {
 			argsIdxs[j] = uint32(wi.ArgIdxs[i][j])
 		}
 
-		p.funcs[i] = &windowFuncHolder{
+		funcs[i] = &windowFuncHolder{
 			expr:           wi.Exprs[i],
 			args:           wi.Exprs[i].Exprs,
 			argsIdxs:       argsIdxs,
@@ -1167,7 +1176,7 @@ func (ef *execFactory) ConstructWindow(root exec.Node, wi exec.WindowInfo) (exec
 			frame:          wi.Exprs[i].WindowDef.Frame,
 		}
 		if len(wi.Ordering) == 0 {
-			frame := p.funcs[i].frame
+			frame := funcs[i].frame
 			if frame.Mode == treewindow.RANGE && frame.Bounds.HasOffset() {
 				// Execution requires a single column to order by when there is
 				// a RANGE mode frame with at least one 'offset' bound.
@@ -1175,8 +1184,7 @@ func (ef *execFactory) ConstructWindow(root exec.Node, wi exec.WindowInfo) (exec
 			}
 		}
 	}
-
-	return p, nil
+	return funcs, nil
 }
 
 // ConstructPlan is part of the exec.Factory interface.
@@ -35,6 +35,7 @@ func TestContextualHelp(t *testing.T) {
 		{`ALTER CHANGEFEED ??`, `ALTER CHANGEFEED`},
 		{`ALTER CHANGEFEED 123 ADD ??`, `ALTER CHANGEFEED`},
 		{`ALTER CHANGEFEED 123 DROP ??`, `ALTER CHANGEFEED`},
+		{`ALTER CHANGEFEED 123 REWIND ??`, `ALTER CHANGEFEED`},
 
 		{`ALTER BACKUP foo ADD NEW_KMS=bar WITH OLD_KMS=foobar ??`, `ALTER BACKUP`},
 
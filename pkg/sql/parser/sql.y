@@ -945,7 +945,7 @@ func (u *sqlSymUnion) triggerForEach() tree.TriggerForEach {
 %token <str> CHARACTER CHARACTERISTICS CHECK CHECK_FILES CLOSE
 %token <str> CLUSTER CLUSTERS COALESCE COLLATE COLLATION COLUMN COLUMNS COMMENT COMMENTS COMMIT
 %token <str> COMMITTED COMPACT COMPLETE COMPLETIONS CONCAT CONCURRENTLY CONFIGURATION CONFIGURATIONS CONFIGURE
-%token <str> CONFLICT CONNECTION CONNECTIONS CONSTRAINT CONSTRAINTS CONTAINS CONTROLCHANGEFEED CONTROLJOB
+%token <str> CONFLICT CONNECTION CONNECTIONS CONSTRAINT CONSTRAINTS CONTAINS CONTINUOUS CONTROLCHANGEFEED CONTROLJOB
 %token <str> CONVERSION CONVERT COPY COS_DISTANCE COST COVERING CREATE CREATEDB CREATELOGIN CREATEROLE
 %token <str> CROSS CSV CUBE CURRENT CURRENT_CATALOG CURRENT_DATE CURRENT_SCHEMA
 %token <str> CURRENT_ROLE CURRENT_TIME CURRENT_TIMESTAMP
@@ -953,7 +953,7 @@ func (u *sqlSymUnion) triggerForEach() tree.TriggerForEach {
 
 %token <str> DATA DATABASE DATABASES DATE DAY DEBUG_IDS DEC DEBUG_DUMP_METADATA_SST DECIMAL DEFAULT DEFAULTS DEFINER
 %token <str> DEALLOCATE DECLARE DEFERRABLE DEFERRED DELETE DELIMITER DEPENDS DESC DESTINATION DETACHED DETAILS
-%token <str> DISCARD DISTANCE DISTINCT DO DOMAIN DOUBLE DROP
+%token <str> DIFF DISCARD DISTANCE DISTINCT DO DOMAIN DOUBLE DROP
 
 %token <str> EACH ELSE ENCODING ENCRYPTED ENCRYPTION_INFO_DIR ENCRYPTION_PASSPHRASE END ENUM ENUMS ESCAPE EXCEPT EXCLUDE EXCLUDING
 %token <str> EXISTS EXECUTE EXECUTION EXPERIMENTAL
@@ -1017,14 +1017,14 @@ func (u *sqlSymUnion) triggerForEach() tree.TriggerForEach {
 %token <str> REGCLASS REGION REGIONAL REGIONS REGNAMESPACE REGPROC REGPROCEDURE REGROLE REGTYPE REINDEX
 %token <str> RELATIVE RELOCATE REMOVE_PATH REMOVE_REGIONS RENAME REPEATABLE REPLACE REPLICATION
 %token <str> RELEASE RESET RESTART RESTORE RESTRICT RESTRICTED RESUME RETENTION RETURNING RETURN RETURNS RETRY REVISION_HISTORY
-%token <str> REVOKE RIGHT ROLE ROLES ROLLBACK ROLLUP ROUTINES ROW ROWS RSHIFT RULE RUNNING
+%token <str> REVOKE REWIND RIGHT ROLE ROLES ROLLBACK ROLLUP ROUTINES ROW ROWS RSHIFT RULE RUNNING
 
 %token <str> SAVEPOINT SCANS SCATTER SCHEDULE SCHEDULES SCROLL SCHEMA SCHEMA_ONLY SCHEMAS SCRUB
 %token <str> SEARCH SECOND SECONDARY SECURITY SELECT SEQUENCE SEQUENCES
 %token <str> SERIALIZABLE SERVER SERVICE SESSION SESSIONS SESSION_USER SET SETOF SETS SETTING SETTINGS
-%token <str> SHARE SHARED SHOW SIMILAR SIMPLE SIZE SKIP SKIP_LOCALITIES_CHECK SKIP_MISSING_FOREIGN_KEYS
+%token <str> SHARE SHARED SHOW SIGNING_KMS SIMILAR SIMPLE SIZE SKIP SKIP_LOCALITIES_CHECK SKIP_MISSING_FOREIGN_KEYS
 %token <str> SKIP_MISSING_SEQUENCES SKIP_MISSING_SEQUENCE_OWNERS SKIP_MISSING_VIEWS SKIP_MISSING_UDFS SMALLINT SMALLSERIAL
-%token <str> SNAPSHOT SOME SPLIT SQL SQLLOGIN
+%token <str> SNAPSHOT SOME SPANS SPLIT SQL SQLLOGIN
 %token <str> STABLE START STATE STATEMENT STATISTICS STATUS STDIN STDOUT STOP STRAIGHT STREAM STRICT STRING STORAGE STORE STORED STORING SUBJECT SUBSTRING SUPER
 %token <str> SUPPORT SURVIVE SURVIVAL SYMMETRIC SYNTAX SYSTEM SQRT SUBSCRIPTION STATEMENTS
 
@@ -1037,7 +1037,7 @@ func (u *sqlSymUnion) triggerForEach() tree.TriggerForEach {
 %token <str> UNBOUNDED UNCOMMITTED UNION UNIQUE UNKNOWN UNLISTEN UNLOGGED UNSAFE_RESTORE_INCOMPATIBLE_VERSION UNSPLIT
 %token <str> UPDATE UPDATES_CLUSTER_MONITORING_METRICS UPSERT UNSET UNTIL USE USER USERS USING UUID
 
-%token <str> VALID VALIDATE VALUE VALUES VARBIT VARCHAR VARIADIC VECTOR VERIFY_BACKUP_TABLE_DATA VIEW VARIABLES VARYING VIEWACTIVITY VIEWACTIVITYREDACTED VIEWDEBUG
+%token <str> VALID VALIDATE VALUE VALUES VARBIT VARCHAR VARIADIC VECTOR VERIFY_BACKUP_INTEGRITY VERIFY_BACKUP_TABLE_DATA VIEW VARIABLES VARYING VIEWACTIVITY VIEWACTIVITYREDACTED VIEWDEBUG
 %token <str> VIEWCLUSTERMETADATA VIEWCLUSTERSETTING VIRTUAL VISIBLE INVISIBLE VISIBILITY VOLATILE VOTERS
 %token <str> VIRTUAL_CLUSTER_NAME VIRTUAL_CLUSTER
 
@@ -1685,7 +1685,7 @@ func (u *sqlSymUnion) triggerForEach() tree.TriggerForEach {
 %type <[]tree.ColumnID> opt_tableref_col_list tableref_col_list
 
 %type <tree.ChangefeedTargets> changefeed_targets
-%type <tree.ChangefeedTarget> changefeed_target
+%type <tree.ChangefeedTarget> changefeed_target changefeed_table_target
 %type <tree.BackupTargetList> backup_targets
 %type <*tree.BackupTargetList> opt_backup_targets
 
@@ -3351,6 +3351,9 @@ opt_clear_data:
 //    detached: execute backup job asynchronously, without waiting for its completion
 //    incremental_location: specify a different path to store the incremental backup
 //    include_all_virtual_clusters: enable backups of all virtual clusters during a cluster backup
+//    continuous: continuously extend the most recent backup in a collection (INTO LATEST IN only)
+//                with a log of changes that can be restored AS OF SYSTEM TIME
+//    signing_kms="[kms_provider]://[kms_host]/[master_key_identifier]?[parameters]" : sign the backup manifest using KMS
 //
 // %SeeAlso: RESTORE, WEBDOCS/backup.html
 backup_stmt:
@@ -3485,6 +3488,14 @@ backup_options:
   {
     $$.val = &tree.BackupOptions{UpdatesClusterMonitoringMetrics: $3.expr()}
   }
+| CONTINUOUS
+  {
+    $$.val = &tree.BackupOptions{Continuous: tree.MakeDBool(true)}
+  }
+| SIGNING_KMS '=' string_or_placeholder
+  {
+    $$.val = &tree.BackupOptions{SigningKMSURI: $3.expr()}
+  }
 
 include_all_clusters:
   INCLUDE_ALL_SECONDARY_TENANTS { /* SKIP DOC */ }
@@ -3561,6 +3572,12 @@ include_all_clusters:
 //     If backups were already created in the destination in which a new schedule references,
 //     this flag must be passed in to acknowledge that the new schedule may be backing up different
 //     objects.
+//   * retain_full_backups=INT:
+//     After each full backup, delete older backup chains, i.e. full backups along with their
+//     incremental backups, except for the specified number of most recent ones.
+//   * retention_period=INTERVAL:
+//     After each full backup, delete older backup chains that are not needed to restore to
+//     any time within the specified interval.
 //
 // %SeeAlso: BACKUP
 create_schedule_for_backup_stmt:
@@ -3809,6 +3826,11 @@ drop_external_connection_stmt:
 //    skip_localities_check: ignore difference of zone configuration between restore cluster and backup cluster
 //    new_db_name: renames the restored database. only applies to database restores
 //    include_all_virtual_clusters: enable backups of all virtual clusters during a cluster backup
+//    verify_backup_integrity="[kms_provider]://[kms_host]/[master_key_identifier]?[parameters]" : verify
+//                            the signature and file hashes of a signed backup before restoring
+//    experimental read only: with experimental deferred copy and new_db_name, mount the backup's tables
+//                            as read-only tables whose rows are served from the backup in place. backups
+//                            cannot be queried without such a restore, e.g. with SELECT ... FROM [BACKUP ...]
 // %SeeAlso: BACKUP, WEBDOCS/restore.html
 restore_stmt:
   RESTORE FROM error
@@ -3974,10 +3996,18 @@ restore_options:
   {
     $$.val = &tree.RestoreOptions{ExperimentalOnline: true}
   }
+| EXPERIMENTAL READ ONLY
+  {
+    $$.val = &tree.RestoreOptions{ExperimentalReadOnly: true}
+  }
 | REMOVE_REGIONS
   {
     $$.val = &tree.RestoreOptions{RemoveRegions: true, SkipLocalitiesCheck: true}
   }
+| VERIFY_BACKUP_INTEGRITY '=' string_or_placeholder
+  {
+    $$.val = &tree.RestoreOptions{VerifyIntegrityKMSURI: $3.expr()}
+  }
 
 virtual_cluster_opt:
   TENANT  { /* SKIP DOC */ }
@@ -5732,7 +5762,11 @@ create_stats_option:
 // %Text:
 // CREATE CHANGEFEED
 // FOR <targets> [INTO sink] [WITH <options>]
+// CREATE CHANGEFEED
+// FOR DATABASE <database_name> [INTO sink] [WITH <options>]
 //
+// targets: tables, optionally restricted to a column family, or all the
+//          tables of a schema (db.schema.*)
 // sink: data capture stream destination (Enterprise only)
 create_changefeed_stmt:
   CREATE CHANGEFEED FOR changefeed_targets opt_changefeed_sink opt_with_options
@@ -5743,6 +5777,14 @@ create_changefeed_stmt:
       Options: $6.kvOptions(),
     }
   }
+| CREATE CHANGEFEED FOR DATABASE database_name opt_changefeed_sink opt_with_options
+  {
+    $$.val = &tree.CreateChangefeed{
+      Database: tree.Name($5),
+      SinkURI:  $6.expr(),
+      Options:  $7.kvOptions(),
+    }
+  }
 | CREATE CHANGEFEED /*$3=*/ opt_changefeed_sink /*$4=*/ opt_with_options
   AS SELECT /*$7=*/target_list FROM /*$9=*/changefeed_target_expr /*$10=*/opt_where_clause
   {
@@ -5852,22 +5894,31 @@ changefeed_targets:
   }
 
 changefeed_target:
-  opt_table_prefix table_name opt_changefeed_family
+  changefeed_table_target
+| TABLE changefeed_table_target
+  {
+    $$.val = $2.changefeedTarget()
+  }
+
+// The optional TABLE prefix is not expressed as an empty rule, which would
+// conflict with CREATE CHANGEFEED FOR DATABASE.
+changefeed_table_target:
+  table_name opt_changefeed_family
   {
     $$.val = tree.ChangefeedTarget{
-      TableName:  $2.unresolvedObjectName().ToUnresolvedName(),
-      FamilyName: tree.Name($3),
+      TableName:  $1.unresolvedObjectName().ToUnresolvedName(),
+      FamilyName: tree.Name($2),
+    }
+  }
+| db_object_name_component '.' unrestricted_name '.' '*'
+  {
+    $$.val = tree.ChangefeedTarget{
+      TableName: &tree.UnresolvedName{Star: true, NumParts: 3, Parts: tree.NameParts{"", $3, $1}},
     }
   }
 
 changefeed_target_expr: insert_target
 
-opt_table_prefix:
-  TABLE
-  {}
-| /* EMPTY */
-  {}
-
 opt_changefeed_family:
   FAMILY family_name
   {
@@ -6419,7 +6470,7 @@ explain_option_list:
 // %Help: ALTER CHANGEFEED - alter an existing changefeed
 // %Category: CCL
 // %Text:
-// ALTER CHANGEFEED <job_id> {{ADD|DROP <targets...>} | SET <options...>}...
+// ALTER CHANGEFEED <job_id> {{ADD|DROP <targets...>} | SET <options...> | REWIND [<targets...>] TO <timestamp>}...
 alter_changefeed_stmt:
   ALTER CHANGEFEED a_expr alter_changefeed_cmds
   {
@@ -6468,18 +6519,38 @@ alter_changefeed_cmd:
       Options: $2.nameList(),
     }
   }
+  // ALTER CHANGEFEED <job_id> REWIND [[TABLE] ...] TO <timestamp>
+| REWIND TO a_expr
+  {
+    $$.val = &tree.AlterChangefeedRewind{
+      Timestamp: $3.expr(),
+    }
+  }
+| REWIND changefeed_targets TO a_expr
+  {
+    $$.val = &tree.AlterChangefeedRewind{
+      Targets:   $2.changefeedTargets(),
+      Timestamp: $4.expr(),
+    }
+  }
 
-// %Help: ALTER BACKUP - alter an existing backup's encryption keys
+// %Help: ALTER BACKUP - alter an existing backup's encryption keys or compact it
 // %Category: CCL
 // %Text:
 // ALTER BACKUP <location...>
 //        [ ADD NEW_KMS = <kms...> ]
 //        [ WITH OLD_KMS = <kms...> ]
+// ALTER BACKUP <subdir> IN <location...> COMPACT [ WITH <option> [= <value>] [, ...] ]
 // Locations:
 //    "[scheme]://[host]/[path to backup]?[parameters]"
 //
 // KMS:
 //    "[kms_provider]://[kms_host]/[master_key_identifier]?[parameters]" : add new kms keys to backup
+//
+// Compaction options:
+//    encryption_passphrase="secret": decrypt the backup chain with a passphrase
+//    kms="[kms_provider]://[kms_host]/[master_key_identifier]?[parameters]" : decrypt the backup chain with KMS
+//    incremental_location: location of the incremental backups of the chain
 alter_backup_stmt:
   ALTER BACKUP string_or_placeholder alter_backup_cmds
   {
@@ -6515,6 +6586,12 @@ alter_backup_cmd:
       KMSInfo:	$2.backupKMS(),
     }
 	}
+|	COMPACT opt_with_backup_options
+	{
+    $$.val = &tree.AlterBackupCompact{
+      Options:	*$2.backupOptions(),
+    }
+	}
 
 backup_kms:
 	NEW_KMS '=' string_or_placeholder_opt_list WITH OLD_KMS '=' string_or_placeholder_opt_list
@@ -8203,7 +8280,9 @@ show_histogram_stmt:
 
 // %Help: SHOW BACKUP - list backup contents
 // %Category: CCL
-// %Text: SHOW BACKUP [SCHEMAS|FILES|RANGES] <location>
+// %Text:
+// SHOW BACKUP [SCHEMAS|FILES|RANGES] <location>
+// SHOW BACKUP DIFF FROM <subdir> TO <subdir> IN <collection> [WITH keys = <tables>]
 // %SeeAlso: WEBDOCS/show-backup.html
 show_backup_stmt:
   SHOW BACKUPS IN string_or_placeholder_opt_list
@@ -8222,6 +8301,17 @@ show_backup_stmt:
 			Options: *$8.showBackupOptions(),
 		}
 	}
+| SHOW BACKUP DIFF FROM string_or_placeholder TO string_or_placeholder IN string_or_placeholder_opt_list opt_with_show_backup_options
+	{
+		$$.val = &tree.ShowBackup{
+			From:    true,
+			Details:    tree.BackupDiffDetails,
+			DiffFrom:    $5.expr(),
+			Path:    $7.expr(),
+			InCollection: $9.stringOrPlaceholderOptList(),
+			Options: *$10.showBackupOptions(),
+		}
+	}
 | SHOW BACKUP string_or_placeholder IN string_or_placeholder_opt_list opt_with_show_backup_options
 	{
 		$$.val = &tree.ShowBackup{
@@ -8367,6 +8457,14 @@ show_backup_options:
  {
  $$.val = &tree.ShowBackupOptions{DebugMetadataSST: true}
  }
+ | KEYS '=' string_or_placeholder_opt_list
+ {
+ $$.val = &tree.ShowBackupOptions{KeyDiffTables: $3.stringOrPlaceholderOptList()}
+ }
+ | VERIFY_BACKUP_INTEGRITY '=' string_or_placeholder
+ {
+ $$.val = &tree.ShowBackupOptions{VerifyIntegrityKMSURI: $3.expr()}
+ }
 
 opt_with_show_backup_connection_options_list:
   WITH show_backup_connection_options_list
@@ -8733,6 +8831,7 @@ statements_or_queries:
 // SHOW [AUTOMATIC | CHANGEFEED] JOBS [select clause] [WITH EXECUTION DETAILS]
 // SHOW JOBS FOR SCHEDULES [select clause]
 // SHOW [CHANGEFEED] JOB <jobid> [WITH EXECUTION DETAILS]
+// SHOW CHANGEFEED JOB <jobid> SPANS
 // %SeeAlso: CANCEL JOBS, PAUSE JOBS, RESUME JOBS
 show_jobs_stmt:
   SHOW AUTOMATIC JOBS
@@ -8808,6 +8907,10 @@ show_jobs_stmt:
       },
     }
   }
+| SHOW CHANGEFEED JOB a_expr SPANS
+  {
+    $$.val = &tree.ShowChangefeedSpans{Job: $4.expr()}
+  }
 | SHOW JOB WHEN COMPLETE a_expr
   {
     $$.val = &tree.ShowJobs{
@@ -17514,6 +17617,7 @@ unreserved_keyword:
 | CONNECTION
 | CONNECTIONS
 | CONSTRAINTS
+| CONTINUOUS
 | CONTROLCHANGEFEED
 | CONTROLJOB
 | CONVERSION
@@ -17546,6 +17650,7 @@ unreserved_keyword:
 | DESTINATION
 | DETACHED
 | DETAILS
+| DIFF
 | DISCARD
 | DOMAIN
 | DOUBLE
@@ -17799,6 +17904,7 @@ unreserved_keyword:
 | RETURNS
 | REVISION_HISTORY
 | REVOKE
+| REWIND
 | ROLE
 | ROLES
 | ROLLBACK
@@ -17836,6 +17942,7 @@ unreserved_keyword:
 | SHARE
 | SHARED
 | SHOW
+| SIGNING_KMS
 | SIMPLE
 | SIZE
 | SKIP
@@ -17846,6 +17953,7 @@ unreserved_keyword:
 | SKIP_MISSING_VIEWS
 | SKIP_MISSING_UDFS
 | SNAPSHOT
+| SPANS
 | SPLIT
 | SQL
 | SQLLOGIN
@@ -17916,6 +18024,7 @@ unreserved_keyword:
 | VALUE
 | VARIABLES
 | VARYING
+| VERIFY_BACKUP_INTEGRITY
 | VERIFY_BACKUP_TABLE_DATA
 | VIEW
 | VIEWACTIVITY
@@ -18019,6 +18128,7 @@ bare_label_keywords:
 | CONNECTIONS
 | CONSTRAINT
 | CONSTRAINTS
+| CONTINUOUS
 | CONTROLCHANGEFEED
 | CONTROLJOB
 | CONVERSION
@@ -18063,6 +18173,7 @@ bare_label_keywords:
 | DESTINATION
 | DETACHED
 | DETAILS
+| DIFF
 | DISCARD
 | DISTINCT
 | DO
@@ -18370,6 +18481,7 @@ bare_label_keywords:
 | RETURNS
 | REVISION_HISTORY
 | REVOKE
+| REWIND
 | RIGHT
 | ROLE
 | ROLES
@@ -18410,6 +18522,7 @@ bare_label_keywords:
 | SHARE
 | SHARED
 | SHOW
+| SIGNING_KMS
 | SIMILAR
 | SIMPLE
 | SIZE
@@ -18423,6 +18536,7 @@ bare_label_keywords:
 | SMALLINT
 | SNAPSHOT
 | SOME
+| SPANS
 | SPLIT
 | SQL
 | SQLLOGIN
@@ -18514,6 +18628,7 @@ bare_label_keywords:
 | VARIABLES
 | VARIADIC
 | VECTOR
+| VERIFY_BACKUP_INTEGRITY
 | VERIFY_BACKUP_TABLE_DATA
 | VIEW
 | VIEWACTIVITY
@@ -26,6 +26,11 @@ type ExprContext interface {
 
 	// IsLocal returns true if the current plan is local.
 	IsLocal() bool
+
+	// DeferSubqueries returns true if subqueries should be left in the
+	// expressions to be evaluated during the execution (this is the case when
+	// the plan is constructed before the subqueries are run).
+	DeferSubqueries() bool
 }
 
 // fakeExprContext is a fake implementation of ExprContext that always behaves
@@ -42,6 +47,10 @@ func (fakeExprContext) IsLocal() bool {
 	return false
 }
 
+func (fakeExprContext) DeferSubqueries() bool {
+	return false
+}
+
 // MakeExpression creates a execinfrapb.Expression.
 //
 // The execinfrapb.Expression uses the placeholder syntax (@1, @2, @3..) to
@@ -118,11 +127,12 @@ func (ef *ExprFactory) Make(expr tree.TypedExpr) (execinfrapb.Expression, error)
 	}
 
 	// Replace subqueries with their results (they must have been executed
-	// before the main query) and remap IndexedVars.
+	// before the main query, unless they are deferred) and remap IndexedVars.
 	evalCtx := ef.eCtx.EvalContext()
 	if ef.replacer == nil {
 		// Lazily allocate the replacer.
 		ef.replacer = newIVarAndSubqueryReplacer(evalCtx, ef.indexVarMap, ef.indexedVarsHint)
+		ef.replacer.deferSubqueries = ef.eCtx.DeferSubqueries()
 	}
 	newExpr, _ := tree.WalkExpr(ef.replacer, expr)
 	if ef.replacer.err != nil {
@@ -147,12 +157,15 @@ func (ef *ExprFactory) Make(expr tree.TypedExpr) (execinfrapb.Expression, error)
 
 // iVarAndSubqueryReplacer is a tree.Visitor that replaces subqueries with their
 // results (they must have been executed before the main query) and remaps
-// tree.IndexedVars in expr using indexVarMap, if it is non-nil.
+// tree.IndexedVars in expr using indexVarMap, if it is non-nil. If
+// deferSubqueries is set, then the subqueries are left in place (but the
+// IndexedVars are still remapped) and are evaluated during the execution.
 type iVarAndSubqueryReplacer struct {
-	evalCtx       *eval.Context
-	indexVarMap   []int
-	indexVarAlloc []tree.IndexedVar
-	err           error
+	evalCtx         *eval.Context
+	indexVarMap     []int
+	indexVarAlloc   []tree.IndexedVar
+	deferSubqueries bool
+	err             error
 }
 
 var _ tree.Visitor = &iVarAndSubqueryReplacer{}
@@ -175,6 +188,9 @@ func newIVarAndSubqueryReplacer(
 func (r *iVarAndSubqueryReplacer) VisitPre(expr tree.Expr) (bool, tree.Expr) {
 	switch t := expr.(type) {
 	case *tree.Subquery:
+		if r.deferSubqueries {
+			return false, expr
+		}
 		val, err := r.evalCtx.Planner.EvalSubquery(t)
 		if err != nil {
 			r.err = err
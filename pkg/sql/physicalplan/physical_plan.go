@@ -212,6 +212,75 @@ func MakePhysicalPlan(infra *PhysicalInfrastructure) PhysicalPlan {
 	}
 }
 
+// Detach moves the processors that the results of the plan depend on (as well
+// as the streams between them) into a new PhysicalInfrastructure with the
+// given flow ID. This allows for several plans that were constructed using the
+// same infrastructure, but that are run separately (like subqueries and the
+// main query), to be finalized and run on their own. The old infrastructure is
+// left untouched.
+func (p *PhysicalPlan) Detach(flowID uuid.UUID) {
+	// Find all processors that are reachable from the result routers by
+	// following the streams backwards.
+	inputStreams := make(map[ProcessorIdx][]int, len(p.Streams))
+	for i, s := range p.Streams {
+		inputStreams[s.DestProcessor] = append(inputStreams[s.DestProcessor], i)
+	}
+	var reachable intsets.Fast
+	toVisit := append([]ProcessorIdx(nil), p.ResultRouters...)
+	for len(toVisit) > 0 {
+		pIdx := toVisit[len(toVisit)-1]
+		toVisit = toVisit[:len(toVisit)-1]
+		if reachable.Contains(int(pIdx)) {
+			continue
+		}
+		reachable.Add(int(pIdx))
+		for _, sIdx := range inputStreams[pIdx] {
+			toVisit = append(toVisit, p.Streams[sIdx].SourceProcessor)
+		}
+	}
+
+	infra := NewPhysicalInfrastructure(flowID, p.GatewaySQLInstanceID)
+	infra.stageCounter = p.stageCounter
+	newIdx := make(map[ProcessorIdx]ProcessorIdx, reachable.Len())
+	for i := range p.Processors {
+		pIdx := ProcessorIdx(i)
+		if !reachable.Contains(i) {
+			continue
+		}
+		proc := p.Processors[i]
+		if localPlanNode := proc.Spec.Core.LocalPlanNode; localPlanNode != nil {
+			newLocalPlanNode := *localPlanNode
+			newLocalPlanNode.RowSourceIdx = uint32(
+				infra.AddLocalProcessor(p.LocalProcessors[localPlanNode.RowSourceIdx]),
+			)
+			proc.Spec.Core.LocalPlanNode = &newLocalPlanNode
+		}
+		newIdx[pIdx] = infra.AddProcessor(proc)
+		if source, ok := p.LocalVectorSources[int32(pIdx)]; ok {
+			if infra.LocalVectorSources == nil {
+				infra.LocalVectorSources = make(map[int32]any)
+			}
+			infra.LocalVectorSources[int32(newIdx[pIdx])] = source
+		}
+	}
+	// Streams are kept in their original order since the order of the
+	// streams originating from the same router matters.
+	for _, s := range p.Streams {
+		if !reachable.Contains(int(s.DestProcessor)) {
+			continue
+		}
+		s.SourceProcessor = newIdx[s.SourceProcessor]
+		s.DestProcessor = newIdx[s.DestProcessor]
+		infra.Streams = append(infra.Streams, s)
+	}
+	resultRouters := make([]ProcessorIdx, len(p.ResultRouters))
+	for i, pIdx := range p.ResultRouters {
+		resultRouters[i] = newIdx[pIdx]
+	}
+	p.PhysicalInfrastructure = infra
+	p.ResultRouters = resultRouters
+}
+
 // GetResultTypes returns the schema (column types) of the rows produced by the
 // ResultRouters which *must* contain at least one index into Processors slice.
 // This is aliased with ColumnTypes of the processor spec, so it must not be
@@ -11,11 +11,13 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/cockroachdb/cockroach/pkg/base"
 	"github.com/cockroachdb/cockroach/pkg/sql/execinfrapb"
 	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
 	"github.com/cockroachdb/cockroach/pkg/sql/types"
 	"github.com/cockroachdb/cockroach/pkg/util/leaktest"
 	"github.com/cockroachdb/cockroach/pkg/util/log"
+	"github.com/cockroachdb/cockroach/pkg/util/uuid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -279,3 +281,62 @@ func TestProjectionAndRendering(t *testing.T) {
 		}
 	}
 }
+
+func TestDetach(t *testing.T) {
+	defer leaktest.AfterTest(t)()
+	defer log.Scope(t).Close(t)
+
+	infra := NewPhysicalInfrastructure(uuid.MakeV4(), base.SQLInstanceID(1))
+	addProc := func(localPlanNode bool) ProcessorIdx {
+		proc := Processor{SQLInstanceID: 1}
+		if localPlanNode {
+			proc.Spec.Core.LocalPlanNode = &execinfrapb.LocalPlanNodeSpec{
+				RowSourceIdx: uint32(infra.AddLocalProcessor(nil)),
+			}
+		} else {
+			proc.Spec.Core.Noop = &execinfrapb.NoopCoreSpec{}
+		}
+		return infra.AddProcessor(proc)
+	}
+	connect := func(from, to ProcessorIdx) {
+		infra.Streams = append(infra.Streams, Stream{SourceProcessor: from, DestProcessor: to})
+	}
+
+	// Build two plans that share the infrastructure and interleave their
+	// processors: 0 -> 2 -> 4 and 1 -> 3.
+	var first, second PhysicalPlan
+	first.PhysicalInfrastructure, second.PhysicalInfrastructure = infra, infra
+	p0, p1 := addProc(false), addProc(true)
+	p2, p3 := addProc(true), addProc(false)
+	p4 := addProc(false)
+	connect(p0, p2)
+	connect(p1, p3)
+	connect(p2, p4)
+	first.ResultRouters = []ProcessorIdx{p4}
+	second.ResultRouters = []ProcessorIdx{p3}
+
+	firstFlowID, secondFlowID := uuid.MakeV4(), uuid.MakeV4()
+	first.Detach(firstFlowID)
+	second.Detach(secondFlowID)
+
+	require.Equal(t, firstFlowID, first.FlowID)
+	require.Len(t, first.Processors, 3)
+	require.Len(t, first.LocalProcessors, 1)
+	require.Equal(t, uint32(0), first.Processors[1].Spec.Core.LocalPlanNode.RowSourceIdx)
+	require.Equal(t, []Stream{
+		{SourceProcessor: 0, DestProcessor: 1},
+		{SourceProcessor: 1, DestProcessor: 2},
+	}, first.Streams)
+	require.Equal(t, []ProcessorIdx{2}, first.ResultRouters)
+
+	require.Equal(t, secondFlowID, second.FlowID)
+	require.Len(t, second.Processors, 2)
+	require.Len(t, second.LocalProcessors, 1)
+	require.Equal(t, uint32(0), second.Processors[0].Spec.Core.LocalPlanNode.RowSourceIdx)
+	require.Equal(t, []Stream{{SourceProcessor: 0, DestProcessor: 1}}, second.Streams)
+	require.Equal(t, []ProcessorIdx{1}, second.ResultRouters)
+
+	// The original infrastructure must be left untouched.
+	require.Len(t, infra.Processors, 5)
+	require.Equal(t, uint32(1), infra.Processors[p2].Spec.Core.LocalPlanNode.RowSourceIdx)
+}
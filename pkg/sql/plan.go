@@ -210,6 +210,7 @@ var _ planNode = &sequenceSelectNode{}
 var _ planNode = &showFingerprintsNode{}
 var _ planNode = &showTraceNode{}
 var _ planNode = &sortNode{}
+var _ planNode = &specInputNode{}
 var _ planNode = &splitNode{}
 var _ planNode = &topKNode{}
 var _ planNode = &unsplitNode{}
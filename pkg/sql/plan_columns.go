@@ -168,6 +168,8 @@ func getPlanColumns(plan planNode, mut bool) colinfo.ResultColumns {
 		}
 	case *rowSourceToPlanNode:
 		return n.planCols
+	case *specInputNode:
+		return n.physPlan.ResultColumns
 	case *cdcValuesNode:
 		return n.resultColumns
 
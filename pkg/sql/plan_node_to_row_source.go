@@ -87,6 +87,16 @@ func newPlanNodeToRowSource(
 	return p
 }
 
+// enableFastPath makes the planNodeToRowSource trigger the wrapped planNode's
+// fast path and output a single row with the row count. It is used when the
+// wrapper is created before it is known whether it is the root of the plan,
+// and it must be called before the processor is initialized.
+func (p *planNodeToRowSource) enableFastPath() {
+	p.fastPath = true
+	p.outputTypes = []*types.T{types.Int}
+	p.row = make(rowenc.EncDatumRow, len(p.outputTypes))
+}
+
 // MustBeStreaming implements the execinfra.Processor interface.
 func (p *planNodeToRowSource) MustBeStreaming() bool {
 	switch p.node.(type) {
@@ -271,6 +271,12 @@ func (p *planner) makeOptimizerPlan(ctx context.Context) error {
 		if p.Descriptors().HasUncommittedTypes() {
 			planningMode = distSQLLocalOnlyPlanning
 		}
+		// Mutations are performed by planNodes on the gateway using the root
+		// txn, so the plans containing them are not distributed (the same as
+		// with the old path).
+		if execMemo.RootExpr().(memo.RelExpr).Relational().CanMutate {
+			planningMode = distSQLLocalOnlyPlanning
+		}
 		err := opc.runExecBuilder(
 			ctx,
 			&p.curPlan,
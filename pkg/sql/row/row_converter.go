@@ -51,6 +51,9 @@ func (i KVInserter) Del(key ...interface{}) {
 	// exist and the imported column family 0 will conflict (and the IMPORT INTO
 	// will fail) or the row does not exist (and thus the column families are all
 	// empty).
+	//
+	// When IMPORT INTO is asked to overwrite conflicting rows instead, the
+	// importer reads each row it replaces and deletes all of its KVs itself.
 }
 
 // Put method of the putter interface.
@@ -254,6 +254,15 @@ func NewProcessor(
 		}
 		return NewBackupDataProcessor(ctx, flowCtx, processorID, *core.BackupData, post)
 	}
+	if core.ContinuousBackupData != nil {
+		if err := checkNumIn(inputs, 0); err != nil {
+			return nil, err
+		}
+		if NewContinuousBackupDataProcessor == nil {
+			return nil, errors.New("ContinuousBackupData processor unimplemented")
+		}
+		return NewContinuousBackupDataProcessor(ctx, flowCtx, processorID, *core.ContinuousBackupData, post)
+	}
 	if core.RestoreData != nil {
 		if err := checkNumIn(inputs, 1); err != nil {
 			return nil, err
@@ -400,6 +409,9 @@ var NewIngestStoppedProcessor func(context.Context, *execinfra.FlowCtx, int32, e
 // NewBackupDataProcessor is implemented in the non-free (CCL) codebase and then injected here via runtime initialization.
 var NewBackupDataProcessor func(context.Context, *execinfra.FlowCtx, int32, execinfrapb.BackupDataSpec, *execinfrapb.PostProcessSpec) (execinfra.Processor, error)
 
+// NewContinuousBackupDataProcessor is implemented in the non-free (CCL) codebase and then injected here via runtime initialization.
+var NewContinuousBackupDataProcessor func(context.Context, *execinfra.FlowCtx, int32, execinfrapb.ContinuousBackupDataSpec, *execinfrapb.PostProcessSpec) (execinfra.Processor, error)
+
 // NewRestoreDataProcessor is implemented in the non-free (CCL) codebase and then injected here via runtime initialization.
 var NewRestoreDataProcessor func(context.Context, *execinfra.FlowCtx, int32, execinfrapb.RestoreDataSpec, *execinfrapb.PostProcessSpec, execinfra.RowSource) (execinfra.Processor, error)
 
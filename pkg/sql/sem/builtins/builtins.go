@@ -8806,6 +8806,15 @@ specified store on the node it's run from. One of 'mvccGC', 'merge', 'split',
 			CalledOnNullInput: true,
 		},
 	),
+	"crdb_internal.request_workload_index_advice": makeBuiltin(
+		tree.FunctionProperties{
+			Category:         builtinconstants.CategorySystemInfo,
+			DistsqlBlocklist: true, // applicable only on the gateway
+		},
+		makeRequestWorkloadIndexAdviceOverload(false /* hasTimestamp */),
+		makeRequestWorkloadIndexAdviceOverload(true /* hasTimestamp */),
+	),
+
 	"crdb_internal.protect_mvcc_history": makeBuiltin(
 		tree.FunctionProperties{
 			Category:     builtinconstants.CategoryClusterReplication,
@@ -11791,6 +11800,58 @@ func spanToDatum(span roachpb.Span) (tree.Datum, error) {
 	return result, nil
 }
 
+// makeRequestWorkloadIndexAdviceOverload returns an overload that creates a
+// workload index advice job and returns its ID. The hasTimestamp represents
+// whether there is a timestamp filter.
+func makeRequestWorkloadIndexAdviceOverload(hasTimestamp bool) tree.Overload {
+	params := tree.ParamTypes{}
+	info := "Creates a job that computes the ranked set of index recommendations " +
+		"for the workload, and returns its ID. The recommendations are returned " +
+		"by workload_index_advice() once the job succeeds."
+	if hasTimestamp {
+		params = tree.ParamTypes{{Name: "timestamptz", Typ: types.TimestampTZ}}
+		info = "Creates a job that computes the ranked set of index recommendations " +
+			"for the workload after the given timestamp, and returns its ID. The " +
+			"recommendations are returned by workload_index_advice(timestamptz) once " +
+			"the job succeeds."
+	}
+	return tree.Overload{
+		Types:      params,
+		ReturnType: tree.FixedReturnType(types.Int),
+		Fn: func(ctx context.Context, evalCtx *eval.Context, args tree.Datums) (tree.Datum, error) {
+			if err := checkWorkloadIndexAdvicePrivilege(ctx, evalCtx); err != nil {
+				return nil, err
+			}
+			// The zero time stands for all the persisted statements.
+			var since time.Time
+			if hasTimestamp {
+				since = tree.MustBeDTimestampTZ(args[0]).Time
+			}
+			jobID, err := evalCtx.Planner.CreateWorkloadIndexAdviceJob(ctx, since)
+			if err != nil {
+				return nil, err
+			}
+			return tree.NewDInt(tree.DInt(jobID)), nil
+		},
+		Info:       info,
+		Volatility: volatility.Volatile,
+	}
+}
+
+// checkWorkloadIndexAdvicePrivilege returns an error if the user cannot see
+// the statement statistics the workload index advice is computed from.
+func checkWorkloadIndexAdvicePrivilege(ctx context.Context, evalCtx *eval.Context) error {
+	hasViewActivity, _, err := evalCtx.SessionAccessor.HasViewActivityOrViewActivityRedactedRole(ctx)
+	if err != nil {
+		return err
+	}
+	if !hasViewActivity {
+		return pgerror.Newf(pgcode.InsufficientPrivilege,
+			"user needs ADMIN role or the VIEWACTIVITY/VIEWACTIVITYREDACTED permission to view workload index advice")
+	}
+	return nil
+}
+
 func makeRequestStatementBundleBuiltinOverload(
 	withPlanGist bool, withAntiPlanGist bool, withRedacted bool,
 ) tree.Overload {
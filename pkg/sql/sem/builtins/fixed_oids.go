@@ -2606,6 +2606,10 @@ var builtinOidsArray = []string{
 	2643: `crdb_internal.type_is_indexable(oid: oid) -> bool`,
 	2644: `crdb_internal.range_stats_with_errors(key: bytes) -> jsonb`,
 	2645: `crdb_internal.lease_holder_with_errors(key: bytes) -> jsonb`,
+	2646: `workload_index_advice() -> tuple{string AS recommendation, float AS read_cost_delta, float AS write_cost_delta}`,
+	2647: `workload_index_advice(timestamptz: timestamptz) -> tuple{string AS recommendation, float AS read_cost_delta, float AS write_cost_delta}`,
+	2648: `crdb_internal.request_workload_index_advice() -> int`,
+	2649: `crdb_internal.request_workload_index_advice(timestamptz: timestamptz) -> int`,
 }
 
 var builtinOidsBySignature map[string]oid.Oid
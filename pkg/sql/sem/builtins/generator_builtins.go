@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/cockroachdb/cockroach/pkg/build"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
 	"github.com/cockroachdb/cockroach/pkg/keys"
 	"github.com/cockroachdb/cockroach/pkg/kv"
 	"github.com/cockroachdb/cockroach/pkg/kv/kvpb"
@@ -312,6 +313,32 @@ var generators = map[string]builtinDefinition{
 		),
 	),
 
+	"workload_index_advice": makeBuiltin(genProps(),
+		makeGeneratorOverload(
+			tree.ParamTypes{},
+			workloadIndexAdviceGeneratorType,
+			makeWorkloadIndexAdviceGeneratorFactory(false /* hasTimestamp */),
+			"Returns the ranked set of index recommendations for the workload, "+
+				"including dropping unused and redundant indexes, along with their "+
+				"estimated impact on the optimizer cost of the workload's reads and writes. "+
+				"The recommendations are those of the latest successful job created by "+
+				"crdb_internal.request_workload_index_advice",
+			volatility.Volatile,
+		),
+		makeGeneratorOverload(
+			tree.ParamTypes{{Name: "timestamptz", Typ: types.TimestampTZ}},
+			workloadIndexAdviceGeneratorType,
+			makeWorkloadIndexAdviceGeneratorFactory(true /* hasTimestamp */),
+			"Returns the ranked set of index recommendations for the workload "+
+				"after the given timestamp, including dropping unused and redundant "+
+				"indexes, along with their estimated impact on the optimizer cost of "+
+				"the workload's reads and writes. The recommendations are those of the "+
+				"latest successful job created by "+
+				"crdb_internal.request_workload_index_advice with the same timestamp",
+			volatility.Volatile,
+		),
+	),
+
 	"unnest": makeBuiltin(genProps(),
 		// See https://www.postgresql.org/docs/current/static/functions-array.html
 		makeGeneratorOverloadWithReturnType(
@@ -1230,6 +1257,69 @@ func makeWorkloadIndexRecsGeneratorFactory(hasTimestamp bool) eval.GeneratorOver
 	}
 }
 
+var workloadIndexAdviceGeneratorType = types.MakeLabeledTuple(
+	[]*types.T{types.String, types.Float, types.Float},
+	[]string{"recommendation", "read_cost_delta", "write_cost_delta"},
+)
+
+// workloadIndexAdviceGenerator supports the execution of
+// workload_index_advice().
+type workloadIndexAdviceGenerator struct {
+	advice []jobspb.WorkloadIndexAdviceProgress_Advice
+	idx    int
+}
+
+// makeWorkloadIndexAdviceGeneratorFactory returns a generator of the workload
+// index advice computed by the latest successful workload index advice job.
+// The hasTimestamp represents whether there is a timestamp filter.
+func makeWorkloadIndexAdviceGeneratorFactory(hasTimestamp bool) eval.GeneratorOverload {
+	return func(ctx context.Context, evalCtx *eval.Context, args tree.Datums) (eval.ValueGenerator, error) {
+		if err := checkWorkloadIndexAdvicePrivilege(ctx, evalCtx); err != nil {
+			return nil, err
+		}
+		var since *time.Time
+		if hasTimestamp {
+			ts := tree.MustBeDTimestampTZ(args[0])
+			since = &ts.Time
+		}
+		advice, err := evalCtx.Planner.LatestWorkloadIndexAdvice(ctx, since)
+		if err != nil {
+			return nil, err
+		}
+		return &workloadIndexAdviceGenerator{advice: advice}, nil
+	}
+}
+
+// ResolvedType implements the eval.ValueGenerator interface.
+func (*workloadIndexAdviceGenerator) ResolvedType() *types.T {
+	return workloadIndexAdviceGeneratorType
+}
+
+// Start implements the eval.ValueGenerator interface.
+func (g *workloadIndexAdviceGenerator) Start(_ context.Context, _ *kv.Txn) error {
+	g.idx = -1
+	return nil
+}
+
+// Close implements the eval.ValueGenerator interface.
+func (*workloadIndexAdviceGenerator) Close(_ context.Context) {}
+
+// Next implements the eval.ValueGenerator interface.
+func (g *workloadIndexAdviceGenerator) Next(_ context.Context) (bool, error) {
+	g.idx++
+	return g.idx < len(g.advice), nil
+}
+
+// Values implements the eval.ValueGenerator interface.
+func (g *workloadIndexAdviceGenerator) Values() (tree.Datums, error) {
+	a := &g.advice[g.idx]
+	return tree.Datums{
+		tree.NewDString(a.Stmt),
+		tree.NewDFloat(tree.DFloat(a.ReadCostDelta)),
+		tree.NewDFloat(tree.DFloat(a.WriteCostDelta)),
+	}, nil
+}
+
 func makeArrayGenerator(
 	_ context.Context, _ *eval.Context, args tree.Datums,
 ) (eval.ValueGenerator, error) {
@@ -305,6 +305,15 @@ type Planner interface {
 	// DecodeGist exposes gist functionality to the builtin functions.
 	DecodeGist(ctx context.Context, gist string, external bool) ([]string, error)
 
+	// EstimateStatementCost optimizes the statement fingerprint stmt in the
+	// given database, once against the current schema and once with the given
+	// indexes hypothetically added to their tables. It returns the estimated
+	// cost of both optimal plans along with the estimated number of rows
+	// returned, or written for a mutation, by the statement.
+	EstimateStatementCost(
+		ctx context.Context, stmt string, database string, hypotheticalIndexes []tree.CreateIndex,
+	) (cost, hypotheticalCost, rowCount float64, _ error)
+
 	// SerializeSessionState serializes the variables in the current session
 	// and returns a state, in bytes form.
 	SerializeSessionState() (*tree.DBytes, error)
@@ -437,6 +446,18 @@ type Planner interface {
 	// protected timestamp.
 	ExtendHistoryRetention(ctx context.Context, id jobspb.JobID) error
 
+	// CreateWorkloadIndexAdviceJob creates a job that computes the workload
+	// index advice over the statements executed after since, and returns its
+	// ID. The job is started once the transaction commits.
+	CreateWorkloadIndexAdviceJob(ctx context.Context, since time.Time) (jobspb.JobID, error)
+
+	// LatestWorkloadIndexAdvice returns the advice computed by the latest
+	// successful workload index advice job. If since is non-nil, only the jobs
+	// over the statements executed after since are considered.
+	LatestWorkloadIndexAdvice(
+		ctx context.Context, since *time.Time,
+	) ([]jobspb.WorkloadIndexAdviceProgress_Advice, error)
+
 	// InsertTemporarySchema inserts a temporary schema into the current session
 	// data.
 	InsertTemporarySchema(
@@ -47,9 +47,11 @@ type AlterBackupCmd interface {
 	alterBackupCmd()
 }
 
-func (node *AlterBackupKMS) alterBackupCmd() {}
+func (node *AlterBackupKMS) alterBackupCmd()     {}
+func (node *AlterBackupCompact) alterBackupCmd() {}
 
 var _ AlterBackupCmd = &AlterBackupKMS{}
+var _ AlterBackupCmd = &AlterBackupCompact{}
 
 // AlterBackupKMS represents a possible alter_backup_cmd option.
 type AlterBackupKMS struct {
@@ -65,6 +67,22 @@ func (node *AlterBackupKMS) Format(ctx *FmtCtx) {
 	ctx.FormatURIs(node.KMSInfo.OldKMSURI)
 }
 
+// AlterBackupCompact represents an alter_backup_cmd that merges a full backup
+// and its incremental backups into a new full backup.
+type AlterBackupCompact struct {
+	Options BackupOptions
+}
+
+// Format implements the NodeFormatter interface.
+func (node *AlterBackupCompact) Format(ctx *FmtCtx) {
+	ctx.WriteString(" COMPACT")
+	if !node.Options.IsDefault() {
+		ctx.WriteString(" WITH OPTIONS (")
+		ctx.FormatNode(&node.Options)
+		ctx.WriteString(")")
+	}
+}
+
 // BackupKMS represents possible options used when altering a backup KMS
 type BackupKMS struct {
 	NewKMSURI StringOrPlaceholderOptList
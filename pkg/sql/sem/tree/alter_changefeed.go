@@ -45,11 +45,13 @@ func (*AlterChangefeedAddTarget) alterChangefeedCmd()    {}
 func (*AlterChangefeedDropTarget) alterChangefeedCmd()   {}
 func (*AlterChangefeedSetOptions) alterChangefeedCmd()   {}
 func (*AlterChangefeedUnsetOptions) alterChangefeedCmd() {}
+func (*AlterChangefeedRewind) alterChangefeedCmd()       {}
 
 var _ AlterChangefeedCmd = &AlterChangefeedAddTarget{}
 var _ AlterChangefeedCmd = &AlterChangefeedDropTarget{}
 var _ AlterChangefeedCmd = &AlterChangefeedSetOptions{}
 var _ AlterChangefeedCmd = &AlterChangefeedUnsetOptions{}
+var _ AlterChangefeedCmd = &AlterChangefeedRewind{}
 
 // AlterChangefeedAddTarget represents an ADD <targets> command
 type AlterChangefeedAddTarget struct {
@@ -107,3 +109,21 @@ func (node *AlterChangefeedUnsetOptions) Format(ctx *FmtCtx) {
 	ctx.WriteString(" UNSET ")
 	ctx.FormatNode(&node.Options)
 }
+
+// AlterChangefeedRewind represents a REWIND [<targets>] TO <timestamp> command.
+// Without targets, the whole changefeed is rewound.
+type AlterChangefeedRewind struct {
+	Targets   ChangefeedTargets
+	Timestamp Expr
+}
+
+// Format implements the NodeFormatter interface.
+func (node *AlterChangefeedRewind) Format(ctx *FmtCtx) {
+	ctx.WriteString(" REWIND ")
+	if len(node.Targets) > 0 {
+		ctx.FormatNode(&node.Targets)
+		ctx.WriteString(" ")
+	}
+	ctx.WriteString("TO ")
+	ctx.FormatNode(node.Timestamp)
+}
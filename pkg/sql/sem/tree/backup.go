@@ -43,6 +43,8 @@ type BackupOptions struct {
 	IncrementalStorage              StringOrPlaceholderOptList
 	ExecutionLocality               Expr
 	UpdatesClusterMonitoringMetrics Expr
+	Continuous                      *DBool
+	SigningKMSURI                   Expr
 }
 
 var _ NodeFormatter = &BackupOptions{}
@@ -127,7 +129,9 @@ type RestoreOptions struct {
 	UnsafeRestoreIncompatibleVersion bool
 	ExecutionLocality                Expr
 	ExperimentalOnline               bool
+	ExperimentalReadOnly             bool
 	RemoveRegions                    bool
+	VerifyIntegrityKMSURI            Expr
 }
 
 var _ NodeFormatter = &RestoreOptions{}
@@ -297,6 +301,20 @@ func (o *BackupOptions) Format(ctx *FmtCtx) {
 		ctx.WriteString("updates_cluster_monitoring_metrics = ")
 		ctx.FormatNode(o.UpdatesClusterMonitoringMetrics)
 	}
+
+	if o.Continuous != nil {
+		maybeAddSep()
+		ctx.WriteString("continuous")
+		if o.Continuous != DBoolTrue {
+			ctx.WriteString(" = FALSE")
+		}
+	}
+
+	if o.SigningKMSURI != nil {
+		maybeAddSep()
+		ctx.WriteString("signing_kms = ")
+		ctx.FormatURI(o.SigningKMSURI)
+	}
 }
 
 // CombineWith merges other backup options into this backup options struct.
@@ -357,6 +375,20 @@ func (o *BackupOptions) CombineWith(other *BackupOptions) error {
 	} else {
 		o.UpdatesClusterMonitoringMetrics = other.UpdatesClusterMonitoringMetrics
 	}
+
+	if o.Continuous != nil {
+		if other.Continuous != nil {
+			return errors.New("continuous option specified multiple times")
+		}
+	} else {
+		o.Continuous = other.Continuous
+	}
+
+	if o.SigningKMSURI == nil {
+		o.SigningKMSURI = other.SigningKMSURI
+	} else if other.SigningKMSURI != nil {
+		return errors.New("signing_kms specified multiple times")
+	}
 	return nil
 }
 
@@ -370,7 +402,9 @@ func (o BackupOptions) IsDefault() bool {
 		cmp.Equal(o.IncrementalStorage, options.IncrementalStorage) &&
 		o.ExecutionLocality == options.ExecutionLocality &&
 		o.IncludeAllSecondaryTenants == options.IncludeAllSecondaryTenants &&
-		o.UpdatesClusterMonitoringMetrics == options.UpdatesClusterMonitoringMetrics
+		o.UpdatesClusterMonitoringMetrics == options.UpdatesClusterMonitoringMetrics &&
+		(o.Continuous == nil || o.Continuous == DBoolFalse) &&
+		o.SigningKMSURI == options.SigningKMSURI
 }
 
 // Format implements the NodeFormatter interface.
@@ -488,10 +522,21 @@ func (o *RestoreOptions) Format(ctx *FmtCtx) {
 		ctx.WriteString("experimental deferred copy")
 	}
 
+	if o.ExperimentalReadOnly {
+		maybeAddSep()
+		ctx.WriteString("experimental read only")
+	}
+
 	if o.RemoveRegions {
 		maybeAddSep()
 		ctx.WriteString("remove_regions")
 	}
+
+	if o.VerifyIntegrityKMSURI != nil {
+		maybeAddSep()
+		ctx.WriteString("verify_backup_integrity = ")
+		ctx.FormatURI(o.VerifyIntegrityKMSURI)
+	}
 }
 
 // CombineWith merges other backup options into this backup options struct.
@@ -633,6 +678,14 @@ func (o *RestoreOptions) CombineWith(other *RestoreOptions) error {
 		o.ExperimentalOnline = other.ExperimentalOnline
 	}
 
+	if o.ExperimentalReadOnly {
+		if other.ExperimentalReadOnly {
+			return errors.New("experimental read only specified multiple times")
+		}
+	} else {
+		o.ExperimentalReadOnly = other.ExperimentalReadOnly
+	}
+
 	if o.RemoveRegions {
 		if other.RemoveRegions {
 			return errors.New("remove_regions specified multiple times")
@@ -641,6 +694,12 @@ func (o *RestoreOptions) CombineWith(other *RestoreOptions) error {
 		o.RemoveRegions = other.RemoveRegions
 	}
 
+	if o.VerifyIntegrityKMSURI == nil {
+		o.VerifyIntegrityKMSURI = other.VerifyIntegrityKMSURI
+	} else if other.VerifyIntegrityKMSURI != nil {
+		return errors.New("verify_backup_integrity specified multiple times")
+	}
+
 	return nil
 }
 
@@ -666,7 +725,9 @@ func (o RestoreOptions) IsDefault() bool {
 		o.UnsafeRestoreIncompatibleVersion == options.UnsafeRestoreIncompatibleVersion &&
 		o.ExecutionLocality == options.ExecutionLocality &&
 		o.ExperimentalOnline == options.ExperimentalOnline &&
-		o.RemoveRegions == options.RemoveRegions
+		o.ExperimentalReadOnly == options.ExperimentalReadOnly &&
+		o.RemoveRegions == options.RemoveRegions &&
+		o.VerifyIntegrityKMSURI == options.VerifyIntegrityKMSURI
 }
 
 // BackupTargetList represents a list of targets.
@@ -13,9 +13,12 @@ import (
 // CreateChangefeed represents a CREATE CHANGEFEED statement.
 type CreateChangefeed struct {
 	Targets ChangefeedTargets
-	SinkURI Expr
-	Options KVOptions
-	Select  *SelectClause
+	// Database is set for database-level changefeeds, which watch all the
+	// tables of the database, including the ones created after the changefeed.
+	Database Name
+	SinkURI  Expr
+	Options  KVOptions
+	Select   *SelectClause
 }
 
 var _ Statement = &CreateChangefeed{}
@@ -36,7 +39,12 @@ func (node *CreateChangefeed) Format(ctx *FmtCtx) {
 	}
 
 	ctx.WriteString("CHANGEFEED FOR ")
-	ctx.FormatNode(&node.Targets)
+	if node.Database != "" {
+		ctx.WriteString("DATABASE ")
+		ctx.FormatNode(&node.Database)
+	} else {
+		ctx.FormatNode(&node.Targets)
+	}
 	if node.SinkURI != nil {
 		ctx.WriteString(" INTO ")
 		ctx.FormatURI(node.SinkURI)
@@ -88,6 +88,8 @@ const (
 	BackupValidateDetails
 	// BackupConnectionTest identifies a SHOW BACKUP CONNECTION statement
 	BackupConnectionTest
+	// BackupDiffDetails identifies a SHOW BACKUP DIFF statement.
+	BackupDiffDetails
 )
 
 // TODO (msbutler): 22.2 after removing old style show backup syntax, rename
@@ -102,6 +104,10 @@ type ShowBackup struct {
 	From         bool
 	Details      ShowBackupDetails
 	Options      ShowBackupOptions
+
+	// DiffFrom is the subdirectory of the backup that a SHOW BACKUP DIFF
+	// statement compares the backup in Path to.
+	DiffFrom Expr
 }
 
 // Format implements the NodeFormatter interface.
@@ -122,11 +128,17 @@ func (node *ShowBackup) Format(ctx *FmtCtx) {
 		ctx.WriteString("SCHEMAS ")
 	case BackupConnectionTest:
 		ctx.WriteString("CONNECTION ")
+	case BackupDiffDetails:
+		ctx.WriteString("DIFF ")
 	}
 
 	if node.From {
 		ctx.WriteString("FROM ")
 	}
+	if node.DiffFrom != nil {
+		ctx.FormatNode(node.DiffFrom)
+		ctx.WriteString(" TO ")
+	}
 
 	if node.InCollection != nil {
 		ctx.FormatNode(node.Path)
@@ -163,6 +175,14 @@ type ShowBackupOptions struct {
 	EncryptionInfoDir Expr
 	DebugMetadataSST  bool
 
+	// KeyDiffTables names the tables whose key-level differences a SHOW
+	// BACKUP DIFF statement shows.
+	KeyDiffTables StringOrPlaceholderOptList
+
+	// VerifyIntegrityKMSURI is the KMS used to verify the signature and file
+	// hashes of a signed backup.
+	VerifyIntegrityKMSURI Expr
+
 	CheckConnectionTransferSize Expr
 	CheckConnectionDuration     Expr
 	CheckConnectionConcurrency  Expr
@@ -228,6 +248,27 @@ func (o *ShowBackupOptions) Format(ctx *FmtCtx) {
 		maybeAddSep()
 		ctx.WriteString("debug_dump_metadata_sst")
 	}
+	if o.KeyDiffTables != nil {
+		maybeAddSep()
+		ctx.WriteString("keys = ")
+		if len(o.KeyDiffTables) > 1 {
+			ctx.WriteString("(")
+		}
+		for i, table := range o.KeyDiffTables {
+			if i > 0 {
+				ctx.WriteString(", ")
+			}
+			ctx.FormatNode(table)
+		}
+		if len(o.KeyDiffTables) > 1 {
+			ctx.WriteString(")")
+		}
+	}
+	if o.VerifyIntegrityKMSURI != nil {
+		maybeAddSep()
+		ctx.WriteString("verify_backup_integrity = ")
+		ctx.FormatURI(o.VerifyIntegrityKMSURI)
+	}
 
 	// The following are only used in connection-check SHOW.
 	if o.CheckConnectionConcurrency != nil {
@@ -258,6 +299,8 @@ func (o ShowBackupOptions) IsDefault() bool {
 		o.Privileges == options.Privileges &&
 		o.SkipSize == options.SkipSize &&
 		o.DebugMetadataSST == options.DebugMetadataSST &&
+		cmp.Equal(o.KeyDiffTables, options.KeyDiffTables) &&
+		o.VerifyIntegrityKMSURI == options.VerifyIntegrityKMSURI &&
 		o.EncryptionInfoDir == options.EncryptionInfoDir &&
 		o.CheckConnectionTransferSize == options.CheckConnectionTransferSize &&
 		o.CheckConnectionDuration == options.CheckConnectionDuration &&
@@ -340,6 +383,16 @@ func (o *ShowBackupOptions) CombineWith(other *ShowBackupOptions) error {
 	if err != nil {
 		return err
 	}
+	o.KeyDiffTables, err = combineStringOrPlaceholderOptList(o.KeyDiffTables,
+		other.KeyDiffTables, "keys")
+	if err != nil {
+		return err
+	}
+	o.VerifyIntegrityKMSURI, err = combineExpr(o.VerifyIntegrityKMSURI,
+		other.VerifyIntegrityKMSURI, "verify_backup_integrity")
+	if err != nil {
+		return err
+	}
 
 	o.CheckConnectionTransferSize, err = combineExpr(o.CheckConnectionTransferSize, other.CheckConnectionTransferSize,
 		"transfer")
@@ -574,6 +627,18 @@ func (node *ShowChangefeedJobs) Format(ctx *FmtCtx) {
 	}
 }
 
+// ShowChangefeedSpans represents a SHOW CHANGEFEED JOB <jobid> SPANS statement
+type ShowChangefeedSpans struct {
+	Job Expr
+}
+
+// Format implements the NodeFormatter interface.
+func (node *ShowChangefeedSpans) Format(ctx *FmtCtx) {
+	ctx.WriteString("SHOW CHANGEFEED JOB ")
+	ctx.FormatNode(node.Job)
+	ctx.WriteString(" SPANS")
+}
+
 // ShowSurvivalGoal represents a SHOW SURVIVAL GOAL statement
 type ShowSurvivalGoal struct {
 	DatabaseName Name
@@ -244,6 +244,7 @@ var _ CCLOnlyStatement = &ShowBackup{}
 var _ CCLOnlyStatement = &Restore{}
 var _ CCLOnlyStatement = &CreateChangefeed{}
 var _ CCLOnlyStatement = &AlterChangefeed{}
+var _ CCLOnlyStatement = &ShowChangefeedSpans{}
 var _ CCLOnlyStatement = &Import{}
 var _ CCLOnlyStatement = &Export{}
 var _ CCLOnlyStatement = &ScheduledBackup{}
@@ -1682,6 +1683,17 @@ func (*ShowBackup) StatementTag() string { return "SHOW BACKUP" }
 
 func (*ShowBackup) cclOnlyStatement() {}
 
+// StatementReturnType implements the Statement interface.
+func (*ShowChangefeedSpans) StatementReturnType() StatementReturnType { return Rows }
+
+// StatementType implements the Statement interface.
+func (*ShowChangefeedSpans) StatementType() StatementType { return TypeDML }
+
+// StatementTag returns a short string identifying the type of statement.
+func (*ShowChangefeedSpans) StatementTag() string { return "SHOW CHANGEFEED SPANS" }
+
+func (*ShowChangefeedSpans) cclOnlyStatement() {}
+
 // StatementReturnType implements the Statement interface.
 func (*ShowDatabases) StatementReturnType() StatementReturnType { return Rows }
 
@@ -2502,6 +2514,7 @@ func (n *ShowSchedules) String() string                       { return AsString(
 func (n *ShowIndexes) String() string                         { return AsString(n) }
 func (n *ShowJobs) String() string                            { return AsString(n) }
 func (n *ShowChangefeedJobs) String() string                  { return AsString(n) }
+func (n *ShowChangefeedSpans) String() string                 { return AsString(n) }
 func (n *ShowLastQueryStatistics) String() string             { return AsString(n) }
 func (n *ShowPartitions) String() string                      { return AsString(n) }
 func (n *ShowQueries) String() string                         { return AsString(n) }
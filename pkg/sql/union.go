@@ -119,29 +119,9 @@ func (p *planner) newUnionNode(
 		return nil, errors.Errorf("%v is not supported", typ)
 	}
 
-	leftColumns := planColumns(left)
-	rightColumns := planColumns(right)
-	if len(leftColumns) != len(rightColumns) {
-		return nil, pgerror.Newf(
-			pgcode.Syntax,
-			"each %v query must have the same number of columns: %d vs %d",
-			typ, len(leftColumns), len(rightColumns),
-		)
-	}
-	unionColumns := append(colinfo.ResultColumns(nil), leftColumns...)
-	for i := 0; i < len(unionColumns); i++ {
-		l := leftColumns[i]
-		r := rightColumns[i]
-		// TODO(dan): This currently checks whether the types are exactly the same,
-		// but Postgres is more lenient:
-		// http://www.postgresql.org/docs/9.5/static/typeconv-union-case.html.
-		if !(l.Typ.Equivalent(r.Typ) || l.Typ.Family() == types.UnknownFamily || r.Typ.Family() == types.UnknownFamily) {
-			return nil, pgerror.Newf(pgcode.DatatypeMismatch,
-				"%v types %s and %s cannot be matched", typ, l.Typ, r.Typ)
-		}
-		if l.Typ.Family() == types.UnknownFamily {
-			unionColumns[i].Typ = r.Typ
-		}
+	unionColumns, err := getSetOpResultColumns(typ, planColumns(left), planColumns(right))
+	if err != nil {
+		return nil, err
 	}
 
 	inverted := false
@@ -171,6 +151,36 @@ func (p *planner) newUnionNode(
 	return node, nil
 }
 
+// getSetOpResultColumns returns the result columns of a set operation of the
+// given type with the given inputs.
+func getSetOpResultColumns(
+	typ tree.UnionType, leftColumns, rightColumns colinfo.ResultColumns,
+) (colinfo.ResultColumns, error) {
+	if len(leftColumns) != len(rightColumns) {
+		return nil, pgerror.Newf(
+			pgcode.Syntax,
+			"each %v query must have the same number of columns: %d vs %d",
+			typ, len(leftColumns), len(rightColumns),
+		)
+	}
+	unionColumns := append(colinfo.ResultColumns(nil), leftColumns...)
+	for i := 0; i < len(unionColumns); i++ {
+		l := leftColumns[i]
+		r := rightColumns[i]
+		// TODO(dan): This currently checks whether the types are exactly the same,
+		// but Postgres is more lenient:
+		// http://www.postgresql.org/docs/9.5/static/typeconv-union-case.html.
+		if !(l.Typ.Equivalent(r.Typ) || l.Typ.Family() == types.UnknownFamily || r.Typ.Family() == types.UnknownFamily) {
+			return nil, pgerror.Newf(pgcode.DatatypeMismatch,
+				"%v types %s and %s cannot be matched", typ, l.Typ, r.Typ)
+		}
+		if l.Typ.Family() == types.UnknownFamily {
+			unionColumns[i].Typ = r.Typ
+		}
+	}
+	return unionColumns, nil
+}
+
 func (n *unionNode) startExec(params runParams) error {
 	panic("unionNode cannot be run in local mode")
 }
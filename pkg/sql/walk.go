@@ -277,6 +277,8 @@ func (v *planVisitor) visitInternal(plan planNode, name string) {
 		// planNodeToRowSource on the other end of the adapter will take care of
 		// propagating signals via its own walker.
 
+	case *specInputNode:
+
 	case *errorIfRowsNode:
 		n.plan = v.visit(n.plan)
 
@@ -463,6 +465,7 @@ var planNodeNames = map[reflect.Type]string{
 	reflect.TypeOf(&showTraceReplicaNode{}):                    "replica trace",
 	reflect.TypeOf(&showVarNode{}):                             "show",
 	reflect.TypeOf(&sortNode{}):                                "sort",
+	reflect.TypeOf(&specInputNode{}):                           "spec input",
 	reflect.TypeOf(&splitNode{}):                               "split",
 	reflect.TypeOf(&topKNode{}):                                "top-k",
 	reflect.TypeOf(&unsplitNode{}):                             "unsplit",
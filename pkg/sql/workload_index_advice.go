@@ -0,0 +1,387 @@
+// Copyright 2024 The Cockroach Authors.
+//
+// Use of this software is governed by the CockroachDB Software License
+// included in the /LICENSE file.
+
+package sql
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach/pkg/clusterversion"
+	"github.com/cockroachdb/cockroach/pkg/jobs"
+	"github.com/cockroachdb/cockroach/pkg/jobs/jobspb"
+	"github.com/cockroachdb/cockroach/pkg/security/username"
+	"github.com/cockroachdb/cockroach/pkg/settings/cluster"
+	"github.com/cockroachdb/cockroach/pkg/sql/clusterunique"
+	"github.com/cockroachdb/cockroach/pkg/sql/isql"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/cat"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/indexrec"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/memo"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/optbuilder"
+	"github.com/cockroachdb/cockroach/pkg/sql/opt/workloadindexrec"
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgcode"
+	"github.com/cockroachdb/cockroach/pkg/sql/pgwire/pgerror"
+	"github.com/cockroachdb/cockroach/pkg/sql/sem/tree"
+	"github.com/cockroachdb/cockroach/pkg/sql/sessiondata"
+	"github.com/cockroachdb/cockroach/pkg/util/errorutil"
+	"github.com/cockroachdb/cockroach/pkg/util/timeutil"
+	"github.com/cockroachdb/errors"
+)
+
+// EstimateStatementCost is part of the eval.Planner interface.
+//
+// The statement is planned in a separate internal planner, like a prepared
+// statement: the constants hidden by the fingerprint are replaced with
+// placeholders, so the costs are those of the generic plans.
+func (p *planner) EstimateStatementCost(
+	ctx context.Context, stmt string, database string, hypotheticalIndexes []tree.CreateIndex,
+) (cost, hypotheticalCost, rowCount float64, _ error) {
+	parsed, err := parser.ParseOne(stmt)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	switch parsed.AST.(type) {
+	case *tree.Select, *tree.ParenSelect, *tree.Insert, *tree.Update, *tree.Delete:
+	default:
+		return 0, 0, 0, errors.Newf("cannot estimate the cost of a %s statement", parsed.AST.StatementTag())
+	}
+	parsed.AST, parsed.NumPlaceholders, err = replaceHiddenConstants(parsed.AST)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+
+	sd := p.SessionData().Clone()
+	sd.Database = database
+	ip, cleanup := newInternalPlanner(
+		"estimate-statement-cost", p.Txn(), p.User(), &MemoryMetrics{}, p.ExecCfg(), sd,
+	)
+	defer cleanup()
+	ip.stmt = makeStatement(parsed, clusterunique.ID{}, /* queryID */
+		tree.FmtFlags(queryFormattingForFingerprintsMask.Get(&p.execCfg.Settings.SV)))
+	ip.semaCtx.Placeholders.Init(parsed.NumPlaceholders, nil /* typeHints */)
+	return ip.optPlanningCtx.estimateCost(ctx, hypotheticalIndexes)
+}
+
+// replaceHiddenConstants replaces the constants hidden by a statement
+// fingerprint, i.e. "_", '_' and the "__more__" markers of collapsed lists, as
+// well as the fingerprint's placeholders with distinct placeholders. It returns
+// the number of placeholders in the resulting statement.
+func replaceHiddenConstants(stmt tree.Statement) (tree.Statement, int, error) {
+	var numPlaceholders int
+	newPlaceholder := func() *tree.Placeholder {
+		numPlaceholders++
+		return &tree.Placeholder{Idx: tree.PlaceholderIdx(numPlaceholders - 1)}
+	}
+	stmt, err := tree.SimpleStmtVisit(stmt, func(expr tree.Expr) (bool, tree.Expr, error) {
+		switch t := expr.(type) {
+		case *tree.UnresolvedName:
+			if t.NumParts == 1 && (t.Parts[0] == "_" || strings.HasPrefix(t.Parts[0], "__more")) {
+				return false, newPlaceholder(), nil
+			}
+		case *tree.StrVal:
+			if t.RawString() == "_" {
+				return false, newPlaceholder(), nil
+			}
+		case *tree.Placeholder:
+			return false, newPlaceholder(), nil
+		}
+		return true, expr, nil
+	})
+	return stmt, numPlaceholders, err
+}
+
+// estimateCost builds the planner's statement and optimizes it twice: once
+// against the current schema, and once with the given indexes hypothetically
+// added to their tables. It returns the costs of both optimal plans, along
+// with the estimated row count of the first one.
+func (opc *optPlanningCtx) estimateCost(
+	ctx context.Context, hypotheticalIndexes []tree.CreateIndex,
+) (cost, hypotheticalCost, rowCount float64, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			// This code allows us to propagate internal errors without having to add
+			// error checks everywhere throughout the code. This is only possible
+			// because the code does not update shared state and does not manipulate
+			// locks.
+			if ok, e := errorutil.ShouldCatch(r); ok {
+				err = e
+			} else {
+				panic(r)
+			}
+		}
+	}()
+
+	p := opc.p
+	opc.reset(ctx)
+	f := opc.optimizer.Factory()
+	f.FoldingControl().AllowStableFolds()
+	bld := optbuilder.New(ctx, &p.semaCtx, p.EvalContext(), opc.catalog, f, p.stmt.AST)
+	if err := bld.Build(); err != nil {
+		return 0, 0, 0, err
+	}
+	savedMemo := opc.optimizer.DetachMemo(ctx)
+
+	optimize := func(tables map[cat.StableID]cat.Table) (memo.RelExpr, error) {
+		opc.optimizer.Init(ctx, p.EvalContext(), opc.catalog)
+		f := opc.optimizer.Factory()
+		f.FoldingControl().AllowStableFolds()
+		f.CopyAndReplace(
+			savedMemo.RootExpr().(memo.RelExpr),
+			savedMemo.RootProps(),
+			f.CopyWithoutAssigningPlaceholders,
+		)
+		if tables != nil {
+			opc.optimizer.Memo().Metadata().UpdateTableMeta(ctx, p.EvalContext(), tables)
+		}
+		root, err := opc.optimizer.Optimize()
+		if err != nil {
+			return nil, err
+		}
+		return root.(memo.RelExpr), nil
+	}
+
+	root, err := optimize(nil /* tables */)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	cost = float64(root.Cost())
+	rowCount = root.Relational().Statistics().RowCount
+
+	candidates, err := opc.hypotheticalIndexCandidates(ctx, hypotheticalIndexes)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if len(candidates) == 0 {
+		return cost, cost, rowCount, nil
+	}
+	_, hypTables := indexrec.BuildOptAndHypTableMaps(opc.catalog, candidates)
+	if root, err = optimize(hypTables); err != nil {
+		return 0, 0, 0, err
+	}
+	return cost, float64(root.Cost()), rowCount, nil
+}
+
+// hypotheticalIndexCandidates resolves the tables and key columns of the given
+// CREATE INDEX statements into the index candidates used to build hypothetical
+// tables.
+func (opc *optPlanningCtx) hypotheticalIndexCandidates(
+	ctx context.Context, indexes []tree.CreateIndex,
+) (map[cat.Table][][]cat.IndexColumn, error) {
+	candidates := make(map[cat.Table][][]cat.IndexColumn)
+	for i := range indexes {
+		tn := indexes[i].Table
+		ds, _, err := opc.catalog.ResolveDataSource(ctx, cat.Flags{}, &tn)
+		if err != nil {
+			return nil, err
+		}
+		tab, ok := ds.(cat.Table)
+		if !ok {
+			return nil, errors.Newf("%s is not a table", tree.ErrString(&tn))
+		}
+		cols := make([]cat.IndexColumn, len(indexes[i].Columns))
+		for j, elem := range indexes[i].Columns {
+			if elem.Expr != nil {
+				return nil, errors.Newf("expression indexes are not supported")
+			}
+			var col *cat.Column
+			for k, n := 0, tab.ColumnCount(); k < n; k++ {
+				if c := tab.Column(k); c.ColName() == elem.Column {
+					col = c
+					break
+				}
+			}
+			if col == nil {
+				return nil, errors.Newf("column %q does not exist in %s", elem.Column, tree.ErrString(&tn))
+			}
+			cols[j] = cat.IndexColumn{Column: col, Descending: elem.Direction == tree.Descending}
+		}
+		candidates[tab] = append(candidates[tab], cols)
+	}
+	return candidates, nil
+}
+
+// CreateWorkloadIndexAdviceJob creates a workload index advice job over the
+// statements executed after since, or over all the persisted statements if
+// since is zero, and returns its ID. The job is started once txn commits; its
+// advice can then be read with LatestWorkloadIndexAdvice.
+func CreateWorkloadIndexAdviceJob(
+	ctx context.Context,
+	execCfg *ExecutorConfig,
+	txn isql.Txn,
+	user username.SQLUsername,
+	since time.Time,
+) (jobspb.JobID, error) {
+	if !execCfg.Settings.Version.IsActive(ctx, clusterversion.V25_1_WorkloadIndexAdviceJob) {
+		return 0, pgerror.New(pgcode.FeatureNotSupported,
+			"workload index advice job not supported until the cluster is upgraded to V25.1")
+	}
+	description := "workload index advice"
+	if !since.IsZero() {
+		description = fmt.Sprintf("workload index advice since %s", since.Format(time.RFC3339))
+	}
+	record := jobs.Record{
+		JobID:       execCfg.JobRegistry.MakeJobID(),
+		Description: description,
+		Username:    user,
+		Details:     jobspb.WorkloadIndexAdviceDetails{Since: since},
+		Progress:    jobspb.WorkloadIndexAdviceProgress{},
+	}
+	if _, err := execCfg.JobRegistry.CreateJobWithTxn(ctx, record, record.JobID, txn); err != nil {
+		return 0, err
+	}
+	txn.KV().AddCommitTrigger(func(ctx context.Context) {
+		execCfg.JobRegistry.NotifyToResume(ctx, record.JobID)
+	})
+	return record.JobID, nil
+}
+
+// WorkloadIndexAdvice is the advice computed by a successful workload index
+// advice job.
+type WorkloadIndexAdvice struct {
+	JobID jobspb.JobID
+	// Since is the time after which the statements were considered by the job.
+	Since    time.Time
+	Finished time.Time
+	Advice   []jobspb.WorkloadIndexAdviceProgress_Advice
+}
+
+// LatestWorkloadIndexAdvice returns the advice computed by the most recently
+// created successful workload index advice job, or nil if there is no such
+// job. If since is non-nil, only the jobs over the statements executed after
+// since are considered.
+func LatestWorkloadIndexAdvice(
+	ctx context.Context, execCfg *ExecutorConfig, txn isql.Txn, since *time.Time,
+) (*WorkloadIndexAdvice, error) {
+	it, err := txn.QueryIteratorEx(ctx, "latest-workload-index-advice", txn.KV(),
+		sessiondata.NodeUserSessionDataOverride,
+		`SELECT id FROM system.jobs WHERE job_type = $1 AND status = $2 ORDER BY created DESC, id DESC`,
+		jobspb.TypeWorkloadIndexAdvice.String(), string(jobs.StatusSucceeded),
+	)
+	if err != nil {
+		return nil, err
+	}
+	var jobIDs []jobspb.JobID
+	var ok bool
+	for ok, err = it.Next(ctx); ok; ok, err = it.Next(ctx) {
+		jobIDs = append(jobIDs, jobspb.JobID(tree.MustBeDInt(it.Cur()[0])))
+	}
+	if err = errors.CombineErrors(err, it.Close()); err != nil {
+		return nil, err
+	}
+
+	for _, jobID := range jobIDs {
+		job, err := execCfg.JobRegistry.LoadJobWithTxn(ctx, jobID, txn)
+		if err != nil {
+			return nil, err
+		}
+		details := job.Details().(jobspb.WorkloadIndexAdviceDetails)
+		if since != nil && !details.Since.Equal(*since) {
+			continue
+		}
+		progress := job.Progress()
+		return &WorkloadIndexAdvice{
+			JobID:    jobID,
+			Since:    details.Since,
+			Finished: timeutil.FromUnixMicros(job.Payload().FinishedMicros),
+			Advice:   progress.GetWorkloadIndexAdvice().Advice,
+		}, nil
+	}
+	return nil, nil
+}
+
+// CreateWorkloadIndexAdviceJob is part of the eval.Planner interface.
+func (p *planner) CreateWorkloadIndexAdviceJob(
+	ctx context.Context, since time.Time,
+) (jobspb.JobID, error) {
+	return CreateWorkloadIndexAdviceJob(ctx, p.ExecCfg(), p.InternalSQLTxn(), p.User(), since)
+}
+
+// LatestWorkloadIndexAdvice is part of the eval.Planner interface.
+func (p *planner) LatestWorkloadIndexAdvice(
+	ctx context.Context, since *time.Time,
+) ([]jobspb.WorkloadIndexAdviceProgress_Advice, error) {
+	advice, err := LatestWorkloadIndexAdvice(ctx, p.ExecCfg(), p.InternalSQLTxn(), since)
+	if err != nil || advice == nil {
+		return nil, err
+	}
+	return advice.Advice, nil
+}
+
+// workloadIndexAdviceResumer computes the workload index advice over the
+// persisted statement statistics and stores it in the job's progress.
+type workloadIndexAdviceResumer struct {
+	job *jobs.Job
+}
+
+var _ jobs.Resumer = (*workloadIndexAdviceResumer)(nil)
+
+// Resume is part of the jobs.Resumer interface.
+func (r *workloadIndexAdviceResumer) Resume(ctx context.Context, execCtx interface{}) error {
+	jobExecCtx := execCtx.(JobExecContext)
+	execCfg := jobExecCtx.ExecCfg()
+	details := r.job.Details().(jobspb.WorkloadIndexAdviceDetails)
+	sinceTime := details.Since
+	if sinceTime.IsZero() {
+		sinceTime = tree.MinSupportedTime
+	}
+	since, err := tree.MakeDTimestampTZ(sinceTime, time.Microsecond)
+	if err != nil {
+		return err
+	}
+
+	var advice []workloadindexrec.IndexAdvice
+	if err := execCfg.InternalDB.Txn(ctx, func(ctx context.Context, txn isql.Txn) error {
+		ip, cleanup := newInternalPlanner(
+			"workload-index-advice-job",
+			txn.KV(),
+			jobExecCtx.User(),
+			&MemoryMetrics{},
+			execCfg,
+			NewInternalSessionData(ctx, execCfg.Settings, "workload-index-advice-job"),
+		)
+		defer cleanup()
+		advice, err = workloadindexrec.FindWorkloadAdvice(ctx, ip.EvalContext(), since)
+		return err
+	}); err != nil {
+		return err
+	}
+
+	return r.job.NoTxn().Update(ctx, func(txn isql.Txn, md jobs.JobMetadata, ju *jobs.JobUpdater) error {
+		progress := md.Progress.GetWorkloadIndexAdvice()
+		progress.Advice = make([]jobspb.WorkloadIndexAdviceProgress_Advice, len(advice))
+		for i := range advice {
+			progress.Advice[i] = jobspb.WorkloadIndexAdviceProgress_Advice{
+				Stmt:           advice[i].Stmt,
+				ReadCostDelta:  advice[i].ReadCostDelta,
+				WriteCostDelta: advice[i].WriteCostDelta,
+			}
+		}
+		ju.UpdateProgress(md.Progress)
+		return nil
+	})
+}
+
+// OnFailOrCancel is part of the jobs.Resumer interface.
+func (r *workloadIndexAdviceResumer) OnFailOrCancel(context.Context, interface{}, error) error {
+	return nil
+}
+
+// CollectProfile is part of the jobs.Resumer interface.
+func (r *workloadIndexAdviceResumer) CollectProfile(context.Context, interface{}) error {
+	return nil
+}
+
+func init() {
+	jobs.RegisterConstructor(
+		jobspb.TypeWorkloadIndexAdvice,
+		func(job *jobs.Job, _ *cluster.Settings) jobs.Resumer {
+			return &workloadIndexAdviceResumer{job: job}
+		},
+		jobs.UsesTenantCostControl,
+	)
+}
@@ -42,6 +42,7 @@ func (m *CommonJobEventDetails) CommonJobDetails() *CommonJobEventDetails { retu
 
 var _ EventWithCommonJobPayload = (*Import)(nil)
 var _ EventWithCommonJobPayload = (*Restore)(nil)
+var _ EventWithCommonJobPayload = (*ChangefeedRewind)(nil)
 
 // RecoveryEventType describes the type of recovery for a RecoveryEvent.
 type RecoveryEventType string